@@ -4,14 +4,17 @@ import (
 	"database/sql"
 	"log"
 	"os"
+	"time"
 
 	"leetcode-clone-backend/pkg/auth"
 	"leetcode-clone-backend/pkg/database"
 	"leetcode-clone-backend/pkg/execution"
 	"leetcode-clone-backend/pkg/handlers"
 	"leetcode-clone-backend/pkg/middleware"
+	"leetcode-clone-backend/pkg/ratelimit"
 	"leetcode-clone-backend/pkg/repository"
 	"leetcode-clone-backend/pkg/services"
+	"leetcode-clone-backend/pkg/storage"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -25,10 +28,19 @@ type Server struct {
 	problemService    *services.ProblemService
 	submissionService *services.SubmissionService
 	executionService  *execution.ExecutionService
+	rejudgeService    *services.RejudgeService
+	draftService      *services.DraftService
+	tagService        *services.TagService
+	userService       *services.UserService
 	authHandler       *handlers.AuthHandlers
 	problemHandler    *handlers.ProblemHandlers
 	submissionHandler *handlers.SubmissionHandlers
 	executionHandler  *handlers.ExecutionHandlers
+	rejudgeHandler    *handlers.RejudgeHandlers
+	draftHandler      *handlers.DraftHandlers
+	tagHandler        *handlers.TagHandlers
+	userHandler       *handlers.UserHandlers
+	rateLimitStore    ratelimit.Store
 }
 
 func main() {
@@ -48,8 +60,21 @@ func main() {
 		log.Fatal("Failed to run migrations:", err)
 	}
 
+	// Initialize test data store, which keeps large test inputs and expected outputs
+	testDataStore, err := storage.NewStore(storage.LoadConfigFromEnv())
+	if err != nil {
+		log.Fatal("Failed to initialize test data store:", err)
+	}
+
 	// Initialize repository
-	repo := repository.NewRepository(db)
+	repo := repository.NewRepository(db, testDataStore)
+
+	// Initialize rate limit store, shared between replicas unless it is kept in memory
+	rateLimitStore, err := ratelimit.NewStore(ratelimit.LoadConfigFromEnv(), db)
+	if err != nil {
+		log.Fatal("Failed to initialize rate limit store:", err)
+	}
+	ratelimit.StartCleanup(rateLimitStore, 5*time.Minute)
 
 	// Initialize authentication service
 	jwtSecret := os.Getenv("JWT_SECRET")
@@ -59,15 +84,26 @@ func main() {
 	authService := auth.NewAuthService(jwtSecret)
 
 	// Initialize services
-	problemService := services.NewProblemService(repo.Problem, repo.TestCase)
 	executionService := execution.NewExecutionService()
-	submissionService := services.NewSubmissionService(repo.Submission, repo.TestCase, repo.UserProgress, executionService)
+	problemService := services.NewProblemService(repo, executionService)
+	problemService.StartScheduledPublishing(time.Minute)
+	percentileService := services.NewPercentileService(repo.PerformanceHistogram)
+	submissionService := services.NewSubmissionService(repo, executionService, percentileService)
+	rejudgeService := services.NewRejudgeService(repo, executionService, percentileService)
+	rejudgeService.Start()
+	draftService := services.NewDraftService(repo)
+	tagService := services.NewTagService(repo)
+	userService := services.NewUserService(repo)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandlers(authService, repo.User)
 	problemHandler := handlers.NewProblemHandlers(problemService)
 	submissionHandler := handlers.NewSubmissionHandlers(submissionService)
-	executionHandler := handlers.NewExecutionHandlers(executionService, repo.TestCase)
+	executionHandler := handlers.NewExecutionHandlers(executionService, repo.Problem, repo.TestCase, repo.JudgeSettings)
+	rejudgeHandler := handlers.NewRejudgeHandlers(rejudgeService)
+	draftHandler := handlers.NewDraftHandlers(draftService)
+	tagHandler := handlers.NewTagHandlers(tagService)
+	userHandler := handlers.NewUserHandlers(userService)
 
 	server := &Server{
 		router:            gin.Default(),
@@ -77,10 +113,19 @@ func main() {
 		problemService:    problemService,
 		submissionService: submissionService,
 		executionService:  executionService,
+		rejudgeService:    rejudgeService,
+		draftService:      draftService,
+		tagService:        tagService,
+		userService:       userService,
 		authHandler:       authHandler,
 		problemHandler:    problemHandler,
 		submissionHandler: submissionHandler,
 		executionHandler:  executionHandler,
+		rejudgeHandler:    rejudgeHandler,
+		draftHandler:      draftHandler,
+		tagHandler:        tagHandler,
+		userHandler:       userHandler,
+		rateLimitStore:    rateLimitStore,
 	}
 
 	// Setup CORS
@@ -95,9 +140,14 @@ func main() {
 		AllowHeaders: []string{
 			"Origin", "Content-Type", "Authorization", "Accept",
 			"X-Requested-With", "sec-ch-ua", "sec-ch-ua-mobile",
-			"sec-ch-ua-platform", "User-Agent", "Referer",
+			"sec-ch-ua-platform", "User-Agent", "Referer", "If-Match",
+			"Idempotency-Key",
+		},
+		ExposeHeaders: []string{
+			"Content-Length", "ETag", "Idempotent-Replayed", "Retry-After",
+			"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset",
+			"X-RateLimit-CPU-Limit", "X-RateLimit-CPU-Remaining",
 		},
-		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * 3600, // 12 hours
 	}))
@@ -124,17 +174,19 @@ func (s *Server) setupRoutes() {
 
 	// Auth routes with rate limiting
 	auth := api.Group("/auth")
-	auth.Use(middleware.RateLimitMiddleware(10)) // 10 requests per minute for auth endpoints
+	auth.Use(middleware.RateLimitMiddleware(s.rateLimitStore, "auth", 10)) // 10 requests per minute for auth endpoints
 	auth.POST("/register", s.authHandler.Register)
 	auth.POST("/login", s.authHandler.Login)
 	auth.POST("/password-reset", s.authHandler.RequestPasswordReset)
 	auth.POST("/password-reset/confirm", s.authHandler.ResetPassword)
 
-	// Public problem routes (read-only)
-	api.GET("/problems", s.problemHandler.ListProblems)
-	api.GET("/problems/search", s.problemHandler.SearchProblems)
-	api.GET("/problems/:id", s.problemHandler.GetProblem)
-	api.GET("/problems/slug/:slug", s.problemHandler.GetProblemBySlug)
+	// Public problem routes (read-only), showing the progress of signed-in users
+	optionalAuth := handlers.OptionalAuthMiddleware(s.authService)
+	api.GET("/problems", optionalAuth, s.problemHandler.ListProblems)
+	api.GET("/problems/search", optionalAuth, s.problemHandler.SearchProblems)
+	api.GET("/problems/tags", optionalAuth, s.tagHandler.ListTags)
+	api.GET("/problems/:id", optionalAuth, s.problemHandler.GetProblem)
+	api.GET("/problems/slug/:slug", optionalAuth, s.problemHandler.GetProblemBySlug)
 	api.GET("/problems/:id/testcases", s.problemHandler.GetTestCases)
 
 	// Protected routes
@@ -144,29 +196,95 @@ func (s *Server) setupRoutes() {
 	// Admin-only problem management routes
 	admin := protected.Group("/admin")
 	admin.Use(s.adminMiddleware())
+	admin.GET("/problems", s.problemHandler.ListAllProblems)
 	admin.POST("/problems", s.problemHandler.CreateProblem)
+	admin.GET("/problems/:id", s.problemHandler.GetAnyProblem)
 	admin.PUT("/problems/:id", s.problemHandler.UpdateProblem)
 	admin.DELETE("/problems/:id", s.problemHandler.DeleteProblem)
 	admin.POST("/problems/:id/testcases", s.problemHandler.CreateTestCase)
+	admin.POST("/problems/:id/testcases/bulk", s.problemHandler.UploadTestCases)
+	admin.POST("/problems/:id/testcases/check-outputs", s.problemHandler.CheckExpectedOutputs)
+	admin.POST("/problems/:id/testcases/generate-outputs", s.problemHandler.GenerateExpectedOutputs)
+	admin.GET("/problems/:id/solutions", s.problemHandler.GetSolutions)
+	admin.PUT("/problems/:id/solutions", s.problemHandler.ReplaceSolutions)
+	admin.POST("/problems/:id/testcases/generate", s.problemHandler.GenerateTestCases)
+	admin.GET("/problems/:id/test-programs", s.problemHandler.GetTestPrograms)
+	admin.PUT("/problems/:id/test-programs/:kind", s.problemHandler.SetTestProgram)
+	admin.DELETE("/problems/:id/test-programs/:kind", s.problemHandler.DeleteTestProgram)
+	admin.GET("/testcases/:id", s.problemHandler.GetTestCase)
+	admin.GET("/testcases/:id/input", s.problemHandler.GetTestCaseInput)
+	admin.GET("/testcases/:id/output", s.problemHandler.GetTestCaseOutput)
 	admin.PUT("/testcases/:id", s.problemHandler.UpdateTestCase)
 	admin.DELETE("/testcases/:id", s.problemHandler.DeleteTestCase)
 
+	// Admin-only problem lifecycle routes
+	admin.PUT("/problems/:id/state", s.problemHandler.TransitionProblem)
+	admin.GET("/problems/:id/publish-checks", s.problemHandler.GetPublishChecks)
+
+	// Admin-only problem package routes
+	admin.POST("/problem-packages", s.problemHandler.ImportPackage)
+	admin.POST("/problem-packages/validate", s.problemHandler.ValidatePackage)
+	admin.POST("/problem-packages/external", s.problemHandler.ImportExternalPackage)
+	admin.GET("/problems/:id/package", s.problemHandler.ExportPackage)
+
+	// Admin-only judging settings routes
+	admin.GET("/problems/:id/judge-settings", s.problemHandler.GetJudgeSettings)
+	admin.PUT("/problems/:id/judge-settings", s.problemHandler.UpdateJudgeSettings)
+	admin.POST("/problems/:id/judge-settings/calibrate", s.problemHandler.CalibrateLimits)
+
+	// Admin-only problem revision routes
+	admin.GET("/problems/:id/revisions", s.problemHandler.ListRevisions)
+	admin.GET("/problems/:id/revisions/diff", s.problemHandler.DiffRevisions)
+	admin.GET("/problems/:id/revisions/:revision", s.problemHandler.GetRevision)
+	admin.POST("/problems/:id/revisions/:revision/restore", s.problemHandler.RestoreRevision)
+
+	// Admin-only rejudge routes
+	admin.POST("/rejudge", s.rejudgeHandler.RejudgeRange)
+	admin.POST("/rejudge/submissions/:id", s.rejudgeHandler.RejudgeSubmission)
+	admin.POST("/rejudge/problems/:id", s.rejudgeHandler.RejudgeProblem)
+	admin.GET("/rejudge/jobs", s.rejudgeHandler.ListJobs)
+	admin.GET("/rejudge/jobs/:id", s.rejudgeHandler.GetJob)
+
+	// Admin-only tag catalog routes
+	admin.PUT("/tags/:name", s.tagHandler.UpdateTag)
+	admin.POST("/tags/:name/rename", s.tagHandler.RenameTag)
+	admin.POST("/tags/merge", s.tagHandler.MergeTags)
+
+	// Admin-only user routes
+	admin.GET("/users", s.userHandler.ListUsers)
+
+	// Submissions may be retried safely with an Idempotency-Key header
+	idempotent := middleware.IdempotencyMiddleware(s.repo.IdempotencyKey, middleware.DefaultIdempotencyRetention)
+
+	// Per-user rate limits and daily sandbox time for the routes that execute code
+	limits := middleware.LoadExecutionLimitsFromEnv()
+	runLimit := middleware.UserRateLimitMiddleware(s.rateLimitStore, "run", limits.Run)
+	submitLimit := middleware.UserRateLimitMiddleware(s.rateLimitStore, "submit", limits.Submit)
+	cpuBudget := middleware.CPUBudgetMiddleware(middleware.NewCPUBudget(s.rateLimitStore, time.Duration(limits.DailyCPUSeconds)*time.Second))
+
 	// Code execution routes
-	protected.POST("/execute/run", s.executionHandler.RunCode)
-	protected.POST("/execute/submit", s.executionHandler.SubmitCode)
+	protected.POST("/execute/run", runLimit, cpuBudget, s.executionHandler.RunCode)
+	protected.POST("/execute/submit", idempotent, submitLimit, cpuBudget, s.executionHandler.SubmitCode)
 	protected.POST("/execute/validate", s.executionHandler.ValidateCode)
 	protected.GET("/execute/languages", s.executionHandler.GetSupportedLanguages)
 
 	// Submission routes
-	protected.POST("/submissions", s.submissionHandler.CreateSubmission)
+	protected.POST("/submissions", idempotent, submitLimit, cpuBudget, s.submissionHandler.CreateSubmission)
 	protected.GET("/submissions/:id", s.submissionHandler.GetSubmission)
+	protected.GET("/submissions/:id/details", s.submissionHandler.GetSubmissionDetails)
+	protected.GET("/submissions/:id/diff/:otherId", s.submissionHandler.DiffSubmissions)
 	protected.GET("/submissions/me", s.submissionHandler.GetUserSubmissions)
 	protected.GET("/submissions/user/:userId", s.submissionHandler.GetUserSubmissions)
 	protected.GET("/submissions/stats/me", s.submissionHandler.GetUserSubmissionStats)
 	protected.GET("/submissions/stats/:userId", s.submissionHandler.GetUserSubmissionStats)
 
 	// Problem-specific submission routes
-	protected.GET("/problems/:problemId/submissions", s.submissionHandler.GetProblemSubmissions)
+	protected.GET("/problems/:id/submissions", s.submissionHandler.GetProblemSubmissions)
+
+	// Code draft routes
+	protected.GET("/problems/:id/draft", s.draftHandler.GetDraft)
+	protected.PUT("/problems/:id/draft", s.draftHandler.SaveDraft)
+	protected.GET("/drafts", s.draftHandler.ListDrafts)
 }
 
 // adminMiddleware checks if the user is an admin
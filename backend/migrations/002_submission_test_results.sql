@@ -0,0 +1,24 @@
+-- Per-test-case results for submissions
+-- Stores the verdict, runtime, memory and (truncated) output of every test case
+-- that was executed for a submission, so results can be shown after the fact.
+
+CREATE TABLE IF NOT EXISTS submission_test_results (
+    id SERIAL PRIMARY KEY,
+    submission_id INTEGER NOT NULL REFERENCES submissions(id) ON DELETE CASCADE,
+    test_case_id INTEGER REFERENCES test_cases(id) ON DELETE SET NULL,
+    position INTEGER NOT NULL, -- 1-based order in which the test case was run
+    status VARCHAR(30) NOT NULL, -- Verdict for this test case ('Accepted', 'Wrong Answer', ...)
+    passed BOOLEAN NOT NULL DEFAULT FALSE,
+    runtime_ms INTEGER,
+    memory_kb INTEGER,
+    is_hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    input TEXT,
+    expected_output TEXT,
+    actual_output TEXT,
+    output_truncated BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (submission_id, position)
+);
+
+-- 'Memory Limit Exceeded' does not fit in VARCHAR(20)
+ALTER TABLE submissions ALTER COLUMN status TYPE VARCHAR(30);
@@ -0,0 +1,40 @@
+-- Rejudge jobs
+-- A rejudge job re-executes existing submissions against the current test cases,
+-- records the old and new verdict of every submission and recomputes user progress.
+
+CREATE TABLE IF NOT EXISTS rejudge_jobs (
+    id SERIAL PRIMARY KEY,
+    scope VARCHAR(20) NOT NULL CHECK (scope IN ('submission', 'problem', 'range')),
+    submission_id INTEGER REFERENCES submissions(id) ON DELETE SET NULL,
+    problem_id INTEGER REFERENCES problems(id) ON DELETE CASCADE,
+    from_time TIMESTAMP,
+    to_time TIMESTAMP,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'completed', 'failed')),
+    total INTEGER NOT NULL DEFAULT 0,
+    processed INTEGER NOT NULL DEFAULT 0,
+    changed INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    error_message TEXT,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+-- Old and new verdict of every submission processed by a rejudge job
+CREATE TABLE IF NOT EXISTS rejudge_results (
+    id SERIAL PRIMARY KEY,
+    job_id INTEGER NOT NULL REFERENCES rejudge_jobs(id) ON DELETE CASCADE,
+    submission_id INTEGER NOT NULL REFERENCES submissions(id) ON DELETE CASCADE,
+    old_status VARCHAR(30) NOT NULL,
+    new_status VARCHAR(30) NOT NULL,
+    old_test_cases_passed INTEGER NOT NULL DEFAULT 0,
+    new_test_cases_passed INTEGER NOT NULL DEFAULT 0,
+    old_runtime_ms INTEGER,
+    new_runtime_ms INTEGER,
+    verdict_changed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_rejudge_jobs_status ON rejudge_jobs(status);
+CREATE INDEX IF NOT EXISTS idx_rejudge_results_job_id ON rejudge_results(job_id);
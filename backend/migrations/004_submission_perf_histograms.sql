@@ -0,0 +1,28 @@
+-- Runtime and memory histograms of accepted submissions
+-- One row per (problem, language, metric, bucket) is kept up to date as submissions are accepted,
+-- so "beats X%" percentiles can be computed without scanning the submissions table.
+-- bucket is the inclusive lower bound of the bucket: 10 ms wide for runtime, 256 KB wide for memory.
+
+CREATE TABLE IF NOT EXISTS submission_perf_histograms (
+    problem_id INTEGER NOT NULL REFERENCES problems(id) ON DELETE CASCADE,
+    language VARCHAR(20) NOT NULL,
+    metric VARCHAR(10) NOT NULL CHECK (metric IN ('runtime', 'memory')),
+    bucket INTEGER NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (problem_id, language, metric, bucket)
+);
+
+-- Backfill from existing accepted submissions
+INSERT INTO submission_perf_histograms (problem_id, language, metric, bucket, count)
+SELECT problem_id, language, 'runtime', (runtime_ms / 10) * 10, COUNT(*)
+FROM submissions
+WHERE status = 'Accepted' AND runtime_ms IS NOT NULL
+GROUP BY problem_id, language, (runtime_ms / 10) * 10
+ON CONFLICT (problem_id, language, metric, bucket) DO NOTHING;
+
+INSERT INTO submission_perf_histograms (problem_id, language, metric, bucket, count)
+SELECT problem_id, language, 'memory', (memory_kb / 256) * 256, COUNT(*)
+FROM submissions
+WHERE status = 'Accepted' AND memory_kb IS NOT NULL
+GROUP BY problem_id, language, (memory_kb / 256) * 256
+ON CONFLICT (problem_id, language, metric, bucket) DO NOTHING;
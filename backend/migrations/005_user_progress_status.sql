@@ -0,0 +1,50 @@
+-- User progress status
+-- Progress is now updated on every submission: attempts count all submissions, and status
+-- distinguishes attempted from solved problems. The best submission is the fastest accepted
+-- submission (then the one using the least memory) in the language of the first accepted submission.
+
+ALTER TABLE user_progress
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'unsolved'
+    CHECK (status IN ('unsolved', 'attempted', 'solved'));
+
+UPDATE user_progress SET status = 'solved' WHERE is_solved = TRUE;
+
+-- Create progress records for problems that were attempted but never solved
+INSERT INTO user_progress (user_id, problem_id, is_solved, attempts, status)
+SELECT user_id, problem_id, FALSE, 0, 'attempted'
+FROM submissions
+GROUP BY user_id, problem_id
+ON CONFLICT (user_id, problem_id) DO NOTHING;
+
+-- Recompute attempts, solved state and best submission from the submissions
+WITH stats AS (
+    SELECT user_id, problem_id,
+           COUNT(*) AS attempts,
+           COUNT(*) FILTER (WHERE status = 'Accepted') AS accepted,
+           MIN(submitted_at) FILTER (WHERE status = 'Accepted') AS first_solved_at
+    FROM submissions
+    GROUP BY user_id, problem_id
+), first_accepted AS (
+    SELECT DISTINCT ON (user_id, problem_id) user_id, problem_id, language
+    FROM submissions
+    WHERE status = 'Accepted'
+    ORDER BY user_id, problem_id, submitted_at ASC, id ASC
+), best AS (
+    SELECT DISTINCT ON (s.user_id, s.problem_id) s.user_id, s.problem_id, s.id
+    FROM submissions s
+    JOIN first_accepted fa
+      ON fa.user_id = s.user_id AND fa.problem_id = s.problem_id AND fa.language = s.language
+    WHERE s.status = 'Accepted'
+    ORDER BY s.user_id, s.problem_id, s.runtime_ms ASC NULLS LAST, s.memory_kb ASC NULLS LAST, s.submitted_at ASC, s.id ASC
+)
+UPDATE user_progress up
+SET attempts = st.attempts,
+    is_solved = st.accepted > 0,
+    first_solved_at = st.first_solved_at,
+    best_submission_id = b.id,
+    status = CASE WHEN st.accepted > 0 THEN 'solved' ELSE 'attempted' END
+FROM stats st
+LEFT JOIN best b ON b.user_id = st.user_id AND b.problem_id = st.problem_id
+WHERE up.user_id = st.user_id AND up.problem_id = st.problem_id;
+
+CREATE INDEX IF NOT EXISTS idx_user_progress_status ON user_progress(user_id, status);
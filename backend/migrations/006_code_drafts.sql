@@ -0,0 +1,15 @@
+-- Code drafts
+-- Autosaved editor contents per user, problem and language, so code survives closing the tab.
+-- version is incremented on every save and used for optimistic concurrency control.
+
+CREATE TABLE IF NOT EXISTS code_drafts (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    problem_id INTEGER NOT NULL REFERENCES problems(id) ON DELETE CASCADE,
+    language VARCHAR(20) NOT NULL,
+    code TEXT NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, problem_id, language)
+);
+
+CREATE INDEX IF NOT EXISTS idx_code_drafts_user_updated ON code_drafts(user_id, updated_at DESC);
@@ -0,0 +1,19 @@
+-- Idempotency keys
+-- Remembers the response to requests sent with an Idempotency-Key header, so that a retried
+-- or double-clicked submission returns the original result instead of being judged again.
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    endpoint VARCHAR(255) NOT NULL, -- Method and path, e.g. 'POST /api/v1/submissions'
+    key VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL, -- SHA-256 of the request body
+    status VARCHAR(20) NOT NULL DEFAULT 'processing' CHECK (status IN ('processing', 'completed')),
+    response_status INTEGER,
+    response_content_type VARCHAR(255),
+    response_body TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    PRIMARY KEY (user_id, endpoint, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at);
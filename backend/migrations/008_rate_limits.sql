@@ -0,0 +1,21 @@
+-- Rate limits
+-- Token buckets and expiring counters used by the rate limiter when RATE_LIMIT_STORE=postgres,
+-- so that limits survive restarts and are shared between backend replicas.
+-- Times come from the backend and are stored with their time zone.
+
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key VARCHAR(255) PRIMARY KEY, -- e.g. 'submit:user:42'
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    idle_at TIMESTAMPTZ NOT NULL -- When the bucket is full again and can be deleted
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_idle_at ON rate_limit_buckets(idle_at);
+
+CREATE TABLE IF NOT EXISTS rate_limit_counters (
+    key VARCHAR(255) PRIMARY KEY, -- e.g. 'cpu:user:42:2024-05-01'
+    value DOUBLE PRECISION NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_counters_expires_at ON rate_limit_counters(expires_at);
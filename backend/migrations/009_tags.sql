@@ -0,0 +1,20 @@
+-- Tags
+-- Catalog of problem tags with an optional description. problems.tags remains the source of
+-- truth for which problems have a tag; this table holds per-tag metadata.
+
+CREATE TABLE IF NOT EXISTS tags (
+    name VARCHAR(50) PRIMARY KEY,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_tags_updated_at BEFORE UPDATE ON tags
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Add every tag already used by a problem
+INSERT INTO tags (name)
+SELECT DISTINCT tag
+FROM problems, unnest(tags) AS tag
+WHERE tag <> '' AND length(tag) <= 50
+ON CONFLICT (name) DO NOTHING;
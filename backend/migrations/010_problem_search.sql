@@ -0,0 +1,19 @@
+-- Problem full-text search
+-- Adds a generated tsvector over the title, tags and description of every problem, weighted
+-- in that order, so searches can use a GIN index and be ranked with ts_rank.
+
+-- array_to_string is only STABLE, which generated columns do not accept. Joining text values
+-- does not depend on any setting, so it is safe to wrap as IMMUTABLE.
+CREATE OR REPLACE FUNCTION problem_tags_to_text(tags TEXT[])
+RETURNS TEXT AS $$
+    SELECT array_to_string(tags, ' ')
+$$ LANGUAGE sql IMMUTABLE;
+
+ALTER TABLE problems ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE(problem_tags_to_text(tags), '')), 'B') ||
+        setweight(to_tsvector('english', COALESCE(description, '')), 'C')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_problems_search_vector ON problems USING GIN(search_vector);
@@ -0,0 +1,27 @@
+-- Problem revisions
+-- Every change to a problem or its test cases stores a snapshot of the problem with all of its
+-- test cases, so changes can be reviewed, diffed and rolled back. Revisions are numbered from 1
+-- per problem and never change once written.
+
+CREATE TABLE IF NOT EXISTS problem_revisions (
+    id SERIAL PRIMARY KEY,
+    problem_id INTEGER NOT NULL REFERENCES problems(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    author_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    action VARCHAR(30) NOT NULL,
+    changed_fields TEXT[] NOT NULL DEFAULT '{}',
+    restored_from INTEGER,
+    snapshot JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (problem_id, revision)
+);
+
+CREATE OR REPLACE FUNCTION reject_problem_revision_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'problem revisions cannot be modified';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER problem_revisions_immutable BEFORE UPDATE ON problem_revisions
+    FOR EACH ROW EXECUTE FUNCTION reject_problem_revision_update();
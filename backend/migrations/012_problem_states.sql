@@ -0,0 +1,19 @@
+-- Problem lifecycle
+-- Problems move from draft through review to published, optionally scheduled to publish at a
+-- later time, and can be archived. Only published problems are public. Existing problems were
+-- public already and stay published; new problems start as drafts.
+
+ALTER TABLE problems
+    ADD COLUMN IF NOT EXISTS state VARCHAR(20) NOT NULL DEFAULT 'published'
+    CHECK (state IN ('draft', 'review', 'scheduled', 'published', 'archived')),
+    ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS published_at TIMESTAMP;
+
+UPDATE problems SET published_at = created_at WHERE state = 'published' AND published_at IS NULL;
+
+ALTER TABLE problems ALTER COLUMN state SET DEFAULT 'draft';
+
+CREATE INDEX IF NOT EXISTS idx_problems_state ON problems(state);
+
+-- Scheduled problems are polled by publish time
+CREATE INDEX IF NOT EXISTS idx_problems_publish_at ON problems(publish_at) WHERE state = 'scheduled';
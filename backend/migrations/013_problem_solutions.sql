@@ -0,0 +1,17 @@
+-- Reference solutions
+-- Known-correct solutions of a problem, carried in problem packages so that a problem moved
+-- between instances keeps them. Solution names are unique within a problem.
+
+CREATE TABLE IF NOT EXISTS problem_solutions (
+    id SERIAL PRIMARY KEY,
+    problem_id INTEGER NOT NULL REFERENCES problems(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    language VARCHAR(20) NOT NULL CHECK (language IN ('javascript', 'python', 'java')),
+    code TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (problem_id, name)
+);
+
+CREATE TRIGGER update_problem_solutions_updated_at BEFORE UPDATE ON problem_solutions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,16 @@
+-- Judging settings
+-- Per-problem time and memory limits and output checker. Problems without a row are judged with
+-- the defaults: 10 seconds, 128 MB and exact comparison of the trimmed output.
+
+CREATE TABLE IF NOT EXISTS judge_settings (
+    problem_id INTEGER PRIMARY KEY REFERENCES problems(id) ON DELETE CASCADE,
+    time_limit_ms INTEGER NOT NULL CHECK (time_limit_ms BETWEEN 100 AND 10000),
+    memory_limit_mb INTEGER NOT NULL CHECK (memory_limit_mb BETWEEN 16 AND 1024),
+    checker VARCHAR(20) NOT NULL CHECK (checker IN ('exact', 'tokens')),
+    ignore_case BOOLEAN NOT NULL DEFAULT FALSE,
+    float_tolerance DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (float_tolerance >= 0 AND float_tolerance < 1),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_judge_settings_updated_at BEFORE UPDATE ON judge_settings
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,33 @@
+-- Test data storage
+-- Large test inputs and expected outputs are kept in a content-addressed store outside the
+-- database, under their SHA-256 hash. Every test case keeps the hash, size in bytes and a preview
+-- of its data, so test cases can be listed without reading the data; input and expected_output
+-- hold the data only when it is small and are NULL when it is in the store. Existing test cases
+-- keep their data in the database.
+
+ALTER TABLE test_cases
+    ALTER COLUMN input DROP NOT NULL,
+    ALTER COLUMN expected_output DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS input_hash CHAR(64),
+    ADD COLUMN IF NOT EXISTS input_size BIGINT,
+    ADD COLUMN IF NOT EXISTS input_preview TEXT,
+    ADD COLUMN IF NOT EXISTS output_hash CHAR(64),
+    ADD COLUMN IF NOT EXISTS output_size BIGINT,
+    ADD COLUMN IF NOT EXISTS output_preview TEXT;
+
+UPDATE test_cases SET
+    input_hash = encode(sha256(convert_to(input, 'UTF8')), 'hex'),
+    input_size = octet_length(input),
+    input_preview = left(input, 256),
+    output_hash = encode(sha256(convert_to(expected_output, 'UTF8')), 'hex'),
+    output_size = octet_length(expected_output),
+    output_preview = left(expected_output, 256)
+WHERE input_hash IS NULL;
+
+ALTER TABLE test_cases
+    ALTER COLUMN input_hash SET NOT NULL,
+    ALTER COLUMN input_size SET NOT NULL,
+    ALTER COLUMN input_preview SET NOT NULL,
+    ALTER COLUMN output_hash SET NOT NULL,
+    ALTER COLUMN output_size SET NOT NULL,
+    ALTER COLUMN output_preview SET NOT NULL;
@@ -0,0 +1,10 @@
+-- Expected output status
+-- Result of the last check of a test case's expected output against the reference solutions of
+-- its problem: unverified, verified, stale (the solutions agree on another output) or disputed
+-- (the solutions disagree or fail). Changing the test data or the reference solutions resets it
+-- to unverified.
+
+ALTER TABLE test_cases
+    ADD COLUMN IF NOT EXISTS output_status VARCHAR(20) NOT NULL DEFAULT 'unverified'
+        CHECK (output_status IN ('unverified', 'verified', 'stale', 'disputed')),
+    ADD COLUMN IF NOT EXISTS output_checked_at TIMESTAMP;
@@ -0,0 +1,18 @@
+-- Test programs
+-- The test input generator and input validator of a problem, one of each at most. Both are
+-- written like submissions: the generator turns a seed into a test input, and the validator
+-- returns OK for an input that satisfies the constraints of the problem or the constraint it
+-- violates.
+
+CREATE TABLE IF NOT EXISTS test_programs (
+    problem_id INTEGER NOT NULL REFERENCES problems(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('generator', 'validator')),
+    language VARCHAR(20) NOT NULL CHECK (language IN ('javascript', 'python', 'java')),
+    code TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (problem_id, kind)
+);
+
+CREATE TRIGGER update_test_programs_updated_at BEFORE UPDATE ON test_programs
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,6 @@
+-- Per-language limits
+-- Time and memory limits of languages judged with limits of their own, such as those suggested by
+-- calibrating the limits of a problem against its reference solutions. Maps each language to its
+-- {"time_limit_ms", "memory_limit_mb"}; languages not listed use the limits of the problem.
+
+ALTER TABLE judge_settings ADD COLUMN IF NOT EXISTS language_limits JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,16 @@
+-- Rejudge job leases
+-- A worker claims a queued job by moving it to running and refreshes heartbeat_at while it works
+-- on it. A running job whose heartbeat is stale was abandoned, for instance by a restart, and may
+-- be claimed again by any instance. Each claim sets a new claim_token, which the worker must
+-- present to renew the lease or update the job, so that a worker whose job was claimed again
+-- stops changing it. A submission has at most one result per job.
+
+ALTER TABLE rejudge_jobs ADD COLUMN IF NOT EXISTS heartbeat_at TIMESTAMP;
+ALTER TABLE rejudge_jobs ADD COLUMN IF NOT EXISTS claim_token VARCHAR(64);
+
+-- Keep the first result recorded for each submission of a job
+DELETE FROM rejudge_results a
+USING rejudge_results b
+WHERE a.job_id = b.job_id AND a.submission_id = b.submission_id AND a.id > b.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rejudge_results_job_submission ON rejudge_results(job_id, submission_id);
@@ -0,0 +1,336 @@
+// Package diff computes line-based differences between two texts and renders them
+// as structured hunks or in unified diff format.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Line kinds
+const (
+	KindEqual  = "equal"
+	KindInsert = "insert"
+	KindDelete = "delete"
+)
+
+// DefaultContext is the number of unchanged lines shown around each change
+const DefaultContext = 3
+
+// maxEditDistance bounds the work done by the diff algorithm. Texts that differ in more
+// lines than this are diffed as a replacement of the whole differing region instead.
+const maxEditDistance = 2000
+
+// Line is a single line of an edit script
+type Line struct {
+	Kind    string `json:"kind"`
+	Content string `json:"content"`
+	OldLine int    `json:"old_line,omitempty"` // 1-based line number in the old text, 0 for inserted lines
+	NewLine int    `json:"new_line,omitempty"` // 1-based line number in the new text, 0 for deleted lines
+}
+
+// Hunk is a group of changes with surrounding context lines
+type Hunk struct {
+	OldStart int    `json:"old_start"`
+	OldLines int    `json:"old_lines"`
+	NewStart int    `json:"new_start"`
+	NewLines int    `json:"new_lines"`
+	Lines    []Line `json:"lines"`
+}
+
+// Result is the difference between two texts
+type Result struct {
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Hunks     []Hunk `json:"hunks"`
+	Unified   string `json:"unified"`
+}
+
+// Compute diffs two texts line by line. oldName and newName are used in the unified diff headers.
+func Compute(oldName, newName, oldText, newText string, context int) *Result {
+	script := Lines(SplitLines(oldText), SplitLines(newText))
+
+	result := &Result{Hunks: Hunks(script, context)}
+	for _, line := range script {
+		switch line.Kind {
+		case KindInsert:
+			result.Additions++
+		case KindDelete:
+			result.Deletions++
+		}
+	}
+	result.Unified = Unified(oldName, newName, result.Hunks)
+
+	return result
+}
+
+// SplitLines splits a text into lines. Windows line endings are normalized and a trailing
+// newline does not produce an empty last line.
+func SplitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Lines computes a shortest edit script that turns a into b, using Myers' algorithm
+func Lines(a, b []string) []Line {
+	// Lines shared at the start and end do not need to go through the algorithm
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	script := make([]Line, 0, len(a)+len(b)-prefix-suffix)
+	for i := 0; i < prefix; i++ {
+		script = append(script, Line{Kind: KindEqual, Content: a[i], OldLine: i + 1, NewLine: i + 1})
+	}
+
+	middle := myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	for _, line := range middle {
+		if line.OldLine > 0 {
+			line.OldLine += prefix
+		}
+		if line.NewLine > 0 {
+			line.NewLine += prefix
+		}
+		script = append(script, line)
+	}
+
+	for i := 0; i < suffix; i++ {
+		oldIndex := len(a) - suffix + i
+		newIndex := len(b) - suffix + i
+		script = append(script, Line{Kind: KindEqual, Content: a[oldIndex], OldLine: oldIndex + 1, NewLine: newIndex + 1})
+	}
+
+	return script
+}
+
+// myers returns the edit script between a and b. See "An O(ND) Difference Algorithm and
+// Its Variations" (Myers, 1986).
+func myers(a, b []string) []Line {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+
+	limit := n + m
+	if limit > maxEditDistance {
+		limit = maxEditDistance
+	}
+
+	// v[offset+k] is the furthest x reached on diagonal k = x - y
+	offset := limit + 1
+	v := make([]int, 2*offset+1)
+	// trace[d] holds v[-d..d] as it was before step d, for backtracking
+	var trace [][]int
+
+	for d := 0; d <= limit; d++ {
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // move down: insert b[y]
+			} else {
+				x = v[offset+k-1] + 1 // move right: delete a[x]
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+
+	return replace(a, b)
+}
+
+// backtrack walks the trace from the end to recover the edit script
+func backtrack(a, b []string, trace [][]int) []Line {
+	x, y := len(a), len(b)
+	var reversed []Line
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := 0
+		if d > 0 {
+			prevX = at(prevK)
+		}
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, Line{Kind: KindEqual, Content: a[x-1], OldLine: x, NewLine: y})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, Line{Kind: KindInsert, Content: b[y-1], NewLine: y})
+			} else {
+				reversed = append(reversed, Line{Kind: KindDelete, Content: a[x-1], OldLine: x})
+			}
+		}
+
+		x, y = prevX, prevY
+	}
+
+	script := make([]Line, len(reversed))
+	for i, line := range reversed {
+		script[len(reversed)-1-i] = line
+	}
+	return script
+}
+
+// replace returns an edit script that deletes all of a and inserts all of b
+func replace(a, b []string) []Line {
+	script := make([]Line, 0, len(a)+len(b))
+	for i, line := range a {
+		script = append(script, Line{Kind: KindDelete, Content: line, OldLine: i + 1})
+	}
+	for i, line := range b {
+		script = append(script, Line{Kind: KindInsert, Content: line, NewLine: i + 1})
+	}
+	return script
+}
+
+// Hunks groups an edit script into hunks with up to context unchanged lines around each change.
+// Changes separated by at most 2*context unchanged lines share a hunk.
+func Hunks(script []Line, context int) []Hunk {
+	if context < 0 {
+		context = 0
+	}
+
+	hunks := []Hunk{}
+	i, prevStop := 0, 0
+	for i < len(script) {
+		// Find the next change
+		for i < len(script) && script[i].Kind == KindEqual {
+			i++
+		}
+		if i == len(script) {
+			break
+		}
+
+		// Leading context never overlaps the previous hunk
+		start := i - context
+		if start < prevStop {
+			start = prevStop
+		}
+
+		// Extend the hunk while the next change is within 2*context unchanged lines
+		end := i
+		for end < len(script) {
+			if script[end].Kind != KindEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(script) && script[run].Kind == KindEqual {
+				run++
+			}
+			if run == len(script) || run-end > 2*context {
+				break
+			}
+			end = run
+		}
+
+		stop := end + context
+		if stop > len(script) {
+			stop = len(script)
+		}
+
+		hunks = append(hunks, newHunk(script, start, stop))
+		i, prevStop = stop, stop
+	}
+
+	return hunks
+}
+
+// newHunk builds a hunk from script[start:stop]
+func newHunk(script []Line, start, stop int) Hunk {
+	lines := make([]Line, stop-start)
+	copy(lines, script[start:stop])
+
+	hunk := Hunk{Lines: lines}
+	for _, line := range lines {
+		if line.Kind != KindInsert {
+			hunk.OldLines++
+		}
+		if line.Kind != KindDelete {
+			hunk.NewLines++
+		}
+	}
+
+	// Line numbers of the first line on each side, or of the line before an empty side
+	hunk.OldStart = lineBefore(script, start, func(l Line) int { return l.OldLine })
+	hunk.NewStart = lineBefore(script, start, func(l Line) int { return l.NewLine })
+	if hunk.OldLines > 0 {
+		hunk.OldStart++
+	}
+	if hunk.NewLines > 0 {
+		hunk.NewStart++
+	}
+
+	return hunk
+}
+
+// lineBefore returns the last line number on one side before script[start], or 0
+func lineBefore(script []Line, start int, number func(Line) int) int {
+	for i := start - 1; i >= 0; i-- {
+		if n := number(script[i]); n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// Unified renders hunks in unified diff format
+func Unified(oldName, newName string, hunks []Hunk) string {
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	for _, hunk := range hunks {
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines)
+		for _, line := range hunk.Lines {
+			switch line.Kind {
+			case KindInsert:
+				sb.WriteString("+")
+			case KindDelete:
+				sb.WriteString("-")
+			default:
+				sb.WriteString(" ")
+			}
+			sb.WriteString(line.Content)
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
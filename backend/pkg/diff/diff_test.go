@@ -0,0 +1,144 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+// apply rebuilds the old and new texts from an edit script
+func apply(script []Line) (oldLines, newLines []string) {
+	for _, line := range script {
+		if line.Kind != KindInsert {
+			oldLines = append(oldLines, line.Content)
+		}
+		if line.Kind != KindDelete {
+			newLines = append(newLines, line.Content)
+		}
+	}
+	return oldLines, newLines
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		a       []string
+		b       []string
+		changes int
+	}{
+		{"identical", []string{"a", "b", "c"}, []string{"a", "b", "c"}, 0},
+		{"both empty", nil, nil, 0},
+		{"insert into empty", nil, []string{"a", "b"}, 2},
+		{"delete everything", []string{"a", "b"}, nil, 2},
+		{"change middle line", []string{"a", "b", "c"}, []string{"a", "x", "c"}, 2},
+		{"insert and delete", []string{"a", "b", "c", "d"}, []string{"b", "c", "e", "d"}, 2},
+		{"classic example", strings.Split("ABCABBA", ""), strings.Split("CBABAC", ""), 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := Lines(tt.a, tt.b)
+
+			oldLines, newLines := apply(script)
+			if strings.Join(oldLines, "\n") != strings.Join(tt.a, "\n") {
+				t.Errorf("Script does not reproduce old text: %v", oldLines)
+			}
+			if strings.Join(newLines, "\n") != strings.Join(tt.b, "\n") {
+				t.Errorf("Script does not reproduce new text: %v", newLines)
+			}
+
+			changes := 0
+			for _, line := range script {
+				if line.Kind != KindEqual {
+					changes++
+				}
+			}
+			if changes != tt.changes {
+				t.Errorf("Expected %d changed lines, got %d", tt.changes, changes)
+			}
+		})
+	}
+}
+
+func TestLines_LineNumbers(t *testing.T) {
+	script := Lines([]string{"a", "b", "c"}, []string{"a", "x", "c"})
+
+	expected := []Line{
+		{Kind: KindEqual, Content: "a", OldLine: 1, NewLine: 1},
+		{Kind: KindDelete, Content: "b", OldLine: 2},
+		{Kind: KindInsert, Content: "x", NewLine: 2},
+		{Kind: KindEqual, Content: "c", OldLine: 3, NewLine: 3},
+	}
+
+	if len(script) != len(expected) {
+		t.Fatalf("Expected %d lines, got %d: %v", len(expected), len(script), script)
+	}
+	for i := range expected {
+		if script[i] != expected[i] {
+			t.Errorf("Line %d: expected %+v, got %+v", i, expected[i], script[i])
+		}
+	}
+}
+
+func TestHunks(t *testing.T) {
+	var a, b []string
+	for i := 1; i <= 20; i++ {
+		line := string(rune('a' + i - 1))
+		a = append(a, line)
+		b = append(b, line)
+	}
+	b[1] = "X"  // line 2
+	b[3] = "Y"  // line 4, close enough to share a hunk with line 2
+	b[15] = "Z" // line 16, in its own hunk
+
+	hunks := Hunks(Lines(a, b), 3)
+
+	if len(hunks) != 2 {
+		t.Fatalf("Expected 2 hunks, got %d", len(hunks))
+	}
+	if hunks[0].OldStart != 1 || hunks[0].OldLines != 7 || hunks[0].NewStart != 1 || hunks[0].NewLines != 7 {
+		t.Errorf("Unexpected first hunk range: %+v", hunks[0])
+	}
+	if hunks[1].OldStart != 13 || hunks[1].OldLines != 7 || hunks[1].NewStart != 13 || hunks[1].NewLines != 7 {
+		t.Errorf("Unexpected second hunk range: %+v", hunks[1])
+	}
+}
+
+func TestCompute(t *testing.T) {
+	oldText := "def solve(n):\n    return n\n"
+	newText := "def solve(n):\n    return n * 2\n"
+
+	result := Compute("a/solution.py", "b/solution.py", oldText, newText, DefaultContext)
+
+	expected := "--- a/solution.py\n" +
+		"+++ b/solution.py\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		" def solve(n):\n" +
+		"-    return n\n" +
+		"+    return n * 2\n"
+
+	if result.Unified != expected {
+		t.Errorf("Unexpected unified diff:\n%s", result.Unified)
+	}
+	if result.Additions != 1 || result.Deletions != 1 {
+		t.Errorf("Expected 1 addition and 1 deletion, got %d and %d", result.Additions, result.Deletions)
+	}
+}
+
+func TestCompute_Identical(t *testing.T) {
+	result := Compute("a", "b", "x\r\ny\r\n", "x\ny", DefaultContext)
+
+	if result.Unified != "" {
+		t.Errorf("Expected empty unified diff, got %q", result.Unified)
+	}
+	if result.Hunks == nil || len(result.Hunks) != 0 {
+		t.Errorf("Expected no hunks, got %v", result.Hunks)
+	}
+}
+
+func TestCompute_EmptyOldText(t *testing.T) {
+	result := Compute("a", "b", "", "x\n", DefaultContext)
+
+	if !strings.Contains(result.Unified, "@@ -0,0 +1,1 @@\n+x\n") {
+		t.Errorf("Unexpected unified diff:\n%s", result.Unified)
+	}
+}
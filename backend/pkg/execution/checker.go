@@ -0,0 +1,58 @@
+package execution
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+// OutputsMatch reports whether the output of a program is accepted for the expected output by
+// the checker of the judging settings. nil settings use the defaults.
+func OutputsMatch(expected, actual string, settings *models.JudgeSettings) bool {
+	if settings == nil {
+		settings = models.DefaultJudgeSettings(0)
+	}
+	return outputMatches(strings.TrimSpace(expected), strings.TrimSpace(actual), settings)
+}
+
+// outputMatches reports whether the trimmed output of a program is accepted for the trimmed
+// expected output by the checker of the judging settings
+func outputMatches(expected, actual string, settings *models.JudgeSettings) bool {
+	if settings.Checker != models.CheckerTokens {
+		return expected == actual
+	}
+
+	expectedTokens, actualTokens := strings.Fields(expected), strings.Fields(actual)
+	if len(expectedTokens) != len(actualTokens) {
+		return false
+	}
+	for i, token := range expectedTokens {
+		if !tokenMatches(token, actualTokens[i], settings) {
+			return false
+		}
+	}
+	return true
+}
+
+// tokenMatches compares a single token of the output with the expected token
+func tokenMatches(expected, actual string, settings *models.JudgeSettings) bool {
+	if expected == actual || (settings.IgnoreCase && strings.EqualFold(expected, actual)) {
+		return true
+	}
+	if settings.FloatTolerance <= 0 {
+		return false
+	}
+
+	want, err := strconv.ParseFloat(expected, 64)
+	if err != nil {
+		return false
+	}
+	got, err := strconv.ParseFloat(actual, 64)
+	if err != nil || math.IsNaN(got) {
+		return false
+	}
+	diff := math.Abs(got - want)
+	return diff <= settings.FloatTolerance || diff <= settings.FloatTolerance*math.Abs(want)
+}
@@ -2,15 +2,27 @@ package execution
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"leetcode-clone-backend/pkg/models"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// MaxCodeLength is the maximum size of submitted code in bytes
+const MaxCodeLength = 50000 // 50KB limit
+
+// containerStartupAllowance is the time allowed on top of the time limit for starting a container
+// and compiling; the time limit itself is enforced inside the container
+const containerStartupAllowance = 10 * time.Second
+
+// timeoutExitCode is the exit status of the timeout command when it stops a program
+const timeoutExitCode = 124
+
 // ExecutionResult represents the result of code execution
 type ExecutionResult struct {
 	Status          string       `json:"status"`
@@ -23,42 +35,64 @@ type ExecutionResult struct {
 	TestResults     []TestResult `json:"test_results,omitempty"`
 }
 
+// SandboxTime returns the time spent running the code in the sandbox, over every test case run
+func (r *ExecutionResult) SandboxTime() time.Duration {
+	total := 0
+	for _, testResult := range r.TestResults {
+		total += testResult.RuntimeMs
+	}
+	return time.Duration(total) * time.Millisecond
+}
+
 // TestResult represents the result of a single test case
 type TestResult struct {
 	Input          string `json:"input"`
 	ExpectedOutput string `json:"expected_output"`
 	ActualOutput   string `json:"actual_output"`
 	Passed         bool   `json:"passed"`
+	Status         string `json:"status,omitempty"`
 	RuntimeMs      int    `json:"runtime_ms"`
 	MemoryKb       int    `json:"memory_kb"`
 }
 
+// RunResult is the output of a program on one input, not compared with any expected output
+type RunResult struct {
+	Output    string `json:"output"` // Trimmed output, or "timeout" or the error of a failed run
+	Status    string `json:"status"` // Accepted when the program ran to completion
+	RuntimeMs int    `json:"runtime_ms"`
+	MemoryKb  int    `json:"memory_kb"`
+}
+
 // ExecutionServiceInterface defines the interface for code execution
 type ExecutionServiceInterface interface {
-	ExecuteCode(code, language string, testCases []models.TestCase) (*ExecutionResult, error)
+	ExecuteCode(code, language string, testCases []models.TestCase, settings *models.JudgeSettings) (*ExecutionResult, error)
+	RunCode(code, language string, inputs []string, settings *models.JudgeSettings) ([]RunResult, error)
 	ValidateCode(code, language string) error
 }
 
 // ExecutionService handles code execution in sandboxed environments
 type ExecutionService struct {
-	dockerImage    string
-	timeoutSeconds int
-	memoryLimitMB  int
-	tempDir        string
+	dockerImage string
+	tempDir     string
 }
 
 // NewExecutionService creates a new execution service
 func NewExecutionService() *ExecutionService {
 	return &ExecutionService{
-		dockerImage:    "ubuntu:22.04",
-		timeoutSeconds: 10,  // 10 seconds timeout
-		memoryLimitMB:  128, // 128MB memory limit
-		tempDir:        "/tmp/leetcode-execution",
+		dockerImage: "ubuntu:22.04",
+		tempDir:     "/tmp/leetcode-execution",
 	}
 }
 
-// ExecuteCode runs the provided code against test cases in a sandboxed environment
-func (es *ExecutionService) ExecuteCode(code, language string, testCases []models.TestCase) (*ExecutionResult, error) {
+// ExecuteCode runs the provided code against test cases in a sandboxed environment, with the
+// limits and output checker of the problem's judging settings, using the limits of the language
+// if it has its own. nil settings use the defaults.
+func (es *ExecutionService) ExecuteCode(code, language string, testCases []models.TestCase, settings *models.JudgeSettings) (*ExecutionResult, error) {
+	if settings == nil {
+		settings = models.DefaultJudgeSettings(0)
+	}
+	settings = settings.ForLanguage(language)
+
 	// Validate language support
 	if !es.isLanguageSupported(language) {
 		return &ExecutionResult{
@@ -96,13 +130,14 @@ func (es *ExecutionService) ExecuteCode(code, language string, testCases []model
 	maxMemory := 0
 
 	for _, testCase := range testCases {
-		testResult, err := es.executeTestCase(execDir, codeFile, language, testCase)
+		testResult, err := es.executeTestCase(execDir, codeFile, language, testCase, settings)
 		if err != nil {
 			result.Status = models.StatusInternalError
 			result.ErrorMessage = err.Error()
 			return result, nil
 		}
 
+		testResult.Status = es.classifyTestResult(testResult)
 		result.TestResults = append(result.TestResults, *testResult)
 		totalRuntime += testResult.RuntimeMs
 		if testResult.MemoryKb > maxMemory {
@@ -112,17 +147,9 @@ func (es *ExecutionService) ExecuteCode(code, language string, testCases []model
 		if testResult.Passed {
 			result.TestCasesPassed++
 		} else {
-			// If any test case fails, determine the failure reason
-			if strings.Contains(testResult.ActualOutput, "timeout") {
-				result.Status = models.StatusTimeLimitExceeded
-			} else if strings.Contains(testResult.ActualOutput, "memory") {
-				result.Status = models.StatusMemoryLimitExceeded
-			} else if strings.Contains(testResult.ActualOutput, "error") || strings.Contains(testResult.ActualOutput, "Error") {
-				result.Status = models.StatusRuntimeError
-			} else {
-				result.Status = models.StatusWrongAnswer
-			}
-			result.ErrorMessage = fmt.Sprintf("Test case failed: expected %s, got %s", testResult.ExpectedOutput, testResult.ActualOutput)
+			// If any test case fails, the submission takes that test case's verdict
+			result.Status = testResult.Status
+			result.ErrorMessage = failureMessage(testResult)
 			break
 		}
 	}
@@ -138,21 +165,87 @@ func (es *ExecutionService) ExecuteCode(code, language string, testCases []model
 	return result, nil
 }
 
+// RunCode runs the provided code on each input in a sandboxed environment, with the limits of
+// the judging settings for its language, and returns its output for every input. Unlike
+// ExecuteCode, it does not stop at the first failed run. nil settings use the defaults.
+func (es *ExecutionService) RunCode(code, language string, inputs []string, settings *models.JudgeSettings) ([]RunResult, error) {
+	if settings == nil {
+		settings = models.DefaultJudgeSettings(0)
+	}
+	settings = settings.ForLanguage(language)
+
+	if !es.isLanguageSupported(language) {
+		return nil, fmt.Errorf("unsupported language: %s", language)
+	}
+
+	execDir, err := es.createTempDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(execDir)
+
+	codeFile, err := es.prepareCodeFile(execDir, code, language)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare code file: %w", err)
+	}
+
+	results := make([]RunResult, 0, len(inputs))
+	for _, input := range inputs {
+		result, err := es.runInput(execDir, codeFile, language, input, settings)
+		if err != nil {
+			return nil, err
+		}
+		// The wrappers report exceptions thrown by the solution without failing
+		if result.Status == models.StatusAccepted && strings.Contains(result.Output, "Runtime Error:") {
+			result.Status = models.StatusRuntimeError
+		}
+		results = append(results, *result)
+	}
+
+	return results, nil
+}
+
 // executeTestCase runs a single test case
-func (es *ExecutionService) executeTestCase(execDir, codeFile, language string, testCase models.TestCase) (*TestResult, error) {
+func (es *ExecutionService) executeTestCase(execDir, codeFile, language string, testCase models.TestCase, settings *models.JudgeSettings) (*TestResult, error) {
+	run, err := es.runInput(execDir, codeFile, language, testCase.Input, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TestResult{
+		Input:          testCase.Input,
+		ExpectedOutput: strings.TrimSpace(testCase.ExpectedOutput),
+		ActualOutput:   run.Output,
+		RuntimeMs:      run.RuntimeMs,
+		MemoryKb:       run.MemoryKb,
+	}
+	if run.Status != models.StatusAccepted {
+		result.Passed = false
+		return result, nil
+	}
+
+	// Compare outputs
+	result.Passed = outputMatches(result.ExpectedOutput, result.ActualOutput, settings)
+
+	return result, nil
+}
+
+// runInput runs a prepared program on a single input
+func (es *ExecutionService) runInput(execDir, codeFile, language, input string, settings *models.JudgeSettings) (*RunResult, error) {
 	start := time.Now()
 
 	// Create input file
 	inputFile := filepath.Join(execDir, "input.txt")
-	if err := os.WriteFile(inputFile, []byte(testCase.Input), 0644); err != nil {
+	if err := os.WriteFile(inputFile, []byte(input), 0644); err != nil {
 		return nil, fmt.Errorf("failed to create input file: %v", err)
 	}
 
 	// Prepare Docker command
-	dockerCmd := es.buildDockerCommand(execDir, codeFile, language)
+	dockerCmd := es.buildDockerCommand(execDir, codeFile, language, settings)
 
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(es.timeoutSeconds)*time.Second)
+	timeLimit := time.Duration(settings.TimeLimitMs) * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), timeLimit+containerStartupAllowance)
 	defer cancel()
 
 	// Execute in Docker container
@@ -161,58 +254,88 @@ func (es *ExecutionService) executeTestCase(execDir, codeFile, language string,
 
 	runtime := int(time.Since(start).Milliseconds())
 
-	result := &TestResult{
-		Input:          testCase.Input,
-		ExpectedOutput: strings.TrimSpace(testCase.ExpectedOutput),
-		ActualOutput:   strings.TrimSpace(string(output)),
-		RuntimeMs:      runtime,
-		MemoryKb:       es.estimateMemoryUsage(string(output)), // Simple estimation
+	result := &RunResult{
+		Output:    strings.TrimSpace(string(output)),
+		Status:    models.StatusAccepted,
+		RuntimeMs: runtime,
+		MemoryKb:  es.estimateMemoryUsage(string(output)), // Simple estimation
 	}
 
-	// Check for timeout
-	if ctx.Err() == context.DeadlineExceeded {
-		result.ActualOutput = "timeout"
-		result.Passed = false
+	// Check for timeout, either of the container or of the program inside it
+	var exitErr *exec.ExitError
+	if ctx.Err() == context.DeadlineExceeded || (errors.As(err, &exitErr) && exitErr.ExitCode() == timeoutExitCode) {
+		result.Output = "timeout"
+		result.Status = models.StatusTimeLimitExceeded
 		return result, nil
 	}
 
 	// Check for execution errors
 	if err != nil {
-		result.ActualOutput = fmt.Sprintf("error: %v", err)
-		result.Passed = false
+		result.Output = fmt.Sprintf("error: %v", err)
+		result.Status = models.StatusRuntimeError
 		return result, nil
 	}
 
-	// Compare outputs
-	result.Passed = result.ExpectedOutput == result.ActualOutput
-
 	return result, nil
 }
 
+// failureMessage describes a failed test case. The outputs are cut to previews, since the
+// message is stored with the submission and test data may be large.
+func failureMessage(testResult *TestResult) string {
+	return fmt.Sprintf("Test case failed: expected %s, got %s", outputPreview(testResult.ExpectedOutput), outputPreview(testResult.ActualOutput))
+}
+
+// outputPreview returns the preview of an output, marked when the output was cut
+func outputPreview(output string) string {
+	preview := models.TestDataPreview(output)
+	if len(preview) < len(output) {
+		preview += "..."
+	}
+	return preview
+}
+
+// classifyTestResult determines the verdict of a single executed test case
+func (es *ExecutionService) classifyTestResult(testResult *TestResult) string {
+	if testResult.Passed {
+		return models.StatusAccepted
+	}
+
+	if strings.Contains(testResult.ActualOutput, "timeout") {
+		return models.StatusTimeLimitExceeded
+	} else if strings.Contains(testResult.ActualOutput, "memory") {
+		return models.StatusMemoryLimitExceeded
+	} else if strings.Contains(testResult.ActualOutput, "error") || strings.Contains(testResult.ActualOutput, "Error") {
+		return models.StatusRuntimeError
+	}
+	return models.StatusWrongAnswer
+}
+
 // buildDockerCommand constructs the Docker command for code execution
-func (es *ExecutionService) buildDockerCommand(execDir, codeFile, language string) []string {
+func (es *ExecutionService) buildDockerCommand(execDir, codeFile, language string, settings *models.JudgeSettings) []string {
+	timeout := strconv.FormatFloat(float64(settings.TimeLimitMs)/1000, 'f', -1, 64) + "s"
+
 	baseCmd := []string{
 		"run",
 		"--rm",
 		"--network=none",                            // No network access
 		"--read-only",                               // Read-only filesystem
 		"--tmpfs", "/tmp:rw,noexec,nosuid,size=10m", // Limited temp space
-		fmt.Sprintf("--memory=%dm", es.memoryLimitMB), // Memory limit
-		fmt.Sprintf("--cpus=0.5"),                     // CPU limit
-		"--user", "nobody",                            // Run as nobody user
+		fmt.Sprintf("--memory=%dm", settings.MemoryLimitMb), // Memory limit
+		fmt.Sprintf("--cpus=0.5"),                           // CPU limit
+		"--user", "nobody",                                  // Run as nobody user
 		"-v", fmt.Sprintf("%s:/workspace:ro", execDir), // Mount code directory as read-only
 		"-w", "/workspace",
 	}
 
 	switch language {
 	case models.LanguageJavaScript:
-		return append(baseCmd, "node:18-alpine", "timeout", fmt.Sprintf("%ds", es.timeoutSeconds), "node", filepath.Base(codeFile))
+		return append(baseCmd, "node:18-alpine", "timeout", timeout, "node", filepath.Base(codeFile))
 	case models.LanguagePython:
-		return append(baseCmd, "python:3.11-alpine", "timeout", fmt.Sprintf("%ds", es.timeoutSeconds), "python3", filepath.Base(codeFile))
+		return append(baseCmd, "python:3.11-alpine", "timeout", timeout, "python3", filepath.Base(codeFile))
 	case models.LanguageJava:
 		className := strings.TrimSuffix(filepath.Base(codeFile), ".java")
 		return append(baseCmd, "openjdk:17-alpine", "sh", "-c",
-			fmt.Sprintf("javac %s && timeout %ds java %s", filepath.Base(codeFile), es.timeoutSeconds, className))
+			fmt.Sprintf("javac %s && timeout %s java %s", filepath.Base(codeFile), timeout, className))
 	default:
 		return append(baseCmd, "alpine:latest", "echo", "Unsupported language")
 	}
@@ -274,7 +397,7 @@ func (es *ExecutionService) validateCode(code, language string) error {
 	}
 
 	// Check code length (prevent extremely large submissions)
-	if len(code) > 50000 { // 50KB limit
+	if len(code) > MaxCodeLength {
 		return fmt.Errorf("code exceeds maximum length limit")
 	}
 
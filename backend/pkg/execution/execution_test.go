@@ -1,7 +1,9 @@
 package execution
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"leetcode-clone-backend/pkg/models"
 )
@@ -172,7 +174,7 @@ func TestExecutionService_BuildDockerCommand(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cmd := es.buildDockerCommand(execDir, codeFile, tt.language)
+			cmd := es.buildDockerCommand(execDir, codeFile, tt.language, models.DefaultJudgeSettings(1))
 
 			// Check that all expected security flags are present
 			for _, expected := range tt.expected {
@@ -192,6 +194,58 @@ func TestExecutionService_BuildDockerCommand(t *testing.T) {
 	}
 }
 
+func TestExecutionService_ClassifyTestResult(t *testing.T) {
+	es := NewExecutionService()
+
+	tests := []struct {
+		name     string
+		result   TestResult
+		expected string
+	}{
+		{"passed", TestResult{Passed: true, ActualOutput: "42"}, models.StatusAccepted},
+		{"timeout", TestResult{ActualOutput: "timeout"}, models.StatusTimeLimitExceeded},
+		{"memory", TestResult{ActualOutput: "out of memory"}, models.StatusMemoryLimitExceeded},
+		{"runtime error", TestResult{ActualOutput: "Runtime Error: boom"}, models.StatusRuntimeError},
+		{"wrong answer", TestResult{ActualOutput: "41"}, models.StatusWrongAnswer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := es.classifyTestResult(&tt.result); got != tt.expected {
+				t.Errorf("ExecutionService.classifyTestResult() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFailureMessage(t *testing.T) {
+	got := failureMessage(&TestResult{ExpectedOutput: "2", ActualOutput: "3"})
+	if got != "Test case failed: expected 2, got 3" {
+		t.Errorf("failureMessage() = %q, want the full outputs", got)
+	}
+
+	large := strings.Repeat("7", models.TestDataPreviewLength*100)
+	got = failureMessage(&TestResult{ExpectedOutput: large, ActualOutput: large + "8"})
+	preview := models.TestDataPreview(large) + "..."
+	if got != "Test case failed: expected "+preview+", got "+preview {
+		t.Errorf("failureMessage() of large outputs has length %d, want previews of both", len(got))
+	}
+}
+
+func TestExecutionResult_SandboxTime(t *testing.T) {
+	result := &ExecutionResult{
+		RuntimeMs:   150,
+		TestResults: []TestResult{{RuntimeMs: 100}, {RuntimeMs: 200}},
+	}
+	if got := result.SandboxTime(); got != 300*time.Millisecond {
+		t.Errorf("ExecutionResult.SandboxTime() = %v, want 300ms", got)
+	}
+
+	if got := (&ExecutionResult{}).SandboxTime(); got != 0 {
+		t.Errorf("ExecutionResult.SandboxTime() of no runs = %v, want 0", got)
+	}
+}
+
 // Helper functions for tests
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && (s[:len(substr)] == substr || s[len(s)-len(substr):] == substr || containsSubstring(s, substr)))
@@ -214,3 +268,50 @@ func containsString(slice []string, item string) bool {
 	}
 	return false
 }
+
+func TestOutputMatches(t *testing.T) {
+	exact := models.DefaultJudgeSettings(1)
+	tokens := &models.JudgeSettings{Checker: models.CheckerTokens}
+	ignoreCase := &models.JudgeSettings{Checker: models.CheckerTokens, IgnoreCase: true}
+	float := &models.JudgeSettings{Checker: models.CheckerTokens, FloatTolerance: 1e-6}
+
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		settings *models.JudgeSettings
+		want     bool
+	}{
+		{"exact match", "1 2\n3", "1 2\n3", exact, true},
+		{"exact rejects changed spacing", "1 2\n3", "1  2 3", exact, false},
+		{"tokens ignore spacing", "1 2\n3", "1  2 3", tokens, true},
+		{"tokens compare count", "1 2 3", "1 2", tokens, false},
+		{"tokens are case sensitive", "YES", "yes", tokens, false},
+		{"tokens ignoring case", "YES", "yes", ignoreCase, true},
+		{"absolute tolerance", "0.5", "0.5000004", float, true},
+		{"relative tolerance", "1000000", "1000000.5", float, true},
+		{"outside tolerance", "0.5", "0.51", float, false},
+		{"tolerance needs numbers", "abc", "abd", float, false},
+		{"NaN is never close", "1", "NaN", float, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := outputMatches(tt.expected, tt.actual, tt.settings); got != tt.want {
+				t.Errorf("outputMatches(%q, %q) = %v, want %v", tt.expected, tt.actual, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputsMatch(t *testing.T) {
+	if !OutputsMatch("3\n", " 3", nil) {
+		t.Error("Expected outputs compared after trimming with the default checker")
+	}
+	if OutputsMatch("1 2", "1  2", nil) {
+		t.Error("Expected the default checker to compare exactly")
+	}
+	if !OutputsMatch("1 2", "1  2", &models.JudgeSettings{Checker: models.CheckerTokens}) {
+		t.Error("Expected the tokens checker to ignore spacing")
+	}
+}
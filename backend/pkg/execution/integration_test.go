@@ -55,7 +55,7 @@ func TestExecutionService_Integration(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := es.ExecuteCode(tt.code, tt.language, testCases)
+			result, err := es.ExecuteCode(tt.code, tt.language, testCases, nil)
 			if err != nil {
 				t.Fatalf("ExecuteCode() error = %v", err)
 			}
@@ -71,6 +71,32 @@ func TestExecutionService_Integration(t *testing.T) {
 	}
 }
 
+// TestExecutionService_RunCode tests running a program on several inputs with actual Docker
+func TestExecutionService_RunCode(t *testing.T) {
+	// Check if Docker is available
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("Docker not available, skipping integration test")
+	}
+
+	es := NewExecutionService()
+
+	code := "def solution(input_data):\n    a, b = map(int, input_data.split())\n    return a + b"
+	results, err := es.RunCode(code, models.LanguagePython, []string{"1 2", "x", "20 22"}, nil)
+	if err != nil {
+		t.Fatalf("RunCode() error = %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("Expected a result for every input, got %d", len(results))
+	}
+	if results[0].Output != "3" || results[2].Output != "42" {
+		t.Errorf("Expected outputs 3 and 42, got %q and %q", results[0].Output, results[2].Output)
+	}
+	if results[0].Status != models.StatusAccepted {
+		t.Errorf("Expected status %s, got %s", models.StatusAccepted, results[0].Status)
+	}
+}
+
 // TestExecutionService_SecurityValidation tests security measures
 func TestExecutionService_SecurityValidation(t *testing.T) {
 	// Check if Docker is available
@@ -346,10 +346,30 @@ func AuthMiddleware(authService *auth.AuthService) gin.HandlerFunc {
 		}
 
 		// Set user information in context
+		c.Set("user", claims)
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("is_admin", claims.IsAdmin)
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// OptionalAuthMiddleware sets the user in the context if the request carries a valid JWT, and
+// lets the request through without a user otherwise. It is used by public routes that show
+// more information to signed-in users.
+func OptionalAuthMiddleware(authService *auth.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenParts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(tokenParts) == 2 && tokenParts[0] == "Bearer" {
+			if claims, err := authService.ValidateToken(tokenParts[1]); err == nil {
+				c.Set("user", claims)
+				c.Set("user_id", claims.UserID)
+				c.Set("username", claims.Username)
+				c.Set("is_admin", claims.IsAdmin)
+			}
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"leetcode-clone-backend/pkg/auth"
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+	"leetcode-clone-backend/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DraftHandlers handles HTTP requests for autosaved code drafts
+type DraftHandlers struct {
+	draftService services.DraftServiceInterface
+}
+
+// NewDraftHandlers creates a new draft handlers instance
+func NewDraftHandlers(draftService services.DraftServiceInterface) *DraftHandlers {
+	return &DraftHandlers{
+		draftService: draftService,
+	}
+}
+
+// SaveDraftRequest represents the request payload for saving a draft
+type SaveDraftRequest struct {
+	Code    string `json:"code"`
+	Version *int   `json:"version"` // Used when no If-Match header is sent
+}
+
+// GetDraft handles GET /api/v1/problems/:id/draft?language=
+func (h *DraftHandlers) GetDraft(c *gin.Context) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	problemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid problem ID",
+			"details": "Problem ID must be a valid integer",
+		})
+		return
+	}
+
+	draft, err := h.draftService.GetDraft(user.UserID, problemID, c.Query("language"))
+	if err != nil {
+		h.handleError(c, err, "Failed to retrieve draft")
+		return
+	}
+
+	setDraftETag(c, draft)
+	c.JSON(http.StatusOK, draft)
+}
+
+// SaveDraft handles PUT /api/v1/problems/:id/draft?language=
+func (h *DraftHandlers) SaveDraft(c *gin.Context) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	problemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid problem ID",
+			"details": "Problem ID must be a valid integer",
+		})
+		return
+	}
+
+	var req SaveDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// The If-Match header takes precedence over the version in the body
+	expectedVersion := 0
+	if req.Version != nil {
+		expectedVersion = *req.Version
+	}
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		expectedVersion, err = parseDraftETag(ifMatch)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid If-Match header",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	language := c.Query("language")
+	draft, err := h.draftService.SaveDraft(user.UserID, problemID, language, req.Code, expectedVersion)
+	if err != nil {
+		if repository.IsConflict(err) {
+			// Return the stored draft so the client can resolve the conflict
+			response := gin.H{
+				"error":   "Draft was modified",
+				"details": "The draft was saved from somewhere else; reload it and retry with its version",
+			}
+			if current, getErr := h.draftService.GetDraft(user.UserID, problemID, language); getErr == nil {
+				setDraftETag(c, current)
+				response["draft"] = current
+			}
+			c.JSON(http.StatusConflict, response)
+			return
+		}
+		h.handleError(c, err, "Failed to save draft")
+		return
+	}
+
+	setDraftETag(c, draft)
+	c.JSON(http.StatusOK, draft)
+}
+
+// ListDrafts handles GET /api/v1/drafts
+func (h *DraftHandlers) ListDrafts(c *gin.Context) {
+	user, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	drafts, err := h.draftService.ListDrafts(user.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list drafts",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"drafts": drafts})
+}
+
+// handleError maps draft service errors to HTTP responses
+func (h *DraftHandlers) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case strings.Contains(err.Error(), "invalid draft"):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid draft",
+			"details": err.Error(),
+		})
+	case repository.IsNotFound(err):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Problem not found",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
+
+// requireUser returns the authenticated user, responding with 401 if there is none
+func requireUser(c *gin.Context) (*auth.Claims, bool) {
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil, false
+	}
+
+	user, ok := userInterface.(*auth.Claims)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user context"})
+		return nil, false
+	}
+
+	return user, true
+}
+
+// setDraftETag sets the ETag header to the version of a saved draft
+func setDraftETag(c *gin.Context, draft *models.CodeDraft) {
+	if draft.Version > 0 {
+		c.Header("ETag", fmt.Sprintf(`"%d"`, draft.Version))
+	}
+}
+
+// parseDraftETag parses a draft version from an ETag such as "3" or W/"3"
+func parseDraftETag(value string) (int, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+	value = strings.Trim(value, `"`)
+
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("expected a draft version such as \"3\"")
+	}
+
+	return version, nil
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"leetcode-clone-backend/pkg/auth"
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock draft service
+type MockDraftService struct {
+	mock.Mock
+}
+
+func (m *MockDraftService) GetDraft(userID, problemID int, language string) (*models.CodeDraft, error) {
+	args := m.Called(userID, problemID, language)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CodeDraft), args.Error(1)
+}
+
+func (m *MockDraftService) SaveDraft(userID, problemID int, language, code string, expectedVersion int) (*models.CodeDraft, error) {
+	args := m.Called(userID, problemID, language, code, expectedVersion)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CodeDraft), args.Error(1)
+}
+
+func (m *MockDraftService) ListDrafts(userID int) ([]*models.CodeDraft, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.CodeDraft), args.Error(1)
+}
+
+func setupDraftTestRouter() (*gin.Engine, *MockDraftService) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockService := new(MockDraftService)
+	handler := NewDraftHandlers(mockService)
+
+	router.Use(func(c *gin.Context) {
+		c.Set("user", &auth.Claims{UserID: 1, Username: "testuser"})
+		c.Next()
+	})
+
+	router.GET("/api/v1/problems/:id/draft", handler.GetDraft)
+	router.PUT("/api/v1/problems/:id/draft", handler.SaveDraft)
+	router.GET("/api/v1/drafts", handler.ListDrafts)
+
+	return router, mockService
+}
+
+func TestDraftHandlers_GetDraft(t *testing.T) {
+	router, mockService := setupDraftTestRouter()
+
+	t.Run("saved draft sets ETag", func(t *testing.T) {
+		draft := &models.CodeDraft{UserID: 1, ProblemID: 2, Language: models.LanguagePython, Code: "print(1)", Version: 3}
+		mockService.On("GetDraft", 1, 2, models.LanguagePython).Return(draft, nil).Once()
+
+		req, _ := http.NewRequest("GET", "/api/v1/problems/2/draft?language=python", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	})
+
+	t.Run("template fallback has no ETag", func(t *testing.T) {
+		draft := &models.CodeDraft{UserID: 1, ProblemID: 2, Language: models.LanguageJava, Code: "class Solution {}", FromTemplate: true}
+		mockService.On("GetDraft", 1, 2, models.LanguageJava).Return(draft, nil).Once()
+
+		req, _ := http.NewRequest("GET", "/api/v1/problems/2/draft?language=java", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("ETag"))
+
+		var response models.CodeDraft
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.True(t, response.FromTemplate)
+	})
+
+	t.Run("invalid language", func(t *testing.T) {
+		mockService.On("GetDraft", 1, 2, "ruby").Return(nil, errors.New("invalid draft: unsupported language: ruby")).Once()
+
+		req, _ := http.NewRequest("GET", "/api/v1/problems/2/draft?language=ruby", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	mockService.AssertExpectations(t)
+}
+
+func TestDraftHandlers_SaveDraft(t *testing.T) {
+	router, mockService := setupDraftTestRouter()
+
+	t.Run("If-Match header", func(t *testing.T) {
+		saved := &models.CodeDraft{UserID: 1, ProblemID: 2, Language: models.LanguagePython, Code: "print(2)", Version: 4}
+		mockService.On("SaveDraft", 1, 2, models.LanguagePython, "print(2)", 3).Return(saved, nil).Once()
+
+		body, _ := json.Marshal(SaveDraftRequest{Code: "print(2)"})
+		req, _ := http.NewRequest("PUT", "/api/v1/problems/2/draft?language=python", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"3"`)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+	})
+
+	t.Run("version in body", func(t *testing.T) {
+		version := 0
+		saved := &models.CodeDraft{UserID: 1, ProblemID: 2, Language: models.LanguagePython, Code: "print(3)", Version: 1}
+		mockService.On("SaveDraft", 1, 2, models.LanguagePython, "print(3)", 0).Return(saved, nil).Once()
+
+		body, _ := json.Marshal(SaveDraftRequest{Code: "print(3)", Version: &version})
+		req, _ := http.NewRequest("PUT", "/api/v1/problems/2/draft?language=python", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("version conflict returns current draft", func(t *testing.T) {
+		current := &models.CodeDraft{UserID: 1, ProblemID: 2, Language: models.LanguagePython, Code: "print(4)", Version: 5}
+		mockService.On("SaveDraft", 1, 2, models.LanguagePython, "print(2)", 3).
+			Return(nil, repository.NewRepositoryError("Save", repository.ErrConflict, "draft_version_conflict")).Once()
+		mockService.On("GetDraft", 1, 2, models.LanguagePython).Return(current, nil).Once()
+
+		body, _ := json.Marshal(SaveDraftRequest{Code: "print(2)"})
+		req, _ := http.NewRequest("PUT", "/api/v1/problems/2/draft?language=python", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `W/"3"`)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, `"5"`, w.Header().Get("ETag"))
+		assert.Contains(t, w.Body.String(), "print(4)")
+	})
+
+	t.Run("invalid If-Match header", func(t *testing.T) {
+		body, _ := json.Marshal(SaveDraftRequest{Code: "print(2)"})
+		req, _ := http.NewRequest("PUT", "/api/v1/problems/2/draft?language=python", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", "abc")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	mockService.AssertExpectations(t)
+}
+
+func TestDraftHandlers_ListDrafts(t *testing.T) {
+	router, mockService := setupDraftTestRouter()
+
+	drafts := []*models.CodeDraft{
+		{UserID: 1, ProblemID: 2, Language: models.LanguagePython, Code: "print(1)", Version: 1, Problem: &models.SubmissionProblem{ID: 2, Title: "Two Sum", Difficulty: models.DifficultyEasy}},
+	}
+	mockService.On("ListDrafts", 1).Return(drafts, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/api/v1/drafts", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string][]*models.CodeDraft
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response["drafts"], 1)
+	assert.Equal(t, "Two Sum", response["drafts"][0].Problem.Title)
+	mockService.AssertExpectations(t)
+}
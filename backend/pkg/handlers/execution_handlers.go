@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"leetcode-clone-backend/pkg/execution"
+	"leetcode-clone-backend/pkg/middleware"
 	"leetcode-clone-backend/pkg/models"
 	"leetcode-clone-backend/pkg/repository"
 
@@ -12,15 +13,19 @@ import (
 
 // ExecutionHandlers handles code execution related HTTP requests
 type ExecutionHandlers struct {
-	executionService *execution.ExecutionService
-	testCaseRepo     repository.TestCaseRepository
+	executionService  *execution.ExecutionService
+	problemRepo       repository.ProblemRepository
+	testCaseRepo      repository.TestCaseRepository
+	judgeSettingsRepo repository.JudgeSettingsRepository
 }
 
 // NewExecutionHandlers creates a new execution handlers instance
-func NewExecutionHandlers(executionService *execution.ExecutionService, testCaseRepo repository.TestCaseRepository) *ExecutionHandlers {
+func NewExecutionHandlers(executionService *execution.ExecutionService, problemRepo repository.ProblemRepository, testCaseRepo repository.TestCaseRepository, judgeSettingsRepo repository.JudgeSettingsRepository) *ExecutionHandlers {
 	return &ExecutionHandlers{
-		executionService: executionService,
-		testCaseRepo:     testCaseRepo,
+		executionService:  executionService,
+		problemRepo:       problemRepo,
+		testCaseRepo:      testCaseRepo,
+		judgeSettingsRepo: judgeSettingsRepo,
 	}
 }
 
@@ -39,6 +44,10 @@ func (eh *ExecutionHandlers) RunCode(c *gin.Context) {
 		return
 	}
 
+	if !eh.publishedProblem(c, req.ProblemID) {
+		return
+	}
+
 	// Get public test cases for the problem
 	testCases, err := eh.testCaseRepo.GetByProblemID(req.ProblemID)
 	if err != nil {
@@ -59,8 +68,17 @@ func (eh *ExecutionHandlers) RunCode(c *gin.Context) {
 		return
 	}
 
+	settings, err := eh.judgeSettingsRepo.GetByProblemID(req.ProblemID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve judging settings"})
+		return
+	}
+
 	// Execute code
-	result, err := eh.executionService.ExecuteCode(req.Code, req.Language, publicTestCases)
+	result, err := eh.executionService.ExecuteCode(req.Code, req.Language, publicTestCases, settings)
+	if result != nil {
+		middleware.RecordSandboxTime(c, result.SandboxTime())
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Code execution failed"})
 		return
@@ -77,6 +95,10 @@ func (eh *ExecutionHandlers) SubmitCode(c *gin.Context) {
 		return
 	}
 
+	if !eh.publishedProblem(c, req.ProblemID) {
+		return
+	}
+
 	// Get all test cases for the problem (including hidden ones)
 	testCases, err := eh.testCaseRepo.GetByProblemID(req.ProblemID)
 	if err != nil {
@@ -95,8 +117,17 @@ func (eh *ExecutionHandlers) SubmitCode(c *gin.Context) {
 		allTestCases[i] = *tc
 	}
 
+	settings, err := eh.judgeSettingsRepo.GetByProblemID(req.ProblemID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve judging settings"})
+		return
+	}
+
 	// Execute code against all test cases
-	result, err := eh.executionService.ExecuteCode(req.Code, req.Language, allTestCases)
+	result, err := eh.executionService.ExecuteCode(req.Code, req.Language, allTestCases, settings)
+	if result != nil {
+		middleware.RecordSandboxTime(c, result.SandboxTime())
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Code execution failed"})
 		return
@@ -181,3 +212,20 @@ func (eh *ExecutionHandlers) GetSupportedLanguages(c *gin.Context) {
 		"languages": languages,
 	})
 }
+
+// publishedProblem responds with 404 Not Found and returns false unless the problem is published,
+// so that code cannot be judged against the tests of unreleased problems
+func (eh *ExecutionHandlers) publishedProblem(c *gin.Context, problemID int) bool {
+	problem, err := eh.problemRepo.GetByID(problemID)
+	if err == nil && problem.State == models.ProblemStatePublished {
+		return true
+	}
+
+	if err == nil || repository.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return false
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve problem"})
+	return false
+}
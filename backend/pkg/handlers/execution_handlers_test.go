@@ -32,6 +32,18 @@ func (m *MockTestCaseRepository) GetPublicByProblemID(problemID int) ([]*models.
 	return publicTestCases, nil
 }
 
+func (m *MockTestCaseRepository) GetInlineByProblemID(problemID int) ([]*models.TestCase, error) {
+	return m.testCases, nil
+}
+
+func (m *MockTestCaseRepository) ListByProblemID(problemID int) ([]*models.TestCase, error) {
+	return m.testCases, nil
+}
+
+func (m *MockTestCaseRepository) ListPublicByProblemID(problemID int) ([]*models.TestCase, error) {
+	return m.GetPublicByProblemID(problemID)
+}
+
 func (m *MockTestCaseRepository) Create(testCase *models.TestCase) (*models.TestCase, error) {
 	return testCase, nil
 }
@@ -40,6 +52,14 @@ func (m *MockTestCaseRepository) Update(testCase *models.TestCase) (*models.Test
 	return testCase, nil
 }
 
+func (m *MockTestCaseRepository) SetOutputStatus(id int, status string) error {
+	return nil
+}
+
+func (m *MockTestCaseRepository) ResetOutputStatus(problemID int) error {
+	return nil
+}
+
 func (m *MockTestCaseRepository) Delete(id int) error {
 	return nil
 }
@@ -52,12 +72,21 @@ func (m *MockTestCaseRepository) GetByID(id int) (*models.TestCase, error) {
 	return nil, nil
 }
 
+// newExecutionProblemRepo returns a problem repository with published problem 1 and draft
+// problem 2
+func newExecutionProblemRepo() *mockProblemRepo {
+	problems := newMockProblemRepo()
+	problems.problems[1] = &models.Problem{ID: 1, State: models.ProblemStatePublished}
+	problems.problems[2] = &models.Problem{ID: 2, State: models.ProblemStateDraft}
+	return problems
+}
+
 func TestExecutionHandlers_ValidateCode(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	executionService := execution.NewExecutionService()
 	mockRepo := &MockTestCaseRepository{}
-	handler := NewExecutionHandlers(executionService, mockRepo)
+	handler := NewExecutionHandlers(executionService, newExecutionProblemRepo(), mockRepo, newMockJudgeSettingsRepo())
 
 	tests := []struct {
 		name           string
@@ -131,7 +160,7 @@ func TestExecutionHandlers_GetSupportedLanguages(t *testing.T) {
 
 	executionService := execution.NewExecutionService()
 	mockRepo := &MockTestCaseRepository{}
-	handler := NewExecutionHandlers(executionService, mockRepo)
+	handler := NewExecutionHandlers(executionService, newExecutionProblemRepo(), mockRepo, newMockJudgeSettingsRepo())
 
 	// Create request
 	req, _ := http.NewRequest("GET", "/languages", nil)
@@ -198,7 +227,7 @@ func TestExecutionHandlers_RunCode(t *testing.T) {
 		},
 	}
 
-	handler := NewExecutionHandlers(executionService, mockRepo)
+	handler := NewExecutionHandlers(executionService, newExecutionProblemRepo(), mockRepo, newMockJudgeSettingsRepo())
 
 	tests := []struct {
 		name           string
@@ -222,6 +251,15 @@ func TestExecutionHandlers_RunCode(t *testing.T) {
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "Unpublished problem",
+			requestBody: map[string]interface{}{
+				"code":       "function solution(input) { return 'test output'; }",
+				"language":   models.LanguageJavaScript,
+				"problem_id": 2,
+			},
+			expectedStatus: http.StatusNotFound,
+		},
 	}
 
 	for _, tt := range tests {
@@ -248,3 +286,30 @@ func TestExecutionHandlers_RunCode(t *testing.T) {
 		})
 	}
 }
+
+func TestExecutionHandlers_SubmitCode_UnpublishedProblem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := &MockTestCaseRepository{
+		testCases: []*models.TestCase{{ID: 1, ProblemID: 2, Input: "in", ExpectedOutput: "out", IsHidden: true}},
+	}
+	handler := NewExecutionHandlers(execution.NewExecutionService(), newExecutionProblemRepo(), mockRepo, newMockJudgeSettingsRepo())
+
+	jsonBody, _ := json.Marshal(map[string]interface{}{
+		"code":       "function solution(input) { return 'out'; }",
+		"language":   models.LanguageJavaScript,
+		"problem_id": 2,
+	})
+	req, _ := http.NewRequest("POST", "/submit", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.SubmitCode(c)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"leetcode-clone-backend/pkg/models"
+)
+
+// UpdateJudgeSettingsRequest is the request body of UpdateJudgeSettings
+type UpdateJudgeSettingsRequest struct {
+	TimeLimitMs    int                   `json:"time_limit_ms" binding:"required"`
+	MemoryLimitMb  int                   `json:"memory_limit_mb" binding:"required"`
+	LanguageLimits models.LanguageLimits `json:"language_limits"`
+	Checker        string                `json:"checker" binding:"required"`
+	IgnoreCase     bool                  `json:"ignore_case"`
+	FloatTolerance float64               `json:"float_tolerance"`
+}
+
+// GetJudgeSettings handles GET /api/v1/admin/problems/:id/judge-settings
+func (h *ProblemHandlers) GetJudgeSettings(c *gin.Context) {
+	id, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+
+	settings, err := h.problemService.GetJudgeSettings(id)
+	if err != nil {
+		handleJudgeSettingsError(c, err, "Failed to get judging settings")
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateJudgeSettings handles PUT /api/v1/admin/problems/:id/judge-settings
+func (h *ProblemHandlers) UpdateJudgeSettings(c *gin.Context) {
+	id, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateJudgeSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	settings, err := h.problemService.UpdateJudgeSettings(&models.JudgeSettings{
+		ProblemID:      id,
+		TimeLimitMs:    req.TimeLimitMs,
+		MemoryLimitMb:  req.MemoryLimitMb,
+		LanguageLimits: req.LanguageLimits,
+		Checker:        req.Checker,
+		IgnoreCase:     req.IgnoreCase,
+		FloatTolerance: req.FloatTolerance,
+	})
+	if err != nil {
+		handleJudgeSettingsError(c, err, "Failed to update judging settings")
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// CalibrateLimits handles POST /api/v1/admin/problems/:id/judge-settings/calibrate. The optional
+// runs query parameter sets how many times each reference solution runs on every test case.
+func (h *ProblemHandlers) CalibrateLimits(c *gin.Context) {
+	id, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+
+	runs := 0
+	if value := c.Query("runs"); value != "" {
+		var err error
+		if runs, err = strconv.Atoi(value); err != nil || runs < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid runs",
+				"details": "runs must be a positive integer",
+			})
+			return
+		}
+	}
+
+	report, err := h.problemService.CalibrateLimits(id, runs)
+	if err != nil {
+		handleReferenceSolutionError(c, err, "Failed to calibrate limits")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// handleJudgeSettingsError maps a judging settings service error to an HTTP response
+func handleJudgeSettingsError(c *gin.Context, err error, message string) {
+	switch {
+	case strings.Contains(err.Error(), "validation failed"):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid judging settings",
+			"details": err.Error(),
+		})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Problem not found",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+	"leetcode-clone-backend/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type mockJudgeSettingsRepo struct {
+	settings map[int]*models.JudgeSettings
+}
+
+func newMockJudgeSettingsRepo() *mockJudgeSettingsRepo {
+	return &mockJudgeSettingsRepo{settings: make(map[int]*models.JudgeSettings)}
+}
+
+func (m *mockJudgeSettingsRepo) GetByProblemID(problemID int) (*models.JudgeSettings, error) {
+	if settings, exists := m.settings[problemID]; exists {
+		return settings, nil
+	}
+	return models.DefaultJudgeSettings(problemID), nil
+}
+
+func (m *mockJudgeSettingsRepo) Upsert(settings *models.JudgeSettings) (*models.JudgeSettings, error) {
+	now := time.Now()
+	saved := *settings
+	saved.UpdatedAt = &now
+	m.settings[settings.ProblemID] = &saved
+	return &saved, nil
+}
+
+func TestProblemHandlers_JudgeSettings(t *testing.T) {
+	router, handler := setupTestRouter()
+	router.GET("/admin/problems/:id/judge-settings", handler.GetJudgeSettings)
+	router.PUT("/admin/problems/:id/judge-settings", handler.UpdateJudgeSettings)
+	problem := createDraftProblem(t, handler, "Judged Problem")
+
+	req, _ := http.NewRequest("GET", "/admin/problems/1/judge-settings", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var settings models.JudgeSettings
+	json.Unmarshal(w.Body.Bytes(), &settings)
+	if w.Code != http.StatusOK || settings.TimeLimitMs != models.DefaultTimeLimitMs || settings.Checker != models.CheckerExact {
+		t.Fatalf("Expected the default settings, got %d: %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		body           map[string]interface{}
+		expectedStatus int
+	}{
+		{
+			name:           "tokens checker",
+			path:           "/admin/problems/1/judge-settings",
+			body:           map[string]interface{}{"time_limit_ms": 2000, "memory_limit_mb": 256, "checker": "tokens", "float_tolerance": 1e-6},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid language limits",
+			path:           "/admin/problems/1/judge-settings",
+			body:           map[string]interface{}{"time_limit_ms": 2000, "memory_limit_mb": 256, "checker": "exact", "language_limits": map[string]interface{}{"java": map[string]int{"time_limit_ms": 20}}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid checker",
+			path:           "/admin/problems/1/judge-settings",
+			body:           map[string]interface{}{"time_limit_ms": 2000, "memory_limit_mb": 256, "checker": "testlib"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing limits",
+			path:           "/admin/problems/1/judge-settings",
+			body:           map[string]interface{}{"checker": "exact"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing problem",
+			path:           "/admin/problems/99/judge-settings",
+			body:           map[string]interface{}{"time_limit_ms": 2000, "memory_limit_mb": 256, "checker": "exact"},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.body)
+			req, _ := http.NewRequest("PUT", tt.path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	stored, _ := handler.problemService.GetJudgeSettings(problem.ID)
+	if stored.TimeLimitMs != 2000 || stored.Checker != models.CheckerTokens {
+		t.Errorf("Expected the valid settings stored, got %+v", stored)
+	}
+}
+
+func TestProblemHandlers_CalibrateLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	problemService := services.NewProblemService(&repository.Repository{
+		Problem:         newMockProblemRepo(),
+		TestCase:        newMockTestCaseRepo(),
+		ProblemRevision: newMockProblemRevisionRepo(),
+		ProblemSolution: newMockProblemSolutionRepo(),
+		JudgeSettings:   newMockJudgeSettingsRepo(),
+		TestProgram:     newMockTestProgramRepo(),
+	}, echoRunner{})
+	handler := NewProblemHandlers(problemService)
+
+	router := gin.New()
+	router.POST("/admin/problems/:id/judge-settings/calibrate", handler.CalibrateLimits)
+
+	problem := createDraftProblem(t, handler, "Calibrated")
+	problemService.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "hello", ExpectedOutput: "hello"}, 1)
+	path := fmt.Sprintf("/admin/problems/%d/judge-settings/calibrate", problem.ID)
+
+	calibrate := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := calibrate(path); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "no reference solutions") {
+		t.Errorf("Expected a problem without reference solutions rejected, got %d: %s", w.Code, w.Body.String())
+	}
+
+	problemService.ReplaceSolutions(problem.ID, []*models.ProblemSolution{{Name: "echo", Language: models.LanguagePython, Code: "echo"}})
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"default runs", path, http.StatusOK, `"suggested_settings"`},
+		{"runs", path + "?runs=1", http.StatusOK, `"runs":1`},
+		{"invalid runs", path + "?runs=abc", http.StatusBadRequest, "positive integer"},
+		{"too many runs", path + "?runs=9", http.StatusBadRequest, "runs must be between"},
+		{"missing problem", "/admin/problems/99/judge-settings/calibrate", http.StatusNotFound, "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := calibrate(tt.path)
+			if w.Code != tt.expectedStatus || !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("Expected status %d with %q, got %d: %s", tt.expectedStatus, tt.expectedBody, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	var report services.LimitCalibration
+	json.Unmarshal(calibrate(path).Body.Bytes(), &report)
+	if len(report.Languages) != 1 || report.Languages[0].Suggested == nil ||
+		report.SuggestedSettings.LanguageLimits[models.LanguagePython] != *report.Languages[0].Suggested {
+		t.Errorf("Expected a Python suggestion in the suggested settings, got %+v", report)
+	}
+}
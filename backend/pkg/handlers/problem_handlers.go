@@ -1,16 +1,22 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/pagination"
 	"leetcode-clone-backend/pkg/repository"
+	"leetcode-clone-backend/pkg/search"
 	"leetcode-clone-backend/pkg/services"
 )
 
+// defaultProblemPageSize is the number of problems listed per page unless the client asks for more
+const defaultProblemPageSize = 50
+
 // ProblemHandlers handles HTTP requests for problems
 type ProblemHandlers struct {
 	problemService *services.ProblemService
@@ -34,7 +40,7 @@ func (h *ProblemHandlers) CreateProblem(c *gin.Context) {
 		return
 	}
 
-	created, err := h.problemService.CreateProblem(&problem)
+	created, err := h.problemService.CreateProblem(&problem, adminUserID(c))
 	if err != nil {
 		if strings.Contains(err.Error(), "validation failed") {
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -72,7 +78,7 @@ func (h *ProblemHandlers) GetProblem(c *gin.Context) {
 		return
 	}
 
-	problem, err := h.problemService.GetProblem(id)
+	problem, err := h.problemService.GetProblem(id, optionalUserID(c))
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -102,7 +108,7 @@ func (h *ProblemHandlers) GetProblemBySlug(c *gin.Context) {
 		return
 	}
 
-	problem, err := h.problemService.GetProblemBySlug(slug)
+	problem, err := h.problemService.GetProblemBySlug(slug, optionalUserID(c))
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -145,7 +151,7 @@ func (h *ProblemHandlers) UpdateProblem(c *gin.Context) {
 	// Set the ID from the URL parameter
 	problem.ID = id
 
-	updated, err := h.problemService.UpdateProblem(&problem)
+	updated, err := h.problemService.UpdateProblem(&problem, adminUserID(c))
 	if err != nil {
 		if strings.Contains(err.Error(), "validation failed") {
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -209,9 +215,20 @@ func (h *ProblemHandlers) DeleteProblem(c *gin.Context) {
 	c.JSON(http.StatusNoContent, nil)
 }
 
-// ListProblems handles GET /problems
+// ListProblems handles GET /problems, listing published problems
 func (h *ProblemHandlers) ListProblems(c *gin.Context) {
-	filters := repository.ProblemFilters{}
+	h.listProblems(c, models.ProblemStatePublished)
+}
+
+// ListAllProblems handles GET /admin/problems, listing problems in every state unless the state
+// query parameter is set
+func (h *ProblemHandlers) ListAllProblems(c *gin.Context) {
+	h.listProblems(c, c.Query("state"))
+}
+
+// listProblems lists the problems in a lifecycle state, or in every state if state is empty
+func (h *ProblemHandlers) listProblems(c *gin.Context, state string) {
+	filters := repository.ProblemFilters{State: state}
 
 	// Parse difficulty filters
 	if difficultyStr := c.Query("difficulty"); difficultyStr != "" {
@@ -223,19 +240,18 @@ func (h *ProblemHandlers) ListProblems(c *gin.Context) {
 		filters.Tags = strings.Split(tagsStr, ",")
 	}
 
-	// Parse limit
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
-			filters.Limit = limit
-		}
-	}
-
-	// Parse offset
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil && offset >= 0 {
-			filters.Offset = offset
-		}
+	// Parse page, limit, offset and cursor
+	params, err := pagination.ParseQuery(c.Request.URL.Query(), defaultProblemPageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid pagination parameters",
+			"details": err.Error(),
+		})
+		return
 	}
+	filters.Limit = params.Limit
+	filters.Offset = params.Offset
+	filters.Cursor = params.Cursor
 
 	// Parse sort by
 	if sortBy := c.Query("sort_by"); sortBy != "" {
@@ -247,7 +263,11 @@ func (h *ProblemHandlers) ListProblems(c *gin.Context) {
 		filters.SortOrder = sortOrder
 	}
 
-	problems, err := h.problemService.ListProblems(filters)
+	// Parse solved status filter, which needs a signed-in user
+	filters.Status = c.Query("status")
+	filters.UserID = optionalUserID(c)
+
+	page, err := h.problemService.ListProblems(filters)
 	if err != nil {
 		if strings.Contains(err.Error(), "invalid filters") {
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -263,10 +283,7 @@ func (h *ProblemHandlers) ListProblems(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"problems": problems,
-		"count":    len(problems),
-	})
+	c.JSON(http.StatusOK, page)
 }
 
 // SearchProblems handles GET /problems/search
@@ -292,19 +309,18 @@ func (h *ProblemHandlers) SearchProblems(c *gin.Context) {
 		filters.Tags = strings.Split(tagsStr, ",")
 	}
 
-	// Parse limit
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
-			filters.Limit = limit
-		}
-	}
-
-	// Parse offset
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil && offset >= 0 {
-			filters.Offset = offset
-		}
+	// Parse page, limit, offset and cursor
+	params, err := pagination.ParseQuery(c.Request.URL.Query(), defaultProblemPageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid pagination parameters",
+			"details": err.Error(),
+		})
+		return
 	}
+	filters.Limit = params.Limit
+	filters.Offset = params.Offset
+	filters.Cursor = params.Cursor
 
 	// Parse sort by
 	if sortBy := c.Query("sort_by"); sortBy != "" {
@@ -316,9 +332,25 @@ func (h *ProblemHandlers) SearchProblems(c *gin.Context) {
 		filters.SortOrder = sortOrder
 	}
 
-	problems, err := h.problemService.SearchProblems(query, filters)
+	// Signed-in users can filter by their solved status
+	filters.UserID = optionalUserID(c)
+
+	// Only published problems can be found
+	filters.State = models.ProblemStatePublished
+
+	page, err := h.problemService.SearchProblems(query, filters)
 	if err != nil {
-		if strings.Contains(err.Error(), "invalid filters") || strings.Contains(err.Error(), "cannot be empty") {
+		var parseErr *search.ParseError
+		if errors.As(err, &parseErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":    "Invalid search query",
+				"details":  parseErr.Message,
+				"position": parseErr.Position,
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "invalid filters") || strings.Contains(err.Error(), "invalid search query") ||
+			strings.Contains(err.Error(), "cannot be empty") {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error":   "Invalid search parameters",
 				"details": err.Error(),
@@ -332,11 +364,10 @@ func (h *ProblemHandlers) SearchProblems(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"problems": problems,
-		"count":    len(problems),
-		"query":    query,
-	})
+	c.JSON(http.StatusOK, struct {
+		*services.ProblemSearchPage
+		Query string `json:"query"`
+	}{page, query})
 }
 
 // CreateTestCase handles POST /problems/:id/testcases
@@ -363,7 +394,7 @@ func (h *ProblemHandlers) CreateTestCase(c *gin.Context) {
 	// Set the problem ID from the URL parameter
 	testCase.ProblemID = problemID
 
-	created, err := h.problemService.CreateTestCase(&testCase)
+	created, err := h.problemService.CreateTestCase(&testCase, adminUserID(c))
 	if err != nil {
 		if strings.Contains(err.Error(), "validation failed") {
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -412,6 +443,13 @@ func (h *ProblemHandlers) GetTestCases(c *gin.Context) {
 	}
 
 	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Problem not found",
+				"details": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to get test cases",
 			"details": err.Error(),
@@ -449,7 +487,7 @@ func (h *ProblemHandlers) UpdateTestCase(c *gin.Context) {
 	// Set the ID from the URL parameter
 	testCase.ID = id
 
-	updated, err := h.problemService.UpdateTestCase(&testCase)
+	updated, err := h.problemService.UpdateTestCase(&testCase, adminUserID(c))
 	if err != nil {
 		if strings.Contains(err.Error(), "validation failed") {
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -487,7 +525,7 @@ func (h *ProblemHandlers) DeleteTestCase(c *gin.Context) {
 		return
 	}
 
-	err = h.problemService.DeleteTestCase(id)
+	err = h.problemService.DeleteTestCase(id, adminUserID(c))
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -5,12 +5,16 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"leetcode-clone-backend/pkg/auth"
 	"leetcode-clone-backend/pkg/models"
 	"leetcode-clone-backend/pkg/repository"
 	"leetcode-clone-backend/pkg/services"
+	"leetcode-clone-backend/pkg/storage"
 )
 
 // Mock repositories for testing handlers
@@ -27,6 +31,9 @@ func newMockProblemRepo() *mockProblemRepo {
 }
 
 func (m *mockProblemRepo) Create(problem *models.Problem) (*models.Problem, error) {
+	if problem.State == "" {
+		problem.State = models.ProblemStateDraft
+	}
 	problem.ID = m.nextID
 	m.nextID++
 	m.problems[problem.ID] = problem
@@ -46,13 +53,15 @@ func (m *mockProblemRepo) GetBySlug(slug string) (*models.Problem, error) {
 			return problem, nil
 		}
 	}
-	return nil, &mockError{message: "problem not found"}
+	return nil, repository.NewRepositoryError("GetBySlug", repository.ErrNotFound, "problem_not_found")
 }
 
 func (m *mockProblemRepo) Update(problem *models.Problem) (*models.Problem, error) {
-	if _, exists := m.problems[problem.ID]; !exists {
+	existing, exists := m.problems[problem.ID]
+	if !exists {
 		return nil, &mockError{message: "problem not found"}
 	}
+	problem.State, problem.PublishAt, problem.PublishedAt = existing.State, existing.PublishAt, existing.PublishedAt
 	m.problems[problem.ID] = problem
 	return problem, nil
 }
@@ -68,19 +77,74 @@ func (m *mockProblemRepo) Delete(id int) error {
 func (m *mockProblemRepo) List(filters repository.ProblemFilters) ([]*models.Problem, error) {
 	var result []*models.Problem
 	for _, problem := range m.problems {
-		result = append(result, problem)
+		if filters.State != "" && problem.State != filters.State {
+			continue
+		}
+		listed := *problem
+		result = append(result, &listed)
 	}
 	return result, nil
 }
 
-func (m *mockProblemRepo) Search(query string, filters repository.ProblemFilters) ([]*models.Problem, error) {
-	var result []*models.Problem
+func (m *mockProblemRepo) Search(text repository.SearchText, filters repository.ProblemFilters) ([]*models.ProblemSearchResult, error) {
+	var result []*models.ProblemSearchResult
 	for _, problem := range m.problems {
-		result = append(result, problem)
+		result = append(result, &models.ProblemSearchResult{Problem: *problem})
 	}
 	return result, nil
 }
 
+func (m *mockProblemRepo) Count(filters repository.ProblemFilters) (int, error) {
+	count := 0
+	for _, problem := range m.problems {
+		if filters.State == "" || problem.State == filters.State {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockProblemRepo) CountSearch(text repository.SearchText, filters repository.ProblemFilters) (int, error) {
+	return len(m.problems), nil
+}
+
+func (m *mockProblemRepo) Annotate(problems []*models.Problem, userID int) error {
+	for _, problem := range problems {
+		rate := 0.0
+		problem.AcceptanceRate = &rate
+		if userID != 0 {
+			solved := false
+			problem.UserStatus = models.ProblemStatusNew
+			problem.IsSolved = &solved
+		}
+	}
+	return nil
+}
+
+func (m *mockProblemRepo) SetState(id int, state string, publishAt *time.Time) (*models.Problem, error) {
+	problem, exists := m.problems[id]
+	if !exists {
+		return nil, &mockError{message: "problem not found"}
+	}
+	problem.State = state
+	problem.PublishAt = publishAt
+	if state == models.ProblemStatePublished && problem.PublishedAt == nil {
+		now := time.Now()
+		problem.PublishedAt = &now
+	}
+	return problem, nil
+}
+
+func (m *mockProblemRepo) ListScheduledDue(now time.Time) ([]int, error) {
+	var ids []int
+	for id := 1; id < m.nextID; id++ {
+		if problem, exists := m.problems[id]; exists && problem.State == models.ProblemStateScheduled && !problem.PublishAt.After(now) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
 type mockTestCaseRepo struct {
 	testCases map[int]*models.TestCase
 	nextID    int
@@ -96,6 +160,10 @@ func newMockTestCaseRepo() *mockTestCaseRepo {
 func (m *mockTestCaseRepo) Create(testCase *models.TestCase) (*models.TestCase, error) {
 	testCase.ID = m.nextID
 	m.nextID++
+	setTestDataSummary(testCase)
+	if testCase.OutputStatus == "" {
+		testCase.OutputStatus = models.OutputStatusUnverified
+	}
 	m.testCases[testCase.ID] = testCase
 	return testCase, nil
 }
@@ -114,6 +182,7 @@ func (m *mockTestCaseRepo) GetByProblemID(problemID int) ([]*models.TestCase, er
 			result = append(result, testCase)
 		}
 	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
 	return result, nil
 }
 
@@ -127,14 +196,73 @@ func (m *mockTestCaseRepo) GetPublicByProblemID(problemID int) ([]*models.TestCa
 	return result, nil
 }
 
+func (m *mockTestCaseRepo) GetInlineByProblemID(problemID int) ([]*models.TestCase, error) {
+	return m.GetByProblemID(problemID)
+}
+
+func (m *mockTestCaseRepo) ListByProblemID(problemID int) ([]*models.TestCase, error) {
+	testCases, err := m.GetByProblemID(problemID)
+	return testCaseSummaries(testCases), err
+}
+
+func (m *mockTestCaseRepo) ListPublicByProblemID(problemID int) ([]*models.TestCase, error) {
+	testCases, err := m.GetPublicByProblemID(problemID)
+	return testCaseSummaries(testCases), err
+}
+
+// setTestDataSummary fills in the hashes and sizes of test data, as the repository does
+func setTestDataSummary(testCase *models.TestCase) {
+	if testCase.Input != "" {
+		testCase.InputHash, testCase.InputSize = storage.Hash([]byte(testCase.Input)), int64(len(testCase.Input))
+	}
+	if testCase.ExpectedOutput != "" {
+		testCase.OutputHash, testCase.OutputSize = storage.Hash([]byte(testCase.ExpectedOutput)), int64(len(testCase.ExpectedOutput))
+	}
+}
+
+// testCaseSummaries returns copies of test cases without their data, as lists are returned
+func testCaseSummaries(testCases []*models.TestCase) []*models.TestCase {
+	summaries := make([]*models.TestCase, 0, len(testCases))
+	for _, testCase := range testCases {
+		summary := *testCase
+		summary.Input, summary.ExpectedOutput = "", ""
+		summaries = append(summaries, &summary)
+	}
+	return summaries
+}
+
 func (m *mockTestCaseRepo) Update(testCase *models.TestCase) (*models.TestCase, error) {
-	if _, exists := m.testCases[testCase.ID]; !exists {
+	existing, exists := m.testCases[testCase.ID]
+	if !exists {
 		return nil, &mockError{message: "testcase not found"}
 	}
+	setTestDataSummary(testCase)
+	testCase.OutputStatus = existing.OutputStatus
+	if testCase.InputHash != existing.InputHash || testCase.OutputHash != existing.OutputHash {
+		testCase.OutputStatus = models.OutputStatusUnverified
+	}
 	m.testCases[testCase.ID] = testCase
 	return testCase, nil
 }
 
+func (m *mockTestCaseRepo) SetOutputStatus(id int, status string) error {
+	testCase, exists := m.testCases[id]
+	if !exists {
+		return &mockError{message: "testcase not found"}
+	}
+	testCase.OutputStatus = status
+	return nil
+}
+
+func (m *mockTestCaseRepo) ResetOutputStatus(problemID int) error {
+	for _, testCase := range m.testCases {
+		if testCase.ProblemID == problemID {
+			testCase.OutputStatus = models.OutputStatusUnverified
+		}
+	}
+	return nil
+}
+
 func (m *mockTestCaseRepo) Delete(id int) error {
 	if _, exists := m.testCases[id]; !exists {
 		return &mockError{message: "testcase not found"}
@@ -165,7 +293,7 @@ func setupTestRouter() (*gin.Engine, *ProblemHandlers) {
 	
 	problemRepo := newMockProblemRepo()
 	testCaseRepo := newMockTestCaseRepo()
-	problemService := services.NewProblemService(problemRepo, testCaseRepo)
+	problemService := services.NewProblemService(&repository.Repository{Problem: problemRepo, TestCase: testCaseRepo, ProblemRevision: newMockProblemRevisionRepo(), ProblemSolution: newMockProblemSolutionRepo(), JudgeSettings: newMockJudgeSettingsRepo(), TestProgram: newMockTestProgramRepo()}, nil)
 	problemHandler := NewProblemHandlers(problemService)
 	
 	router := gin.New()
@@ -195,8 +323,8 @@ func TestProblemHandlers_ListProblems(t *testing.T) {
 		t.Error("Expected 'problems' field in response")
 	}
 
-	if _, exists := response["count"]; !exists {
-		t.Error("Expected 'count' field in response")
+	if _, exists := response["total"]; !exists {
+		t.Error("Expected 'total' field in response")
 	}
 }
 
@@ -271,6 +399,17 @@ func TestProblemHandlers_GetProblem(t *testing.T) {
 	var created models.Problem
 	json.Unmarshal(w.Body.Bytes(), &created)
 
+	// Drafts are not public
+	req, _ = http.NewRequest("GET", "/problems/1", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a draft, got %d", http.StatusNotFound, w.Code)
+	}
+
+	publishTestProblem(t, handler, created.ID)
+
 	// Now get the problem
 	req, _ = http.NewRequest("GET", "/problems/1", nil)
 	w = httptest.NewRecorder()
@@ -342,4 +481,135 @@ func TestProblemHandlers_GetProblem_InvalidID(t *testing.T) {
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
 	}
-}
\ No newline at end of file
+}
+
+func TestProblemHandlers_SearchProblems_NoWords(t *testing.T) {
+	router, handler := setupTestRouter()
+	router.GET("/problems/search", handler.SearchProblems)
+
+	req, _ := http.NewRequest("GET", "/problems/search?q=%26%7C%21", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestProblemHandlers_SearchProblems_InvalidQuery(t *testing.T) {
+	router, handler := setupTestRouter()
+	router.GET("/problems/search", handler.SearchProblems)
+
+	req, _ := http.NewRequest("GET", "/problems/search?q=graph+difficulty%3Aimpossible", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response["position"] != float64(17) {
+		t.Errorf("Expected position 17, got %v", response["position"])
+	}
+}
+
+func TestProblemHandlers_SearchProblems_StatusRequiresUser(t *testing.T) {
+	router, handler := setupTestRouter()
+	router.GET("/problems/search", handler.SearchProblems)
+
+	req, _ := http.NewRequest("GET", "/problems/search?q=status%3Asolved", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestProblemHandlers_ListProblems_UserStatus(t *testing.T) {
+	router, handler := setupTestRouter()
+	router.GET("/problems", func(c *gin.Context) {
+		c.Set("user", &auth.Claims{UserID: 1, Username: "testuser"})
+		c.Next()
+	}, handler.ListProblems)
+	anonymous, _ := setupTestRouter()
+	anonymous.GET("/problems", handler.ListProblems)
+
+	_, err := handler.problemService.CreateProblem(&models.Problem{
+		Title:        "Test Problem",
+		Description:  "Test description",
+		Difficulty:   models.DifficultyEasy,
+		Examples:     models.Examples{{Input: "test", Output: "test"}},
+		TemplateCode: models.TemplateCode{models.LanguageJavaScript: "function test() {}"},
+	}, 1)
+	if err != nil {
+		t.Fatalf("Failed to create problem: %v", err)
+	}
+	publishTestProblem(t, handler, 1)
+
+	t.Run("signed-in users get their status", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/problems?status=new", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			Problems []map[string]interface{} `json:"problems"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if len(response.Problems) != 1 {
+			t.Fatalf("Expected 1 problem, got %d", len(response.Problems))
+		}
+		if response.Problems[0]["user_status"] != models.ProblemStatusNew {
+			t.Errorf("Expected user_status %q, got %v", models.ProblemStatusNew, response.Problems[0]["user_status"])
+		}
+		if response.Problems[0]["is_solved"] != false {
+			t.Errorf("Expected is_solved false, got %v", response.Problems[0]["is_solved"])
+		}
+		if _, exists := response.Problems[0]["acceptance_rate"]; !exists {
+			t.Error("Expected 'acceptance_rate' field in problem")
+		}
+	})
+
+	t.Run("anonymous users get no status", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/problems", nil)
+		w := httptest.NewRecorder()
+		anonymous.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if bytes.Contains(w.Body.Bytes(), []byte("user_status")) {
+			t.Error("Expected no 'user_status' field for anonymous users")
+		}
+	})
+
+	t.Run("status filter requires a user", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/problems?status=solved", nil)
+		w := httptest.NewRecorder()
+		anonymous.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/problems?status=done", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"leetcode-clone-backend/pkg/problempkg"
+	"leetcode-clone-backend/pkg/repository"
+	"leetcode-clone-backend/pkg/services"
+)
+
+// ImportPackage handles POST /api/v1/admin/problem-packages, creating or updating the problem of
+// a zip package by slug. The package is the request body, or the "package" file of a multipart form.
+func (h *ProblemHandlers) ImportPackage(c *gin.Context) {
+	pkg, err := readUploadedPackage(c)
+	if err != nil {
+		handlePackageUploadError(c, err)
+		return
+	}
+
+	result, err := h.problemService.ImportPackage(pkg, adminUserID(c))
+	if err != nil {
+		var validationErr *problempkg.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			handlePackageUploadError(c, err)
+		case repository.IsDuplicateKey(err):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Problem was created concurrently",
+				"details": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to import package",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if result.Action == services.PackageImportCreated {
+		status = http.StatusCreated
+	}
+	c.JSON(status, result)
+}
+
+// ValidatePackage handles POST /api/v1/admin/problem-packages/validate, checking a package
+// without importing it
+func (h *ProblemHandlers) ValidatePackage(c *gin.Context) {
+	pkg, err := readUploadedPackage(c)
+	if err == nil {
+		err = h.problemService.ValidatePackage(pkg)
+	}
+
+	issues := []string{}
+	var validationErr *problempkg.ValidationError
+	if errors.As(err, &validationErr) {
+		issues = validationErr.Issues
+	} else if err != nil {
+		handlePackageUploadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"valid":  len(issues) == 0,
+		"issues": issues,
+	})
+}
+
+// ImportExternalPackageResponse is the response of ImportExternalPackage
+type ImportExternalPackageResponse struct {
+	*services.PackageImportResult
+	Report *problempkg.Report `json:"report"`
+}
+
+// ImportExternalPackage handles POST /api/v1/admin/problem-packages/external, converting a Kattis
+// or Polygon zip package and importing it like ImportPackage. The response reports what the
+// conversion could not carry over. With dry_run=true the package is only converted and checked.
+// The short name of a Kattis package without a top-level directory is taken from the name
+// query parameter or the uploaded file name.
+func (h *ProblemHandlers) ImportExternalPackage(c *gin.Context) {
+	data, filename, err := readUploadedZip(c)
+	if err != nil {
+		handlePackageUploadError(c, err)
+		return
+	}
+	name := c.DefaultQuery("name", filename)
+
+	pkg, report, err := problempkg.ConvertZip(bytes.NewReader(data), int64(len(data)), name)
+	if err != nil {
+		handlePackageUploadError(c, err)
+		return
+	}
+
+	if c.Query("dry_run") == "true" {
+		issues := []string{}
+		var validationErr *problempkg.ValidationError
+		if err := h.problemService.ValidatePackage(pkg); errors.As(err, &validationErr) {
+			issues = validationErr.Issues
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"valid":  len(issues) == 0,
+			"issues": issues,
+			"slug":   pkg.Manifest.Slug,
+			"report": report,
+		})
+		return
+	}
+
+	result, err := h.problemService.ImportPackage(pkg, adminUserID(c))
+	if err != nil {
+		var validationErr *problempkg.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":   "Invalid problem package",
+				"details": err.Error(),
+				"issues":  validationErr.Issues,
+				"report":  report,
+			})
+		case repository.IsDuplicateKey(err):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Problem was created concurrently",
+				"details": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to import package",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if result.Action == services.PackageImportCreated {
+		status = http.StatusCreated
+	}
+	c.JSON(status, ImportExternalPackageResponse{PackageImportResult: result, Report: report})
+}
+
+// ExportPackage handles GET /api/v1/admin/problems/:id/package, downloading the problem as a
+// zip package
+func (h *ProblemHandlers) ExportPackage(c *gin.Context) {
+	id, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+
+	pkg, err := h.problemService.ExportPackage(id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Problem not found",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to export package",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := pkg.WriteZip(&buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to export package",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, pkg.Manifest.Slug))
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+// readUploadedPackage reads the zip package uploaded with a request, as the request body or the
+// "package" file of a multipart form
+func readUploadedPackage(c *gin.Context) (*problempkg.Package, error) {
+	data, _, err := readUploadedZip(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return problempkg.ReadZip(bytes.NewReader(data), int64(len(data)))
+}
+
+// readUploadedZip reads the zip file uploaded with a request, as the request body or the
+// "package" file of a multipart form, with the name of the uploaded file without extension
+func readUploadedZip(c *gin.Context) ([]byte, string, error) {
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, problempkg.MaxSize)
+
+	var data []byte
+	var filename string
+	var err error
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		c.Request.Body = body
+		data, filename, err = formFileData(c, "package")
+	} else {
+		data, err = io.ReadAll(body)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read package: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, "", fmt.Errorf("package is required")
+	}
+
+	return data, strings.TrimSuffix(filename, ".zip"), nil
+}
+
+// formFileData reads a file of a multipart form with its name
+func formFileData(c *gin.Context, name string) ([]byte, string, error) {
+	header, err := c.FormFile(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, err := readFormFileHeader(header)
+	return data, header.Filename, err
+}
+
+// handlePackageUploadError responds to a package that cannot be read or is invalid, listing the
+// issues of an invalid package
+func handlePackageUploadError(c *gin.Context, err error) {
+	var validationErr *problempkg.ValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "Invalid problem package",
+			"details": err.Error(),
+			"issues":  validationErr.Issues,
+		})
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":   "Invalid package upload",
+		"details": err.Error(),
+	})
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/problempkg"
+)
+
+type mockProblemSolutionRepo struct {
+	solutions map[int][]*models.ProblemSolution
+}
+
+func newMockProblemSolutionRepo() *mockProblemSolutionRepo {
+	return &mockProblemSolutionRepo{solutions: make(map[int][]*models.ProblemSolution)}
+}
+
+func (m *mockProblemSolutionRepo) GetByProblemID(problemID int) ([]*models.ProblemSolution, error) {
+	return m.solutions[problemID], nil
+}
+
+func (m *mockProblemSolutionRepo) Replace(problemID int, solutions []*models.ProblemSolution) ([]*models.ProblemSolution, error) {
+	m.solutions[problemID] = solutions
+	return solutions, nil
+}
+
+// testPackageZip returns a valid zip package of a problem with the given slug
+func testPackageZip(t *testing.T, slug string) []byte {
+	t.Helper()
+
+	pkg := &problempkg.Package{
+		Manifest: problempkg.Manifest{
+			FormatVersion: problempkg.FormatVersion,
+			Slug:          slug,
+			Title:         "Imported Problem",
+			Difficulty:    models.DifficultyMedium,
+			Examples:      []problempkg.Example{{Input: "1", Output: "1"}},
+			PublicTests:   []string{"01"},
+		},
+		Statement: "Print the input",
+		Tests: []problempkg.Test{
+			{Name: "01", Input: "1", Output: "1", Public: true},
+			{Name: "02", Input: "2", Output: "2"},
+		},
+		Templates: map[string]string{models.LanguageJavaScript: "function solve(x) {}"},
+	}
+
+	var buf bytes.Buffer
+	if err := pkg.WriteZip(&buf); err != nil {
+		t.Fatalf("Failed to write package: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProblemHandlers_ImportPackage(t *testing.T) {
+	router, handler := setupTestRouter()
+	router.POST("/admin/problem-packages", handler.ImportPackage)
+
+	data := testPackageZip(t, "imported-problem")
+
+	tests := []struct {
+		name           string
+		body           []byte
+		expectedStatus int
+		expectedAction string
+	}{
+		{"creates problem", data, http.StatusCreated, "created"},
+		{"same package again", data, http.StatusOK, "unchanged"},
+		{"empty body", nil, http.StatusBadRequest, ""},
+		{"not a zip", []byte("problem"), http.StatusUnprocessableEntity, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/admin/problem-packages", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/zip")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedAction == "" {
+				return
+			}
+
+			var response struct {
+				Action  string         `json:"action"`
+				Problem models.Problem `json:"problem"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &response)
+			if response.Action != tt.expectedAction || response.Problem.Slug != "imported-problem" {
+				t.Errorf("Expected %s problem imported-problem, got %s %q", tt.expectedAction, response.Action, response.Problem.Slug)
+			}
+		})
+	}
+
+	t.Run("multipart upload", func(t *testing.T) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, _ := form.CreateFormFile("package", "other.zip")
+		part.Write(testPackageZip(t, "other-problem"))
+		form.Close()
+
+		req, _ := http.NewRequest("POST", "/admin/problem-packages", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestProblemHandlers_ValidatePackage(t *testing.T) {
+	router, handler := setupTestRouter()
+	router.POST("/admin/problem-packages/validate", handler.ValidatePackage)
+
+	tests := []struct {
+		name          string
+		body          []byte
+		expectedValid bool
+	}{
+		{"valid package", testPackageZip(t, "valid-problem"), true},
+		{"invalid slug", testPackageZip(t, "Not A Slug"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/admin/problem-packages/validate", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/zip")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+
+			var response struct {
+				Valid  bool     `json:"valid"`
+				Issues []string `json:"issues"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &response)
+			if response.Valid != tt.expectedValid || (len(response.Issues) == 0) != tt.expectedValid {
+				t.Errorf("Expected valid %v, got %v with issues %v", tt.expectedValid, response.Valid, response.Issues)
+			}
+		})
+	}
+}
+
+func TestProblemHandlers_ExportPackage(t *testing.T) {
+	router, handler := setupTestRouter()
+	router.GET("/admin/problems/:id/package", handler.ExportPackage)
+
+	data := testPackageZip(t, "exported-problem")
+	pkg, _ := problempkg.ReadZip(bytes.NewReader(data), int64(len(data)))
+	if _, err := handler.problemService.ImportPackage(pkg, 1); err != nil {
+		t.Fatalf("Failed to import package: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", "/admin/problems/1/package", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if disposition := w.Header().Get("Content-Disposition"); disposition != `attachment; filename="exported-problem.zip"` {
+		t.Errorf("Unexpected Content-Disposition %q", disposition)
+	}
+
+	exported, err := problempkg.ReadZip(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("Failed to read exported package: %v", err)
+	}
+	if exported.Manifest.Slug != "exported-problem" || len(exported.Tests) != 2 {
+		t.Errorf("Expected the problem with 2 tests, got %q with %d", exported.Manifest.Slug, len(exported.Tests))
+	}
+
+	req, _ = http.NewRequest("GET", "/admin/problems/99/package", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// testKattisZip returns a zip Kattis package of a problem in the directory dir
+func testKattisZip(t *testing.T, dir string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := []struct{ name, data string }{
+		{dir + "/problem.yaml", "name: Echo\nlimits:\n  memory: 256\n"},
+		{dir + "/.timelimit", "1\n"},
+		{dir + "/problem_statement/problem.md", "Print the input."},
+		{dir + "/data/sample/1.in", "1\n"},
+		{dir + "/data/sample/1.ans", "1\n"},
+		{dir + "/data/secret/1.in", "2\n"},
+		{dir + "/data/secret/1.ans", "2\n"},
+		{dir + "/input_validators/validate.py", "import sys"},
+	}
+	for _, f := range files {
+		w, _ := zw.Create(f.name)
+		w.Write([]byte(f.data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to write package: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProblemHandlers_ImportExternalPackage(t *testing.T) {
+	router, handler := setupTestRouter()
+	router.POST("/admin/problem-packages/external", handler.ImportExternalPackage)
+
+	data := testKattisZip(t, "echo")
+
+	tests := []struct {
+		name           string
+		query          string
+		body           []byte
+		expectedStatus int
+		expectedValid  bool
+	}{
+		{"dry run", "?dry_run=true", data, http.StatusOK, true},
+		{"creates problem", "", data, http.StatusCreated, true},
+		{"imports again", "", data, http.StatusOK, true},
+		{"not a package", "", testPackageZip(t, "native-problem"), http.StatusUnprocessableEntity, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/admin/problem-packages/external"+tt.query, bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/zip")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if !tt.expectedValid {
+				return
+			}
+
+			var response struct {
+				Valid   bool               `json:"valid"`
+				Problem *models.Problem    `json:"problem"`
+				Report  *problempkg.Report `json:"report"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &response)
+			if response.Report == nil || response.Report.Format != problempkg.FormatKattis || len(response.Report.Unsupported) == 0 {
+				t.Errorf("Expected a report of the Kattis conversion, got %s", w.Body.String())
+			}
+			if tt.query == "" && (response.Problem == nil || response.Problem.Slug != "echo") {
+				t.Errorf("Expected problem echo imported, got %s", w.Body.String())
+			}
+		})
+	}
+
+	settings, _ := handler.problemService.GetJudgeSettings(1)
+	if settings.TimeLimitMs != 1000 || settings.MemoryLimitMb != 256 || settings.Checker != models.CheckerTokens {
+		t.Errorf("Expected the limits of the package stored, got %+v", settings)
+	}
+}
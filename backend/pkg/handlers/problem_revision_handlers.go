@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"leetcode-clone-backend/pkg/pagination"
+	"leetcode-clone-backend/pkg/repository"
+)
+
+// defaultRevisionPageSize is the number of revisions listed per page unless the client asks for more
+const defaultRevisionPageSize = 20
+
+// ListRevisions handles GET /api/v1/admin/problems/:id/revisions
+func (h *ProblemHandlers) ListRevisions(c *gin.Context) {
+	problemID, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+
+	params, err := pagination.ParseQuery(c.Request.URL.Query(), defaultRevisionPageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid pagination parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.problemService.ListRevisions(problemID, params)
+	if err != nil {
+		h.handleRevisionError(c, err, "Failed to list revisions")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetRevision handles GET /api/v1/admin/problems/:id/revisions/:revision
+func (h *ProblemHandlers) GetRevision(c *gin.Context) {
+	problemID, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+	revision, ok := revisionParam(c, c.Param("revision"), "revision")
+	if !ok {
+		return
+	}
+
+	result, err := h.problemService.GetRevision(problemID, revision)
+	if err != nil {
+		h.handleRevisionError(c, err, "Failed to get revision")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// DiffRevisions handles GET /api/v1/admin/problems/:id/revisions/diff?from=&to=
+func (h *ProblemHandlers) DiffRevisions(c *gin.Context) {
+	problemID, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+	from, ok := revisionParam(c, c.Query("from"), "from")
+	if !ok {
+		return
+	}
+	to, ok := revisionParam(c, c.Query("to"), "to")
+	if !ok {
+		return
+	}
+
+	result, err := h.problemService.DiffRevisions(problemID, from, to)
+	if err != nil {
+		h.handleRevisionError(c, err, "Failed to diff revisions")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// RestoreRevision handles POST /api/v1/admin/problems/:id/revisions/:revision/restore
+func (h *ProblemHandlers) RestoreRevision(c *gin.Context) {
+	problemID, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+	revision, ok := revisionParam(c, c.Param("revision"), "revision")
+	if !ok {
+		return
+	}
+
+	result, err := h.problemService.RestoreRevision(problemID, revision, adminUserID(c))
+	if err != nil {
+		if repository.IsDuplicateKey(err) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Another problem now uses the slug of this revision",
+				"details": err.Error(),
+			})
+			return
+		}
+		h.handleRevisionError(c, err, "Failed to restore revision")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// handleRevisionError maps a revision service error to an HTTP response
+func (h *ProblemHandlers) handleRevisionError(c *gin.Context, err error, message string) {
+	switch {
+	case strings.Contains(err.Error(), "invalid pagination"):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid pagination parameters",
+			"details": err.Error(),
+		})
+	case strings.Contains(err.Error(), "not found") && strings.Contains(err.Error(), "retrieve revision"):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Revision not found",
+			"details": err.Error(),
+		})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Problem not found",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
+
+// problemIDParam parses the problem ID of the URL, responding with an error if it is invalid
+func problemIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid problem ID",
+			"details": "Problem ID must be a valid integer",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// revisionParam parses a revision number, responding with an error if it is invalid
+func revisionParam(c *gin.Context, value, name string) (int, bool) {
+	revision, err := strconv.Atoi(value)
+	if err != nil || revision < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid revision",
+			"details": name + " must be a positive integer",
+		})
+		return 0, false
+	}
+	return revision, true
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"leetcode-clone-backend/pkg/auth"
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+)
+
+type mockProblemRevisionRepo struct {
+	revisions []*models.ProblemRevision
+}
+
+func newMockProblemRevisionRepo() *mockProblemRevisionRepo {
+	return &mockProblemRevisionRepo{}
+}
+
+func (m *mockProblemRevisionRepo) LockProblem(problemID int) error {
+	return nil
+}
+
+func (m *mockProblemRevisionRepo) Create(revision *models.ProblemRevision) (*models.ProblemRevision, error) {
+	created := *revision
+	created.ID = len(m.revisions) + 1
+	created.Revision = 1
+	if latest, err := m.GetLatest(revision.ProblemID); err == nil {
+		created.Revision = latest.Revision + 1
+	}
+	created.CreatedAt = time.Now()
+	m.revisions = append(m.revisions, &created)
+	return &created, nil
+}
+
+func (m *mockProblemRevisionRepo) Get(problemID, revision int) (*models.ProblemRevision, error) {
+	for _, r := range m.revisions {
+		if r.ProblemID == problemID && r.Revision == revision {
+			found := *r
+			return &found, nil
+		}
+	}
+	return nil, repository.NewRepositoryError("Get", repository.ErrNotFound, "revision_not_found")
+}
+
+func (m *mockProblemRevisionRepo) GetLatest(problemID int) (*models.ProblemRevision, error) {
+	var latest *models.ProblemRevision
+	for _, r := range m.revisions {
+		if r.ProblemID == problemID {
+			latest = r
+		}
+	}
+	if latest == nil {
+		return nil, repository.NewRepositoryError("GetLatest", repository.ErrNotFound, "revision_not_found")
+	}
+	found := *latest
+	return &found, nil
+}
+
+func (m *mockProblemRevisionRepo) List(filters repository.ProblemRevisionFilters) ([]*models.ProblemRevision, error) {
+	var result []*models.ProblemRevision
+	for i := len(m.revisions) - 1; i >= 0; i-- {
+		r := m.revisions[i]
+		if r.ProblemID == filters.ProblemID && (filters.Cursor == nil || r.Revision < filters.Cursor.ID) {
+			listed := *r
+			listed.Snapshot = nil
+			result = append(result, &listed)
+		}
+	}
+	if filters.Limit > 0 && len(result) > filters.Limit {
+		result = result[:filters.Limit]
+	}
+	return result, nil
+}
+
+func (m *mockProblemRevisionRepo) Count(problemID int) (int, error) {
+	count := 0
+	for _, r := range m.revisions {
+		if r.ProblemID == problemID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func setupRevisionTestRouter(t *testing.T) (*gin.Engine, int) {
+	router, handler := setupTestRouter()
+	router.Use(func(c *gin.Context) {
+		c.Set("user", &auth.Claims{UserID: 4, Username: "admin", IsAdmin: true})
+		c.Next()
+	})
+	router.GET("/admin/problems/:id/revisions", handler.ListRevisions)
+	router.GET("/admin/problems/:id/revisions/diff", handler.DiffRevisions)
+	router.GET("/admin/problems/:id/revisions/:revision", handler.GetRevision)
+	router.POST("/admin/problems/:id/revisions/:revision/restore", handler.RestoreRevision)
+
+	problem := &models.Problem{
+		Title:        "Two Sum",
+		Description:  "Find two numbers that add up to target",
+		Difficulty:   models.DifficultyEasy,
+		Examples:     models.Examples{{Input: "[2,7,11,15], 9", Output: "[0,1]"}},
+		TemplateCode: models.TemplateCode{models.LanguageJavaScript: "function twoSum(nums, target) {}"},
+	}
+	created, err := handler.problemService.CreateProblem(problem, 4)
+	if err != nil {
+		t.Fatalf("Failed to create problem: %v", err)
+	}
+
+	update := *created
+	update.Title = "Two Sum II"
+	if _, err := handler.problemService.UpdateProblem(&update, 4); err != nil {
+		t.Fatalf("Failed to update problem: %v", err)
+	}
+
+	return router, created.ID
+}
+
+func TestProblemHandlers_ListRevisions(t *testing.T) {
+	router, _ := setupRevisionTestRouter(t)
+
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+	}{
+		{"lists revisions", "/admin/problems/1/revisions", http.StatusOK},
+		{"invalid problem ID", "/admin/problems/abc/revisions", http.StatusBadRequest},
+		{"problem not found", "/admin/problems/99/revisions", http.StatusNotFound},
+		{"invalid limit", "/admin/problems/1/revisions?limit=abc", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Revisions []models.ProblemRevision `json:"revisions"`
+				Total     int                      `json:"total"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if response.Total != 2 || len(response.Revisions) != 2 {
+				t.Fatalf("Expected 2 revisions, got %d of %d", len(response.Revisions), response.Total)
+			}
+			if response.Revisions[0].Action != models.RevisionActionUpdate {
+				t.Errorf("Expected newest revision first, got %q", response.Revisions[0].Action)
+			}
+		})
+	}
+}
+
+func TestProblemHandlers_GetRevision(t *testing.T) {
+	router, _ := setupRevisionTestRouter(t)
+
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+	}{
+		{"gets revision", "/admin/problems/1/revisions/2", http.StatusOK},
+		{"invalid revision", "/admin/problems/1/revisions/0", http.StatusBadRequest},
+		{"revision not found", "/admin/problems/1/revisions/9", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Snapshot *models.ProblemSnapshot `json:"snapshot"`
+				Changes  []struct {
+					Field string `json:"field"`
+				} `json:"changes"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if response.Snapshot == nil || response.Snapshot.Title != "Two Sum II" {
+				t.Errorf("Expected snapshot of revision 2, got %+v", response.Snapshot)
+			}
+			if len(response.Changes) != 1 || response.Changes[0].Field != "title" {
+				t.Errorf("Expected only the title to change, got %+v", response.Changes)
+			}
+		})
+	}
+}
+
+func TestProblemHandlers_DiffRevisions(t *testing.T) {
+	router, _ := setupRevisionTestRouter(t)
+
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+	}{
+		{"diffs revisions", "/admin/problems/1/revisions/diff?from=1&to=2", http.StatusOK},
+		{"missing from", "/admin/problems/1/revisions/diff?to=2", http.StatusBadRequest},
+		{"revision not found", "/admin/problems/1/revisions/diff?from=1&to=3", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Fields []struct {
+					Field string `json:"field"`
+					Diff  struct {
+						Unified string `json:"unified"`
+					} `json:"diff"`
+				} `json:"fields"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if len(response.Fields) != 1 || response.Fields[0].Field != "title" {
+				t.Fatalf("Expected only the title to differ, got %+v", response.Fields)
+			}
+			if response.Fields[0].Diff.Unified == "" {
+				t.Error("Expected a unified diff of the title")
+			}
+		})
+	}
+}
+
+func TestProblemHandlers_RestoreRevision(t *testing.T) {
+	router, _ := setupRevisionTestRouter(t)
+
+	req, _ := http.NewRequest("POST", "/admin/problems/1/revisions/1/restore", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var revision models.ProblemRevision
+	if err := json.Unmarshal(w.Body.Bytes(), &revision); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if revision.Revision != 3 || revision.Action != models.RevisionActionRestore {
+		t.Errorf("Expected restore recorded as revision 3, got %d %q", revision.Revision, revision.Action)
+	}
+	if revision.RestoredFrom == nil || *revision.RestoredFrom != 1 {
+		t.Errorf("Expected restored_from 1, got %v", revision.RestoredFrom)
+	}
+	if revision.AuthorID == nil || *revision.AuthorID != 4 {
+		t.Errorf("Expected restore authored by the admin, got %v", revision.AuthorID)
+	}
+
+	req, _ = http.NewRequest("POST", "/admin/problems/1/revisions/9/restore", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a missing revision, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"leetcode-clone-backend/pkg/services"
+)
+
+// TransitionProblemRequest is the request body of TransitionProblem
+type TransitionProblemRequest struct {
+	State     string     `json:"state" binding:"required"`
+	PublishAt *time.Time `json:"publish_at"` // Required when state is "scheduled"
+}
+
+// GetAnyProblem handles GET /api/v1/admin/problems/:id, returning the problem whatever its state
+func (h *ProblemHandlers) GetAnyProblem(c *gin.Context) {
+	id, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+
+	problem, err := h.problemService.GetProblemAnyState(id)
+	if err != nil {
+		h.handleStateError(c, err, "Failed to get problem")
+		return
+	}
+
+	c.JSON(http.StatusOK, problem)
+}
+
+// TransitionProblem handles PUT /api/v1/admin/problems/:id/state
+func (h *ProblemHandlers) TransitionProblem(c *gin.Context) {
+	id, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req TransitionProblemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	problem, err := h.problemService.TransitionProblem(id, req.State, req.PublishAt)
+	if err != nil {
+		h.handleStateError(c, err, "Failed to update problem state")
+		return
+	}
+
+	c.JSON(http.StatusOK, problem)
+}
+
+// GetPublishChecks handles GET /api/v1/admin/problems/:id/publish-checks
+func (h *ProblemHandlers) GetPublishChecks(c *gin.Context) {
+	id, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+
+	checks, err := h.problemService.CheckPublishable(id)
+	if err != nil {
+		h.handleStateError(c, err, "Failed to check problem")
+		return
+	}
+
+	passed := true
+	for _, check := range checks {
+		passed = passed && check.Passed
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"publishable": passed,
+		"checks":      checks,
+	})
+}
+
+// handleStateError maps a lifecycle service error to an HTTP response
+func (h *ProblemHandlers) handleStateError(c *gin.Context, err error, message string) {
+	var checkErr *services.PublishCheckError
+	switch {
+	case errors.As(err, &checkErr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "Problem cannot be published",
+			"details": err.Error(),
+			"checks":  checkErr.Checks,
+		})
+	case strings.Contains(err.Error(), "invalid state"):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid state",
+			"details": err.Error(),
+		})
+	case strings.Contains(err.Error(), "invalid transition"):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Invalid state transition",
+			"details": err.Error(),
+		})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Problem not found",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
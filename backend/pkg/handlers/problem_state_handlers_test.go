@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+// publishTestProblem adds a public and a hidden test case to a problem and publishes it
+func publishTestProblem(t *testing.T, handler *ProblemHandlers, problemID int) {
+	t.Helper()
+
+	for _, hidden := range []bool{false, true} {
+		testCase := &models.TestCase{ProblemID: problemID, Input: "1", ExpectedOutput: "1", IsHidden: hidden}
+		if _, err := handler.problemService.CreateTestCase(testCase, 1); err != nil {
+			t.Fatalf("Failed to create test case: %v", err)
+		}
+	}
+	if _, err := handler.problemService.TransitionProblem(problemID, models.ProblemStatePublished, nil); err != nil {
+		t.Fatalf("Failed to publish problem: %v", err)
+	}
+}
+
+// createDraftProblem creates a draft problem with no test cases
+func createDraftProblem(t *testing.T, handler *ProblemHandlers, title string) *models.Problem {
+	t.Helper()
+
+	created, err := handler.problemService.CreateProblem(&models.Problem{
+		Title:        title,
+		Description:  "Test description",
+		Difficulty:   models.DifficultyEasy,
+		Examples:     models.Examples{{Input: "test", Output: "test"}},
+		TemplateCode: models.TemplateCode{models.LanguageJavaScript: "function test() {}"},
+	}, 1)
+	if err != nil {
+		t.Fatalf("Failed to create problem: %v", err)
+	}
+	return created
+}
+
+func TestProblemHandlers_TransitionProblem(t *testing.T) {
+	router, handler := setupTestRouter()
+	router.PUT("/admin/problems/:id/state", handler.TransitionProblem)
+	router.GET("/problems/:id", handler.GetProblem)
+
+	draft := createDraftProblem(t, handler, "Draft Problem")
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"missing state", `{}`, http.StatusBadRequest},
+		{"unknown state", `{"state":"deleted"}`, http.StatusBadRequest},
+		{"schedule without publish time", `{"state":"scheduled"}`, http.StatusBadRequest},
+		{"publish without test cases", `{"state":"published"}`, http.StatusUnprocessableEntity},
+		{"move to review", `{"state":"review"}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("PUT", "/admin/problems/1/state", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus == http.StatusUnprocessableEntity {
+				var response map[string]interface{}
+				json.Unmarshal(w.Body.Bytes(), &response)
+				if _, exists := response["checks"]; !exists {
+					t.Error("Expected 'checks' field in response")
+				}
+			}
+		})
+	}
+
+	t.Run("published problems are public", func(t *testing.T) {
+		for _, hidden := range []bool{false, true} {
+			handler.problemService.CreateTestCase(&models.TestCase{ProblemID: draft.ID, Input: "1", ExpectedOutput: "1", IsHidden: hidden}, 1)
+		}
+
+		req, _ := http.NewRequest("PUT", "/admin/problems/1/state", bytes.NewBufferString(`{"state":"published"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		req, _ = http.NewRequest("GET", "/problems/1", nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("published problems cannot return to draft", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/admin/problems/1/state", bytes.NewBufferString(`{"state":"draft"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+		}
+	})
+}
+
+func TestProblemHandlers_GetPublishChecks(t *testing.T) {
+	router, handler := setupTestRouter()
+	router.GET("/admin/problems/:id/publish-checks", handler.GetPublishChecks)
+
+	createDraftProblem(t, handler, "Draft Problem")
+
+	req, _ := http.NewRequest("GET", "/admin/problems/1/publish-checks", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Publishable bool `json:"publishable"`
+		Checks      []struct {
+			Name   string `json:"name"`
+			Passed bool   `json:"passed"`
+		} `json:"checks"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Publishable {
+		t.Error("Expected a problem without test cases not to be publishable")
+	}
+	if len(response.Checks) != 4 || !response.Checks[0].Passed {
+		t.Errorf("Expected 4 checks with the problem valid, got %+v", response.Checks)
+	}
+
+	req, _ = http.NewRequest("GET", "/admin/problems/99/publish-checks", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestProblemHandlers_ListAllProblems(t *testing.T) {
+	router, handler := setupTestRouter()
+	router.GET("/problems", handler.ListProblems)
+	router.GET("/admin/problems", handler.ListAllProblems)
+	router.GET("/admin/problems/:id", handler.GetAnyProblem)
+
+	createDraftProblem(t, handler, "Draft Problem")
+	published := createDraftProblem(t, handler, "Published Problem")
+	publishTestProblem(t, handler, published.ID)
+
+	tests := []struct {
+		name     string
+		url      string
+		expected int
+	}{
+		{"public list shows published problems", "/problems", 1},
+		{"admin list shows every problem", "/admin/problems", 2},
+		{"admin list filters by state", "/admin/problems?state=draft", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+
+			var response struct {
+				Problems []models.Problem `json:"problems"`
+				Total    int              `json:"total"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &response)
+			if len(response.Problems) != tt.expected || response.Total != tt.expected {
+				t.Errorf("Expected %d problems, got %d of %d", tt.expected, len(response.Problems), response.Total)
+			}
+		})
+	}
+
+	t.Run("invalid state", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/admin/problems?state=hidden", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("admins get drafts", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/admin/problems/1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var problem models.Problem
+		json.Unmarshal(w.Body.Bytes(), &problem)
+		if problem.State != models.ProblemStateDraft {
+			t.Errorf("Expected state %q, got %q", models.ProblemStateDraft, problem.State)
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReplaceSolutionsRequest is the request body of ReplaceSolutions
+type ReplaceSolutionsRequest struct {
+	Solutions []SolutionRequest `json:"solutions" binding:"required"`
+}
+
+// SolutionRequest is a reference solution in a ReplaceSolutionsRequest
+type SolutionRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Language string `json:"language" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// GetSolutions handles GET /api/v1/admin/problems/:id/solutions
+func (h *ProblemHandlers) GetSolutions(c *gin.Context) {
+	id, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+
+	solutions, err := h.problemService.GetSolutions(id)
+	if err != nil {
+		handleReferenceSolutionError(c, err, "Failed to get reference solutions")
+		return
+	}
+	if solutions == nil {
+		solutions = []*models.ProblemSolution{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"solutions": solutions})
+}
+
+// ReplaceSolutions handles PUT /api/v1/admin/problems/:id/solutions
+func (h *ProblemHandlers) ReplaceSolutions(c *gin.Context) {
+	id, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req ReplaceSolutionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	solutions := make([]*models.ProblemSolution, len(req.Solutions))
+	for i, solution := range req.Solutions {
+		solutions[i] = &models.ProblemSolution{
+			ProblemID: id,
+			Name:      solution.Name,
+			Language:  solution.Language,
+			Code:      solution.Code,
+		}
+	}
+
+	replaced, err := h.problemService.ReplaceSolutions(id, solutions)
+	if err != nil {
+		handleReferenceSolutionError(c, err, "Failed to replace reference solutions")
+		return
+	}
+	if replaced == nil {
+		replaced = []*models.ProblemSolution{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"solutions": replaced})
+}
+
+// CheckExpectedOutputs handles POST /api/v1/admin/problems/:id/testcases/check-outputs
+func (h *ProblemHandlers) CheckExpectedOutputs(c *gin.Context) {
+	id, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.problemService.CheckExpectedOutputs(id)
+	if err != nil {
+		handleReferenceSolutionError(c, err, "Failed to check expected outputs")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GenerateExpectedOutputs handles POST /api/v1/admin/problems/:id/testcases/generate-outputs
+func (h *ProblemHandlers) GenerateExpectedOutputs(c *gin.Context) {
+	id, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.problemService.GenerateExpectedOutputs(id, adminUserID(c))
+	if err != nil {
+		handleReferenceSolutionError(c, err, "Failed to generate expected outputs")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// handleReferenceSolutionError maps a reference solution service error to an HTTP response
+func handleReferenceSolutionError(c *gin.Context, err error, message string) {
+	var checkErr *services.OutputCheckError
+	switch {
+	case errors.As(err, &checkErr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "Expected outputs rejected",
+			"details": err.Error(),
+			"checks":  checkErr.Checks,
+		})
+	case strings.Contains(err.Error(), "validation failed"):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation failed",
+			"details": err.Error(),
+		})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Problem not found",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"leetcode-clone-backend/pkg/execution"
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+	"leetcode-clone-backend/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// echoRunner runs reference solutions that print their input, or "wrong" for the code "wrong"
+type echoRunner struct{}
+
+func (echoRunner) ExecuteCode(code, language string, testCases []models.TestCase, settings *models.JudgeSettings) (*execution.ExecutionResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (echoRunner) RunCode(code, language string, inputs []string, settings *models.JudgeSettings) ([]execution.RunResult, error) {
+	results := make([]execution.RunResult, len(inputs))
+	for i, input := range inputs {
+		results[i] = execution.RunResult{Output: strings.TrimSpace(input), Status: models.StatusAccepted}
+		if code == "wrong" {
+			results[i].Output = "wrong"
+		}
+	}
+	return results, nil
+}
+
+func (echoRunner) ValidateCode(code, language string) error {
+	return nil
+}
+
+func TestProblemHandlers_ReferenceSolutions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	problemService := services.NewProblemService(&repository.Repository{
+		Problem:         newMockProblemRepo(),
+		TestCase:        newMockTestCaseRepo(),
+		ProblemRevision: newMockProblemRevisionRepo(),
+		ProblemSolution: newMockProblemSolutionRepo(),
+		JudgeSettings:   newMockJudgeSettingsRepo(),
+		TestProgram:     newMockTestProgramRepo(),
+	}, echoRunner{})
+	handler := NewProblemHandlers(problemService)
+
+	router := gin.New()
+	router.GET("/admin/problems/:id/solutions", handler.GetSolutions)
+	router.PUT("/admin/problems/:id/solutions", handler.ReplaceSolutions)
+	router.POST("/admin/problems/:id/testcases/check-outputs", handler.CheckExpectedOutputs)
+	router.POST("/admin/problems/:id/testcases/generate-outputs", handler.GenerateExpectedOutputs)
+
+	problem := createDraftProblem(t, handler, "Echo")
+	testCase, _ := problemService.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "hello", ExpectedOutput: "hallo"}, 1)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	solutionsPath := fmt.Sprintf("/admin/problems/%d/solutions", problem.ID)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"check without solutions", "POST", fmt.Sprintf("/admin/problems/%d/testcases/check-outputs", problem.ID), "", http.StatusBadRequest, "no reference solutions"},
+		{"invalid language", "PUT", solutionsPath, `{"solutions":[{"name":"main","language":"cpp","code":"x"}]}`, http.StatusBadRequest, "language must be one of"},
+		{"missing solutions", "PUT", solutionsPath, `{}`, http.StatusBadRequest, "Invalid request body"},
+		{"replace solutions", "PUT", solutionsPath, `{"solutions":[{"name":"main","language":"python","code":"echo"}]}`, http.StatusOK, `"name":"main"`},
+		{"list solutions", "GET", solutionsPath, "", http.StatusOK, `"code":"echo"`},
+		{"problem not found", "GET", "/admin/problems/999/solutions", "", http.StatusNotFound, "Problem not found"},
+		{"check outputs", "POST", fmt.Sprintf("/admin/problems/%d/testcases/check-outputs", problem.ID), "", http.StatusOK, `"stale":1`},
+		{"generate outputs", "POST", fmt.Sprintf("/admin/problems/%d/testcases/generate-outputs", problem.ID), "", http.StatusOK, `"updated":1`},
+		{"disagreeing solutions", "PUT", solutionsPath, `{"solutions":[{"name":"a","language":"python","code":"echo"},{"name":"b","language":"java","code":"wrong"}]}`, http.StatusOK, `"name":"b"`},
+		{"generate with disagreeing solutions", "POST", fmt.Sprintf("/admin/problems/%d/testcases/generate-outputs", problem.ID), "", http.StatusUnprocessableEntity, "solutions a and b disagree"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := send(tt.method, tt.path, tt.body)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("Expected body containing %q, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+
+	stored, _ := problemService.GetTestCase(testCase.ID)
+	if stored.ExpectedOutput != "hello" {
+		t.Errorf("Expected the generated output kept, got %q", stored.ExpectedOutput)
+	}
+
+	w := send("POST", fmt.Sprintf("/admin/problems/%d/testcases/generate-outputs", problem.ID), "")
+	var response struct {
+		Checks []services.OutputCheck `json:"checks"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if len(response.Checks) != 1 || response.Checks[0].Status != models.OutputStatusDisputed || len(response.Checks[0].Runs) != 2 {
+		t.Errorf("Expected the disputed check with both runs, got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"leetcode-clone-backend/pkg/auth"
+	"leetcode-clone-backend/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RejudgeHandlers handles HTTP requests for rejudging submissions
+type RejudgeHandlers struct {
+	rejudgeService services.RejudgeServiceInterface
+}
+
+// NewRejudgeHandlers creates a new rejudge handlers instance
+func NewRejudgeHandlers(rejudgeService services.RejudgeServiceInterface) *RejudgeHandlers {
+	return &RejudgeHandlers{
+		rejudgeService: rejudgeService,
+	}
+}
+
+// RejudgeRequest represents the request payload for problem and range rejudges
+type RejudgeRequest struct {
+	ProblemID int        `json:"problem_id"`
+	From      *time.Time `json:"from"`
+	To        *time.Time `json:"to"`
+}
+
+// RejudgeSubmission handles POST /admin/rejudge/submissions/:id
+func (h *RejudgeHandlers) RejudgeSubmission(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid submission ID",
+			"details": "Submission ID must be a valid integer",
+		})
+		return
+	}
+
+	job, err := h.rejudgeService.RejudgeSubmission(id, adminUserID(c))
+	if err != nil {
+		h.handleCreateError(c, err, "Submission not found")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// RejudgeProblem handles POST /admin/rejudge/problems/:id
+func (h *RejudgeHandlers) RejudgeProblem(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid problem ID",
+			"details": "Problem ID must be a valid integer",
+		})
+		return
+	}
+
+	// The body is optional; without it every submission for the problem is rejudged
+	var req RejudgeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request body",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	job, err := h.rejudgeService.RejudgeProblem(id, req.From, req.To, adminUserID(c))
+	if err != nil {
+		h.handleCreateError(c, err, "Problem not found")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// RejudgeRange handles POST /admin/rejudge
+func (h *RejudgeHandlers) RejudgeRange(c *gin.Context) {
+	var req RejudgeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	job, err := h.rejudgeService.RejudgeRange(req.ProblemID, req.From, req.To, adminUserID(c))
+	if err != nil {
+		h.handleCreateError(c, err, "Problem not found")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ListJobs handles GET /admin/rejudge/jobs
+func (h *RejudgeHandlers) ListJobs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	jobs, err := h.rejudgeService.ListJobs(page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list rejudge jobs",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// GetJob handles GET /admin/rejudge/jobs/:id
+func (h *RejudgeHandlers) GetJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid job ID",
+			"details": "Job ID must be a valid integer",
+		})
+		return
+	}
+
+	changedOnly := c.Query("changed") == "true"
+
+	details, err := h.rejudgeService.GetJob(id, changedOnly)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Rejudge job not found",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get rejudge job",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+// handleCreateError maps errors from queueing a rejudge job to HTTP responses
+func (h *RejudgeHandlers) handleCreateError(c *gin.Context, err error, notFoundMessage string) {
+	if strings.Contains(err.Error(), "invalid rejudge request") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid rejudge request",
+			"details": err.Error(),
+		})
+		return
+	}
+	if strings.Contains(err.Error(), "not found") {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   notFoundMessage,
+			"details": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "Failed to create rejudge job",
+		"details": err.Error(),
+	})
+}
+
+// adminUserID returns the ID of the authenticated user, or 0 if it is unavailable
+func adminUserID(c *gin.Context) int {
+	userInterface, exists := c.Get("user")
+	if !exists {
+		return 0
+	}
+	user, ok := userInterface.(*auth.Claims)
+	if !ok {
+		return 0
+	}
+	return user.UserID
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"leetcode-clone-backend/pkg/auth"
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock rejudge service
+type MockRejudgeService struct {
+	mock.Mock
+}
+
+func (m *MockRejudgeService) RejudgeSubmission(submissionID, adminID int) (*models.RejudgeJob, error) {
+	args := m.Called(submissionID, adminID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RejudgeJob), args.Error(1)
+}
+
+func (m *MockRejudgeService) RejudgeProblem(problemID int, from, to *time.Time, adminID int) (*models.RejudgeJob, error) {
+	args := m.Called(problemID, from, to, adminID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RejudgeJob), args.Error(1)
+}
+
+func (m *MockRejudgeService) RejudgeRange(problemID int, from, to *time.Time, adminID int) (*models.RejudgeJob, error) {
+	args := m.Called(problemID, from, to, adminID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RejudgeJob), args.Error(1)
+}
+
+func (m *MockRejudgeService) GetJob(id int, changedOnly bool) (*services.RejudgeJobDetails, error) {
+	args := m.Called(id, changedOnly)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.RejudgeJobDetails), args.Error(1)
+}
+
+func (m *MockRejudgeService) ListJobs(page, pageSize int) (*services.RejudgeJobListResponse, error) {
+	args := m.Called(page, pageSize)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.RejudgeJobListResponse), args.Error(1)
+}
+
+func setupRejudgeTestRouter() (*gin.Engine, *MockRejudgeService) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockService := new(MockRejudgeService)
+	handler := NewRejudgeHandlers(mockService)
+
+	router.Use(func(c *gin.Context) {
+		c.Set("user", &auth.Claims{UserID: 9, Username: "admin", IsAdmin: true})
+		c.Next()
+	})
+
+	admin := router.Group("/api/v1/admin")
+	admin.POST("/rejudge", handler.RejudgeRange)
+	admin.POST("/rejudge/submissions/:id", handler.RejudgeSubmission)
+	admin.POST("/rejudge/problems/:id", handler.RejudgeProblem)
+	admin.GET("/rejudge/jobs", handler.ListJobs)
+	admin.GET("/rejudge/jobs/:id", handler.GetJob)
+
+	return router, mockService
+}
+
+func TestRejudgeHandlers_RejudgeSubmission(t *testing.T) {
+	router, mockService := setupRejudgeTestRouter()
+
+	t.Run("queues job", func(t *testing.T) {
+		job := &models.RejudgeJob{ID: 1, Scope: models.RejudgeScopeSubmission, Status: models.RejudgeStatusQueued}
+		mockService.On("RejudgeSubmission", 42, 9).Return(job, nil).Once()
+
+		req, _ := http.NewRequest("POST", "/api/v1/admin/rejudge/submissions/42", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+
+		var response models.RejudgeJob
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, models.RejudgeStatusQueued, response.Status)
+	})
+
+	t.Run("submission not found", func(t *testing.T) {
+		mockService.On("RejudgeSubmission", 404, 9).Return(nil, errors.New("failed to retrieve submission: not found")).Once()
+
+		req, _ := http.NewRequest("POST", "/api/v1/admin/rejudge/submissions/404", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid submission ID", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/api/v1/admin/rejudge/submissions/abc", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRejudgeHandlers_RejudgeProblem(t *testing.T) {
+	router, mockService := setupRejudgeTestRouter()
+
+	t.Run("without body", func(t *testing.T) {
+		job := &models.RejudgeJob{ID: 2, Scope: models.RejudgeScopeProblem, Status: models.RejudgeStatusQueued}
+		mockService.On("RejudgeProblem", 3, (*time.Time)(nil), (*time.Time)(nil), 9).Return(job, nil).Once()
+
+		req, _ := http.NewRequest("POST", "/api/v1/admin/rejudge/problems/3", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	})
+
+	t.Run("with date range", func(t *testing.T) {
+		from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+		job := &models.RejudgeJob{ID: 3, Scope: models.RejudgeScopeProblem, Status: models.RejudgeStatusQueued}
+		mockService.On("RejudgeProblem", 3, &from, &to, 9).Return(job, nil).Once()
+
+		body, _ := json.Marshal(RejudgeRequest{From: &from, To: &to})
+		req, _ := http.NewRequest("POST", "/api/v1/admin/rejudge/problems/3", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	})
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRejudgeHandlers_RejudgeRange(t *testing.T) {
+	router, mockService := setupRejudgeTestRouter()
+
+	mockService.On("RejudgeRange", 0, mock.Anything, (*time.Time)(nil), 9).
+		Return(nil, errors.New("invalid rejudge request: both from and to are required")).Once()
+
+	req, _ := http.NewRequest("POST", "/api/v1/admin/rejudge", bytes.NewBufferString(`{"from":"2024-01-01T00:00:00Z"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestRejudgeHandlers_GetJob(t *testing.T) {
+	router, mockService := setupRejudgeTestRouter()
+
+	t.Run("changed results only", func(t *testing.T) {
+		details := &services.RejudgeJobDetails{
+			Job: &models.RejudgeJob{ID: 4, Status: models.RejudgeStatusCompleted, Total: 2, Processed: 2, Changed: 1},
+			Results: []*models.RejudgeResult{
+				{JobID: 4, SubmissionID: 10, OldStatus: models.StatusAccepted, NewStatus: models.StatusWrongAnswer, VerdictChanged: true},
+			},
+		}
+		mockService.On("GetJob", 4, true).Return(details, nil).Once()
+
+		req, _ := http.NewRequest("GET", "/api/v1/admin/rejudge/jobs/4?changed=true", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response services.RejudgeJobDetails
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, response.Job.Changed)
+		assert.Len(t, response.Results, 1)
+	})
+
+	t.Run("job not found", func(t *testing.T) {
+		mockService.On("GetJob", 99, false).Return(nil, errors.New("failed to retrieve rejudge job: not found")).Once()
+
+		req, _ := http.NewRequest("GET", "/api/v1/admin/rejudge/jobs/99", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	mockService.AssertExpectations(t)
+}
@@ -1,16 +1,24 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"leetcode-clone-backend/pkg/auth"
+	"leetcode-clone-backend/pkg/middleware"
+	"leetcode-clone-backend/pkg/pagination"
 	"leetcode-clone-backend/pkg/repository"
 	"leetcode-clone-backend/pkg/services"
 
 	"github.com/gin-gonic/gin"
 )
 
+// defaultSubmissionPageSize is the number of submissions listed per page unless the client asks for more
+const defaultSubmissionPageSize = 20
+
 // SubmissionHandlers handles submission-related HTTP requests
 type SubmissionHandlers struct {
 	submissionService services.SubmissionServiceInterface
@@ -62,9 +70,14 @@ func (sh *SubmissionHandlers) CreateSubmission(c *gin.Context) {
 	// Process the submission
 	result, err := sh.submissionService.ProcessSubmission(submissionReq)
 	if err != nil {
+		if repository.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	middleware.RecordSandboxTime(c, result.SandboxTime)
 
 	c.JSON(http.StatusCreated, result)
 }
@@ -102,8 +115,7 @@ func (sh *SubmissionHandlers) GetSubmission(c *gin.Context) {
 	}
 
 	// Users can only view their own submissions (unless they're admin)
-	if submission.UserID != user.UserID {
-		// TODO: Check if user is admin - for now, deny access
+	if submission.UserID != user.UserID && !user.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
@@ -111,6 +123,92 @@ func (sh *SubmissionHandlers) GetSubmission(c *gin.Context) {
 	c.JSON(http.StatusOK, submission)
 }
 
+// GetSubmissionDetails handles GET /api/v1/submissions/:id/details
+func (sh *SubmissionHandlers) GetSubmissionDetails(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid submission ID"})
+		return
+	}
+
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, ok := userInterface.(*auth.Claims)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user context"})
+		return
+	}
+
+	// Hidden test case content is only visible to admins
+	details, err := sh.submissionService.GetSubmissionDetails(id, user.IsAdmin)
+	if err != nil {
+		if repository.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve submission details"})
+		return
+	}
+
+	// Users can only view their own submissions (unless they're admin)
+	if details.Submission.UserID != user.UserID && !user.IsAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+// DiffSubmissions handles GET /api/v1/submissions/:id/diff/:otherId
+func (sh *SubmissionHandlers) DiffSubmissions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid submission ID"})
+		return
+	}
+
+	otherID, err := strconv.Atoi(c.Param("otherId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid submission ID"})
+		return
+	}
+
+	userInterface, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, ok := userInterface.(*auth.Claims)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user context"})
+		return
+	}
+
+	result, err := sh.submissionService.DiffSubmissions(id, otherID)
+	if err != nil {
+		if repository.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to diff submissions"})
+		return
+	}
+
+	// Users can only compare their own submissions (unless they're admin)
+	if (result.From.UserID != user.UserID || result.To.UserID != user.UserID) && !user.IsAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 // GetUserSubmissions handles GET /api/v1/submissions/user/:userId or GET /api/v1/submissions/me
 func (sh *SubmissionHandlers) GetUserSubmissions(c *gin.Context) {
 	// Get user from context
@@ -151,32 +249,25 @@ func (sh *SubmissionHandlers) GetUserSubmissions(c *gin.Context) {
 	}
 
 	// Parse pagination parameters
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-
-	// Parse problem filter if provided
-	problemIDStr := c.Query("problem_id")
-	if problemIDStr != "" {
-		problemID, err := strconv.Atoi(problemIDStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid problem ID"})
-			return
-		}
-
-		// Get submissions for specific user and problem
-		result, err := sh.submissionService.GetUserProblemSubmissions(targetUserID, problemID, page, pageSize)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve submissions"})
-			return
-		}
+	params, err := pagination.ParseQuery(c.Request.URL.Query(), defaultSubmissionPageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-		c.JSON(http.StatusOK, result)
+	filters, err := parseSubmissionFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	filters.UserID = targetUserID
 
-	// Get all submissions for the user
-	result, err := sh.submissionService.GetUserSubmissions(targetUserID, page, pageSize)
+	result, err := sh.submissionService.ListSubmissions(filters, params)
 	if err != nil {
+		if strings.Contains(err.Error(), "invalid filters") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve submissions"})
 		return
 	}
@@ -184,9 +275,9 @@ func (sh *SubmissionHandlers) GetUserSubmissions(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
-// GetProblemSubmissions handles GET /api/v1/problems/:problemId/submissions
+// GetProblemSubmissions handles GET /api/v1/problems/:id/submissions
 func (sh *SubmissionHandlers) GetProblemSubmissions(c *gin.Context) {
-	problemIDStr := c.Param("problemId")
+	problemIDStr := c.Param("id")
 	problemID, err := strconv.Atoi(problemIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid problem ID"})
@@ -194,11 +285,25 @@ func (sh *SubmissionHandlers) GetProblemSubmissions(c *gin.Context) {
 	}
 
 	// Parse pagination parameters
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	params, err := pagination.ParseQuery(c.Request.URL.Query(), defaultSubmissionPageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filters, err := parseSubmissionFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filters.ProblemID = problemID
 
-	result, err := sh.submissionService.GetProblemSubmissions(problemID, page, pageSize)
+	result, err := sh.submissionService.ListSubmissions(filters, params)
 	if err != nil {
+		if strings.Contains(err.Error(), "invalid filters") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve submissions"})
 		return
 	}
@@ -253,3 +358,54 @@ func (sh *SubmissionHandlers) GetUserSubmissionStats(c *gin.Context) {
 
 	c.JSON(http.StatusOK, stats)
 }
+
+// parseSubmissionFilters parses the status, language, problem_id, from and to query parameters.
+// Dates are accepted as RFC 3339 timestamps or as YYYY-MM-DD, in which case "to" includes the whole day.
+func parseSubmissionFilters(c *gin.Context) (repository.SubmissionFilters, error) {
+	var filters repository.SubmissionFilters
+
+	if status := c.Query("status"); status != "" && status != "all" {
+		filters.Status = status
+	}
+	if language := c.Query("language"); language != "" && language != "all" {
+		filters.Language = language
+	}
+
+	if problemIDStr := c.Query("problem_id"); problemIDStr != "" {
+		problemID, err := strconv.Atoi(problemIDStr)
+		if err != nil || problemID <= 0 {
+			return filters, errors.New("Invalid problem ID")
+		}
+		filters.ProblemID = problemID
+	}
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, _, err := parseSubmissionDate(fromStr)
+		if err != nil {
+			return filters, errors.New("Invalid from date")
+		}
+		filters.From = &from
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		to, dateOnly, err := parseSubmissionDate(toStr)
+		if err != nil {
+			return filters, errors.New("Invalid to date")
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filters.To = &to
+	}
+
+	return filters, nil
+}
+
+// parseSubmissionDate parses an RFC 3339 timestamp or a YYYY-MM-DD date
+func parseSubmissionDate(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	return t, true, err
+}
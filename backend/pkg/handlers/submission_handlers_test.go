@@ -4,13 +4,16 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"leetcode-clone-backend/pkg/auth"
+	"leetcode-clone-backend/pkg/diff"
 	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/pagination"
 	"leetcode-clone-backend/pkg/repository"
 	"leetcode-clone-backend/pkg/services"
 
@@ -40,6 +43,30 @@ func (m *MockSubmissionService) GetSubmissionByID(id int) (*models.Submission, e
 	return args.Get(0).(*models.Submission), args.Error(1)
 }
 
+func (m *MockSubmissionService) GetSubmissionDetails(id int, includeHidden bool) (*services.SubmissionDetails, error) {
+	args := m.Called(id, includeHidden)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.SubmissionDetails), args.Error(1)
+}
+
+func (m *MockSubmissionService) DiffSubmissions(id, otherID int) (*services.SubmissionDiff, error) {
+	args := m.Called(id, otherID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.SubmissionDiff), args.Error(1)
+}
+
+func (m *MockSubmissionService) ListSubmissions(filters repository.SubmissionFilters, params pagination.Params) (*services.SubmissionListResponse, error) {
+	args := m.Called(filters, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.SubmissionListResponse), args.Error(1)
+}
+
 func (m *MockSubmissionService) GetUserSubmissions(userID, page, pageSize int) (*services.SubmissionListResponse, error) {
 	args := m.Called(userID, page, pageSize)
 	if args.Get(0) == nil {
@@ -94,11 +121,13 @@ func setupSubmissionTestRouter() (*gin.Engine, *MockSubmissionService) {
 	api := router.Group("/api/v1")
 	api.POST("/submissions", handler.CreateSubmission)
 	api.GET("/submissions/:id", handler.GetSubmission)
+	api.GET("/submissions/:id/details", handler.GetSubmissionDetails)
+	api.GET("/submissions/:id/diff/:otherId", handler.DiffSubmissions)
 	api.GET("/submissions/me", handler.GetUserSubmissions)
 	api.GET("/submissions/user/:userId", handler.GetUserSubmissions)
 	api.GET("/submissions/stats/me", handler.GetUserSubmissionStats)
 	api.GET("/submissions/stats/:userId", handler.GetUserSubmissionStats)
-	api.GET("/problems/:problemId/submissions", handler.GetProblemSubmissions)
+	api.GET("/problems/:id/submissions", handler.GetProblemSubmissions)
 
 	return router, mockService
 }
@@ -185,6 +214,30 @@ func TestSubmissionHandlers_CreateSubmission(t *testing.T) {
 
 		mockService2.AssertExpectations(t)
 	})
+
+	t.Run("problem not found", func(t *testing.T) {
+		router3, mockService3 := setupSubmissionTestRouter()
+
+		requestBody := SubmitCodeRequest{
+			ProblemID: 2,
+			Language:  models.LanguageJavaScript,
+			Code:      "function solution() {}",
+		}
+
+		notFound := fmt.Errorf("failed to retrieve problem: %w", repository.NewRepositoryError("GetByID", repository.ErrNotFound, "problem_not_found"))
+		mockService3.On("ProcessSubmission", mock.AnythingOfType("*services.SubmissionRequest")).Return(nil, notFound)
+
+		body, _ := json.Marshal(requestBody)
+		req, _ := http.NewRequest("POST", "/api/v1/submissions", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router3.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		mockService3.AssertExpectations(t)
+	})
 }
 
 func TestSubmissionHandlers_GetSubmission(t *testing.T) {
@@ -260,6 +313,132 @@ func TestSubmissionHandlers_GetSubmission(t *testing.T) {
 	})
 }
 
+func TestSubmissionHandlers_GetSubmissionDetails(t *testing.T) {
+	t.Run("owner receives redacted details", func(t *testing.T) {
+		router, mockService := setupSubmissionTestRouter()
+
+		details := &services.SubmissionDetails{
+			Submission: &models.Submission{ID: 1, UserID: 1, Status: models.StatusWrongAnswer},
+			TestCaseResults: []*models.SubmissionTestResult{
+				{Position: 1, Status: models.StatusWrongAnswer, IsHidden: true, Redacted: true},
+			},
+		}
+
+		// The mock user is not an admin, so hidden content must not be requested
+		mockService.On("GetSubmissionDetails", 1, false).Return(details, nil)
+
+		req, _ := http.NewRequest("GET", "/api/v1/submissions/1/details", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response services.SubmissionDetails
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Len(t, response.TestCaseResults, 1)
+		assert.True(t, response.TestCaseResults[0].Redacted)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("access denied - different user", func(t *testing.T) {
+		router, mockService := setupSubmissionTestRouter()
+
+		details := &services.SubmissionDetails{
+			Submission: &models.Submission{ID: 2, UserID: 2},
+		}
+		mockService.On("GetSubmissionDetails", 2, false).Return(details, nil)
+
+		req, _ := http.NewRequest("GET", "/api/v1/submissions/2/details", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("submission not found", func(t *testing.T) {
+		router, mockService := setupSubmissionTestRouter()
+
+		mockService.On("GetSubmissionDetails", 999, false).Return(nil, repository.NewRepositoryError("GetByID", repository.ErrNotFound, "not_found"))
+
+		req, _ := http.NewRequest("GET", "/api/v1/submissions/999/details", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestSubmissionHandlers_DiffSubmissions(t *testing.T) {
+	t.Run("own submissions", func(t *testing.T) {
+		router, mockService := setupSubmissionTestRouter()
+
+		result := &services.SubmissionDiff{
+			From:            &services.SubmissionDiffSide{ID: 1, UserID: 1, Language: models.LanguagePython, Status: models.StatusWrongAnswer},
+			To:              &services.SubmissionDiffSide{ID: 2, UserID: 1, Language: models.LanguageJava, Status: models.StatusAccepted},
+			LanguagesDiffer: true,
+			Notes:           []string{"The submissions are written in different languages (python and java)"},
+			Result:          diff.Compute("a", "b", "x\n", "y\n", diff.DefaultContext),
+		}
+		mockService.On("DiffSubmissions", 1, 2).Return(result, nil)
+
+		req, _ := http.NewRequest("GET", "/api/v1/submissions/1/diff/2", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, true, response["languages_differ"])
+		assert.Contains(t, response["unified"], "-x\n+y\n")
+		assert.Len(t, response["hunks"], 1)
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("access denied - other user's submission", func(t *testing.T) {
+		router, mockService := setupSubmissionTestRouter()
+
+		result := &services.SubmissionDiff{
+			From:   &services.SubmissionDiffSide{ID: 1, UserID: 1},
+			To:     &services.SubmissionDiffSide{ID: 3, UserID: 2},
+			Result: diff.Compute("a", "b", "", "", diff.DefaultContext),
+		}
+		mockService.On("DiffSubmissions", 1, 3).Return(result, nil)
+
+		req, _ := http.NewRequest("GET", "/api/v1/submissions/1/diff/3", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("submission not found", func(t *testing.T) {
+		router, mockService := setupSubmissionTestRouter()
+
+		mockService.On("DiffSubmissions", 1, 999).Return(nil, repository.NewRepositoryError("GetByID", repository.ErrNotFound, "not_found"))
+
+		req, _ := http.NewRequest("GET", "/api/v1/submissions/1/diff/999", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid other ID", func(t *testing.T) {
+		router, _ := setupSubmissionTestRouter()
+
+		req, _ := http.NewRequest("GET", "/api/v1/submissions/1/diff/abc", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
 func TestSubmissionHandlers_GetUserSubmissions(t *testing.T) {
 	router, mockService := setupSubmissionTestRouter()
 
@@ -269,13 +448,10 @@ func TestSubmissionHandlers_GetUserSubmissions(t *testing.T) {
 				{ID: 1, UserID: 1, Status: models.StatusAccepted},
 				{ID: 2, UserID: 1, Status: models.StatusWrongAnswer},
 			},
-			Total:    2,
-			Page:     1,
-			PageSize: 20,
-			HasNext:  false,
+			Meta: pagination.Meta{Total: 2, Page: 1, Limit: 20, HasNext: false},
 		}
 
-		mockService.On("GetUserSubmissions", 1, 1, 20).Return(expectedResponse, nil)
+		mockService.On("ListSubmissions", repository.SubmissionFilters{UserID: 1}, pagination.Params{Page: 1, Limit: 20}).Return(expectedResponse, nil)
 
 		req, _ := http.NewRequest("GET", "/api/v1/submissions/me", nil)
 		w := httptest.NewRecorder()
@@ -296,13 +472,10 @@ func TestSubmissionHandlers_GetUserSubmissions(t *testing.T) {
 			Submissions: []*models.Submission{
 				{ID: 1, UserID: 1, ProblemID: 1, Status: models.StatusAccepted},
 			},
-			Total:    1,
-			Page:     1,
-			PageSize: 20,
-			HasNext:  false,
+			Meta: pagination.Meta{Total: 1, Page: 1, Limit: 20, HasNext: false},
 		}
 
-		mockService.On("GetUserProblemSubmissions", 1, 1, 1, 20).Return(expectedResponse, nil)
+		mockService.On("ListSubmissions", repository.SubmissionFilters{UserID: 1, ProblemID: 1}, pagination.Params{Page: 1, Limit: 20}).Return(expectedResponse, nil)
 
 		req, _ := http.NewRequest("GET", "/api/v1/submissions/me?problem_id=1", nil)
 		w := httptest.NewRecorder()
@@ -313,6 +486,62 @@ func TestSubmissionHandlers_GetUserSubmissions(t *testing.T) {
 		mockService.AssertExpectations(t)
 	})
 
+	t.Run("get user submissions with status, language and date filters", func(t *testing.T) {
+		from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+		filters := repository.SubmissionFilters{
+			UserID:   1,
+			Status:   models.StatusWrongAnswer,
+			Language: models.LanguagePython,
+			From:     &from,
+			To:       &to,
+		}
+		expectedResponse := &services.SubmissionListResponse{
+			Submissions: []*models.Submission{
+				{ID: 3, UserID: 1, ProblemID: 2, Status: models.StatusWrongAnswer, Problem: &models.SubmissionProblem{ID: 2, Title: "Add Two Numbers", Difficulty: models.DifficultyMedium}},
+			},
+			Meta: pagination.Meta{Total: 41, Page: 2, Limit: 20, HasNext: true},
+		}
+
+		mockService.On("ListSubmissions", filters, pagination.Params{Page: 2, Limit: 20, Offset: 20}).Return(expectedResponse, nil)
+
+		req, _ := http.NewRequest("GET", "/api/v1/submissions/me?page=2&status=Wrong+Answer&language=python&from=2024-01-01&to=2024-01-31", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(41), response["total"])
+		submission := response["submissions"].([]interface{})[0].(map[string]interface{})
+		problem := submission["problem"].(map[string]interface{})
+		assert.Equal(t, "Add Two Numbers", problem["title"])
+		assert.Equal(t, "Medium", problem["difficulty"])
+
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid date filter", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/submissions/me?from=yesterday", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid status filter", func(t *testing.T) {
+		mockService.On("ListSubmissions", repository.SubmissionFilters{UserID: 1, Status: "Pending"}, pagination.Params{Page: 1, Limit: 20}).
+			Return(nil, errors.New("invalid filters: invalid status: Pending"))
+
+		req, _ := http.NewRequest("GET", "/api/v1/submissions/me?status=Pending", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
 	t.Run("access denied - different user", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/api/v1/submissions/user/2", nil)
 		w := httptest.NewRecorder()
@@ -374,13 +603,10 @@ func TestSubmissionHandlers_GetProblemSubmissions(t *testing.T) {
 				{ID: 1, UserID: 1, ProblemID: 1, Status: models.StatusAccepted},
 				{ID: 2, UserID: 2, ProblemID: 1, Status: models.StatusWrongAnswer},
 			},
-			Total:    2,
-			Page:     1,
-			PageSize: 20,
-			HasNext:  false,
+			Meta: pagination.Meta{Total: 2, Page: 1, Limit: 20, HasNext: false},
 		}
 
-		mockService.On("GetProblemSubmissions", 1, 1, 20).Return(expectedResponse, nil)
+		mockService.On("ListSubmissions", repository.SubmissionFilters{ProblemID: 1}, pagination.Params{Page: 1, Limit: 20}).Return(expectedResponse, nil)
 
 		req, _ := http.NewRequest("GET", "/api/v1/problems/1/submissions", nil)
 		w := httptest.NewRecorder()
@@ -9,8 +9,11 @@ import (
 	"time"
 
 	"leetcode-clone-backend/pkg/auth"
+	"leetcode-clone-backend/pkg/diff"
 	"leetcode-clone-backend/pkg/execution"
 	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/pagination"
+	"leetcode-clone-backend/pkg/repository"
 	"leetcode-clone-backend/pkg/services"
 
 	"github.com/gin-gonic/gin"
@@ -136,6 +139,44 @@ func (m *MockSubmissionServiceIntegration) GetSubmissionByID(id int) (*models.Su
 	}, nil
 }
 
+func (m *MockSubmissionServiceIntegration) GetSubmissionDetails(id int, includeHidden bool) (*services.SubmissionDetails, error) {
+	submission, err := m.GetSubmissionByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return &services.SubmissionDetails{
+		Submission:      submission,
+		TestCaseResults: []*models.SubmissionTestResult{},
+	}, nil
+}
+
+func (m *MockSubmissionServiceIntegration) DiffSubmissions(id, otherID int) (*services.SubmissionDiff, error) {
+	from, err := m.GetSubmissionByID(id)
+	if err != nil {
+		return nil, err
+	}
+	to, err := m.GetSubmissionByID(otherID)
+	if err != nil {
+		return nil, err
+	}
+	return &services.SubmissionDiff{
+		From:   &services.SubmissionDiffSide{ID: from.ID, UserID: from.UserID, Language: from.Language},
+		To:     &services.SubmissionDiffSide{ID: to.ID, UserID: to.UserID, Language: to.Language},
+		Result: diff.Compute("a", "b", from.Code, to.Code, diff.DefaultContext),
+	}, nil
+}
+
+func (m *MockSubmissionServiceIntegration) ListSubmissions(filters repository.SubmissionFilters, params pagination.Params) (*services.SubmissionListResponse, error) {
+	switch {
+	case filters.UserID > 0 && filters.ProblemID > 0:
+		return m.GetUserProblemSubmissions(filters.UserID, filters.ProblemID, params.Page, params.Limit)
+	case filters.UserID > 0:
+		return m.GetUserSubmissions(filters.UserID, params.Page, params.Limit)
+	default:
+		return m.GetProblemSubmissions(filters.ProblemID, params.Page, params.Limit)
+	}
+}
+
 func (m *MockSubmissionServiceIntegration) GetUserSubmissions(userID, page, pageSize int) (*services.SubmissionListResponse, error) {
 	runtime := 150
 	memory := 1024
@@ -156,30 +197,21 @@ func (m *MockSubmissionServiceIntegration) GetUserSubmissions(userID, page, page
 
 	return &services.SubmissionListResponse{
 		Submissions: submissions,
-		Total:       1,
-		Page:        page,
-		PageSize:    pageSize,
-		HasNext:     false,
+		Meta:        pagination.Meta{Total: 1, Page: page, Limit: pageSize},
 	}, nil
 }
 
 func (m *MockSubmissionServiceIntegration) GetProblemSubmissions(problemID, page, pageSize int) (*services.SubmissionListResponse, error) {
 	return &services.SubmissionListResponse{
 		Submissions: []*models.Submission{},
-		Total:       0,
-		Page:        page,
-		PageSize:    pageSize,
-		HasNext:     false,
+		Meta:        pagination.Meta{Total: 0, Page: page, Limit: pageSize},
 	}, nil
 }
 
 func (m *MockSubmissionServiceIntegration) GetUserProblemSubmissions(userID, problemID, page, pageSize int) (*services.SubmissionListResponse, error) {
 	return &services.SubmissionListResponse{
 		Submissions: []*models.Submission{},
-		Total:       0,
-		Page:        page,
-		PageSize:    pageSize,
-		HasNext:     false,
+		Meta:        pagination.Meta{Total: 0, Page: page, Limit: pageSize},
 	}, nil
 }
 
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"leetcode-clone-backend/pkg/auth"
+	"leetcode-clone-backend/pkg/repository"
+	"leetcode-clone-backend/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TagHandlers handles HTTP requests for the tag catalog
+type TagHandlers struct {
+	tagService services.TagServiceInterface
+}
+
+// NewTagHandlers creates a new tag handlers instance
+func NewTagHandlers(tagService services.TagServiceInterface) *TagHandlers {
+	return &TagHandlers{
+		tagService: tagService,
+	}
+}
+
+// UpdateTagRequest represents the request payload for updating a tag
+type UpdateTagRequest struct {
+	Description *string `json:"description"`
+}
+
+// RenameTagRequest represents the request payload for renaming a tag
+type RenameTagRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// MergeTagsRequest represents the request payload for merging tags
+type MergeTagsRequest struct {
+	Sources []string `json:"sources" binding:"required,min=1"`
+	Target  string   `json:"target" binding:"required"`
+}
+
+// ListTags handles GET /api/v1/problems/tags
+func (h *TagHandlers) ListTags(c *gin.Context) {
+	tags, err := h.tagService.ListTags(optionalUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve tags",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tags": tags,
+	})
+}
+
+// UpdateTag handles PUT /api/v1/admin/tags/:name
+func (h *TagHandlers) UpdateTag(c *gin.Context) {
+	var req UpdateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tag, err := h.tagService.UpdateTag(c.Param("name"), req.Description)
+	if err != nil {
+		h.handleError(c, err, "Failed to update tag")
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// RenameTag handles POST /api/v1/admin/tags/:name/rename
+func (h *TagHandlers) RenameTag(c *gin.Context) {
+	var req RenameTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.tagService.RenameTag(c.Param("name"), req.Name, adminUserID(c))
+	if err != nil {
+		h.handleError(c, err, "Failed to rename tag")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// MergeTags handles POST /api/v1/admin/tags/merge
+func (h *TagHandlers) MergeTags(c *gin.Context) {
+	var req MergeTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.tagService.MergeTags(req.Sources, req.Target, adminUserID(c))
+	if err != nil {
+		h.handleError(c, err, "Failed to merge tags")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// handleError maps tag service errors to HTTP responses
+func (h *TagHandlers) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case strings.Contains(err.Error(), "invalid tag"):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid tag",
+			"details": err.Error(),
+		})
+	case repository.IsNotFound(err):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Tag not found",
+			"details": err.Error(),
+		})
+	case repository.IsConflict(err):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Tag already exists",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
+
+// optionalUserID returns the ID of the authenticated user, or 0 for anonymous requests
+func optionalUserID(c *gin.Context) int {
+	userInterface, exists := c.Get("user")
+	if !exists {
+		return 0
+	}
+	user, ok := userInterface.(*auth.Claims)
+	if !ok {
+		return 0
+	}
+	return user.UserID
+}
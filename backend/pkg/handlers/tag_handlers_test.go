@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"leetcode-clone-backend/pkg/auth"
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+	"leetcode-clone-backend/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock tag service
+type MockTagService struct {
+	mock.Mock
+}
+
+func (m *MockTagService) ListTags(userID int) ([]*models.Tag, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Tag), args.Error(1)
+}
+
+func (m *MockTagService) UpdateTag(name string, description *string) (*models.Tag, error) {
+	args := m.Called(name, description)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Tag), args.Error(1)
+}
+
+func (m *MockTagService) RenameTag(name, newName string, authorID int) (*services.TagMergeResult, error) {
+	args := m.Called(name, newName, authorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.TagMergeResult), args.Error(1)
+}
+
+func (m *MockTagService) MergeTags(sources []string, target string, authorID int) (*services.TagMergeResult, error) {
+	args := m.Called(sources, target, authorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.TagMergeResult), args.Error(1)
+}
+
+func setupTagTestRouter(user *auth.Claims) (*gin.Engine, *MockTagService) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockService := new(MockTagService)
+	handler := NewTagHandlers(mockService)
+
+	router.Use(func(c *gin.Context) {
+		if user != nil {
+			c.Set("user", user)
+		}
+		c.Next()
+	})
+
+	router.GET("/api/v1/problems/tags", handler.ListTags)
+	router.PUT("/api/v1/admin/tags/:name", handler.UpdateTag)
+	router.POST("/api/v1/admin/tags/:name/rename", handler.RenameTag)
+	router.POST("/api/v1/admin/tags/merge", handler.MergeTags)
+
+	return router, mockService
+}
+
+func TestTagHandlers_ListTags(t *testing.T) {
+	t.Run("anonymous users get counts without solved", func(t *testing.T) {
+		router, mockService := setupTagTestRouter(nil)
+		mockService.On("ListTags", 0).Return([]*models.Tag{{Name: "Array", ProblemCount: 2, EasyCount: 1, HardCount: 1}}, nil)
+
+		req, _ := http.NewRequest("GET", "/api/v1/problems/tags", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Tags []map[string]interface{} `json:"tags"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response.Tags, 1)
+		assert.Equal(t, "Array", response.Tags[0]["name"])
+		assert.Equal(t, float64(1), response.Tags[0]["hard_count"])
+		assert.NotContains(t, response.Tags[0], "solved_count")
+	})
+
+	t.Run("signed-in users get their solved count", func(t *testing.T) {
+		router, mockService := setupTagTestRouter(&auth.Claims{UserID: 3})
+		solved := 1
+		mockService.On("ListTags", 3).Return([]*models.Tag{{Name: "Array", ProblemCount: 2, SolvedCount: &solved}}, nil)
+
+		req, _ := http.NewRequest("GET", "/api/v1/problems/tags", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"solved_count":1`)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		router, mockService := setupTagTestRouter(nil)
+		mockService.On("ListTags", 0).Return(nil, errors.New("database error"))
+
+		req, _ := http.NewRequest("GET", "/api/v1/problems/tags", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestTagHandlers_UpdateTag(t *testing.T) {
+	router, mockService := setupTagTestRouter(&auth.Claims{UserID: 1, IsAdmin: true})
+	description := "Two pointers and sliding windows"
+	mockService.On("UpdateTag", "Two Pointers", &description).Return(&models.Tag{Name: "Two Pointers", Description: &description}, nil)
+
+	body, _ := json.Marshal(UpdateTagRequest{Description: &description})
+	req, _ := http.NewRequest("PUT", "/api/v1/admin/tags/Two%20Pointers", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), description)
+}
+
+func TestTagHandlers_RenameTag(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{"renamed", nil, http.StatusOK},
+		{"invalid name", errors.New("invalid tag: tag name cannot contain commas"), http.StatusBadRequest},
+		{"not found", fmt.Errorf("failed to retrieve tag: %w", repository.ErrNotFound), http.StatusNotFound},
+		{"target exists", fmt.Errorf("tag \"Graph\" already exists, merge the tags instead: %w", repository.ErrConflict), http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockService := setupTagTestRouter(&auth.Claims{UserID: 1, IsAdmin: true})
+			if tt.err != nil {
+				mockService.On("RenameTag", "Graphs", "Graph", 1).Return(nil, tt.err)
+			} else {
+				mockService.On("RenameTag", "Graphs", "Graph", 1).Return(&services.TagMergeResult{Tag: &models.Tag{Name: "Graph"}, ProblemsUpdated: 4}, nil)
+			}
+
+			body, _ := json.Marshal(RenameTagRequest{Name: "Graph"})
+			req, _ := http.NewRequest("POST", "/api/v1/admin/tags/Graphs/rename", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.err == nil {
+				assert.Contains(t, w.Body.String(), `"problems_updated":4`)
+			}
+		})
+	}
+}
+
+func TestTagHandlers_MergeTags(t *testing.T) {
+	t.Run("merges tags", func(t *testing.T) {
+		router, mockService := setupTagTestRouter(&auth.Claims{UserID: 1, IsAdmin: true})
+		mockService.On("MergeTags", []string{"DP", "Memoization"}, "Dynamic Programming", 1).
+			Return(&services.TagMergeResult{Tag: &models.Tag{Name: "Dynamic Programming"}, ProblemsUpdated: 6}, nil)
+
+		body, _ := json.Marshal(MergeTagsRequest{Sources: []string{"DP", "Memoization"}, Target: "Dynamic Programming"})
+		req, _ := http.NewRequest("POST", "/api/v1/admin/tags/merge", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"problems_updated":6`)
+	})
+
+	t.Run("missing sources", func(t *testing.T) {
+		router, _ := setupTagTestRouter(&auth.Claims{UserID: 1, IsAdmin: true})
+
+		req, _ := http.NewRequest("POST", "/api/v1/admin/tags/merge", bytes.NewBufferString(`{"target":"Array"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"leetcode-clone-backend/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTestCase handles GET /admin/testcases/:id, returning a test case with its data
+func (h *ProblemHandlers) GetTestCase(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid test case ID",
+			"details": "Test case ID must be a valid integer",
+		})
+		return
+	}
+
+	testCase, err := h.problemService.GetTestCase(id)
+	if err != nil {
+		handleTestCaseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, testCase)
+}
+
+// GetTestCaseInput handles GET /admin/testcases/:id/input, returning the input as a text file
+func (h *ProblemHandlers) GetTestCaseInput(c *gin.Context) {
+	h.getTestCaseData(c, "in")
+}
+
+// GetTestCaseOutput handles GET /admin/testcases/:id/output, returning the expected output as a
+// text file
+func (h *ProblemHandlers) GetTestCaseOutput(c *gin.Context) {
+	h.getTestCaseData(c, "out")
+}
+
+// getTestCaseData responds with the input or expected output of a test case, named after the
+// test case with extension ext
+func (h *ProblemHandlers) getTestCaseData(c *gin.Context, ext string) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid test case ID",
+			"details": "Test case ID must be a valid integer",
+		})
+		return
+	}
+
+	testCase, err := h.problemService.GetTestCase(id)
+	if err != nil {
+		handleTestCaseError(c, err)
+		return
+	}
+
+	data := testCase.Input
+	if ext == "out" {
+		data = testCase.ExpectedOutput
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="testcase-%d.%s"`, id, ext))
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(data))
+}
+
+// UploadTestCases handles POST /admin/problems/:id/testcases/bulk. Test data is uploaded as a zip
+// file, either as the request body or the "archive" file of a multipart form, or as the "files"
+// of a multipart form. The public query parameter lists the names of the public tests, separated
+// by commas; replace=true deletes the existing test cases first, and generate=true generates the
+// expected outputs with the reference solutions of the problem.
+func (h *ProblemHandlers) UploadTestCases(c *gin.Context) {
+	problemID, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+
+	files, err := readTestDataUpload(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid test data upload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	upload := &services.TestCaseUpload{
+		Files:           files,
+		Replace:         c.Query("replace") == "true",
+		GenerateOutputs: c.Query("generate") == "true",
+	}
+	for _, names := range c.QueryArray("public") {
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				upload.Public = append(upload.Public, name)
+			}
+		}
+	}
+
+	result, err := h.problemService.UploadTestCases(problemID, upload, adminUserID(c))
+	if err != nil {
+		handleReferenceSolutionError(c, err, "Failed to upload test cases")
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// handleTestCaseError responds to an error getting a test case
+func handleTestCaseError(c *gin.Context, err error) {
+	if strings.Contains(err.Error(), "not found") {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Test case not found",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "Failed to get test case",
+		"details": err.Error(),
+	})
+}
+
+// readTestDataUpload reads the test data files uploaded with a request, up to
+// services.MaxTestCaseUploadSize in total
+func readTestDataUpload(c *gin.Context) ([]services.TestDataFile, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, services.MaxTestCaseUploadSize)
+
+	if !strings.HasPrefix(c.ContentType(), "multipart/") {
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read upload: %w", err)
+		}
+		if len(data) == 0 {
+			return nil, fmt.Errorf("test data is required")
+		}
+		return readTestDataZip(data)
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if archives := form.File["archive"]; len(archives) > 0 {
+		data, err := readFormFileHeader(archives[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read upload: %w", err)
+		}
+		return readTestDataZip(data)
+	}
+
+	var files []services.TestDataFile
+	for _, header := range form.File["files"] {
+		data, err := readFormFileHeader(header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read upload: %w", err)
+		}
+		files = append(files, services.TestDataFile{Name: header.Filename, Data: data})
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("an archive or test data files are required")
+	}
+
+	return files, nil
+}
+
+// readTestDataZip reads the files of a zip file of test data, up to
+// services.MaxTestCaseUploadSize uncompressed
+func readTestDataZip(data []byte) ([]services.TestDataFile, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not a valid zip file: %w", err)
+	}
+
+	var files []services.TestDataFile
+	remaining := int64(services.MaxTestCaseUploadSize)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		// Sizes in the zip directory may lie, so the budget is enforced while reading
+		content, err := io.ReadAll(io.LimitReader(rc, remaining+1))
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		remaining -= int64(len(content))
+		if remaining < 0 {
+			return nil, fmt.Errorf("test data is larger than %d MB", services.MaxTestCaseUploadSize>>20)
+		}
+
+		files = append(files, services.TestDataFile{Name: f.Name, Data: content})
+	}
+
+	return files, nil
+}
+
+// readFormFileHeader reads an uploaded file of a multipart form
+func readFormFileHeader(header *multipart.FileHeader) ([]byte, error) {
+	f, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
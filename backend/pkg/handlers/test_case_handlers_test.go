@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/services"
+)
+
+func testDataZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, _ := zw.Create(name)
+		w.Write([]byte(data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to write zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProblemHandlers_UploadTestCases(t *testing.T) {
+	router, handler := setupTestRouter()
+	router.POST("/admin/problems/:id/testcases/bulk", handler.UploadTestCases)
+	router.GET("/problems/:id/testcases", handler.GetTestCases)
+
+	problem := createDraftProblem(t, handler, "Upload Problem")
+	path := fmt.Sprintf("/admin/problems/%d/testcases/bulk", problem.ID)
+
+	multipartFiles := func(files map[string]string) (*bytes.Buffer, string) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		for name, data := range files {
+			part, _ := form.CreateFormFile("files", name)
+			part.Write([]byte(data))
+		}
+		form.Close()
+		return &body, form.FormDataContentType()
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		body           func() (*bytes.Buffer, string)
+		expectedStatus int
+		expectedCount  int
+	}{
+		{
+			name:  "zip body",
+			query: "?public=data/1",
+			body: func() (*bytes.Buffer, string) {
+				data := testDataZip(t, map[string]string{"data/1.in": "1 2", "data/1.out": "3", "data/2.in": "2 2", "data/2.ans": "4"})
+				return bytes.NewBuffer(data), "application/zip"
+			},
+			expectedStatus: http.StatusCreated,
+			expectedCount:  2,
+		},
+		{
+			name:  "multipart archive",
+			query: "?replace=true",
+			body: func() (*bytes.Buffer, string) {
+				var body bytes.Buffer
+				form := multipart.NewWriter(&body)
+				part, _ := form.CreateFormFile("archive", "tests.zip")
+				part.Write(testDataZip(t, map[string]string{"1.in": "5 5", "1.out": "10"}))
+				form.Close()
+				return &body, form.FormDataContentType()
+			},
+			expectedStatus: http.StatusCreated,
+			expectedCount:  1,
+		},
+		{
+			name:  "multipart files",
+			query: "?public=a,b",
+			body: func() (*bytes.Buffer, string) {
+				return multipartFiles(map[string]string{"a.in": "1 1", "a.out": "2", "b.in": "2 3", "b.out": "5"})
+			},
+			expectedStatus: http.StatusCreated,
+			expectedCount:  2,
+		},
+		{
+			name: "unpaired files",
+			body: func() (*bytes.Buffer, string) {
+				return multipartFiles(map[string]string{"a.in": "1 1"})
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "not a zip file",
+			body: func() (*bytes.Buffer, string) {
+				return bytes.NewBufferString("1 2"), "application/zip"
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType := tt.body()
+			req, _ := http.NewRequest("POST", path+tt.query, body)
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusCreated {
+				return
+			}
+
+			var result services.TestCaseUploadResult
+			json.Unmarshal(w.Body.Bytes(), &result)
+			if result.Count != tt.expectedCount {
+				t.Errorf("Expected %d test cases, got %d", tt.expectedCount, result.Count)
+			}
+			if strings.Contains(w.Body.String(), `"input":`) {
+				t.Errorf("Expected test cases without their data, got %s", w.Body.String())
+			}
+		})
+	}
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/problems/%d/testcases", problem.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response struct {
+		TestCases []*models.TestCase `json:"test_cases"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if len(response.TestCases) != 3 {
+		t.Fatalf("Expected 3 test cases after replacing and adding, got %s", w.Body.String())
+	}
+	for _, testCase := range response.TestCases {
+		if testCase.Input != "" || testCase.InputHash == "" || testCase.InputSize == 0 {
+			t.Errorf("Expected listed test case with hash and size but no data, got %+v", testCase)
+		}
+	}
+}
+
+func TestProblemHandlers_GetTestCase(t *testing.T) {
+	router, handler := setupTestRouter()
+	router.GET("/admin/testcases/:id", handler.GetTestCase)
+	router.GET("/admin/testcases/:id/input", handler.GetTestCaseInput)
+	router.GET("/admin/testcases/:id/output", handler.GetTestCaseOutput)
+
+	problem := createDraftProblem(t, handler, "Data Problem")
+	testCase, err := handler.problemService.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "1 2\n", ExpectedOutput: "3\n"}, 1)
+	if err != nil {
+		t.Fatalf("Failed to create test case: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"test case with data", fmt.Sprintf("/admin/testcases/%d", testCase.ID), http.StatusOK, `"input":"1 2\n"`},
+		{"input file", fmt.Sprintf("/admin/testcases/%d/input", testCase.ID), http.StatusOK, "1 2\n"},
+		{"expected output file", fmt.Sprintf("/admin/testcases/%d/output", testCase.ID), http.StatusOK, "3\n"},
+		{"not found", "/admin/testcases/999", http.StatusNotFound, "Test case not found"},
+		{"invalid ID", "/admin/testcases/abc/input", http.StatusBadRequest, "Invalid test case ID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("Expected body containing %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetTestProgramRequest is the request body of SetTestProgram
+type SetTestProgramRequest struct {
+	Language string `json:"language" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// GenerateTestCasesRequest is the request body of GenerateTestCases
+type GenerateTestCasesRequest struct {
+	Count  int   `json:"count" binding:"required"`
+	Seed   int64 `json:"seed"`
+	Public bool  `json:"public"`
+}
+
+// GetTestPrograms handles GET /api/v1/admin/problems/:id/test-programs
+func (h *ProblemHandlers) GetTestPrograms(c *gin.Context) {
+	id, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+
+	programs, err := h.problemService.GetTestPrograms(id)
+	if err != nil {
+		handleReferenceSolutionError(c, err, "Failed to get test programs")
+		return
+	}
+	if programs == nil {
+		programs = []*models.TestProgram{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"programs": programs})
+}
+
+// SetTestProgram handles PUT /api/v1/admin/problems/:id/test-programs/:kind
+func (h *ProblemHandlers) SetTestProgram(c *gin.Context) {
+	id, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req SetTestProgramRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	program, err := h.problemService.SetTestProgram(&models.TestProgram{
+		ProblemID: id,
+		Kind:      c.Param("kind"),
+		Language:  req.Language,
+		Code:      req.Code,
+	})
+	if err != nil {
+		handleReferenceSolutionError(c, err, "Failed to save test program")
+		return
+	}
+
+	c.JSON(http.StatusOK, program)
+}
+
+// DeleteTestProgram handles DELETE /api/v1/admin/problems/:id/test-programs/:kind
+func (h *ProblemHandlers) DeleteTestProgram(c *gin.Context) {
+	id, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.problemService.DeleteTestProgram(id, c.Param("kind")); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Test program not found",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete test program",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GenerateTestCases handles POST /api/v1/admin/problems/:id/testcases/generate
+func (h *ProblemHandlers) GenerateTestCases(c *gin.Context) {
+	id, ok := problemIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req GenerateTestCasesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.problemService.GenerateTestCases(id, &services.TestCaseGeneration{
+		Count:  req.Count,
+		Seed:   req.Seed,
+		Public: req.Public,
+	}, adminUserID(c))
+	if err != nil {
+		handleReferenceSolutionError(c, err, "Failed to generate test cases")
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+	"leetcode-clone-backend/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type mockTestProgramRepo struct {
+	programs map[int]map[string]*models.TestProgram
+}
+
+func newMockTestProgramRepo() *mockTestProgramRepo {
+	return &mockTestProgramRepo{programs: make(map[int]map[string]*models.TestProgram)}
+}
+
+func (m *mockTestProgramRepo) GetByProblemID(problemID int) ([]*models.TestProgram, error) {
+	var programs []*models.TestProgram
+	for _, kind := range []string{models.TestProgramGenerator, models.TestProgramValidator} {
+		if program, exists := m.programs[problemID][kind]; exists {
+			programs = append(programs, program)
+		}
+	}
+	return programs, nil
+}
+
+func (m *mockTestProgramRepo) Get(problemID int, kind string) (*models.TestProgram, error) {
+	program, exists := m.programs[problemID][kind]
+	if !exists {
+		return nil, repository.NewRepositoryError("Get", repository.ErrNotFound, "test_program_not_found")
+	}
+	return program, nil
+}
+
+func (m *mockTestProgramRepo) Upsert(program *models.TestProgram) (*models.TestProgram, error) {
+	if m.programs[program.ProblemID] == nil {
+		m.programs[program.ProblemID] = make(map[string]*models.TestProgram)
+	}
+	m.programs[program.ProblemID][program.Kind] = program
+	return program, nil
+}
+
+func (m *mockTestProgramRepo) Delete(problemID int, kind string) error {
+	if _, exists := m.programs[problemID][kind]; !exists {
+		return repository.NewRepositoryError("Delete", repository.ErrNotFound, "test_program_not_found")
+	}
+	delete(m.programs[problemID], kind)
+	return nil
+}
+
+func TestProblemHandlers_TestPrograms(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewProblemHandlers(services.NewProblemService(&repository.Repository{
+		Problem:         newMockProblemRepo(),
+		TestCase:        newMockTestCaseRepo(),
+		ProblemRevision: newMockProblemRevisionRepo(),
+		ProblemSolution: newMockProblemSolutionRepo(),
+		JudgeSettings:   newMockJudgeSettingsRepo(),
+		TestProgram:     newMockTestProgramRepo(),
+	}, echoRunner{}))
+
+	router := gin.New()
+	router.GET("/admin/problems/:id/test-programs", handler.GetTestPrograms)
+	router.PUT("/admin/problems/:id/test-programs/:kind", handler.SetTestProgram)
+	router.DELETE("/admin/problems/:id/test-programs/:kind", handler.DeleteTestProgram)
+	router.PUT("/admin/problems/:id/solutions", handler.ReplaceSolutions)
+	router.POST("/admin/problems/:id/testcases/generate", handler.GenerateTestCases)
+
+	problem := createDraftProblem(t, handler, "Echo")
+	programsPath := fmt.Sprintf("/admin/problems/%d/test-programs", problem.ID)
+	generatePath := fmt.Sprintf("/admin/problems/%d/testcases/generate", problem.ID)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"no programs", "GET", programsPath, "", http.StatusOK, `"programs":[]`},
+		{"set generator", "PUT", programsPath + "/generator", `{"language":"python","code":"echo"}`, http.StatusOK, `"kind":"generator"`},
+		{"unknown kind", "PUT", programsPath + "/checker", `{"language":"python","code":"echo"}`, http.StatusBadRequest, "kind must be one of"},
+		{"missing code", "PUT", programsPath + "/validator", `{"language":"python"}`, http.StatusBadRequest, "Invalid request body"},
+		{"problem not found", "GET", "/admin/problems/999/test-programs", "", http.StatusNotFound, "Problem not found"},
+		{"generate without solutions", "POST", generatePath, `{"count":2}`, http.StatusBadRequest, "no reference solutions"},
+		{"set solutions", "PUT", fmt.Sprintf("/admin/problems/%d/solutions", problem.ID), `{"solutions":[{"name":"main","language":"python","code":"echo"}]}`, http.StatusOK, `"name":"main"`},
+		{"generate test cases", "POST", generatePath, `{"count":2,"seed":4}`, http.StatusCreated, `"name":"seed 5"`},
+		{"missing count", "POST", generatePath, `{"seed":4}`, http.StatusBadRequest, "Invalid request body"},
+		{"too many test cases", "POST", generatePath, `{"count":1000}`, http.StatusBadRequest, "count must be between"},
+		{"validator rejects test cases", "PUT", programsPath + "/validator", `{"language":"python","code":"echo"}`, http.StatusBadRequest, "validator rejects existing test cases"},
+		{"delete generator", "DELETE", programsPath + "/generator", "", http.StatusNoContent, ""},
+		{"delete missing generator", "DELETE", programsPath + "/generator", "", http.StatusNotFound, "Test program not found"},
+		{"generate without generator", "POST", generatePath, `{"count":2}`, http.StatusBadRequest, "problem has no generator"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("Expected body containing %q, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
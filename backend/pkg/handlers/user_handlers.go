@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"leetcode-clone-backend/pkg/pagination"
+	"leetcode-clone-backend/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultUserPageSize is the number of users listed per page unless the client asks for more
+const defaultUserPageSize = 20
+
+// UserHandlers handles HTTP requests for user accounts
+type UserHandlers struct {
+	userService services.UserServiceInterface
+}
+
+// NewUserHandlers creates a new user handlers instance
+func NewUserHandlers(userService services.UserServiceInterface) *UserHandlers {
+	return &UserHandlers{
+		userService: userService,
+	}
+}
+
+// ListUsers handles GET /api/v1/admin/users
+func (h *UserHandlers) ListUsers(c *gin.Context) {
+	params, err := pagination.ParseQuery(c.Request.URL.Query(), defaultUserPageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid pagination parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.userService.ListUsers(params)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid pagination") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid pagination parameters",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list users",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
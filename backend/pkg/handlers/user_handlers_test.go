@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/pagination"
+	"leetcode-clone-backend/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock user service
+type MockUserService struct {
+	mock.Mock
+}
+
+func (m *MockUserService) ListUsers(params pagination.Params) (*services.UserListResponse, error) {
+	args := m.Called(params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.UserListResponse), args.Error(1)
+}
+
+func setupUserTestRouter() (*gin.Engine, *MockUserService) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockService := new(MockUserService)
+	handler := NewUserHandlers(mockService)
+	router.GET("/api/v1/admin/users", handler.ListUsers)
+
+	return router, mockService
+}
+
+func TestUserHandlers_ListUsers(t *testing.T) {
+	t.Run("returns the pagination envelope without password hashes", func(t *testing.T) {
+		router, mockService := setupUserTestRouter()
+		mockService.On("ListUsers", pagination.Params{Page: 2, Limit: 10, Offset: 10}).Return(&services.UserListResponse{
+			Users: []*models.User{{ID: 1, Username: "alice", PasswordHash: "secret-hash"}},
+			Meta:  pagination.Meta{Total: 11, Page: 2, Limit: 10},
+		}, nil)
+
+		req, _ := http.NewRequest("GET", "/api/v1/admin/users?page=2&limit=10", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(11), response["total"])
+		assert.Equal(t, float64(2), response["page"])
+		assert.Equal(t, float64(10), response["limit"])
+		assert.Equal(t, false, response["has_next"])
+		assert.Len(t, response["users"], 1)
+		assert.NotContains(t, w.Body.String(), "secret-hash")
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		router, _ := setupUserTestRouter()
+
+		req, _ := http.NewRequest("GET", "/api/v1/admin/users?cursor=%21%21", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("cursor from another list", func(t *testing.T) {
+		router, mockService := setupUserTestRouter()
+		mockService.On("ListUsers", mock.Anything).Return(nil, errors.New("invalid pagination: cursor was created for another sort order"))
+
+		cursor := (&pagination.Cursor{Sort: "title:asc", Value: "Two Sum", ID: 1}).Encode()
+		req, _ := http.NewRequest("GET", "/api/v1/admin/users?cursor="+cursor, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		router, mockService := setupUserTestRouter()
+		mockService.On("ListUsers", mock.Anything).Return(nil, errors.New("database error"))
+
+		req, _ := http.NewRequest("GET", "/api/v1/admin/users", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"leetcode-clone-backend/pkg/auth"
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader is the request header carrying the client-chosen idempotency key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength is the maximum length of an idempotency key
+const maxIdempotencyKeyLength = 255
+
+// DefaultIdempotencyRetention is how long responses are kept for replay
+const DefaultIdempotencyRetention = 24 * time.Hour
+
+// idempotencyStaleAfter is how long a request may stay in progress before its key can be
+// reused, e.g. because the server stopped while handling it
+const idempotencyStaleAfter = 10 * time.Minute
+
+// idempotencyRecorder captures the response body while it is written to the client
+type idempotencyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware makes requests sent with an Idempotency-Key header safe to retry.
+// The first request with a key is handled normally and its response is stored; retries with
+// the same key and body within the retention period replay the stored response with an
+// Idempotent-Replayed header instead of running the handler again. A retry while the first
+// request is still running gets 409 Conflict, and reusing a key with a different body gets
+// 422 Unprocessable Entity. Responses with a 5xx or 429 status are not stored, so the request
+// can be retried. It runs before the rate limits, so that replays do not spend them. Requests
+// without the header, or without an authenticated user, are not affected.
+func IdempotencyMiddleware(keys repository.IdempotencyKeyRepository, retention time.Duration) gin.HandlerFunc {
+	// Start cleanup goroutine
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := keys.DeleteExpired(time.Now().Add(-retention)); err != nil {
+				fmt.Printf("Warning: failed to delete expired idempotency keys: %v\n", err)
+			}
+		}
+	}()
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid idempotency key",
+				"details": fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength),
+			})
+			c.Abort()
+			return
+		}
+
+		userInterface, exists := c.Get("user")
+		if !exists {
+			c.Next()
+			return
+		}
+		user, ok := userInterface.(*auth.Claims)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.Sum256(body)
+		endpoint := c.Request.Method + " " + c.Request.URL.Path
+		now := time.Now()
+
+		record, reserved, err := keys.Reserve(&models.IdempotencyKey{
+			UserID:      user.UserID,
+			Endpoint:    endpoint,
+			Key:         key,
+			RequestHash: hex.EncodeToString(hash[:]),
+		}, now.Add(-retention), now.Add(-idempotencyStaleAfter))
+		if err != nil {
+			if repository.IsConflict(err) {
+				respondIdempotencyInProgress(c)
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to check idempotency key",
+				"details": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		if !reserved {
+			replayIdempotentResponse(c, record, hex.EncodeToString(hash[:]))
+			return
+		}
+
+		recorder := &idempotencyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+			// Let the client retry requests that failed on our side or were rate limited
+			if err := keys.Delete(user.UserID, endpoint, key); err != nil {
+				fmt.Printf("Warning: failed to release idempotency key: %v\n", err)
+			}
+			return
+		}
+
+		contentType := recorder.Header().Get("Content-Type")
+		if err := keys.Complete(user.UserID, endpoint, key, status, contentType, recorder.body.Bytes()); err != nil {
+			fmt.Printf("Warning: failed to store idempotent response: %v\n", err)
+		}
+	}
+}
+
+// replayIdempotentResponse responds to a request whose idempotency key is already in use
+func replayIdempotentResponse(c *gin.Context, record *models.IdempotencyKey, requestHash string) {
+	if record.RequestHash != requestHash {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "Idempotency key reused",
+			"details": "The idempotency key was already used for a request with a different body",
+		})
+		c.Abort()
+		return
+	}
+
+	if record.Status != models.IdempotencyStatusCompleted || record.ResponseStatus == nil {
+		respondIdempotencyInProgress(c)
+		return
+	}
+
+	contentType := "application/json; charset=utf-8"
+	if record.ResponseContentType != nil && *record.ResponseContentType != "" {
+		contentType = *record.ResponseContentType
+	}
+	body := ""
+	if record.ResponseBody != nil {
+		body = *record.ResponseBody
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(*record.ResponseStatus, contentType, []byte(body))
+	c.Abort()
+}
+
+// respondIdempotencyInProgress responds to a duplicate of a request that is still being handled
+func respondIdempotencyInProgress(c *gin.Context) {
+	c.Header("Retry-After", "1")
+	c.JSON(http.StatusConflict, gin.H{
+		"error":   "Request in progress",
+		"details": "A request with this idempotency key is still being processed; retry shortly to receive its result",
+	})
+	c.Abort()
+}
@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"leetcode-clone-backend/pkg/auth"
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock idempotency key repository
+type MockIdempotencyKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyKeyRepository) Reserve(key *models.IdempotencyKey, expiredBefore, staleBefore time.Time) (*models.IdempotencyKey, bool, error) {
+	args := m.Called(key, expiredBefore, staleBefore)
+	if args.Get(0) == nil {
+		return nil, args.Bool(1), args.Error(2)
+	}
+	return args.Get(0).(*models.IdempotencyKey), args.Bool(1), args.Error(2)
+}
+
+func (m *MockIdempotencyKeyRepository) Complete(userID int, endpoint, key string, status int, contentType string, body []byte) error {
+	args := m.Called(userID, endpoint, key, status, contentType, body)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyKeyRepository) Delete(userID int, endpoint, key string) error {
+	args := m.Called(userID, endpoint, key)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyKeyRepository) DeleteExpired(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// hashBody returns the hex-encoded sha256 of a request body
+func hashBody(body string) string {
+	hash := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(hash[:])
+}
+
+func setupIdempotencyTestRouter(status int, calls *int) (*gin.Engine, *MockIdempotencyKeyRepository) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockRepo := new(MockIdempotencyKeyRepository)
+
+	router.Use(func(c *gin.Context) {
+		c.Set("user", &auth.Claims{UserID: 1, Username: "testuser"})
+		c.Next()
+	})
+
+	router.POST("/api/v1/submissions", IdempotencyMiddleware(mockRepo, DefaultIdempotencyRetention), func(c *gin.Context) {
+		*calls++
+		c.JSON(status, gin.H{"id": 42})
+	})
+
+	return router, mockRepo
+}
+
+func newIdempotentRequest(key, body string) *http.Request {
+	req, _ := http.NewRequest("POST", "/api/v1/submissions", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	return req
+}
+
+func matchReservation(hash string) interface{} {
+	return mock.MatchedBy(func(key *models.IdempotencyKey) bool {
+		return key.UserID == 1 && key.Endpoint == "POST /api/v1/submissions" && key.Key == "abc" &&
+			(hash == "" || key.RequestHash == hash)
+	})
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	t.Run("request without key is not affected", func(t *testing.T) {
+		calls := 0
+		router, mockRepo := setupIdempotencyTestRouter(http.StatusCreated, &calls)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, newIdempotentRequest("", `{"code":"x"}`))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 1, calls)
+		mockRepo.AssertNotCalled(t, "Reserve", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("first request stores the response", func(t *testing.T) {
+		calls := 0
+		router, mockRepo := setupIdempotencyTestRouter(http.StatusCreated, &calls)
+
+		reserved := &models.IdempotencyKey{UserID: 1, Endpoint: "POST /api/v1/submissions", Key: "abc", Status: models.IdempotencyStatusProcessing}
+		mockRepo.On("Reserve", matchReservation(""), mock.Anything, mock.Anything).Return(reserved, true, nil)
+		mockRepo.On("Complete", 1, "POST /api/v1/submissions", "abc", http.StatusCreated, "application/json; charset=utf-8", []byte(`{"id":42}`)).Return(nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, newIdempotentRequest("abc", `{"code":"x"}`))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, `{"id":42}`, w.Body.String())
+		assert.Equal(t, 1, calls)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("completed request is replayed", func(t *testing.T) {
+		calls := 0
+		router, mockRepo := setupIdempotencyTestRouter(http.StatusCreated, &calls)
+
+		status := http.StatusCreated
+		contentType := "application/json; charset=utf-8"
+		body := `{"id":7}`
+		existing := &models.IdempotencyKey{
+			UserID: 1, Endpoint: "POST /api/v1/submissions", Key: "abc", RequestHash: hashBody(`{"code":"x"}`),
+			Status: models.IdempotencyStatusCompleted, ResponseStatus: &status, ResponseContentType: &contentType, ResponseBody: &body,
+		}
+		mockRepo.On("Reserve", matchReservation(hashBody(`{"code":"x"}`)), mock.Anything, mock.Anything).Return(existing, false, nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, newIdempotentRequest("abc", `{"code":"x"}`))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, `{"id":7}`, w.Body.String())
+		assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+		assert.Equal(t, 0, calls)
+		mockRepo.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("key reused with a different body", func(t *testing.T) {
+		calls := 0
+		router, mockRepo := setupIdempotencyTestRouter(http.StatusCreated, &calls)
+
+		status := http.StatusCreated
+		existing := &models.IdempotencyKey{
+			UserID: 1, Endpoint: "POST /api/v1/submissions", Key: "abc", RequestHash: hashBody(`{"code":"x"}`),
+			Status: models.IdempotencyStatusCompleted, ResponseStatus: &status,
+		}
+		mockRepo.On("Reserve", matchReservation(""), mock.Anything, mock.Anything).Return(existing, false, nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, newIdempotentRequest("abc", `{"code":"y"}`))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("request still in progress", func(t *testing.T) {
+		calls := 0
+		router, mockRepo := setupIdempotencyTestRouter(http.StatusCreated, &calls)
+
+		mockRepo.On("Reserve", matchReservation(""), mock.Anything, mock.Anything).
+			Return(nil, false, repository.NewRepositoryError("Reserve", repository.ErrConflict, "idempotency_key_conflict"))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, newIdempotentRequest("abc", `{"code":"x"}`))
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "1", w.Header().Get("Retry-After"))
+		assert.Equal(t, 0, calls)
+	})
+
+	for _, status := range []int{http.StatusInternalServerError, http.StatusTooManyRequests} {
+		t.Run(fmt.Sprintf("status %d releases the key", status), func(t *testing.T) {
+			calls := 0
+			router, mockRepo := setupIdempotencyTestRouter(status, &calls)
+
+			reserved := &models.IdempotencyKey{UserID: 1, Endpoint: "POST /api/v1/submissions", Key: "abc", Status: models.IdempotencyStatusProcessing}
+			mockRepo.On("Reserve", matchReservation(""), mock.Anything, mock.Anything).Return(reserved, true, nil)
+			mockRepo.On("Delete", 1, "POST /api/v1/submissions", "abc").Return(nil)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, newIdempotentRequest("abc", `{"code":"x"}`))
+
+			assert.Equal(t, status, w.Code)
+			assert.Equal(t, 1, calls)
+			mockRepo.AssertExpectations(t)
+			mockRepo.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("repository failure", func(t *testing.T) {
+		calls := 0
+		router, mockRepo := setupIdempotencyTestRouter(http.StatusCreated, &calls)
+
+		mockRepo.On("Reserve", matchReservation(""), mock.Anything, mock.Anything).Return(nil, false, errors.New("database error"))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, newIdempotentRequest("abc", `{"code":"x"}`))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, 0, calls)
+	})
+}
@@ -1,84 +1,84 @@
 package middleware
 
 import (
+	"fmt"
+	"math"
 	"net/http"
-	"sync"
+	"strconv"
 	"time"
 
+	"leetcode-clone-backend/pkg/ratelimit"
+
 	"github.com/gin-gonic/gin"
-	"golang.org/x/time/rate"
 )
 
-// RateLimiter represents a rate limiter for IP addresses
+// RateLimiter represents a rate limiter whose token buckets are kept in a shared store
 type RateLimiter struct {
-	limiters map[string]*rate.Limiter
-	mu       sync.RWMutex
-	rate     rate.Limit
-	burst    int
+	store  ratelimit.Store
+	prefix string
+	limit  ratelimit.Limit
 }
 
-// NewRateLimiter creates a new rate limiter
-func NewRateLimiter(r rate.Limit, b int) *RateLimiter {
+// NewRateLimiter creates a new rate limiter. prefix separates its buckets from those of other
+// limiters using the same store.
+func NewRateLimiter(store ratelimit.Store, prefix string, limit ratelimit.Limit) *RateLimiter {
 	return &RateLimiter{
-		limiters: make(map[string]*rate.Limiter),
-		rate:     r,
-		burst:    b,
+		store:  store,
+		prefix: prefix,
+		limit:  limit,
 	}
 }
 
-// GetLimiter returns the rate limiter for the given IP
-func (rl *RateLimiter) GetLimiter(ip string) *rate.Limiter {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+// Take takes a token from the bucket for the given key, e.g. an IP address or user ID
+func (rl *RateLimiter) Take(key string) (*ratelimit.Result, error) {
+	return rl.store.Take(rl.prefix+":"+key, rl.limit, time.Now())
+}
 
-	limiter, exists := rl.limiters[ip]
-	if !exists {
-		limiter = rate.NewLimiter(rl.rate, rl.burst)
-		rl.limiters[ip] = limiter
+// allow takes a token and writes the rate limit headers. If the store fails the request is
+// allowed, so that an unavailable store does not take the API down. It returns false after
+// responding with 429 Too Many Requests.
+func (rl *RateLimiter) allow(c *gin.Context, key string) bool {
+	result, err := rl.Take(key)
+	if err != nil {
+		fmt.Printf("Warning: rate limiter unavailable: %v\n", err)
+		return true
 	}
 
-	return limiter
-}
-
-// CleanupOldLimiters removes old limiters to prevent memory leaks
-func (rl *RateLimiter) CleanupOldLimiters() {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+	c.Header("X-RateLimit-Limit", strconv.Itoa(rl.limit.Burst))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(int(math.Max(0, math.Floor(result.Remaining)))))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.ResetAfter.Seconds()))))
 
-	for ip, limiter := range rl.limiters {
-		// Remove limiters that haven't been used recently
-		if limiter.TokensAt(time.Now()) == float64(rl.burst) {
-			delete(rl.limiters, ip)
-		}
+	if !result.Allowed {
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":   "Rate limit exceeded",
+			"message": "Too many requests. Please try again later.",
+		})
+		c.Abort()
+		return false
 	}
+
+	return true
 }
 
-// RateLimitMiddleware creates a rate limiting middleware
-func RateLimitMiddleware(requestsPerMinute int) gin.HandlerFunc {
-	limiter := NewRateLimiter(rate.Limit(requestsPerMinute)/60, requestsPerMinute) // Convert to per-second rate
-
-	// Start cleanup goroutine
-	go func() {
-		ticker := time.NewTicker(5 * time.Minute)
-		defer ticker.Stop()
-		for range ticker.C {
-			limiter.CleanupOldLimiters()
-		}
-	}()
+// RateLimitMiddleware creates a rate limiting middleware keyed by client IP
+func RateLimitMiddleware(store ratelimit.Store, prefix string, requestsPerMinute int) gin.HandlerFunc {
+	limiter := NewRateLimiter(store, prefix, ratelimit.PerMinute(requestsPerMinute, requestsPerMinute))
 
 	return func(c *gin.Context) {
-		ip := c.ClientIP()
-		rateLimiter := limiter.GetLimiter(ip)
-
-		if !rateLimiter.Allow() {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":   "Rate limit exceeded",
-				"message": "Too many requests. Please try again later.",
-			})
-			c.Abort()
+		if !limiter.allow(c, c.ClientIP()) {
 			return
 		}
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// ceilSeconds rounds a duration up to whole seconds, with a minimum of one second
+func ceilSeconds(d time.Duration) int {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"leetcode-clone-backend/pkg/auth"
+	"leetcode-clone-backend/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserRateLimit configures a per-user token bucket
+type UserRateLimit struct {
+	RequestsPerMinute int // Rate at which tokens are refilled
+	Burst             int // Maximum number of tokens, i.e. requests that can be made at once
+}
+
+// ExecutionLimits configures the limits applied to the code execution and submission routes
+type ExecutionLimits struct {
+	Run             UserRateLimit // POST /execute/run
+	Submit          UserRateLimit // POST /execute/submit and POST /submissions
+	DailyCPUSeconds int           // Sandbox time each user may use per UTC day, 0 for no limit
+}
+
+// LoadExecutionLimitsFromEnv loads execution limits from environment variables
+func LoadExecutionLimitsFromEnv() *ExecutionLimits {
+	return &ExecutionLimits{
+		Run: UserRateLimit{
+			RequestsPerMinute: getEnvInt("RUN_RATE_PER_MINUTE", 20),
+			Burst:             getEnvInt("RUN_RATE_BURST", 5),
+		},
+		Submit: UserRateLimit{
+			RequestsPerMinute: getEnvInt("SUBMIT_RATE_PER_MINUTE", 6),
+			Burst:             getEnvInt("SUBMIT_RATE_BURST", 3),
+		},
+		DailyCPUSeconds: getEnvInt("DAILY_CPU_SECONDS", 1800),
+	}
+}
+
+// getEnvInt returns an integer environment variable or a default value
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value >= 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// UserRateLimitMiddleware limits requests per authenticated user with a token bucket named
+// prefix in the store. Every response carries X-RateLimit-Limit (bucket size),
+// X-RateLimit-Remaining (tokens left) and X-RateLimit-Reset (seconds until the bucket is full
+// again). Requests over the limit get 429 Too Many Requests with a Retry-After header. Admins
+// are not limited.
+func UserRateLimitMiddleware(store ratelimit.Store, prefix string, limit UserRateLimit) gin.HandlerFunc {
+	limiter := NewRateLimiter(store, prefix, ratelimit.PerMinute(limit.RequestsPerMinute, limit.Burst))
+
+	return func(c *gin.Context) {
+		user, ok := rateLimitedUser(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		if !limiter.allow(c, "user:"+strconv.Itoa(user.UserID)) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// DefaultCPUReservation is the sandbox time reserved for a request while it runs, until the time
+// it actually used is known
+const DefaultCPUReservation = 10 * time.Second
+
+// sandboxTimeKey is the context key under which handlers record the sandbox time they used
+const sandboxTimeKey = "sandbox_time"
+
+// RecordSandboxTime records sandbox time used while handling a request, to be charged to the
+// user's daily budget
+func RecordSandboxTime(c *gin.Context, d time.Duration) {
+	c.Set(sandboxTimeKey, sandboxTime(c)+d)
+}
+
+// sandboxTime returns the sandbox time recorded while handling a request
+func sandboxTime(c *gin.Context) time.Duration {
+	return c.GetDuration(sandboxTimeKey)
+}
+
+// CPUBudget tracks the sandbox time used by each user during the current UTC day
+type CPUBudget struct {
+	store       ratelimit.Store
+	dailyLimit  time.Duration
+	reservation time.Duration
+	now         func() time.Time
+}
+
+// NewCPUBudget creates a new daily CPU budget kept in the store
+func NewCPUBudget(store ratelimit.Store, dailyLimit time.Duration) *CPUBudget {
+	return &CPUBudget{
+		store:       store,
+		dailyLimit:  dailyLimit,
+		reservation: DefaultCPUReservation,
+		now:         time.Now,
+	}
+}
+
+// add adds sandbox time to the user's usage for today and returns the total
+func (b *CPUBudget) add(userID int, d time.Duration) (time.Duration, error) {
+	now := b.now().UTC()
+	key := fmt.Sprintf("cpu:user:%d:%s", userID, now.Format("2006-01-02"))
+
+	// Keep the counter a little past midnight so replicas with skewed clocks agree on the total
+	seconds, err := b.store.Add(key, d.Seconds(), now, now.Add(b.ResetIn()+time.Hour))
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// Remaining returns the sandbox time the user has left today
+func (b *CPUBudget) Remaining(userID int) (time.Duration, error) {
+	used, err := b.add(userID, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	remaining := b.dailyLimit - used
+	if remaining < 0 {
+		return 0, nil
+	}
+	return remaining, nil
+}
+
+// Charge adds sandbox time to the user's usage for today
+func (b *CPUBudget) Charge(userID int, d time.Duration) error {
+	_, err := b.add(userID, d)
+	return err
+}
+
+// ResetIn returns the time until the budget is reset at midnight UTC
+func (b *CPUBudget) ResetIn() time.Duration {
+	now := b.now().UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	return midnight.Sub(now)
+}
+
+// CPUBudgetMiddleware limits the sandbox time each authenticated user can use per UTC day.
+// DefaultCPUReservation is reserved from the budget before a request is handled, so that
+// concurrent requests cannot all pass the check and overshoot it, and is settled afterwards
+// against the sandbox time the handler recorded with RecordSandboxTime. Requests from users who
+// have used up their budget get 429 Too Many Requests with a Retry-After header pointing at
+// midnight UTC. Every response carries X-RateLimit-CPU-Limit and X-RateLimit-CPU-Remaining in
+// seconds. Admins are not limited, and a budget of zero disables the limit.
+func CPUBudgetMiddleware(budget *CPUBudget) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := rateLimitedUser(c)
+		if !ok || budget.dailyLimit <= 0 {
+			c.Next()
+			return
+		}
+
+		used, err := budget.add(user.UserID, budget.reservation)
+		if err != nil {
+			// Do not take the API down when the store is unavailable
+			fmt.Printf("Warning: execution budget unavailable: %v\n", err)
+			c.Next()
+			return
+		}
+
+		// Time reserved by this request does not count against it
+		remaining := max(budget.dailyLimit-(used-budget.reservation), 0)
+		c.Header("X-RateLimit-CPU-Limit", strconv.Itoa(int(budget.dailyLimit.Seconds())))
+		c.Header("X-RateLimit-CPU-Remaining", strconv.Itoa(int(remaining.Seconds())))
+
+		if remaining <= 0 {
+			if err := budget.Charge(user.UserID, -budget.reservation); err != nil {
+				fmt.Printf("Warning: failed to release execution time: %v\n", err)
+			}
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(budget.ResetIn())))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":   "Daily execution budget exceeded",
+				"message": "You have used all of today's code execution time. Please try again tomorrow.",
+			})
+			c.Abort()
+			return
+		}
+
+		// Settle the reservation even if the handler panics
+		defer func() {
+			if err := budget.Charge(user.UserID, sandboxTime(c)-budget.reservation); err != nil {
+				fmt.Printf("Warning: failed to charge execution time: %v\n", err)
+			}
+		}()
+		c.Next()
+	}
+}
+
+// rateLimitedUser returns the authenticated user if their requests are limited
+func rateLimitedUser(c *gin.Context) (*auth.Claims, bool) {
+	userInterface, exists := c.Get("user")
+	if !exists {
+		return nil, false
+	}
+
+	user, ok := userInterface.(*auth.Claims)
+	if !ok || user.IsAdmin {
+		return nil, false
+	}
+
+	return user, true
+}
@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"leetcode-clone-backend/pkg/auth"
+	"leetcode-clone-backend/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// unavailableStore is a rate limit store that always fails
+type unavailableStore struct{}
+
+func (unavailableStore) Take(key string, limit ratelimit.Limit, now time.Time) (*ratelimit.Result, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (unavailableStore) Add(key string, amount float64, now, expiresAt time.Time) (float64, error) {
+	return 0, errors.New("connection refused")
+}
+
+func (unavailableStore) Cleanup(now time.Time) error {
+	return errors.New("connection refused")
+}
+
+func setupUserRateLimitTestRouter(user *auth.Claims, handlers ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	router.Use(func(c *gin.Context) {
+		if user != nil {
+			c.Set("user", user)
+		}
+		c.Next()
+	})
+
+	handlers = append(handlers, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	router.POST("/api/v1/execute/run", handlers...)
+
+	return router
+}
+
+func postRun(router *gin.Engine) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/api/v1/execute/run", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestUserRateLimitMiddleware(t *testing.T) {
+	t.Run("limits each user separately", func(t *testing.T) {
+		limit := UserRateLimitMiddleware(ratelimit.NewMemoryStore(), "run", UserRateLimit{RequestsPerMinute: 1, Burst: 2})
+		alice := setupUserRateLimitTestRouter(&auth.Claims{UserID: 1}, limit)
+		bob := setupUserRateLimitTestRouter(&auth.Claims{UserID: 2}, limit)
+
+		w := postRun(alice)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+
+		w = postRun(alice)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+		assert.NotEqual(t, "0", w.Header().Get("X-RateLimit-Reset"))
+
+		w = postRun(alice)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+		// Another user has their own bucket
+		w = postRun(bob)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("admins are exempt", func(t *testing.T) {
+		limit := UserRateLimitMiddleware(ratelimit.NewMemoryStore(), "run", UserRateLimit{RequestsPerMinute: 1, Burst: 1})
+		router := setupUserRateLimitTestRouter(&auth.Claims{UserID: 1, IsAdmin: true}, limit)
+
+		for i := 0; i < 3; i++ {
+			w := postRun(router)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+		}
+	})
+
+	t.Run("requests are allowed when the store is unavailable", func(t *testing.T) {
+		limit := UserRateLimitMiddleware(unavailableStore{}, "run", UserRateLimit{RequestsPerMinute: 1, Burst: 1})
+		router := setupUserRateLimitTestRouter(&auth.Claims{UserID: 1}, limit, CPUBudgetMiddleware(NewCPUBudget(unavailableStore{}, time.Second)))
+
+		assert.Equal(t, http.StatusOK, postRun(router).Code)
+		assert.Equal(t, http.StatusOK, postRun(router).Code)
+	})
+
+	t.Run("unauthenticated requests pass through", func(t *testing.T) {
+		limit := UserRateLimitMiddleware(ratelimit.NewMemoryStore(), "run", UserRateLimit{RequestsPerMinute: 1, Burst: 1})
+		router := setupUserRateLimitTestRouter(nil, limit)
+
+		assert.Equal(t, http.StatusOK, postRun(router).Code)
+		assert.Equal(t, http.StatusOK, postRun(router).Code)
+	})
+}
+
+func TestCPUBudget(t *testing.T) {
+	now := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	budget := NewCPUBudget(ratelimit.NewMemoryStore(), 10*time.Second)
+	budget.now = func() time.Time { return now }
+
+	assert.NoError(t, budget.Charge(1, 4*time.Second))
+	remaining, err := budget.Remaining(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 6*time.Second, remaining)
+	remaining, err = budget.Remaining(2)
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, remaining)
+
+	assert.NoError(t, budget.Charge(1, 20*time.Second))
+	remaining, err = budget.Remaining(1)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), remaining)
+	assert.Equal(t, time.Hour, budget.ResetIn())
+
+	// Usage is reset at midnight UTC
+	now = now.Add(2 * time.Hour)
+	remaining, err = budget.Remaining(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, remaining)
+}
+
+func TestCPUBudgetMiddleware(t *testing.T) {
+	t.Run("charges recorded sandbox time and rejects when used up", func(t *testing.T) {
+		now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		budget := NewCPUBudget(ratelimit.NewMemoryStore(), 5*time.Second)
+		budget.now = func() time.Time { return now }
+
+		slowHandler := func(c *gin.Context) {
+			// Wall time spent outside the sandbox is not charged
+			now = now.Add(time.Second)
+			RecordSandboxTime(c, 2*time.Second)
+			RecordSandboxTime(c, time.Second)
+			c.Next()
+		}
+		router := setupUserRateLimitTestRouter(&auth.Claims{UserID: 1}, CPUBudgetMiddleware(budget), slowHandler)
+
+		w := postRun(router)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "5", w.Header().Get("X-RateLimit-CPU-Limit"))
+		assert.Equal(t, "5", w.Header().Get("X-RateLimit-CPU-Remaining"))
+
+		w = postRun(router)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-RateLimit-CPU-Remaining"))
+
+		w = postRun(router)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "0", w.Header().Get("X-RateLimit-CPU-Remaining"))
+		assert.Equal(t, "43198", w.Header().Get("Retry-After"))
+
+		remaining, err := budget.Remaining(1)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Duration(0), remaining, "a rejected request must release its reservation")
+	})
+
+	t.Run("reserves time while a request runs", func(t *testing.T) {
+		budget := NewCPUBudget(ratelimit.NewMemoryStore(), 5*time.Second)
+		var router *gin.Engine
+		var concurrent *httptest.ResponseRecorder
+
+		handler := func(c *gin.Context) {
+			remaining, err := budget.Remaining(1)
+			assert.NoError(t, err)
+			assert.Equal(t, time.Duration(0), remaining)
+
+			// Another request made meanwhile must not get past the check
+			concurrent = postRun(router)
+			RecordSandboxTime(c, time.Second)
+			c.Next()
+		}
+		router = setupUserRateLimitTestRouter(&auth.Claims{UserID: 1}, CPUBudgetMiddleware(budget), handler)
+
+		w := postRun(router)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusTooManyRequests, concurrent.Code)
+
+		remaining, err := budget.Remaining(1)
+		assert.NoError(t, err)
+		assert.Equal(t, 4*time.Second, remaining)
+	})
+
+	t.Run("releases the reservation when no code ran", func(t *testing.T) {
+		budget := NewCPUBudget(ratelimit.NewMemoryStore(), 5*time.Second)
+		router := setupUserRateLimitTestRouter(&auth.Claims{UserID: 1}, CPUBudgetMiddleware(budget))
+
+		assert.Equal(t, http.StatusOK, postRun(router).Code)
+		remaining, err := budget.Remaining(1)
+		assert.NoError(t, err)
+		assert.Equal(t, 5*time.Second, remaining)
+	})
+
+	t.Run("admins are exempt", func(t *testing.T) {
+		budget := NewCPUBudget(ratelimit.NewMemoryStore(), time.Nanosecond)
+		assert.NoError(t, budget.Charge(1, time.Second))
+		router := setupUserRateLimitTestRouter(&auth.Claims{UserID: 1, IsAdmin: true}, CPUBudgetMiddleware(budget))
+
+		assert.Equal(t, http.StatusOK, postRun(router).Code)
+	})
+
+	t.Run("zero budget disables the limit", func(t *testing.T) {
+		budget := NewCPUBudget(ratelimit.NewMemoryStore(), 0)
+		router := setupUserRateLimitTestRouter(&auth.Claims{UserID: 1}, CPUBudgetMiddleware(budget))
+
+		assert.Equal(t, http.StatusOK, postRun(router).Code)
+		assert.Empty(t, postRun(router).Header().Get("X-RateLimit-CPU-Limit"))
+	})
+}
@@ -90,18 +90,292 @@ type Problem struct {
 	Examples     Examples     `json:"examples" db:"examples"`
 	Constraints  string       `json:"constraints" db:"constraints"`
 	TemplateCode TemplateCode `json:"template_code" db:"template_code"`
+	State        string       `json:"state" db:"state"`
+	PublishAt    *time.Time   `json:"publish_at,omitempty" db:"publish_at"` // When a scheduled problem is published
+	PublishedAt  *time.Time   `json:"published_at,omitempty" db:"published_at"`
 	CreatedAt    time.Time    `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time    `json:"updated_at" db:"updated_at"`
+
+	// Set when problems are listed or viewed, not stored
+	AcceptanceRate *float64 `json:"acceptance_rate,omitempty" db:"-"` // Percentage of accepted submissions
+	UserStatus     string   `json:"user_status,omitempty" db:"-"`     // Only for signed-in users
+	IsSolved       *bool    `json:"is_solved,omitempty" db:"-"`       // Only for signed-in users
+}
+
+// Problem status of a user, as shown in problem listings
+const (
+	ProblemStatusNew       = "new"
+	ProblemStatusAttempted = ProgressStatusAttempted
+	ProblemStatusSolved    = ProgressStatusSolved
+)
+
+// Problem lifecycle states. Only published problems are public.
+const (
+	ProblemStateDraft     = "draft"
+	ProblemStateReview    = "review"
+	ProblemStateScheduled = "scheduled" // Published automatically at PublishAt
+	ProblemStatePublished = "published"
+	ProblemStateArchived  = "archived"
+)
+
+// ProblemSearchResult is a problem matched by a full-text search
+type ProblemSearchResult struct {
+	Problem
+	Rank       float64          `json:"rank"`
+	Highlights SearchHighlights `json:"highlights"`
 }
 
-// TestCase represents a test case for a problem
+// SearchHighlights holds the title and a description snippet of a search result, with the
+// matched words wrapped in <mark> tags
+type SearchHighlights struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// TestCase represents a test case for a problem. Lists of test cases carry the hash, size and
+// preview of the input and expected output but not the data itself.
 type TestCase struct {
 	ID             int       `json:"id" db:"id"`
 	ProblemID      int       `json:"problem_id" db:"problem_id"`
-	Input          string    `json:"input" db:"input"`
-	ExpectedOutput string    `json:"expected_output" db:"expected_output"`
+	Input          string    `json:"input,omitempty" db:"input"`
+	ExpectedOutput string    `json:"expected_output,omitempty" db:"expected_output"`
+	InputHash      string    `json:"input_hash" db:"input_hash"` // SHA-256 of the input, its key in the test data store
+	InputSize      int64     `json:"input_size" db:"input_size"` // In bytes
+	InputPreview   string    `json:"input_preview" db:"input_preview"`
+	OutputHash     string    `json:"output_hash" db:"output_hash"`
+	OutputSize     int64     `json:"output_size" db:"output_size"`
+	OutputPreview  string    `json:"output_preview" db:"output_preview"`
 	IsHidden       bool      `json:"is_hidden" db:"is_hidden"`
 	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+
+	// OutputStatus is the result of the last check of the expected output against the reference
+	// solutions of the problem, reset when the test data or the reference solutions change
+	OutputStatus    string     `json:"output_status" db:"output_status"`
+	OutputCheckedAt *time.Time `json:"output_checked_at,omitempty" db:"output_checked_at"`
+
+	// InputStored and OutputStored are set when the data is kept in the test data store rather
+	// than in the database
+	InputStored  bool `json:"-" db:"-"`
+	OutputStored bool `json:"-" db:"-"`
+}
+
+// Test data limits
+const (
+	MaxTestDataSize       = 64 << 20 // Largest input or expected output of a test case, in bytes
+	MaxInlineTestDataSize = 64 << 10 // Larger test data is kept in the test data store
+	TestDataPreviewLength = 256      // Characters of test data in a preview
+)
+
+// TestDataPreview returns the first TestDataPreviewLength characters of test data, as the
+// test_cases migration computes them
+func TestDataPreview(data string) string {
+	count := 0
+	for i := range data {
+		if count == TestDataPreviewLength {
+			return data[:i]
+		}
+		count++
+	}
+	return data
+}
+
+// Expected output statuses of a test case
+const (
+	OutputStatusUnverified = "unverified" // Not checked since the test data or reference solutions changed
+	OutputStatusVerified   = "verified"   // The reference solutions agree with the expected output
+	OutputStatusStale      = "stale"      // The reference solutions agree on a different output
+	OutputStatusDisputed   = "disputed"   // The reference solutions disagree or fail on the input
+)
+
+// ProblemSolution is a named reference solution of a problem
+type ProblemSolution struct {
+	ID        int       `json:"id" db:"id"`
+	ProblemID int       `json:"problem_id" db:"problem_id"`
+	Name      string    `json:"name" db:"name"`
+	Language  string    `json:"language" db:"language"`
+	Code      string    `json:"code" db:"code"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TestProgram is the test input generator or input validator of a problem
+type TestProgram struct {
+	ProblemID int       `json:"problem_id" db:"problem_id"`
+	Kind      string    `json:"kind" db:"kind"`
+	Language  string    `json:"language" db:"language"`
+	Code      string    `json:"code" db:"code"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Test program kinds
+const (
+	TestProgramGenerator = "generator" // Turns a seed into a test input
+	TestProgramValidator = "validator" // Checks that a test input satisfies the constraints
+)
+
+// Output checkers of the judge
+const (
+	CheckerExact  = "exact"  // Trimmed output equals the expected output
+	CheckerTokens = "tokens" // Same whitespace-separated tokens, optionally ignoring case or within a float tolerance
+)
+
+// Judging limits. Problems without judging settings use the defaults.
+const (
+	DefaultTimeLimitMs   = 10000
+	DefaultMemoryLimitMb = 128
+	MinTimeLimitMs       = 100
+	MaxTimeLimitMs       = 10000
+	MinMemoryLimitMb     = 16
+	MaxMemoryLimitMb     = 1024
+)
+
+// JudgeSettings are the limits and output checker used to judge submissions to a problem
+type JudgeSettings struct {
+	ProblemID      int            `json:"problem_id" db:"problem_id"`
+	TimeLimitMs    int            `json:"time_limit_ms" db:"time_limit_ms"` // Per test case
+	MemoryLimitMb  int            `json:"memory_limit_mb" db:"memory_limit_mb"`
+	LanguageLimits LanguageLimits `json:"language_limits,omitempty" db:"language_limits"` // Limits of languages that do not use the ones above
+	Checker        string         `json:"checker" db:"checker"`
+	IgnoreCase     bool           `json:"ignore_case" db:"ignore_case"`         // CheckerTokens only
+	FloatTolerance float64        `json:"float_tolerance" db:"float_tolerance"` // CheckerTokens only: absolute or relative error allowed between numbers
+	UpdatedAt      *time.Time     `json:"updated_at,omitempty" db:"updated_at"` // Unset for default settings
+}
+
+// ForLanguage returns the settings a submission in a language is judged with: a copy of the
+// settings with the limits of the language, if it has its own
+func (s *JudgeSettings) ForLanguage(language string) *JudgeSettings {
+	settings := *s
+	if limits, ok := s.LanguageLimits[language]; ok {
+		settings.TimeLimitMs, settings.MemoryLimitMb = limits.TimeLimitMs, limits.MemoryLimitMb
+	}
+	return &settings
+}
+
+// Limits are the time and memory limits of a language
+type Limits struct {
+	TimeLimitMs   int `json:"time_limit_ms"` // Per test case
+	MemoryLimitMb int `json:"memory_limit_mb"`
+}
+
+// LanguageLimits maps languages to their limits
+type LanguageLimits map[string]Limits
+
+// Scan implements the sql.Scanner interface for LanguageLimits
+func (ll *LanguageLimits) Scan(value interface{}) error {
+	if value == nil {
+		*ll = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into LanguageLimits", value)
+	}
+
+	var limits map[string]Limits
+	if err := json.Unmarshal(bytes, &limits); err != nil {
+		return err
+	}
+	if len(limits) == 0 {
+		limits = nil
+	}
+	*ll = limits
+	return nil
+}
+
+// Value implements the driver.Valuer interface for LanguageLimits
+func (ll LanguageLimits) Value() (driver.Value, error) {
+	if len(ll) == 0 {
+		return json.Marshal(map[string]Limits{})
+	}
+	return json.Marshal(ll)
+}
+
+// DefaultJudgeSettings returns the settings of a problem that has none stored
+func DefaultJudgeSettings(problemID int) *JudgeSettings {
+	return &JudgeSettings{
+		ProblemID:     problemID,
+		TimeLimitMs:   DefaultTimeLimitMs,
+		MemoryLimitMb: DefaultMemoryLimitMb,
+		Checker:       CheckerExact,
+	}
+}
+
+// ProblemRevision is an immutable record of a problem and its test cases after a change
+type ProblemRevision struct {
+	ID             int              `json:"id" db:"id"`
+	ProblemID      int              `json:"problem_id" db:"problem_id"`
+	Revision       int              `json:"revision" db:"revision"` // 1-based number within the problem
+	AuthorID       *int             `json:"author_id,omitempty" db:"author_id"`
+	AuthorUsername *string          `json:"author_username,omitempty" db:"-"`
+	Action         string           `json:"action" db:"action"`
+	ChangedFields  pq.StringArray   `json:"changed_fields" db:"changed_fields"`
+	RestoredFrom   *int             `json:"restored_from,omitempty" db:"restored_from"` // Revision restored by a restore
+	Snapshot       *ProblemSnapshot `json:"snapshot,omitempty" db:"snapshot"`           // Not loaded when revisions are listed
+	CreatedAt      time.Time        `json:"created_at" db:"created_at"`
+}
+
+// Problem revision actions
+const (
+	RevisionActionBaseline         = "baseline" // State of a problem changed before revisions were recorded
+	RevisionActionCreate           = "create"
+	RevisionActionUpdate           = "update"
+	RevisionActionTestCaseCreate   = "test_case_create"
+	RevisionActionTestCaseUpdate   = "test_case_update"
+	RevisionActionTestCaseDelete   = "test_case_delete"
+	RevisionActionTestCaseUpload   = "test_case_upload"   // Test cases uploaded in bulk
+	RevisionActionTestCaseGenerate = "test_case_generate" // Test cases generated by the generator program
+	RevisionActionOutputGenerate   = "output_generate"    // Expected outputs generated by the reference solutions
+	RevisionActionTagChange        = "tag_change"         // Tag renamed or merged in the catalog
+	RevisionActionRestore          = "restore"
+	RevisionActionImport           = "import" // Problem package imported
+)
+
+// ProblemSnapshot is the stored content of a problem and its test cases at one revision
+type ProblemSnapshot struct {
+	Title        string             `json:"title"`
+	Slug         string             `json:"slug"`
+	Description  string             `json:"description"`
+	Difficulty   string             `json:"difficulty"`
+	Tags         []string           `json:"tags"`
+	Examples     Examples           `json:"examples"`
+	Constraints  string             `json:"constraints"`
+	TemplateCode TemplateCode       `json:"template_code"`
+	TestCases    []SnapshotTestCase `json:"test_cases"`
+}
+
+// SnapshotTestCase is a test case stored in a problem snapshot. Test data kept in the test data
+// store is referenced by its hash and size instead of being copied into the snapshot.
+type SnapshotTestCase struct {
+	ID             int    `json:"id"`
+	Input          string `json:"input"`
+	ExpectedOutput string `json:"expected_output"`
+	InputHash      string `json:"input_hash,omitempty"`
+	InputSize      int64  `json:"input_size,omitempty"`
+	OutputHash     string `json:"output_hash,omitempty"`
+	OutputSize     int64  `json:"output_size,omitempty"`
+	IsHidden       bool   `json:"is_hidden"`
+}
+
+// Scan implements the sql.Scanner interface for ProblemSnapshot
+func (ps *ProblemSnapshot) Scan(value interface{}) error {
+	if value == nil {
+		*ps = ProblemSnapshot{}
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into ProblemSnapshot", value)
+	}
+
+	return json.Unmarshal(bytes, ps)
+}
+
+// Value implements the driver.Valuer interface for ProblemSnapshot
+func (ps ProblemSnapshot) Value() (driver.Value, error) {
+	return json.Marshal(ps)
 }
 
 // Submission represents a code submission
@@ -118,8 +392,93 @@ type Submission struct {
 	TotalTestCases   int        `json:"total_test_cases" db:"total_test_cases"`
 	ErrorMessage     *string    `json:"error_message" db:"error_message"`
 	SubmittedAt      time.Time  `json:"submitted_at" db:"submitted_at"`
+	Problem          *SubmissionProblem `json:"problem,omitempty" db:"-"`
 }
 
+// SubmissionProblem is the summary of a problem included with submission and draft listings
+type SubmissionProblem struct {
+	ID         int    `json:"id"`
+	Title      string `json:"title"`
+	Difficulty string `json:"difficulty"`
+}
+
+// CodeDraft represents the autosaved editor contents of a user for a problem in one language
+type CodeDraft struct {
+	UserID       int                `json:"user_id" db:"user_id"`
+	ProblemID    int                `json:"problem_id" db:"problem_id"`
+	Language     string             `json:"language" db:"language"`
+	Code         string             `json:"code" db:"code"`
+	Version      int                `json:"version" db:"version"` // 0 if no draft has been saved yet
+	UpdatedAt    *time.Time         `json:"updated_at,omitempty" db:"updated_at"`
+	FromTemplate bool               `json:"from_template,omitempty" db:"-"`
+	Problem      *SubmissionProblem `json:"problem,omitempty" db:"-"`
+}
+
+// SubmissionTestResult represents the outcome of a single test case for a submission
+type SubmissionTestResult struct {
+	ID              int       `json:"id" db:"id"`
+	SubmissionID    int       `json:"submission_id" db:"submission_id"`
+	TestCaseID      *int      `json:"test_case_id" db:"test_case_id"`
+	Position        int       `json:"position" db:"position"`
+	Status          string    `json:"status" db:"status"`
+	Passed          bool      `json:"passed" db:"passed"`
+	RuntimeMs       *int      `json:"runtime_ms" db:"runtime_ms"`
+	MemoryKb        *int      `json:"memory_kb" db:"memory_kb"`
+	IsHidden        bool      `json:"is_hidden" db:"is_hidden"`
+	Input           string    `json:"input" db:"input"`
+	ExpectedOutput  string    `json:"expected_output" db:"expected_output"`
+	ActualOutput    string    `json:"actual_output" db:"actual_output"`
+	OutputTruncated bool      `json:"output_truncated" db:"output_truncated"`
+	Redacted        bool      `json:"redacted,omitempty" db:"-"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// SubmissionStats represents aggregated submission statistics for a user
+type SubmissionStats struct {
+	TotalSubmissions   int                       `json:"total_submissions"`
+	StatusCounts       map[string]int            `json:"status_counts"`
+	LanguageCounts     map[string]int            `json:"language_counts"`
+	AvgRuntimeMs       float64                   `json:"avg_runtime_ms"`
+	AvgMemoryKb        float64                   `json:"avg_memory_kb"`
+	RuntimePercentiles PerformancePercentiles    `json:"runtime_percentiles"`
+	MemoryPercentiles  PerformancePercentiles    `json:"memory_percentiles"`
+	DailyActivity      []DailySubmissionActivity `json:"daily_activity"`
+}
+
+// PerformancePercentiles represents the distribution of a performance metric
+type PerformancePercentiles struct {
+	P25 float64 `json:"p25"`
+	P50 float64 `json:"p50"`
+	P75 float64 `json:"p75"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+}
+
+// DailySubmissionActivity represents the number of submissions made on a single day
+type DailySubmissionActivity struct {
+	Date        string `json:"date"` // YYYY-MM-DD
+	Submissions int    `json:"submissions"`
+	Accepted    int    `json:"accepted"`
+}
+
+// PerformanceBucket represents one bucket of a runtime or memory histogram of accepted submissions
+type PerformanceBucket struct {
+	Bucket int `json:"bucket" db:"bucket"` // Inclusive lower bound in ms or KB
+	Count  int `json:"count" db:"count"`
+}
+
+// Performance histogram metrics and bucket widths
+const (
+	PerformanceMetricRuntime = "runtime"
+	PerformanceMetricMemory  = "memory"
+
+	RuntimeBucketMs = 10
+	MemoryBucketKb  = 256
+)
+
+// MaxStoredOutputLength is the maximum number of bytes of test input/output kept per test result
+const MaxStoredOutputLength = 4096
+
 // UserProgress represents a user's progress on a specific problem
 type UserProgress struct {
 	UserID           int        `json:"user_id" db:"user_id"`
@@ -128,8 +487,168 @@ type UserProgress struct {
 	BestSubmissionID *int       `json:"best_submission_id" db:"best_submission_id"`
 	Attempts         int        `json:"attempts" db:"attempts"`
 	FirstSolvedAt    *time.Time `json:"first_solved_at" db:"first_solved_at"`
+	Status           string     `json:"status" db:"status"`
+}
+
+// ApplySubmission updates the progress with a newly stored submission. Every submission counts as an
+// attempt. The first accepted submission solves the problem; later accepted submissions replace
+// the best submission only when IsBetterSubmission says so. currentBest may be nil.
+func (p *UserProgress) ApplySubmission(submission *Submission, currentBest *Submission) {
+	p.Attempts++
+
+	if submission.Status != StatusAccepted {
+		if p.Status != ProgressStatusSolved {
+			p.Status = ProgressStatusAttempted
+		}
+		return
+	}
+
+	if !p.IsSolved {
+		p.IsSolved = true
+		p.Status = ProgressStatusSolved
+		p.BestSubmissionID = &submission.ID
+		solvedAt := submission.SubmittedAt
+		p.FirstSolvedAt = &solvedAt
+		return
+	}
+
+	p.Status = ProgressStatusSolved
+	if IsBetterSubmission(submission, currentBest) {
+		p.BestSubmissionID = &submission.ID
+	}
+}
+
+// IsBetterSubmission reports whether an accepted candidate should replace the current best submission.
+// Submissions are only compared within the language of the current best: a lower runtime wins, and
+// on equal runtime a lower memory usage wins. Missing metrics rank behind recorded ones.
+func IsBetterSubmission(candidate, current *Submission) bool {
+	if candidate.Status != StatusAccepted {
+		return false
+	}
+	if current == nil {
+		return true
+	}
+	if candidate.Language != current.Language {
+		return false
+	}
+
+	if cmp := compareMetric(candidate.RuntimeMs, current.RuntimeMs); cmp != 0 {
+		return cmp < 0
+	}
+	return compareMetric(candidate.MemoryKb, current.MemoryKb) < 0
+}
+
+// compareMetric compares two optional metrics where lower is better and nil ranks last
+func compareMetric(a, b *int) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	case *a < *b:
+		return -1
+	case *a > *b:
+		return 1
+	default:
+		return 0
+	}
 }
 
+// User progress status constants
+const (
+	ProgressStatusUnsolved  = "unsolved"
+	ProgressStatusAttempted = "attempted"
+	ProgressStatusSolved    = "solved"
+)
+
+// IdempotencyKey records a request sent with an Idempotency-Key header and, once it
+// has completed, the response to replay for retries of the same request
+type IdempotencyKey struct {
+	UserID              int        `json:"user_id" db:"user_id"`
+	Endpoint            string     `json:"endpoint" db:"endpoint"`
+	Key                 string     `json:"key" db:"key"`
+	RequestHash         string     `json:"request_hash" db:"request_hash"`
+	Status              string     `json:"status" db:"status"`
+	ResponseStatus      *int       `json:"response_status,omitempty" db:"response_status"`
+	ResponseContentType *string    `json:"response_content_type,omitempty" db:"response_content_type"`
+	ResponseBody        *string    `json:"response_body,omitempty" db:"response_body"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt         *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// Idempotency key status constants
+const (
+	IdempotencyStatusProcessing = "processing"
+	IdempotencyStatusCompleted  = "completed"
+)
+
+// Tag is an entry of the tag catalog with the number of problems that have the tag
+type Tag struct {
+	Name         string  `json:"name" db:"name"`
+	Description  *string `json:"description,omitempty" db:"description"`
+	ProblemCount int     `json:"problem_count"`
+	EasyCount    int     `json:"easy_count"`
+	MediumCount  int     `json:"medium_count"`
+	HardCount    int     `json:"hard_count"`
+	SolvedCount  *int    `json:"solved_count,omitempty"` // Only set for authenticated users
+}
+
+// MaxTagLength is the maximum length of a tag name
+const MaxTagLength = 50
+
+// RejudgeJob represents an asynchronous re-execution of existing submissions
+type RejudgeJob struct {
+	ID           int        `json:"id" db:"id"`
+	Scope        string     `json:"scope" db:"scope"`
+	SubmissionID *int       `json:"submission_id,omitempty" db:"submission_id"`
+	ProblemID    *int       `json:"problem_id,omitempty" db:"problem_id"`
+	FromTime     *time.Time `json:"from_time,omitempty" db:"from_time"`
+	ToTime       *time.Time `json:"to_time,omitempty" db:"to_time"`
+	Status       string     `json:"status" db:"status"`
+	Total        int        `json:"total" db:"total"`
+	Processed    int        `json:"processed" db:"processed"`
+	Changed      int        `json:"changed" db:"changed"`
+	Failed       int        `json:"failed" db:"failed"`
+	ErrorMessage *string    `json:"error_message,omitempty" db:"error_message"`
+	CreatedBy    *int       `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	StartedAt    *time.Time `json:"started_at,omitempty" db:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty" db:"finished_at"`
+	ClaimToken   string     `json:"-" db:"claim_token"` // Identifies the worker running the job
+}
+
+// RejudgeResult records the old and new verdict of a submission processed by a rejudge job
+type RejudgeResult struct {
+	ID                 int       `json:"id" db:"id"`
+	JobID              int       `json:"job_id" db:"job_id"`
+	SubmissionID       int       `json:"submission_id" db:"submission_id"`
+	OldStatus          string    `json:"old_status" db:"old_status"`
+	NewStatus          string    `json:"new_status" db:"new_status"`
+	OldTestCasesPassed int       `json:"old_test_cases_passed" db:"old_test_cases_passed"`
+	NewTestCasesPassed int       `json:"new_test_cases_passed" db:"new_test_cases_passed"`
+	OldRuntimeMs       *int      `json:"old_runtime_ms" db:"old_runtime_ms"`
+	NewRuntimeMs       *int      `json:"new_runtime_ms" db:"new_runtime_ms"`
+	VerdictChanged     bool      `json:"verdict_changed" db:"verdict_changed"`
+	CreatedAt          time.Time `json:"created_at" db:"created_at"`
+}
+
+// Rejudge job scope constants
+const (
+	RejudgeScopeSubmission = "submission"
+	RejudgeScopeProblem    = "problem"
+	RejudgeScopeRange      = "range"
+)
+
+// Rejudge job status constants
+const (
+	RejudgeStatusQueued    = "queued"
+	RejudgeStatusRunning   = "running"
+	RejudgeStatusCompleted = "completed"
+	RejudgeStatusFailed    = "failed"
+)
+
 // Submission status constants
 const (
 	StatusAccepted           = "Accepted"
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 )
@@ -196,4 +197,144 @@ func TestProblem_LanguageConstants(t *testing.T) {
 			t.Error("Language constant should not be empty")
 		}
 	}
-}
\ No newline at end of file
+}
+func TestUserProgress_ApplySubmission(t *testing.T) {
+	runtime := func(ms int) *int { return &ms }
+	solvedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	progress := &UserProgress{UserID: 1, ProblemID: 1, Status: ProgressStatusUnsolved}
+
+	// A failed submission counts as an attempt
+	progress.ApplySubmission(&Submission{ID: 1, Status: StatusWrongAnswer}, nil)
+	if progress.Attempts != 1 || progress.Status != ProgressStatusAttempted || progress.IsSolved {
+		t.Errorf("Expected 1 attempt and status attempted, got %d attempts and status %s", progress.Attempts, progress.Status)
+	}
+
+	// The first accepted submission solves the problem
+	first := &Submission{ID: 2, Status: StatusAccepted, Language: LanguagePython, RuntimeMs: runtime(80), SubmittedAt: solvedAt}
+	progress.ApplySubmission(first, nil)
+	if !progress.IsSolved || progress.Status != ProgressStatusSolved {
+		t.Errorf("Expected problem to be solved, got status %s", progress.Status)
+	}
+	if progress.BestSubmissionID == nil || *progress.BestSubmissionID != 2 {
+		t.Errorf("Expected best submission 2, got %v", progress.BestSubmissionID)
+	}
+	if progress.FirstSolvedAt == nil || !progress.FirstSolvedAt.Equal(solvedAt) {
+		t.Errorf("Expected first solved at %v, got %v", solvedAt, progress.FirstSolvedAt)
+	}
+
+	// A slower accepted submission keeps the best submission
+	progress.ApplySubmission(&Submission{ID: 3, Status: StatusAccepted, Language: LanguagePython, RuntimeMs: runtime(120)}, first)
+	if *progress.BestSubmissionID != 2 {
+		t.Errorf("Expected best submission 2, got %d", *progress.BestSubmissionID)
+	}
+
+	// A failed submission after solving keeps the problem solved
+	progress.ApplySubmission(&Submission{ID: 4, Status: StatusRuntimeError}, first)
+	if progress.Status != ProgressStatusSolved || !progress.IsSolved {
+		t.Errorf("Expected problem to stay solved, got status %s", progress.Status)
+	}
+
+	// A faster accepted submission replaces the best submission
+	progress.ApplySubmission(&Submission{ID: 5, Status: StatusAccepted, Language: LanguagePython, RuntimeMs: runtime(40)}, first)
+	if *progress.BestSubmissionID != 5 {
+		t.Errorf("Expected best submission 5, got %d", *progress.BestSubmissionID)
+	}
+
+	if progress.Attempts != 5 {
+		t.Errorf("Expected 5 attempts, got %d", progress.Attempts)
+	}
+	if !progress.FirstSolvedAt.Equal(solvedAt) {
+		t.Errorf("Expected first solved at to stay %v, got %v", solvedAt, progress.FirstSolvedAt)
+	}
+}
+
+func TestIsBetterSubmission(t *testing.T) {
+	value := func(v int) *int { return &v }
+	current := &Submission{Status: StatusAccepted, Language: LanguageJava, RuntimeMs: value(50), MemoryKb: value(2048)}
+
+	tests := []struct {
+		name      string
+		candidate *Submission
+		current   *Submission
+		expected  bool
+	}{
+		{"no current best", &Submission{Status: StatusAccepted, Language: LanguageJava}, nil, true},
+		{"not accepted", &Submission{Status: StatusWrongAnswer, Language: LanguageJava, RuntimeMs: value(10)}, current, false},
+		{"different language", &Submission{Status: StatusAccepted, Language: LanguagePython, RuntimeMs: value(10)}, current, false},
+		{"faster", &Submission{Status: StatusAccepted, Language: LanguageJava, RuntimeMs: value(40), MemoryKb: value(4096)}, current, true},
+		{"slower", &Submission{Status: StatusAccepted, Language: LanguageJava, RuntimeMs: value(60), MemoryKb: value(1024)}, current, false},
+		{"same runtime less memory", &Submission{Status: StatusAccepted, Language: LanguageJava, RuntimeMs: value(50), MemoryKb: value(1024)}, current, true},
+		{"same runtime and memory", &Submission{Status: StatusAccepted, Language: LanguageJava, RuntimeMs: value(50), MemoryKb: value(2048)}, current, false},
+		{"missing runtime", &Submission{Status: StatusAccepted, Language: LanguageJava}, current, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsBetterSubmission(tt.candidate, tt.current); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTestDataPreview(t *testing.T) {
+	if got := TestDataPreview("1 2\n"); got != "1 2\n" {
+		t.Errorf("Expected short data unchanged, got %q", got)
+	}
+
+	long := strings.Repeat("é", TestDataPreviewLength+10)
+	if got := TestDataPreview(long); got != strings.Repeat("é", TestDataPreviewLength) {
+		t.Errorf("Expected %d characters, got %d bytes", TestDataPreviewLength, len(got))
+	}
+}
+
+func TestJudgeSettings_ForLanguage(t *testing.T) {
+	settings := &JudgeSettings{
+		TimeLimitMs:    1000,
+		MemoryLimitMb:  128,
+		LanguageLimits: LanguageLimits{LanguageJava: {TimeLimitMs: 2500, MemoryLimitMb: 256}},
+		Checker:        CheckerExact,
+	}
+
+	java := settings.ForLanguage(LanguageJava)
+	if java.TimeLimitMs != 2500 || java.MemoryLimitMb != 256 || java.Checker != CheckerExact {
+		t.Errorf("Expected the Java limits, got %+v", java)
+	}
+	if settings.TimeLimitMs != 1000 || settings.MemoryLimitMb != 128 {
+		t.Errorf("Expected the settings unchanged, got %+v", settings)
+	}
+
+	python := settings.ForLanguage(LanguagePython)
+	if python.TimeLimitMs != 1000 || python.MemoryLimitMb != 128 {
+		t.Errorf("Expected the problem limits for Python, got %+v", python)
+	}
+}
+
+func TestLanguageLimits_ScanValue(t *testing.T) {
+	limits := LanguageLimits{LanguagePython: {TimeLimitMs: 3000, MemoryLimitMb: 64}}
+
+	value, err := limits.Value()
+	if err != nil {
+		t.Fatalf("Failed to get value: %v", err)
+	}
+
+	var scanned LanguageLimits
+	if err := scanned.Scan(value); err != nil {
+		t.Fatalf("Failed to scan: %v", err)
+	}
+	if scanned[LanguagePython] != limits[LanguagePython] {
+		t.Errorf("Expected %+v, got %+v", limits, scanned)
+	}
+
+	empty, err := LanguageLimits(nil).Value()
+	if err != nil {
+		t.Fatalf("Failed to get empty value: %v", err)
+	}
+	if string(empty.([]byte)) != "{}" {
+		t.Errorf("Expected {}, got %s", empty)
+	}
+	if err := scanned.Scan(empty); err != nil || scanned != nil {
+		t.Errorf("Expected empty limits to scan as nil, got %v (%v)", scanned, err)
+	}
+}
@@ -0,0 +1,160 @@
+// Package pagination implements the pagination parameters and response envelope shared by list
+// endpoints. Lists can be paged with page numbers, which use OFFSET, or with opaque keyset
+// cursors, which stay fast however deep the client pages.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// MaxLimit is the largest number of items returned in one page
+const MaxLimit = 100
+
+// Params are the pagination parameters of a list request
+type Params struct {
+	Page   int     // 1-based page number, ignored when Cursor is set
+	Limit  int     // Number of items per page
+	Offset int     // Number of items skipped, derived from Page unless given explicitly
+	Cursor *Cursor // Position after which the page starts
+}
+
+// Cursor is a keyset position in a sorted list: the sort key and ID of the last item of the
+// previous page. Sort records the order the cursor was created for, so that a cursor is not
+// reused with a different order.
+type Cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"`
+	ID    int    `json:"id"`
+}
+
+// Meta is the pagination envelope of a list response
+type Meta struct {
+	Total      int    `json:"total"`          // Number of items matching the filters across all pages
+	Page       int    `json:"page,omitempty"` // Omitted when paging with a cursor
+	Limit      int    `json:"limit"`
+	HasNext    bool   `json:"has_next"`
+	NextCursor string `json:"next_cursor,omitempty"` // Cursor of the next page, if there is one
+}
+
+// ParseQuery reads the page, limit, offset and cursor query parameters. page_size is accepted
+// as an alias of limit. A missing or out of range limit falls back to defaultLimit or MaxLimit.
+func ParseQuery(query url.Values, defaultLimit int) (Params, error) {
+	params := Params{Page: 1, Limit: defaultLimit}
+
+	limitStr := query.Get("limit")
+	if limitStr == "" {
+		limitStr = query.Get("page_size")
+	}
+	if limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			return Params{}, fmt.Errorf("limit must be an integer")
+		}
+		if limit > 0 {
+			params.Limit = limit
+		}
+	}
+	if params.Limit > MaxLimit {
+		params.Limit = MaxLimit
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		decoded, err := DecodeCursor(cursor)
+		if err != nil {
+			return Params{}, err
+		}
+		params.Cursor = decoded
+		return params, nil
+	}
+
+	if pageStr := query.Get("page"); pageStr != "" {
+		page, err := strconv.Atoi(pageStr)
+		if err != nil {
+			return Params{}, fmt.Errorf("page must be an integer")
+		}
+		if page > 1 {
+			params.Page = page
+		}
+	}
+	params.Offset = (params.Page - 1) * params.Limit
+
+	// An explicit offset takes precedence over the page number
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil {
+			return Params{}, fmt.Errorf("offset must be an integer")
+		}
+		if offset > 0 {
+			params.Offset = offset
+			params.Page = offset/params.Limit + 1
+		}
+	}
+
+	return params, nil
+}
+
+// Encode returns the cursor as an opaque URL-safe string
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes a cursor returned in next_cursor
+func DecodeCursor(encoded string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Sort == "" {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return &cursor, nil
+}
+
+// Check returns an error if the cursor was created for a different sort order
+func (c *Cursor) Check(sort string) error {
+	if c.Sort != sort {
+		return fmt.Errorf("cursor was created for another sort order")
+	}
+	return nil
+}
+
+// Time returns the value of a cursor on a timestamp column
+func (c *Cursor) Time() (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, c.Value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid cursor")
+	}
+	return t, nil
+}
+
+// TimeValue formats a timestamp as a cursor value
+func TimeValue(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// NewMeta builds the envelope of a page. next is the cursor of the last item returned, which
+// becomes next_cursor when there is a next page.
+func NewMeta(params Params, total int, hasNext bool, next *Cursor) Meta {
+	meta := Meta{
+		Total:   total,
+		Limit:   params.Limit,
+		HasNext: hasNext,
+	}
+	if params.Cursor == nil {
+		meta.Page = params.Page
+	}
+	if hasNext && next != nil {
+		meta.NextCursor = next.Encode()
+	}
+
+	return meta
+}
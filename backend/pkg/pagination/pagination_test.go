@@ -0,0 +1,105 @@
+package pagination
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		page   int
+		limit  int
+		offset int
+	}{
+		{"defaults", "", 1, 20, 0},
+		{"page and limit", "page=3&limit=10", 3, 10, 20},
+		{"page_size is an alias of limit", "page=2&page_size=15", 2, 15, 15},
+		{"limit is capped", "limit=1000", 1, MaxLimit, 0},
+		{"non-positive values fall back to defaults", "page=0&limit=-5", 1, 20, 0},
+		{"offset takes precedence over page", "page=5&limit=10&offset=35", 4, 10, 35},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			params, err := ParseQuery(query, 20)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if params.Page != tt.page || params.Limit != tt.limit || params.Offset != tt.offset {
+				t.Errorf("Expected page %d, limit %d, offset %d, got %+v", tt.page, tt.limit, tt.offset, params)
+			}
+		})
+	}
+}
+
+func TestParseQuery_Errors(t *testing.T) {
+	for _, query := range []string{"limit=ten", "page=first", "offset=x", "cursor=not-a-cursor"} {
+		t.Run(query, func(t *testing.T) {
+			values, _ := url.ParseQuery(query)
+			if _, err := ParseQuery(values, 20); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestCursor_RoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 30, 0, 123456000, time.UTC)
+	cursor := &Cursor{Sort: "created_at:desc", Value: TimeValue(createdAt), ID: 42}
+
+	query := url.Values{"cursor": {cursor.Encode()}, "page": {"7"}, "limit": {"10"}}
+	params, err := ParseQuery(query, 20)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if params.Cursor == nil || *params.Cursor != *cursor {
+		t.Fatalf("Expected cursor %+v, got %+v", cursor, params.Cursor)
+	}
+	if params.Offset != 0 {
+		t.Errorf("Expected the page to be ignored with a cursor, got offset %d", params.Offset)
+	}
+
+	decoded, err := params.Cursor.Time()
+	if err != nil || !decoded.Equal(createdAt) {
+		t.Errorf("Expected time %v, got %v (%v)", createdAt, decoded, err)
+	}
+
+	if err := params.Cursor.Check("created_at:desc"); err != nil {
+		t.Errorf("Expected cursor to match its sort order, got %v", err)
+	}
+	if err := params.Cursor.Check("title:asc"); err == nil {
+		t.Error("Expected an error for a different sort order")
+	}
+}
+
+func TestNewMeta(t *testing.T) {
+	next := &Cursor{Sort: "created_at:desc", Value: "2024-03-01T00:00:00Z", ID: 9}
+
+	t.Run("offset page", func(t *testing.T) {
+		meta := NewMeta(Params{Page: 2, Limit: 10, Offset: 10}, 25, true, next)
+		if meta.Total != 25 || meta.Page != 2 || meta.Limit != 10 || !meta.HasNext {
+			t.Errorf("Unexpected meta %+v", meta)
+		}
+		if meta.NextCursor != next.Encode() {
+			t.Errorf("Expected next cursor %q, got %q", next.Encode(), meta.NextCursor)
+		}
+	})
+
+	t.Run("cursor page omits the page number", func(t *testing.T) {
+		meta := NewMeta(Params{Page: 1, Limit: 10, Cursor: next}, 25, true, next)
+		if meta.Page != 0 {
+			t.Errorf("Expected no page, got %d", meta.Page)
+		}
+	})
+
+	t.Run("last page has no next cursor", func(t *testing.T) {
+		meta := NewMeta(Params{Page: 3, Limit: 10, Offset: 20}, 25, false, next)
+		if meta.HasNext || meta.NextCursor != "" {
+			t.Errorf("Expected no next page, got %+v", meta)
+		}
+	})
+}
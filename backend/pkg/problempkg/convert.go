@@ -0,0 +1,317 @@
+package problempkg
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+// Formats of the packages converted by Convert
+const (
+	FormatKattis  = "kattis"  // ICPC problem package format, as used by Kattis and DOMjudge
+	FormatPolygon = "polygon" // Codeforces Polygon package
+)
+
+// Report describes what a conversion could not carry over. Features that make a problem
+// impossible to judge, such as interaction, are also reported by Validate, so that the package
+// cannot be imported.
+type Report struct {
+	Format      string   `json:"format"`
+	Unsupported []string `json:"unsupported"` // Features dropped or approximated
+	Defaults    []string `json:"defaults"`    // Fields missing from the package that were filled in
+}
+
+func (r *Report) unsupported(format string, args ...interface{}) {
+	r.Unsupported = append(r.Unsupported, fmt.Sprintf(format, args...))
+}
+
+func (r *Report) defaulted(format string, args ...interface{}) {
+	r.Defaults = append(r.Defaults, fmt.Sprintf(format, args...))
+}
+
+// defaultTemplates are the starter code of converted problems. Kattis and Polygon problems read
+// standard input; the judge passes the whole input to solution as a string.
+var defaultTemplates = map[string]string{
+	models.LanguageJavaScript: "function solution(input) {\n    // input holds the whole test input\n}\n",
+	models.LanguagePython:     "def solution(input):\n    # input holds the whole test input\n    pass\n",
+	models.LanguageJava:       "public String solution(String input) {\n    // input holds the first line of the test input\n    return \"\";\n}\n",
+}
+
+// ConvertPath converts a Kattis or Polygon package in a directory or a zip file
+func ConvertPath(name string) (*Package, *Report, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	base := strings.TrimSuffix(filepath.Base(filepath.Clean(name)), ".zip")
+	if info.IsDir() {
+		return Convert(os.DirFS(name), base)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	return ConvertZip(f, info.Size(), base)
+}
+
+// ConvertZip converts a Kattis or Polygon package in a zip file
+func ConvertZip(r io.ReaderAt, size int64, name string) (*Package, *Report, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, &ValidationError{Issues: []string{fmt.Sprintf("not a valid zip file: %v", err)}}
+	}
+	return Convert(zr, name)
+}
+
+// Convert converts a package in the Kattis or Polygon format, detected from its files, to a
+// package of this format. name is the short name of the problem when the package is not in a
+// directory of its own, as Kattis names problems after their directory; it may be empty. The
+// converted package still has to be validated.
+func Convert(fsys fs.FS, name string) (*Package, *Report, error) {
+	root, dir, format, err := convertRoot(fsys)
+	if err != nil {
+		return nil, nil, err
+	}
+	if dir != "" {
+		name = dir
+	}
+
+	r := &reader{fsys: root, remaining: MaxSize}
+	switch format {
+	case FormatPolygon:
+		return convertPolygon(r, name)
+	default:
+		return convertKattis(r, name)
+	}
+}
+
+// convertRoot returns the directory of fsys holding the package with its name, if it is a
+// subdirectory, and the format of the package
+func convertRoot(fsys fs.FS) (fs.FS, string, string, error) {
+	if format := detectFormat(fsys); format != "" {
+		return fsys, "", format, nil
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to read package: %w", err)
+	}
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") && entry.Name() != "__MACOSX" {
+			dirs = append(dirs, entry.Name())
+		}
+	}
+	if len(dirs) == 1 {
+		sub, err := fs.Sub(fsys, dirs[0])
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to read package: %w", err)
+		}
+		if format := detectFormat(sub); format != "" {
+			return sub, dirs[0], format, nil
+		}
+	}
+
+	return nil, "", "", &ValidationError{Issues: []string{"not a Kattis or Polygon package: neither problem.yaml nor problem.xml found"}}
+}
+
+// detectFormat returns the format of the package at the root of fsys, or "" if there is none
+func detectFormat(fsys fs.FS) string {
+	if _, err := fs.Stat(fsys, "problem.xml"); err == nil {
+		return FormatPolygon
+	}
+	if _, err := fs.Stat(fsys, "problem.yaml"); err == nil {
+		return FormatKattis
+	}
+	return ""
+}
+
+// exists reports whether a file or directory exists in the package
+func (r *reader) exists(name string) bool {
+	_, err := fs.Stat(r.fsys, name)
+	return err == nil
+}
+
+// readOptional reads a file of the package, returning nil if it does not exist
+func (r *reader) readOptional(name string) ([]byte, error) {
+	data, err := r.readFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// listFiles returns the names of the files under a directory of the package, sorted, skipping
+// hidden files. It returns nil if the directory does not exist.
+func (r *reader) listFiles(dir string) ([]string, error) {
+	if !r.exists(dir) {
+		return nil, nil
+	}
+
+	var names []string
+	err := fs.WalkDir(r.fsys, dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != dir && strings.HasPrefix(path.Base(name), ".") {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !entry.IsDir() {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package: %w", err)
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// numberTests names the tests of a converted package in order and lists the public ones as
+// examples
+func numberTests(pkg *Package) {
+	width := len(fmt.Sprint(len(pkg.Tests)))
+	if width < 2 {
+		width = 2
+	}
+	for i := range pkg.Tests {
+		test := &pkg.Tests[i]
+		test.Name = fmt.Sprintf("%0*d", width, i+1)
+		if test.Public {
+			pkg.Manifest.PublicTests = append(pkg.Manifest.PublicTests, test.Name)
+			pkg.Manifest.Examples = append(pkg.Manifest.Examples, Example{
+				Input:  strings.TrimSpace(test.Input),
+				Output: strings.TrimSpace(test.Output),
+			})
+		}
+	}
+}
+
+// newConvertedPackage returns a package with the defaults of converted problems
+func newConvertedPackage(report *Report) *Package {
+	pkg := &Package{
+		Manifest: Manifest{
+			FormatVersion: FormatVersion,
+			Difficulty:    models.DifficultyMedium,
+		},
+		Templates: make(map[string]string, len(defaultTemplates)),
+	}
+	for language, code := range defaultTemplates {
+		pkg.Templates[language] = code
+	}
+
+	report.defaulted("difficulty set to %s", models.DifficultyMedium)
+	report.defaulted("generic templates added for %s", strings.Join(sortedLanguages(pkg.Templates), ", "))
+	return pkg
+}
+
+// setLimits sets the limits of a converted package, clamped to those the judge supports
+func setLimits(pkg *Package, report *Report, timeLimitMs, memoryLimitMb int) {
+	switch {
+	case timeLimitMs == 0:
+		report.defaulted("time limit set to the default of %d ms", models.DefaultTimeLimitMs)
+	case timeLimitMs < models.MinTimeLimitMs:
+		report.unsupported("time limit of %d ms raised to the minimum of %d ms", timeLimitMs, models.MinTimeLimitMs)
+		pkg.Manifest.TimeLimitMs = models.MinTimeLimitMs
+	case timeLimitMs > models.MaxTimeLimitMs:
+		report.unsupported("time limit of %d ms lowered to the maximum of %d ms", timeLimitMs, models.MaxTimeLimitMs)
+		pkg.Manifest.TimeLimitMs = models.MaxTimeLimitMs
+	default:
+		pkg.Manifest.TimeLimitMs = timeLimitMs
+	}
+
+	switch {
+	case memoryLimitMb == 0:
+		report.defaulted("memory limit set to the default of %d MB", models.DefaultMemoryLimitMb)
+	case memoryLimitMb < models.MinMemoryLimitMb:
+		report.unsupported("memory limit of %d MB raised to the minimum of %d MB", memoryLimitMb, models.MinMemoryLimitMb)
+		pkg.Manifest.MemoryLimitMb = models.MinMemoryLimitMb
+	case memoryLimitMb > models.MaxMemoryLimitMb:
+		report.unsupported("memory limit of %d MB lowered to the maximum of %d MB", memoryLimitMb, models.MaxMemoryLimitMb)
+		pkg.Manifest.MemoryLimitMb = models.MaxMemoryLimitMb
+	default:
+		pkg.Manifest.MemoryLimitMb = memoryLimitMb
+	}
+}
+
+// setSlug sets the slug of a converted package from the short name of the problem, or its title
+func setSlug(pkg *Package, report *Report, shortName string) {
+	if slug := slugify(shortName); slug != "" {
+		pkg.Manifest.Slug = slug
+		return
+	}
+	pkg.Manifest.Slug = slugify(pkg.Manifest.Title)
+	report.defaulted("slug %q derived from the title", pkg.Manifest.Slug)
+}
+
+var nonSlugCharacters = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugify turns a name into a slug, as slugs are generated for new problems
+func slugify(name string) string {
+	slug := strings.Trim(nonSlugCharacters.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(slug) > 200 {
+		slug = strings.TrimRight(slug[:200], "-")
+	}
+	return slug
+}
+
+// latexReplacements turn the LaTeX commands common in problem statements into Markdown
+var latexReplacements = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?m)^\s*%.*\n?`), ""},
+	{regexp.MustCompile(`\\(?:sub)?section\*?\{([^{}]*)\}`), "## $1"},
+	{regexp.MustCompile(`\\(?:InputFile|Input)\b`), "## Input"},
+	{regexp.MustCompile(`\\(?:OutputFile|Output)\b`), "## Output"},
+	{regexp.MustCompile(`\\(?:Note|Notes)\b`), "## Note"},
+	{regexp.MustCompile(`\\(?:textbf)\{([^{}]*)\}`), "**$1**"},
+	{regexp.MustCompile(`\\(?:emph|textit)\{([^{}]*)\}`), "*$1*"},
+	{regexp.MustCompile(`\\(?:texttt|t)\{([^{}]*)\}`), "`$1`"},
+	{regexp.MustCompile(`\\begin\{(?:itemize|enumerate)\}|\\end\{(?:itemize|enumerate)\}`), ""},
+	{regexp.MustCompile(`(?m)^\s*\\item\s*`), "- "},
+	{regexp.MustCompile(`\\\\\s*`), "\n"},
+	{regexp.MustCompile(`~`), " "},
+	{regexp.MustCompile(`\n{3,}`), "\n\n"},
+}
+
+// latexGraphics matches the commands that include images in a statement
+var latexGraphics = regexp.MustCompile(`\\(?:includegraphics|illustration)(?:\[[^\]]*\])?\{[^{}]*\}(?:\{[^{}]*\})*`)
+
+// latexToMarkdown converts the body of a LaTeX statement to Markdown, keeping math in $...$ as
+// is. It handles the commands statements commonly use, not LaTeX in general.
+func latexToMarkdown(latex string, report *Report) string {
+	if latexGraphics.MatchString(latex) {
+		report.unsupported("statement images dropped")
+		latex = latexGraphics.ReplaceAllString(latex, "")
+	}
+	for _, r := range latexReplacements {
+		latex = r.pattern.ReplaceAllString(latex, r.replacement)
+	}
+	return strings.TrimSpace(latex) + "\n"
+}
+
+// joinFeatures lists names for a report, sorted
+func joinFeatures(names []string) string {
+	names = append([]string(nil), names...)
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
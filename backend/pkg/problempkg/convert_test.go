@@ -0,0 +1,279 @@
+package problempkg
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+// assertReported checks that every expected entry is part of one of the reported entries
+func assertReported(t *testing.T, kind string, reported, expected []string) {
+	t.Helper()
+	joined := strings.Join(reported, "\n")
+	for _, entry := range expected {
+		if !strings.Contains(joined, entry) {
+			t.Errorf("Expected %s entry %q, got:\n%s", kind, entry, joined)
+		}
+	}
+}
+
+func TestConvert_Kattis(t *testing.T) {
+	fsys := fstest.MapFS{
+		"different/problem.yaml": {Data: []byte(`problem_format_version: legacy
+name: A Different Problem
+author: Jane Doe
+source: NCPC 2005
+license: cc by-sa
+keywords: arithmetic math
+validator_flags: float_tolerance 1e-6
+limits:
+  memory: 256
+  time_multiplier: 5
+grading:
+  on_reject: worst_error
+`)},
+		"different/.timelimit": {Data: []byte("2.5\n")},
+		"different/problem_statement/problem.en.tex": {Data: []byte(`\problemname{A Different Problem}
+% A comment
+Write a program that computes the difference between \emph{two} integers.
+\illustration{0.3}{image.jpg}{Picture}
+
+\section*{Input}
+Each line holds two integers $a$ and $b$.
+
+\section*{Output}
+For each line, print $|a-b|$.
+`)},
+		"different/problem_statement/problem.sv.tex": {Data: []byte(`\problemname{Ett annat problem}`)},
+		"different/problem_statement/image.jpg":      {Data: []byte("jpeg")},
+		"different/data/sample/1.in":                 {Data: []byte("10 12\n")},
+		"different/data/sample/1.ans":                {Data: []byte("2\n")},
+		"different/data/secret/group1/1.in":          {Data: []byte("1 5\n")},
+		"different/data/secret/group1/1.ans":         {Data: []byte("4\n")},
+		"different/data/secret/group1/testdata.yaml": {Data: []byte("accept_score: 25\n")},
+		"different/data/secret/2.in":                 {Data: []byte("7 7\n")},
+		"different/data/secret/2.ans":                {Data: []byte("0\n")},
+		"different/data/invalid_input/1.in":          {Data: []byte("x\n")},
+		"different/input_validators/validate.py":     {Data: []byte("import sys")},
+		"different/submissions/accepted/sol.py":      {Data: []byte("print(0)")},
+	}
+
+	pkg, report, err := Convert(fsys, "")
+	if err != nil {
+		t.Fatalf("Failed to convert package: %v", err)
+	}
+	if err := pkg.Validate(); err != nil {
+		t.Fatalf("Expected converted package to be valid, got %v", err)
+	}
+
+	m := pkg.Manifest
+	if report.Format != FormatKattis || m.Slug != "different" || m.Title != "A Different Problem" {
+		t.Errorf("Expected Kattis problem different, got %s %q %q", report.Format, m.Slug, m.Title)
+	}
+	if !reflect.DeepEqual(m.Tags, []string{"arithmetic", "math"}) {
+		t.Errorf("Expected keywords as tags, got %v", m.Tags)
+	}
+	if m.TimeLimitMs != 2500 || m.MemoryLimitMb != 256 {
+		t.Errorf("Expected limits of 2500 ms and 256 MB, got %d and %d", m.TimeLimitMs, m.MemoryLimitMb)
+	}
+	if m.Checker != models.CheckerTokens || !m.IgnoreCase || m.FloatTolerance != 1e-6 {
+		t.Errorf("Expected the default validator with a tolerance, got %q %v %g", m.Checker, m.IgnoreCase, m.FloatTolerance)
+	}
+
+	if len(pkg.Tests) != 3 || !pkg.Tests[0].Public || pkg.Tests[1].Public || pkg.Tests[2].Output != "4\n" {
+		t.Errorf("Expected the sample then the secret tests, got %+v", pkg.Tests)
+	}
+	if len(m.Examples) != 1 || m.Examples[0].Input != "10 12" || m.Examples[0].Output != "2" {
+		t.Errorf("Expected the sample as example, got %+v", m.Examples)
+	}
+
+	if !strings.HasPrefix(pkg.Statement, "Write a program") || !strings.Contains(pkg.Statement, "*two*") ||
+		!strings.Contains(pkg.Statement, "## Input") || strings.Contains(pkg.Statement, "comment") {
+		t.Errorf("Expected the statement converted to Markdown, got:\n%s", pkg.Statement)
+	}
+
+	assertReported(t, "unsupported", report.Unsupported, []string{
+		"problem.yaml settings ignored: grading, limits.time_multiplier",
+		"statement images dropped",
+		"statements in other languages dropped: problem.sv.tex",
+		"statement files dropped: image.jpg",
+		"test groups flattened: group1",
+		"data/secret/group1/testdata.yaml: test group settings ignored",
+		"test data for validators ignored: invalid_input",
+		"input_validators: input validators are not run",
+		"submissions: submissions are standard input programs",
+	})
+	assertReported(t, "default", report.Defaults, []string{"difficulty set to Medium", "generic templates"})
+}
+
+func TestConvert_KattisInteractive(t *testing.T) {
+	fsys := fstest.MapFS{
+		"problem.yaml":                      {Data: []byte("name: Guess\nvalidation: custom interactive\n")},
+		"problem_statement/problem.md":      {Data: []byte("Guess the number.")},
+		"data/sample/1.interaction":         {Data: []byte("<1\n>2\n")},
+		"output_validators/guess/guess.cpp": {Data: []byte("int main() {}")},
+	}
+
+	pkg, report, err := Convert(fsys, "guess")
+	if err != nil {
+		t.Fatalf("Failed to convert package: %v", err)
+	}
+
+	var validationErr *ValidationError
+	if err := pkg.Validate(); !errors.As(err, &validationErr) {
+		t.Fatalf("Expected an interactive problem to be invalid, got %v", err)
+	}
+	assertReported(t, "issue", validationErr.Issues, []string{
+		"interactive problems are not supported",
+		"data/sample/1.interaction",
+	})
+	assertReported(t, "unsupported", report.Unsupported, []string{
+		"custom output validator",
+		"time limit derived from the running time of submissions",
+	})
+}
+
+func TestConvert_Polygon(t *testing.T) {
+	fsys := fstest.MapFS{
+		"problem.xml": {Data: []byte(`<?xml version="1.0" encoding="utf-8" standalone="no"?>
+<problem revision="3" short-name="a-plus-b" url="https://polygon.codeforces.com/p/example/a-plus-b">
+    <names>
+        <name language="english" value="A+B"/>
+        <name language="russian" value="А+Б"/>
+    </names>
+    <statements>
+        <statement charset="UTF-8" language="english" mathjax="true" path="statements/english/problem.tex" type="application/x-tex"/>
+        <statement charset="UTF-8" language="russian" mathjax="true" path="statements/russian/problem.tex" type="application/x-tex"/>
+    </statements>
+    <judging cpu-name="Intel(R) Core(TM) i3-8100 CPU @ 3.60GHz" cpu-speed="3600" input-file="" output-file="">
+        <testset name="tests">
+            <time-limit>1000</time-limit>
+            <memory-limit>268435456</memory-limit>
+            <test-count>3</test-count>
+            <input-path-pattern>tests/%02d</input-path-pattern>
+            <answer-path-pattern>tests/%02d.a</answer-path-pattern>
+            <tests>
+                <test method="manual" sample="true"/>
+                <test method="manual"/>
+                <test cmd="gen 100" method="generated"/>
+            </tests>
+        </testset>
+    </judging>
+    <assets>
+        <checker name="std::rcmp6.cpp" type="testlib">
+            <source path="files/check.cpp" type="cpp.g++17"/>
+        </checker>
+        <validators>
+            <validator>
+                <source path="files/val.cpp" type="cpp.g++17"/>
+            </validator>
+        </validators>
+        <solutions>
+            <solution tag="main">
+                <source path="solutions/sol.cpp" type="cpp.g++17"/>
+            </solution>
+        </solutions>
+    </assets>
+    <tags>
+        <tag value="math"/>
+    </tags>
+</problem>
+`)},
+		"statement-sections/english/name.tex":    {Data: []byte("A+B")},
+		"statement-sections/english/legend.tex":  {Data: []byte("Print the sum of $a$ and $b$.")},
+		"statement-sections/english/input.tex":   {Data: []byte("Two numbers $a$ and $b$.")},
+		"statement-sections/english/output.tex":  {Data: []byte("Their sum.")},
+		"statement-sections/english/picture.png": {Data: []byte("png")},
+		"tests/01":                               {Data: []byte("1 2\n")},
+		"tests/01.a":                             {Data: []byte("3\n")},
+		"tests/02":                               {Data: []byte("0.5 0.25\n")},
+		"tests/02.a":                             {Data: []byte("0.75\n")},
+	}
+
+	pkg, report, err := Convert(fsys, "")
+	if err != nil {
+		t.Fatalf("Failed to convert package: %v", err)
+	}
+
+	m := pkg.Manifest
+	if report.Format != FormatPolygon || m.Slug != "a-plus-b" || m.Title != "A+B" || !reflect.DeepEqual(m.Tags, []string{"math"}) {
+		t.Errorf("Expected Polygon problem a-plus-b, got %s %q %q %v", report.Format, m.Slug, m.Title, m.Tags)
+	}
+	if m.TimeLimitMs != 1000 || m.MemoryLimitMb != 256 {
+		t.Errorf("Expected limits of 1000 ms and 256 MB, got %d and %d", m.TimeLimitMs, m.MemoryLimitMb)
+	}
+	if m.Checker != models.CheckerTokens || m.IgnoreCase || m.FloatTolerance != 1e-6 {
+		t.Errorf("Expected rcmp6 as tokens with a tolerance, got %q %v %g", m.Checker, m.IgnoreCase, m.FloatTolerance)
+	}
+	if len(pkg.Tests) != 2 || !pkg.Tests[0].Public || pkg.Tests[1].Public {
+		t.Errorf("Expected the sample and the manual test, got %+v", pkg.Tests)
+	}
+	expectedStatement := "Print the sum of $a$ and $b$.\n\n## Input\n\nTwo numbers $a$ and $b$.\n\n## Output\n\nTheir sum.\n"
+	if pkg.Statement != expectedStatement {
+		t.Errorf("Expected statement:\n%s\ngot:\n%s", expectedStatement, pkg.Statement)
+	}
+
+	var validationErr *ValidationError
+	if err := pkg.Validate(); !errors.As(err, &validationErr) {
+		t.Fatalf("Expected the missing generated test to be reported, got %v", err)
+	}
+	assertReported(t, "issue", validationErr.Issues, []string{`test 3 is generated by "gen 100" and tests/03 is missing`})
+	assertReported(t, "unsupported", report.Unsupported, []string{
+		"statements in other languages dropped: russian",
+		"statement files dropped: picture.png",
+		"input validators are not run",
+		"solutions are standard input programs",
+	})
+}
+
+func TestConvert_PolygonCheckers(t *testing.T) {
+	tests := []struct {
+		checker     string
+		expected    string
+		ignoreCase  bool
+		tolerance   float64
+		unsupported string
+	}{
+		{`<checker name="std::wcmp.cpp" type="testlib"/>`, models.CheckerTokens, false, 0, ""},
+		{`<checker name="std::yesno.cpp" type="testlib"/>`, models.CheckerTokens, true, 0, ""},
+		{`<checker name="std::fcmp.cpp" type="testlib"/>`, models.CheckerExact, false, 0, "the whole output is compared"},
+		{`<checker name="std::uncmp.cpp" type="testlib"/>`, models.CheckerTokens, false, 0, "standard checker std::uncmp.cpp"},
+		{`<checker type="testlib"><source path="files/check.cpp" type="cpp.g++17"/></checker>`, models.CheckerTokens, false, 0, "custom checker files/check.cpp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.checker, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				"problem.xml": {Data: []byte(`<problem short-name="checked"><judging><testset name="tests">
+<time-limit>2000</time-limit><memory-limit>67108864</memory-limit><test-count>0</test-count>
+</testset></judging><assets>` + tt.checker + `</assets></problem>`)},
+			}
+
+			pkg, report, err := Convert(fsys, "")
+			if err != nil {
+				t.Fatalf("Failed to convert package: %v", err)
+			}
+
+			m := pkg.Manifest
+			if m.Checker != tt.expected || m.IgnoreCase != tt.ignoreCase || m.FloatTolerance != tt.tolerance {
+				t.Errorf("Expected %q %v %g, got %q %v %g", tt.expected, tt.ignoreCase, tt.tolerance, m.Checker, m.IgnoreCase, m.FloatTolerance)
+			}
+			if tt.unsupported != "" {
+				assertReported(t, "unsupported", report.Unsupported, []string{tt.unsupported})
+			}
+		})
+	}
+}
+
+func TestConvert_UnknownFormat(t *testing.T) {
+	_, _, err := Convert(fstest.MapFS{"statement.md": {Data: []byte("text")}}, "")
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+}
@@ -0,0 +1,421 @@
+package problempkg
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+// kattisMetadata are the keys of a Kattis problem.yaml that do not affect judging
+var kattisMetadata = map[string]bool{
+	"problem_format_version": true,
+	"uuid":                   true,
+	"version":                true,
+	"author":                 true,
+	"authors":                true,
+	"credits":                true,
+	"source":                 true,
+	"source_url":             true,
+	"license":                true,
+	"rights_owner":           true,
+}
+
+// kattisStatementDirs are the statement directories of the legacy and current Kattis formats
+var kattisStatementDirs = []string{"problem_statement", "statement"}
+
+// kattisIgnoredDirs are the directories of a Kattis package that cannot be carried over, with
+// what they hold
+var kattisIgnoredDirs = []struct{ dir, feature string }{
+	{"input_validators", "input validators are not run"},
+	{"input_format_validators", "input validators are not run"},
+	{"input_validator", "input validators are not run"},
+	{"submissions", "submissions are standard input programs and were not imported as reference solutions"},
+	{"attachments", "attachments dropped"},
+	{"generators", "test generators dropped"},
+	{"include", "included source files dropped"},
+	{"solution", "solution descriptions dropped"},
+}
+
+// kattisProblemName matches the title of a LaTeX Kattis statement
+var kattisProblemName = regexp.MustCompile(`\\problemname\{([^{}]*)\}\s*`)
+
+// kattisProblem is the part of a Kattis problem.yaml used by the conversion
+type kattisProblem struct {
+	Name           interface{} `yaml:"name"`     // A name or names by language
+	Keywords       interface{} `yaml:"keywords"` // Space-separated or a list
+	Type           interface{} `yaml:"type"`     // A type or a list of types
+	Validation     string      `yaml:"validation"`
+	ValidatorFlags string      `yaml:"validator_flags"`
+	Limits         struct {
+		TimeLimit float64 `yaml:"time_limit"` // Seconds
+		Memory    int     `yaml:"memory"`     // MiB
+	} `yaml:"limits"`
+}
+
+// kattisTestData is the part of a Kattis testdata.yaml used by the conversion
+type kattisTestData struct {
+	OutputValidatorFlags string `yaml:"output_validator_flags"`
+}
+
+// convertKattis converts a Kattis problem package
+func convertKattis(r *reader, shortName string) (*Package, *Report, error) {
+	report := &Report{Format: FormatKattis}
+	pkg := newConvertedPackage(report)
+
+	data, err := r.readFile("problem.yaml")
+	if err != nil {
+		return nil, nil, err
+	}
+	var problem kattisProblem
+	var keys map[string]interface{}
+	if err := yaml.Unmarshal(data, &problem); err != nil {
+		return nil, nil, &ValidationError{Issues: []string{fmt.Sprintf("problem.yaml: %v", err)}}
+	}
+	if err := yaml.Unmarshal(data, &keys); err != nil {
+		return nil, nil, &ValidationError{Issues: []string{fmt.Sprintf("problem.yaml: %v", err)}}
+	}
+
+	var unknown []string
+	for key := range keys {
+		switch {
+		case kattisMetadata[key]:
+		case key == "name" || key == "keywords" || key == "type" || key == "validation" || key == "validator_flags":
+		case key == "limits":
+			if limits, ok := keys[key].(map[string]interface{}); ok {
+				for limit := range limits {
+					if limit != "time_limit" && limit != "memory" {
+						unknown = append(unknown, "limits."+limit)
+					}
+				}
+			}
+		default:
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		report.unsupported("problem.yaml settings ignored: %s", joinFeatures(unknown))
+	}
+
+	pkg.Manifest.Title = kattisName(problem.Name)
+	pkg.Manifest.Tags = stringList(problem.Keywords)
+
+	types := append(stringList(problem.Type), strings.Fields(problem.Validation)...)
+	for _, kind := range types {
+		switch kind {
+		case "interactive", "multi-pass", "submit-answer":
+			pkg.issues = append(pkg.issues, fmt.Sprintf("problem.yaml: %s problems are not supported", kind))
+			report.unsupported("%s problem", kind)
+		case "scoring", "score":
+			report.unsupported("scoring problem judged as pass-fail")
+		}
+	}
+
+	if err := convertKattisStatement(r, pkg, report); err != nil {
+		return nil, nil, err
+	}
+	setSlug(pkg, report, shortName)
+
+	timeLimitMs := int(math.Round(problem.Limits.TimeLimit * 1000))
+	if timelimit, err := r.readOptional(".timelimit"); err != nil {
+		return nil, nil, err
+	} else if timelimit != nil {
+		seconds, err := strconv.ParseFloat(strings.TrimSpace(string(timelimit)), 64)
+		if err != nil {
+			pkg.issues = append(pkg.issues, fmt.Sprintf(".timelimit: invalid time limit %q", strings.TrimSpace(string(timelimit))))
+		}
+		timeLimitMs = int(math.Round(seconds * 1000))
+	}
+	if timeLimitMs == 0 {
+		report.unsupported("time limit derived from the running time of submissions; the default is used instead")
+	}
+	setLimits(pkg, report, timeLimitMs, problem.Limits.Memory)
+
+	flags := problem.ValidatorFlags
+	for _, name := range []string{"data/testdata.yaml", "data/secret/testdata.yaml"} {
+		testData, err := readKattisTestData(r, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if testData.OutputValidatorFlags != "" {
+			flags = testData.OutputValidatorFlags
+		}
+	}
+	if customValidator(r, problem.Validation) {
+		report.unsupported("custom output validator; output is compared token by token instead")
+	}
+	setKattisChecker(pkg, report, flags)
+
+	if err := convertKattisTests(r, pkg, report); err != nil {
+		return nil, nil, err
+	}
+
+	for _, ignored := range kattisIgnoredDirs {
+		if files, err := r.listFiles(ignored.dir); err != nil {
+			return nil, nil, err
+		} else if len(files) > 0 {
+			report.unsupported("%s: %s", ignored.dir, ignored.feature)
+		}
+	}
+
+	return pkg, report, nil
+}
+
+// kattisName returns the English name of a problem, or its only name
+func kattisName(name interface{}) string {
+	switch name := name.(type) {
+	case string:
+		return name
+	case map[string]interface{}:
+		if english, ok := name["en"].(string); ok {
+			return english
+		}
+		for _, language := range sortedKeys(name) {
+			if value, ok := name[language].(string); ok {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// stringList returns a YAML value that is a space-separated string or a list as a list
+func stringList(value interface{}) []string {
+	switch value := value.(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		var list []string
+		for _, item := range value {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				list = append(list, strings.TrimSpace(s))
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// customValidator reports whether a Kattis problem is checked by its own output validator
+func customValidator(r *reader, validation string) bool {
+	if strings.HasPrefix(validation, "custom") {
+		return true
+	}
+	return r.exists("output_validators") || r.exists("output_validator")
+}
+
+// readKattisTestData reads a testdata.yaml file, if there is one
+func readKattisTestData(r *reader, name string) (*kattisTestData, error) {
+	var testData kattisTestData
+	data, err := r.readOptional(name)
+	if err != nil || data == nil {
+		return &testData, err
+	}
+	if err := yaml.Unmarshal(data, &testData); err != nil {
+		return nil, &ValidationError{Issues: []string{fmt.Sprintf("%s: %v", name, err)}}
+	}
+	return &testData, nil
+}
+
+// setKattisChecker sets the checker of the default Kattis output validator with its flags
+func setKattisChecker(pkg *Package, report *Report, flags string) {
+	pkg.Manifest.Checker = models.CheckerTokens
+	pkg.Manifest.IgnoreCase = true
+
+	var absolute, relative float64
+	fields := strings.Fields(flags)
+	for i := 0; i < len(fields); i++ {
+		switch flag := fields[i]; flag {
+		case "case_sensitive":
+			pkg.Manifest.IgnoreCase = false
+		case "space_change_sensitive":
+			report.unsupported("space_change_sensitive: changes in whitespace are accepted")
+		case "float_tolerance", "float_absolute_tolerance", "float_relative_tolerance":
+			if i+1 == len(fields) {
+				pkg.issues = append(pkg.issues, fmt.Sprintf("output validator flag %s needs a value", flag))
+				continue
+			}
+			i++
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil || value < 0 {
+				pkg.issues = append(pkg.issues, fmt.Sprintf("output validator flag %s has invalid value %q", flag, fields[i]))
+				continue
+			}
+			if flag != "float_relative_tolerance" {
+				absolute = value
+			}
+			if flag != "float_absolute_tolerance" {
+				relative = value
+			}
+		default:
+			report.unsupported("output validator flag %s ignored", flag)
+		}
+	}
+
+	pkg.Manifest.FloatTolerance = math.Max(absolute, relative)
+	if absolute != relative {
+		report.unsupported("absolute tolerance %g and relative tolerance %g replaced by a tolerance of %g for both",
+			absolute, relative, pkg.Manifest.FloatTolerance)
+	}
+}
+
+// convertKattisStatement converts the statement of a Kattis package, preferring English
+func convertKattisStatement(r *reader, pkg *Package, report *Report) error {
+	var files []string
+	for _, dir := range kattisStatementDirs {
+		dirFiles, err := r.listFiles(dir)
+		if err != nil {
+			return err
+		}
+		files = append(files, dirFiles...)
+	}
+
+	var statement string
+	var others, dropped []string
+	for _, preferred := range []string{"problem.en.md", "problem.md", "problem.en.tex", "problem.tex"} {
+		for _, name := range files {
+			if path.Base(name) == preferred && statement == "" {
+				statement = name
+			}
+		}
+	}
+	for _, name := range files {
+		base := path.Base(name)
+		switch {
+		case name == statement:
+		case strings.HasPrefix(base, "problem.") && (path.Ext(base) == ".tex" || path.Ext(base) == ".md"):
+			others = append(others, base)
+		default:
+			dropped = append(dropped, base)
+		}
+	}
+	if statement == "" && len(others) > 0 {
+		for _, name := range files {
+			if path.Base(name) == others[0] {
+				statement = name
+			}
+		}
+		others = others[1:]
+	}
+
+	if len(others) > 0 {
+		report.unsupported("statements in other languages dropped: %s", joinFeatures(others))
+	}
+	if len(dropped) > 0 {
+		report.unsupported("statement files dropped: %s", joinFeatures(dropped))
+	}
+	if statement == "" {
+		return nil
+	}
+
+	data, err := r.readFile(statement)
+	if err != nil {
+		return err
+	}
+	text := string(data)
+	if path.Ext(statement) == ".md" {
+		pkg.Statement = strings.TrimSpace(text) + "\n"
+		return nil
+	}
+
+	if match := kattisProblemName.FindStringSubmatch(text); match != nil {
+		if pkg.Manifest.Title == "" {
+			pkg.Manifest.Title = strings.TrimSpace(match[1])
+		}
+		text = kattisProblemName.ReplaceAllString(text, "")
+	}
+	pkg.Statement = latexToMarkdown(text, report)
+	report.unsupported("LaTeX statement converted to Markdown; check its formatting")
+	return nil
+}
+
+// convertKattisTests converts the sample and secret test data of a Kattis package in the order of
+// their names, which puts samples first. Samples are public; secret test groups are flattened.
+func convertKattisTests(r *reader, pkg *Package, report *Report) error {
+	files, err := r.listFiles("data")
+	if err != nil {
+		return err
+	}
+
+	var groups, ignored []string
+	for _, name := range files {
+		dir, base, ext := path.Dir(name), path.Base(name), path.Ext(name)
+		stem := strings.TrimSuffix(name, ext)
+		public := strings.HasPrefix(name, "data/sample/")
+		switch {
+		case !public && !strings.HasPrefix(name, "data/secret/"):
+			if top := strings.SplitN(name, "/", 3); len(top) == 3 {
+				ignored = append(ignored, top[1])
+			}
+		case ext == ".interaction":
+			pkg.issues = append(pkg.issues, fmt.Sprintf("%s: interactive problems are not supported", name))
+		case ext == ".in":
+			output, err := r.readOptional(stem + ".ans")
+			if err != nil {
+				return err
+			}
+			if output == nil {
+				pkg.issues = append(pkg.issues, fmt.Sprintf("%s has no %s.ans", name, path.Base(stem)))
+				continue
+			}
+			input, err := r.readFile(name)
+			if err != nil {
+				return err
+			}
+			pkg.Tests = append(pkg.Tests, Test{Input: string(input), Output: string(output), Public: public})
+			if dir != "data/sample" && dir != "data/secret" {
+				groups = appendOnce(groups, strings.TrimPrefix(dir, "data/secret/"))
+			}
+		case base == "testdata.yaml" && dir != "data" && dir != "data/secret":
+			report.unsupported("%s: test group settings ignored", name)
+		}
+	}
+
+	if len(groups) > 0 {
+		report.unsupported("test groups flattened: %s", joinFeatures(groups))
+	}
+	if len(ignored) > 0 {
+		report.unsupported("test data for validators ignored: %s", joinFeatures(uniqueStrings(ignored)))
+	}
+
+	numberTests(pkg)
+	return nil
+}
+
+// appendOnce appends a string to a list unless it is already the last element
+func appendOnce(list []string, s string) []string {
+	if len(list) > 0 && list[len(list)-1] == s {
+		return list
+	}
+	return append(list, s)
+}
+
+// uniqueStrings returns the distinct strings of a list in order
+func uniqueStrings(list []string) []string {
+	seen := make(map[string]bool, len(list))
+	var unique []string
+	for _, s := range list {
+		if !seen[s] {
+			seen[s] = true
+			unique = append(unique, s)
+		}
+	}
+	return unique
+}
+
+// sortedKeys returns the keys of a YAML mapping in order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
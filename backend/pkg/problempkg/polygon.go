@@ -0,0 +1,370 @@
+package problempkg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+// polygonChecker is how a standard testlib checker is judged
+type polygonChecker struct {
+	checker    string
+	ignoreCase bool
+	tolerance  float64
+	note       string // Set when the checker is only approximated
+}
+
+// polygonCheckers maps the standard testlib checkers to the checkers of the judge
+var polygonCheckers = map[string]polygonChecker{
+	"wcmp":   {checker: models.CheckerTokens},
+	"ncmp":   {checker: models.CheckerTokens},
+	"hcmp":   {checker: models.CheckerTokens},
+	"lcmp":   {checker: models.CheckerTokens, note: "line breaks are not compared"},
+	"fcmp":   {checker: models.CheckerExact, note: "the whole output is compared instead of each line"},
+	"yesno":  {checker: models.CheckerTokens, ignoreCase: true},
+	"nyesno": {checker: models.CheckerTokens, ignoreCase: true},
+	"rcmp":   {checker: models.CheckerTokens, tolerance: 1.5e-6},
+	"rcmp4":  {checker: models.CheckerTokens, tolerance: 1e-4},
+	"rcmp6":  {checker: models.CheckerTokens, tolerance: 1e-6},
+	"rcmp9":  {checker: models.CheckerTokens, tolerance: 1e-9},
+	"dcmp":   {checker: models.CheckerTokens, tolerance: 1e-6},
+	"acmp":   {checker: models.CheckerTokens, tolerance: 1.5e-6, note: "relative error is accepted as well as absolute error"},
+}
+
+// polygonSections are the statement sections of a Polygon package in the order they are shown,
+// with their heading
+var polygonSections = []struct{ file, heading string }{
+	{"legend.tex", ""},
+	{"input.tex", "Input"},
+	{"output.tex", "Output"},
+	{"notes.tex", "Note"},
+}
+
+// polygonStatementWrappers match the LaTeX of a full Polygon statement that is not part of the
+// problem description
+var polygonStatementWrappers = regexp.MustCompile(`\\begin\{problem\}(?:\{[^{}]*\})*|\\end\{problem\}|\\Examples\b|\\exmp(?:file)?\{[^{}]*\}\{[^{}]*\}%?`)
+
+// polygonProblem is the part of a Polygon problem.xml used by the conversion
+type polygonProblem struct {
+	ShortName string `xml:"short-name,attr"`
+	Names     []struct {
+		Language string `xml:"language,attr"`
+		Value    string `xml:"value,attr"`
+	} `xml:"names>name"`
+	Statements []struct {
+		Language string `xml:"language,attr"`
+		Path     string `xml:"path,attr"`
+		Type     string `xml:"type,attr"`
+	} `xml:"statements>statement"`
+	Judging struct {
+		InputFile  string           `xml:"input-file,attr"`
+		OutputFile string           `xml:"output-file,attr"`
+		Testsets   []polygonTestset `xml:"testset"`
+	} `xml:"judging"`
+	Assets struct {
+		Checker *struct {
+			Name   string        `xml:"name,attr"`
+			Source polygonSource `xml:"source"`
+		} `xml:"checker"`
+		Interactor *struct {
+			Source polygonSource `xml:"source"`
+		} `xml:"interactor"`
+		Validators []struct {
+			Source polygonSource `xml:"source"`
+		} `xml:"validators>validator"`
+		Solutions []struct {
+			Tag    string        `xml:"tag,attr"`
+			Source polygonSource `xml:"source"`
+		} `xml:"solutions>solution"`
+	} `xml:"assets"`
+	Tags []struct {
+		Value string `xml:"value,attr"`
+	} `xml:"tags>tag"`
+}
+
+// polygonTestset is a set of tests of a Polygon problem with its limits
+type polygonTestset struct {
+	Name              string `xml:"name,attr"`
+	TimeLimit         int    `xml:"time-limit"`   // Milliseconds
+	MemoryLimit       int64  `xml:"memory-limit"` // Bytes
+	TestCount         int    `xml:"test-count"`
+	InputPathPattern  string `xml:"input-path-pattern"`
+	AnswerPathPattern string `xml:"answer-path-pattern"`
+	Tests             []struct {
+		Method string `xml:"method,attr"`
+		Cmd    string `xml:"cmd,attr"`
+		Sample bool   `xml:"sample,attr"`
+		Group  string `xml:"group,attr"`
+		Points string `xml:"points,attr"`
+	} `xml:"tests>test"`
+	Groups []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"groups>group"`
+}
+
+// polygonSource is a source file of a Polygon package
+type polygonSource struct {
+	Path string `xml:"path,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// convertPolygon converts a Polygon problem package
+func convertPolygon(r *reader, shortName string) (*Package, *Report, error) {
+	report := &Report{Format: FormatPolygon}
+	pkg := newConvertedPackage(report)
+
+	data, err := r.readFile("problem.xml")
+	if err != nil {
+		return nil, nil, err
+	}
+	var problem polygonProblem
+	if err := xml.Unmarshal(data, &problem); err != nil {
+		return nil, nil, &ValidationError{Issues: []string{fmt.Sprintf("problem.xml: %v", err)}}
+	}
+
+	language := polygonLanguage(&problem)
+	for _, name := range problem.Names {
+		if name.Language == language || pkg.Manifest.Title == "" {
+			pkg.Manifest.Title = name.Value
+		}
+	}
+	if problem.ShortName != "" {
+		shortName = problem.ShortName
+	}
+	setSlug(pkg, report, shortName)
+	for _, tag := range problem.Tags {
+		pkg.Manifest.Tags = append(pkg.Manifest.Tags, tag.Value)
+	}
+
+	if err := convertPolygonStatement(r, pkg, report, &problem, language); err != nil {
+		return nil, nil, err
+	}
+
+	if file := problem.Judging.InputFile; file != "" && file != "stdin" {
+		report.unsupported("input file %s: the input is passed to solution instead", file)
+	}
+	if file := problem.Judging.OutputFile; file != "" && file != "stdout" {
+		report.unsupported("output file %s: the value returned by solution is checked instead", file)
+	}
+
+	if len(problem.Judging.Testsets) == 0 {
+		pkg.issues = append(pkg.issues, "problem.xml: no testset")
+		setLimits(pkg, report, 0, 0)
+	} else {
+		testset := problem.Judging.Testsets[0]
+		var others []string
+		for _, candidate := range problem.Judging.Testsets {
+			if candidate.Name == "tests" {
+				testset = candidate
+			}
+		}
+		for _, other := range problem.Judging.Testsets {
+			if other.Name != testset.Name {
+				others = append(others, other.Name)
+			}
+		}
+		if len(others) > 0 {
+			report.unsupported("testsets other than %s dropped: %s", testset.Name, joinFeatures(others))
+		}
+
+		setLimits(pkg, report, testset.TimeLimit, int(testset.MemoryLimit>>20))
+		if err := convertPolygonTests(r, pkg, report, &testset); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	setPolygonChecker(pkg, report, &problem)
+
+	if problem.Assets.Interactor != nil {
+		pkg.issues = append(pkg.issues, "problem.xml: interactive problems are not supported")
+		report.unsupported("interactor %s", problem.Assets.Interactor.Source.Path)
+	}
+	if len(problem.Assets.Validators) > 0 {
+		report.unsupported("input validators are not run")
+	}
+	if len(problem.Assets.Solutions) > 0 {
+		report.unsupported("solutions are standard input programs and were not imported as reference solutions")
+	}
+
+	return pkg, report, nil
+}
+
+// polygonLanguage returns the language of the names and statements to convert: English if the
+// problem has it, or else its first language
+func polygonLanguage(problem *polygonProblem) string {
+	var first string
+	for _, name := range problem.Names {
+		if name.Language == "english" {
+			return name.Language
+		}
+		if first == "" {
+			first = name.Language
+		}
+	}
+	for _, statement := range problem.Statements {
+		if statement.Language == "english" {
+			return statement.Language
+		}
+		if first == "" {
+			first = statement.Language
+		}
+	}
+	return first
+}
+
+// convertPolygonStatement converts the statement of a Polygon package, from its sections if the
+// package has them or else from the full LaTeX statement
+func convertPolygonStatement(r *reader, pkg *Package, report *Report, problem *polygonProblem, language string) error {
+	var others []string
+	for _, statement := range problem.Statements {
+		if statement.Language != language {
+			others = appendOnce(others, statement.Language)
+		}
+	}
+	if len(others) > 0 {
+		report.unsupported("statements in other languages dropped: %s", joinFeatures(uniqueStrings(others)))
+	}
+
+	dir := "statement-sections/" + language
+	if r.exists(dir) {
+		var sections []string
+		for _, section := range polygonSections {
+			data, err := r.readOptional(dir + "/" + section.file)
+			if err != nil {
+				return err
+			}
+			if strings.TrimSpace(string(data)) == "" {
+				continue
+			}
+			if section.heading != "" {
+				sections = append(sections, "## "+section.heading)
+			}
+			sections = append(sections, string(data))
+		}
+		pkg.Statement = latexToMarkdown(strings.Join(sections, "\n\n"), report)
+
+		files, err := r.listFiles(dir)
+		if err != nil {
+			return err
+		}
+		var dropped []string
+		for _, name := range files {
+			base := path.Base(name)
+			switch {
+			case base == "name.tex" || strings.HasPrefix(base, "example."):
+			case base == "legend.tex" || base == "input.tex" || base == "output.tex" || base == "notes.tex":
+			default:
+				dropped = append(dropped, base)
+			}
+		}
+		if len(dropped) > 0 {
+			report.unsupported("statement files dropped: %s", joinFeatures(dropped))
+		}
+	} else {
+		for _, statement := range problem.Statements {
+			if statement.Language != language || statement.Type != "application/x-tex" {
+				continue
+			}
+			data, err := r.readOptional(statement.Path)
+			if err != nil {
+				return err
+			}
+			if data != nil {
+				pkg.Statement = latexToMarkdown(polygonStatementWrappers.ReplaceAllString(string(data), ""), report)
+				break
+			}
+		}
+	}
+
+	if pkg.Statement != "" {
+		report.unsupported("LaTeX statement converted to Markdown; check its formatting")
+	}
+	return nil
+}
+
+// convertPolygonTests converts the tests of a testset. Sample tests are public.
+func convertPolygonTests(r *reader, pkg *Package, report *Report, testset *polygonTestset) error {
+	inputPattern, answerPattern := testset.InputPathPattern, testset.AnswerPathPattern
+	if inputPattern == "" {
+		inputPattern = testset.Name + "/%02d"
+	}
+	if answerPattern == "" {
+		answerPattern = inputPattern + ".a"
+	}
+
+	count := len(testset.Tests)
+	if count == 0 {
+		count = testset.TestCount
+	}
+	scored := len(testset.Groups) > 0
+	for i := 1; i <= count; i++ {
+		var method, cmd string
+		var sample bool
+		if i <= len(testset.Tests) {
+			test := testset.Tests[i-1]
+			method, cmd, sample = test.Method, test.Cmd, test.Sample
+			scored = scored || test.Group != "" || test.Points != ""
+		}
+
+		inputName, answerName := fmt.Sprintf(inputPattern, i), fmt.Sprintf(answerPattern, i)
+		input, err := r.readOptional(inputName)
+		if err != nil {
+			return err
+		}
+		if input == nil {
+			if method == "generated" {
+				pkg.issues = append(pkg.issues, fmt.Sprintf("test %d is generated by %q and %s is missing; export a full package from Polygon", i, cmd, inputName))
+			} else {
+				pkg.issues = append(pkg.issues, fmt.Sprintf("test %d: %s is missing", i, inputName))
+			}
+			continue
+		}
+		answer, err := r.readOptional(answerName)
+		if err != nil {
+			return err
+		}
+		if answer == nil {
+			pkg.issues = append(pkg.issues, fmt.Sprintf("test %d: %s is missing; export a full package from Polygon to include answers", i, answerName))
+			continue
+		}
+
+		pkg.Tests = append(pkg.Tests, Test{Input: string(input), Output: string(answer), Public: sample})
+	}
+
+	if scored {
+		report.unsupported("test groups and points ignored; problems are judged pass-fail")
+	}
+	numberTests(pkg)
+	return nil
+}
+
+// setPolygonChecker sets the checker of a Polygon problem from its standard testlib checker
+func setPolygonChecker(pkg *Package, report *Report, problem *polygonProblem) {
+	pkg.Manifest.Checker = models.CheckerTokens
+
+	checker := problem.Assets.Checker
+	if checker == nil {
+		report.defaulted("no checker: output is compared token by token")
+		return
+	}
+
+	name := strings.TrimSuffix(strings.TrimPrefix(checker.Name, "std::"), ".cpp")
+	standard, known := polygonCheckers[name]
+	switch {
+	case !strings.HasPrefix(checker.Name, "std::"):
+		report.unsupported("custom checker %s; output is compared token by token instead", checker.Source.Path)
+	case !known:
+		report.unsupported("standard checker %s; output is compared token by token instead", checker.Name)
+	default:
+		pkg.Manifest.Checker = standard.checker
+		pkg.Manifest.IgnoreCase = standard.ignoreCase
+		pkg.Manifest.FloatTolerance = standard.tolerance
+		if standard.note != "" {
+			report.unsupported("checker %s approximated: %s", checker.Name, standard.note)
+		}
+	}
+}
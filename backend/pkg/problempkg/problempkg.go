@@ -0,0 +1,273 @@
+// Package problempkg reads, writes and validates problem packages: portable, versioned archives
+// of a problem with its statement, test data, templates and reference solutions, used to move
+// problems between instances. A package is a directory or a zip file laid out as
+//
+//	problem.yaml          manifest: format version, slug, title, difficulty, tags, examples, limits, ...
+//	statement.md          problem description
+//	tests/NN.in           test input
+//	tests/NN.out          expected output
+//	templates/template.js starter code, one file per language (.js, .py, .java)
+//	solutions/NAME.py     reference solutions, language taken from the extension
+//	generator.py          optional test input generator, language taken from the extension
+//	validator.py          optional test input validator, language taken from the extension
+//
+// A zip file may also hold the package in a single top-level directory. Packages in the ICPC
+// (Kattis) problem package format and Codeforces Polygon packages are converted to this format by
+// Convert, which reports what could not be carried over.
+package problempkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+// FormatVersion is the version of the package format written by this package
+const FormatVersion = 1
+
+// MaxSize is the total size of the files read from a package
+const MaxSize = 64 << 20
+
+// languageExtensions maps the supported languages to their file extension
+var languageExtensions = map[string]string{
+	models.LanguageJavaScript: ".js",
+	models.LanguagePython:     ".py",
+	models.LanguageJava:       ".java",
+}
+
+// Package is a problem package
+type Package struct {
+	Manifest  Manifest
+	Statement string
+	Tests     []Test             // Ordered by name
+	Templates map[string]string  // Template code by language
+	Solutions []Solution         // Ordered by name
+	Programs  map[string]Program // Generator and validator by kind
+
+	// Problems found while reading the package, reported by Validate
+	issues []string
+}
+
+// Manifest is the content of problem.yaml
+type Manifest struct {
+	FormatVersion int       `yaml:"format_version"`
+	Slug          string    `yaml:"slug"`
+	Title         string    `yaml:"title"`
+	Difficulty    string    `yaml:"difficulty"`
+	Tags          []string  `yaml:"tags,omitempty"`
+	Constraints   string    `yaml:"constraints,omitempty"`
+	Examples      []Example `yaml:"examples"`
+	PublicTests   []string  `yaml:"public_tests,omitempty"` // Names of the tests shown to users; the rest are hidden
+
+	// Judging settings; unset fields take the defaults of models.DefaultJudgeSettings
+	TimeLimitMs    int               `yaml:"time_limit_ms,omitempty"`
+	MemoryLimitMb  int               `yaml:"memory_limit_mb,omitempty"`
+	LanguageLimits map[string]Limits `yaml:"language_limits,omitempty"` // Languages with limits of their own
+	Checker        string            `yaml:"checker,omitempty"`         // models.CheckerExact or models.CheckerTokens
+	IgnoreCase     bool              `yaml:"ignore_case,omitempty"`     // Tokens checker only
+	FloatTolerance float64           `yaml:"float_tolerance,omitempty"` // Tokens checker only
+}
+
+// Limits are the time and memory limits of a language
+type Limits struct {
+	TimeLimitMs   int `yaml:"time_limit_ms"`
+	MemoryLimitMb int `yaml:"memory_limit_mb"`
+}
+
+// Example is a worked example shown in the statement
+type Example struct {
+	Input       string `yaml:"input"`
+	Output      string `yaml:"output"`
+	Explanation string `yaml:"explanation,omitempty"`
+}
+
+// Test is a test case of a package, named after its files in tests/
+type Test struct {
+	Name   string
+	Input  string
+	Output string
+	Public bool
+}
+
+// Solution is a reference solution of a package, named after its file in solutions/
+type Solution struct {
+	Name     string
+	Language string
+	Code     string
+}
+
+// Program is the test input generator or validator of a package, named after its kind
+type Program struct {
+	Language string
+	Code     string
+}
+
+// testProgramKinds are the kinds of test programs a package may hold, in file order
+var testProgramKinds = []string{models.TestProgramGenerator, models.TestProgramValidator}
+
+// FromProblem creates a package from a problem, its judging settings, its test cases, its
+// reference solutions and its test programs. Tests are numbered in the order given.
+func FromProblem(problem *models.Problem, settings *models.JudgeSettings, testCases []*models.TestCase, solutions []*models.ProblemSolution, programs []*models.TestProgram) *Package {
+	pkg := &Package{
+		Manifest: Manifest{
+			FormatVersion:  FormatVersion,
+			Slug:           problem.Slug,
+			Title:          problem.Title,
+			Difficulty:     problem.Difficulty,
+			Tags:           append([]string(nil), problem.Tags...),
+			Constraints:    problem.Constraints,
+			TimeLimitMs:    settings.TimeLimitMs,
+			MemoryLimitMb:  settings.MemoryLimitMb,
+			Checker:        settings.Checker,
+			IgnoreCase:     settings.IgnoreCase,
+			FloatTolerance: settings.FloatTolerance,
+		},
+		Statement: problem.Description,
+		Templates: make(map[string]string, len(problem.TemplateCode)),
+		Programs:  make(map[string]Program, len(programs)),
+	}
+
+	for _, example := range problem.Examples {
+		pkg.Manifest.Examples = append(pkg.Manifest.Examples, Example(example))
+	}
+	for language, code := range problem.TemplateCode {
+		pkg.Templates[language] = code
+	}
+	for language, limits := range settings.LanguageLimits {
+		if pkg.Manifest.LanguageLimits == nil {
+			pkg.Manifest.LanguageLimits = make(map[string]Limits, len(settings.LanguageLimits))
+		}
+		pkg.Manifest.LanguageLimits[language] = Limits{TimeLimitMs: limits.TimeLimitMs, MemoryLimitMb: limits.MemoryLimitMb}
+	}
+
+	width := len(fmt.Sprint(len(testCases)))
+	if width < 2 {
+		width = 2
+	}
+	for i, testCase := range testCases {
+		test := Test{
+			Name:   fmt.Sprintf("%0*d", width, i+1),
+			Input:  testCase.Input,
+			Output: testCase.ExpectedOutput,
+			Public: !testCase.IsHidden,
+		}
+		if test.Public {
+			pkg.Manifest.PublicTests = append(pkg.Manifest.PublicTests, test.Name)
+		}
+		pkg.Tests = append(pkg.Tests, test)
+	}
+
+	for _, solution := range solutions {
+		pkg.Solutions = append(pkg.Solutions, Solution{
+			Name:     solution.Name,
+			Language: solution.Language,
+			Code:     solution.Code,
+		})
+	}
+	sort.Slice(pkg.Solutions, func(i, j int) bool { return pkg.Solutions[i].Name < pkg.Solutions[j].Name })
+
+	for _, program := range programs {
+		pkg.Programs[program.Kind] = Program{Language: program.Language, Code: program.Code}
+	}
+
+	return pkg
+}
+
+// Problem returns the problem described by the package, without ID or lifecycle state
+func (p *Package) Problem() *models.Problem {
+	problem := &models.Problem{
+		Title:        strings.TrimSpace(p.Manifest.Title),
+		Slug:         p.Manifest.Slug,
+		Description:  p.Statement,
+		Difficulty:   p.Manifest.Difficulty,
+		Tags:         append([]string{}, p.Manifest.Tags...),
+		Examples:     models.Examples{},
+		Constraints:  p.Manifest.Constraints,
+		TemplateCode: models.TemplateCode{},
+	}
+
+	for _, example := range p.Manifest.Examples {
+		problem.Examples = append(problem.Examples, models.Example(example))
+	}
+	for language, code := range p.Templates {
+		problem.TemplateCode[language] = code
+	}
+
+	return problem
+}
+
+// JudgeSettings returns the judging settings of the package, without problem ID
+func (p *Package) JudgeSettings() *models.JudgeSettings {
+	settings := models.DefaultJudgeSettings(0)
+	if p.Manifest.TimeLimitMs != 0 {
+		settings.TimeLimitMs = p.Manifest.TimeLimitMs
+	}
+	if p.Manifest.MemoryLimitMb != 0 {
+		settings.MemoryLimitMb = p.Manifest.MemoryLimitMb
+	}
+	for language, limits := range p.Manifest.LanguageLimits {
+		if settings.LanguageLimits == nil {
+			settings.LanguageLimits = make(models.LanguageLimits, len(p.Manifest.LanguageLimits))
+		}
+		settings.LanguageLimits[language] = models.Limits{TimeLimitMs: limits.TimeLimitMs, MemoryLimitMb: limits.MemoryLimitMb}
+	}
+	if p.Manifest.Checker != "" {
+		settings.Checker = p.Manifest.Checker
+	}
+	settings.IgnoreCase = p.Manifest.IgnoreCase
+	settings.FloatTolerance = p.Manifest.FloatTolerance
+	return settings
+}
+
+// TestCases returns the test cases of the package in order, without problem ID
+func (p *Package) TestCases() []*models.TestCase {
+	testCases := make([]*models.TestCase, len(p.Tests))
+	for i, test := range p.Tests {
+		testCases[i] = &models.TestCase{
+			Input:          test.Input,
+			ExpectedOutput: test.Output,
+			IsHidden:       !test.Public,
+		}
+	}
+	return testCases
+}
+
+// ProblemSolutions returns the reference solutions of the package, without problem ID
+func (p *Package) ProblemSolutions() []*models.ProblemSolution {
+	solutions := make([]*models.ProblemSolution, len(p.Solutions))
+	for i, solution := range p.Solutions {
+		solutions[i] = &models.ProblemSolution{
+			Name:     solution.Name,
+			Language: solution.Language,
+			Code:     solution.Code,
+		}
+	}
+	return solutions
+}
+
+// TestPrograms returns the test programs of the package ordered by kind, without problem ID
+func (p *Package) TestPrograms() []*models.TestProgram {
+	var programs []*models.TestProgram
+	for _, kind := range testProgramKinds {
+		if program, exists := p.Programs[kind]; exists {
+			programs = append(programs, &models.TestProgram{
+				Kind:     kind,
+				Language: program.Language,
+				Code:     program.Code,
+			})
+		}
+	}
+	return programs
+}
+
+// languageForExtension returns the language of a file extension, or "" if it is not supported
+func languageForExtension(ext string) string {
+	for language, languageExt := range languageExtensions {
+		if languageExt == ext {
+			return language
+		}
+	}
+	return ""
+}
@@ -0,0 +1,274 @@
+package problempkg
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+func testProblem() (*models.Problem, *models.JudgeSettings, []*models.TestCase, []*models.ProblemSolution, []*models.TestProgram) {
+	problem := &models.Problem{
+		ID:          7,
+		Title:       "Two Sum",
+		Slug:        "two-sum",
+		Description: "Find two numbers that add up to target.\n",
+		Difficulty:  models.DifficultyEasy,
+		Tags:        []string{"Array", "Hash Table"},
+		Examples: models.Examples{
+			{Input: "[2,7,11,15], 9", Output: "[0,1]", Explanation: "2 + 7 = 9"},
+		},
+		Constraints: "2 <= nums.length <= 10^4\n-10^9 <= nums[i] <= 10^9",
+		TemplateCode: models.TemplateCode{
+			models.LanguageJavaScript: "function twoSum(nums, target) {\n}\n",
+			models.LanguagePython:     "def two_sum(nums, target):\n    pass\n",
+		},
+		State: models.ProblemStatePublished,
+	}
+	settings := &models.JudgeSettings{
+		ProblemID:      7,
+		TimeLimitMs:    2000,
+		MemoryLimitMb:  256,
+		LanguageLimits: models.LanguageLimits{models.LanguagePython: {TimeLimitMs: 6000, MemoryLimitMb: 256}},
+		Checker:        models.CheckerTokens,
+		FloatTolerance: 1e-6,
+	}
+	testCases := []*models.TestCase{
+		{ID: 3, ProblemID: 7, Input: "[2,7,11,15]\n9\n", ExpectedOutput: "[0,1]\n"},
+		{ID: 5, ProblemID: 7, Input: "[3,3]\n6\n", ExpectedOutput: "[0,1]\n", IsHidden: true},
+	}
+	solutions := []*models.ProblemSolution{
+		{ProblemID: 7, Name: "hash_map", Language: models.LanguagePython, Code: "print('[0,1]')\n"},
+	}
+	programs := []*models.TestProgram{
+		{ProblemID: 7, Kind: models.TestProgramValidator, Language: models.LanguageJavaScript, Code: "function solution(input) { return 'OK'; }\n"},
+	}
+	return problem, settings, testCases, solutions, programs
+}
+
+func TestRoundTrip(t *testing.T) {
+	problem, settings, testCases, solutions, programs := testProblem()
+	pkg := FromProblem(problem, settings, testCases, solutions, programs)
+
+	if err := pkg.Validate(); err != nil {
+		t.Fatalf("Expected exported package to be valid, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := pkg.WriteZip(&buf); err != nil {
+		t.Fatalf("Failed to write package: %v", err)
+	}
+	read, err := ReadZip(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to read package: %v", err)
+	}
+	if err := read.Validate(); err != nil {
+		t.Fatalf("Expected package to be valid, got %v", err)
+	}
+
+	imported := read.Problem()
+	if imported.Title != problem.Title || imported.Slug != problem.Slug || imported.Description != problem.Description ||
+		imported.Difficulty != problem.Difficulty || imported.Constraints != problem.Constraints {
+		t.Errorf("Expected problem fields to round-trip, got %+v", imported)
+	}
+	if !reflect.DeepEqual([]string(imported.Tags), []string(problem.Tags)) {
+		t.Errorf("Expected tags %v, got %v", problem.Tags, imported.Tags)
+	}
+	if !reflect.DeepEqual(imported.Examples, problem.Examples) {
+		t.Errorf("Expected examples %+v, got %+v", problem.Examples, imported.Examples)
+	}
+	if !reflect.DeepEqual(imported.TemplateCode, problem.TemplateCode) {
+		t.Errorf("Expected templates %v, got %v", problem.TemplateCode, imported.TemplateCode)
+	}
+
+	importedSettings := read.JudgeSettings()
+	importedSettings.ProblemID = settings.ProblemID
+	if !reflect.DeepEqual(importedSettings, settings) {
+		t.Errorf("Expected judging settings %+v, got %+v", settings, importedSettings)
+	}
+
+	importedCases := read.TestCases()
+	if len(importedCases) != len(testCases) {
+		t.Fatalf("Expected %d test cases, got %d", len(testCases), len(importedCases))
+	}
+	for i, testCase := range importedCases {
+		if testCase.Input != testCases[i].Input || testCase.ExpectedOutput != testCases[i].ExpectedOutput || testCase.IsHidden != testCases[i].IsHidden {
+			t.Errorf("Test case %d: expected %+v, got %+v", i+1, testCases[i], testCase)
+		}
+	}
+
+	importedSolutions := read.ProblemSolutions()
+	if len(importedSolutions) != 1 || importedSolutions[0].Name != "hash_map" ||
+		importedSolutions[0].Language != models.LanguagePython || importedSolutions[0].Code != solutions[0].Code {
+		t.Errorf("Expected the reference solution to round-trip, got %+v", importedSolutions)
+	}
+
+	importedPrograms := read.TestPrograms()
+	if len(importedPrograms) != 1 || importedPrograms[0].Kind != models.TestProgramValidator ||
+		importedPrograms[0].Language != models.LanguageJavaScript || importedPrograms[0].Code != programs[0].Code {
+		t.Errorf("Expected the validator to round-trip, got %+v", importedPrograms)
+	}
+}
+
+func TestWriteZip_Layout(t *testing.T) {
+	problem, settings, testCases, solutions, programs := testProblem()
+
+	var buf bytes.Buffer
+	if err := FromProblem(problem, settings, testCases, solutions, programs).WriteZip(&buf); err != nil {
+		t.Fatalf("Failed to write package: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to open zip: %v", err)
+	}
+
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	expected := []string{
+		"problem.yaml",
+		"statement.md",
+		"tests/01.in", "tests/01.out",
+		"tests/02.in", "tests/02.out",
+		"templates/template.js",
+		"templates/template.py",
+		"solutions/hash_map.py",
+		"validator.js",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected files %v, got %v", expected, names)
+	}
+}
+
+func TestRead_Directory(t *testing.T) {
+	fsys := fstest.MapFS{
+		"two-sum/problem.yaml": {Data: []byte(`format_version: 1
+slug: two-sum
+title: Two Sum
+difficulty: Easy
+examples:
+  - input: "[3,3], 6"
+    output: "[0,1]"
+public_tests: ["1"]
+`)},
+		"two-sum/statement.md":            {Data: []byte("Find two numbers.")},
+		"two-sum/tests/1.in":              {Data: []byte("[3,3]\n6")},
+		"two-sum/tests/1.out":             {Data: []byte("[0,1]")},
+		"two-sum/tests/2.in":              {Data: []byte("[1,2]\n3")},
+		"two-sum/tests/2.out":             {Data: []byte("[0,1]")},
+		"two-sum/templates/Solution.java": {Data: []byte("class Solution {}")},
+		"two-sum/.DS_Store":               {Data: []byte("ignored")},
+	}
+
+	pkg, err := Read(fsys)
+	if err != nil {
+		t.Fatalf("Failed to read package: %v", err)
+	}
+	if err := pkg.Validate(); err != nil {
+		t.Fatalf("Expected package to be valid, got %v", err)
+	}
+
+	if len(pkg.Tests) != 2 || !pkg.Tests[0].Public || pkg.Tests[1].Public {
+		t.Errorf("Expected test 1 public and test 2 hidden, got %+v", pkg.Tests)
+	}
+	if pkg.Templates[models.LanguageJava] != "class Solution {}" {
+		t.Errorf("Expected the Java template, got %v", pkg.Templates)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"problem.yaml": {Data: []byte(`format_version: 2
+slug: Two Sum
+title: ""
+difficulty: Trivial
+examples: []
+public_tests: ["9"]
+time_limit_ms: 20
+language_limits:
+  ruby: {time_limit_ms: 1000, memory_limit_mb: 64}
+  java: {time_limit_ms: 1000, memory_limit_mb: 4096}
+checker: testlib
+`)},
+		"tests/01.in":      {Data: []byte("1")},
+		"tests/02.out":     {Data: []byte("2")},
+		"templates/a.rb":   {Data: []byte("puts 1")},
+		"checker.cpp":      {Data: []byte("int main() {}")},
+		"notes.txt":        {Data: []byte("todo")},
+		"solutions/ok.py":  {Data: []byte("")},
+		"solutions/ok.js":  {Data: []byte("console.log(1)")},
+		"templates/x.java": {Data: []byte("class X {}")},
+		"generator.rb":     {Data: []byte("puts 1")},
+		"validator.py":     {Data: []byte(" ")},
+	}
+
+	pkg, err := Read(fsys)
+	if err != nil {
+		t.Fatalf("Failed to read package: %v", err)
+	}
+
+	err = pkg.Validate()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+
+	expected := []string{
+		"checker.cpp: custom checker programs",
+		"notes.txt: unexpected file",
+		"templates/a.rb: unsupported template language",
+		"tests/01.in has no tests/01.out",
+		"tests/02.out has no tests/02.in",
+		`more than one solution named "ok"`,
+		"generator.rb: unsupported generator language",
+		"validator.py is empty",
+		"format_version 2 is newer",
+		"slug must be",
+		"title is required",
+		"difficulty must be",
+		"at least one example is required",
+		"time_limit_ms must be between",
+		`language_limits: unsupported language "ruby"`,
+		"language_limits: java memory_limit_mb must be between",
+		`unsupported checker "testlib"`,
+		"statement.md is missing",
+		"tests: at least one test is required",
+		`public test "9" does not exist`,
+	}
+	joined := strings.Join(validationErr.Issues, "\n")
+	for _, issue := range expected {
+		if !strings.Contains(joined, issue) {
+			t.Errorf("Expected issue %q, got:\n%s", issue, joined)
+		}
+	}
+}
+
+func TestRead_MissingManifest(t *testing.T) {
+	_, err := Read(fstest.MapFS{"statement.md": {Data: []byte("text")}})
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || !strings.Contains(err.Error(), "problem.yaml is missing") {
+		t.Errorf("Expected missing problem.yaml, got %v", err)
+	}
+
+	_, err = Read(fstest.MapFS{"problem.yaml": {Data: []byte("slug: a\nunknown: 1\n")}})
+	if !errors.As(err, &validationErr) {
+		t.Errorf("Expected unknown fields to be rejected, got %v", err)
+	}
+}
+
+func TestReadZip_NotAZip(t *testing.T) {
+	data := []byte("not a zip")
+	_, err := ReadZip(bytes.NewReader(data), int64(len(data)))
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+}
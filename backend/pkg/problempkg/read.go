@@ -0,0 +1,244 @@
+package problempkg
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"leetcode-clone-backend/pkg/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReadPath reads a package from a directory or a zip file
+func ReadPath(name string) (*Package, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return ReadDir(name)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadZip(f, info.Size())
+}
+
+// ReadDir reads a package from a directory
+func ReadDir(dir string) (*Package, error) {
+	return Read(os.DirFS(dir))
+}
+
+// ReadZip reads a package from a zip file
+func ReadZip(r io.ReaderAt, size int64) (*Package, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, &ValidationError{Issues: []string{fmt.Sprintf("not a valid zip file: %v", err)}}
+	}
+	return Read(zr)
+}
+
+// Read reads a package from a file system. Files that are not part of the format are reported by
+// Validate rather than ignored; a missing or malformed problem.yaml is returned as a
+// *ValidationError.
+func Read(fsys fs.FS) (*Package, error) {
+	root, err := packageRoot(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &reader{fsys: root, remaining: MaxSize}
+	pkg := &Package{Templates: map[string]string{}, Programs: map[string]Program{}}
+
+	manifest, err := r.readFile("problem.yaml")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, &ValidationError{Issues: []string{"problem.yaml is missing"}}
+		}
+		return nil, err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(manifest))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&pkg.Manifest); err != nil && err != io.EOF {
+		return nil, &ValidationError{Issues: []string{fmt.Sprintf("problem.yaml: %v", err)}}
+	}
+
+	inputs := map[string]string{}
+	outputs := map[string]string{}
+	solutions := map[string]bool{}
+
+	err = fs.WalkDir(root, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		base := path.Base(name)
+		if name != "." && (strings.HasPrefix(base, ".") || base == "__MACOSX") {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		dir, ext := path.Dir(name), path.Ext(name)
+		stem := strings.TrimSuffix(base, ext)
+		switch {
+		case name == "problem.yaml":
+			return nil
+		case name == "statement.md":
+			data, err := r.readFile(name)
+			pkg.Statement = string(data)
+			return err
+		case dir == "tests" && (ext == ".in" || ext == ".out"):
+			data, err := r.readFile(name)
+			if ext == ".in" {
+				inputs[stem] = string(data)
+			} else {
+				outputs[stem] = string(data)
+			}
+			return err
+		case dir == "templates":
+			language := languageForExtension(ext)
+			if language == "" {
+				pkg.issues = append(pkg.issues, fmt.Sprintf("%s: unsupported template language", name))
+				return nil
+			}
+			if _, exists := pkg.Templates[language]; exists {
+				pkg.issues = append(pkg.issues, fmt.Sprintf("%s: more than one %s template", name, language))
+				return nil
+			}
+			data, err := r.readFile(name)
+			pkg.Templates[language] = string(data)
+			return err
+		case dir == "solutions":
+			language := languageForExtension(ext)
+			if language == "" {
+				pkg.issues = append(pkg.issues, fmt.Sprintf("%s: unsupported solution language", name))
+				return nil
+			}
+			if solutions[stem] {
+				pkg.issues = append(pkg.issues, fmt.Sprintf("%s: more than one solution named %q", name, stem))
+				return nil
+			}
+			solutions[stem] = true
+			data, err := r.readFile(name)
+			pkg.Solutions = append(pkg.Solutions, Solution{Name: stem, Language: language, Code: string(data)})
+			return err
+		case dir == "." && (stem == models.TestProgramGenerator || stem == models.TestProgramValidator):
+			language := languageForExtension(ext)
+			if language == "" {
+				pkg.issues = append(pkg.issues, fmt.Sprintf("%s: unsupported %s language", name, stem))
+				return nil
+			}
+			if _, exists := pkg.Programs[stem]; exists {
+				pkg.issues = append(pkg.issues, fmt.Sprintf("%s: more than one %s", name, stem))
+				return nil
+			}
+			data, err := r.readFile(name)
+			pkg.Programs[stem] = Program{Language: language, Code: string(data)}
+			return err
+		case stem == "checker" || strings.HasPrefix(name, "checker/"):
+			pkg.issues = append(pkg.issues, fmt.Sprintf("%s: custom checker programs are not supported; use the exact or tokens checker", name))
+			return nil
+		default:
+			pkg.issues = append(pkg.issues, fmt.Sprintf("%s: unexpected file", name))
+			return nil
+		}
+	})
+	if err != nil {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to read package: %w", err)
+	}
+
+	public := make(map[string]bool, len(pkg.Manifest.PublicTests))
+	for _, name := range pkg.Manifest.PublicTests {
+		public[name] = true
+	}
+	for name, input := range inputs {
+		output, exists := outputs[name]
+		if !exists {
+			pkg.issues = append(pkg.issues, fmt.Sprintf("tests/%s.in has no tests/%s.out", name, name))
+			continue
+		}
+		pkg.Tests = append(pkg.Tests, Test{Name: name, Input: input, Output: output, Public: public[name]})
+	}
+	for name := range outputs {
+		if _, exists := inputs[name]; !exists {
+			pkg.issues = append(pkg.issues, fmt.Sprintf("tests/%s.out has no tests/%s.in", name, name))
+		}
+	}
+	sort.Slice(pkg.Tests, func(i, j int) bool { return pkg.Tests[i].Name < pkg.Tests[j].Name })
+	sort.Slice(pkg.Solutions, func(i, j int) bool { return pkg.Solutions[i].Name < pkg.Solutions[j].Name })
+	sort.Strings(pkg.issues)
+
+	return pkg, nil
+}
+
+// packageRoot returns the directory of fsys holding problem.yaml: the root, or its only
+// subdirectory if the package was archived with its directory
+func packageRoot(fsys fs.FS) (fs.FS, error) {
+	if _, err := fs.Stat(fsys, "problem.yaml"); err == nil {
+		return fsys, nil
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package: %w", err)
+	}
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") && entry.Name() != "__MACOSX" {
+			dirs = append(dirs, entry.Name())
+		}
+	}
+	if len(dirs) == 1 {
+		if _, err := fs.Stat(fsys, dirs[0]+"/problem.yaml"); err == nil {
+			return fs.Sub(fsys, dirs[0])
+		}
+	}
+
+	return fsys, nil
+}
+
+// reader reads the files of a package up to MaxSize in total
+type reader struct {
+	fsys      fs.FS
+	remaining int64
+}
+
+// readFile reads a file of the package
+func (r *reader) readFile(name string) ([]byte, error) {
+	f, err := r.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, r.remaining+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	r.remaining -= int64(len(data))
+	if r.remaining < 0 {
+		return nil, &ValidationError{Issues: []string{fmt.Sprintf("package is larger than %d MB", MaxSize>>20)}}
+	}
+
+	return data, nil
+}
@@ -0,0 +1,156 @@
+package problempkg
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+// slugPattern matches the slugs generated for problems
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// ValidationError lists every problem found in a package
+type ValidationError struct {
+	Issues []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid package: " + strings.Join(e.Issues, "; ")
+}
+
+// Validate checks that the package is complete and describes a valid problem. It returns a
+// *ValidationError listing every issue, or nil.
+func (p *Package) Validate() error {
+	issues := append([]string(nil), p.issues...)
+	add := func(format string, args ...interface{}) {
+		issues = append(issues, fmt.Sprintf(format, args...))
+	}
+	m := p.Manifest
+
+	switch {
+	case m.FormatVersion == 0:
+		add("problem.yaml: format_version is required")
+	case m.FormatVersion > FormatVersion:
+		add("problem.yaml: format_version %d is newer than the supported version %d", m.FormatVersion, FormatVersion)
+	case m.FormatVersion < 0:
+		add("problem.yaml: invalid format_version %d", m.FormatVersion)
+	}
+
+	if !slugPattern.MatchString(m.Slug) || len(m.Slug) > 200 {
+		add("problem.yaml: slug must be lowercase letters, digits and single hyphens, at most 200 characters")
+	}
+	if strings.TrimSpace(m.Title) == "" {
+		add("problem.yaml: title is required")
+	} else if len(m.Title) > 200 {
+		add("problem.yaml: title must be 200 characters or less")
+	}
+	if m.Difficulty != models.DifficultyEasy && m.Difficulty != models.DifficultyMedium && m.Difficulty != models.DifficultyHard {
+		add("problem.yaml: difficulty must be one of: Easy, Medium, Hard")
+	}
+	for i, tag := range m.Tags {
+		if strings.TrimSpace(tag) == "" || len(tag) > models.MaxTagLength {
+			add("problem.yaml: tag %d must be 1 to %d characters", i+1, models.MaxTagLength)
+		}
+	}
+	if len(m.Examples) == 0 {
+		add("problem.yaml: at least one example is required")
+	}
+	for i, example := range m.Examples {
+		if strings.TrimSpace(example.Input) == "" || strings.TrimSpace(example.Output) == "" {
+			add("problem.yaml: example %d needs an input and an output", i+1)
+		}
+	}
+	if m.TimeLimitMs != 0 && (m.TimeLimitMs < models.MinTimeLimitMs || m.TimeLimitMs > models.MaxTimeLimitMs) {
+		add("problem.yaml: time_limit_ms must be between %d and %d", models.MinTimeLimitMs, models.MaxTimeLimitMs)
+	}
+	if m.MemoryLimitMb != 0 && (m.MemoryLimitMb < models.MinMemoryLimitMb || m.MemoryLimitMb > models.MaxMemoryLimitMb) {
+		add("problem.yaml: memory_limit_mb must be between %d and %d", models.MinMemoryLimitMb, models.MaxMemoryLimitMb)
+	}
+	languages := make([]string, 0, len(m.LanguageLimits))
+	for language := range m.LanguageLimits {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	for _, language := range languages {
+		limits := m.LanguageLimits[language]
+		switch {
+		case languageExtensions[language] == "":
+			add("problem.yaml: language_limits: unsupported language %q", language)
+		case limits.TimeLimitMs < models.MinTimeLimitMs || limits.TimeLimitMs > models.MaxTimeLimitMs:
+			add("problem.yaml: language_limits: %s time_limit_ms must be between %d and %d", language, models.MinTimeLimitMs, models.MaxTimeLimitMs)
+		case limits.MemoryLimitMb < models.MinMemoryLimitMb || limits.MemoryLimitMb > models.MaxMemoryLimitMb:
+			add("problem.yaml: language_limits: %s memory_limit_mb must be between %d and %d", language, models.MinMemoryLimitMb, models.MaxMemoryLimitMb)
+		}
+	}
+	switch m.Checker {
+	case "", models.CheckerExact:
+		if m.IgnoreCase || m.FloatTolerance != 0 {
+			add("problem.yaml: ignore_case and float_tolerance need the %q checker", models.CheckerTokens)
+		}
+	case models.CheckerTokens:
+		if m.FloatTolerance < 0 || m.FloatTolerance >= 1 {
+			add("problem.yaml: float_tolerance must be at least 0 and less than 1")
+		}
+	default:
+		add("problem.yaml: unsupported checker %q; must be %q or %q", m.Checker, models.CheckerExact, models.CheckerTokens)
+	}
+
+	if strings.TrimSpace(p.Statement) == "" {
+		add("statement.md is missing or empty")
+	}
+
+	if len(p.Templates) == 0 {
+		add("templates: at least one template is required")
+	}
+	for _, language := range sortedLanguages(p.Templates) {
+		if strings.TrimSpace(p.Templates[language]) == "" {
+			add("templates: %s template is empty", language)
+		}
+	}
+
+	if len(p.Tests) == 0 {
+		add("tests: at least one test is required")
+	}
+	tests := make(map[string]bool, len(p.Tests))
+	for _, test := range p.Tests {
+		tests[test.Name] = true
+		if strings.TrimSpace(test.Input) == "" {
+			add("tests/%s.in is empty", test.Name)
+		}
+		if strings.TrimSpace(test.Output) == "" {
+			add("tests/%s.out is empty", test.Name)
+		}
+	}
+	public := make(map[string]bool, len(m.PublicTests))
+	for _, name := range m.PublicTests {
+		if public[name] {
+			add("problem.yaml: public test %q is listed twice", name)
+		} else if !tests[name] {
+			add("problem.yaml: public test %q does not exist", name)
+		}
+		public[name] = true
+	}
+
+	for _, solution := range p.Solutions {
+		if len(solution.Name) > 100 {
+			add("solutions/%s: name must be 100 characters or less", solution.Name)
+		}
+		if strings.TrimSpace(solution.Code) == "" {
+			add("solutions/%s%s is empty", solution.Name, languageExtensions[solution.Language])
+		}
+	}
+
+	for _, kind := range testProgramKinds {
+		if program, exists := p.Programs[kind]; exists && strings.TrimSpace(program.Code) == "" {
+			add("%s%s is empty", kind, languageExtensions[program.Language])
+		}
+	}
+
+	if len(issues) > 0 {
+		return &ValidationError{Issues: issues}
+	}
+	return nil
+}
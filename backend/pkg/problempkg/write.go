@@ -0,0 +1,132 @@
+package problempkg
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// file is a file of a package with its slash-separated path
+type file struct {
+	name string
+	data []byte
+}
+
+// WriteZip writes the package to w as a zip file
+func (p *Package) WriteZip(w io.Writer) error {
+	files, err := p.files()
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.name, err)
+		}
+		if _, err := fw.Write(f.data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write package: %w", err)
+	}
+	return nil
+}
+
+// WriteDir writes the package to a directory, creating it if needed. Files of the package already
+// in the directory are overwritten; other files are left alone.
+func (p *Package) WriteDir(dir string) error {
+	files, err := p.files()
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		name := filepath.Join(dir, filepath.FromSlash(f.name))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.name, err)
+		}
+		if err := os.WriteFile(name, f.data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.name, err)
+		}
+	}
+
+	return nil
+}
+
+// files returns the files of the package in a stable order
+func (p *Package) files() ([]file, error) {
+	manifest := p.Manifest
+	if manifest.FormatVersion == 0 {
+		manifest.FormatVersion = FormatVersion
+	}
+	var data bytes.Buffer
+	encoder := yaml.NewEncoder(&data)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to write problem.yaml: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write problem.yaml: %w", err)
+	}
+
+	files := []file{
+		{name: "problem.yaml", data: data.Bytes()},
+		{name: "statement.md", data: []byte(p.Statement)},
+	}
+
+	for _, test := range p.Tests {
+		files = append(files,
+			file{name: "tests/" + test.Name + ".in", data: []byte(test.Input)},
+			file{name: "tests/" + test.Name + ".out", data: []byte(test.Output)},
+		)
+	}
+
+	for _, language := range sortedLanguages(p.Templates) {
+		files = append(files, file{
+			name: "templates/template" + languageExtensions[language],
+			data: []byte(p.Templates[language]),
+		})
+	}
+
+	for _, solution := range p.Solutions {
+		ext, supported := languageExtensions[solution.Language]
+		if !supported {
+			return nil, fmt.Errorf("solution %s has unsupported language %s", solution.Name, solution.Language)
+		}
+		files = append(files, file{name: "solutions/" + solution.Name + ext, data: []byte(solution.Code)})
+	}
+
+	for _, kind := range testProgramKinds {
+		program, exists := p.Programs[kind]
+		if !exists {
+			continue
+		}
+		ext, supported := languageExtensions[program.Language]
+		if !supported {
+			return nil, fmt.Errorf("%s has unsupported language %s", kind, program.Language)
+		}
+		files = append(files, file{name: kind + ext, data: []byte(program.Code)})
+	}
+
+	return files, nil
+}
+
+// sortedLanguages returns the languages of a set of templates in alphabetical order
+func sortedLanguages(templates map[string]string) []string {
+	languages := make([]string, 0, len(templates))
+	for language := range templates {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Store types
+const (
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+	StoreRedis    = "redis"
+)
+
+// Config selects and configures the store used by the rate limiter
+type Config struct {
+	Store string // memory, postgres or redis
+	Redis RedisConfig
+}
+
+// LoadConfigFromEnv loads rate limiter configuration from environment variables
+func LoadConfigFromEnv() *Config {
+	db, _ := strconv.Atoi(os.Getenv("REDIS_DB"))
+
+	return &Config{
+		Store: getEnv("RATE_LIMIT_STORE", StoreMemory),
+		Redis: RedisConfig{
+			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
+			Password: os.Getenv("REDIS_PASSWORD"),
+			DB:       db,
+		},
+	}
+}
+
+// NewStore creates the store selected by the configuration. db is used by the postgres store.
+func NewStore(config *Config, db *sql.DB) (Store, error) {
+	switch config.Store {
+	case StoreMemory:
+		return NewMemoryStore(), nil
+	case StorePostgres:
+		return NewPostgresStore(db), nil
+	case StoreRedis:
+		return NewRedisStore(config.Redis), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q, expected %s, %s or %s", config.Store, StoreMemory, StorePostgres, StoreRedis)
+	}
+}
+
+// getEnv gets an environment variable with a default value
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
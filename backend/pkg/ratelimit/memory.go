@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// memoryBucket is a token bucket kept in memory
+type memoryBucket struct {
+	bucket Bucket
+	idleAt time.Time
+}
+
+// memoryCounter is an expiring counter kept in memory
+type memoryCounter struct {
+	value     float64
+	expiresAt time.Time
+}
+
+// MemoryStore keeps rate limiter state in process memory. State is lost on restart and is not
+// shared between replicas.
+type MemoryStore struct {
+	mu       sync.Mutex
+	buckets  map[string]*memoryBucket
+	counters map[string]*memoryCounter
+}
+
+// NewMemoryStore creates a new in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:  make(map[string]*memoryBucket),
+		counters: make(map[string]*memoryCounter),
+	}
+}
+
+// Take removes one token from the bucket stored under key, if one is available
+func (s *MemoryStore) Take(key string, limit Limit, now time.Time) (*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, exists := s.buckets[key]
+	if !exists || !now.Before(stored.idleAt) {
+		stored = &memoryBucket{bucket: NewBucket(limit, now)}
+		s.buckets[key] = stored
+	}
+
+	bucket, result := Take(stored.bucket, limit, now)
+	stored.bucket = bucket
+	stored.idleAt = IdleAt(bucket, limit)
+
+	return result, nil
+}
+
+// Add adds amount to the counter stored under key and returns the new total
+func (s *MemoryStore) Add(key string, amount float64, now, expiresAt time.Time) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counter, exists := s.counters[key]
+	if !exists || !now.Before(counter.expiresAt) {
+		counter = &memoryCounter{}
+		s.counters[key] = counter
+	}
+
+	counter.value += amount
+	counter.expiresAt = expiresAt
+
+	return counter.value, nil
+}
+
+// Cleanup deletes idle buckets and expired counters
+func (s *MemoryStore) Cleanup(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, stored := range s.buckets {
+		if !now.Before(stored.idleAt) {
+			delete(s.buckets, key)
+		}
+	}
+
+	for key, counter := range s.counters {
+		if !now.Before(counter.expiresAt) {
+			delete(s.counters, key)
+		}
+	}
+
+	return nil
+}
+
+// Len returns the number of buckets and counters in the store
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.buckets) + len(s.counters)
+}
@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PostgresStore keeps rate limiter state in the rate_limit_buckets and rate_limit_counters tables,
+// so that it is shared between replicas using the same database
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a new Postgres-backed store
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Take removes one token from the bucket stored under key, if one is available. The bucket row is
+// locked while it is updated, so concurrent requests from several replicas are counted correctly.
+func (s *PostgresStore) Take(key string, limit Limit, now time.Time) (*Result, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO rate_limit_buckets (key, tokens, updated_at, idle_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (key) DO NOTHING`,
+		key, float64(limit.Burst), now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rate limit bucket: %w", err)
+	}
+
+	var bucket Bucket
+	var idleAt time.Time
+	err = tx.QueryRow(`
+		SELECT tokens, updated_at, idle_at
+		FROM rate_limit_buckets
+		WHERE key = $1
+		FOR UPDATE`,
+		key).Scan(&bucket.Tokens, &bucket.UpdatedAt, &idleAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rate limit bucket: %w", err)
+	}
+
+	if !now.Before(idleAt) {
+		bucket = NewBucket(limit, now)
+	}
+
+	bucket, result := Take(bucket, limit, now)
+
+	_, err = tx.Exec(`
+		UPDATE rate_limit_buckets
+		SET tokens = $2, updated_at = $3, idle_at = $4
+		WHERE key = $1`,
+		key, bucket.Tokens, bucket.UpdatedAt, IdleAt(bucket, limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update rate limit bucket: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// Add adds amount to the counter stored under key and returns the new total
+func (s *PostgresStore) Add(key string, amount float64, now, expiresAt time.Time) (float64, error) {
+	var value float64
+	err := s.db.QueryRow(`
+		INSERT INTO rate_limit_counters (key, value, expires_at)
+		VALUES ($1, $2, $4)
+		ON CONFLICT (key) DO UPDATE
+		SET value = CASE
+		        WHEN rate_limit_counters.expires_at <= $3 THEN EXCLUDED.value
+		        ELSE rate_limit_counters.value + EXCLUDED.value
+		    END,
+		    expires_at = EXCLUDED.expires_at
+		RETURNING value`,
+		key, amount, now, expiresAt).Scan(&value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update rate limit counter: %w", err)
+	}
+
+	return value, nil
+}
+
+// Cleanup deletes idle buckets and expired counters
+func (s *PostgresStore) Cleanup(now time.Time) error {
+	if _, err := s.db.Exec(`DELETE FROM rate_limit_buckets WHERE idle_at <= $1`, now); err != nil {
+		return fmt.Errorf("failed to delete idle rate limit buckets: %w", err)
+	}
+
+	if _, err := s.db.Exec(`DELETE FROM rate_limit_counters WHERE expires_at <= $1`, now); err != nil {
+		return fmt.Errorf("failed to delete expired rate limit counters: %w", err)
+	}
+
+	return nil
+}
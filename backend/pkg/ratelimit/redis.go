@@ -0,0 +1,366 @@
+package ratelimit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// maxRedisRetries is how often Take retries when the bucket is changed concurrently
+const maxRedisRetries = 10
+
+// RedisConfig configures a RedisStore
+type RedisConfig struct {
+	Addr     string        // host:port
+	Password string        // Sent with AUTH if set
+	DB       int           // Selected with SELECT if not 0
+	PoolSize int           // Maximum number of idle connections
+	Timeout  time.Duration // Timeout for dialing and for each command
+}
+
+// RedisStore keeps rate limiter state in Redis, or any server speaking the Redis protocol, so that
+// it is shared between replicas. Buckets are hashes that expire once they are full again, and
+// counters are floats that expire at the given time, so Redis deletes idle keys by itself.
+type RedisStore struct {
+	config RedisConfig
+	pool   chan *redisConn
+}
+
+// NewRedisStore creates a new Redis-backed store. Connections are opened when needed.
+func NewRedisStore(config RedisConfig) *RedisStore {
+	if config.PoolSize < 1 {
+		config.PoolSize = 10
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 2 * time.Second
+	}
+
+	return &RedisStore{
+		config: config,
+		pool:   make(chan *redisConn, config.PoolSize),
+	}
+}
+
+// Take removes one token from the bucket stored under key, if one is available. The bucket is
+// read and written with WATCH/MULTI/EXEC, so concurrent updates from other replicas are retried.
+func (s *RedisStore) Take(key string, limit Limit, now time.Time) (*Result, error) {
+	conn, err := s.get()
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < maxRedisRetries; attempt++ {
+		result, committed, err := s.takeOnce(conn, key, limit, now)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to take rate limit token: %w", err)
+		}
+		if committed {
+			s.put(conn)
+			return result, nil
+		}
+	}
+
+	s.put(conn)
+	return nil, fmt.Errorf("failed to take rate limit token: bucket %s is changed too often", key)
+}
+
+// takeOnce runs one optimistic transaction on a bucket. committed is false if the bucket was
+// changed by another client in the meantime.
+func (s *RedisStore) takeOnce(conn *redisConn, key string, limit Limit, now time.Time) (*Result, bool, error) {
+	if _, err := conn.do("WATCH", key); err != nil {
+		return nil, false, err
+	}
+
+	reply, err := conn.do("HMGET", key, "tokens", "updated_at")
+	if err != nil {
+		return nil, false, err
+	}
+
+	bucket, err := parseRedisBucket(reply)
+	if err != nil {
+		return nil, false, err
+	}
+	if bucket == nil {
+		full := NewBucket(limit, now)
+		bucket = &full
+	}
+
+	updated, result := Take(*bucket, limit, now)
+	if !result.Allowed {
+		// Rejected requests do not change the bucket, so there is nothing to write
+		if _, err := conn.do("UNWATCH"); err != nil {
+			return nil, false, err
+		}
+		return result, true, nil
+	}
+	idleAt := IdleAt(updated, limit)
+
+	if _, err := conn.do("MULTI"); err != nil {
+		return nil, false, err
+	}
+	if _, err := conn.do("HSET", key,
+		"tokens", strconv.FormatFloat(updated.Tokens, 'f', -1, 64),
+		"updated_at", strconv.FormatInt(updated.UpdatedAt.UnixNano(), 10)); err != nil {
+		return nil, false, err
+	}
+	if _, err := conn.do("PEXPIREAT", key, strconv.FormatInt(idleAt.UnixMilli()+1, 10)); err != nil {
+		return nil, false, err
+	}
+
+	reply, err = conn.do("EXEC")
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		// The bucket was changed after WATCH
+		return nil, false, nil
+	}
+
+	return result, true, nil
+}
+
+// parseRedisBucket parses the reply to HMGET key tokens updated_at. It returns nil if the
+// bucket does not exist.
+func parseRedisBucket(reply interface{}) (*Bucket, error) {
+	fields, ok := reply.([]interface{})
+	if !ok || len(fields) != 2 {
+		return nil, fmt.Errorf("unexpected reply to HMGET: %v", reply)
+	}
+	if fields[0] == nil || fields[1] == nil {
+		return nil, nil
+	}
+
+	tokens, err := strconv.ParseFloat(redisString(fields[0]), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bucket tokens: %w", err)
+	}
+	updatedAt, err := strconv.ParseInt(redisString(fields[1]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bucket update time: %w", err)
+	}
+
+	return &Bucket{Tokens: tokens, UpdatedAt: time.Unix(0, updatedAt)}, nil
+}
+
+// Add adds amount to the counter stored under key and returns the new total. Expiry is handled
+// by Redis, so now is not used.
+func (s *RedisStore) Add(key string, amount float64, now, expiresAt time.Time) (float64, error) {
+	conn, err := s.get()
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := s.add(conn, key, amount, expiresAt)
+	if err != nil {
+		conn.Close()
+		return 0, fmt.Errorf("failed to update rate limit counter: %w", err)
+	}
+
+	s.put(conn)
+	return value, nil
+}
+
+// add increments a counter and sets its expiry in one transaction
+func (s *RedisStore) add(conn *redisConn, key string, amount float64, expiresAt time.Time) (float64, error) {
+	if _, err := conn.do("MULTI"); err != nil {
+		return 0, err
+	}
+	if _, err := conn.do("INCRBYFLOAT", key, strconv.FormatFloat(amount, 'f', -1, 64)); err != nil {
+		return 0, err
+	}
+	if _, err := conn.do("PEXPIREAT", key, strconv.FormatInt(expiresAt.UnixMilli(), 10)); err != nil {
+		return 0, err
+	}
+
+	reply, err := conn.do("EXEC")
+	if err != nil {
+		return 0, err
+	}
+
+	results, ok := reply.([]interface{})
+	if !ok || len(results) != 2 {
+		return 0, fmt.Errorf("unexpected reply to EXEC: %v", reply)
+	}
+	if err, ok := results[0].(redisError); ok {
+		return 0, err
+	}
+
+	return strconv.ParseFloat(redisString(results[0]), 64)
+}
+
+// Cleanup does nothing, as Redis deletes expired keys by itself
+func (s *RedisStore) Cleanup(now time.Time) error {
+	return nil
+}
+
+// Close closes all idle connections
+func (s *RedisStore) Close() error {
+	for {
+		select {
+		case conn := <-s.pool:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// get returns an idle connection or opens a new one
+func (s *RedisStore) get() (*redisConn, error) {
+	select {
+	case conn := <-s.pool:
+		return conn, nil
+	default:
+	}
+
+	netConn, err := net.DialTimeout("tcp", s.config.Addr, s.config.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	conn := &redisConn{
+		conn:    netConn,
+		reader:  bufio.NewReader(netConn),
+		writer:  bufio.NewWriter(netConn),
+		timeout: s.config.Timeout,
+	}
+
+	if s.config.Password != "" {
+		if _, err := conn.do("AUTH", s.config.Password); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to authenticate with redis: %w", err)
+		}
+	}
+	if s.config.DB != 0 {
+		if _, err := conn.do("SELECT", strconv.Itoa(s.config.DB)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to select redis database: %w", err)
+		}
+	}
+
+	return conn, nil
+}
+
+// put returns a connection to the pool, or closes it if the pool is full
+func (s *RedisStore) put(conn *redisConn) {
+	select {
+	case s.pool <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+// redisError is an error reply from the server
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+// redisString converts a bulk or simple string reply to a string
+func redisString(reply interface{}) string {
+	switch value := reply.(type) {
+	case []byte:
+		return string(value)
+	case string:
+		return value
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// redisConn is a connection speaking RESP, the Redis serialization protocol
+type redisConn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	writer  *bufio.Writer
+	timeout time.Duration
+}
+
+// Close closes the connection
+func (c *redisConn) Close() error {
+	return c.conn.Close()
+}
+
+// do sends a command and reads its reply. Replies are returned as string (simple strings),
+// int64 (integers), []byte (bulk strings), []interface{} (arrays) or nil (null bulk strings and
+// arrays). Error replies are returned as a redisError.
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(c.writer, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(c.writer, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := c.writer.Flush(); err != nil {
+		return nil, err
+	}
+
+	reply, err := readRESP(c.reader)
+	if err != nil {
+		return nil, err
+	}
+	if replyErr, ok := reply.(redisError); ok {
+		return nil, replyErr
+	}
+
+	return reply, nil
+}
+
+// readRESP reads one reply. Error replies nested in arrays are returned as redisError values.
+func readRESP(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("invalid RESP line")
+	}
+	kind, payload := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return payload, nil
+	case '-':
+		return redisError(payload), nil
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		length, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RESP bulk length: %w", err)
+		}
+		if length < 0 {
+			return nil, nil
+		}
+		data := make([]byte, length+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		return data[:length], nil
+	case '*':
+		count, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RESP array length: %w", err)
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			if items[i], err = readRESP(reader); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unknown RESP reply type %q", kind)
+	}
+}
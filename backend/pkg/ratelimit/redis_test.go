@@ -0,0 +1,358 @@
+package ratelimit
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis is a stand-in for a Redis server implementing the commands used by RedisStore
+type fakeRedis struct {
+	listener net.Listener
+	password string
+
+	mu       sync.Mutex
+	strings  map[string]string
+	hashes   map[string]map[string]string
+	expiries map[string]int64 // Unix milliseconds
+	versions map[string]int   // Incremented on every write, for WATCH
+}
+
+// fakeRedisSession is the per-connection state of a client
+type fakeRedisSession struct {
+	authenticated bool
+	watched       map[string]int
+	inMulti       bool
+	queued        [][]string
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &fakeRedis{
+		listener: listener,
+		password: password,
+		strings:  make(map[string]string),
+		hashes:   make(map[string]map[string]string),
+		expiries: make(map[string]int64),
+		versions: make(map[string]int),
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+
+	return server
+}
+
+func (f *fakeRedis) addr() string {
+	return f.listener.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	session := &fakeRedisSession{authenticated: f.password == "", watched: make(map[string]int)}
+
+	for {
+		request, err := readRESP(reader)
+		if err != nil {
+			return
+		}
+		items, ok := request.([]interface{})
+		if !ok || len(items) == 0 {
+			return
+		}
+		args := make([]string, len(items))
+		for i, item := range items {
+			args[i] = redisString(item)
+		}
+
+		if _, err := conn.Write([]byte(f.handle(session, args))); err != nil {
+			return
+		}
+	}
+}
+
+// handle runs a command and returns the encoded reply
+func (f *fakeRedis) handle(session *fakeRedisSession, args []string) string {
+	command := strings.ToUpper(args[0])
+
+	if command == "AUTH" {
+		if len(args) == 2 && args[1] == f.password {
+			session.authenticated = true
+			return "+OK\r\n"
+		}
+		return "-WRONGPASS invalid password\r\n"
+	}
+	if !session.authenticated {
+		return "-NOAUTH Authentication required.\r\n"
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch command {
+	case "UNWATCH":
+		session.watched = make(map[string]int)
+		return "+OK\r\n"
+	case "WATCH":
+		for _, key := range args[1:] {
+			session.watched[key] = f.versions[key]
+		}
+		return "+OK\r\n"
+	case "MULTI":
+		session.inMulti = true
+		session.queued = nil
+		return "+OK\r\n"
+	case "EXEC":
+		defer func() {
+			session.inMulti = false
+			session.queued = nil
+			session.watched = make(map[string]int)
+		}()
+		for key, version := range session.watched {
+			if f.versions[key] != version {
+				return "*-1\r\n"
+			}
+		}
+		reply := fmt.Sprintf("*%d\r\n", len(session.queued))
+		for _, queued := range session.queued {
+			reply += f.run(queued)
+		}
+		return reply
+	}
+
+	if session.inMulti {
+		session.queued = append(session.queued, args)
+		return "+QUEUED\r\n"
+	}
+
+	return f.run(args)
+}
+
+// run executes a data command. The caller must hold the lock.
+func (f *fakeRedis) run(args []string) string {
+	key := ""
+	if len(args) > 1 {
+		key = args[1]
+		f.expire(key)
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "HMGET":
+		reply := fmt.Sprintf("*%d\r\n", len(args)-2)
+		for _, field := range args[2:] {
+			value, ok := f.hashes[key][field]
+			if !ok {
+				reply += "$-1\r\n"
+				continue
+			}
+			reply += fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+		}
+		return reply
+	case "HSET":
+		if f.hashes[key] == nil {
+			f.hashes[key] = make(map[string]string)
+		}
+		added := 0
+		for i := 2; i+1 < len(args); i += 2 {
+			if _, exists := f.hashes[key][args[i]]; !exists {
+				added++
+			}
+			f.hashes[key][args[i]] = args[i+1]
+		}
+		f.versions[key]++
+		return fmt.Sprintf(":%d\r\n", added)
+	case "INCRBYFLOAT":
+		amount, err := strconv.ParseFloat(args[2], 64)
+		if err != nil {
+			return "-ERR value is not a valid float\r\n"
+		}
+		current, _ := strconv.ParseFloat(f.strings[key], 64)
+		value := strconv.FormatFloat(current+amount, 'f', -1, 64)
+		f.strings[key] = value
+		f.versions[key]++
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "PEXPIREAT":
+		at, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+		if !f.exists(key) {
+			return ":0\r\n"
+		}
+		f.expiries[key] = at
+		f.versions[key]++
+		f.expire(key)
+		return ":1\r\n"
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+func (f *fakeRedis) exists(key string) bool {
+	_, isString := f.strings[key]
+	_, isHash := f.hashes[key]
+	return isString || isHash
+}
+
+// expire deletes a key whose expiry has passed. The caller must hold the lock.
+func (f *fakeRedis) expire(key string) {
+	if at, ok := f.expiries[key]; ok && at <= time.Now().UnixMilli() {
+		f.delete(key)
+	}
+}
+
+// delete removes a key. The caller must hold the lock.
+func (f *fakeRedis) delete(key string) {
+	delete(f.strings, key)
+	delete(f.hashes, key)
+	delete(f.expiries, key)
+	f.versions[key]++
+}
+
+func (f *fakeRedis) expiry(key string) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.expiries[key]
+}
+
+func TestRedisStore_Take(t *testing.T) {
+	server := newFakeRedis(t, "")
+	store := NewRedisStore(RedisConfig{Addr: server.addr()})
+	defer store.Close()
+
+	now := time.Now()
+	limit := PerMinute(60, 2)
+
+	result, err := store.Take("submit:user:1", limit, now)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1.0, result.Remaining)
+
+	result, err = store.Take("submit:user:1", limit, now)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = store.Take("submit:user:1", limit, now)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+
+	// The bucket expires once it would be full again
+	assert.Equal(t, now.Add(2*time.Second).UnixMilli()+1, server.expiry("submit:user:1"))
+
+	result, err = store.Take("submit:user:1", limit, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = store.Take("submit:user:2", limit, now)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestRedisStore_ConcurrentTake(t *testing.T) {
+	server := newFakeRedis(t, "")
+	store := NewRedisStore(RedisConfig{Addr: server.addr(), PoolSize: 8})
+	defer store.Close()
+
+	now := time.Now()
+	limit := PerMinute(0, 5)
+
+	var mu sync.Mutex
+	allowed := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 3; j++ {
+				result, err := store.Take("key", limit, now)
+				if !assert.NoError(t, err) {
+					return
+				}
+				if result.Allowed {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 5, allowed)
+}
+
+func TestRedisStore_Add(t *testing.T) {
+	server := newFakeRedis(t, "")
+	store := NewRedisStore(RedisConfig{Addr: server.addr()})
+	defer store.Close()
+
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+
+	value, err := store.Add("cpu:user:1", 1.5, now, expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, value)
+
+	value, err = store.Add("cpu:user:1", 2, now, expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, 3.5, value)
+	assert.Equal(t, expiresAt.UnixMilli(), server.expiry("cpu:user:1"))
+
+	// Expired counters start again from zero
+	server.mu.Lock()
+	server.expiries["cpu:user:1"] = now.Add(-time.Second).UnixMilli()
+	server.mu.Unlock()
+
+	value, err = store.Add("cpu:user:1", 0, now, expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, value)
+}
+
+func TestRedisStore_Auth(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+
+	store := NewRedisStore(RedisConfig{Addr: server.addr(), Password: "secret"})
+	defer store.Close()
+	result, err := store.Take("key", PerMinute(1, 1), time.Now())
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	wrong := NewRedisStore(RedisConfig{Addr: server.addr(), Password: "wrong"})
+	defer wrong.Close()
+	_, err = wrong.Take("key", PerMinute(1, 1), time.Now())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "WRONGPASS")
+}
+
+func TestRedisStore_Unavailable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	store := NewRedisStore(RedisConfig{Addr: addr, Timeout: 100 * time.Millisecond})
+	_, err = store.Take("key", PerMinute(1, 1), time.Now())
+	assert.Error(t, err)
+}
@@ -0,0 +1,115 @@
+// Package ratelimit implements token buckets and expiring counters on top of a pluggable
+// store, so that limits survive restarts and are shared between backend replicas.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Limit configures a token bucket
+type Limit struct {
+	Rate  float64 // Tokens added per second
+	Burst int     // Maximum number of tokens
+}
+
+// PerMinute returns a limit that refills requestsPerMinute tokens per minute
+func PerMinute(requestsPerMinute, burst int) Limit {
+	if burst < 1 {
+		burst = 1
+	}
+	return Limit{Rate: float64(requestsPerMinute) / 60, Burst: burst}
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed    bool
+	Remaining  float64       // Tokens left in the bucket
+	RetryAfter time.Duration // Time until a token is available, if not allowed
+	ResetAfter time.Duration // Time until the bucket is full again
+}
+
+// Store keeps the state of token buckets and counters
+type Store interface {
+	// Take removes one token from the bucket stored under key, if one is available
+	Take(key string, limit Limit, now time.Time) (*Result, error)
+	// Add adds amount to the counter stored under key and returns the new total. The counter
+	// expires at expiresAt, after which it starts again from zero; adding 0 reads the counter.
+	Add(key string, amount float64, now, expiresAt time.Time) (float64, error)
+	// Cleanup deletes idle buckets and expired counters
+	Cleanup(now time.Time) error
+}
+
+// StartCleanup calls Cleanup on the store every interval in a background goroutine
+func StartCleanup(store Store, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := store.Cleanup(time.Now()); err != nil {
+				fmt.Printf("Warning: failed to clean up rate limits: %v\n", err)
+			}
+		}
+	}()
+}
+
+// Bucket is the stored state of a token bucket
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// NewBucket returns a full bucket
+func NewBucket(limit Limit, now time.Time) Bucket {
+	return Bucket{Tokens: float64(limit.Burst), UpdatedAt: now}
+}
+
+// Take refills the bucket for the time elapsed since it was last updated and removes one token
+// if one is available. It returns the new state of the bucket.
+func Take(bucket Bucket, limit Limit, now time.Time) (Bucket, *Result) {
+	burst := float64(limit.Burst)
+
+	tokens := bucket.Tokens
+	if elapsed := now.Sub(bucket.UpdatedAt).Seconds(); elapsed > 0 {
+		tokens += elapsed * limit.Rate
+	}
+	if tokens > burst {
+		tokens = burst
+	}
+	if !now.After(bucket.UpdatedAt) {
+		// Never move the clock of a bucket backwards, e.g. because of skew between replicas
+		now = bucket.UpdatedAt
+	}
+
+	result := &Result{}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = refillTime(1-tokens, limit.Rate)
+	}
+
+	result.Remaining = tokens
+	result.ResetAfter = refillTime(burst-tokens, limit.Rate)
+
+	return Bucket{Tokens: tokens, UpdatedAt: now}, result
+}
+
+// IdleAt returns when the bucket is full again. From then on it is equivalent to a new bucket,
+// so it can be deleted.
+func IdleAt(bucket Bucket, limit Limit) time.Time {
+	return bucket.UpdatedAt.Add(refillTime(float64(limit.Burst)-bucket.Tokens, limit.Rate))
+}
+
+// refillTime returns the time needed to add the given number of tokens
+func refillTime(tokens, rate float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	if rate <= 0 {
+		// The bucket never refills; report a day so callers have a finite wait
+		return 24 * time.Hour
+	}
+	return time.Duration(math.Ceil(tokens / rate * float64(time.Second)))
+}
@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func TestTake(t *testing.T) {
+	limit := PerMinute(60, 2) // One token per second
+
+	t.Run("takes tokens until the bucket is empty", func(t *testing.T) {
+		bucket := NewBucket(limit, testNow)
+
+		bucket, result := Take(bucket, limit, testNow)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 1.0, result.Remaining)
+		assert.Equal(t, time.Second, result.ResetAfter)
+
+		bucket, result = Take(bucket, limit, testNow)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 0.0, result.Remaining)
+
+		_, result = Take(bucket, limit, testNow)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, time.Second, result.RetryAfter)
+		assert.Equal(t, 2*time.Second, result.ResetAfter)
+	})
+
+	t.Run("refills over time up to the burst", func(t *testing.T) {
+		bucket := Bucket{Tokens: 0, UpdatedAt: testNow}
+
+		_, result := Take(bucket, limit, testNow.Add(1500*time.Millisecond))
+		assert.True(t, result.Allowed)
+		assert.InDelta(t, 0.5, result.Remaining, 1e-9)
+
+		_, result = Take(bucket, limit, testNow.Add(time.Hour))
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 1.0, result.Remaining)
+	})
+
+	t.Run("clock going backwards does not refill", func(t *testing.T) {
+		bucket := Bucket{Tokens: 0.5, UpdatedAt: testNow}
+
+		updated, result := Take(bucket, limit, testNow.Add(-time.Minute))
+		assert.False(t, result.Allowed)
+		assert.Equal(t, testNow, updated.UpdatedAt)
+	})
+
+	t.Run("idle when full again", func(t *testing.T) {
+		bucket := Bucket{Tokens: 0.5, UpdatedAt: testNow}
+		assert.Equal(t, testNow.Add(1500*time.Millisecond), IdleAt(bucket, limit))
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	t.Run("buckets are kept per key", func(t *testing.T) {
+		store := NewMemoryStore()
+		limit := PerMinute(1, 1)
+
+		result, err := store.Take("a", limit, testNow)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+
+		result, err = store.Take("a", limit, testNow)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+
+		result, err = store.Take("b", limit, testNow)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("concurrent takes never exceed the burst", func(t *testing.T) {
+		store := NewMemoryStore()
+		limit := PerMinute(0, 5)
+
+		var mu sync.Mutex
+		allowed := 0
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := store.Take("key", limit, testNow)
+				if err == nil && result.Allowed {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 5, allowed)
+	})
+
+	t.Run("counters expire", func(t *testing.T) {
+		store := NewMemoryStore()
+		expiresAt := testNow.Add(time.Hour)
+
+		value, err := store.Add("cpu", 1.5, testNow, expiresAt)
+		require.NoError(t, err)
+		assert.Equal(t, 1.5, value)
+
+		value, err = store.Add("cpu", 2, testNow, expiresAt)
+		require.NoError(t, err)
+		assert.Equal(t, 3.5, value)
+
+		value, err = store.Add("cpu", 0, expiresAt, expiresAt.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, value)
+	})
+
+	t.Run("cleanup deletes idle buckets and expired counters", func(t *testing.T) {
+		store := NewMemoryStore()
+		limit := PerMinute(60, 1)
+
+		_, err := store.Take("bucket", limit, testNow)
+		require.NoError(t, err)
+		_, err = store.Add("counter", 1, testNow, testNow.Add(time.Minute))
+		require.NoError(t, err)
+
+		require.NoError(t, store.Cleanup(testNow.Add(500*time.Millisecond)))
+		assert.Equal(t, 2, store.Len())
+
+		require.NoError(t, store.Cleanup(testNow.Add(time.Second)))
+		assert.Equal(t, 1, store.Len())
+
+		require.NoError(t, store.Cleanup(testNow.Add(time.Minute)))
+		assert.Equal(t, 0, store.Len())
+	})
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"database/sql"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+// codeDraftRepository implements CodeDraftRepository interface
+type codeDraftRepository struct {
+	db DBTX
+}
+
+// NewCodeDraftRepository creates a new code draft repository
+func NewCodeDraftRepository(db DBTX) CodeDraftRepository {
+	return &codeDraftRepository{db: db}
+}
+
+// Get retrieves the draft of a user for a problem in one language
+func (r *codeDraftRepository) Get(userID, problemID int, language string) (*models.CodeDraft, error) {
+	query := `
+		SELECT user_id, problem_id, language, code, version, updated_at
+		FROM code_drafts
+		WHERE user_id = $1 AND problem_id = $2 AND language = $3`
+
+	var draft models.CodeDraft
+	err := r.db.QueryRow(query, userID, problemID, language).Scan(
+		&draft.UserID,
+		&draft.ProblemID,
+		&draft.Language,
+		&draft.Code,
+		&draft.Version,
+		&draft.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NewRepositoryError("Get", ErrNotFound, "draft_not_found")
+		}
+		return nil, NewRepositoryError("Get", err, "database_error")
+	}
+
+	return &draft, nil
+}
+
+// Save stores a draft if its current version matches expectedVersion and increments the version.
+// An expectedVersion of 0 means that no draft may exist yet. ErrConflict is returned if the
+// stored draft has a different version, i.e. it was saved concurrently from somewhere else.
+func (r *codeDraftRepository) Save(draft *models.CodeDraft, expectedVersion int) (*models.CodeDraft, error) {
+	var query string
+	args := []interface{}{draft.UserID, draft.ProblemID, draft.Language, draft.Code}
+
+	if expectedVersion == 0 {
+		query = `
+			INSERT INTO code_drafts (user_id, problem_id, language, code, version, updated_at)
+			VALUES ($1, $2, $3, $4, 1, CURRENT_TIMESTAMP)
+			ON CONFLICT (user_id, problem_id, language) DO NOTHING
+			RETURNING user_id, problem_id, language, code, version, updated_at`
+	} else {
+		query = `
+			UPDATE code_drafts
+			SET code = $4, version = version + 1, updated_at = CURRENT_TIMESTAMP
+			WHERE user_id = $1 AND problem_id = $2 AND language = $3 AND version = $5
+			RETURNING user_id, problem_id, language, code, version, updated_at`
+		args = append(args, expectedVersion)
+	}
+
+	var saved models.CodeDraft
+	err := r.db.QueryRow(query, args...).Scan(
+		&saved.UserID,
+		&saved.ProblemID,
+		&saved.Language,
+		&saved.Code,
+		&saved.Version,
+		&saved.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NewRepositoryError("Save", ErrConflict, "draft_version_conflict")
+		}
+		return nil, NewRepositoryError("Save", err, "database_error")
+	}
+
+	return &saved, nil
+}
+
+// ListByUser retrieves all drafts of a user, most recently saved first
+func (r *codeDraftRepository) ListByUser(userID int) ([]*models.CodeDraft, error) {
+	query := `
+		SELECT d.user_id, d.problem_id, d.language, d.code, d.version, d.updated_at,
+		       p.title, p.difficulty
+		FROM code_drafts d
+		JOIN problems p ON p.id = d.problem_id
+		WHERE d.user_id = $1
+		ORDER BY d.updated_at DESC, d.problem_id ASC, d.language ASC`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, NewRepositoryError("ListByUser", err, "database_error")
+	}
+	defer rows.Close()
+
+	var drafts []*models.CodeDraft
+	for rows.Next() {
+		var draft models.CodeDraft
+		var problem models.SubmissionProblem
+		err := rows.Scan(
+			&draft.UserID,
+			&draft.ProblemID,
+			&draft.Language,
+			&draft.Code,
+			&draft.Version,
+			&draft.UpdatedAt,
+			&problem.Title,
+			&problem.Difficulty,
+		)
+		if err != nil {
+			return nil, NewRepositoryError("ListByUser", err, "scan_error")
+		}
+		problem.ID = draft.ProblemID
+		draft.Problem = &problem
+		drafts = append(drafts, &draft)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, NewRepositoryError("ListByUser", err, "rows_error")
+	}
+
+	return drafts, nil
+}
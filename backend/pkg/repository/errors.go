@@ -12,6 +12,7 @@ var (
 	ErrInvalidInput  = errors.New("invalid input")
 	ErrDatabase      = errors.New("database error")
 	ErrTransaction   = errors.New("transaction error")
+	ErrConflict      = errors.New("conflicting update")
 )
 
 // RepositoryError represents a repository-specific error
@@ -66,4 +67,13 @@ func IsInvalidInput(err error) bool {
 		return errors.Is(repoErr.Err, ErrInvalidInput)
 	}
 	return errors.Is(err, ErrInvalidInput)
-}
\ No newline at end of file
+}
+
+// IsConflict checks if the error is a conflicting update error
+func IsConflict(err error) bool {
+	var repoErr *RepositoryError
+	if errors.As(err, &repoErr) {
+		return errors.Is(repoErr.Err, ErrConflict)
+	}
+	return errors.Is(err, ErrConflict)
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+// idempotencyKeyColumns lists the columns selected for an idempotency key, in scan order
+const idempotencyKeyColumns = `user_id, endpoint, key, request_hash, status, response_status,
+	response_content_type, response_body, created_at, completed_at`
+
+// idempotencyKeyRepository implements IdempotencyKeyRepository interface
+type idempotencyKeyRepository struct {
+	db DBTX
+}
+
+// NewIdempotencyKeyRepository creates a new idempotency key repository
+func NewIdempotencyKeyRepository(db DBTX) IdempotencyKeyRepository {
+	return &idempotencyKeyRepository{db: db}
+}
+
+// scanIdempotencyKey scans a row selected with idempotencyKeyColumns
+func scanIdempotencyKey(row rowScanner) (*models.IdempotencyKey, error) {
+	var key models.IdempotencyKey
+	err := row.Scan(
+		&key.UserID,
+		&key.Endpoint,
+		&key.Key,
+		&key.RequestHash,
+		&key.Status,
+		&key.ResponseStatus,
+		&key.ResponseContentType,
+		&key.ResponseBody,
+		&key.CreatedAt,
+		&key.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Reserve records a new request for an idempotency key. If the key is already in use, the existing
+// record is returned and reserved is false. Records created before expiredBefore, and records that
+// are still processing but were created before staleBefore (e.g. because the server stopped while
+// handling the request), are replaced as if they did not exist.
+func (r *idempotencyKeyRepository) Reserve(key *models.IdempotencyKey, expiredBefore, staleBefore time.Time) (*models.IdempotencyKey, bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (user_id, endpoint, key, request_hash, status, created_at)
+		VALUES ($1, $2, $3, $4, 'processing', CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id, endpoint, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+		    status = 'processing',
+		    response_status = NULL,
+		    response_content_type = NULL,
+		    response_body = NULL,
+		    created_at = CURRENT_TIMESTAMP,
+		    completed_at = NULL
+		WHERE idempotency_keys.created_at < $5
+		   OR (idempotency_keys.status = 'processing' AND idempotency_keys.created_at < $6)
+		RETURNING ` + idempotencyKeyColumns
+
+	reserved, err := scanIdempotencyKey(r.db.QueryRow(query, key.UserID, key.Endpoint, key.Key, key.RequestHash, expiredBefore, staleBefore))
+	if err == nil {
+		return reserved, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, NewRepositoryError("Reserve", err, "database_error")
+	}
+
+	// The key is in use by a request that has not expired
+	query = `SELECT ` + idempotencyKeyColumns + `
+		FROM idempotency_keys
+		WHERE user_id = $1 AND endpoint = $2 AND key = $3`
+
+	existing, err := scanIdempotencyKey(r.db.QueryRow(query, key.UserID, key.Endpoint, key.Key))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// Deleted concurrently; the client may retry
+			return nil, false, NewRepositoryError("Reserve", ErrConflict, "idempotency_key_conflict")
+		}
+		return nil, false, NewRepositoryError("Reserve", err, "database_error")
+	}
+
+	return existing, false, nil
+}
+
+// Complete stores the response to a reserved request
+func (r *idempotencyKeyRepository) Complete(userID int, endpoint, key string, status int, contentType string, body []byte) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status = 'completed', response_status = $4, response_content_type = $5, response_body = $6,
+		    completed_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND endpoint = $2 AND key = $3`
+
+	result, err := r.db.Exec(query, userID, endpoint, key, status, contentType, string(body))
+	if err != nil {
+		return NewRepositoryError("Complete", err, "database_error")
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return NewRepositoryError("Complete", err, "database_error")
+	}
+
+	if rowsAffected == 0 {
+		return NewRepositoryError("Complete", ErrNotFound, "idempotency_key_not_found")
+	}
+
+	return nil
+}
+
+// Delete releases an idempotency key, e.g. after the request failed, so it can be retried
+func (r *idempotencyKeyRepository) Delete(userID int, endpoint, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE user_id = $1 AND endpoint = $2 AND key = $3`
+
+	if _, err := r.db.Exec(query, userID, endpoint, key); err != nil {
+		return NewRepositoryError("Delete", err, "database_error")
+	}
+
+	return nil
+}
+
+// DeleteExpired deletes all idempotency keys created before the given time
+func (r *idempotencyKeyRepository) DeleteExpired(before time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM idempotency_keys WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, NewRepositoryError("DeleteExpired", err, "database_error")
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, NewRepositoryError("DeleteExpired", err, "database_error")
+	}
+
+	return deleted, nil
+}
@@ -1,7 +1,12 @@
 package repository
 
 import (
+	"database/sql"
+	"time"
+
 	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/pagination"
+	"leetcode-clone-backend/pkg/storage"
 )
 
 // UserRepository defines the interface for user data operations
@@ -12,7 +17,8 @@ type UserRepository interface {
 	GetByEmail(email string) (*models.User, error)
 	Update(user *models.User) (*models.User, error)
 	Delete(id int) error
-	List(limit, offset int) ([]*models.User, error)
+	List(filters UserFilters) ([]*models.User, error)
+	Count() (int, error)
 }
 
 // ProblemRepository defines the interface for problem data operations
@@ -23,7 +29,12 @@ type ProblemRepository interface {
 	Update(problem *models.Problem) (*models.Problem, error)
 	Delete(id int) error
 	List(filters ProblemFilters) ([]*models.Problem, error)
-	Search(query string, filters ProblemFilters) ([]*models.Problem, error)
+	Count(filters ProblemFilters) (int, error)
+	Search(text SearchText, filters ProblemFilters) ([]*models.ProblemSearchResult, error)
+	CountSearch(text SearchText, filters ProblemFilters) (int, error)
+	Annotate(problems []*models.Problem, userID int) error
+	SetState(id int, state string, publishAt *time.Time) (*models.Problem, error)
+	ListScheduledDue(now time.Time) ([]int, error)
 }
 
 // TestCaseRepository defines the interface for test case data operations
@@ -32,7 +43,14 @@ type TestCaseRepository interface {
 	GetByID(id int) (*models.TestCase, error)
 	GetByProblemID(problemID int) ([]*models.TestCase, error)
 	GetPublicByProblemID(problemID int) ([]*models.TestCase, error)
+	// GetInlineByProblemID leaves out the data kept in the test data store
+	GetInlineByProblemID(problemID int) ([]*models.TestCase, error)
+	// ListByProblemID and ListPublicByProblemID return test cases without their data
+	ListByProblemID(problemID int) ([]*models.TestCase, error)
+	ListPublicByProblemID(problemID int) ([]*models.TestCase, error)
 	Update(testCase *models.TestCase) (*models.TestCase, error)
+	SetOutputStatus(id int, status string) error
+	ResetOutputStatus(problemID int) error
 	Delete(id int) error
 	DeleteByProblemID(problemID int) error
 }
@@ -47,34 +65,178 @@ type SubmissionRepository interface {
 	Update(submission *models.Submission) (*models.Submission, error)
 	Delete(id int) error
 	GetLatestByUserAndProblem(userID, problemID int) (*models.Submission, error)
+	GetIDsForRejudge(problemID int, from, to *time.Time) ([]int, error)
+	List(filters SubmissionFilters) ([]*models.Submission, error)
+	Count(filters SubmissionFilters) (int, error)
+	GetStats(userID int, activityDays int) (*models.SubmissionStats, error)
+}
+
+// SubmissionTestResultRepository defines the interface for per-test-case submission result operations
+type SubmissionTestResultRepository interface {
+	CreateBatch(results []*models.SubmissionTestResult) error
+	GetBySubmissionID(submissionID int) ([]*models.SubmissionTestResult, error)
+	DeleteBySubmissionID(submissionID int) error
 }
 
 // UserProgressRepository defines the interface for user progress data operations
 type UserProgressRepository interface {
 	Create(progress *models.UserProgress) (*models.UserProgress, error)
 	GetByUserAndProblem(userID, problemID int) (*models.UserProgress, error)
+	GetForUpdate(userID, problemID int) (*models.UserProgress, error)
 	GetByUserID(userID int) ([]*models.UserProgress, error)
 	Update(progress *models.UserProgress) (*models.UserProgress, error)
 	Delete(userID, problemID int) error
 	GetSolvedCount(userID int) (int, error)
 	GetSolvedCountByDifficulty(userID int) (map[string]int, error)
+	Recompute(userID, problemID int) (*models.UserProgress, error)
+}
+
+// CodeDraftRepository defines the interface for code draft operations
+type CodeDraftRepository interface {
+	Get(userID, problemID int, language string) (*models.CodeDraft, error)
+	Save(draft *models.CodeDraft, expectedVersion int) (*models.CodeDraft, error)
+	ListByUser(userID int) ([]*models.CodeDraft, error)
+}
+
+// IdempotencyKeyRepository defines the interface for idempotency key operations
+type IdempotencyKeyRepository interface {
+	Reserve(key *models.IdempotencyKey, expiredBefore, staleBefore time.Time) (*models.IdempotencyKey, bool, error)
+	Complete(userID int, endpoint, key string, status int, contentType string, body []byte) error
+	Delete(userID int, endpoint, key string) error
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+// PerformanceHistogramRepository defines the interface for runtime and memory histogram operations
+type PerformanceHistogramRepository interface {
+	Increment(problemID int, language, metric string, bucket int) error
+	GetBuckets(problemID int, language, metric string) ([]*models.PerformanceBucket, error)
+	RebuildProblem(problemID int) error
+}
+
+// RejudgeRepository defines the interface for rejudge job data operations
+type RejudgeRepository interface {
+	CreateJob(job *models.RejudgeJob) (*models.RejudgeJob, error)
+	GetJob(id int) (*models.RejudgeJob, error)
+	ListJobs(limit, offset int) ([]*models.RejudgeJob, error)
+	GetUnfinishedJobs() ([]*models.RejudgeJob, error)
+	ClaimJob(id int, leaseTimeout time.Duration) (*models.RejudgeJob, error)
+	Heartbeat(id int, claimToken string) error
+	UpdateJob(job *models.RejudgeJob) (*models.RejudgeJob, error)
+	CreateResult(result *models.RejudgeResult) (*models.RejudgeResult, error)
+	GetResults(jobID int, changedOnly bool) ([]*models.RejudgeResult, error)
+}
+
+// ProblemRevisionRepository defines the interface for problem revision operations
+type ProblemRevisionRepository interface {
+	LockProblem(problemID int) error
+	Create(revision *models.ProblemRevision) (*models.ProblemRevision, error)
+	Get(problemID, revision int) (*models.ProblemRevision, error)
+	GetLatest(problemID int) (*models.ProblemRevision, error)
+	List(filters ProblemRevisionFilters) ([]*models.ProblemRevision, error)
+	Count(problemID int) (int, error)
+}
+
+// ProblemSolutionRepository defines the interface for reference solution data operations
+type ProblemSolutionRepository interface {
+	GetByProblemID(problemID int) ([]*models.ProblemSolution, error)
+	Replace(problemID int, solutions []*models.ProblemSolution) ([]*models.ProblemSolution, error)
+}
+
+// TestProgramRepository defines the interface for test generator and validator data operations
+type TestProgramRepository interface {
+	GetByProblemID(problemID int) ([]*models.TestProgram, error)
+	Get(problemID int, kind string) (*models.TestProgram, error)
+	Upsert(program *models.TestProgram) (*models.TestProgram, error)
+	Delete(problemID int, kind string) error
+}
+
+// JudgeSettingsRepository defines the interface for judging settings data operations
+type JudgeSettingsRepository interface {
+	GetByProblemID(problemID int) (*models.JudgeSettings, error)
+	Upsert(settings *models.JudgeSettings) (*models.JudgeSettings, error)
+}
+
+// TagRepository defines the interface for tag catalog operations
+type TagRepository interface {
+	List(userID int, state string) ([]*models.Tag, error)
+	Get(name string) (*models.Tag, error)
+	SetDescription(name string, description *string) error
+	Merge(sources []string, target string) (int64, error)
 }
 
 // ProblemFilters represents filters for problem queries
 type ProblemFilters struct {
-	Difficulty []string
-	Tags       []string
-	Limit      int
-	Offset     int
-	SortBy     string // "title", "difficulty", "created_at"
-	SortOrder  string // "asc", "desc"
+	Difficulty   []string
+	Tags         []string // Problems with any of the tags
+	RequiredTags []string // Problems with all of the tags
+	ExcludedTags []string // Problems with none of the tags
+	State        string   // Lifecycle state; problems in every state when empty
+	Status       string   // "solved", "attempted", "unsolved" or "new" for UserID; unsolved includes attempted
+	UserID       int
+	Limit        int
+	Offset       int
+	Cursor       *pagination.Cursor // Keyset position of List, used instead of Offset
+	SortBy       string             // "title", "difficulty", "created_at"; searches default to relevance
+	SortOrder    string             // "asc", "desc"
+}
+
+// SearchText is the free text of a problem search, as lowercase words
+type SearchText struct {
+	Words    []string   // Every word must match
+	Phrases  [][]string // Every phrase must match, with its words next to each other
+	Excluded [][]string // No excluded word or phrase may match
+	Prefix   bool       // The last word also matches as a prefix
+}
+
+// UserFilters represents filters for listing users
+type UserFilters struct {
+	Limit  int
+	Offset int
+	Cursor *pagination.Cursor // Keyset position of List, used instead of Offset
+}
+
+// ProblemRevisionFilters represents filters for listing the revisions of a problem
+type ProblemRevisionFilters struct {
+	ProblemID int
+	Limit     int
+	Offset    int
+	Cursor    *pagination.Cursor // Keyset position of List, used instead of Offset
+}
+
+// SubmissionFilters represents filters for submission queries.
+// Zero values leave a filter unset; From is inclusive and To is exclusive.
+type SubmissionFilters struct {
+	UserID    int
+	ProblemID int
+	Status    string
+	Language  string
+	From      *time.Time
+	To        *time.Time
+	Limit     int
+	Offset    int
+	Cursor    *pagination.Cursor // Keyset position of List, used instead of Offset
 }
 
 // Repository aggregates all repository interfaces
 type Repository struct {
-	User         UserRepository
-	Problem      ProblemRepository
-	TestCase     TestCaseRepository
-	Submission   SubmissionRepository
-	UserProgress UserProgressRepository
+	User                 UserRepository
+	Problem              ProblemRepository
+	TestCase             TestCaseRepository
+	Submission           SubmissionRepository
+	SubmissionTestResult SubmissionTestResultRepository
+	UserProgress         UserProgressRepository
+	Rejudge              RejudgeRepository
+	PerformanceHistogram PerformanceHistogramRepository
+	CodeDraft            CodeDraftRepository
+	IdempotencyKey       IdempotencyKeyRepository
+	Tag                  TagRepository
+	ProblemRevision      ProblemRevisionRepository
+	ProblemSolution      ProblemSolutionRepository
+	JudgeSettings        JudgeSettingsRepository
+	TestProgram          TestProgramRepository
+
+	// db is nil for transaction-scoped repositories and for repositories assembled by hand
+	db *sql.DB
+	// testData keeps large test data, shared with transaction-scoped repositories
+	testData storage.Store
 }
\ No newline at end of file
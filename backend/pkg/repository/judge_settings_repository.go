@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"database/sql"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+// judgeSettingsRepository implements JudgeSettingsRepository interface
+type judgeSettingsRepository struct {
+	db DBTX
+}
+
+// NewJudgeSettingsRepository creates a new judging settings repository
+func NewJudgeSettingsRepository(db DBTX) JudgeSettingsRepository {
+	return &judgeSettingsRepository{db: db}
+}
+
+// GetByProblemID retrieves the judging settings of a problem, or the defaults if it has none
+func (r *judgeSettingsRepository) GetByProblemID(problemID int) (*models.JudgeSettings, error) {
+	query := `
+		SELECT problem_id, time_limit_ms, memory_limit_mb, language_limits, checker, ignore_case, float_tolerance, updated_at
+		FROM judge_settings
+		WHERE problem_id = $1`
+
+	var settings models.JudgeSettings
+	err := r.db.QueryRow(query, problemID).Scan(
+		&settings.ProblemID,
+		&settings.TimeLimitMs,
+		&settings.MemoryLimitMb,
+		&settings.LanguageLimits,
+		&settings.Checker,
+		&settings.IgnoreCase,
+		&settings.FloatTolerance,
+		&settings.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.DefaultJudgeSettings(problemID), nil
+		}
+		return nil, NewRepositoryError("GetByProblemID", err, "database_error")
+	}
+
+	return &settings, nil
+}
+
+// Upsert creates or replaces the judging settings of a problem
+func (r *judgeSettingsRepository) Upsert(settings *models.JudgeSettings) (*models.JudgeSettings, error) {
+	query := `
+		INSERT INTO judge_settings (problem_id, time_limit_ms, memory_limit_mb, language_limits, checker, ignore_case, float_tolerance)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (problem_id) DO UPDATE
+		SET time_limit_ms = EXCLUDED.time_limit_ms, memory_limit_mb = EXCLUDED.memory_limit_mb,
+		    language_limits = EXCLUDED.language_limits, checker = EXCLUDED.checker, ignore_case = EXCLUDED.ignore_case, float_tolerance = EXCLUDED.float_tolerance
+		RETURNING problem_id, time_limit_ms, memory_limit_mb, language_limits, checker, ignore_case, float_tolerance, updated_at`
+
+	var saved models.JudgeSettings
+	err := r.db.QueryRow(
+		query,
+		settings.ProblemID,
+		settings.TimeLimitMs,
+		settings.MemoryLimitMb,
+		settings.LanguageLimits,
+		settings.Checker,
+		settings.IgnoreCase,
+		settings.FloatTolerance,
+	).Scan(
+		&saved.ProblemID,
+		&saved.TimeLimitMs,
+		&saved.MemoryLimitMb,
+		&saved.LanguageLimits,
+		&saved.Checker,
+		&saved.IgnoreCase,
+		&saved.FloatTolerance,
+		&saved.UpdatedAt,
+	)
+	if err != nil {
+		return nil, NewRepositoryError("Upsert", err, "database_error")
+	}
+
+	return &saved, nil
+}
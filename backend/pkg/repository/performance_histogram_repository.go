@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"leetcode-clone-backend/pkg/models"
+)
+
+// performanceHistogramRepository implements PerformanceHistogramRepository interface
+type performanceHistogramRepository struct {
+	db DBTX
+}
+
+// NewPerformanceHistogramRepository creates a new performance histogram repository
+func NewPerformanceHistogramRepository(db DBTX) PerformanceHistogramRepository {
+	return &performanceHistogramRepository{db: db}
+}
+
+// Increment adds one accepted submission to a histogram bucket
+func (r *performanceHistogramRepository) Increment(problemID int, language, metric string, bucket int) error {
+	query := `
+		INSERT INTO submission_perf_histograms (problem_id, language, metric, bucket, count)
+		VALUES ($1, $2, $3, $4, 1)
+		ON CONFLICT (problem_id, language, metric, bucket)
+		DO UPDATE SET count = submission_perf_histograms.count + 1`
+
+	if _, err := r.db.Exec(query, problemID, language, metric, bucket); err != nil {
+		return NewRepositoryError("Increment", err, "database_error")
+	}
+
+	return nil
+}
+
+// GetBuckets retrieves the non-empty buckets of a histogram in ascending order
+func (r *performanceHistogramRepository) GetBuckets(problemID int, language, metric string) ([]*models.PerformanceBucket, error) {
+	query := `
+		SELECT bucket, count
+		FROM submission_perf_histograms
+		WHERE problem_id = $1 AND language = $2 AND metric = $3 AND count > 0
+		ORDER BY bucket ASC`
+
+	rows, err := r.db.Query(query, problemID, language, metric)
+	if err != nil {
+		return nil, NewRepositoryError("GetBuckets", err, "database_error")
+	}
+	defer rows.Close()
+
+	var buckets []*models.PerformanceBucket
+	for rows.Next() {
+		var bucket models.PerformanceBucket
+		if err := rows.Scan(&bucket.Bucket, &bucket.Count); err != nil {
+			return nil, NewRepositoryError("GetBuckets", err, "scan_error")
+		}
+		buckets = append(buckets, &bucket)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, NewRepositoryError("GetBuckets", err, "rows_error")
+	}
+
+	return buckets, nil
+}
+
+// RebuildProblem recomputes all histograms of a problem from its accepted submissions,
+// e.g. after its submissions were rejudged
+func (r *performanceHistogramRepository) RebuildProblem(problemID int) error {
+	return inTx(r.db, func(tx DBTX) error {
+		if _, err := tx.Exec(`DELETE FROM submission_perf_histograms WHERE problem_id = $1`, problemID); err != nil {
+			return NewRepositoryError("RebuildProblem", err, "database_error")
+		}
+
+		query := `
+			INSERT INTO submission_perf_histograms (problem_id, language, metric, bucket, count)
+			SELECT problem_id, language, $2::varchar, (runtime_ms / $3::int) * $3::int, COUNT(*)
+			FROM submissions
+			WHERE problem_id = $1 AND status = 'Accepted' AND runtime_ms IS NOT NULL
+			GROUP BY problem_id, language, (runtime_ms / $3::int) * $3::int
+			UNION ALL
+			SELECT problem_id, language, $4::varchar, (memory_kb / $5::int) * $5::int, COUNT(*)
+			FROM submissions
+			WHERE problem_id = $1 AND status = 'Accepted' AND memory_kb IS NOT NULL
+			GROUP BY problem_id, language, (memory_kb / $5::int) * $5::int`
+
+		_, err := tx.Exec(
+			query,
+			problemID,
+			models.PerformanceMetricRuntime,
+			models.RuntimeBucketMs,
+			models.PerformanceMetricMemory,
+			models.MemoryBucketKb,
+		)
+		if err != nil {
+			return NewRepositoryError("RebuildProblem", err, "database_error")
+		}
+
+		return nil
+	})
+}
@@ -3,28 +3,38 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"math"
 	"strings"
+	"time"
+	"unicode"
 
 	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/pagination"
 	"github.com/lib/pq"
 )
 
 // problemRepository implements ProblemRepository interface
 type problemRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewProblemRepository creates a new problem repository
-func NewProblemRepository(db *sql.DB) ProblemRepository {
+func NewProblemRepository(db DBTX) ProblemRepository {
 	return &problemRepository{db: db}
 }
 
-// Create creates a new problem
+// Create creates a new problem, as a draft unless problem.State is set
 func (r *problemRepository) Create(problem *models.Problem) (*models.Problem, error) {
+	state := problem.State
+	if state == "" {
+		state = models.ProblemStateDraft
+	}
+
 	query := `
-		INSERT INTO problems (title, slug, description, difficulty, tags, examples, constraints, template_code)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id, title, slug, description, difficulty, tags, examples, constraints, template_code, created_at, updated_at`
+		INSERT INTO problems (title, slug, description, difficulty, tags, examples, constraints, template_code, state, publish_at, published_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9::varchar, $10,
+		        CASE WHEN $9::varchar = 'published' THEN CURRENT_TIMESTAMP END)
+		RETURNING id, title, slug, description, difficulty, tags, examples, constraints, template_code, state, publish_at, published_at, created_at, updated_at`
 
 	var created models.Problem
 	err := r.db.QueryRow(
@@ -37,6 +47,8 @@ func (r *problemRepository) Create(problem *models.Problem) (*models.Problem, er
 		problem.Examples,
 		problem.Constraints,
 		problem.TemplateCode,
+		state,
+		problem.PublishAt,
 	).Scan(
 		&created.ID,
 		&created.Title,
@@ -47,6 +59,9 @@ func (r *problemRepository) Create(problem *models.Problem) (*models.Problem, er
 		&created.Examples,
 		&created.Constraints,
 		&created.TemplateCode,
+		&created.State,
+		&created.PublishAt,
+		&created.PublishedAt,
 		&created.CreatedAt,
 		&created.UpdatedAt,
 	)
@@ -72,7 +87,7 @@ func (r *problemRepository) Create(problem *models.Problem) (*models.Problem, er
 // GetByID retrieves a problem by ID
 func (r *problemRepository) GetByID(id int) (*models.Problem, error) {
 	query := `
-		SELECT id, title, slug, description, difficulty, tags, examples, constraints, template_code, created_at, updated_at
+		SELECT id, title, slug, description, difficulty, tags, examples, constraints, template_code, state, publish_at, published_at, created_at, updated_at
 		FROM problems
 		WHERE id = $1`
 
@@ -87,6 +102,9 @@ func (r *problemRepository) GetByID(id int) (*models.Problem, error) {
 		&problem.Examples,
 		&problem.Constraints,
 		&problem.TemplateCode,
+		&problem.State,
+		&problem.PublishAt,
+		&problem.PublishedAt,
 		&problem.CreatedAt,
 		&problem.UpdatedAt,
 	)
@@ -104,7 +122,7 @@ func (r *problemRepository) GetByID(id int) (*models.Problem, error) {
 // GetBySlug retrieves a problem by slug
 func (r *problemRepository) GetBySlug(slug string) (*models.Problem, error) {
 	query := `
-		SELECT id, title, slug, description, difficulty, tags, examples, constraints, template_code, created_at, updated_at
+		SELECT id, title, slug, description, difficulty, tags, examples, constraints, template_code, state, publish_at, published_at, created_at, updated_at
 		FROM problems
 		WHERE slug = $1`
 
@@ -119,6 +137,9 @@ func (r *problemRepository) GetBySlug(slug string) (*models.Problem, error) {
 		&problem.Examples,
 		&problem.Constraints,
 		&problem.TemplateCode,
+		&problem.State,
+		&problem.PublishAt,
+		&problem.PublishedAt,
 		&problem.CreatedAt,
 		&problem.UpdatedAt,
 	)
@@ -140,7 +161,7 @@ func (r *problemRepository) Update(problem *models.Problem) (*models.Problem, er
 		SET title = $2, slug = $3, description = $4, difficulty = $5, tags = $6, 
 		    examples = $7, constraints = $8, template_code = $9, updated_at = CURRENT_TIMESTAMP
 		WHERE id = $1
-		RETURNING id, title, slug, description, difficulty, tags, examples, constraints, template_code, created_at, updated_at`
+		RETURNING id, title, slug, description, difficulty, tags, examples, constraints, template_code, state, publish_at, published_at, created_at, updated_at`
 
 	var updated models.Problem
 	err := r.db.QueryRow(
@@ -164,6 +185,9 @@ func (r *problemRepository) Update(problem *models.Problem) (*models.Problem, er
 		&updated.Examples,
 		&updated.Constraints,
 		&updated.TemplateCode,
+		&updated.State,
+		&updated.PublishAt,
+		&updated.PublishedAt,
 		&updated.CreatedAt,
 		&updated.UpdatedAt,
 	)
@@ -210,58 +234,110 @@ func (r *problemRepository) Delete(id int) error {
 	return nil
 }
 
-// List retrieves problems with filters
-func (r *problemRepository) List(filters ProblemFilters) ([]*models.Problem, error) {
+// SetState moves a problem to a lifecycle state. publishAt is only kept for scheduled problems.
+// The first time a problem is published, its publish time is recorded: the scheduled time if it
+// has passed, otherwise now.
+func (r *problemRepository) SetState(id int, state string, publishAt *time.Time) (*models.Problem, error) {
 	query := `
-		SELECT id, title, slug, description, difficulty, tags, examples, constraints, template_code, created_at, updated_at
-		FROM problems`
-	
-	var conditions []string
-	var args []interface{}
-	argIndex := 1
+		UPDATE problems
+		SET state = $2::varchar, publish_at = $3, updated_at = CURRENT_TIMESTAMP,
+		    published_at = CASE WHEN $2::varchar = 'published'
+		                        THEN COALESCE(published_at, LEAST(publish_at, CURRENT_TIMESTAMP))
+		                        ELSE published_at END
+		WHERE id = $1
+		RETURNING id, title, slug, description, difficulty, tags, examples, constraints, template_code, state, publish_at, published_at, created_at, updated_at`
 
-	// Apply difficulty filters
-	if len(filters.Difficulty) > 0 {
-		placeholders := make([]string, len(filters.Difficulty))
-		for i, difficulty := range filters.Difficulty {
-			placeholders[i] = fmt.Sprintf("$%d", argIndex)
-			args = append(args, difficulty)
-			argIndex++
+	var updated models.Problem
+	err := r.db.QueryRow(query, id, state, publishAt).Scan(
+		&updated.ID,
+		&updated.Title,
+		&updated.Slug,
+		&updated.Description,
+		&updated.Difficulty,
+		&updated.Tags,
+		&updated.Examples,
+		&updated.Constraints,
+		&updated.TemplateCode,
+		&updated.State,
+		&updated.PublishAt,
+		&updated.PublishedAt,
+		&updated.CreatedAt,
+		&updated.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NewRepositoryError("SetState", ErrNotFound, "problem_not_found")
 		}
-		conditions = append(conditions, fmt.Sprintf("difficulty IN (%s)", strings.Join(placeholders, ",")))
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23514" { // check_violation
+			return nil, NewRepositoryError("SetState", ErrInvalidInput, "invalid_state")
+		}
+		return nil, NewRepositoryError("SetState", err, "database_error")
 	}
 
-	// Apply tag filters
-	if len(filters.Tags) > 0 {
-		tagConditions := make([]string, len(filters.Tags))
-		for i, tag := range filters.Tags {
-			tagConditions[i] = fmt.Sprintf("$%d = ANY(tags)", argIndex)
-			args = append(args, tag)
-			argIndex++
+	return &updated, nil
+}
+
+// ListScheduledDue returns the IDs of scheduled problems whose publish time is at or before now,
+// earliest first
+func (r *problemRepository) ListScheduledDue(now time.Time) ([]int, error) {
+	rows, err := r.db.Query(`
+		SELECT id FROM problems
+		WHERE state = 'scheduled' AND publish_at <= $1
+		ORDER BY publish_at, id`, now)
+	if err != nil {
+		return nil, NewRepositoryError("ListScheduledDue", err, "database_error")
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, NewRepositoryError("ListScheduledDue", err, "scan_error")
 		}
-		conditions = append(conditions, fmt.Sprintf("(%s)", strings.Join(tagConditions, " OR ")))
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, NewRepositoryError("ListScheduledDue", err, "rows_error")
 	}
 
+	return ids, nil
+}
+
+// List retrieves problems with filters
+func (r *problemRepository) List(filters ProblemFilters) ([]*models.Problem, error) {
+	query := `
+		SELECT id, title, slug, description, difficulty, tags, examples, constraints, template_code, state, publish_at, published_at, created_at, updated_at
+		FROM problems`
+	
+	conditions, args := problemFilterConditions(filters, nil)
+	argIndex := len(args) + 1
+
 	// Add WHERE clause if conditions exist
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
-	// Add sorting
-	sortBy := "created_at"
-	if filters.SortBy != "" {
-		switch filters.SortBy {
-		case "title", "difficulty", "created_at":
-			sortBy = filters.SortBy
+	// Add keyset pagination, which starts after the cursor in the sort order
+	sortBy, sortOrder := problemSortKey(filters)
+	if filters.Cursor != nil {
+		condition, cursorArgs, err := problemCursorCondition(filters.Cursor, sortBy, sortOrder, argIndex)
+		if err != nil {
+			return nil, NewRepositoryError("List", err, "invalid_cursor")
 		}
+		if len(conditions) > 0 {
+			query += " AND " + condition
+		} else {
+			query += " WHERE " + condition
+		}
+		args = append(args, cursorArgs...)
+		argIndex += len(cursorArgs)
 	}
 
-	sortOrder := "DESC"
-	if filters.SortOrder == "asc" {
-		sortOrder = "ASC"
-	}
-
-	query += fmt.Sprintf(" ORDER BY %s %s", sortBy, sortOrder)
+	// Add sorting, with the ID breaking ties so pages never overlap
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s", sortBy, sortOrder, sortOrder)
 
 	// Add pagination
 	if filters.Limit > 0 {
@@ -270,7 +346,7 @@ func (r *problemRepository) List(filters ProblemFilters) ([]*models.Problem, err
 		argIndex++
 	}
 
-	if filters.Offset > 0 {
+	if filters.Offset > 0 && filters.Cursor == nil {
 		query += fmt.Sprintf(" OFFSET $%d", argIndex)
 		args = append(args, filters.Offset)
 	}
@@ -294,6 +370,9 @@ func (r *problemRepository) List(filters ProblemFilters) ([]*models.Problem, err
 			&problem.Examples,
 			&problem.Constraints,
 			&problem.TemplateCode,
+			&problem.State,
+			&problem.PublishAt,
+			&problem.PublishedAt,
 			&problem.CreatedAt,
 			&problem.UpdatedAt,
 		)
@@ -310,104 +389,407 @@ func (r *problemRepository) List(filters ProblemFilters) ([]*models.Problem, err
 	return problems, nil
 }
 
-// Search searches problems by title or description
-func (r *problemRepository) Search(query string, filters ProblemFilters) ([]*models.Problem, error) {
-	sqlQuery := `
-		SELECT id, title, slug, description, difficulty, tags, examples, constraints, template_code, created_at, updated_at
-		FROM problems
-		WHERE (title ILIKE $1 OR description ILIKE $1)`
-	
-	var conditions []string
-	var args []interface{}
-	args = append(args, "%"+query+"%")
-	argIndex := 2
+// Count returns the number of problems matching the filters, ignoring pagination
+func (r *problemRepository) Count(filters ProblemFilters) (int, error) {
+	query := `SELECT COUNT(*) FROM problems`
 
-	// Apply difficulty filters
-	if len(filters.Difficulty) > 0 {
-		placeholders := make([]string, len(filters.Difficulty))
-		for i, difficulty := range filters.Difficulty {
-			placeholders[i] = fmt.Sprintf("$%d", argIndex)
-			args = append(args, difficulty)
-			argIndex++
-		}
-		conditions = append(conditions, fmt.Sprintf("difficulty IN (%s)", strings.Join(placeholders, ",")))
+	conditions, args := problemFilterConditions(filters, nil)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
-	// Apply tag filters
-	if len(filters.Tags) > 0 {
-		tagConditions := make([]string, len(filters.Tags))
-		for i, tag := range filters.Tags {
-			tagConditions[i] = fmt.Sprintf("$%d = ANY(tags)", argIndex)
-			args = append(args, tag)
-			argIndex++
-		}
-		conditions = append(conditions, fmt.Sprintf("(%s)", strings.Join(tagConditions, " OR ")))
+	var count int
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, NewRepositoryError("Count", err, "database_error")
 	}
 
+	return count, nil
+}
+
+// Search searches problems by title, tags and description using the full-text search index.
+// Results are ranked by relevance unless filters.SortBy is set. Without any text, every problem
+// matching the filters is returned, newest first.
+func (r *problemRepository) Search(text SearchText, filters ProblemFilters) ([]*models.ProblemSearchResult, error) {
+	tsQuery, conditions, args := searchConditions(text, filters)
+
+	matchQuery := `
+		SELECT problems.id, title, slug, description, difficulty, tags, examples, constraints,
+		       template_code, state, publish_at, published_at, created_at, updated_at, ts_rank(search_vector, q.query) AS rank, q.query
+		FROM problems, to_tsquery('english', $1) AS q(query)`
+
 	// Add additional conditions
 	if len(conditions) > 0 {
-		sqlQuery += " AND " + strings.Join(conditions, " AND ")
+		matchQuery += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
-	// Add sorting
-	sortBy := "created_at"
-	if filters.SortBy != "" {
-		switch filters.SortBy {
-		case "title", "difficulty", "created_at":
-			sortBy = filters.SortBy
-		}
+	// Add sorting, most relevant first by default
+	orderBy := "rank DESC, id ASC"
+	if tsQuery == "" {
+		orderBy = "created_at DESC, id ASC"
 	}
-
-	sortOrder := "DESC"
-	if filters.SortOrder == "asc" {
-		sortOrder = "ASC"
+	switch filters.SortBy {
+	case "title", "difficulty", "created_at":
+		sortOrder := "DESC"
+		if filters.SortOrder == "asc" {
+			sortOrder = "ASC"
+		}
+		orderBy = fmt.Sprintf("%s %s, id ASC", filters.SortBy, sortOrder)
 	}
 
-	sqlQuery += fmt.Sprintf(" ORDER BY %s %s", sortBy, sortOrder)
+	matchQuery += " ORDER BY " + orderBy
 
 	// Add pagination
+	argIndex := len(args) + 1
 	if filters.Limit > 0 {
-		sqlQuery += fmt.Sprintf(" LIMIT $%d", argIndex)
+		matchQuery += fmt.Sprintf(" LIMIT $%d", argIndex)
 		args = append(args, filters.Limit)
 		argIndex++
 	}
 
 	if filters.Offset > 0 {
-		sqlQuery += fmt.Sprintf(" OFFSET $%d", argIndex)
+		matchQuery += fmt.Sprintf(" OFFSET $%d", argIndex)
 		args = append(args, filters.Offset)
 	}
 
+	// Highlights are only built for the page of results, as ts_headline reads the whole text
+	sqlQuery := fmt.Sprintf(`
+		SELECT id, title, slug, description, difficulty, tags, examples, constraints, template_code,
+		       state, publish_at, published_at, created_at, updated_at, rank,
+		       ts_headline('english', title, query, '%s, HighlightAll=true'),
+		       ts_headline('english', description, query, '%s, MaxFragments=2, MinWords=10, MaxWords=30')
+		FROM (%s) matched
+		ORDER BY %s`, searchHighlightOptions, searchHighlightOptions, matchQuery, orderBy)
+
 	rows, err := r.db.Query(sqlQuery, args...)
 	if err != nil {
 		return nil, NewRepositoryError("Search", err, "database_error")
 	}
 	defer rows.Close()
 
-	var problems []*models.Problem
+	var results []*models.ProblemSearchResult
 	for rows.Next() {
-		var problem models.Problem
+		var result models.ProblemSearchResult
 		err := rows.Scan(
-			&problem.ID,
-			&problem.Title,
-			&problem.Slug,
-			&problem.Description,
-			&problem.Difficulty,
-			&problem.Tags,
-			&problem.Examples,
-			&problem.Constraints,
-			&problem.TemplateCode,
-			&problem.CreatedAt,
-			&problem.UpdatedAt,
+			&result.ID,
+			&result.Title,
+			&result.Slug,
+			&result.Description,
+			&result.Difficulty,
+			&result.Tags,
+			&result.Examples,
+			&result.Constraints,
+			&result.TemplateCode,
+			&result.State,
+			&result.PublishAt,
+			&result.PublishedAt,
+			&result.CreatedAt,
+			&result.UpdatedAt,
+			&result.Rank,
+			&result.Highlights.Title,
+			&result.Highlights.Description,
 		)
 		if err != nil {
 			return nil, NewRepositoryError("Search", err, "scan_error")
 		}
-		problems = append(problems, &problem)
+		results = append(results, &result)
 	}
 
 	if err = rows.Err(); err != nil {
 		return nil, NewRepositoryError("Search", err, "rows_error")
 	}
 
-	return problems, nil
-}
\ No newline at end of file
+	return results, nil
+}
+
+// CountSearch returns the number of problems matching a search, ignoring pagination
+func (r *problemRepository) CountSearch(text SearchText, filters ProblemFilters) (int, error) {
+	_, conditions, args := searchConditions(text, filters)
+
+	query := `SELECT COUNT(*) FROM problems, to_tsquery('english', $1) AS q(query)`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var count int
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, NewRepositoryError("CountSearch", err, "database_error")
+	}
+
+	return count, nil
+}
+
+// searchConditions builds the WHERE conditions of a search. The tsquery is always the first
+// argument, so queries can join it as q(query) even when there is no text.
+func searchConditions(text SearchText, filters ProblemFilters) (string, []string, []interface{}) {
+	tsQuery := text.TSQuery()
+
+	conditions, args := problemFilterConditions(filters, []interface{}{tsQuery})
+	if tsQuery != "" {
+		conditions = append([]string{"search_vector @@ q.query"}, conditions...)
+	}
+
+	return tsQuery, conditions, args
+}
+
+// Annotate sets the acceptance rate of problems and, if userID is not 0, the status of the user
+// on each of them. It reads the submission counts of all problems in a single query.
+func (r *problemRepository) Annotate(problems []*models.Problem, userID int) error {
+	if len(problems) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(problems))
+	for i, problem := range problems {
+		ids[i] = int64(problem.ID)
+	}
+
+	query := `
+		SELECT ids.id, COALESCE(stats.accepted, 0), COALESCE(stats.total, 0), COALESCE(up.status, $3)
+		FROM unnest($1::int[]) AS ids(id)
+		LEFT JOIN (
+			SELECT problem_id, COUNT(*) FILTER (WHERE status = $4) AS accepted, COUNT(*) AS total
+			FROM submissions
+			WHERE problem_id = ANY($1::int[])
+			GROUP BY problem_id
+		) stats ON stats.problem_id = ids.id
+		LEFT JOIN user_progress up ON up.problem_id = ids.id AND up.user_id = $2`
+
+	rows, err := r.db.Query(query, pq.Array(ids), userID, models.ProgressStatusUnsolved, models.StatusAccepted)
+	if err != nil {
+		return NewRepositoryError("Annotate", err, "database_error")
+	}
+	defer rows.Close()
+
+	type annotation struct {
+		acceptanceRate float64
+		status         string
+	}
+	annotations := make(map[int]annotation, len(problems))
+	for rows.Next() {
+		var id, accepted, total int
+		var status string
+		if err := rows.Scan(&id, &accepted, &total, &status); err != nil {
+			return NewRepositoryError("Annotate", err, "scan_error")
+		}
+		annotations[id] = annotation{acceptanceRate: acceptanceRate(accepted, total), status: status}
+	}
+
+	if err := rows.Err(); err != nil {
+		return NewRepositoryError("Annotate", err, "rows_error")
+	}
+
+	for _, problem := range problems {
+		a := annotations[problem.ID]
+		rate := a.acceptanceRate
+		problem.AcceptanceRate = &rate
+		if userID != 0 {
+			problem.UserStatus = problemStatus(a.status)
+			solved := problem.UserStatus == models.ProblemStatusSolved
+			problem.IsSolved = &solved
+		}
+	}
+
+	return nil
+}
+
+// acceptanceRate returns the percentage of accepted submissions, rounded to one decimal
+func acceptanceRate(accepted, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(1000*float64(accepted)/float64(total)) / 10
+}
+
+// problemStatus maps a progress status to the status shown in problem listings
+func problemStatus(progressStatus string) string {
+	switch progressStatus {
+	case models.ProgressStatusSolved:
+		return models.ProblemStatusSolved
+	case models.ProgressStatusAttempted:
+		return models.ProblemStatusAttempted
+	default:
+		return models.ProblemStatusNew
+	}
+}
+
+// searchHighlightOptions are the ts_headline options shared by every highlighted field
+const searchHighlightOptions = `StartSel=<mark>, StopSel=</mark>, FragmentDelimiter=" ... "`
+
+// ProblemSort returns the sort order List uses for filters, such as "created_at:desc". Cursors
+// record it so they are only used with the order they were created for.
+func ProblemSort(filters ProblemFilters) string {
+	sortBy, sortOrder := problemSortKey(filters)
+	return sortBy + ":" + strings.ToLower(sortOrder)
+}
+
+// NewProblemCursor returns the cursor of the page starting after problem in the sort order of filters
+func NewProblemCursor(problem *models.Problem, filters ProblemFilters) *pagination.Cursor {
+	cursor := &pagination.Cursor{Sort: ProblemSort(filters), ID: problem.ID}
+
+	sortBy, _ := problemSortKey(filters)
+	switch sortBy {
+	case "title":
+		cursor.Value = problem.Title
+	case "difficulty":
+		cursor.Value = problem.Difficulty
+	default:
+		cursor.Value = pagination.TimeValue(problem.CreatedAt)
+	}
+
+	return cursor
+}
+
+// problemSortKey returns the column and direction problems are sorted by, created_at DESC by default
+func problemSortKey(filters ProblemFilters) (string, string) {
+	sortBy := "created_at"
+	switch filters.SortBy {
+	case "title", "difficulty", "created_at":
+		sortBy = filters.SortBy
+	}
+
+	sortOrder := "DESC"
+	if filters.SortOrder == "asc" {
+		sortOrder = "ASC"
+	}
+
+	return sortBy, sortOrder
+}
+
+// problemCursorCondition builds the condition selecting the problems after a cursor. Rows are
+// compared on (sort column, id) so that problems sharing a sort value are neither skipped nor
+// repeated, using placeholders numbered from argIndex.
+func problemCursorCondition(cursor *pagination.Cursor, sortBy, sortOrder string, argIndex int) (string, []interface{}, error) {
+	operator := "<"
+	if sortOrder == "ASC" {
+		operator = ">"
+	}
+
+	var value interface{} = cursor.Value
+	if sortBy == "created_at" {
+		t, err := cursor.Time()
+		if err != nil {
+			return "", nil, err
+		}
+		value = t
+	}
+
+	condition := fmt.Sprintf("(%s, id) %s ($%d, $%d)", sortBy, operator, argIndex, argIndex+1)
+	return condition, []interface{}{value, cursor.ID}, nil
+}
+
+// problemFilterConditions builds the WHERE conditions for filters, numbering placeholders after
+// the arguments already in args. It returns the conditions and args with the filter values added.
+func problemFilterConditions(filters ProblemFilters, args []interface{}) ([]string, []interface{}) {
+	var conditions []string
+	placeholder := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// Apply lifecycle state filter
+	if filters.State != "" {
+		conditions = append(conditions, fmt.Sprintf("state = %s", placeholder(filters.State)))
+	}
+
+	// Apply difficulty filters
+	if len(filters.Difficulty) > 0 {
+		placeholders := make([]string, len(filters.Difficulty))
+		for i, difficulty := range filters.Difficulty {
+			placeholders[i] = placeholder(difficulty)
+		}
+		conditions = append(conditions, fmt.Sprintf("difficulty IN (%s)", strings.Join(placeholders, ",")))
+	}
+
+	// Apply tag filters, matching any of the tags
+	if len(filters.Tags) > 0 {
+		tagConditions := make([]string, len(filters.Tags))
+		for i, tag := range filters.Tags {
+			tagConditions[i] = fmt.Sprintf("%s = ANY(tags)", placeholder(tag))
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s)", strings.Join(tagConditions, " OR ")))
+	}
+
+	// Required and excluded tags ignore case and treat hyphens as spaces, so that
+	// tag:two-pointers matches "Two Pointers"
+	for _, tag := range filters.RequiredTags {
+		conditions = append(conditions, fmt.Sprintf("EXISTS (%s)", tagMatchQuery(placeholder(tag))))
+	}
+	for _, tag := range filters.ExcludedTags {
+		conditions = append(conditions, fmt.Sprintf("NOT EXISTS (%s)", tagMatchQuery(placeholder(tag))))
+	}
+
+	// Apply solved status filter; problems the user never submitted are unsolved
+	if filters.Status != "" {
+		status := fmt.Sprintf(
+			"COALESCE((SELECT up.status FROM user_progress up WHERE up.problem_id = problems.id AND up.user_id = %s), '%s')",
+			placeholder(filters.UserID), models.ProgressStatusUnsolved)
+		switch filters.Status {
+		case models.ProgressStatusUnsolved:
+			conditions = append(conditions, fmt.Sprintf("%s <> '%s'", status, models.ProgressStatusSolved))
+		case models.ProblemStatusNew:
+			conditions = append(conditions, fmt.Sprintf("%s = '%s'", status, models.ProgressStatusUnsolved))
+		default:
+			conditions = append(conditions, fmt.Sprintf("%s = %s", status, placeholder(filters.Status)))
+		}
+	}
+
+	return conditions, args
+}
+
+// tagMatchQuery selects the tags of a problem equal to the tag bound to placeholder
+func tagMatchQuery(placeholder string) string {
+	return fmt.Sprintf(
+		"SELECT 1 FROM unnest(problems.tags) AS tag WHERE lower(replace(tag, '-', ' ')) = lower(replace(%s, '-', ' '))",
+		placeholder)
+}
+
+// NewSearchText creates the search text for words typed by a user, with the last word also
+// matching as a prefix
+func NewSearchText(text string) SearchText {
+	words := SearchWords(text)
+	return SearchText{Words: words, Prefix: len(words) > 0}
+}
+
+// SearchWords splits text into lowercase words. Characters other than letters and digits
+// separate words, so words are always valid in a tsquery.
+func SearchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// IsEmpty reports whether the text has nothing to match
+func (t SearchText) IsEmpty() bool {
+	return len(t.Words) == 0 && len(t.Phrases) == 0 && len(t.Excluded) == 0
+}
+
+// TSQuery returns the text as a to_tsquery expression, or an empty string if it is empty
+func (t SearchText) TSQuery() string {
+	var parts []string
+	for i, word := range t.Words {
+		if t.Prefix && i == len(t.Words)-1 {
+			word += ":*"
+		}
+		parts = append(parts, word)
+	}
+	for _, phrase := range t.Phrases {
+		if len(phrase) > 0 {
+			parts = append(parts, tsPhrase(phrase))
+		}
+	}
+	for _, excluded := range t.Excluded {
+		if len(excluded) > 0 {
+			parts = append(parts, "!"+tsPhrase(excluded))
+		}
+	}
+
+	return strings.Join(parts, " & ")
+}
+
+// tsPhrase joins words with the tsquery followed-by operator
+func tsPhrase(words []string) string {
+	if len(words) == 1 {
+		return words[0]
+	}
+	return "(" + strings.Join(words, " <-> ") + ")"
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"leetcode-clone-backend/pkg/pagination"
+)
+
+func TestNewSearchText(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"two sum", "two & sum:*"},
+		{"  Linked   Li", "linked & li:*"},
+		{"o'reilly & (bits | !x)", "o & reilly & bits & x:*"},
+		{"3sum", "3sum:*"},
+		{"", ""},
+		{"&|!:*()", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NewSearchText(tt.text).TSQuery(); got != tt.expected {
+			t.Errorf("NewSearchText(%q).TSQuery() = %q, expected %q", tt.text, got, tt.expected)
+		}
+	}
+}
+
+func TestSearchText_TSQuery(t *testing.T) {
+	text := SearchText{
+		Words:    []string{"graph"},
+		Phrases:  [][]string{{"shortest", "path"}},
+		Excluded: [][]string{{"bfs"}, {"breadth", "first"}},
+	}
+
+	expected := "graph & (shortest <-> path) & !bfs & !(breadth <-> first)"
+	if got := text.TSQuery(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+	if text.IsEmpty() {
+		t.Error("Expected text not to be empty")
+	}
+	if !(SearchText{Prefix: true}).IsEmpty() {
+		t.Error("Expected text without words to be empty")
+	}
+}
+
+func TestProblemFilterConditions(t *testing.T) {
+	filters := ProblemFilters{
+		Difficulty:   []string{"Easy", "Hard"},
+		RequiredTags: []string{"dp", "graph"},
+		ExcludedTags: []string{"bfs"},
+		Status:       "unsolved",
+		UserID:       7,
+	}
+
+	conditions, args := problemFilterConditions(filters, []interface{}{"query"})
+
+	if len(conditions) != 5 {
+		t.Fatalf("Expected 5 conditions, got %d: %v", len(conditions), conditions)
+	}
+	if conditions[0] != "difficulty IN ($2,$3)" {
+		t.Errorf("Unexpected difficulty condition %q", conditions[0])
+	}
+	if !strings.HasPrefix(conditions[1], "EXISTS (") || !strings.Contains(conditions[1], "$4") {
+		t.Errorf("Unexpected required tag condition %q", conditions[1])
+	}
+	if !strings.HasPrefix(conditions[3], "NOT EXISTS (") || !strings.Contains(conditions[3], "$6") {
+		t.Errorf("Unexpected excluded tag condition %q", conditions[3])
+	}
+	if !strings.Contains(conditions[4], "up.user_id = $7") || !strings.HasSuffix(conditions[4], "<> 'solved'") {
+		t.Errorf("Unexpected status condition %q", conditions[4])
+	}
+
+	expectedArgs := []interface{}{"query", "Easy", "Hard", "dp", "graph", "bfs", 7}
+	if len(args) != len(expectedArgs) {
+		t.Fatalf("Expected args %v, got %v", expectedArgs, args)
+	}
+	for i := range expectedArgs {
+		if args[i] != expectedArgs[i] {
+			t.Errorf("Expected arg %d to be %v, got %v", i, expectedArgs[i], args[i])
+		}
+	}
+}
+
+func TestAcceptanceRate(t *testing.T) {
+	tests := []struct {
+		accepted, total int
+		expected        float64
+	}{
+		{0, 0, 0},
+		{1, 3, 33.3},
+		{2, 3, 66.7},
+		{5, 5, 100},
+	}
+
+	for _, tt := range tests {
+		if got := acceptanceRate(tt.accepted, tt.total); got != tt.expected {
+			t.Errorf("acceptanceRate(%d, %d) = %v, expected %v", tt.accepted, tt.total, got, tt.expected)
+		}
+	}
+}
+
+func TestProblemStatus(t *testing.T) {
+	tests := map[string]string{
+		"solved":    "solved",
+		"attempted": "attempted",
+		"unsolved":  "new",
+		"":          "new",
+	}
+
+	for progressStatus, expected := range tests {
+		if got := problemStatus(progressStatus); got != expected {
+			t.Errorf("problemStatus(%q) = %q, expected %q", progressStatus, got, expected)
+		}
+	}
+}
+
+func TestProblemCursorCondition(t *testing.T) {
+	cursor := &pagination.Cursor{Sort: "title:asc", Value: "Two Sum", ID: 7}
+	condition, args, err := problemCursorCondition(cursor, "title", "ASC", 3)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if condition != "(title, id) > ($3, $4)" {
+		t.Errorf("Unexpected condition %q", condition)
+	}
+	if len(args) != 2 || args[0] != "Two Sum" || args[1] != 7 {
+		t.Errorf("Unexpected args %v", args)
+	}
+
+	cursor = &pagination.Cursor{Sort: "created_at:desc", Value: "2024-03-01T12:00:00Z", ID: 7}
+	condition, args, err = problemCursorCondition(cursor, "created_at", "DESC", 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if condition != "(created_at, id) < ($1, $2)" {
+		t.Errorf("Unexpected condition %q", condition)
+	}
+	if _, ok := args[0].(time.Time); !ok {
+		t.Errorf("Expected a time argument, got %T", args[0])
+	}
+
+	cursor.Value = "yesterday"
+	if _, _, err := problemCursorCondition(cursor, "created_at", "DESC", 1); err == nil {
+		t.Error("Expected an error for an invalid timestamp")
+	}
+}
+
+func TestProblemSort(t *testing.T) {
+	tests := []struct {
+		filters  ProblemFilters
+		expected string
+	}{
+		{ProblemFilters{}, "created_at:desc"},
+		{ProblemFilters{SortBy: "title", SortOrder: "asc"}, "title:asc"},
+		{ProblemFilters{SortBy: "difficulty"}, "difficulty:desc"},
+	}
+
+	for _, tt := range tests {
+		if got := ProblemSort(tt.filters); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+	}
+}
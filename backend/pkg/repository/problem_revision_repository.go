@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/pagination"
+
+	"github.com/lib/pq"
+)
+
+// problemRevisionColumns are the columns of a revision without its snapshot, with the username
+// of the author from a join on users u
+const problemRevisionColumns = `r.id, r.problem_id, r.revision, r.author_id, u.username, r.action,
+	r.changed_fields, r.restored_from, r.created_at`
+
+// problemRevisionRepository implements ProblemRevisionRepository interface
+type problemRevisionRepository struct {
+	db DBTX
+}
+
+// NewProblemRevisionRepository creates a new problem revision repository
+func NewProblemRevisionRepository(db DBTX) ProblemRevisionRepository {
+	return &problemRevisionRepository{db: db}
+}
+
+// LockProblem locks the problem row until the end of the transaction, so that concurrent
+// changes to a problem record their revisions one after the other
+func (r *problemRevisionRepository) LockProblem(problemID int) error {
+	var id int
+	err := r.db.QueryRow(`SELECT id FROM problems WHERE id = $1 FOR UPDATE`, problemID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return NewRepositoryError("LockProblem", ErrNotFound, "problem_not_found")
+		}
+		return NewRepositoryError("LockProblem", err, "database_error")
+	}
+
+	return nil
+}
+
+// Create stores a new revision, numbered after the latest revision of the problem
+func (r *problemRevisionRepository) Create(revision *models.ProblemRevision) (*models.ProblemRevision, error) {
+	if revision.Snapshot == nil {
+		return nil, NewRepositoryError("Create", fmt.Errorf("revision has no snapshot"), "invalid_revision")
+	}
+
+	query := `
+		INSERT INTO problem_revisions (problem_id, revision, author_id, action, changed_fields, restored_from, snapshot)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5, $6
+		FROM problem_revisions
+		WHERE problem_id = $1
+		RETURNING id, revision, created_at`
+
+	created := *revision
+	if created.ChangedFields == nil {
+		created.ChangedFields = []string{}
+	}
+
+	err := r.db.QueryRow(
+		query,
+		revision.ProblemID,
+		revision.AuthorID,
+		revision.Action,
+		created.ChangedFields,
+		revision.RestoredFrom,
+		revision.Snapshot,
+	).Scan(&created.ID, &created.Revision, &created.CreatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" { // unique_violation
+			return nil, NewRepositoryError("Create", ErrDuplicateKey, "revision_exists")
+		}
+		return nil, NewRepositoryError("Create", err, "database_error")
+	}
+
+	return &created, nil
+}
+
+// Get retrieves a revision of a problem with its snapshot
+func (r *problemRevisionRepository) Get(problemID, revision int) (*models.ProblemRevision, error) {
+	query := `SELECT ` + problemRevisionColumns + `, r.snapshot
+		FROM problem_revisions r
+		LEFT JOIN users u ON u.id = r.author_id
+		WHERE r.problem_id = $1 AND r.revision = $2`
+
+	return r.get("Get", query, problemID, revision)
+}
+
+// GetLatest retrieves the latest revision of a problem with its snapshot
+func (r *problemRevisionRepository) GetLatest(problemID int) (*models.ProblemRevision, error) {
+	query := `SELECT ` + problemRevisionColumns + `, r.snapshot
+		FROM problem_revisions r
+		LEFT JOIN users u ON u.id = r.author_id
+		WHERE r.problem_id = $1
+		ORDER BY r.revision DESC
+		LIMIT 1`
+
+	return r.get("GetLatest", query, problemID)
+}
+
+// get runs a query selecting a single revision with its snapshot
+func (r *problemRevisionRepository) get(op, query string, args ...interface{}) (*models.ProblemRevision, error) {
+	var revision models.ProblemRevision
+	var snapshot models.ProblemSnapshot
+	err := r.db.QueryRow(query, args...).Scan(
+		&revision.ID,
+		&revision.ProblemID,
+		&revision.Revision,
+		&revision.AuthorID,
+		&revision.AuthorUsername,
+		&revision.Action,
+		&revision.ChangedFields,
+		&revision.RestoredFrom,
+		&revision.CreatedAt,
+		&snapshot,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NewRepositoryError(op, ErrNotFound, "revision_not_found")
+		}
+		return nil, NewRepositoryError(op, err, "database_error")
+	}
+	revision.Snapshot = &snapshot
+
+	return &revision, nil
+}
+
+// List retrieves the revisions of a problem without their snapshots, newest first
+func (r *problemRevisionRepository) List(filters ProblemRevisionFilters) ([]*models.ProblemRevision, error) {
+	query := `SELECT ` + problemRevisionColumns + `
+		FROM problem_revisions r
+		LEFT JOIN users u ON u.id = r.author_id
+		WHERE r.problem_id = $1`
+	args := []interface{}{filters.ProblemID}
+
+	// Add keyset pagination on the revision number, which starts after the cursor
+	if filters.Cursor != nil {
+		args = append(args, filters.Cursor.ID)
+		query += fmt.Sprintf(" AND r.revision < $%d", len(args))
+	}
+
+	query += " ORDER BY r.revision DESC"
+
+	// Add pagination
+	if filters.Limit > 0 {
+		args = append(args, filters.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	if filters.Offset > 0 && filters.Cursor == nil {
+		args = append(args, filters.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, NewRepositoryError("List", err, "database_error")
+	}
+	defer rows.Close()
+
+	revisions := []*models.ProblemRevision{}
+	for rows.Next() {
+		var revision models.ProblemRevision
+		err := rows.Scan(
+			&revision.ID,
+			&revision.ProblemID,
+			&revision.Revision,
+			&revision.AuthorID,
+			&revision.AuthorUsername,
+			&revision.Action,
+			&revision.ChangedFields,
+			&revision.RestoredFrom,
+			&revision.CreatedAt,
+		)
+		if err != nil {
+			return nil, NewRepositoryError("List", err, "scan_error")
+		}
+		revisions = append(revisions, &revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, NewRepositoryError("List", err, "rows_error")
+	}
+
+	return revisions, nil
+}
+
+// Count returns the number of revisions of a problem
+func (r *problemRevisionRepository) Count(problemID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM problem_revisions WHERE problem_id = $1`, problemID).Scan(&count)
+	if err != nil {
+		return 0, NewRepositoryError("Count", err, "database_error")
+	}
+
+	return count, nil
+}
+
+// ProblemRevisionSort is the order of revision lists, which cursors record
+const ProblemRevisionSort = "revision:desc"
+
+// NewProblemRevisionCursor returns the cursor of the page starting after revision
+func NewProblemRevisionCursor(revision *models.ProblemRevision) *pagination.Cursor {
+	return &pagination.Cursor{Sort: ProblemRevisionSort, ID: revision.Revision}
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"leetcode-clone-backend/pkg/models"
+
+	"github.com/lib/pq"
+)
+
+// problemSolutionRepository implements ProblemSolutionRepository interface
+type problemSolutionRepository struct {
+	db DBTX
+}
+
+// NewProblemSolutionRepository creates a new problem solution repository
+func NewProblemSolutionRepository(db DBTX) ProblemSolutionRepository {
+	return &problemSolutionRepository{db: db}
+}
+
+// GetByProblemID retrieves the reference solutions of a problem, ordered by name
+func (r *problemSolutionRepository) GetByProblemID(problemID int) ([]*models.ProblemSolution, error) {
+	query := `
+		SELECT id, problem_id, name, language, code, created_at, updated_at
+		FROM problem_solutions
+		WHERE problem_id = $1
+		ORDER BY name ASC`
+
+	rows, err := r.db.Query(query, problemID)
+	if err != nil {
+		return nil, NewRepositoryError("GetByProblemID", err, "database_error")
+	}
+	defer rows.Close()
+
+	var solutions []*models.ProblemSolution
+	for rows.Next() {
+		var solution models.ProblemSolution
+		err := rows.Scan(
+			&solution.ID,
+			&solution.ProblemID,
+			&solution.Name,
+			&solution.Language,
+			&solution.Code,
+			&solution.CreatedAt,
+			&solution.UpdatedAt,
+		)
+		if err != nil {
+			return nil, NewRepositoryError("GetByProblemID", err, "scan_error")
+		}
+		solutions = append(solutions, &solution)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, NewRepositoryError("GetByProblemID", err, "rows_error")
+	}
+
+	return solutions, nil
+}
+
+// Replace replaces the reference solutions of a problem. Solutions keep their ID and creation
+// time when a solution of the same name is replaced.
+func (r *problemSolutionRepository) Replace(problemID int, solutions []*models.ProblemSolution) ([]*models.ProblemSolution, error) {
+	names := make([]string, len(solutions))
+	for i, solution := range solutions {
+		names[i] = solution.Name
+	}
+
+	var replaced []*models.ProblemSolution
+	err := inTx(r.db, func(tx DBTX) error {
+		_, err := tx.Exec(`DELETE FROM problem_solutions WHERE problem_id = $1 AND NOT (name = ANY($2::text[]))`,
+			problemID, pq.Array(names))
+		if err != nil {
+			return NewRepositoryError("Replace", err, "database_error")
+		}
+
+		query := `
+			INSERT INTO problem_solutions (problem_id, name, language, code)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (problem_id, name) DO UPDATE
+			SET language = EXCLUDED.language, code = EXCLUDED.code
+			RETURNING id, problem_id, name, language, code, created_at, updated_at`
+
+		for _, solution := range solutions {
+			var saved models.ProblemSolution
+			err := tx.QueryRow(query, problemID, solution.Name, solution.Language, solution.Code).Scan(
+				&saved.ID,
+				&saved.ProblemID,
+				&saved.Name,
+				&saved.Language,
+				&saved.Code,
+				&saved.CreatedAt,
+				&saved.UpdatedAt,
+			)
+			if err != nil {
+				return NewRepositoryError("Replace", err, "database_error")
+			}
+			replaced = append(replaced, &saved)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return replaced, nil
+}
@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+// rejudgeRepository implements RejudgeRepository interface
+type rejudgeRepository struct {
+	db DBTX
+}
+
+// NewRejudgeRepository creates a new rejudge repository
+func NewRejudgeRepository(db DBTX) RejudgeRepository {
+	return &rejudgeRepository{db: db}
+}
+
+const rejudgeJobColumns = `id, scope, submission_id, problem_id, from_time, to_time, status,
+		       total, processed, changed, failed, error_message, created_by,
+		       created_at, started_at, finished_at`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanRejudgeJob scans a single rejudge job row
+func scanRejudgeJob(row rowScanner) (*models.RejudgeJob, error) {
+	var job models.RejudgeJob
+	var errorMessage sql.NullString
+
+	err := row.Scan(
+		&job.ID,
+		&job.Scope,
+		&job.SubmissionID,
+		&job.ProblemID,
+		&job.FromTime,
+		&job.ToTime,
+		&job.Status,
+		&job.Total,
+		&job.Processed,
+		&job.Changed,
+		&job.Failed,
+		&errorMessage,
+		&job.CreatedBy,
+		&job.CreatedAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if errorMessage.Valid {
+		job.ErrorMessage = &errorMessage.String
+	}
+
+	return &job, nil
+}
+
+// CreateJob creates a new rejudge job
+func (r *rejudgeRepository) CreateJob(job *models.RejudgeJob) (*models.RejudgeJob, error) {
+	status := job.Status
+	if status == "" {
+		status = models.RejudgeStatusQueued
+	}
+
+	query := `
+		INSERT INTO rejudge_jobs (scope, submission_id, problem_id, from_time, to_time, status, total, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + rejudgeJobColumns
+
+	created, err := scanRejudgeJob(r.db.QueryRow(
+		query,
+		job.Scope,
+		job.SubmissionID,
+		job.ProblemID,
+		job.FromTime,
+		job.ToTime,
+		status,
+		job.Total,
+		job.CreatedBy,
+	))
+	if err != nil {
+		return nil, NewRepositoryError("CreateJob", err, "database_error")
+	}
+
+	return created, nil
+}
+
+// GetJob retrieves a rejudge job by ID
+func (r *rejudgeRepository) GetJob(id int) (*models.RejudgeJob, error) {
+	query := `SELECT ` + rejudgeJobColumns + ` FROM rejudge_jobs WHERE id = $1`
+
+	job, err := scanRejudgeJob(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NewRepositoryError("GetJob", ErrNotFound, "rejudge_job_not_found")
+		}
+		return nil, NewRepositoryError("GetJob", err, "database_error")
+	}
+
+	return job, nil
+}
+
+// ListJobs retrieves rejudge jobs, newest first
+func (r *rejudgeRepository) ListJobs(limit, offset int) ([]*models.RejudgeJob, error) {
+	query := `SELECT ` + rejudgeJobColumns + `
+		FROM rejudge_jobs
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1 OFFSET $2`
+
+	return r.queryJobs("ListJobs", query, limit, offset)
+}
+
+// GetUnfinishedJobs retrieves queued and running jobs in creation order, so that jobs
+// interrupted by a restart can be resumed. Jobs must be claimed before they are processed.
+func (r *rejudgeRepository) GetUnfinishedJobs() ([]*models.RejudgeJob, error) {
+	query := `SELECT ` + rejudgeJobColumns + `
+		FROM rejudge_jobs
+		WHERE status IN ('queued', 'running')
+		ORDER BY created_at ASC, id ASC`
+
+	return r.queryJobs("GetUnfinishedJobs", query)
+}
+
+// ClaimJob atomically moves a job to running for the caller. Queued jobs can be claimed, and so
+// can running jobs whose heartbeat is older than leaseTimeout, since their worker is gone. A job
+// claimed by another worker, or finished, is not found. The claimed job carries a new claim
+// token, which Heartbeat and UpdateJob require, so that a worker whose job was claimed again can
+// no longer change it.
+func (r *rejudgeRepository) ClaimJob(id int, leaseTimeout time.Duration) (*models.RejudgeJob, error) {
+	token, err := newClaimToken()
+	if err != nil {
+		return nil, NewRepositoryError("ClaimJob", err, "token_error")
+	}
+
+	query := `
+		UPDATE rejudge_jobs
+		SET status = 'running', started_at = COALESCE(started_at, CURRENT_TIMESTAMP),
+		    finished_at = NULL, error_message = NULL, heartbeat_at = CURRENT_TIMESTAMP,
+		    claim_token = $3
+		WHERE id = $1 AND (status = 'queued' OR (status = 'running' AND
+		      (heartbeat_at IS NULL OR heartbeat_at < CURRENT_TIMESTAMP - $2 * INTERVAL '1 second')))
+		RETURNING ` + rejudgeJobColumns
+
+	job, err := scanRejudgeJob(r.db.QueryRow(query, id, leaseTimeout.Seconds(), token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NewRepositoryError("ClaimJob", ErrNotFound, "rejudge_job_not_claimable")
+		}
+		return nil, NewRepositoryError("ClaimJob", err, "database_error")
+	}
+	job.ClaimToken = token
+
+	return job, nil
+}
+
+// Heartbeat renews the lease of the worker running a job. If the job was claimed again by another
+// worker, the lease is lost and a not found error is returned.
+func (r *rejudgeRepository) Heartbeat(id int, claimToken string) error {
+	query := `
+		UPDATE rejudge_jobs SET heartbeat_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND claim_token = $2 AND status = 'running'`
+
+	result, err := r.db.Exec(query, id, claimToken)
+	if err != nil {
+		return NewRepositoryError("Heartbeat", err, "database_error")
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return NewRepositoryError("Heartbeat", err, "database_error")
+	}
+	if rowsAffected == 0 {
+		return NewRepositoryError("Heartbeat", ErrNotFound, "rejudge_job_lease_lost")
+	}
+	return nil
+}
+
+// newClaimToken returns a random token identifying a claim of a rejudge job
+func newClaimToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// queryJobs runs a query returning rejudge job rows
+func (r *rejudgeRepository) queryJobs(op, query string, args ...interface{}) ([]*models.RejudgeJob, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, NewRepositoryError(op, err, "database_error")
+	}
+	defer rows.Close()
+
+	var jobs []*models.RejudgeJob
+	for rows.Next() {
+		job, err := scanRejudgeJob(rows)
+		if err != nil {
+			return nil, NewRepositoryError(op, err, "scan_error")
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, NewRepositoryError(op, err, "rows_error")
+	}
+
+	return jobs, nil
+}
+
+// UpdateJob updates the status and progress counters of a rejudge job claimed with
+// job.ClaimToken. If the job was claimed again by another worker, the lease is lost and a not
+// found error is returned.
+func (r *rejudgeRepository) UpdateJob(job *models.RejudgeJob) (*models.RejudgeJob, error) {
+	query := `
+		UPDATE rejudge_jobs
+		SET status = $2, total = $3, processed = $4, changed = $5, failed = $6,
+		    error_message = $7, started_at = $8, finished_at = $9, heartbeat_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND claim_token = $10
+		RETURNING ` + rejudgeJobColumns
+
+	updated, err := scanRejudgeJob(r.db.QueryRow(
+		query,
+		job.ID,
+		job.Status,
+		job.Total,
+		job.Processed,
+		job.Changed,
+		job.Failed,
+		job.ErrorMessage,
+		job.StartedAt,
+		job.FinishedAt,
+		job.ClaimToken,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NewRepositoryError("UpdateJob", ErrNotFound, "rejudge_job_lease_lost")
+		}
+		return nil, NewRepositoryError("UpdateJob", err, "database_error")
+	}
+	updated.ClaimToken = job.ClaimToken
+
+	return updated, nil
+}
+
+// CreateResult records the outcome of rejudging a single submission. A submission has one result
+// per job; if it already has one, a conflict error is returned.
+func (r *rejudgeRepository) CreateResult(result *models.RejudgeResult) (*models.RejudgeResult, error) {
+	query := `
+		INSERT INTO rejudge_results (job_id, submission_id, old_status, new_status,
+		                             old_test_cases_passed, new_test_cases_passed,
+		                             old_runtime_ms, new_runtime_ms, verdict_changed)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (job_id, submission_id) DO NOTHING
+		RETURNING id, created_at`
+
+	created := *result
+	err := r.db.QueryRow(
+		query,
+		result.JobID,
+		result.SubmissionID,
+		result.OldStatus,
+		result.NewStatus,
+		result.OldTestCasesPassed,
+		result.NewTestCasesPassed,
+		result.OldRuntimeMs,
+		result.NewRuntimeMs,
+		result.VerdictChanged,
+	).Scan(&created.ID, &created.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NewRepositoryError("CreateResult", ErrConflict, "rejudge_result_exists")
+		}
+		return nil, NewRepositoryError("CreateResult", err, "database_error")
+	}
+
+	return &created, nil
+}
+
+// GetResults retrieves the per-submission results of a rejudge job,
+// optionally limited to submissions whose verdict changed
+func (r *rejudgeRepository) GetResults(jobID int, changedOnly bool) ([]*models.RejudgeResult, error) {
+	query := `
+		SELECT id, job_id, submission_id, old_status, new_status,
+		       old_test_cases_passed, new_test_cases_passed,
+		       old_runtime_ms, new_runtime_ms, verdict_changed, created_at
+		FROM rejudge_results
+		WHERE job_id = $1 AND ($2 = FALSE OR verdict_changed)
+		ORDER BY id ASC`
+
+	rows, err := r.db.Query(query, jobID, changedOnly)
+	if err != nil {
+		return nil, NewRepositoryError("GetResults", err, "database_error")
+	}
+	defer rows.Close()
+
+	var results []*models.RejudgeResult
+	for rows.Next() {
+		var result models.RejudgeResult
+		err := rows.Scan(
+			&result.ID,
+			&result.JobID,
+			&result.SubmissionID,
+			&result.OldStatus,
+			&result.NewStatus,
+			&result.OldTestCasesPassed,
+			&result.NewTestCasesPassed,
+			&result.OldRuntimeMs,
+			&result.NewRuntimeMs,
+			&result.VerdictChanged,
+			&result.CreatedAt,
+		)
+		if err != nil {
+			return nil, NewRepositoryError("GetResults", err, "scan_error")
+		}
+		results = append(results, &result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, NewRepositoryError("GetResults", err, "rows_error")
+	}
+
+	return results, nil
+}
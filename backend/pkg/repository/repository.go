@@ -1,16 +1,97 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+
+	"leetcode-clone-backend/pkg/storage"
 )
 
-// NewRepository creates a new repository instance with all sub-repositories
-func NewRepository(db *sql.DB) *Repository {
+// DBTX is the subset of *sql.DB and *sql.Tx used by the repositories, so that every
+// repository can run either directly against the database or inside a transaction
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// NewRepository creates a new repository instance with all sub-repositories. Large test data
+// is kept in testData.
+func NewRepository(db *sql.DB, testData storage.Store) *Repository {
+	repo := newRepository(db, testData)
+	repo.db = db
+	return repo
+}
+
+// newRepository creates all sub-repositories on top of a database or transaction
+func newRepository(db DBTX, testData storage.Store) *Repository {
 	return &Repository{
-		User:         NewUserRepository(db),
-		Problem:      NewProblemRepository(db),
-		TestCase:     NewTestCaseRepository(db),
-		Submission:   NewSubmissionRepository(db),
-		UserProgress: NewUserProgressRepository(db),
+		User:                 NewUserRepository(db),
+		Problem:              NewProblemRepository(db),
+		TestCase:             NewTestCaseRepository(db, testData),
+		Submission:           NewSubmissionRepository(db),
+		SubmissionTestResult: NewSubmissionTestResultRepository(db),
+		UserProgress:         NewUserProgressRepository(db),
+		Rejudge:              NewRejudgeRepository(db),
+		PerformanceHistogram: NewPerformanceHistogramRepository(db),
+		CodeDraft:            NewCodeDraftRepository(db),
+		IdempotencyKey:       NewIdempotencyKeyRepository(db),
+		Tag:                  NewTagRepository(db),
+		ProblemRevision:      NewProblemRevisionRepository(db),
+		ProblemSolution:      NewProblemSolutionRepository(db),
+		JudgeSettings:        NewJudgeSettingsRepository(db),
+		TestProgram:          NewTestProgramRepository(db),
+
+		testData: testData,
+	}
+}
+
+// WithTx runs fn with repositories that share a single transaction. The transaction is
+// committed if fn returns nil and rolled back if it returns an error or panics. ctx bounds the
+// transaction: if it is done before the commit, the transaction is rolled back and the
+// statements run by fn fail.
+//
+// Calling WithTx on a transaction-scoped repository runs fn in the existing transaction,
+// so services can compose operations that use transactions themselves. Repositories
+// assembled by hand (e.g. from mocks in tests) have no database and run fn directly.
+func (r *Repository) WithTx(ctx context.Context, fn func(tx *Repository) error) error {
+	if r.db == nil {
+		return fn(r)
+	}
+
+	return runInTx(ctx, r.db, func(tx *sql.Tx) error {
+		return fn(newRepository(tx, r.testData))
+	})
+}
+
+// inTx runs fn in a transaction on db. If db is already a transaction, fn joins it.
+func inTx(db DBTX, fn func(tx DBTX) error) error {
+	sqlDB, ok := db.(*sql.DB)
+	if !ok {
+		return fn(db)
 	}
-}
\ No newline at end of file
+
+	return runInTx(context.Background(), sqlDB, func(tx *sql.Tx) error {
+		return fn(tx)
+	})
+}
+
+// runInTx begins a transaction, runs fn and commits if fn succeeds
+func runInTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return NewRepositoryError("BeginTx", err, "database_error")
+	}
+	// Rolling back after a successful commit is a no-op
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return NewRepositoryError("Commit", err, "database_error")
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// txRecorder is a database/sql driver that records the transactions and statements run on it
+type txRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *txRecorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *txRecorder) recorded() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func (r *txRecorder) Connect(ctx context.Context) (driver.Conn, error) {
+	return &txRecorderConn{r}, nil
+}
+func (r *txRecorder) Driver() driver.Driver { return nil }
+
+type txRecorderConn struct {
+	recorder *txRecorder
+}
+
+func (c *txRecorderConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+func (c *txRecorderConn) Close() error { return nil }
+
+func (c *txRecorderConn) Begin() (driver.Tx, error) {
+	c.recorder.record("begin")
+	return &txRecorderTx{c.recorder}, nil
+}
+
+func (c *txRecorderConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.recorder.record(query)
+	return driver.RowsAffected(1), nil
+}
+
+type txRecorderTx struct {
+	recorder *txRecorder
+}
+
+func (t *txRecorderTx) Commit() error {
+	t.recorder.record("commit")
+	return nil
+}
+
+func (t *txRecorderTx) Rollback() error {
+	t.recorder.record("rollback")
+	return nil
+}
+
+// newTxRecorderRepository returns a repository on a database that records what runs on it
+func newTxRecorderRepository(t *testing.T) (*Repository, *txRecorder) {
+	recorder := &txRecorder{}
+	db := sql.OpenDB(recorder)
+	t.Cleanup(func() { db.Close() })
+	return NewRepository(db, nil), recorder
+}
+
+func TestRepository_WithTx(t *testing.T) {
+	t.Run("commits when fn succeeds", func(t *testing.T) {
+		repo, recorder := newTxRecorderRepository(t)
+
+		err := repo.WithTx(context.Background(), func(tx *Repository) error {
+			if tx == repo || tx.db != nil {
+				t.Error("Expected a transaction-scoped repository")
+			}
+			return tx.Rejudge.Heartbeat(1, "token")
+		})
+
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		events := recorder.recorded()
+		if len(events) != 3 || events[0] != "begin" || events[2] != "commit" {
+			t.Errorf("Expected the statement committed in a transaction, got %q", events)
+		}
+	})
+
+	t.Run("rolls back when fn fails", func(t *testing.T) {
+		repo, recorder := newTxRecorderRepository(t)
+		fnErr := errors.New("failed")
+
+		err := repo.WithTx(context.Background(), func(tx *Repository) error {
+			return fnErr
+		})
+
+		if err != fnErr {
+			t.Errorf("Expected %v, got %v", fnErr, err)
+		}
+		if events := recorder.recorded(); !reflect.DeepEqual(events, []string{"begin", "rollback"}) {
+			t.Errorf("Expected a rollback, got %q", events)
+		}
+	})
+
+	t.Run("rolls back when fn panics", func(t *testing.T) {
+		repo, recorder := newTxRecorderRepository(t)
+
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Expected the panic to propagate")
+				}
+			}()
+			repo.WithTx(context.Background(), func(tx *Repository) error {
+				panic("boom")
+			})
+		}()
+
+		if events := recorder.recorded(); !reflect.DeepEqual(events, []string{"begin", "rollback"}) {
+			t.Errorf("Expected a rollback, got %q", events)
+		}
+	})
+
+	t.Run("nested calls join the transaction", func(t *testing.T) {
+		repo, recorder := newTxRecorderRepository(t)
+
+		err := repo.WithTx(context.Background(), func(tx *Repository) error {
+			return tx.WithTx(context.Background(), func(nested *Repository) error {
+				if nested != tx {
+					t.Error("Expected the nested call to receive the same repository")
+				}
+				return nil
+			})
+		})
+
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if events := recorder.recorded(); !reflect.DeepEqual(events, []string{"begin", "commit"}) {
+			t.Errorf("Expected a single transaction, got %q", events)
+		}
+	})
+
+	t.Run("does not begin when the context is done", func(t *testing.T) {
+		repo, recorder := newTxRecorderRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		called := false
+		err := repo.WithTx(ctx, func(tx *Repository) error {
+			called = true
+			return nil
+		})
+
+		if err == nil || called {
+			t.Errorf("Expected the transaction not to begin, got %v (fn called: %v)", err, called)
+		}
+		if events := recorder.recorded(); len(events) != 0 {
+			t.Errorf("Expected nothing run, got %q", events)
+		}
+	})
+}
+
+func TestRepository_WithTxWithoutDatabase(t *testing.T) {
+	repo := &Repository{}
+
+	t.Run("runs fn with the same repository", func(t *testing.T) {
+		var got *Repository
+		err := repo.WithTx(context.Background(), func(tx *Repository) error {
+			got = tx
+			return nil
+		})
+
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if got != repo {
+			t.Error("Expected fn to receive the repository itself")
+		}
+	})
+
+	t.Run("returns the error of fn", func(t *testing.T) {
+		fnErr := errors.New("failed")
+		err := repo.WithTx(context.Background(), func(tx *Repository) error {
+			return fnErr
+		})
+
+		if err != fnErr {
+			t.Errorf("Expected %v, got %v", fnErr, err)
+		}
+	})
+}
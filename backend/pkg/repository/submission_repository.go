@@ -2,17 +2,22 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 
 	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/pagination"
 )
 
 // submissionRepository implements SubmissionRepository interface
 type submissionRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewSubmissionRepository creates a new submission repository
-func NewSubmissionRepository(db *sql.DB) SubmissionRepository {
+func NewSubmissionRepository(db DBTX) SubmissionRepository {
 	return &submissionRepository{db: db}
 }
 
@@ -337,4 +342,257 @@ func (r *submissionRepository) GetLatestByUserAndProblem(userID, problemID int)
 	}
 
 	return &submission, nil
-}
\ No newline at end of file
+}
+
+// GetIDsForRejudge retrieves the IDs of submissions matching a rejudge scope in submission order.
+// A zero problemID matches all problems and nil bounds leave the date range open.
+func (r *submissionRepository) GetIDsForRejudge(problemID int, from, to *time.Time) ([]int, error) {
+	conditions, args := buildSubmissionConditions(SubmissionFilters{ProblemID: problemID, From: from, To: to})
+
+	query := `SELECT s.id FROM submissions s`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY s.submitted_at ASC, s.id ASC"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, NewRepositoryError("GetIDsForRejudge", err, "database_error")
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, NewRepositoryError("GetIDsForRejudge", err, "scan_error")
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, NewRepositoryError("GetIDsForRejudge", err, "rows_error")
+	}
+
+	return ids, nil
+}
+
+// List retrieves submissions matching the filters, newest first, with the title and
+// difficulty of their problem
+func (r *submissionRepository) List(filters SubmissionFilters) ([]*models.Submission, error) {
+	query := `
+		SELECT s.id, s.user_id, s.problem_id, s.language, s.code, s.status, s.runtime_ms, s.memory_kb,
+		       s.test_cases_passed, s.total_test_cases, s.error_message, s.submitted_at,
+		       p.title, p.difficulty
+		FROM submissions s
+		JOIN problems p ON p.id = s.problem_id`
+
+	conditions, args := buildSubmissionConditions(filters)
+
+	// Add keyset pagination, which starts after the cursor
+	if filters.Cursor != nil {
+		submittedAt, err := filters.Cursor.Time()
+		if err != nil {
+			return nil, NewRepositoryError("List", err, "invalid_cursor")
+		}
+		args = append(args, submittedAt, filters.Cursor.ID)
+		conditions = append(conditions, fmt.Sprintf("(s.submitted_at, s.id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query += " ORDER BY s.submitted_at DESC, s.id DESC"
+
+	// Add pagination
+	if filters.Limit > 0 {
+		args = append(args, filters.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	if filters.Offset > 0 && filters.Cursor == nil {
+		args = append(args, filters.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, NewRepositoryError("List", err, "database_error")
+	}
+	defer rows.Close()
+
+	var submissions []*models.Submission
+	for rows.Next() {
+		var submission models.Submission
+		var problem models.SubmissionProblem
+		err := rows.Scan(
+			&submission.ID,
+			&submission.UserID,
+			&submission.ProblemID,
+			&submission.Language,
+			&submission.Code,
+			&submission.Status,
+			&submission.RuntimeMs,
+			&submission.MemoryKb,
+			&submission.TestCasesPassed,
+			&submission.TotalTestCases,
+			&submission.ErrorMessage,
+			&submission.SubmittedAt,
+			&problem.Title,
+			&problem.Difficulty,
+		)
+		if err != nil {
+			return nil, NewRepositoryError("List", err, "scan_error")
+		}
+		problem.ID = submission.ProblemID
+		submission.Problem = &problem
+		submissions = append(submissions, &submission)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, NewRepositoryError("List", err, "rows_error")
+	}
+
+	return submissions, nil
+}
+
+// Count returns the number of submissions matching the filters, ignoring pagination
+func (r *submissionRepository) Count(filters SubmissionFilters) (int, error) {
+	query := `SELECT COUNT(*) FROM submissions s`
+
+	conditions, args := buildSubmissionConditions(filters)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var count int
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, NewRepositoryError("Count", err, "database_error")
+	}
+
+	return count, nil
+}
+
+// SubmissionSort is the order of submission lists, which cursors record
+const SubmissionSort = "submitted_at:desc"
+
+// NewSubmissionCursor returns the cursor of the page starting after submission
+func NewSubmissionCursor(submission *models.Submission) *pagination.Cursor {
+	return &pagination.Cursor{
+		Sort:  SubmissionSort,
+		Value: pagination.TimeValue(submission.SubmittedAt),
+		ID:    submission.ID,
+	}
+}
+
+// buildSubmissionConditions builds the WHERE conditions and arguments for submission filters.
+// Conditions refer to the submissions table through the alias "s".
+func buildSubmissionConditions(filters SubmissionFilters) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filters.UserID > 0 {
+		args = append(args, filters.UserID)
+		conditions = append(conditions, fmt.Sprintf("s.user_id = $%d", len(args)))
+	}
+	if filters.ProblemID > 0 {
+		args = append(args, filters.ProblemID)
+		conditions = append(conditions, fmt.Sprintf("s.problem_id = $%d", len(args)))
+	}
+	if filters.Status != "" {
+		args = append(args, filters.Status)
+		conditions = append(conditions, fmt.Sprintf("s.status = $%d", len(args)))
+	}
+	if filters.Language != "" {
+		args = append(args, filters.Language)
+		conditions = append(conditions, fmt.Sprintf("s.language = $%d", len(args)))
+	}
+	if filters.From != nil {
+		args = append(args, *filters.From)
+		conditions = append(conditions, fmt.Sprintf("s.submitted_at >= $%d", len(args)))
+	}
+	if filters.To != nil {
+		args = append(args, *filters.To)
+		conditions = append(conditions, fmt.Sprintf("s.submitted_at < $%d", len(args)))
+	}
+
+	return conditions, args
+}
+
+// GetStats aggregates the submission statistics of a user in a single query.
+// Daily activity covers the last activityDays days, including today, and omits days without submissions.
+func (r *submissionRepository) GetStats(userID int, activityDays int) (*models.SubmissionStats, error) {
+	query := `
+		WITH user_submissions AS (
+			SELECT status, language, runtime_ms, memory_kb, submitted_at
+			FROM submissions
+			WHERE user_id = $1
+		)
+		SELECT
+			(SELECT COUNT(*) FROM user_submissions),
+			COALESCE((SELECT json_object_agg(status, total)
+			          FROM (SELECT status, COUNT(*) AS total FROM user_submissions GROUP BY status) st), '{}'),
+			COALESCE((SELECT json_object_agg(language, total)
+			          FROM (SELECT language, COUNT(*) AS total FROM user_submissions GROUP BY language) lt), '{}'),
+			(SELECT COALESCE(AVG(runtime_ms), 0) FROM user_submissions),
+			(SELECT COALESCE(AVG(memory_kb), 0) FROM user_submissions),
+			(SELECT json_build_object(
+			            'p25', percentile_cont(0.25) WITHIN GROUP (ORDER BY runtime_ms),
+			            'p50', percentile_cont(0.50) WITHIN GROUP (ORDER BY runtime_ms),
+			            'p75', percentile_cont(0.75) WITHIN GROUP (ORDER BY runtime_ms),
+			            'p90', percentile_cont(0.90) WITHIN GROUP (ORDER BY runtime_ms),
+			            'p99', percentile_cont(0.99) WITHIN GROUP (ORDER BY runtime_ms))
+			 FROM user_submissions WHERE runtime_ms IS NOT NULL),
+			(SELECT json_build_object(
+			            'p25', percentile_cont(0.25) WITHIN GROUP (ORDER BY memory_kb),
+			            'p50', percentile_cont(0.50) WITHIN GROUP (ORDER BY memory_kb),
+			            'p75', percentile_cont(0.75) WITHIN GROUP (ORDER BY memory_kb),
+			            'p90', percentile_cont(0.90) WITHIN GROUP (ORDER BY memory_kb),
+			            'p99', percentile_cont(0.99) WITHIN GROUP (ORDER BY memory_kb))
+			 FROM user_submissions WHERE memory_kb IS NOT NULL),
+			COALESCE((SELECT json_agg(json_build_object(
+			                     'date', to_char(day, 'YYYY-MM-DD'),
+			                     'submissions', total,
+			                     'accepted', accepted) ORDER BY day)
+			          FROM (SELECT submitted_at::date AS day,
+			                       COUNT(*) AS total,
+			                       COUNT(*) FILTER (WHERE status = 'Accepted') AS accepted
+			                FROM user_submissions
+			                WHERE submitted_at >= CURRENT_DATE - ($2::int - 1)
+			                GROUP BY submitted_at::date) da), '[]')`
+
+	var stats models.SubmissionStats
+	var statusCounts, languageCounts, runtimePercentiles, memoryPercentiles, dailyActivity []byte
+	err := r.db.QueryRow(query, userID, activityDays).Scan(
+		&stats.TotalSubmissions,
+		&statusCounts,
+		&languageCounts,
+		&stats.AvgRuntimeMs,
+		&stats.AvgMemoryKb,
+		&runtimePercentiles,
+		&memoryPercentiles,
+		&dailyActivity,
+	)
+	if err != nil {
+		return nil, NewRepositoryError("GetStats", err, "database_error")
+	}
+
+	if err := json.Unmarshal(statusCounts, &stats.StatusCounts); err != nil {
+		return nil, NewRepositoryError("GetStats", err, "scan_error")
+	}
+	if err := json.Unmarshal(languageCounts, &stats.LanguageCounts); err != nil {
+		return nil, NewRepositoryError("GetStats", err, "scan_error")
+	}
+	if err := json.Unmarshal(runtimePercentiles, &stats.RuntimePercentiles); err != nil {
+		return nil, NewRepositoryError("GetStats", err, "scan_error")
+	}
+	if err := json.Unmarshal(memoryPercentiles, &stats.MemoryPercentiles); err != nil {
+		return nil, NewRepositoryError("GetStats", err, "scan_error")
+	}
+	if err := json.Unmarshal(dailyActivity, &stats.DailyActivity); err != nil {
+		return nil, NewRepositoryError("GetStats", err, "scan_error")
+	}
+
+	return &stats, nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+// submissionTestResultRepository implements SubmissionTestResultRepository interface
+type submissionTestResultRepository struct {
+	db DBTX
+}
+
+// NewSubmissionTestResultRepository creates a new submission test result repository
+func NewSubmissionTestResultRepository(db DBTX) SubmissionTestResultRepository {
+	return &submissionTestResultRepository{db: db}
+}
+
+// CreateBatch stores the results of all executed test cases for a submission in a single statement
+func (r *submissionTestResultRepository) CreateBatch(results []*models.SubmissionTestResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	const columns = 12
+	placeholders := make([]string, len(results))
+	args := make([]interface{}, 0, len(results)*columns)
+	for i, result := range results {
+		row := make([]string, columns)
+		for j := range row {
+			row[j] = fmt.Sprintf("$%d", i*columns+j+1)
+		}
+		placeholders[i] = "(" + strings.Join(row, ", ") + ")"
+		args = append(args,
+			result.SubmissionID,
+			result.TestCaseID,
+			result.Position,
+			result.Status,
+			result.Passed,
+			result.RuntimeMs,
+			result.MemoryKb,
+			result.IsHidden,
+			result.Input,
+			result.ExpectedOutput,
+			result.ActualOutput,
+			result.OutputTruncated,
+		)
+	}
+
+	query := `
+		INSERT INTO submission_test_results (submission_id, test_case_id, position, status, passed, runtime_ms,
+		                                     memory_kb, is_hidden, input, expected_output, actual_output, output_truncated)
+		VALUES ` + strings.Join(placeholders, ", ")
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return NewRepositoryError("CreateBatch", err, "database_error")
+	}
+
+	return nil
+}
+
+// GetBySubmissionID retrieves all test case results for a submission in execution order
+func (r *submissionTestResultRepository) GetBySubmissionID(submissionID int) ([]*models.SubmissionTestResult, error) {
+	query := `
+		SELECT id, submission_id, test_case_id, position, status, passed, runtime_ms, memory_kb,
+		       is_hidden, input, expected_output, actual_output, output_truncated, created_at
+		FROM submission_test_results
+		WHERE submission_id = $1
+		ORDER BY position ASC`
+
+	rows, err := r.db.Query(query, submissionID)
+	if err != nil {
+		return nil, NewRepositoryError("GetBySubmissionID", err, "database_error")
+	}
+	defer rows.Close()
+
+	var results []*models.SubmissionTestResult
+	for rows.Next() {
+		var result models.SubmissionTestResult
+		var input, expectedOutput, actualOutput sql.NullString
+		err := rows.Scan(
+			&result.ID,
+			&result.SubmissionID,
+			&result.TestCaseID,
+			&result.Position,
+			&result.Status,
+			&result.Passed,
+			&result.RuntimeMs,
+			&result.MemoryKb,
+			&result.IsHidden,
+			&input,
+			&expectedOutput,
+			&actualOutput,
+			&result.OutputTruncated,
+			&result.CreatedAt,
+		)
+		if err != nil {
+			return nil, NewRepositoryError("GetBySubmissionID", err, "scan_error")
+		}
+		result.Input = input.String
+		result.ExpectedOutput = expectedOutput.String
+		result.ActualOutput = actualOutput.String
+		results = append(results, &result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, NewRepositoryError("GetBySubmissionID", err, "rows_error")
+	}
+
+	return results, nil
+}
+
+// DeleteBySubmissionID deletes all test case results for a submission
+func (r *submissionTestResultRepository) DeleteBySubmissionID(submissionID int) error {
+	query := `DELETE FROM submission_test_results WHERE submission_id = $1`
+
+	if _, err := r.db.Exec(query, submissionID); err != nil {
+		return NewRepositoryError("DeleteBySubmissionID", err, "database_error")
+	}
+
+	return nil
+}
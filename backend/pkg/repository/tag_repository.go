@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"leetcode-clone-backend/pkg/models"
+
+	"github.com/lib/pq"
+)
+
+// tagCatalogQuery counts the problems of every tag used by a problem or listed in the tags table.
+// $1 is the user whose solved problems are counted (0 for none), $2 an optional tag name and $3
+// the lifecycle state of the problems counted (every state when empty).
+const tagCatalogQuery = `
+	WITH problem_tags AS (
+		SELECT DISTINCT p.id, p.difficulty, tag.name
+		FROM problems p
+		CROSS JOIN LATERAL unnest(p.tags) AS tag(name)
+		WHERE tag.name <> '' AND ($3::text = '' OR p.state = $3::text)
+	), counts AS (
+		SELECT pt.name,
+		       COUNT(*) AS problem_count,
+		       COUNT(*) FILTER (WHERE pt.difficulty = 'Easy') AS easy_count,
+		       COUNT(*) FILTER (WHERE pt.difficulty = 'Medium') AS medium_count,
+		       COUNT(*) FILTER (WHERE pt.difficulty = 'Hard') AS hard_count,
+		       COUNT(up.problem_id) FILTER (WHERE up.status = 'solved') AS solved_count
+		FROM problem_tags pt
+		LEFT JOIN user_progress up ON up.problem_id = pt.id AND up.user_id = $1
+		GROUP BY pt.name
+	)
+	SELECT COALESCE(c.name, t.name) AS name, t.description,
+	       COALESCE(c.problem_count, 0), COALESCE(c.easy_count, 0),
+	       COALESCE(c.medium_count, 0), COALESCE(c.hard_count, 0),
+	       COALESCE(c.solved_count, 0)
+	FROM counts c
+	FULL OUTER JOIN tags t ON t.name = c.name
+	WHERE $2::text = '' OR COALESCE(c.name, t.name) = $2::text
+	ORDER BY 3 DESC, 1 ASC`
+
+// tagRepository implements TagRepository interface
+type tagRepository struct {
+	db DBTX
+}
+
+// NewTagRepository creates a new tag repository
+func NewTagRepository(db DBTX) TagRepository {
+	return &tagRepository{db: db}
+}
+
+// List retrieves the tag catalog, most used tags first, counting the problems in state (every
+// state when empty). If userID is not 0, the number of problems the user has solved is included
+// for every tag.
+func (r *tagRepository) List(userID int, state string) ([]*models.Tag, error) {
+	tags, err := r.query(userID, "", state)
+	if err != nil {
+		return nil, NewRepositoryError("List", err, "database_error")
+	}
+
+	return tags, nil
+}
+
+// Get retrieves a single tag of the catalog, counting problems in every state
+func (r *tagRepository) Get(name string) (*models.Tag, error) {
+	tags, err := r.query(0, name, "")
+	if err != nil {
+		return nil, NewRepositoryError("Get", err, "database_error")
+	}
+
+	if len(tags) == 0 {
+		return nil, NewRepositoryError("Get", ErrNotFound, "tag_not_found")
+	}
+
+	return tags[0], nil
+}
+
+// query runs tagCatalogQuery
+func (r *tagRepository) query(userID int, name, state string) ([]*models.Tag, error) {
+	rows, err := r.db.Query(tagCatalogQuery, userID, name, state)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []*models.Tag
+	for rows.Next() {
+		var tag models.Tag
+		var solved int
+		err := rows.Scan(
+			&tag.Name,
+			&tag.Description,
+			&tag.ProblemCount,
+			&tag.EasyCount,
+			&tag.MediumCount,
+			&tag.HardCount,
+			&solved,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if userID != 0 {
+			tag.SolvedCount = &solved
+		}
+		tags = append(tags, &tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// SetDescription sets the description of a tag, adding it to the catalog if needed
+func (r *tagRepository) SetDescription(name string, description *string) error {
+	query := `
+		INSERT INTO tags (name, description)
+		VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description`
+
+	if _, err := r.db.Exec(query, name, description); err != nil {
+		return NewRepositoryError("SetDescription", err, "database_error")
+	}
+
+	return nil
+}
+
+// Merge replaces the source tags with the target tag in every problem, keeping the position of
+// the first of them and removing duplicates. The target keeps its description, or takes the
+// description of a source if it has none. It returns the number of problems changed.
+func (r *tagRepository) Merge(sources []string, target string) (int64, error) {
+	var changed int64
+
+	err := inTx(r.db, func(tx DBTX) error {
+		result, err := tx.Exec(`
+			UPDATE problems
+			SET tags = ARRAY(
+				SELECT renamed.tag
+				FROM (
+					SELECT CASE WHEN old.tag = ANY($1::text[]) THEN $2 ELSE old.tag END AS tag,
+					       MIN(old.position) AS position
+					FROM unnest(problems.tags) WITH ORDINALITY AS old(tag, position)
+					GROUP BY 1
+				) renamed
+				ORDER BY renamed.position
+			)
+			WHERE tags && $1::text[]`,
+			pq.Array(sources), target)
+		if err != nil {
+			return NewRepositoryError("Merge", err, "database_error")
+		}
+
+		if changed, err = result.RowsAffected(); err != nil {
+			return NewRepositoryError("Merge", err, "database_error")
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO tags (name, description)
+			VALUES ($2, (
+				SELECT description FROM tags
+				WHERE name = ANY($1::text[]) AND description IS NOT NULL
+				ORDER BY array_position($1::text[], name::text)
+				LIMIT 1
+			))
+			ON CONFLICT (name) DO UPDATE
+			SET description = COALESCE(tags.description, EXCLUDED.description)`,
+			pq.Array(sources), target)
+		if err != nil {
+			return NewRepositoryError("Merge", err, "database_error")
+		}
+
+		if _, err := tx.Exec(`DELETE FROM tags WHERE name = ANY($1::text[]) AND name <> $2`, pq.Array(sources), target); err != nil {
+			return NewRepositoryError("Merge", err, "database_error")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return changed, nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"database/sql"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+// testProgramRepository implements TestProgramRepository interface
+type testProgramRepository struct {
+	db DBTX
+}
+
+// NewTestProgramRepository creates a new test program repository
+func NewTestProgramRepository(db DBTX) TestProgramRepository {
+	return &testProgramRepository{db: db}
+}
+
+// GetByProblemID retrieves the test programs of a problem, ordered by kind
+func (r *testProgramRepository) GetByProblemID(problemID int) ([]*models.TestProgram, error) {
+	query := `
+		SELECT problem_id, kind, language, code, created_at, updated_at
+		FROM test_programs
+		WHERE problem_id = $1
+		ORDER BY kind ASC`
+
+	rows, err := r.db.Query(query, problemID)
+	if err != nil {
+		return nil, NewRepositoryError("GetByProblemID", err, "database_error")
+	}
+	defer rows.Close()
+
+	var programs []*models.TestProgram
+	for rows.Next() {
+		var program models.TestProgram
+		err := rows.Scan(
+			&program.ProblemID,
+			&program.Kind,
+			&program.Language,
+			&program.Code,
+			&program.CreatedAt,
+			&program.UpdatedAt,
+		)
+		if err != nil {
+			return nil, NewRepositoryError("GetByProblemID", err, "scan_error")
+		}
+		programs = append(programs, &program)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, NewRepositoryError("GetByProblemID", err, "rows_error")
+	}
+
+	return programs, nil
+}
+
+// Get retrieves the test program of a kind of a problem
+func (r *testProgramRepository) Get(problemID int, kind string) (*models.TestProgram, error) {
+	query := `
+		SELECT problem_id, kind, language, code, created_at, updated_at
+		FROM test_programs
+		WHERE problem_id = $1 AND kind = $2`
+
+	var program models.TestProgram
+	err := r.db.QueryRow(query, problemID, kind).Scan(
+		&program.ProblemID,
+		&program.Kind,
+		&program.Language,
+		&program.Code,
+		&program.CreatedAt,
+		&program.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NewRepositoryError("Get", ErrNotFound, "test_program_not_found")
+		}
+		return nil, NewRepositoryError("Get", err, "database_error")
+	}
+
+	return &program, nil
+}
+
+// Upsert creates or replaces the test program of a kind of a problem
+func (r *testProgramRepository) Upsert(program *models.TestProgram) (*models.TestProgram, error) {
+	query := `
+		INSERT INTO test_programs (problem_id, kind, language, code)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (problem_id, kind) DO UPDATE
+		SET language = EXCLUDED.language, code = EXCLUDED.code
+		RETURNING problem_id, kind, language, code, created_at, updated_at`
+
+	var saved models.TestProgram
+	err := r.db.QueryRow(query, program.ProblemID, program.Kind, program.Language, program.Code).Scan(
+		&saved.ProblemID,
+		&saved.Kind,
+		&saved.Language,
+		&saved.Code,
+		&saved.CreatedAt,
+		&saved.UpdatedAt,
+	)
+	if err != nil {
+		return nil, NewRepositoryError("Upsert", err, "database_error")
+	}
+
+	return &saved, nil
+}
+
+// Delete deletes the test program of a kind of a problem
+func (r *testProgramRepository) Delete(problemID int, kind string) error {
+	query := `DELETE FROM test_programs WHERE problem_id = $1 AND kind = $2`
+
+	result, err := r.db.Exec(query, problemID, kind)
+	if err != nil {
+		return NewRepositoryError("Delete", err, "database_error")
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return NewRepositoryError("Delete", err, "database_error")
+	}
+
+	if rowsAffected == 0 {
+		return NewRepositoryError("Delete", ErrNotFound, "test_program_not_found")
+	}
+
+	return nil
+}
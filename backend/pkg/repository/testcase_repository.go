@@ -2,66 +2,207 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
 
 	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/storage"
 )
 
 // testCaseRepository implements TestCaseRepository interface
 type testCaseRepository struct {
-	db *sql.DB
+	db       DBTX
+	testData storage.Store
 }
 
-// NewTestCaseRepository creates a new test case repository
-func NewTestCaseRepository(db *sql.DB) TestCaseRepository {
-	return &testCaseRepository{db: db}
+// NewTestCaseRepository creates a new test case repository. Test data larger than
+// models.MaxInlineTestDataSize is kept in testData; if testData is nil, all test data is kept in
+// the database.
+func NewTestCaseRepository(db DBTX, testData storage.Store) TestCaseRepository {
+	return &testCaseRepository{db: db, testData: testData}
 }
 
-// Create creates a new test case
+const testCaseSummaryColumns = `id, problem_id, input_hash, input_size, input_preview,
+		       output_hash, output_size, output_preview, is_hidden, created_at, output_status, output_checked_at`
+
+const testCaseColumns = `id, problem_id, input, expected_output, input_hash, input_size, input_preview,
+		       output_hash, output_size, output_preview, is_hidden, created_at, output_status, output_checked_at`
+
+// testDataLoad selects the test data read with test cases
+type testDataLoad int
+
+const (
+	withoutTestData    testDataLoad = iota // Hash, size and preview only
+	withInlineTestData                     // Data kept in the database; data in the store is left out
+	withAllTestData                        // Data kept in the database and in the store
+)
+
+// scanTestCase scans a single test case row, selected with testCaseColumns if load includes
+// data and testCaseSummaryColumns otherwise
+func scanTestCase(row rowScanner, load testDataLoad) (*models.TestCase, error) {
+	var testCase models.TestCase
+	var input, expectedOutput sql.NullString
+
+	dest := []interface{}{&testCase.ID, &testCase.ProblemID}
+	if load != withoutTestData {
+		dest = append(dest, &input, &expectedOutput)
+	}
+	dest = append(dest,
+		&testCase.InputHash,
+		&testCase.InputSize,
+		&testCase.InputPreview,
+		&testCase.OutputHash,
+		&testCase.OutputSize,
+		&testCase.OutputPreview,
+		&testCase.IsHidden,
+		&testCase.CreatedAt,
+		&testCase.OutputStatus,
+		&testCase.OutputCheckedAt,
+	)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	if load != withoutTestData {
+		testCase.Input, testCase.InputStored = input.String, !input.Valid
+		testCase.ExpectedOutput, testCase.OutputStored = expectedOutput.String, !expectedOutput.Valid
+	}
+
+	return &testCase, nil
+}
+
+// testDataColumns is test data as written to a test case row
+type testDataColumns struct {
+	data    string
+	value   sql.NullString // NULL when the data is kept in the test data store
+	hash    string
+	size    int64
+	preview string
+}
+
+// prepareTestData computes the hash, size and preview of test data and moves large data to the
+// test data store. Data given by its hash alone, as in restored revisions, is read from the store.
+func (r *testCaseRepository) prepareTestData(data, hash string) (*testDataColumns, error) {
+	if data == "" && hash != "" {
+		stored, err := r.readTestData(hash)
+		if err != nil {
+			return nil, err
+		}
+		return &testDataColumns{
+			data:    stored,
+			hash:    hash,
+			size:    int64(len(stored)),
+			preview: models.TestDataPreview(stored),
+		}, nil
+	}
+
+	columns := &testDataColumns{
+		data:    data,
+		value:   sql.NullString{String: data, Valid: true},
+		hash:    storage.Hash([]byte(data)),
+		size:    int64(len(data)),
+		preview: models.TestDataPreview(data),
+	}
+	if r.testData != nil && len(data) > models.MaxInlineTestDataSize {
+		if _, err := r.testData.Put([]byte(data)); err != nil {
+			return nil, err
+		}
+		columns.value = sql.NullString{}
+	}
+
+	return columns, nil
+}
+
+// readTestData reads test data from the test data store
+func (r *testCaseRepository) readTestData(hash string) (string, error) {
+	if r.testData == nil {
+		return "", fmt.Errorf("test data %s is kept in the test data store, which is not configured", hash)
+	}
+
+	data, err := r.testData.Get(hash)
+	if err != nil {
+		return "", fmt.Errorf("failed to read test data %s: %w", hash, err)
+	}
+
+	return string(data), nil
+}
+
+// loadStoredData reads the data of a test case kept in the test data store
+func (r *testCaseRepository) loadStoredData(testCase *models.TestCase) error {
+	var err error
+	if testCase.InputStored {
+		if testCase.Input, err = r.readTestData(testCase.InputHash); err != nil {
+			return err
+		}
+	}
+	if testCase.OutputStored {
+		if testCase.ExpectedOutput, err = r.readTestData(testCase.OutputHash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Create creates a new test case. Its expected output is unverified unless an output status is
+// given.
 func (r *testCaseRepository) Create(testCase *models.TestCase) (*models.TestCase, error) {
+	input, err := r.prepareTestData(testCase.Input, testCase.InputHash)
+	if err != nil {
+		return nil, NewRepositoryError("Create", err, "storage_error")
+	}
+	output, err := r.prepareTestData(testCase.ExpectedOutput, testCase.OutputHash)
+	if err != nil {
+		return nil, NewRepositoryError("Create", err, "storage_error")
+	}
+
+	status := testCase.OutputStatus
+	if status == "" {
+		status = models.OutputStatusUnverified
+	}
+
 	query := `
-		INSERT INTO test_cases (problem_id, input, expected_output, is_hidden)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, problem_id, input, expected_output, is_hidden, created_at`
+		INSERT INTO test_cases (problem_id, input, expected_output, input_hash, input_size, input_preview,
+		                        output_hash, output_size, output_preview, is_hidden, output_status, output_checked_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, CASE WHEN $12 THEN CURRENT_TIMESTAMP END)
+		RETURNING ` + testCaseSummaryColumns
 
-	var created models.TestCase
-	err := r.db.QueryRow(
+	created, err := scanTestCase(r.db.QueryRow(
 		query,
 		testCase.ProblemID,
-		testCase.Input,
-		testCase.ExpectedOutput,
+		input.value,
+		output.value,
+		input.hash,
+		input.size,
+		input.preview,
+		output.hash,
+		output.size,
+		output.preview,
 		testCase.IsHidden,
-	).Scan(
-		&created.ID,
-		&created.ProblemID,
-		&created.Input,
-		&created.ExpectedOutput,
-		&created.IsHidden,
-		&created.CreatedAt,
-	)
+		status,
+		status != models.OutputStatusUnverified,
+	), withoutTestData)
 
 	if err != nil {
 		return nil, NewRepositoryError("Create", err, "database_error")
 	}
 
-	return &created, nil
+	setTestData(created, input, output)
+	return created, nil
 }
 
-// GetByID retrieves a test case by ID
+// setTestData sets the data of a test case written with input and output
+func setTestData(testCase *models.TestCase, input, output *testDataColumns) {
+	testCase.Input, testCase.InputStored = input.data, !input.value.Valid
+	testCase.ExpectedOutput, testCase.OutputStored = output.data, !output.value.Valid
+}
+
+// GetByID retrieves a test case by ID with its data
 func (r *testCaseRepository) GetByID(id int) (*models.TestCase, error) {
 	query := `
-		SELECT id, problem_id, input, expected_output, is_hidden, created_at
+		SELECT ` + testCaseColumns + `
 		FROM test_cases
 		WHERE id = $1`
 
-	var testCase models.TestCase
-	err := r.db.QueryRow(query, id).Scan(
-		&testCase.ID,
-		&testCase.ProblemID,
-		&testCase.Input,
-		&testCase.ExpectedOutput,
-		&testCase.IsHidden,
-		&testCase.CreatedAt,
-	)
+	testCase, err := scanTestCase(r.db.QueryRow(query, id), withAllTestData)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -70,109 +211,120 @@ func (r *testCaseRepository) GetByID(id int) (*models.TestCase, error) {
 		return nil, NewRepositoryError("GetByID", err, "database_error")
 	}
 
-	return &testCase, nil
+	if err := r.loadStoredData(testCase); err != nil {
+		return nil, NewRepositoryError("GetByID", err, "storage_error")
+	}
+
+	return testCase, nil
 }
 
-// GetByProblemID retrieves all test cases for a problem
+// GetByProblemID retrieves all test cases for a problem with their data
 func (r *testCaseRepository) GetByProblemID(problemID int) ([]*models.TestCase, error) {
-	query := `
-		SELECT id, problem_id, input, expected_output, is_hidden, created_at
-		FROM test_cases
-		WHERE problem_id = $1
-		ORDER BY id ASC`
+	return r.list("GetByProblemID", problemID, false, withAllTestData)
+}
 
-	rows, err := r.db.Query(query, problemID)
-	if err != nil {
-		return nil, NewRepositoryError("GetByProblemID", err, "database_error")
-	}
-	defer rows.Close()
+// GetPublicByProblemID retrieves only public (non-hidden) test cases for a problem with their data
+func (r *testCaseRepository) GetPublicByProblemID(problemID int) ([]*models.TestCase, error) {
+	return r.list("GetPublicByProblemID", problemID, true, withAllTestData)
+}
 
-	var testCases []*models.TestCase
-	for rows.Next() {
-		var testCase models.TestCase
-		err := rows.Scan(
-			&testCase.ID,
-			&testCase.ProblemID,
-			&testCase.Input,
-			&testCase.ExpectedOutput,
-			&testCase.IsHidden,
-			&testCase.CreatedAt,
-		)
-		if err != nil {
-			return nil, NewRepositoryError("GetByProblemID", err, "scan_error")
-		}
-		testCases = append(testCases, &testCase)
-	}
+// GetInlineByProblemID retrieves all test cases for a problem with the data kept in the
+// database, leaving out the data kept in the test data store
+func (r *testCaseRepository) GetInlineByProblemID(problemID int) ([]*models.TestCase, error) {
+	return r.list("GetInlineByProblemID", problemID, false, withInlineTestData)
+}
 
-	if err = rows.Err(); err != nil {
-		return nil, NewRepositoryError("GetByProblemID", err, "rows_error")
-	}
+// ListByProblemID retrieves all test cases for a problem without their data
+func (r *testCaseRepository) ListByProblemID(problemID int) ([]*models.TestCase, error) {
+	return r.list("ListByProblemID", problemID, false, withoutTestData)
+}
 
-	return testCases, nil
+// ListPublicByProblemID retrieves only public (non-hidden) test cases for a problem without
+// their data
+func (r *testCaseRepository) ListPublicByProblemID(problemID int) ([]*models.TestCase, error) {
+	return r.list("ListPublicByProblemID", problemID, true, withoutTestData)
 }
 
-// GetPublicByProblemID retrieves only public (non-hidden) test cases for a problem
-func (r *testCaseRepository) GetPublicByProblemID(problemID int) ([]*models.TestCase, error) {
+// list retrieves the test cases of a problem in ID order, reading the data selected by load
+func (r *testCaseRepository) list(op string, problemID int, publicOnly bool, load testDataLoad) ([]*models.TestCase, error) {
+	columns := testCaseColumns
+	if load == withoutTestData {
+		columns = testCaseSummaryColumns
+	}
 	query := `
-		SELECT id, problem_id, input, expected_output, is_hidden, created_at
+		SELECT ` + columns + `
 		FROM test_cases
-		WHERE problem_id = $1 AND is_hidden = false
+		WHERE problem_id = $1`
+	if publicOnly {
+		query += ` AND is_hidden = false`
+	}
+	query += `
 		ORDER BY id ASC`
 
 	rows, err := r.db.Query(query, problemID)
 	if err != nil {
-		return nil, NewRepositoryError("GetPublicByProblemID", err, "database_error")
+		return nil, NewRepositoryError(op, err, "database_error")
 	}
 	defer rows.Close()
 
 	var testCases []*models.TestCase
 	for rows.Next() {
-		var testCase models.TestCase
-		err := rows.Scan(
-			&testCase.ID,
-			&testCase.ProblemID,
-			&testCase.Input,
-			&testCase.ExpectedOutput,
-			&testCase.IsHidden,
-			&testCase.CreatedAt,
-		)
+		testCase, err := scanTestCase(rows, load)
 		if err != nil {
-			return nil, NewRepositoryError("GetPublicByProblemID", err, "scan_error")
+			return nil, NewRepositoryError(op, err, "scan_error")
 		}
-		testCases = append(testCases, &testCase)
+		testCases = append(testCases, testCase)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, NewRepositoryError("GetPublicByProblemID", err, "rows_error")
+		return nil, NewRepositoryError(op, err, "rows_error")
+	}
+
+	if load == withAllTestData {
+		for _, testCase := range testCases {
+			if err := r.loadStoredData(testCase); err != nil {
+				return nil, NewRepositoryError(op, err, "storage_error")
+			}
+		}
 	}
 
 	return testCases, nil
 }
 
-// Update updates an existing test case
+// Update updates an existing test case. The output status is reset when the test data changes.
 func (r *testCaseRepository) Update(testCase *models.TestCase) (*models.TestCase, error) {
+	input, err := r.prepareTestData(testCase.Input, testCase.InputHash)
+	if err != nil {
+		return nil, NewRepositoryError("Update", err, "storage_error")
+	}
+	output, err := r.prepareTestData(testCase.ExpectedOutput, testCase.OutputHash)
+	if err != nil {
+		return nil, NewRepositoryError("Update", err, "storage_error")
+	}
+
 	query := `
 		UPDATE test_cases
-		SET problem_id = $2, input = $3, expected_output = $4, is_hidden = $5
+		SET problem_id = $2, input = $3, expected_output = $4, input_hash = $5, input_size = $6,
+		    input_preview = $7, output_hash = $8, output_size = $9, output_preview = $10, is_hidden = $11,
+		    output_status = CASE WHEN input_hash = $5 AND output_hash = $8 THEN output_status ELSE 'unverified' END,
+		    output_checked_at = CASE WHEN input_hash = $5 AND output_hash = $8 THEN output_checked_at END
 		WHERE id = $1
-		RETURNING id, problem_id, input, expected_output, is_hidden, created_at`
+		RETURNING ` + testCaseSummaryColumns
 
-	var updated models.TestCase
-	err := r.db.QueryRow(
+	updated, err := scanTestCase(r.db.QueryRow(
 		query,
 		testCase.ID,
 		testCase.ProblemID,
-		testCase.Input,
-		testCase.ExpectedOutput,
+		input.value,
+		output.value,
+		input.hash,
+		input.size,
+		input.preview,
+		output.hash,
+		output.size,
+		output.preview,
 		testCase.IsHidden,
-	).Scan(
-		&updated.ID,
-		&updated.ProblemID,
-		&updated.Input,
-		&updated.ExpectedOutput,
-		&updated.IsHidden,
-		&updated.CreatedAt,
-	)
+	), withoutTestData)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -181,10 +333,50 @@ func (r *testCaseRepository) Update(testCase *models.TestCase) (*models.TestCase
 		return nil, NewRepositoryError("Update", err, "database_error")
 	}
 
-	return &updated, nil
+	setTestData(updated, input, output)
+	return updated, nil
 }
 
-// Delete deletes a test case by ID
+// SetOutputStatus records the result of checking the expected output of a test case
+func (r *testCaseRepository) SetOutputStatus(id int, status string) error {
+	query := `
+		UPDATE test_cases
+		SET output_status = $2, output_checked_at = CURRENT_TIMESTAMP
+		WHERE id = $1`
+
+	result, err := r.db.Exec(query, id, status)
+	if err != nil {
+		return NewRepositoryError("SetOutputStatus", err, "database_error")
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return NewRepositoryError("SetOutputStatus", err, "database_error")
+	}
+
+	if rowsAffected == 0 {
+		return NewRepositoryError("SetOutputStatus", ErrNotFound, "testcase_not_found")
+	}
+
+	return nil
+}
+
+// ResetOutputStatus marks the expected outputs of all test cases for a problem unverified
+func (r *testCaseRepository) ResetOutputStatus(problemID int) error {
+	query := `
+		UPDATE test_cases
+		SET output_status = 'unverified', output_checked_at = NULL
+		WHERE problem_id = $1`
+
+	if _, err := r.db.Exec(query, problemID); err != nil {
+		return NewRepositoryError("ResetOutputStatus", err, "database_error")
+	}
+
+	return nil
+}
+
+// Delete deletes a test case by ID. Its data stays in the test data store, where other test
+// cases and problem revisions may refer to it.
 func (r *testCaseRepository) Delete(id int) error {
 	query := `DELETE FROM test_cases WHERE id = $1`
 
@@ -215,4 +407,4 @@ func (r *testCaseRepository) DeleteByProblemID(problemID int) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
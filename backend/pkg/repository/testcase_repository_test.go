@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/storage"
+)
+
+func TestTestCaseRepository_PrepareTestData(t *testing.T) {
+	store := storage.NewMemoryStore()
+	repo := &testCaseRepository{testData: store}
+
+	t.Run("keeps small data in the database", func(t *testing.T) {
+		columns, err := repo.prepareTestData("1 2\n", "")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !columns.value.Valid || columns.value.String != "1 2\n" {
+			t.Errorf("Expected data in the column, got %+v", columns.value)
+		}
+		if columns.hash != storage.Hash([]byte("1 2\n")) || columns.size != 4 || columns.preview != "1 2\n" {
+			t.Errorf("Expected hash, size and preview of the data, got %+v", columns)
+		}
+		if exists, _ := store.Exists(columns.hash); exists {
+			t.Error("Expected small data not to be stored")
+		}
+	})
+
+	large := strings.Repeat("1 ", models.MaxInlineTestDataSize)
+
+	t.Run("moves large data to the store", func(t *testing.T) {
+		columns, err := repo.prepareTestData(large, "")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if columns.value.Valid {
+			t.Error("Expected NULL column for stored data")
+		}
+		if columns.size != int64(len(large)) || len(columns.preview) != models.TestDataPreviewLength {
+			t.Errorf("Expected size %d and a preview, got %d and %d characters", len(large), columns.size, len(columns.preview))
+		}
+		if stored, err := store.Get(columns.hash); err != nil || string(stored) != large {
+			t.Errorf("Expected data in the store, got error %v", err)
+		}
+	})
+
+	t.Run("reads data given by hash from the store", func(t *testing.T) {
+		columns, err := repo.prepareTestData("", storage.Hash([]byte(large)))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if columns.value.Valid || columns.data != large || columns.size != int64(len(large)) {
+			t.Errorf("Expected stored data referenced, got size %d", columns.size)
+		}
+
+		if _, err := repo.prepareTestData("", storage.Hash([]byte("missing"))); err == nil {
+			t.Error("Expected an error for data missing from the store")
+		}
+	})
+
+	t.Run("keeps all data in the database without a store", func(t *testing.T) {
+		columns, err := (&testCaseRepository{}).prepareTestData(large, "")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !columns.value.Valid {
+			t.Error("Expected data in the column")
+		}
+	})
+}
@@ -8,20 +8,25 @@ import (
 
 // userProgressRepository implements UserProgressRepository interface
 type userProgressRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewUserProgressRepository creates a new user progress repository
-func NewUserProgressRepository(db *sql.DB) UserProgressRepository {
+func NewUserProgressRepository(db DBTX) UserProgressRepository {
 	return &userProgressRepository{db: db}
 }
 
 // Create creates a new user progress record
 func (r *userProgressRepository) Create(progress *models.UserProgress) (*models.UserProgress, error) {
+	status := progress.Status
+	if status == "" {
+		status = models.ProgressStatusUnsolved
+	}
+
 	query := `
-		INSERT INTO user_progress (user_id, problem_id, is_solved, best_submission_id, attempts, first_solved_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING user_id, problem_id, is_solved, best_submission_id, attempts, first_solved_at`
+		INSERT INTO user_progress (user_id, problem_id, is_solved, best_submission_id, attempts, first_solved_at, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING user_id, problem_id, is_solved, best_submission_id, attempts, first_solved_at, status`
 
 	var created models.UserProgress
 	err := r.db.QueryRow(
@@ -32,6 +37,7 @@ func (r *userProgressRepository) Create(progress *models.UserProgress) (*models.
 		progress.BestSubmissionID,
 		progress.Attempts,
 		progress.FirstSolvedAt,
+		status,
 	).Scan(
 		&created.UserID,
 		&created.ProblemID,
@@ -39,6 +45,7 @@ func (r *userProgressRepository) Create(progress *models.UserProgress) (*models.
 		&created.BestSubmissionID,
 		&created.Attempts,
 		&created.FirstSolvedAt,
+		&created.Status,
 	)
 
 	if err != nil {
@@ -51,7 +58,7 @@ func (r *userProgressRepository) Create(progress *models.UserProgress) (*models.
 // GetByUserAndProblem retrieves user progress for a specific user and problem
 func (r *userProgressRepository) GetByUserAndProblem(userID, problemID int) (*models.UserProgress, error) {
 	query := `
-		SELECT user_id, problem_id, is_solved, best_submission_id, attempts, first_solved_at
+		SELECT user_id, problem_id, is_solved, best_submission_id, attempts, first_solved_at, status
 		FROM user_progress
 		WHERE user_id = $1 AND problem_id = $2`
 
@@ -63,6 +70,7 @@ func (r *userProgressRepository) GetByUserAndProblem(userID, problemID int) (*mo
 		&progress.BestSubmissionID,
 		&progress.Attempts,
 		&progress.FirstSolvedAt,
+		&progress.Status,
 	)
 
 	if err != nil {
@@ -75,10 +83,47 @@ func (r *userProgressRepository) GetByUserAndProblem(userID, problemID int) (*mo
 	return &progress, nil
 }
 
+// GetForUpdate retrieves user progress for a specific user and problem and locks it until the end of
+// the current transaction, creating an unsolved record first if none exists. Without a transaction
+// the lock is released immediately.
+func (r *userProgressRepository) GetForUpdate(userID, problemID int) (*models.UserProgress, error) {
+	_, err := r.db.Exec(`
+		INSERT INTO user_progress (user_id, problem_id, status)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, problem_id) DO NOTHING`,
+		userID, problemID, models.ProgressStatusUnsolved)
+	if err != nil {
+		return nil, NewRepositoryError("GetForUpdate", err, "database_error")
+	}
+
+	query := `
+		SELECT user_id, problem_id, is_solved, best_submission_id, attempts, first_solved_at, status
+		FROM user_progress
+		WHERE user_id = $1 AND problem_id = $2
+		FOR UPDATE`
+
+	var progress models.UserProgress
+	err = r.db.QueryRow(query, userID, problemID).Scan(
+		&progress.UserID,
+		&progress.ProblemID,
+		&progress.IsSolved,
+		&progress.BestSubmissionID,
+		&progress.Attempts,
+		&progress.FirstSolvedAt,
+		&progress.Status,
+	)
+
+	if err != nil {
+		return nil, NewRepositoryError("GetForUpdate", err, "database_error")
+	}
+
+	return &progress, nil
+}
+
 // GetByUserID retrieves all progress records for a user
 func (r *userProgressRepository) GetByUserID(userID int) ([]*models.UserProgress, error) {
 	query := `
-		SELECT user_id, problem_id, is_solved, best_submission_id, attempts, first_solved_at
+		SELECT user_id, problem_id, is_solved, best_submission_id, attempts, first_solved_at, status
 		FROM user_progress
 		WHERE user_id = $1
 		ORDER BY first_solved_at DESC NULLS LAST, problem_id ASC`
@@ -99,6 +144,7 @@ func (r *userProgressRepository) GetByUserID(userID int) ([]*models.UserProgress
 			&progress.BestSubmissionID,
 			&progress.Attempts,
 			&progress.FirstSolvedAt,
+			&progress.Status,
 		)
 		if err != nil {
 			return nil, NewRepositoryError("GetByUserID", err, "scan_error")
@@ -117,9 +163,9 @@ func (r *userProgressRepository) GetByUserID(userID int) ([]*models.UserProgress
 func (r *userProgressRepository) Update(progress *models.UserProgress) (*models.UserProgress, error) {
 	query := `
 		UPDATE user_progress
-		SET is_solved = $3, best_submission_id = $4, attempts = $5, first_solved_at = $6
+		SET is_solved = $3, best_submission_id = $4, attempts = $5, first_solved_at = $6, status = $7
 		WHERE user_id = $1 AND problem_id = $2
-		RETURNING user_id, problem_id, is_solved, best_submission_id, attempts, first_solved_at`
+		RETURNING user_id, problem_id, is_solved, best_submission_id, attempts, first_solved_at, status`
 
 	var updated models.UserProgress
 	err := r.db.QueryRow(
@@ -130,6 +176,7 @@ func (r *userProgressRepository) Update(progress *models.UserProgress) (*models.
 		progress.BestSubmissionID,
 		progress.Attempts,
 		progress.FirstSolvedAt,
+		progress.Status,
 	).Scan(
 		&updated.UserID,
 		&updated.ProblemID,
@@ -137,6 +184,7 @@ func (r *userProgressRepository) Update(progress *models.UserProgress) (*models.
 		&updated.BestSubmissionID,
 		&updated.Attempts,
 		&updated.FirstSolvedAt,
+		&updated.Status,
 	)
 
 	if err != nil {
@@ -222,4 +270,66 @@ func (r *userProgressRepository) GetSolvedCountByDifficulty(userID int) (map[str
 	}
 
 	return counts, nil
-}
\ No newline at end of file
+}
+
+// Recompute rebuilds a user's progress on a problem from the stored submissions, e.g. after
+// submissions were rejudged. The best submission is the fastest accepted submission, then the one
+// using the least memory, in the language of the first accepted submission, matching
+// models.IsBetterSubmission. Returns nil if the user has neither a progress record nor any
+// submission.
+func (r *userProgressRepository) Recompute(userID, problemID int) (*models.UserProgress, error) {
+	query := `
+		WITH stats AS (
+			SELECT COUNT(*) AS attempts,
+			       COUNT(*) FILTER (WHERE status = 'Accepted') AS accepted,
+			       MIN(submitted_at) FILTER (WHERE status = 'Accepted') AS first_solved_at
+			FROM submissions
+			WHERE user_id = $1 AND problem_id = $2
+		), first_accepted AS (
+			SELECT language
+			FROM submissions
+			WHERE user_id = $1 AND problem_id = $2 AND status = 'Accepted'
+			ORDER BY submitted_at ASC, id ASC
+			LIMIT 1
+		), best AS (
+			SELECT s.id
+			FROM submissions s
+			JOIN first_accepted fa ON fa.language = s.language
+			WHERE s.user_id = $1 AND s.problem_id = $2 AND s.status = 'Accepted'
+			ORDER BY s.runtime_ms ASC NULLS LAST, s.memory_kb ASC NULLS LAST, s.submitted_at ASC, s.id ASC
+			LIMIT 1
+		)
+		INSERT INTO user_progress (user_id, problem_id, is_solved, best_submission_id, attempts, first_solved_at, status)
+		SELECT $1, $2, accepted > 0, (SELECT id FROM best), attempts, first_solved_at,
+		       CASE WHEN accepted > 0 THEN 'solved' WHEN attempts > 0 THEN 'attempted' ELSE 'unsolved' END
+		FROM stats
+		WHERE attempts > 0
+		   OR EXISTS (SELECT 1 FROM user_progress WHERE user_id = $1 AND problem_id = $2)
+		ON CONFLICT (user_id, problem_id) DO UPDATE
+		SET is_solved = EXCLUDED.is_solved,
+		    best_submission_id = EXCLUDED.best_submission_id,
+		    attempts = EXCLUDED.attempts,
+		    first_solved_at = EXCLUDED.first_solved_at,
+		    status = EXCLUDED.status
+		RETURNING user_id, problem_id, is_solved, best_submission_id, attempts, first_solved_at, status`
+
+	var progress models.UserProgress
+	err := r.db.QueryRow(query, userID, problemID).Scan(
+		&progress.UserID,
+		&progress.ProblemID,
+		&progress.IsSolved,
+		&progress.BestSubmissionID,
+		&progress.Attempts,
+		&progress.FirstSolvedAt,
+		&progress.Status,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, NewRepositoryError("Recompute", err, "database_error")
+	}
+
+	return &progress, nil
+}
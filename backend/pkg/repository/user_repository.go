@@ -2,19 +2,21 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
 	"strings"
 
 	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/pagination"
 	"github.com/lib/pq"
 )
 
 // userRepository implements UserRepository interface
 type userRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewUserRepository creates a new user repository
-func NewUserRepository(db *sql.DB) UserRepository {
+func NewUserRepository(db DBTX) UserRepository {
 	return &userRepository{db: db}
 }
 
@@ -201,21 +203,44 @@ func (r *userRepository) Delete(id int) error {
 	return nil
 }
 
-// List retrieves a list of users with pagination
-func (r *userRepository) List(limit, offset int) ([]*models.User, error) {
+// List retrieves a page of users, newest first
+func (r *userRepository) List(filters UserFilters) ([]*models.User, error) {
 	query := `
 		SELECT id, username, email, password_hash, is_admin, created_at, updated_at
-		FROM users
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2`
+		FROM users`
+
+	var args []interface{}
+
+	// Add keyset pagination, which starts after the cursor
+	if filters.Cursor != nil {
+		createdAt, err := filters.Cursor.Time()
+		if err != nil {
+			return nil, NewRepositoryError("List", err, "invalid_cursor")
+		}
+		args = append(args, createdAt, filters.Cursor.ID)
+		query += " WHERE (created_at, id) < ($1, $2)"
+	}
+
+	query += " ORDER BY created_at DESC, id DESC"
+
+	// Add pagination
+	if filters.Limit > 0 {
+		args = append(args, filters.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	if filters.Offset > 0 && filters.Cursor == nil {
+		args = append(args, filters.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
 
-	rows, err := r.db.Query(query, limit, offset)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, NewRepositoryError("List", err, "database_error")
 	}
 	defer rows.Close()
 
-	var users []*models.User
+	users := []*models.User{}
 	for rows.Next() {
 		var user models.User
 		err := rows.Scan(
@@ -238,4 +263,26 @@ func (r *userRepository) List(limit, offset int) ([]*models.User, error) {
 	}
 
 	return users, nil
-}
\ No newline at end of file
+}
+
+// Count returns the number of users
+func (r *userRepository) Count() (int, error) {
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		return 0, NewRepositoryError("Count", err, "database_error")
+	}
+
+	return count, nil
+}
+
+// UserSort is the order of user lists, which cursors record
+const UserSort = "created_at:desc"
+
+// NewUserCursor returns the cursor of the page starting after user
+func NewUserCursor(user *models.User) *pagination.Cursor {
+	return &pagination.Cursor{
+		Sort:  UserSort,
+		Value: pagination.TimeValue(user.CreatedAt),
+		ID:    user.ID,
+	}
+}
@@ -172,7 +172,7 @@ func TestUserRepository_List(t *testing.T) {
 
 	repo := NewUserRepository(db)
 
-	users, err := repo.List(10, 0)
+	users, err := repo.List(UserFilters{Limit: 10})
 	if err != nil {
 		t.Fatalf("Failed to list users: %v", err)
 	}
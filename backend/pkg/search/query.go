@@ -0,0 +1,261 @@
+// Package search parses the query language of the problem search. A query mixes filters and
+// free text, for example
+//
+//	tag:dp tag:graph difficulty:hard status:unsolved "shortest path" -tag:bfs
+//
+// Filters are written key:value, with the value quoted if it contains spaces. Every tag filter
+// must match, difficulty filters match any of the difficulties and a leading "-" excludes a tag,
+// word or phrase. Anything else is free text matched against the problem contents.
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+)
+
+// Filter keys
+const (
+	KeyTag        = "tag"
+	KeyDifficulty = "difficulty"
+	KeyStatus     = "status"
+)
+
+// Query is a parsed search query
+type Query struct {
+	Text         repository.SearchText
+	Difficulty   []string // Problems with any of the difficulties
+	RequiredTags []string // Problems with all of the tags
+	ExcludedTags []string // Problems with none of the tags
+	Status       string   // Solved status of the user searching
+}
+
+// HasFilters reports whether the query has any filter besides free text
+func (q *Query) HasFilters() bool {
+	return len(q.Difficulty) > 0 || len(q.RequiredTags) > 0 || len(q.ExcludedTags) > 0 || q.Status != ""
+}
+
+// ParseError is an error in a query, with the position of the term that caused it
+type ParseError struct {
+	Position int    `json:"position"` // 0-based offset in characters
+	Message  string `json:"message"`
+}
+
+// Error implements the error interface
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Position)
+}
+
+// difficulties maps lowercase difficulty names to their stored values
+var difficulties = map[string]string{
+	"easy":   models.DifficultyEasy,
+	"medium": models.DifficultyMedium,
+	"hard":   models.DifficultyHard,
+}
+
+// statuses lists the values of the status filter
+var statuses = map[string]bool{
+	models.ProgressStatusSolved:    true,
+	models.ProgressStatusAttempted: true,
+	models.ProgressStatusUnsolved:  true,
+	models.ProblemStatusNew:        true,
+}
+
+// parser holds the state of a query being parsed
+type parser struct {
+	input  []rune
+	pos    int
+	query  *Query
+	prefix bool // the last term is a word typed up to the end of the input
+}
+
+// Parse parses a search query. The last word of the free text also matches as a prefix, unless
+// the query ends with a space, so results can be shown while the user types.
+func Parse(input string) (*Query, error) {
+	p := &parser{input: []rune(input), query: &Query{}}
+
+	for {
+		p.skipSpace()
+		if p.eof() {
+			break
+		}
+		if err := p.parseTerm(); err != nil {
+			return nil, err
+		}
+	}
+
+	p.query.Text.Prefix = p.prefix && len(p.query.Text.Words) > 0
+	return p.query, nil
+}
+
+// parseTerm parses a filter, phrase or word, optionally negated
+func (p *parser) parseTerm() error {
+	start := p.pos
+	negated := false
+	if p.peek() == '-' && p.pos+1 < len(p.input) && !unicode.IsSpace(p.input[p.pos+1]) {
+		negated = true
+		p.pos++
+	}
+	p.prefix = false
+
+	if p.peek() == '"' {
+		phrase, err := p.readQuoted()
+		if err != nil {
+			return err
+		}
+		words := repository.SearchWords(phrase)
+		if len(words) == 0 {
+			return &ParseError{Position: start, Message: "phrase has no words"}
+		}
+		if negated {
+			p.query.Text.Excluded = append(p.query.Text.Excluded, words)
+		} else {
+			p.query.Text.Phrases = append(p.query.Text.Phrases, words)
+		}
+		return nil
+	}
+
+	keyStart := p.pos
+	key := p.readWhile(unicode.IsLetter)
+	if key != "" && p.peek() == ':' {
+		p.pos++
+		return p.parseFilter(start, keyStart, strings.ToLower(key), negated)
+	}
+
+	// Free text runs to the next space or quote; words without letters or digits are ignored
+	p.readWhile(func(r rune) bool { return !unicode.IsSpace(r) && r != '"' })
+	words := repository.SearchWords(string(p.input[keyStart:p.pos]))
+	if len(words) == 0 {
+		return nil
+	}
+	if negated {
+		p.query.Text.Excluded = append(p.query.Text.Excluded, words)
+	} else {
+		p.query.Text.Words = append(p.query.Text.Words, words...)
+		p.prefix = p.eof()
+	}
+
+	return nil
+}
+
+// parseFilter parses the value of a key: filter
+func (p *parser) parseFilter(start, keyStart int, key string, negated bool) error {
+	valueStart := p.pos
+	var value string
+	if p.peek() == '"' {
+		quoted, err := p.readQuoted()
+		if err != nil {
+			return err
+		}
+		value = quoted
+	} else {
+		value = p.readWhile(func(r rune) bool { return !unicode.IsSpace(r) })
+	}
+	value = strings.TrimSpace(value)
+
+	switch key {
+	case KeyTag, KeyDifficulty, KeyStatus:
+		if value == "" {
+			return &ParseError{Position: valueStart, Message: fmt.Sprintf("missing value for %s filter", key)}
+		}
+	default:
+		return &ParseError{
+			Position: keyStart,
+			Message:  fmt.Sprintf("unknown filter %q, expected %s, %s or %s", key, KeyTag, KeyDifficulty, KeyStatus),
+		}
+	}
+
+	if negated && key != KeyTag {
+		return &ParseError{Position: start, Message: fmt.Sprintf("%s filter cannot be negated", key)}
+	}
+
+	switch key {
+	case KeyTag:
+		if len(value) > models.MaxTagLength {
+			return &ParseError{
+				Position: valueStart,
+				Message:  fmt.Sprintf("tag must be %d characters or less", models.MaxTagLength),
+			}
+		}
+		if negated {
+			p.query.ExcludedTags = append(p.query.ExcludedTags, value)
+		} else {
+			p.query.RequiredTags = append(p.query.RequiredTags, value)
+		}
+
+	case KeyDifficulty:
+		difficulty, ok := difficulties[strings.ToLower(value)]
+		if !ok {
+			return &ParseError{
+				Position: valueStart,
+				Message:  fmt.Sprintf("unknown difficulty %q, expected easy, medium or hard", value),
+			}
+		}
+		for _, existing := range p.query.Difficulty {
+			if existing == difficulty {
+				return nil
+			}
+		}
+		p.query.Difficulty = append(p.query.Difficulty, difficulty)
+
+	case KeyStatus:
+		status := strings.ToLower(value)
+		if !statuses[status] {
+			return &ParseError{
+				Position: valueStart,
+				Message:  fmt.Sprintf("unknown status %q, expected solved, attempted, unsolved or new", value),
+			}
+		}
+		if p.query.Status != "" && p.query.Status != status {
+			return &ParseError{Position: start, Message: "only one status filter is allowed"}
+		}
+		p.query.Status = status
+	}
+
+	return nil
+}
+
+// readQuoted reads a double-quoted string and returns its contents
+func (p *parser) readQuoted() (string, error) {
+	start := p.pos
+	p.pos++
+	for i := p.pos; i < len(p.input); i++ {
+		if p.input[i] == '"' {
+			value := string(p.input[p.pos:i])
+			p.pos = i + 1
+			return value, nil
+		}
+	}
+
+	return "", &ParseError{Position: start, Message: "unterminated quote"}
+}
+
+// readWhile reads characters while keep returns true
+func (p *parser) readWhile(keep func(rune) bool) string {
+	start := p.pos
+	for !p.eof() && keep(p.input[p.pos]) {
+		p.pos++
+	}
+	return string(p.input[start:p.pos])
+}
+
+// skipSpace skips whitespace
+func (p *parser) skipSpace() {
+	p.readWhile(unicode.IsSpace)
+}
+
+// peek returns the next character, or 0 at the end of the input
+func (p *parser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+// eof reports whether the whole input has been read
+func (p *parser) eof() bool {
+	return p.pos >= len(p.input)
+}
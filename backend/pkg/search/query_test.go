@@ -0,0 +1,103 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+func TestParse(t *testing.T) {
+	query, err := Parse(`tag:dp tag:graph difficulty:hard status:unsolved "shortest path" -tag:bfs`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(query.RequiredTags, []string{"dp", "graph"}) {
+		t.Errorf("Unexpected required tags %v", query.RequiredTags)
+	}
+	if !reflect.DeepEqual(query.ExcludedTags, []string{"bfs"}) {
+		t.Errorf("Unexpected excluded tags %v", query.ExcludedTags)
+	}
+	if !reflect.DeepEqual(query.Difficulty, []string{models.DifficultyHard}) {
+		t.Errorf("Unexpected difficulty %v", query.Difficulty)
+	}
+	if query.Status != models.ProgressStatusUnsolved {
+		t.Errorf("Expected status unsolved, got %q", query.Status)
+	}
+	if !reflect.DeepEqual(query.Text.Phrases, [][]string{{"shortest", "path"}}) {
+		t.Errorf("Unexpected phrases %v", query.Text.Phrases)
+	}
+	if len(query.Text.Words) != 0 || query.Text.Prefix {
+		t.Errorf("Expected no words, got %v", query.Text)
+	}
+}
+
+func TestParse_FreeText(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		tsQuery  string
+		filtered bool
+	}{
+		{"words with prefix", "Two Su", "two & su:*", false},
+		{"trailing space ends the word", "two sum ", "two & sum", false},
+		{"filter after words", "two sum difficulty:easy", "two & sum", true},
+		{"excluded word and phrase", `graph -bfs -"breadth first"`, "graph & !bfs & !(breadth <-> first)", false},
+		{"quoted tag value", `tag:"dynamic programming" knapsack`, "knapsack:*", true},
+		{"case-insensitive keys", "Difficulty:MEDIUM", "", true},
+		{"lone dash is ignored", "a - b", "a & b:*", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got := query.Text.TSQuery(); got != tt.tsQuery {
+				t.Errorf("Expected tsquery %q, got %q", tt.tsQuery, got)
+			}
+			if query.HasFilters() != tt.filtered {
+				t.Errorf("Expected HasFilters() to be %v", tt.filtered)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		input    string
+		position int
+		message  string
+	}{
+		{"two tags:dp", 4, `unknown filter "tags", expected tag, difficulty or status`},
+		{"difficulty:impossible", 11, `unknown difficulty "impossible", expected easy, medium or hard`},
+		{"tag: dp", 4, "missing value for tag filter"},
+		{`sum "two`, 4, "unterminated quote"},
+		{`tag:"dp`, 4, "unterminated quote"},
+		{"graph -difficulty:hard", 6, "difficulty filter cannot be negated"},
+		{"status:done", 7, `unknown status "done", expected solved, attempted, unsolved or new`},
+		{"status:solved status:unsolved", 14, "only one status filter is allowed"},
+		{`"" two`, 0, "phrase has no words"},
+		{"héllo tag:x tags:y", 12, `unknown filter "tags", expected tag, difficulty or status`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(tt.input)
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a ParseError, got %v", err)
+			}
+			if parseErr.Position != tt.position {
+				t.Errorf("Expected position %d, got %d", tt.position, parseErr.Position)
+			}
+			if parseErr.Message != tt.message {
+				t.Errorf("Expected message %q, got %q", tt.message, parseErr.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"fmt"
+
+	"leetcode-clone-backend/pkg/execution"
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+)
+
+// DraftServiceInterface defines the interface for draft service
+type DraftServiceInterface interface {
+	GetDraft(userID, problemID int, language string) (*models.CodeDraft, error)
+	SaveDraft(userID, problemID int, language, code string, expectedVersion int) (*models.CodeDraft, error)
+	ListDrafts(userID int) ([]*models.CodeDraft, error)
+}
+
+// DraftService handles business logic for autosaved code drafts
+type DraftService struct {
+	repo *repository.Repository
+}
+
+// NewDraftService creates a new draft service
+func NewDraftService(repo *repository.Repository) *DraftService {
+	return &DraftService{
+		repo: repo,
+	}
+}
+
+// GetDraft retrieves the draft of a user for a published problem in one language. If no draft has
+// been saved yet, the problem's template code is returned with version 0.
+func (ds *DraftService) GetDraft(userID, problemID int, language string) (*models.CodeDraft, error) {
+	if err := validateDraftLanguage(language); err != nil {
+		return nil, fmt.Errorf("invalid draft: %w", err)
+	}
+
+	problem, err := ds.publishedProblem(problemID)
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := ds.repo.CodeDraft.Get(userID, problemID, language)
+	if err == nil {
+		return draft, nil
+	}
+	if !repository.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to retrieve draft: %w", err)
+	}
+
+	return &models.CodeDraft{
+		UserID:       userID,
+		ProblemID:    problemID,
+		Language:     language,
+		Code:         problem.TemplateCode[language],
+		Version:      0,
+		FromTemplate: true,
+	}, nil
+}
+
+// SaveDraft stores the draft of a user for a published problem in one language. expectedVersion
+// must be the version the client last read (0 if it has not saved a draft yet); a conflict error
+// is returned if the draft was saved from somewhere else in the meantime.
+func (ds *DraftService) SaveDraft(userID, problemID int, language, code string, expectedVersion int) (*models.CodeDraft, error) {
+	if err := validateDraftLanguage(language); err != nil {
+		return nil, fmt.Errorf("invalid draft: %w", err)
+	}
+
+	if len(code) > execution.MaxCodeLength {
+		return nil, fmt.Errorf("invalid draft: code exceeds maximum length of %d bytes", execution.MaxCodeLength)
+	}
+
+	if expectedVersion < 0 {
+		return nil, fmt.Errorf("invalid draft: version cannot be negative")
+	}
+
+	if _, err := ds.publishedProblem(problemID); err != nil {
+		return nil, err
+	}
+
+	draft, err := ds.repo.CodeDraft.Save(&models.CodeDraft{
+		UserID:    userID,
+		ProblemID: problemID,
+		Language:  language,
+		Code:      code,
+	}, expectedVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save draft: %w", err)
+	}
+
+	return draft, nil
+}
+
+// ListDrafts retrieves all drafts of a user, most recently saved first
+func (ds *DraftService) ListDrafts(userID int) ([]*models.CodeDraft, error) {
+	drafts, err := ds.repo.CodeDraft.ListByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list drafts: %w", err)
+	}
+
+	if drafts == nil {
+		drafts = []*models.CodeDraft{}
+	}
+
+	return drafts, nil
+}
+
+// publishedProblem retrieves a problem, which is not found unless it is published, so that the
+// template code of unreleased problems is not exposed
+func (ds *DraftService) publishedProblem(problemID int) (*models.Problem, error) {
+	problem, err := ds.repo.Problem.GetByID(problemID)
+	if err == nil && problem.State != models.ProblemStatePublished {
+		err = repository.NewRepositoryError("GetByID", repository.ErrNotFound, "problem_not_found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve problem: %w", err)
+	}
+
+	return problem, nil
+}
+
+// validateDraftLanguage checks that a draft language is supported
+func validateDraftLanguage(language string) error {
+	switch language {
+	case models.LanguageJavaScript, models.LanguagePython, models.LanguageJava:
+		return nil
+	case "":
+		return fmt.Errorf("language is required")
+	default:
+		return fmt.Errorf("unsupported language: %s", language)
+	}
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"leetcode-clone-backend/pkg/execution"
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCodeDraftRepository struct {
+	mock.Mock
+}
+
+func (m *MockCodeDraftRepository) Get(userID, problemID int, language string) (*models.CodeDraft, error) {
+	args := m.Called(userID, problemID, language)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CodeDraft), args.Error(1)
+}
+
+func (m *MockCodeDraftRepository) Save(draft *models.CodeDraft, expectedVersion int) (*models.CodeDraft, error) {
+	args := m.Called(draft, expectedVersion)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CodeDraft), args.Error(1)
+}
+
+func (m *MockCodeDraftRepository) ListByUser(userID int) ([]*models.CodeDraft, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.CodeDraft), args.Error(1)
+}
+
+func newDraftTestService() (*DraftService, *MockCodeDraftRepository) {
+	draftRepo := new(MockCodeDraftRepository)
+	problemRepo := newMockProblemRepository()
+	problemRepo.Create(&models.Problem{
+		Title:        "Two Sum",
+		State:        models.ProblemStatePublished,
+		TemplateCode: models.TemplateCode{models.LanguagePython: "def two_sum(nums, target):\n    pass"},
+	})
+	problemRepo.Create(&models.Problem{
+		Title:        "Three Sum",
+		TemplateCode: models.TemplateCode{models.LanguagePython: "def three_sum(nums):\n    pass"},
+	})
+
+	service := NewDraftService(&repository.Repository{CodeDraft: draftRepo, Problem: problemRepo})
+	return service, draftRepo
+}
+
+func TestDraftService_GetDraft(t *testing.T) {
+	t.Run("returns saved draft", func(t *testing.T) {
+		service, draftRepo := newDraftTestService()
+		draft := &models.CodeDraft{UserID: 1, ProblemID: 1, Language: models.LanguagePython, Code: "print(1)", Version: 3}
+		draftRepo.On("Get", 1, 1, models.LanguagePython).Return(draft, nil)
+
+		result, err := service.GetDraft(1, 1, models.LanguagePython)
+
+		assert.NoError(t, err)
+		assert.Equal(t, draft, result)
+	})
+
+	t.Run("falls back to template code", func(t *testing.T) {
+		service, draftRepo := newDraftTestService()
+		draftRepo.On("Get", 1, 1, models.LanguagePython).Return(nil, repository.NewRepositoryError("Get", repository.ErrNotFound, "draft_not_found"))
+
+		result, err := service.GetDraft(1, 1, models.LanguagePython)
+
+		assert.NoError(t, err)
+		assert.True(t, result.FromTemplate)
+		assert.Equal(t, 0, result.Version)
+		assert.Equal(t, "def two_sum(nums, target):\n    pass", result.Code)
+	})
+
+	t.Run("problem not found", func(t *testing.T) {
+		service, _ := newDraftTestService()
+
+		_, err := service.GetDraft(1, 99, models.LanguagePython)
+
+		assert.Error(t, err)
+		assert.True(t, repository.IsNotFound(err))
+	})
+
+	t.Run("unpublished problem not found", func(t *testing.T) {
+		service, draftRepo := newDraftTestService()
+
+		_, err := service.GetDraft(1, 2, models.LanguagePython)
+
+		assert.Error(t, err)
+		assert.True(t, repository.IsNotFound(err))
+		draftRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("unsupported language", func(t *testing.T) {
+		service, _ := newDraftTestService()
+
+		_, err := service.GetDraft(1, 1, "ruby")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid draft")
+	})
+}
+
+func TestDraftService_SaveDraft(t *testing.T) {
+	t.Run("saves draft with expected version", func(t *testing.T) {
+		service, draftRepo := newDraftTestService()
+		saved := &models.CodeDraft{UserID: 1, ProblemID: 1, Language: models.LanguagePython, Code: "print(2)", Version: 4}
+		draftRepo.On("Save", mock.MatchedBy(func(draft *models.CodeDraft) bool {
+			return draft.UserID == 1 && draft.ProblemID == 1 && draft.Code == "print(2)"
+		}), 3).Return(saved, nil)
+
+		result, err := service.SaveDraft(1, 1, models.LanguagePython, "print(2)", 3)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 4, result.Version)
+		draftRepo.AssertExpectations(t)
+	})
+
+	t.Run("version conflict", func(t *testing.T) {
+		service, draftRepo := newDraftTestService()
+		draftRepo.On("Save", mock.Anything, 1).Return(nil, repository.NewRepositoryError("Save", repository.ErrConflict, "draft_version_conflict"))
+
+		_, err := service.SaveDraft(1, 1, models.LanguagePython, "print(2)", 1)
+
+		assert.Error(t, err)
+		assert.True(t, repository.IsConflict(err))
+	})
+
+	t.Run("code too long", func(t *testing.T) {
+		service, draftRepo := newDraftTestService()
+
+		_, err := service.SaveDraft(1, 1, models.LanguagePython, strings.Repeat("a", execution.MaxCodeLength+1), 0)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid draft")
+		draftRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+
+	t.Run("problem not found", func(t *testing.T) {
+		service, draftRepo := newDraftTestService()
+
+		_, err := service.SaveDraft(1, 99, models.LanguagePython, "print(2)", 0)
+
+		assert.Error(t, err)
+		assert.True(t, repository.IsNotFound(err))
+		draftRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unpublished problem not found", func(t *testing.T) {
+		service, draftRepo := newDraftTestService()
+
+		_, err := service.SaveDraft(1, 2, models.LanguagePython, "print(2)", 0)
+
+		assert.Error(t, err)
+		assert.True(t, repository.IsNotFound(err))
+		draftRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+}
+
+func TestDraftService_ListDrafts(t *testing.T) {
+	service, draftRepo := newDraftTestService()
+	draftRepo.On("ListByUser", 1).Return(nil, nil)
+
+	drafts, err := service.ListDrafts(1)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, drafts)
+	assert.Len(t, drafts, 0)
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+// GetJudgeSettings retrieves the judging settings of a problem, whatever its lifecycle state
+func (s *ProblemService) GetJudgeSettings(problemID int) (*models.JudgeSettings, error) {
+	if _, err := s.repo.Problem.GetByID(problemID); err != nil {
+		return nil, fmt.Errorf("failed to get problem: %w", err)
+	}
+
+	settings, err := s.repo.JudgeSettings.GetByProblemID(problemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get judging settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// UpdateJudgeSettings replaces the judging settings of a problem. Submissions are judged with the
+// new settings from then on; past verdicts change only when the problem is rejudged.
+func (s *ProblemService) UpdateJudgeSettings(settings *models.JudgeSettings) (*models.JudgeSettings, error) {
+	if err := validateJudgeSettings(settings); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if _, err := s.repo.Problem.GetByID(settings.ProblemID); err != nil {
+		return nil, fmt.Errorf("failed to get problem: %w", err)
+	}
+
+	updated, err := s.repo.JudgeSettings.Upsert(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update judging settings: %w", err)
+	}
+
+	return updated, nil
+}
+
+// validateJudgeSettings validates judging settings data
+func validateJudgeSettings(settings *models.JudgeSettings) error {
+	if settings.TimeLimitMs < models.MinTimeLimitMs || settings.TimeLimitMs > models.MaxTimeLimitMs {
+		return fmt.Errorf("time limit must be between %d and %d ms", models.MinTimeLimitMs, models.MaxTimeLimitMs)
+	}
+
+	if settings.MemoryLimitMb < models.MinMemoryLimitMb || settings.MemoryLimitMb > models.MaxMemoryLimitMb {
+		return fmt.Errorf("memory limit must be between %d and %d MB", models.MinMemoryLimitMb, models.MaxMemoryLimitMb)
+	}
+
+	languages := make([]string, 0, len(settings.LanguageLimits))
+	for language := range settings.LanguageLimits {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	for _, language := range languages {
+		if err := validateLanguageLimits(language, settings.LanguageLimits[language]); err != nil {
+			return err
+		}
+	}
+
+	switch settings.Checker {
+	case models.CheckerExact:
+		if settings.IgnoreCase || settings.FloatTolerance != 0 {
+			return fmt.Errorf("ignore_case and float_tolerance need the %s checker", models.CheckerTokens)
+		}
+	case models.CheckerTokens:
+		if settings.FloatTolerance < 0 || settings.FloatTolerance >= 1 {
+			return fmt.Errorf("float tolerance must be at least 0 and less than 1")
+		}
+	default:
+		return fmt.Errorf("checker must be one of: %s, %s", models.CheckerExact, models.CheckerTokens)
+	}
+
+	return nil
+}
+
+// validateLanguageLimits validates the limits of a language
+func validateLanguageLimits(language string, limits models.Limits) error {
+	switch language {
+	case models.LanguageJavaScript, models.LanguagePython, models.LanguageJava:
+	default:
+		return fmt.Errorf("language limits: language must be one of: javascript, python, java")
+	}
+	if limits.TimeLimitMs < models.MinTimeLimitMs || limits.TimeLimitMs > models.MaxTimeLimitMs {
+		return fmt.Errorf("%s: time limit must be between %d and %d ms", language, models.MinTimeLimitMs, models.MaxTimeLimitMs)
+	}
+	if limits.MemoryLimitMb < models.MinMemoryLimitMb || limits.MemoryLimitMb > models.MaxMemoryLimitMb {
+		return fmt.Errorf("%s: memory limit must be between %d and %d MB", language, models.MinMemoryLimitMb, models.MaxMemoryLimitMb)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+type mockJudgeSettingsRepository struct {
+	settings map[int]*models.JudgeSettings
+}
+
+func newMockJudgeSettingsRepository() *mockJudgeSettingsRepository {
+	return &mockJudgeSettingsRepository{settings: make(map[int]*models.JudgeSettings)}
+}
+
+func (m *mockJudgeSettingsRepository) GetByProblemID(problemID int) (*models.JudgeSettings, error) {
+	if settings, exists := m.settings[problemID]; exists {
+		stored := *settings
+		return &stored, nil
+	}
+	return models.DefaultJudgeSettings(problemID), nil
+}
+
+func (m *mockJudgeSettingsRepository) Upsert(settings *models.JudgeSettings) (*models.JudgeSettings, error) {
+	now := time.Now()
+	saved := *settings
+	saved.UpdatedAt = &now
+	m.settings[settings.ProblemID] = &saved
+	return &saved, nil
+}
+
+func TestProblemService_UpdateJudgeSettings(t *testing.T) {
+	service, problemRepo, _, _ := newRevisionTestService()
+	problem, _ := problemRepo.Create(revisionTestProblem("Two Sum"))
+
+	tests := []struct {
+		name        string
+		settings    models.JudgeSettings
+		expectedErr string
+	}{
+		{"exact checker", models.JudgeSettings{ProblemID: problem.ID, TimeLimitMs: 1000, MemoryLimitMb: 256, Checker: models.CheckerExact}, ""},
+		{"tokens with tolerance", models.JudgeSettings{ProblemID: problem.ID, TimeLimitMs: 2000, MemoryLimitMb: 64, Checker: models.CheckerTokens, IgnoreCase: true, FloatTolerance: 1e-6}, ""},
+		{"time limit too low", models.JudgeSettings{ProblemID: problem.ID, TimeLimitMs: 50, MemoryLimitMb: 256, Checker: models.CheckerExact}, "time limit"},
+		{"memory limit too high", models.JudgeSettings{ProblemID: problem.ID, TimeLimitMs: 1000, MemoryLimitMb: 4096, Checker: models.CheckerExact}, "memory limit"},
+		{"language limits", models.JudgeSettings{ProblemID: problem.ID, TimeLimitMs: 1000, MemoryLimitMb: 256, LanguageLimits: models.LanguageLimits{models.LanguagePython: {TimeLimitMs: 3000, MemoryLimitMb: 256}}, Checker: models.CheckerExact}, ""},
+		{"limits of unknown language", models.JudgeSettings{ProblemID: problem.ID, TimeLimitMs: 1000, MemoryLimitMb: 256, LanguageLimits: models.LanguageLimits{"ruby": {TimeLimitMs: 1000, MemoryLimitMb: 256}}, Checker: models.CheckerExact}, "language must be"},
+		{"language time limit too high", models.JudgeSettings{ProblemID: problem.ID, TimeLimitMs: 1000, MemoryLimitMb: 256, LanguageLimits: models.LanguageLimits{models.LanguageJava: {TimeLimitMs: 20000, MemoryLimitMb: 256}}, Checker: models.CheckerExact}, "java: time limit"},
+		{"unknown checker", models.JudgeSettings{ProblemID: problem.ID, TimeLimitMs: 1000, MemoryLimitMb: 256, Checker: "testlib"}, "checker must be"},
+		{"tolerance with exact checker", models.JudgeSettings{ProblemID: problem.ID, TimeLimitMs: 1000, MemoryLimitMb: 256, Checker: models.CheckerExact, FloatTolerance: 0.1}, "float_tolerance"},
+		{"missing problem", models.JudgeSettings{ProblemID: 99, TimeLimitMs: 1000, MemoryLimitMb: 256, Checker: models.CheckerExact}, "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := tt.settings
+			updated, err := service.UpdateJudgeSettings(&settings)
+
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			stored, _ := service.GetJudgeSettings(problem.ID)
+			if !sameJudgeSettings(stored, &tt.settings) || updated.UpdatedAt == nil {
+				t.Errorf("Expected settings %+v stored, got %+v", tt.settings, stored)
+			}
+		})
+	}
+}
+
+func TestProblemService_ImportPackage_JudgeSettings(t *testing.T) {
+	service, _, _, _ := newRevisionTestService()
+
+	created, _ := service.ImportPackage(testPackage(), 4)
+	settings, _ := service.GetJudgeSettings(created.Problem.ID)
+	if !sameJudgeSettings(settings, models.DefaultJudgeSettings(created.Problem.ID)) {
+		t.Errorf("Expected default settings for a package without limits, got %+v", settings)
+	}
+
+	pkg := testPackage()
+	pkg.Manifest.TimeLimitMs = 1500
+	pkg.Manifest.Checker = models.CheckerTokens
+	result, err := service.ImportPackage(pkg, 4)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.Action != PackageImportUpdated {
+		t.Errorf("Expected a change of limits to update the problem, got %q", result.Action)
+	}
+	settings, _ = service.GetJudgeSettings(created.Problem.ID)
+	if settings.TimeLimitMs != 1500 || settings.Checker != models.CheckerTokens {
+		t.Errorf("Expected the package limits stored, got %+v", settings)
+	}
+
+	exported, _ := service.ExportPackage(created.Problem.ID)
+	if exported.Manifest.TimeLimitMs != 1500 || exported.Manifest.Checker != models.CheckerTokens {
+		t.Errorf("Expected the limits exported, got %+v", exported.Manifest)
+	}
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+// Limit calibration settings
+const (
+	DefaultCalibrationRuns = 3   // Runs of every reference solution on every test case
+	MaxCalibrationRuns     = 5   // Runs a calibration may ask for
+	CalibrationTimeFactor  = 3   // Suggested time limit as a multiple of the slowest reference run, plus the baseline
+	CalibrationTimeStepMs  = 100 // Suggested time limits are rounded up to a multiple of this
+)
+
+// calibrationBaselines are programs that do no work. How long they take to run is the overhead of
+// a run in each language: starting the container or compiling, which the time limit does not
+// cover, and starting the interpreter or the JVM, which it does.
+var calibrationBaselines = map[string]string{
+	models.LanguageJavaScript: "function solution(input) { return input; }",
+	models.LanguagePython:     "def solution(input):\n    return input",
+	models.LanguageJava:       "public String solution(String input) { return input; }",
+}
+
+// LimitCalibration is the report of a limit calibration: how the reference solutions of a
+// problem ran in every language and the limits they suggest. Nothing is changed until the
+// suggested settings are applied as the judging settings of the problem.
+type LimitCalibration struct {
+	Runs      int                    `json:"runs"` // Runs of every reference solution on every test case
+	TestCases int                    `json:"test_cases"`
+	Languages []*LanguageCalibration `json:"languages"` // Ordered by language
+	// Current judging settings with the suggested limits of every language that has them
+	SuggestedSettings *models.JudgeSettings `json:"suggested_settings"`
+}
+
+// LanguageCalibration is how the reference solutions in one language ran and the limits they
+// suggest
+type LanguageCalibration struct {
+	Language   string                      `json:"language"`
+	Solutions  []string                    `json:"solutions"`
+	Samples    int                         `json:"samples"` // Runs measured, over every solution, test case and repetition
+	RuntimeMs  RuntimeDistribution         `json:"runtime_ms"`
+	Histogram  []*models.PerformanceBucket `json:"histogram"`   // Runtimes in buckets of models.RuntimeBucketMs
+	BaselineMs int                         `json:"baseline_ms"` // Fastest run of a program doing no work, subtracted from every runtime and added to the suggested time limit
+	Slowest    *CalibrationRun             `json:"slowest,omitempty"`
+	Current    models.Limits               `json:"current"`             // Limits the language is judged with now
+	Suggested  *models.Limits              `json:"suggested,omitempty"` // Unset when a run failed
+	Failures   []string                    `json:"failures,omitempty"`
+	Notes      []string                    `json:"notes,omitempty"`
+}
+
+// RuntimeDistribution summarizes a set of runtimes in milliseconds
+type RuntimeDistribution struct {
+	Min    int `json:"min"`
+	Median int `json:"median"`
+	P90    int `json:"p90"`
+	Max    int `json:"max"`
+}
+
+// CalibrationRun is a run of a reference solution on a test case
+type CalibrationRun struct {
+	Solution   string `json:"solution"`
+	TestCaseID int    `json:"test_case_id"`
+	RuntimeMs  int    `json:"runtime_ms"`
+}
+
+// CalibrateLimits runs every reference solution of a problem runs times on all of its test cases,
+// with the maximum limits, and suggests the limits of each language: CalibrationTimeFactor times
+// its slowest run plus its baseline, rounded up and kept within the allowed limits, and its
+// current memory limit, as the sandbox does not measure memory usage. Runtimes are measured
+// around the whole sandbox run, so the baseline of each language, the fastest run of a program
+// doing no work on the same inputs, is subtracted from them first. A language in which a solution
+// fails gets no suggestion. runs of 0 uses DefaultCalibrationRuns.
+func (s *ProblemService) CalibrateLimits(problemID, runs int) (*LimitCalibration, error) {
+	if runs == 0 {
+		runs = DefaultCalibrationRuns
+	}
+	if runs < 1 || runs > MaxCalibrationRuns {
+		return nil, fmt.Errorf("validation failed: runs must be between 1 and %d", MaxCalibrationRuns)
+	}
+
+	if _, err := s.repo.Problem.GetByID(problemID); err != nil {
+		return nil, fmt.Errorf("failed to get problem: %w", err)
+	}
+
+	testCases, err := s.repo.TestCase.GetByProblemID(problemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test cases: %w", err)
+	}
+	if len(testCases) == 0 {
+		return nil, fmt.Errorf("validation failed: problem has no test cases")
+	}
+
+	solutions, err := s.repo.ProblemSolution.GetByProblemID(problemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reference solutions: %w", err)
+	}
+	if len(solutions) == 0 {
+		return nil, fmt.Errorf("validation failed: problem has no reference solutions")
+	}
+	if s.executionService == nil {
+		return nil, fmt.Errorf("reference solutions cannot be run: no execution service configured")
+	}
+
+	settings, err := s.repo.JudgeSettings.GetByProblemID(problemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get judging settings: %w", err)
+	}
+
+	// Solutions run with the maximum limits, so that the current limits do not cut runs short
+	calibration := *settings
+	calibration.TimeLimitMs, calibration.MemoryLimitMb, calibration.LanguageLimits = models.MaxTimeLimitMs, models.MaxMemoryLimitMb, nil
+
+	inputs := make([]string, len(testCases))
+	for i, testCase := range testCases {
+		inputs[i] = testCase.Input
+	}
+
+	byLanguage := make(map[string]*LanguageCalibration)
+	samples := make(map[string][]int)
+	for _, solution := range solutions {
+		language := byLanguage[solution.Language]
+		if language == nil {
+			current := settings.ForLanguage(solution.Language)
+			language = &LanguageCalibration{
+				Language: solution.Language,
+				Current:  models.Limits{TimeLimitMs: current.TimeLimitMs, MemoryLimitMb: current.MemoryLimitMb},
+			}
+			language.BaselineMs, err = s.measureBaseline(solution.Language, inputs, runs, &calibration)
+			if err != nil {
+				return nil, err
+			}
+			byLanguage[solution.Language] = language
+		}
+		language.Solutions = append(language.Solutions, solution.Name)
+
+		failed := make(map[int]bool)
+		for run := 0; run < runs; run++ {
+			results, err := s.executionService.RunCode(solution.Code, solution.Language, inputs, &calibration)
+			if err != nil {
+				return nil, fmt.Errorf("failed to run reference solution %s: %w", solution.Name, err)
+			}
+			if len(results) != len(inputs) {
+				return nil, fmt.Errorf("failed to run reference solution %s: got %d results for %d inputs", solution.Name, len(results), len(inputs))
+			}
+
+			for i, result := range results {
+				if result.Status != models.StatusAccepted {
+					if !failed[i] {
+						failed[i] = true
+						language.Failures = append(language.Failures, fmt.Sprintf("solution %s on test case %d: %s", solution.Name, testCases[i].ID, result.Status))
+					}
+					continue
+				}
+
+				runtime := max(result.RuntimeMs-language.BaselineMs, 0)
+				samples[solution.Language] = append(samples[solution.Language], runtime)
+				if language.Slowest == nil || runtime > language.Slowest.RuntimeMs {
+					language.Slowest = &CalibrationRun{Solution: solution.Name, TestCaseID: testCases[i].ID, RuntimeMs: runtime}
+				}
+			}
+		}
+	}
+
+	report := &LimitCalibration{Runs: runs, TestCases: len(testCases)}
+	suggested := *settings
+	suggested.UpdatedAt = nil
+	suggested.LanguageLimits = make(models.LanguageLimits, len(settings.LanguageLimits)+len(byLanguage))
+	for language, limits := range settings.LanguageLimits {
+		suggested.LanguageLimits[language] = limits
+	}
+
+	for _, language := range byLanguage {
+		language.Samples = len(samples[language.Language])
+		language.RuntimeMs = newRuntimeDistribution(samples[language.Language])
+		language.Histogram = runtimeHistogram(samples[language.Language])
+		if len(language.Failures) == 0 {
+			language.Suggested = suggestLimits(language)
+			suggested.LanguageLimits[language.Language] = *language.Suggested
+		}
+		report.Languages = append(report.Languages, language)
+	}
+	sort.Slice(report.Languages, func(i, j int) bool { return report.Languages[i].Language < report.Languages[j].Language })
+	if len(suggested.LanguageLimits) == 0 {
+		suggested.LanguageLimits = nil
+	}
+	report.SuggestedSettings = &suggested
+
+	return report, nil
+}
+
+// measureBaseline runs the baseline program of a language runs times on the inputs and returns
+// its fastest run, so that the overhead is never overestimated
+func (s *ProblemService) measureBaseline(language string, inputs []string, runs int, settings *models.JudgeSettings) (int, error) {
+	code, ok := calibrationBaselines[language]
+	if !ok {
+		return 0, nil
+	}
+
+	baseline := -1
+	for run := 0; run < runs; run++ {
+		results, err := s.executionService.RunCode(code, language, inputs, settings)
+		if err != nil {
+			return 0, fmt.Errorf("failed to run %s baseline: %w", language, err)
+		}
+
+		for _, result := range results {
+			if result.Status != models.StatusAccepted {
+				return 0, fmt.Errorf("failed to run %s baseline: %s", language, result.Status)
+			}
+			if baseline < 0 || result.RuntimeMs < baseline {
+				baseline = result.RuntimeMs
+			}
+		}
+	}
+
+	return max(baseline, 0), nil
+}
+
+// suggestLimits suggests the limits of a language from the runs of its reference solutions, with
+// a note for every limit that cannot follow them. The baseline includes the startup of the
+// interpreter or the JVM, which runs within the time limit but cannot be measured apart from the
+// overhead outside it, so the whole baseline is added back once to the scaled runtime. A
+// suggestion is therefore never below the baseline.
+func suggestLimits(language *LanguageCalibration) *models.Limits {
+	timeLimitMs := roundUp(CalibrationTimeFactor*language.RuntimeMs.Max+language.BaselineMs, CalibrationTimeStepMs)
+	if timeLimitMs > models.MaxTimeLimitMs {
+		language.Notes = append(language.Notes, fmt.Sprintf("%d times the slowest run plus the baseline exceeds the maximum time limit of %d ms", CalibrationTimeFactor, models.MaxTimeLimitMs))
+	}
+
+	// The memory reported for a run is estimated from its output, so it cannot suggest a limit
+	language.Notes = append(language.Notes, "memory usage is not measured by the sandbox; the current memory limit is kept")
+
+	return &models.Limits{
+		TimeLimitMs:   min(max(timeLimitMs, models.MinTimeLimitMs), models.MaxTimeLimitMs),
+		MemoryLimitMb: language.Current.MemoryLimitMb,
+	}
+}
+
+// newRuntimeDistribution summarizes runtimes, using the nearest-rank percentiles
+func newRuntimeDistribution(runtimes []int) RuntimeDistribution {
+	if len(runtimes) == 0 {
+		return RuntimeDistribution{}
+	}
+
+	sorted := append([]int(nil), runtimes...)
+	sort.Ints(sorted)
+	percentile := func(p int) int {
+		return sorted[(p*len(sorted)+99)/100-1]
+	}
+
+	return RuntimeDistribution{
+		Min:    sorted[0],
+		Median: percentile(50),
+		P90:    percentile(90),
+		Max:    sorted[len(sorted)-1],
+	}
+}
+
+// runtimeHistogram counts runtimes in buckets of models.RuntimeBucketMs, ordered by bucket
+func runtimeHistogram(runtimes []int) []*models.PerformanceBucket {
+	counts := make(map[int]int)
+	for _, runtime := range runtimes {
+		counts[bucketFor(runtime, models.RuntimeBucketMs)]++
+	}
+
+	histogram := make([]*models.PerformanceBucket, 0, len(counts))
+	for bucket, count := range counts {
+		histogram = append(histogram, &models.PerformanceBucket{Bucket: bucket, Count: count})
+	}
+	sort.Slice(histogram, func(i, j int) bool { return histogram[i].Bucket < histogram[j].Bucket })
+	return histogram
+}
+
+// roundUp rounds a value up to a multiple of step
+func roundUp(value, step int) int {
+	return (value + step - 1) / step * step
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+func TestProblemService_CalibrateLimits(t *testing.T) {
+	service, _, _, problem := newReferenceTestService(t, "sum")
+	service.ReplaceSolutions(problem.ID, []*models.ProblemSolution{
+		{Name: "fast", Language: models.LanguagePython, Code: "sum"},
+		{Name: "slow", Language: models.LanguagePython, Code: "sum"},
+		{Name: "broken", Language: models.LanguageJava, Code: "crash"},
+	})
+	first, _ := service.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "1 2", ExpectedOutput: "3"}, 1)
+	service.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "40 60", ExpectedOutput: "100"}, 1)
+	service.UpdateJudgeSettings(&models.JudgeSettings{
+		ProblemID:      problem.ID,
+		TimeLimitMs:    1000,
+		MemoryLimitMb:  128,
+		LanguageLimits: models.LanguageLimits{models.LanguageJavaScript: {TimeLimitMs: 500, MemoryLimitMb: 64}},
+		Checker:        models.CheckerExact,
+	})
+	runner := service.executionService.(*fakeSolutionRunner)
+	runner.runs = 0
+
+	report, err := service.CalibrateLimits(problem.ID, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// 3 solutions and the baselines of 2 languages, twice each
+	if report.Runs != 2 || report.TestCases != 2 || runner.runs != 10 {
+		t.Errorf("Expected every solution and baseline run twice on 2 test cases, got %d runs of %d (%d calls)", report.Runs, report.TestCases, runner.runs)
+	}
+	if len(report.Languages) != 2 || report.Languages[0].Language != models.LanguageJava || report.Languages[1].Language != models.LanguagePython {
+		t.Fatalf("Expected Java and Python in order, got %+v", report.Languages)
+	}
+
+	java := report.Languages[0]
+	if java.Suggested != nil || len(java.Failures) != 2 || !strings.Contains(java.Failures[0], "solution broken on test case") {
+		t.Errorf("Expected no suggestion for Java with one failure per test case, got %+v", java)
+	}
+
+	python := report.Languages[1]
+	if !reflect.DeepEqual(python.Solutions, []string{"fast", "slow"}) || python.Samples != 8 {
+		t.Errorf("Expected 8 samples of both solutions, got %v and %d", python.Solutions, python.Samples)
+	}
+	if python.BaselineMs != fakeStartupMs || java.BaselineMs != fakeStartupMs {
+		t.Errorf("Expected a baseline of %d ms, got %d and %d", fakeStartupMs, python.BaselineMs, java.BaselineMs)
+	}
+	// Startup is not counted in the runtimes
+	if python.RuntimeMs != (RuntimeDistribution{Min: 30, Median: 30, P90: 1000, Max: 1000}) {
+		t.Errorf("Expected runtimes of 30 and 1000 ms, got %+v", python.RuntimeMs)
+	}
+	if len(python.Histogram) != 2 || python.Histogram[0].Bucket != 30 || python.Histogram[0].Count != 4 || python.Histogram[1].Count != 4 {
+		t.Errorf("Expected two buckets of 4 runs, got %+v", python.Histogram)
+	}
+	if python.Slowest == nil || python.Slowest.Solution != "fast" || python.Slowest.TestCaseID == first.ID {
+		t.Errorf("Expected the slowest run on the second test case, got %+v", python.Slowest)
+	}
+	if python.Current != (models.Limits{TimeLimitMs: 1000, MemoryLimitMb: 128}) {
+		t.Errorf("Expected the current limits of the problem, got %+v", python.Current)
+	}
+	// 3 × 1000 ms plus the 200 ms baseline, and the current memory limit whatever memory the runs report
+	if python.Suggested == nil || *python.Suggested != (models.Limits{TimeLimitMs: 3200, MemoryLimitMb: 128}) {
+		t.Errorf("Expected 3200 ms and 128 MB suggested, got %+v", python.Suggested)
+	}
+	if len(python.Notes) != 1 || !strings.Contains(python.Notes[0], "memory usage is not measured") {
+		t.Errorf("Expected a note that memory is not measured, got %v", python.Notes)
+	}
+
+	suggested := report.SuggestedSettings
+	expected := models.LanguageLimits{
+		models.LanguageJavaScript: {TimeLimitMs: 500, MemoryLimitMb: 64},
+		models.LanguagePython:     {TimeLimitMs: 3200, MemoryLimitMb: 128},
+	}
+	if suggested.TimeLimitMs != 1000 || !reflect.DeepEqual(suggested.LanguageLimits, expected) || suggested.UpdatedAt != nil {
+		t.Errorf("Expected the current settings with the Python suggestion, got %+v", suggested)
+	}
+	if stored, _ := service.GetJudgeSettings(problem.ID); len(stored.LanguageLimits) != 1 {
+		t.Errorf("Expected the settings unchanged until applied, got %+v", stored.LanguageLimits)
+	}
+	if _, err := service.UpdateJudgeSettings(suggested); err != nil {
+		t.Errorf("Expected the suggested settings to be valid, got %v", err)
+	}
+
+	t.Run("limits kept within the maximum", func(t *testing.T) {
+		service, _, _, problem := newReferenceTestService(t, "sum")
+		service.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "400 400", ExpectedOutput: "800"}, 1)
+
+		report, err := service.CalibrateLimits(problem.ID, 0)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		python := report.Languages[0]
+		if report.Runs != DefaultCalibrationRuns || python.Suggested.TimeLimitMs != models.MaxTimeLimitMs || len(python.Notes) != 2 {
+			t.Errorf("Expected the maximum time limit with notes, got %+v", python)
+		}
+	})
+
+	t.Run("limits never below the baseline", func(t *testing.T) {
+		service, _, _, problem := newReferenceTestService(t, "sum")
+		// The solution does no work, so it runs as fast as the baseline
+		service.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "0 0", ExpectedOutput: "0"}, 1)
+
+		report, err := service.CalibrateLimits(problem.ID, 1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		python := report.Languages[0]
+		if python.RuntimeMs.Max != 0 || python.Suggested.TimeLimitMs < python.BaselineMs || python.Suggested.TimeLimitMs != 200 {
+			t.Errorf("Expected a time limit of the %d ms baseline, got %+v", fakeStartupMs, python.Suggested)
+		}
+	})
+
+	tests := []struct {
+		name          string
+		solutions     []string
+		testCase      bool
+		problemID     int
+		runs          int
+		expectedError string
+	}{
+		{"too many runs", []string{"sum"}, true, 0, MaxCalibrationRuns + 1, "runs must be between"},
+		{"no test cases", []string{"sum"}, false, 0, 1, "no test cases"},
+		{"no reference solutions", nil, true, 0, 1, "no reference solutions"},
+		{"problem not found", []string{"sum"}, true, 999, 1, "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _, _, problem := newReferenceTestService(t, tt.solutions...)
+			if tt.testCase {
+				service.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "1 2", ExpectedOutput: "3"}, 1)
+			}
+			problemID := problem.ID
+			if tt.problemID != 0 {
+				problemID = tt.problemID
+			}
+
+			_, err := service.CalibrateLimits(problemID, tt.runs)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"fmt"
+	"math"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+)
+
+// PercentileServiceInterface defines the interface for percentile service
+type PercentileServiceInterface interface {
+	RecordAccepted(submission *models.Submission) error
+	GetPercentiles(submission *models.Submission) (*SubmissionPercentiles, error)
+	RebuildProblem(problemID int) error
+}
+
+// PercentileService compares the runtime and memory of accepted submissions with other
+// accepted submissions for the same problem and language. Comparisons are based on
+// histograms that are updated incrementally as submissions are accepted.
+type PercentileService struct {
+	histogramRepo repository.PerformanceHistogramRepository
+}
+
+// NewPercentileService creates a new percentile service
+func NewPercentileService(histogramRepo repository.PerformanceHistogramRepository) *PercentileService {
+	return &PercentileService{
+		histogramRepo: histogramRepo,
+	}
+}
+
+// SubmissionPercentiles represents how a submission compares with other accepted submissions
+type SubmissionPercentiles struct {
+	Runtime *MetricPercentile `json:"runtime,omitempty"`
+	Memory  *MetricPercentile `json:"memory,omitempty"`
+}
+
+// MetricPercentile represents the position of a submission within the distribution of a metric
+type MetricPercentile struct {
+	Value      int                         `json:"value"`
+	Beats      float64                     `json:"beats"` // Percentage of other accepted submissions that are slower or use more memory
+	Total      int                         `json:"total"` // Number of accepted submissions in the distribution, including this one
+	BucketSize int                         `json:"bucket_size"`
+	Buckets    []*models.PerformanceBucket `json:"buckets"`
+}
+
+// RecordAccepted adds an accepted submission to the runtime and memory histograms of its problem and language
+func (ps *PercentileService) RecordAccepted(submission *models.Submission) error {
+	if submission.Status != models.StatusAccepted {
+		return nil
+	}
+
+	if submission.RuntimeMs != nil {
+		bucket := bucketFor(*submission.RuntimeMs, models.RuntimeBucketMs)
+		if err := ps.histogramRepo.Increment(submission.ProblemID, submission.Language, models.PerformanceMetricRuntime, bucket); err != nil {
+			return fmt.Errorf("failed to record runtime: %w", err)
+		}
+	}
+
+	if submission.MemoryKb != nil {
+		bucket := bucketFor(*submission.MemoryKb, models.MemoryBucketKb)
+		if err := ps.histogramRepo.Increment(submission.ProblemID, submission.Language, models.PerformanceMetricMemory, bucket); err != nil {
+			return fmt.Errorf("failed to record memory usage: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetPercentiles computes the runtime and memory percentiles of an accepted submission.
+// The submission is expected to be part of the histograms already.
+func (ps *PercentileService) GetPercentiles(submission *models.Submission) (*SubmissionPercentiles, error) {
+	percentiles := &SubmissionPercentiles{}
+
+	if submission.RuntimeMs != nil {
+		buckets, err := ps.histogramRepo.GetBuckets(submission.ProblemID, submission.Language, models.PerformanceMetricRuntime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve runtime distribution: %w", err)
+		}
+		percentiles.Runtime = computeMetricPercentile(buckets, *submission.RuntimeMs, models.RuntimeBucketMs)
+	}
+
+	if submission.MemoryKb != nil {
+		buckets, err := ps.histogramRepo.GetBuckets(submission.ProblemID, submission.Language, models.PerformanceMetricMemory)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve memory distribution: %w", err)
+		}
+		percentiles.Memory = computeMetricPercentile(buckets, *submission.MemoryKb, models.MemoryBucketKb)
+	}
+
+	return percentiles, nil
+}
+
+// RebuildProblem recomputes the histograms of a problem from its accepted submissions
+func (ps *PercentileService) RebuildProblem(problemID int) error {
+	if err := ps.histogramRepo.RebuildProblem(problemID); err != nil {
+		return fmt.Errorf("failed to rebuild performance histograms: %w", err)
+	}
+	return nil
+}
+
+// bucketFor returns the inclusive lower bound of the bucket containing value
+func bucketFor(value, bucketSize int) int {
+	if value < 0 {
+		value = 0
+	}
+	return (value / bucketSize) * bucketSize
+}
+
+// computeMetricPercentile computes the percentage of other submissions in the histogram that a value beats.
+// Submissions in higher buckets count as beaten and submissions sharing the value's bucket count as half,
+// since their exact position within the bucket is unknown. The value itself is excluded from the comparison.
+func computeMetricPercentile(buckets []*models.PerformanceBucket, value, bucketSize int) *MetricPercentile {
+	if buckets == nil {
+		buckets = []*models.PerformanceBucket{}
+	}
+
+	own := bucketFor(value, bucketSize)
+	total, worse, same := 0, 0, 0
+	for _, bucket := range buckets {
+		total += bucket.Count
+		switch {
+		case bucket.Bucket > own:
+			worse += bucket.Count
+		case bucket.Bucket == own:
+			same += bucket.Count
+		}
+	}
+
+	// Exclude the submission itself from its own bucket
+	if same > 0 {
+		same--
+	}
+	others := total - 1
+
+	beats := 100.0
+	if others > 0 {
+		beats = (float64(worse) + float64(same)/2) / float64(others) * 100
+		beats = math.Round(beats*100) / 100
+	}
+
+	return &MetricPercentile{
+		Value:      value,
+		Beats:      beats,
+		Total:      total,
+		BucketSize: bucketSize,
+		Buckets:    buckets,
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"leetcode-clone-backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPerformanceHistogramRepository struct {
+	mock.Mock
+}
+
+func (m *MockPerformanceHistogramRepository) Increment(problemID int, language, metric string, bucket int) error {
+	args := m.Called(problemID, language, metric, bucket)
+	return args.Error(0)
+}
+
+func (m *MockPerformanceHistogramRepository) GetBuckets(problemID int, language, metric string) ([]*models.PerformanceBucket, error) {
+	args := m.Called(problemID, language, metric)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.PerformanceBucket), args.Error(1)
+}
+
+func (m *MockPerformanceHistogramRepository) RebuildProblem(problemID int) error {
+	args := m.Called(problemID)
+	return args.Error(0)
+}
+
+func TestPercentileService_RecordAccepted(t *testing.T) {
+	runtime := 57
+	memory := 1300
+
+	t.Run("records runtime and memory buckets", func(t *testing.T) {
+		mockRepo := new(MockPerformanceHistogramRepository)
+		service := NewPercentileService(mockRepo)
+
+		mockRepo.On("Increment", 3, models.LanguagePython, models.PerformanceMetricRuntime, 50).Return(nil)
+		mockRepo.On("Increment", 3, models.LanguagePython, models.PerformanceMetricMemory, 1280).Return(nil)
+
+		err := service.RecordAccepted(&models.Submission{
+			ProblemID: 3,
+			Language:  models.LanguagePython,
+			Status:    models.StatusAccepted,
+			RuntimeMs: &runtime,
+			MemoryKb:  &memory,
+		})
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("ignores submissions that were not accepted", func(t *testing.T) {
+		mockRepo := new(MockPerformanceHistogramRepository)
+		service := NewPercentileService(mockRepo)
+
+		err := service.RecordAccepted(&models.Submission{
+			ProblemID: 3,
+			Language:  models.LanguagePython,
+			Status:    models.StatusWrongAnswer,
+			RuntimeMs: &runtime,
+		})
+
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "Increment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepo := new(MockPerformanceHistogramRepository)
+		service := NewPercentileService(mockRepo)
+
+		mockRepo.On("Increment", 3, models.LanguagePython, models.PerformanceMetricRuntime, 50).Return(errors.New("database error"))
+
+		err := service.RecordAccepted(&models.Submission{
+			ProblemID: 3,
+			Language:  models.LanguagePython,
+			Status:    models.StatusAccepted,
+			RuntimeMs: &runtime,
+		})
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to record runtime")
+	})
+}
+
+func TestPercentileService_GetPercentiles(t *testing.T) {
+	mockRepo := new(MockPerformanceHistogramRepository)
+	service := NewPercentileService(mockRepo)
+
+	runtime := 25
+	buckets := []*models.PerformanceBucket{
+		{Bucket: 10, Count: 2},
+		{Bucket: 20, Count: 3},
+		{Bucket: 40, Count: 5},
+	}
+	mockRepo.On("GetBuckets", 1, models.LanguageJava, models.PerformanceMetricRuntime).Return(buckets, nil)
+
+	percentiles, err := service.GetPercentiles(&models.Submission{
+		ProblemID: 1,
+		Language:  models.LanguageJava,
+		Status:    models.StatusAccepted,
+		RuntimeMs: &runtime,
+	})
+
+	assert.NoError(t, err)
+	assert.NotNil(t, percentiles.Runtime)
+	assert.Nil(t, percentiles.Memory)
+	assert.Equal(t, 10, percentiles.Runtime.Total)
+	assert.Equal(t, models.RuntimeBucketMs, percentiles.Runtime.BucketSize)
+	assert.Equal(t, buckets, percentiles.Runtime.Buckets)
+	// 5 slower submissions and 2 others in the same bucket counted as half, out of 9 others
+	assert.InDelta(t, 66.67, percentiles.Runtime.Beats, 0.001)
+}
+
+func TestComputeMetricPercentile(t *testing.T) {
+	tests := []struct {
+		name    string
+		buckets []*models.PerformanceBucket
+		value   int
+		beats   float64
+	}{
+		{
+			name:    "only submission",
+			buckets: []*models.PerformanceBucket{{Bucket: 0, Count: 1}},
+			value:   5,
+			beats:   100,
+		},
+		{
+			name:    "fastest bucket",
+			buckets: []*models.PerformanceBucket{{Bucket: 0, Count: 1}, {Bucket: 10, Count: 4}},
+			value:   3,
+			beats:   100,
+		},
+		{
+			name:    "slowest bucket",
+			buckets: []*models.PerformanceBucket{{Bucket: 0, Count: 4}, {Bucket: 10, Count: 1}},
+			value:   15,
+			beats:   0,
+		},
+		{
+			name:    "tied with everyone",
+			buckets: []*models.PerformanceBucket{{Bucket: 10, Count: 5}},
+			value:   12,
+			beats:   50,
+		},
+		{
+			name:    "empty histogram",
+			buckets: nil,
+			value:   12,
+			beats:   100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := computeMetricPercentile(tt.buckets, tt.value, 10)
+
+			assert.Equal(t, tt.beats, result.Beats)
+			assert.Equal(t, tt.value, result.Value)
+			assert.NotNil(t, result.Buckets)
+		})
+	}
+}
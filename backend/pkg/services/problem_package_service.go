@@ -0,0 +1,368 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/problempkg"
+	"leetcode-clone-backend/pkg/repository"
+	"leetcode-clone-backend/pkg/storage"
+)
+
+// Outcomes of a package import
+const (
+	PackageImportCreated   = "created"
+	PackageImportUpdated   = "updated"
+	PackageImportUnchanged = "unchanged"
+)
+
+// PackageImportResult is the outcome of importing a problem package
+type PackageImportResult struct {
+	Action   string          `json:"action"`
+	Problem  *models.Problem `json:"problem"`
+	Revision int             `json:"revision,omitempty"` // Revision recorded by the import, if it changed the problem
+}
+
+// ValidatePackage checks that a package is complete and describes a valid problem, and that the
+// input of every test passes the validator of the package, if it has one. The validator is only
+// run when the service has an execution service. It returns a *problempkg.ValidationError listing
+// every issue, or nil.
+func (s *ProblemService) ValidatePackage(pkg *problempkg.Package) error {
+	if err := pkg.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.validateProblem(pkg.Problem()); err != nil {
+		return &problempkg.ValidationError{Issues: []string{err.Error()}}
+	}
+
+	var issues []string
+	for _, program := range pkg.TestPrograms() {
+		if err := s.validateTestProgram(program); err != nil {
+			issues = append(issues, err.Error())
+		}
+	}
+	if len(issues) > 0 {
+		return &problempkg.ValidationError{Issues: issues}
+	}
+
+	if s.executionService == nil {
+		return nil
+	}
+	return s.validatePackageInputs(pkg)
+}
+
+// ImportPackage creates or updates the problem of a package, matched by slug, and records the
+// change as a revision authored by authorID. Importing the same package again changes nothing.
+// New problems are drafts; updated problems keep their lifecycle state. The test cases, reference
+// solutions, test programs and judging settings of the problem are replaced by those of the
+// package. Every test must pass the validator of the package, if it has one.
+func (s *ProblemService) ImportPackage(pkg *problempkg.Package, authorID int) (*PackageImportResult, error) {
+	if err := s.ValidatePackage(pkg); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.Problem.GetBySlug(pkg.Manifest.Slug)
+	if err != nil {
+		if !repository.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get problem by slug: %w", err)
+		}
+		return s.createFromPackage(pkg, authorID)
+	}
+
+	return s.updateFromPackage(existing, pkg, authorID)
+}
+
+// ExportPackage builds the package of a problem, whatever its lifecycle state
+func (s *ProblemService) ExportPackage(id int) (*problempkg.Package, error) {
+	problem, err := s.repo.Problem.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get problem: %w", err)
+	}
+
+	testCases, err := s.repo.TestCase.GetByProblemID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test cases: %w", err)
+	}
+
+	solutions, err := s.repo.ProblemSolution.GetByProblemID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reference solutions: %w", err)
+	}
+
+	programs, err := s.repo.TestProgram.GetByProblemID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test programs: %w", err)
+	}
+
+	settings, err := s.repo.JudgeSettings.GetByProblemID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get judging settings: %w", err)
+	}
+
+	return problempkg.FromProblem(problem, settings, testCases, solutions, programs), nil
+}
+
+// validatePackageInputs runs the validator of a package, if it has one, on the input of every
+// test and returns a *problempkg.ValidationError listing those it rejects
+func (s *ProblemService) validatePackageInputs(pkg *problempkg.Package) error {
+	program, exists := pkg.Programs[models.TestProgramValidator]
+	if !exists {
+		return nil
+	}
+
+	inputs := make([]string, len(pkg.Tests))
+	for i, test := range pkg.Tests {
+		inputs[i] = test.Input
+	}
+	validator := &models.TestProgram{Kind: models.TestProgramValidator, Language: program.Language, Code: program.Code}
+	violations, err := s.inputViolations(validator, inputs)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	issues := make([]string, len(violations))
+	for i, violation := range violations {
+		issues[i] = fmt.Sprintf("tests/%s.in: input violates the constraints: %s", pkg.Tests[violation.index].Name, violation.message)
+	}
+	return &problempkg.ValidationError{Issues: issues}
+}
+
+// createFromPackage creates a draft problem from a package
+func (s *ProblemService) createFromPackage(pkg *problempkg.Package, authorID int) (*PackageImportResult, error) {
+	problem := pkg.Problem()
+	problem.State = models.ProblemStateDraft
+
+	result := &PackageImportResult{Action: PackageImportCreated}
+	err := s.repo.WithTx(context.Background(), func(tx *repository.Repository) error {
+		created, err := tx.Problem.Create(problem)
+		if err != nil {
+			return fmt.Errorf("failed to create problem: %w", err)
+		}
+		result.Problem = created
+
+		for _, testCase := range pkg.TestCases() {
+			testCase.ProblemID = created.ID
+			if _, err := tx.TestCase.Create(testCase); err != nil {
+				return fmt.Errorf("failed to create test case: %w", err)
+			}
+		}
+
+		if len(pkg.Solutions) > 0 {
+			if _, err := tx.ProblemSolution.Replace(created.ID, pkg.ProblemSolutions()); err != nil {
+				return fmt.Errorf("failed to save reference solutions: %w", err)
+			}
+		}
+
+		for _, program := range pkg.TestPrograms() {
+			program.ProblemID = created.ID
+			if _, err := tx.TestProgram.Upsert(program); err != nil {
+				return fmt.Errorf("failed to save %s: %w", program.Kind, err)
+			}
+		}
+
+		settings := pkg.JudgeSettings()
+		settings.ProblemID = created.ID
+		if _, err := tx.JudgeSettings.Upsert(settings); err != nil {
+			return fmt.Errorf("failed to save judging settings: %w", err)
+		}
+
+		change := revisionChange{authorID: authorID, action: models.RevisionActionImport}
+		revision, err := recordProblemRevision(tx, created.ID, change)
+		if err != nil {
+			return err
+		}
+		if revision != nil {
+			result.Revision = revision.Revision
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// updateFromPackage updates an existing problem to match a package, changing only what differs
+func (s *ProblemService) updateFromPackage(existing *models.Problem, pkg *problempkg.Package, authorID int) (*PackageImportResult, error) {
+	problem := pkg.Problem()
+	problem.ID = existing.ID
+
+	result := &PackageImportResult{Action: PackageImportUnchanged, Problem: existing}
+	solutionsChanged, programsChanged, settingsChanged := false, false, false
+
+	change := revisionChange{authorID: authorID, action: models.RevisionActionImport}
+	revisions, err := withProblemRevisions(s.repo, []int{existing.ID}, change, func(tx *repository.Repository) error {
+		// Saving an unchanged problem would still bump its update time
+		if len(changedFieldNames(newProblemSnapshot(existing, nil), newProblemSnapshot(problem, nil))) > 0 {
+			updated, err := tx.Problem.Update(problem)
+			if err != nil {
+				return fmt.Errorf("failed to update problem: %w", err)
+			}
+			result.Problem = updated
+		}
+
+		if err := syncTestCases(tx, existing.ID, pkg.TestCases()); err != nil {
+			return err
+		}
+
+		current, err := tx.ProblemSolution.GetByProblemID(existing.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get reference solutions: %w", err)
+		}
+		solutions := pkg.ProblemSolutions()
+		if !sameSolutions(current, solutions) {
+			if _, err := tx.ProblemSolution.Replace(existing.ID, solutions); err != nil {
+				return fmt.Errorf("failed to save reference solutions: %w", err)
+			}
+			if err := tx.TestCase.ResetOutputStatus(existing.ID); err != nil {
+				return fmt.Errorf("failed to reset expected output status: %w", err)
+			}
+			solutionsChanged = true
+		}
+
+		programsChanged, err = syncTestPrograms(tx, existing.ID, pkg.TestPrograms())
+		if err != nil {
+			return err
+		}
+
+		stored, err := tx.JudgeSettings.GetByProblemID(existing.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get judging settings: %w", err)
+		}
+		settings := pkg.JudgeSettings()
+		settings.ProblemID = existing.ID
+		if !sameJudgeSettings(stored, settings) {
+			if _, err := tx.JudgeSettings.Upsert(settings); err != nil {
+				return fmt.Errorf("failed to save judging settings: %w", err)
+			}
+			settingsChanged = true
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if revisions[0] != nil {
+		result.Revision = revisions[0].Revision
+	}
+	if revisions[0] != nil || solutionsChanged || programsChanged || settingsChanged {
+		result.Action = PackageImportUpdated
+	}
+
+	return result, nil
+}
+
+// syncTestCases makes the test cases of a problem match testCases in order. Test cases are
+// matched by position and compared by the hashes of their data, so only those that differ are
+// updated, and test cases beyond the end of testCases are deleted.
+func syncTestCases(tx *repository.Repository, problemID int, testCases []*models.TestCase) error {
+	current, err := tx.TestCase.ListByProblemID(problemID)
+	if err != nil {
+		return fmt.Errorf("failed to get test cases: %w", err)
+	}
+
+	for i, testCase := range testCases {
+		testCase.ProblemID = problemID
+		if i >= len(current) {
+			if _, err := tx.TestCase.Create(testCase); err != nil {
+				return fmt.Errorf("failed to create test case: %w", err)
+			}
+			continue
+		}
+
+		old := current[i]
+		if old.InputHash == storage.Hash([]byte(testCase.Input)) && old.OutputHash == storage.Hash([]byte(testCase.ExpectedOutput)) &&
+			old.IsHidden == testCase.IsHidden {
+			continue
+		}
+		testCase.ID = old.ID
+		if _, err := tx.TestCase.Update(testCase); err != nil {
+			return fmt.Errorf("failed to update test case: %w", err)
+		}
+	}
+
+	for _, old := range current[min(len(testCases), len(current)):] {
+		if err := tx.TestCase.Delete(old.ID); err != nil {
+			return fmt.Errorf("failed to delete test case: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// syncTestPrograms makes the test programs of a problem match programs and reports whether any
+// changed
+func syncTestPrograms(tx *repository.Repository, problemID int, programs []*models.TestProgram) (bool, error) {
+	current, err := tx.TestProgram.GetByProblemID(problemID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get test programs: %w", err)
+	}
+
+	byKind := make(map[string]*models.TestProgram, len(current))
+	for _, program := range current {
+		byKind[program.Kind] = program
+	}
+
+	changed := false
+	for _, program := range programs {
+		old, exists := byKind[program.Kind]
+		delete(byKind, program.Kind)
+		if exists && old.Language == program.Language && old.Code == program.Code {
+			continue
+		}
+		program.ProblemID = problemID
+		if _, err := tx.TestProgram.Upsert(program); err != nil {
+			return false, fmt.Errorf("failed to save %s: %w", program.Kind, err)
+		}
+		changed = true
+	}
+	for kind := range byKind {
+		if err := tx.TestProgram.Delete(problemID, kind); err != nil {
+			return false, fmt.Errorf("failed to delete %s: %w", kind, err)
+		}
+		changed = true
+	}
+
+	return changed, nil
+}
+
+// sameSolutions reports whether two sets of reference solutions have the same names, languages
+// and code
+func sameSolutions(current, solutions []*models.ProblemSolution) bool {
+	if len(current) != len(solutions) {
+		return false
+	}
+
+	byName := make(map[string]*models.ProblemSolution, len(current))
+	for _, solution := range current {
+		byName[solution.Name] = solution
+	}
+	for _, solution := range solutions {
+		old, exists := byName[solution.Name]
+		if !exists || old.Language != solution.Language || old.Code != solution.Code {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sameJudgeSettings reports whether two judging settings have the same limits and checker
+func sameJudgeSettings(a, b *models.JudgeSettings) bool {
+	return a.ProblemID == b.ProblemID &&
+		a.TimeLimitMs == b.TimeLimitMs &&
+		a.MemoryLimitMb == b.MemoryLimitMb &&
+		maps.Equal(a.LanguageLimits, b.LanguageLimits) &&
+		a.Checker == b.Checker &&
+		a.IgnoreCase == b.IgnoreCase &&
+		a.FloatTolerance == b.FloatTolerance
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/problempkg"
+)
+
+type mockProblemSolutionRepository struct {
+	solutions map[int][]*models.ProblemSolution
+	replaced  int
+}
+
+func newMockProblemSolutionRepository() *mockProblemSolutionRepository {
+	return &mockProblemSolutionRepository{solutions: make(map[int][]*models.ProblemSolution)}
+}
+
+func (m *mockProblemSolutionRepository) GetByProblemID(problemID int) ([]*models.ProblemSolution, error) {
+	return m.solutions[problemID], nil
+}
+
+func (m *mockProblemSolutionRepository) Replace(problemID int, solutions []*models.ProblemSolution) ([]*models.ProblemSolution, error) {
+	m.replaced++
+	var replaced []*models.ProblemSolution
+	for _, solution := range solutions {
+		saved := *solution
+		saved.ProblemID = problemID
+		replaced = append(replaced, &saved)
+	}
+	m.solutions[problemID] = replaced
+	return replaced, nil
+}
+
+// testPackage returns a valid package with a public and a hidden test and a reference solution
+func testPackage() *problempkg.Package {
+	return &problempkg.Package{
+		Manifest: problempkg.Manifest{
+			FormatVersion: problempkg.FormatVersion,
+			Slug:          "two-sum",
+			Title:         "Two Sum",
+			Difficulty:    models.DifficultyEasy,
+			Tags:          []string{"Array"},
+			Examples:      []problempkg.Example{{Input: "[2,7,11,15], 9", Output: "[0,1]"}},
+			PublicTests:   []string{"01"},
+		},
+		Statement: "Find two numbers that add up to target",
+		Tests: []problempkg.Test{
+			{Name: "01", Input: "[2,7,11,15]\n9", Output: "[0,1]", Public: true},
+			{Name: "02", Input: "[3,3]\n6", Output: "[0,1]"},
+		},
+		Templates: map[string]string{models.LanguagePython: "def two_sum(nums, target):\n    pass"},
+		Solutions: []problempkg.Solution{{Name: "main", Language: models.LanguagePython, Code: "print('[0,1]')"}},
+	}
+}
+
+func TestProblemService_ImportPackage(t *testing.T) {
+	t.Run("creates a draft problem", func(t *testing.T) {
+		service, _, testCaseRepo, revisionRepo := newRevisionTestService()
+
+		result, err := service.ImportPackage(testPackage(), 4)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if result.Action != PackageImportCreated || result.Problem.State != models.ProblemStateDraft {
+			t.Errorf("Expected a created draft, got %q %q", result.Action, result.Problem.State)
+		}
+		testCases, _ := testCaseRepo.GetByProblemID(result.Problem.ID)
+		if len(testCases) != 2 || testCases[0].IsHidden || !testCases[1].IsHidden {
+			t.Errorf("Expected a public and a hidden test case, got %+v", testCases)
+		}
+		if result.Revision != 1 || len(revisionRepo.revisions) != 1 || revisionRepo.revisions[0].Action != models.RevisionActionImport {
+			t.Errorf("Expected the import recorded as revision 1, got %d of %d", result.Revision, len(revisionRepo.revisions))
+		}
+	})
+
+	t.Run("importing again changes nothing", func(t *testing.T) {
+		service, _, _, revisionRepo := newRevisionTestService()
+		solutionRepo := service.repo.ProblemSolution.(*mockProblemSolutionRepository)
+
+		first, _ := service.ImportPackage(testPackage(), 4)
+		result, err := service.ImportPackage(testPackage(), 4)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if result.Action != PackageImportUnchanged || result.Problem.ID != first.Problem.ID {
+			t.Errorf("Expected problem %d unchanged, got %q problem %d", first.Problem.ID, result.Action, result.Problem.ID)
+		}
+		if len(revisionRepo.revisions) != 1 || solutionRepo.replaced != 1 {
+			t.Errorf("Expected no new revision or solutions, got %d revisions and %d replacements", len(revisionRepo.revisions), solutionRepo.replaced)
+		}
+	})
+
+	t.Run("updates a problem by slug", func(t *testing.T) {
+		service, problemRepo, testCaseRepo, _ := newRevisionTestService()
+		first, _ := service.ImportPackage(testPackage(), 4)
+		problemRepo.problems[first.Problem.ID].State = models.ProblemStatePublished
+		before, _ := testCaseRepo.GetByProblemID(first.Problem.ID)
+
+		pkg := testPackage()
+		pkg.Manifest.Title = "Two Sum Again"
+		pkg.Tests = pkg.Tests[:1]
+		pkg.Tests[0].Output = "[1,0]"
+
+		result, err := service.ImportPackage(pkg, 4)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if result.Action != PackageImportUpdated || result.Revision != 2 {
+			t.Errorf("Expected an update recorded as revision 2, got %q %d", result.Action, result.Revision)
+		}
+		if result.Problem.Title != "Two Sum Again" || result.Problem.State != models.ProblemStatePublished {
+			t.Errorf("Expected the title updated and the state kept, got %q %q", result.Problem.Title, result.Problem.State)
+		}
+		testCases, _ := testCaseRepo.GetByProblemID(first.Problem.ID)
+		if len(testCases) != 1 || testCases[0].ID != before[0].ID || testCases[0].ExpectedOutput != "[1,0]" {
+			t.Errorf("Expected the first test case updated in place and the second deleted, got %+v", testCases)
+		}
+	})
+
+	t.Run("rejects invalid packages", func(t *testing.T) {
+		service, problemRepo, _, _ := newRevisionTestService()
+		pkg := testPackage()
+		pkg.Statement = ""
+		pkg.Templates = nil
+
+		_, err := service.ImportPackage(pkg, 4)
+
+		var validationErr *problempkg.ValidationError
+		if !errors.As(err, &validationErr) || len(validationErr.Issues) != 2 {
+			t.Fatalf("Expected 2 validation issues, got %v", err)
+		}
+		if len(problemRepo.problems) != 0 {
+			t.Errorf("Expected no problem created, got %d", len(problemRepo.problems))
+		}
+	})
+}
+
+func TestProblemService_ExportPackage(t *testing.T) {
+	service, _, _, _ := newRevisionTestService()
+	imported, _ := service.ImportPackage(testPackage(), 4)
+
+	pkg, err := service.ExportPackage(imported.Problem.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := pkg.Validate(); err != nil {
+		t.Errorf("Expected exported package to be valid, got %v", err)
+	}
+	if pkg.Manifest.Slug != "two-sum" || len(pkg.Tests) != 2 || len(pkg.Solutions) != 1 {
+		t.Errorf("Expected the problem with 2 tests and a solution, got %q %d %d", pkg.Manifest.Slug, len(pkg.Tests), len(pkg.Solutions))
+	}
+	if strings.Join(pkg.Manifest.PublicTests, ",") != "01" {
+		t.Errorf("Expected test 01 public, got %v", pkg.Manifest.PublicTests)
+	}
+
+	if _, err := service.ExportPackage(99); err == nil {
+		t.Error("Expected error for a missing problem")
+	}
+}
@@ -0,0 +1,521 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"leetcode-clone-backend/pkg/diff"
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/pagination"
+	"leetcode-clone-backend/pkg/repository"
+)
+
+// defaultRevisionPageSize is the number of revisions listed per page unless the client asks for more
+const defaultRevisionPageSize = 20
+
+// ProblemRevisionListResponse represents a paginated list of problem revisions
+type ProblemRevisionListResponse struct {
+	Revisions []*models.ProblemRevision `json:"revisions"`
+	pagination.Meta
+}
+
+// ProblemRevisionDetails is a revision with its snapshot and the changes since the previous revision
+type ProblemRevisionDetails struct {
+	*models.ProblemRevision
+	Changes []*ProblemFieldDiff `json:"changes"`
+}
+
+// ProblemRevisionDiff is the difference between two revisions of a problem
+type ProblemRevisionDiff struct {
+	From   *models.ProblemRevision `json:"from"`
+	To     *models.ProblemRevision `json:"to"`
+	Fields []*ProblemFieldDiff     `json:"fields"`
+}
+
+// ProblemFieldDiff is the difference in one field of a problem between two revisions
+type ProblemFieldDiff struct {
+	Field string       `json:"field"`
+	Diff  *diff.Result `json:"diff"`
+}
+
+// revisionChange describes how a change to problems is recorded in their revisions
+type revisionChange struct {
+	authorID     int
+	action       string
+	restoredFrom *int
+}
+
+// ListRevisions retrieves a page of the revisions of a problem, newest first
+func (s *ProblemService) ListRevisions(problemID int, params pagination.Params) (*ProblemRevisionListResponse, error) {
+	if params.Limit < 1 || params.Limit > pagination.MaxLimit {
+		params.Limit = defaultRevisionPageSize
+	}
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Offset <= 0 {
+		params.Offset = (params.Page - 1) * params.Limit
+	}
+	if params.Cursor != nil {
+		if err := params.Cursor.Check(repository.ProblemRevisionSort); err != nil {
+			return nil, fmt.Errorf("invalid pagination: %w", err)
+		}
+	}
+
+	if _, err := s.repo.Problem.GetByID(problemID); err != nil {
+		return nil, fmt.Errorf("failed to retrieve problem: %w", err)
+	}
+
+	total, err := s.repo.ProblemRevision.Count(problemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count revisions: %w", err)
+	}
+
+	// Fetch one extra revision to know whether there is a next page
+	revisions, err := s.repo.ProblemRevision.List(repository.ProblemRevisionFilters{
+		ProblemID: problemID,
+		Limit:     params.Limit + 1,
+		Offset:    params.Offset,
+		Cursor:    params.Cursor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+
+	hasNext := len(revisions) > params.Limit
+	if hasNext {
+		revisions = revisions[:params.Limit]
+	}
+	if revisions == nil {
+		revisions = []*models.ProblemRevision{}
+	}
+
+	var next *pagination.Cursor
+	if len(revisions) > 0 {
+		next = repository.NewProblemRevisionCursor(revisions[len(revisions)-1])
+	}
+
+	return &ProblemRevisionListResponse{
+		Revisions: revisions,
+		Meta:      pagination.NewMeta(params, total, hasNext, next),
+	}, nil
+}
+
+// GetRevision retrieves a revision of a problem with its snapshot and the changes it made to
+// the previous revision
+func (s *ProblemService) GetRevision(problemID, revision int) (*ProblemRevisionDetails, error) {
+	current, err := s.repo.ProblemRevision.Get(problemID, revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve revision: %w", err)
+	}
+
+	previous := &models.ProblemSnapshot{}
+	if revision > 1 {
+		prev, err := s.repo.ProblemRevision.Get(problemID, revision-1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve revision: %w", err)
+		}
+		previous = prev.Snapshot
+	}
+
+	return &ProblemRevisionDetails{
+		ProblemRevision: current,
+		Changes:         diffSnapshots(previous, current.Snapshot, revision-1, revision),
+	}, nil
+}
+
+// DiffRevisions computes the difference between two revisions of a problem, field by field.
+// Only fields that differ are included.
+func (s *ProblemService) DiffRevisions(problemID, from, to int) (*ProblemRevisionDiff, error) {
+	fromRevision, err := s.repo.ProblemRevision.Get(problemID, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve revision %d: %w", from, err)
+	}
+	toRevision, err := s.repo.ProblemRevision.Get(problemID, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve revision %d: %w", to, err)
+	}
+
+	result := &ProblemRevisionDiff{
+		Fields: diffSnapshots(fromRevision.Snapshot, toRevision.Snapshot, from, to),
+	}
+
+	// The snapshots are represented by the diff
+	fromRevision.Snapshot = nil
+	toRevision.Snapshot = nil
+	result.From = fromRevision
+	result.To = toRevision
+
+	return result, nil
+}
+
+// RestoreRevision restores the fields and test cases of a problem to a revision. The restore is
+// recorded as a new revision, so it can be undone like any other change. Test cases deleted
+// since the revision are created again with new IDs.
+func (s *ProblemService) RestoreRevision(problemID, revision, authorID int) (*models.ProblemRevision, error) {
+	target, err := s.repo.ProblemRevision.Get(problemID, revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve revision: %w", err)
+	}
+	snapshot := target.Snapshot
+
+	change := revisionChange{authorID: authorID, action: models.RevisionActionRestore, restoredFrom: &revision}
+	revisions, err := withProblemRevisions(s.repo, []int{problemID}, change, func(tx *repository.Repository) error {
+		_, err := tx.Problem.Update(&models.Problem{
+			ID:           problemID,
+			Title:        snapshot.Title,
+			Slug:         snapshot.Slug,
+			Description:  snapshot.Description,
+			Difficulty:   snapshot.Difficulty,
+			Tags:         snapshot.Tags,
+			Examples:     snapshot.Examples,
+			Constraints:  snapshot.Constraints,
+			TemplateCode: snapshot.TemplateCode,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to restore problem: %w", err)
+		}
+
+		current, err := tx.TestCase.ListByProblemID(problemID)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve test cases: %w", err)
+		}
+
+		restored := make(map[int]bool, len(snapshot.TestCases))
+		for _, testCase := range snapshot.TestCases {
+			restored[testCase.ID] = true
+		}
+		existing := make(map[int]bool, len(current))
+		for _, testCase := range current {
+			existing[testCase.ID] = true
+			if !restored[testCase.ID] {
+				if err := tx.TestCase.Delete(testCase.ID); err != nil {
+					return fmt.Errorf("failed to delete test case: %w", err)
+				}
+			}
+		}
+
+		for _, testCase := range snapshot.TestCases {
+			restoredCase := &models.TestCase{
+				ID:             testCase.ID,
+				ProblemID:      problemID,
+				Input:          testCase.Input,
+				ExpectedOutput: testCase.ExpectedOutput,
+				InputHash:      testCase.InputHash,
+				OutputHash:     testCase.OutputHash,
+				IsHidden:       testCase.IsHidden,
+			}
+			if existing[testCase.ID] {
+				_, err = tx.TestCase.Update(restoredCase)
+			} else {
+				_, err = tx.TestCase.Create(restoredCase)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to restore test case: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions[0], nil
+}
+
+// withProblemRevisions runs fn in a transaction and records a revision of each problem it
+// changed. Problems changed before revisions were recorded first get a baseline revision of
+// their state before fn. The returned revisions are nil for problems fn did not change, except
+// for restores, which are always recorded.
+func withProblemRevisions(repo *repository.Repository, problemIDs []int, change revisionChange, fn func(tx *repository.Repository) error) ([]*models.ProblemRevision, error) {
+	// Problems are locked in ID order so that concurrent changes cannot deadlock
+	ids := append([]int(nil), problemIDs...)
+	sort.Ints(ids)
+
+	var revisions []*models.ProblemRevision
+	err := repo.WithTx(context.Background(), func(tx *repository.Repository) error {
+		for _, id := range ids {
+			if err := tx.ProblemRevision.LockProblem(id); err != nil {
+				return fmt.Errorf("failed to lock problem: %w", err)
+			}
+			if err := ensureProblemBaseline(tx, id); err != nil {
+				return err
+			}
+		}
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		revisions = make([]*models.ProblemRevision, len(problemIDs))
+		for i, id := range problemIDs {
+			revision, err := recordProblemRevision(tx, id, change)
+			if err != nil {
+				return err
+			}
+			revisions[i] = revision
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// ensureProblemBaseline records the current state of a problem without revisions, so that the
+// first recorded change can be diffed and undone
+func ensureProblemBaseline(tx *repository.Repository, problemID int) error {
+	_, err := tx.ProblemRevision.GetLatest(problemID)
+	if err == nil {
+		return nil
+	}
+	if !repository.IsNotFound(err) {
+		return fmt.Errorf("failed to retrieve latest revision: %w", err)
+	}
+
+	snapshot, err := loadProblemSnapshot(tx, problemID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ProblemRevision.Create(&models.ProblemRevision{
+		ProblemID:     problemID,
+		Action:        models.RevisionActionBaseline,
+		ChangedFields: changedFields(&models.ProblemSnapshot{}, snapshot),
+		Snapshot:      snapshot,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record baseline revision: %w", err)
+	}
+
+	return nil
+}
+
+// recordProblemRevision records the current state of a problem as a new revision. Nothing is
+// recorded if the problem did not change since its latest revision, unless it was restored.
+func recordProblemRevision(tx *repository.Repository, problemID int, change revisionChange) (*models.ProblemRevision, error) {
+	snapshot, err := loadProblemSnapshot(tx, problemID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := &models.ProblemSnapshot{}
+	latest, err := tx.ProblemRevision.GetLatest(problemID)
+	if err == nil {
+		previous = latest.Snapshot
+	} else if !repository.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to retrieve latest revision: %w", err)
+	}
+
+	changed := changedFields(previous, snapshot)
+	if len(changed) == 0 && change.action != models.RevisionActionRestore {
+		return nil, nil
+	}
+
+	revision := &models.ProblemRevision{
+		ProblemID:     problemID,
+		Action:        change.action,
+		ChangedFields: changed,
+		RestoredFrom:  change.restoredFrom,
+		Snapshot:      snapshot,
+	}
+	if change.authorID != 0 {
+		authorID := change.authorID
+		revision.AuthorID = &authorID
+	}
+
+	created, err := tx.ProblemRevision.Create(revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record revision: %w", err)
+	}
+
+	return created, nil
+}
+
+// loadProblemSnapshot reads the current state of a problem and its test cases. Test data kept
+// in the test data store is not read, as snapshots refer to it by hash.
+func loadProblemSnapshot(tx *repository.Repository, problemID int) (*models.ProblemSnapshot, error) {
+	problem, err := tx.Problem.GetByID(problemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve problem: %w", err)
+	}
+
+	testCases, err := tx.TestCase.GetInlineByProblemID(problemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve test cases: %w", err)
+	}
+
+	return newProblemSnapshot(problem, testCases), nil
+}
+
+// newProblemSnapshot builds the snapshot of a problem, with test cases in ID order
+func newProblemSnapshot(problem *models.Problem, testCases []*models.TestCase) *models.ProblemSnapshot {
+	snapshot := &models.ProblemSnapshot{
+		Title:        problem.Title,
+		Slug:         problem.Slug,
+		Description:  problem.Description,
+		Difficulty:   problem.Difficulty,
+		Tags:         append([]string{}, problem.Tags...),
+		Examples:     problem.Examples,
+		Constraints:  problem.Constraints,
+		TemplateCode: problem.TemplateCode,
+		TestCases:    make([]models.SnapshotTestCase, 0, len(testCases)),
+	}
+
+	for _, testCase := range testCases {
+		snapshotCase := models.SnapshotTestCase{
+			ID:             testCase.ID,
+			Input:          testCase.Input,
+			ExpectedOutput: testCase.ExpectedOutput,
+			IsHidden:       testCase.IsHidden,
+		}
+		if testCase.InputStored {
+			snapshotCase.Input, snapshotCase.InputHash, snapshotCase.InputSize = "", testCase.InputHash, testCase.InputSize
+		}
+		if testCase.OutputStored {
+			snapshotCase.ExpectedOutput, snapshotCase.OutputHash, snapshotCase.OutputSize = "", testCase.OutputHash, testCase.OutputSize
+		}
+		snapshot.TestCases = append(snapshot.TestCases, snapshotCase)
+	}
+	sort.Slice(snapshot.TestCases, func(i, j int) bool {
+		return snapshot.TestCases[i].ID < snapshot.TestCases[j].ID
+	})
+
+	return snapshot
+}
+
+// snapshotField is a field of a snapshot rendered as text for diffing
+type snapshotField struct {
+	name string
+	text string
+}
+
+// snapshotFields renders the fields of a snapshot as text, in a fixed order. Each template
+// language is a separate field, named template_code.<language>.
+func snapshotFields(snapshot *models.ProblemSnapshot) []snapshotField {
+	fields := []snapshotField{
+		{"title", snapshot.Title},
+		{"slug", snapshot.Slug},
+		{"difficulty", snapshot.Difficulty},
+		{"tags", strings.Join(snapshot.Tags, "\n")},
+		{"description", snapshot.Description},
+		{"examples", examplesText(snapshot.Examples)},
+		{"constraints", snapshot.Constraints},
+	}
+
+	languages := make([]string, 0, len(snapshot.TemplateCode))
+	for language := range snapshot.TemplateCode {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	for _, language := range languages {
+		fields = append(fields, snapshotField{"template_code." + language, snapshot.TemplateCode[language]})
+	}
+
+	return append(fields, snapshotField{"test_cases", testCasesText(snapshot.TestCases)})
+}
+
+// examplesText renders examples as text, one block per example
+func examplesText(examples models.Examples) string {
+	blocks := make([]string, 0, len(examples))
+	for i, example := range examples {
+		block := fmt.Sprintf("Example %d\nInput: %s\nOutput: %s", i+1, example.Input, example.Output)
+		if example.Explanation != "" {
+			block += "\nExplanation: " + example.Explanation
+		}
+		blocks = append(blocks, block)
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// testCasesText renders test cases as text, one block per test case. Blocks are not numbered,
+// so adding or removing a test case does not show every following test case as changed. Test
+// data kept in the test data store is rendered by its size and hash.
+func testCasesText(testCases []models.SnapshotTestCase) string {
+	blocks := make([]string, 0, len(testCases))
+	for _, testCase := range testCases {
+		header := "Test case"
+		if testCase.IsHidden {
+			header += " (hidden)"
+		}
+		blocks = append(blocks, fmt.Sprintf("%s\nInput:\n%s\nExpected output:\n%s", header,
+			testDataText(testCase.Input, testCase.InputHash, testCase.InputSize),
+			testDataText(testCase.ExpectedOutput, testCase.OutputHash, testCase.OutputSize)))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// testDataText renders the test data of a snapshot, referring to stored data by size and hash
+func testDataText(data, hash string, size int64) string {
+	if hash != "" {
+		return fmt.Sprintf("[%d bytes, sha256 %s]", size, hash)
+	}
+	return strings.TrimSuffix(data, "\n")
+}
+
+// fieldTexts returns the fields of a snapshot by name, with the names in order
+func fieldTexts(snapshot *models.ProblemSnapshot) (map[string]string, []string) {
+	texts := make(map[string]string)
+	var names []string
+	for _, field := range snapshotFields(snapshot) {
+		texts[field.name] = field.text
+		names = append(names, field.name)
+	}
+	return texts, names
+}
+
+// changedFieldNames returns the names of the fields that differ between two snapshots, in the
+// order of snapshotFields, with the fields only in the old snapshot last
+func changedFieldNames(old, new *models.ProblemSnapshot) []string {
+	oldTexts, oldNames := fieldTexts(old)
+	newTexts, newNames := fieldTexts(new)
+
+	var changed []string
+	for _, name := range newNames {
+		if oldTexts[name] != newTexts[name] {
+			changed = append(changed, name)
+		}
+	}
+	for _, name := range oldNames {
+		if _, exists := newTexts[name]; !exists && oldTexts[name] != "" {
+			changed = append(changed, name)
+		}
+	}
+
+	return changed
+}
+
+// changedFields returns the names of the fields that differ between two snapshots
+func changedFields(old, new *models.ProblemSnapshot) []string {
+	changed := changedFieldNames(old, new)
+	if changed == nil {
+		return []string{}
+	}
+	return changed
+}
+
+// diffSnapshots diffs the fields that differ between two snapshots
+func diffSnapshots(old, new *models.ProblemSnapshot, oldRevision, newRevision int) []*ProblemFieldDiff {
+	oldTexts, _ := fieldTexts(old)
+	newTexts, _ := fieldTexts(new)
+
+	diffs := []*ProblemFieldDiff{}
+	for _, name := range changedFieldNames(old, new) {
+		diffs = append(diffs, &ProblemFieldDiff{
+			Field: name,
+			Diff: diff.Compute(
+				fmt.Sprintf("r%d/%s", oldRevision, name),
+				fmt.Sprintf("r%d/%s", newRevision, name),
+				oldTexts[name], newTexts[name], diff.DefaultContext,
+			),
+		})
+	}
+
+	return diffs
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/pagination"
+	"leetcode-clone-backend/pkg/repository"
+)
+
+type mockProblemRevisionRepository struct {
+	revisions []*models.ProblemRevision
+}
+
+func newMockProblemRevisionRepository() *mockProblemRevisionRepository {
+	return &mockProblemRevisionRepository{}
+}
+
+func (m *mockProblemRevisionRepository) LockProblem(problemID int) error {
+	return nil
+}
+
+func (m *mockProblemRevisionRepository) Create(revision *models.ProblemRevision) (*models.ProblemRevision, error) {
+	created := *revision
+	created.ID = len(m.revisions) + 1
+	created.Revision = 1
+	if latest, err := m.GetLatest(revision.ProblemID); err == nil {
+		created.Revision = latest.Revision + 1
+	}
+	created.CreatedAt = time.Now()
+	m.revisions = append(m.revisions, &created)
+	return &created, nil
+}
+
+func (m *mockProblemRevisionRepository) Get(problemID, revision int) (*models.ProblemRevision, error) {
+	for _, r := range m.revisions {
+		if r.ProblemID == problemID && r.Revision == revision {
+			found := *r
+			return &found, nil
+		}
+	}
+	return nil, repository.NewRepositoryError("Get", repository.ErrNotFound, "revision_not_found")
+}
+
+func (m *mockProblemRevisionRepository) GetLatest(problemID int) (*models.ProblemRevision, error) {
+	var latest *models.ProblemRevision
+	for _, r := range m.revisions {
+		if r.ProblemID == problemID {
+			latest = r
+		}
+	}
+	if latest == nil {
+		return nil, repository.NewRepositoryError("GetLatest", repository.ErrNotFound, "revision_not_found")
+	}
+	found := *latest
+	return &found, nil
+}
+
+func (m *mockProblemRevisionRepository) List(filters repository.ProblemRevisionFilters) ([]*models.ProblemRevision, error) {
+	var result []*models.ProblemRevision
+	for i := len(m.revisions) - 1; i >= 0; i-- {
+		r := m.revisions[i]
+		if r.ProblemID != filters.ProblemID {
+			continue
+		}
+		if filters.Cursor != nil && r.Revision >= filters.Cursor.ID {
+			continue
+		}
+		listed := *r
+		listed.Snapshot = nil
+		result = append(result, &listed)
+	}
+	if filters.Cursor == nil && filters.Offset > 0 {
+		if filters.Offset >= len(result) {
+			return nil, nil
+		}
+		result = result[filters.Offset:]
+	}
+	if filters.Limit > 0 && len(result) > filters.Limit {
+		result = result[:filters.Limit]
+	}
+	return result, nil
+}
+
+func (m *mockProblemRevisionRepository) Count(problemID int) (int, error) {
+	count := 0
+	for _, r := range m.revisions {
+		if r.ProblemID == problemID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func newRevisionTestService() (*ProblemService, *mockProblemRepository, *mockTestCaseRepository, *mockProblemRevisionRepository) {
+	problemRepo := newMockProblemRepository()
+	testCaseRepo := newMockTestCaseRepository()
+	revisionRepo := newMockProblemRevisionRepository()
+	service := NewProblemService(&repository.Repository{
+		Problem:         problemRepo,
+		TestCase:        testCaseRepo,
+		ProblemRevision: revisionRepo,
+		ProblemSolution: newMockProblemSolutionRepository(),
+		JudgeSettings:   newMockJudgeSettingsRepository(),
+		TestProgram:     newMockTestProgramRepository(),
+	}, nil)
+	return service, problemRepo, testCaseRepo, revisionRepo
+}
+
+func revisionTestProblem(title string) *models.Problem {
+	return &models.Problem{
+		Title:       title,
+		Slug:        "two-sum",
+		Description: "Find two numbers that add up to target",
+		Difficulty:  models.DifficultyEasy,
+		Tags:        []string{"Array"},
+		Examples: models.Examples{
+			{Input: "[2,7,11,15], 9", Output: "[0,1]"},
+		},
+		TemplateCode: models.TemplateCode{
+			models.LanguageJavaScript: "function twoSum(nums, target) {}",
+		},
+	}
+}
+
+func TestProblemService_Revisions_RecordChanges(t *testing.T) {
+	service, _, _, revisionRepo := newRevisionTestService()
+
+	created, err := service.CreateProblem(revisionTestProblem("Two Sum"), 7)
+	if err != nil {
+		t.Fatalf("Failed to create problem: %v", err)
+	}
+
+	update := revisionTestProblem("Two Sum II")
+	update.ID = created.ID
+	if _, err := service.UpdateProblem(update, 8); err != nil {
+		t.Fatalf("Failed to update problem: %v", err)
+	}
+
+	// Saving the problem unchanged records nothing
+	unchanged := revisionTestProblem("Two Sum II")
+	unchanged.ID = created.ID
+	if _, err := service.UpdateProblem(unchanged, 8); err != nil {
+		t.Fatalf("Failed to update problem: %v", err)
+	}
+
+	testCase, err := service.CreateTestCase(&models.TestCase{ProblemID: created.ID, Input: "1 2", ExpectedOutput: "3"}, 8)
+	if err != nil {
+		t.Fatalf("Failed to create test case: %v", err)
+	}
+	if err := service.DeleteTestCase(testCase.ID, 9); err != nil {
+		t.Fatalf("Failed to delete test case: %v", err)
+	}
+
+	expected := []struct {
+		action   string
+		authorID int
+		changed  string
+	}{
+		{models.RevisionActionCreate, 7, "title,slug,difficulty,tags,description,examples,template_code.javascript"},
+		{models.RevisionActionUpdate, 8, "title"},
+		{models.RevisionActionTestCaseCreate, 8, "test_cases"},
+		{models.RevisionActionTestCaseDelete, 9, "test_cases"},
+	}
+	if len(revisionRepo.revisions) != len(expected) {
+		t.Fatalf("Expected %d revisions, got %d", len(expected), len(revisionRepo.revisions))
+	}
+	for i, want := range expected {
+		revision := revisionRepo.revisions[i]
+		if revision.Revision != i+1 {
+			t.Errorf("Revision %d: expected number %d, got %d", i, i+1, revision.Revision)
+		}
+		if revision.Action != want.action {
+			t.Errorf("Revision %d: expected action %q, got %q", i, want.action, revision.Action)
+		}
+		if revision.AuthorID == nil || *revision.AuthorID != want.authorID {
+			t.Errorf("Revision %d: expected author %d, got %v", i, want.authorID, revision.AuthorID)
+		}
+		if changed := strings.Join(revision.ChangedFields, ","); changed != want.changed {
+			t.Errorf("Revision %d: expected changed fields %q, got %q", i, want.changed, changed)
+		}
+	}
+}
+
+func TestProblemService_Revisions_Baseline(t *testing.T) {
+	service, problemRepo, _, revisionRepo := newRevisionTestService()
+
+	// A problem created before revisions were recorded
+	existing, _ := problemRepo.Create(revisionTestProblem("Two Sum"))
+
+	update := revisionTestProblem("Two Sum")
+	update.ID = existing.ID
+	update.Difficulty = models.DifficultyMedium
+	if _, err := service.UpdateProblem(update, 3); err != nil {
+		t.Fatalf("Failed to update problem: %v", err)
+	}
+
+	if len(revisionRepo.revisions) != 2 {
+		t.Fatalf("Expected baseline and update revisions, got %d", len(revisionRepo.revisions))
+	}
+	baseline := revisionRepo.revisions[0]
+	if baseline.Action != models.RevisionActionBaseline || baseline.AuthorID != nil {
+		t.Errorf("Expected anonymous baseline revision, got %q by %v", baseline.Action, baseline.AuthorID)
+	}
+	if baseline.Snapshot.Difficulty != models.DifficultyEasy {
+		t.Errorf("Expected baseline to keep the old difficulty, got %q", baseline.Snapshot.Difficulty)
+	}
+	if changed := strings.Join(revisionRepo.revisions[1].ChangedFields, ","); changed != "difficulty" {
+		t.Errorf("Expected difficulty to change, got %q", changed)
+	}
+}
+
+func TestProblemService_DiffRevisions(t *testing.T) {
+	service, _, _, _ := newRevisionTestService()
+
+	problem := revisionTestProblem("Two Sum")
+	problem.TemplateCode = models.TemplateCode{models.LanguagePython: "def two_sum():\n    pass\n"}
+	created, err := service.CreateProblem(problem, 1)
+	if err != nil {
+		t.Fatalf("Failed to create problem: %v", err)
+	}
+
+	update := revisionTestProblem("Two Sum")
+	update.ID = created.ID
+	update.TemplateCode = models.TemplateCode{models.LanguagePython: "def two_sum(nums, target):\n    pass\n"}
+	if _, err := service.UpdateProblem(update, 1); err != nil {
+		t.Fatalf("Failed to update problem: %v", err)
+	}
+
+	result, err := service.DiffRevisions(created.ID, 1, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Fields) != 1 || result.Fields[0].Field != "template_code.python" {
+		t.Fatalf("Expected only template_code.python to differ, got %+v", result.Fields)
+	}
+	unified := result.Fields[0].Diff.Unified
+	if !strings.Contains(unified, "-def two_sum():\n") || !strings.Contains(unified, "+def two_sum(nums, target):\n") {
+		t.Errorf("Unexpected diff:\n%s", unified)
+	}
+	if result.From.Snapshot != nil || result.To.Snapshot != nil {
+		t.Error("Expected snapshots to be omitted from the diff")
+	}
+
+	if _, err := service.DiffRevisions(created.ID, 1, 5); err == nil || !repository.IsNotFound(err) {
+		t.Errorf("Expected not found error for a missing revision, got %v", err)
+	}
+}
+
+func TestProblemService_RestoreRevision(t *testing.T) {
+	service, problemRepo, testCaseRepo, revisionRepo := newRevisionTestService()
+
+	created, err := service.CreateProblem(revisionTestProblem("Two Sum"), 1)
+	if err != nil {
+		t.Fatalf("Failed to create problem: %v", err)
+	}
+	kept, _ := service.CreateTestCase(&models.TestCase{ProblemID: created.ID, Input: "1 2", ExpectedOutput: "3"}, 1)
+	deleted, _ := service.CreateTestCase(&models.TestCase{ProblemID: created.ID, Input: "2 2", ExpectedOutput: "4", IsHidden: true}, 1)
+
+	// Revision 3 has both test cases; then the title changes and a test case is replaced
+	update := revisionTestProblem("Renamed")
+	update.ID = created.ID
+	service.UpdateProblem(update, 2)
+	service.DeleteTestCase(deleted.ID, 2)
+	added, _ := service.CreateTestCase(&models.TestCase{ProblemID: created.ID, Input: "5 5", ExpectedOutput: "10"}, 2)
+	service.UpdateTestCase(&models.TestCase{ID: kept.ID, ProblemID: created.ID, Input: "1 2", ExpectedOutput: "4"}, 2)
+
+	revision, err := service.RestoreRevision(created.ID, 3, 5)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if revision.Action != models.RevisionActionRestore || revision.RestoredFrom == nil || *revision.RestoredFrom != 3 {
+		t.Errorf("Expected restore of revision 3, got %q from %v", revision.Action, revision.RestoredFrom)
+	}
+	if revision.Revision != len(revisionRepo.revisions) {
+		t.Errorf("Expected restore to be the latest revision, got %d", revision.Revision)
+	}
+	if problemRepo.problems[created.ID].Title != "Two Sum" {
+		t.Errorf("Expected title to be restored, got %q", problemRepo.problems[created.ID].Title)
+	}
+
+	testCases, _ := testCaseRepo.GetByProblemID(created.ID)
+	if len(testCases) != 2 {
+		t.Fatalf("Expected 2 test cases, got %d", len(testCases))
+	}
+	if _, exists := testCaseRepo.testCases[added.ID]; exists {
+		t.Error("Expected test case added after the revision to be deleted")
+	}
+	if testCaseRepo.testCases[kept.ID].ExpectedOutput != "3" {
+		t.Errorf("Expected kept test case to be restored, got %q", testCaseRepo.testCases[kept.ID].ExpectedOutput)
+	}
+
+	// The restored state matches revision 3, so diffing them shows nothing
+	result, err := service.DiffRevisions(created.ID, 3, revision.Revision)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.Fields) != 0 {
+		t.Errorf("Expected restored revision to match, got %+v", result.Fields)
+	}
+}
+
+func TestProblemService_ListRevisions(t *testing.T) {
+	service, _, _, _ := newRevisionTestService()
+
+	created, _ := service.CreateProblem(revisionTestProblem("Two Sum"), 1)
+	for _, title := range []string{"A", "B", "C"} {
+		update := revisionTestProblem(title)
+		update.ID = created.ID
+		service.UpdateProblem(update, 1)
+	}
+
+	page, err := service.ListRevisions(created.ID, pagination.Params{Limit: 3})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if page.Total != 4 || !page.HasNext || len(page.Revisions) != 3 {
+		t.Fatalf("Expected first 3 of 4 revisions, got %d of %d (has next %v)", len(page.Revisions), page.Total, page.HasNext)
+	}
+	if page.Revisions[0].Revision != 4 || page.Revisions[0].Snapshot != nil {
+		t.Errorf("Expected newest revision first without snapshot, got %+v", page.Revisions[0])
+	}
+
+	cursor, _ := pagination.DecodeCursor(page.NextCursor)
+	next, err := service.ListRevisions(created.ID, pagination.Params{Limit: 3, Cursor: cursor})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(next.Revisions) != 1 || next.Revisions[0].Revision != 1 || next.HasNext {
+		t.Errorf("Expected only revision 1 on the next page, got %+v", next.Revisions)
+	}
+
+	if _, err := service.ListRevisions(99, pagination.Params{}); err == nil || !repository.IsNotFound(err) {
+		t.Errorf("Expected not found error for a missing problem, got %v", err)
+	}
+}
+
+func TestProblemService_GetRevision(t *testing.T) {
+	service, _, _, _ := newRevisionTestService()
+
+	created, _ := service.CreateProblem(revisionTestProblem("Two Sum"), 1)
+
+	revision, err := service.GetRevision(created.ID, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if revision.Snapshot == nil || revision.Snapshot.Title != "Two Sum" {
+		t.Errorf("Expected snapshot of the created problem, got %+v", revision.Snapshot)
+	}
+	if len(revision.Changes) != 7 || revision.Changes[0].Field != "title" || revision.Changes[0].Diff.Additions != 1 {
+		t.Errorf("Expected every set field to be added, got %+v", revision.Changes)
+	}
+}
+
+func TestNewProblemSnapshot_StoredTestData(t *testing.T) {
+	testCases := []*models.TestCase{
+		{ID: 2, Input: "1 2", ExpectedOutput: "3"},
+		{ID: 1, InputHash: strings.Repeat("a", 64), InputSize: 1 << 20, InputStored: true, ExpectedOutput: "large"},
+	}
+
+	snapshot := newProblemSnapshot(revisionTestProblem("Two Sum"), testCases)
+
+	stored := snapshot.TestCases[0]
+	if stored.ID != 1 || stored.Input != "" || stored.InputHash != testCases[1].InputHash || stored.InputSize != 1<<20 {
+		t.Errorf("Expected stored input referenced by hash and size, got %+v", stored)
+	}
+	if stored.ExpectedOutput != "large" || stored.OutputHash != "" {
+		t.Errorf("Expected inline expected output copied, got %+v", stored)
+	}
+	if inline := snapshot.TestCases[1]; inline.Input != "1 2" || inline.InputHash != "" {
+		t.Errorf("Expected inline input copied, got %+v", inline)
+	}
+
+	text := testCasesText(snapshot.TestCases)
+	if !strings.Contains(text, "[1048576 bytes, sha256 "+testCases[1].InputHash+"]") {
+		t.Errorf("Expected stored input rendered by size and hash, got %q", text)
+	}
+}
@@ -1,80 +1,150 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 
+	"leetcode-clone-backend/pkg/execution"
 	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/pagination"
 	"leetcode-clone-backend/pkg/repository"
+	"leetcode-clone-backend/pkg/search"
+	"leetcode-clone-backend/pkg/storage"
 )
 
 // ProblemService handles business logic for problems
 type ProblemService struct {
-	problemRepo  repository.ProblemRepository
-	testCaseRepo repository.TestCaseRepository
+	repo             *repository.Repository
+	executionService execution.ExecutionServiceInterface // Runs reference solutions; nil if they cannot be run
 }
 
-// NewProblemService creates a new problem service
-func NewProblemService(problemRepo repository.ProblemRepository, testCaseRepo repository.TestCaseRepository) *ProblemService {
+// ProblemPage is a page of problems with its pagination envelope
+type ProblemPage struct {
+	Problems []*models.Problem `json:"problems"`
+	pagination.Meta
+}
+
+// ProblemSearchPage is a page of search results with its pagination envelope
+type ProblemSearchPage struct {
+	Problems []*models.ProblemSearchResult `json:"problems"`
+	pagination.Meta
+}
+
+// NewProblemService creates a new problem service. executionService runs the reference solutions
+// of problems; it may be nil where they are never run.
+func NewProblemService(repo *repository.Repository, executionService execution.ExecutionServiceInterface) *ProblemService {
 	return &ProblemService{
-		problemRepo:  problemRepo,
-		testCaseRepo: testCaseRepo,
+		repo:             repo,
+		executionService: executionService,
 	}
 }
 
-// CreateProblem creates a new problem with validation
-func (s *ProblemService) CreateProblem(problem *models.Problem) (*models.Problem, error) {
+// CreateProblem creates a new problem with validation and records its first revision, authored
+// by authorID. New problems are drafts until they are published with TransitionProblem.
+func (s *ProblemService) CreateProblem(problem *models.Problem, authorID int) (*models.Problem, error) {
 	// Validate problem data
 	if err := s.validateProblem(problem); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
+	problem.State = models.ProblemStateDraft
+	problem.PublishAt = nil
+
 	// Generate slug if not provided
 	if problem.Slug == "" {
 		problem.Slug = s.generateSlug(problem.Title)
 	}
 
 	// Create the problem
-	created, err := s.problemRepo.Create(problem)
+	var created *models.Problem
+	err := s.repo.WithTx(context.Background(), func(tx *repository.Repository) error {
+		var err error
+		created, err = tx.Problem.Create(problem)
+		if err != nil {
+			return fmt.Errorf("failed to create problem: %w", err)
+		}
+
+		change := revisionChange{authorID: authorID, action: models.RevisionActionCreate}
+		_, err = recordProblemRevision(tx, created.ID, change)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create problem: %w", err)
+		return nil, err
 	}
 
 	return created, nil
 }
 
-// GetProblem retrieves a problem by ID
-func (s *ProblemService) GetProblem(id int) (*models.Problem, error) {
-	problem, err := s.problemRepo.GetByID(id)
+// GetProblem retrieves a published problem by ID, with its acceptance rate and, if userID is not
+// 0, the user's status on it
+func (s *ProblemService) GetProblem(id, userID int) (*models.Problem, error) {
+	problem, err := s.repo.Problem.GetByID(id)
+	if err == nil && problem.State != models.ProblemStatePublished {
+		err = repository.NewRepositoryError("GetByID", repository.ErrNotFound, "problem_not_found")
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get problem: %w", err)
 	}
 
+	if err := s.repo.Problem.Annotate([]*models.Problem{problem}, userID); err != nil {
+		return nil, fmt.Errorf("failed to get problem stats: %w", err)
+	}
+
 	return problem, nil
 }
 
-// GetProblemBySlug retrieves a problem by slug
-func (s *ProblemService) GetProblemBySlug(slug string) (*models.Problem, error) {
-	problem, err := s.problemRepo.GetBySlug(slug)
+// GetProblemBySlug retrieves a published problem by slug, with its acceptance rate and, if userID
+// is not 0, the user's status on it
+func (s *ProblemService) GetProblemBySlug(slug string, userID int) (*models.Problem, error) {
+	problem, err := s.repo.Problem.GetBySlug(slug)
+	if err == nil && problem.State != models.ProblemStatePublished {
+		err = repository.NewRepositoryError("GetBySlug", repository.ErrNotFound, "problem_not_found")
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get problem by slug: %w", err)
 	}
 
+	if err := s.repo.Problem.Annotate([]*models.Problem{problem}, userID); err != nil {
+		return nil, fmt.Errorf("failed to get problem stats: %w", err)
+	}
+
+	return problem, nil
+}
+
+// GetProblemAnyState retrieves a problem by ID whatever its lifecycle state, for admins
+func (s *ProblemService) GetProblemAnyState(id int) (*models.Problem, error) {
+	problem, err := s.repo.Problem.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get problem: %w", err)
+	}
+
 	return problem, nil
 }
 
-// UpdateProblem updates an existing problem
-func (s *ProblemService) UpdateProblem(problem *models.Problem) (*models.Problem, error) {
+// UpdateProblem updates an existing problem and records the change as a revision authored by
+// authorID
+func (s *ProblemService) UpdateProblem(problem *models.Problem, authorID int) (*models.Problem, error) {
 	// Validate problem data
 	if err := s.validateProblem(problem); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
 	// Update the problem
-	updated, err := s.problemRepo.Update(problem)
+	var updated *models.Problem
+	change := revisionChange{authorID: authorID, action: models.RevisionActionUpdate}
+	_, err := withProblemRevisions(s.repo, []int{problem.ID}, change, func(tx *repository.Repository) error {
+		var err error
+		updated, err = tx.Problem.Update(problem)
+		if err != nil {
+			return fmt.Errorf("failed to update problem: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update problem: %w", err)
+		return nil, err
 	}
 
 	return updated, nil
@@ -82,78 +152,174 @@ func (s *ProblemService) UpdateProblem(problem *models.Problem) (*models.Problem
 
 // DeleteProblem deletes a problem by ID
 func (s *ProblemService) DeleteProblem(id int) error {
-	// First delete all test cases for this problem
-	if err := s.testCaseRepo.DeleteByProblemID(id); err != nil {
-		return fmt.Errorf("failed to delete test cases: %w", err)
-	}
+	return s.repo.WithTx(context.Background(), func(tx *repository.Repository) error {
+		// First delete all test cases for this problem
+		if err := tx.TestCase.DeleteByProblemID(id); err != nil {
+			return fmt.Errorf("failed to delete test cases: %w", err)
+		}
 
-	// Then delete the problem
-	if err := s.problemRepo.Delete(id); err != nil {
-		return fmt.Errorf("failed to delete problem: %w", err)
-	}
+		// Then delete the problem
+		if err := tx.Problem.Delete(id); err != nil {
+			return fmt.Errorf("failed to delete problem: %w", err)
+		}
 
-	return nil
+		return nil
+	})
 }
 
-// ListProblems retrieves problems with filters
-func (s *ProblemService) ListProblems(filters repository.ProblemFilters) ([]*models.Problem, error) {
+// ListProblems retrieves a page of problems with filters. filters.Cursor, when set, must come
+// from a page listed in the same sort order.
+func (s *ProblemService) ListProblems(filters repository.ProblemFilters) (*ProblemPage, error) {
 	// Validate filters
 	if err := s.validateFilters(&filters); err != nil {
 		return nil, fmt.Errorf("invalid filters: %w", err)
 	}
+	if filters.Cursor != nil {
+		if err := filters.Cursor.Check(repository.ProblemSort(filters)); err != nil {
+			return nil, fmt.Errorf("invalid filters: %w", err)
+		}
+		if filters.SortBy == "" || filters.SortBy == "created_at" {
+			if _, err := filters.Cursor.Time(); err != nil {
+				return nil, fmt.Errorf("invalid filters: %w", err)
+			}
+		}
+	}
+
+	total, err := s.repo.Problem.Count(filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count problems: %w", err)
+	}
 
-	problems, err := s.problemRepo.List(filters)
+	// Fetch one extra problem to know whether there is a next page
+	limit := filters.Limit
+	filters.Limit = limit + 1
+	problems, err := s.repo.Problem.List(filters)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list problems: %w", err)
 	}
+	filters.Limit = limit
+
+	hasNext := len(problems) > limit
+	if hasNext {
+		problems = problems[:limit]
+	}
+
+	if err := s.repo.Problem.Annotate(problems, filters.UserID); err != nil {
+		return nil, fmt.Errorf("failed to get problem stats: %w", err)
+	}
 
-	return problems, nil
+	var next *pagination.Cursor
+	if len(problems) > 0 {
+		next = repository.NewProblemCursor(problems[len(problems)-1], filters)
+	}
+
+	return &ProblemPage{
+		Problems: problems,
+		Meta:     pagination.NewMeta(filtersParams(filters), total, hasNext, next),
+	}, nil
 }
 
-// SearchProblems searches problems by title or description
-func (s *ProblemService) SearchProblems(query string, filters repository.ProblemFilters) ([]*models.Problem, error) {
+// SearchProblems searches problems with a query written in the search query language (see
+// package search). Filters in the query are added to filters; the status filter needs
+// filters.UserID to be set. Search results are paged by offset only, as relevance ranks cannot
+// be used as a cursor.
+func (s *ProblemService) SearchProblems(query string, filters repository.ProblemFilters) (*ProblemSearchPage, error) {
 	// Validate search query
 	if strings.TrimSpace(query) == "" {
 		return nil, fmt.Errorf("search query cannot be empty")
 	}
 
+	parsed, err := search.Parse(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search query: %w", err)
+	}
+	if parsed.Text.IsEmpty() && !parsed.HasFilters() {
+		return nil, fmt.Errorf("invalid search query: must contain at least one letter or digit")
+	}
+
+	filters.Difficulty = append(filters.Difficulty, parsed.Difficulty...)
+	filters.RequiredTags = append(filters.RequiredTags, parsed.RequiredTags...)
+	filters.ExcludedTags = append(filters.ExcludedTags, parsed.ExcludedTags...)
+	if parsed.Status != "" {
+		filters.Status = parsed.Status
+	}
+
+	// Relevance is the default order of search results
+	if filters.SortBy == "relevance" {
+		filters.SortBy = ""
+	}
+
 	// Validate filters
 	if err := s.validateFilters(&filters); err != nil {
 		return nil, fmt.Errorf("invalid filters: %w", err)
 	}
+	if filters.Cursor != nil {
+		return nil, fmt.Errorf("invalid filters: search results cannot be paged with a cursor")
+	}
 
-	problems, err := s.problemRepo.Search(query, filters)
+	total, err := s.repo.Problem.CountSearch(parsed.Text, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	results, err := s.repo.Problem.Search(parsed.Text, filters)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search problems: %w", err)
 	}
 
-	return problems, nil
+	problems := make([]*models.Problem, len(results))
+	for i, result := range results {
+		problems[i] = &result.Problem
+	}
+	if err := s.repo.Problem.Annotate(problems, filters.UserID); err != nil {
+		return nil, fmt.Errorf("failed to get problem stats: %w", err)
+	}
+
+	hasNext := filters.Offset+len(results) < total
+	return &ProblemSearchPage{
+		Problems: results,
+		Meta:     pagination.NewMeta(filtersParams(filters), total, hasNext, nil),
+	}, nil
 }
 
-// CreateTestCase creates a new test case for a problem
-func (s *ProblemService) CreateTestCase(testCase *models.TestCase) (*models.TestCase, error) {
+// CreateTestCase creates a new test case for a problem and records the change as a revision
+// authored by authorID. Its input must pass the validator of the problem, if it has one.
+func (s *ProblemService) CreateTestCase(testCase *models.TestCase, authorID int) (*models.TestCase, error) {
 	// Validate test case
 	if err := s.validateTestCase(testCase); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
 	// Verify problem exists
-	_, err := s.problemRepo.GetByID(testCase.ProblemID)
+	_, err := s.repo.Problem.GetByID(testCase.ProblemID)
 	if err != nil {
 		return nil, fmt.Errorf("problem not found: %w", err)
 	}
 
-	created, err := s.testCaseRepo.Create(testCase)
+	if err := s.validateInputs(testCase.ProblemID, nil, []*models.TestCase{testCase}); err != nil {
+		return nil, err
+	}
+
+	var created *models.TestCase
+	change := revisionChange{authorID: authorID, action: models.RevisionActionTestCaseCreate}
+	_, err = withProblemRevisions(s.repo, []int{testCase.ProblemID}, change, func(tx *repository.Repository) error {
+		var err error
+		created, err = tx.TestCase.Create(testCase)
+		if err != nil {
+			return fmt.Errorf("failed to create test case: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create test case: %w", err)
+		return nil, err
 	}
 
 	return created, nil
 }
 
-// GetTestCases retrieves all test cases for a problem
+// GetTestCases retrieves all test cases for a problem, without their data
 func (s *ProblemService) GetTestCases(problemID int) ([]*models.TestCase, error) {
-	testCases, err := s.testCaseRepo.GetByProblemID(problemID)
+	testCases, err := s.repo.TestCase.ListByProblemID(problemID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get test cases: %w", err)
 	}
@@ -161,9 +327,23 @@ func (s *ProblemService) GetTestCases(problemID int) ([]*models.TestCase, error)
 	return testCases, nil
 }
 
-// GetPublicTestCases retrieves only public test cases for a problem
+// GetTestCase retrieves a test case by ID with its data
+func (s *ProblemService) GetTestCase(id int) (*models.TestCase, error) {
+	testCase, err := s.repo.TestCase.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test case: %w", err)
+	}
+
+	return testCase, nil
+}
+
+// GetPublicTestCases retrieves only public test cases for a published problem, without their data
 func (s *ProblemService) GetPublicTestCases(problemID int) ([]*models.TestCase, error) {
-	testCases, err := s.testCaseRepo.GetPublicByProblemID(problemID)
+	if _, err := s.GetProblem(problemID, 0); err != nil {
+		return nil, err
+	}
+
+	testCases, err := s.repo.TestCase.ListPublicByProblemID(problemID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get public test cases: %w", err)
 	}
@@ -171,28 +351,65 @@ func (s *ProblemService) GetPublicTestCases(problemID int) ([]*models.TestCase,
 	return testCases, nil
 }
 
-// UpdateTestCase updates an existing test case
-func (s *ProblemService) UpdateTestCase(testCase *models.TestCase) (*models.TestCase, error) {
+// UpdateTestCase updates an existing test case and records the change as a revision of its
+// problem, authored by authorID. A test case moved to another problem is recorded in both. A
+// changed or moved input must pass the validator of its problem, if it has one.
+func (s *ProblemService) UpdateTestCase(testCase *models.TestCase, authorID int) (*models.TestCase, error) {
 	// Validate test case
 	if err := s.validateTestCase(testCase); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
-	updated, err := s.testCaseRepo.Update(testCase)
+	existing, err := s.repo.TestCase.GetByID(testCase.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update test case: %w", err)
 	}
 
+	problemIDs := []int{existing.ProblemID}
+	if testCase.ProblemID != existing.ProblemID {
+		problemIDs = append(problemIDs, testCase.ProblemID)
+	}
+
+	if testCase.ProblemID != existing.ProblemID || storage.Hash([]byte(testCase.Input)) != existing.InputHash {
+		if err := s.validateInputs(testCase.ProblemID, nil, []*models.TestCase{testCase}); err != nil {
+			return nil, err
+		}
+	}
+
+	var updated *models.TestCase
+	change := revisionChange{authorID: authorID, action: models.RevisionActionTestCaseUpdate}
+	_, err = withProblemRevisions(s.repo, problemIDs, change, func(tx *repository.Repository) error {
+		var err error
+		updated, err = tx.TestCase.Update(testCase)
+		if err != nil {
+			return fmt.Errorf("failed to update test case: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return updated, nil
 }
 
-// DeleteTestCase deletes a test case by ID
-func (s *ProblemService) DeleteTestCase(id int) error {
-	if err := s.testCaseRepo.Delete(id); err != nil {
+// DeleteTestCase deletes a test case by ID and records the change as a revision of its problem,
+// authored by authorID
+func (s *ProblemService) DeleteTestCase(id, authorID int) error {
+	existing, err := s.repo.TestCase.GetByID(id)
+	if err != nil {
 		return fmt.Errorf("failed to delete test case: %w", err)
 	}
 
-	return nil
+	change := revisionChange{authorID: authorID, action: models.RevisionActionTestCaseDelete}
+	_, err = withProblemRevisions(s.repo, []int{existing.ProblemID}, change, func(tx *repository.Repository) error {
+		if err := tx.TestCase.Delete(id); err != nil {
+			return fmt.Errorf("failed to delete test case: %w", err)
+		}
+		return nil
+	})
+
+	return err
 }
 
 // validateProblem validates problem data
@@ -270,6 +487,23 @@ func (s *ProblemService) validateTestCase(testCase *models.TestCase) error {
 		return fmt.Errorf("expected output is required")
 	}
 
+	if err := validateTestData("input", testCase.Input); err != nil {
+		return err
+	}
+
+	return validateTestData("expected output", testCase.ExpectedOutput)
+}
+
+// validateTestData checks that test data is text the database and the judge can hold
+func validateTestData(name, data string) error {
+	if len(data) > models.MaxTestDataSize {
+		return fmt.Errorf("%s must be %d MB or less", name, models.MaxTestDataSize>>20)
+	}
+
+	if !utf8.ValidString(data) || strings.ContainsRune(data, 0) {
+		return fmt.Errorf("%s must be UTF-8 text without NUL characters", name)
+	}
+
 	return nil
 }
 
@@ -288,14 +522,21 @@ func (s *ProblemService) validateFilters(filters *repository.ProblemFilters) err
 		}
 	}
 
+	// Validate lifecycle state filter
+	if filters.State != "" {
+		if _, exists := problemTransitions[filters.State]; !exists {
+			return fmt.Errorf("invalid state: %s", filters.State)
+		}
+	}
+
 	// Set default limit if not provided
 	if filters.Limit <= 0 {
 		filters.Limit = 50 // Default limit
 	}
 
 	// Validate limit bounds
-	if filters.Limit > 100 {
-		filters.Limit = 100 // Maximum limit
+	if filters.Limit > pagination.MaxLimit {
+		filters.Limit = pagination.MaxLimit
 	}
 
 	// Validate offset
@@ -320,9 +561,30 @@ func (s *ProblemService) validateFilters(filters *repository.ProblemFilters) err
 		return fmt.Errorf("invalid sort order: %s (must be 'asc' or 'desc')", filters.SortOrder)
 	}
 
+	// Validate status filter
+	switch filters.Status {
+	case "":
+	case models.ProgressStatusSolved, models.ProgressStatusAttempted, models.ProgressStatusUnsolved, models.ProblemStatusNew:
+		if filters.UserID == 0 {
+			return fmt.Errorf("status filter requires authentication")
+		}
+	default:
+		return fmt.Errorf("invalid status: %s", filters.Status)
+	}
+
 	return nil
 }
 
+// filtersParams returns the pagination parameters of validated filters
+func filtersParams(filters repository.ProblemFilters) pagination.Params {
+	return pagination.Params{
+		Page:   filters.Offset/filters.Limit + 1,
+		Limit:  filters.Limit,
+		Offset: filters.Offset,
+		Cursor: filters.Cursor,
+	}
+}
+
 // generateSlug generates a URL-friendly slug from a title
 func (s *ProblemService) generateSlug(title string) string {
 	// Convert to lowercase
@@ -1,16 +1,22 @@
 package services
 
 import (
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/pagination"
 	"leetcode-clone-backend/pkg/repository"
+	"leetcode-clone-backend/pkg/storage"
 )
 
 // Mock repositories for testing
 type mockProblemRepository struct {
 	problems map[int]*models.Problem
 	nextID   int
+	dueAt    time.Time // Time passed to the last ListScheduledDue call
 }
 
 func newMockProblemRepository() *mockProblemRepository {
@@ -21,6 +27,9 @@ func newMockProblemRepository() *mockProblemRepository {
 }
 
 func (m *mockProblemRepository) Create(problem *models.Problem) (*models.Problem, error) {
+	if problem.State == "" {
+		problem.State = models.ProblemStateDraft
+	}
 	problem.ID = m.nextID
 	m.nextID++
 	m.problems[problem.ID] = problem
@@ -44,9 +53,11 @@ func (m *mockProblemRepository) GetBySlug(slug string) (*models.Problem, error)
 }
 
 func (m *mockProblemRepository) Update(problem *models.Problem) (*models.Problem, error) {
-	if _, exists := m.problems[problem.ID]; !exists {
+	existing, exists := m.problems[problem.ID]
+	if !exists {
 		return nil, repository.NewRepositoryError("Update", repository.ErrNotFound, "problem_not_found")
 	}
+	problem.State, problem.PublishAt, problem.PublishedAt = existing.State, existing.PublishAt, existing.PublishedAt
 	m.problems[problem.ID] = problem
 	return problem, nil
 }
@@ -61,20 +72,77 @@ func (m *mockProblemRepository) Delete(id int) error {
 
 func (m *mockProblemRepository) List(filters repository.ProblemFilters) ([]*models.Problem, error) {
 	var result []*models.Problem
-	for _, problem := range m.problems {
-		result = append(result, problem)
+	for id := 1; id < m.nextID; id++ {
+		if problem, exists := m.problems[id]; exists && (filters.State == "" || problem.State == filters.State) {
+			result = append(result, problem)
+		}
+	}
+	if filters.Limit > 0 && len(result) > filters.Limit {
+		result = result[:filters.Limit]
 	}
 	return result, nil
 }
 
-func (m *mockProblemRepository) Search(query string, filters repository.ProblemFilters) ([]*models.Problem, error) {
-	var result []*models.Problem
+func (m *mockProblemRepository) Search(text repository.SearchText, filters repository.ProblemFilters) ([]*models.ProblemSearchResult, error) {
+	var result []*models.ProblemSearchResult
 	for _, problem := range m.problems {
-		result = append(result, problem)
+		result = append(result, &models.ProblemSearchResult{Problem: *problem})
 	}
 	return result, nil
 }
 
+func (m *mockProblemRepository) Count(filters repository.ProblemFilters) (int, error) {
+	count := 0
+	for _, problem := range m.problems {
+		if filters.State == "" || problem.State == filters.State {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockProblemRepository) CountSearch(text repository.SearchText, filters repository.ProblemFilters) (int, error) {
+	return len(m.problems), nil
+}
+
+func (m *mockProblemRepository) Annotate(problems []*models.Problem, userID int) error {
+	for _, problem := range problems {
+		rate := 0.0
+		problem.AcceptanceRate = &rate
+		if userID != 0 {
+			solved := false
+			problem.UserStatus = models.ProblemStatusNew
+			problem.IsSolved = &solved
+		}
+	}
+	return nil
+}
+
+func (m *mockProblemRepository) SetState(id int, state string, publishAt *time.Time) (*models.Problem, error) {
+	problem, exists := m.problems[id]
+	if !exists {
+		return nil, repository.NewRepositoryError("SetState", repository.ErrNotFound, "problem_not_found")
+	}
+	problem.State = state
+	problem.PublishAt = publishAt
+	if state == models.ProblemStatePublished && problem.PublishedAt == nil {
+		now := time.Now()
+		problem.PublishedAt = &now
+	}
+	return problem, nil
+}
+
+func (m *mockProblemRepository) ListScheduledDue(now time.Time) ([]int, error) {
+	m.dueAt = now
+	var ids []int
+	for id := 1; id < m.nextID; id++ {
+		if problem, exists := m.problems[id]; exists && problem.State == models.ProblemStateScheduled && !problem.PublishAt.After(now) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
 type mockTestCaseRepository struct {
 	testCases map[int]*models.TestCase
 	nextID    int
@@ -90,6 +158,10 @@ func newMockTestCaseRepository() *mockTestCaseRepository {
 func (m *mockTestCaseRepository) Create(testCase *models.TestCase) (*models.TestCase, error) {
 	testCase.ID = m.nextID
 	m.nextID++
+	setTestDataSummary(testCase)
+	if testCase.OutputStatus == "" {
+		testCase.OutputStatus = models.OutputStatusUnverified
+	}
 	m.testCases[testCase.ID] = testCase
 	return testCase, nil
 }
@@ -108,6 +180,7 @@ func (m *mockTestCaseRepository) GetByProblemID(problemID int) ([]*models.TestCa
 			result = append(result, testCase)
 		}
 	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
 	return result, nil
 }
 
@@ -121,14 +194,73 @@ func (m *mockTestCaseRepository) GetPublicByProblemID(problemID int) ([]*models.
 	return result, nil
 }
 
+func (m *mockTestCaseRepository) GetInlineByProblemID(problemID int) ([]*models.TestCase, error) {
+	return m.GetByProblemID(problemID)
+}
+
+func (m *mockTestCaseRepository) ListByProblemID(problemID int) ([]*models.TestCase, error) {
+	testCases, err := m.GetByProblemID(problemID)
+	return testCaseSummaries(testCases), err
+}
+
+func (m *mockTestCaseRepository) ListPublicByProblemID(problemID int) ([]*models.TestCase, error) {
+	testCases, err := m.GetPublicByProblemID(problemID)
+	return testCaseSummaries(testCases), err
+}
+
+// setTestDataSummary fills in the hashes and sizes of test data, as the repository does
+func setTestDataSummary(testCase *models.TestCase) {
+	if testCase.Input != "" {
+		testCase.InputHash, testCase.InputSize = storage.Hash([]byte(testCase.Input)), int64(len(testCase.Input))
+	}
+	if testCase.ExpectedOutput != "" {
+		testCase.OutputHash, testCase.OutputSize = storage.Hash([]byte(testCase.ExpectedOutput)), int64(len(testCase.ExpectedOutput))
+	}
+}
+
+// testCaseSummaries returns copies of test cases without their data, as lists are returned
+func testCaseSummaries(testCases []*models.TestCase) []*models.TestCase {
+	summaries := make([]*models.TestCase, 0, len(testCases))
+	for _, testCase := range testCases {
+		summary := *testCase
+		summary.Input, summary.ExpectedOutput = "", ""
+		summaries = append(summaries, &summary)
+	}
+	return summaries
+}
+
 func (m *mockTestCaseRepository) Update(testCase *models.TestCase) (*models.TestCase, error) {
-	if _, exists := m.testCases[testCase.ID]; !exists {
+	existing, exists := m.testCases[testCase.ID]
+	if !exists {
 		return nil, repository.NewRepositoryError("Update", repository.ErrNotFound, "testcase_not_found")
 	}
+	setTestDataSummary(testCase)
+	testCase.OutputStatus = existing.OutputStatus
+	if testCase.InputHash != existing.InputHash || testCase.OutputHash != existing.OutputHash {
+		testCase.OutputStatus = models.OutputStatusUnverified
+	}
 	m.testCases[testCase.ID] = testCase
 	return testCase, nil
 }
 
+func (m *mockTestCaseRepository) SetOutputStatus(id int, status string) error {
+	testCase, exists := m.testCases[id]
+	if !exists {
+		return repository.NewRepositoryError("SetOutputStatus", repository.ErrNotFound, "testcase_not_found")
+	}
+	testCase.OutputStatus = status
+	return nil
+}
+
+func (m *mockTestCaseRepository) ResetOutputStatus(problemID int) error {
+	for _, testCase := range m.testCases {
+		if testCase.ProblemID == problemID {
+			testCase.OutputStatus = models.OutputStatusUnverified
+		}
+	}
+	return nil
+}
+
 func (m *mockTestCaseRepository) Delete(id int) error {
 	if _, exists := m.testCases[id]; !exists {
 		return repository.NewRepositoryError("Delete", repository.ErrNotFound, "testcase_not_found")
@@ -149,7 +281,7 @@ func (m *mockTestCaseRepository) DeleteByProblemID(problemID int) error {
 func TestProblemService_CreateProblem(t *testing.T) {
 	problemRepo := newMockProblemRepository()
 	testCaseRepo := newMockTestCaseRepository()
-	service := NewProblemService(problemRepo, testCaseRepo)
+	service := NewProblemService(&repository.Repository{Problem: problemRepo, TestCase: testCaseRepo, ProblemRevision: newMockProblemRevisionRepository(), TestProgram: newMockTestProgramRepository()}, nil)
 
 	problem := &models.Problem{
 		Title:       "Two Sum",
@@ -163,7 +295,7 @@ func TestProblemService_CreateProblem(t *testing.T) {
 		},
 	}
 
-	created, err := service.CreateProblem(problem)
+	created, err := service.CreateProblem(problem, 1)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -184,7 +316,7 @@ func TestProblemService_CreateProblem(t *testing.T) {
 func TestProblemService_CreateProblem_ValidationError(t *testing.T) {
 	problemRepo := newMockProblemRepository()
 	testCaseRepo := newMockTestCaseRepository()
-	service := NewProblemService(problemRepo, testCaseRepo)
+	service := NewProblemService(&repository.Repository{Problem: problemRepo, TestCase: testCaseRepo, ProblemRevision: newMockProblemRevisionRepository(), TestProgram: newMockTestProgramRepository()}, nil)
 
 	// Test with empty title
 	problem := &models.Problem{
@@ -193,7 +325,7 @@ func TestProblemService_CreateProblem_ValidationError(t *testing.T) {
 		Difficulty:  models.DifficultyEasy,
 	}
 
-	_, err := service.CreateProblem(problem)
+	_, err := service.CreateProblem(problem, 1)
 	if err == nil {
 		t.Error("Expected validation error for empty title")
 	}
@@ -202,7 +334,7 @@ func TestProblemService_CreateProblem_ValidationError(t *testing.T) {
 func TestProblemService_GetProblem(t *testing.T) {
 	problemRepo := newMockProblemRepository()
 	testCaseRepo := newMockTestCaseRepository()
-	service := NewProblemService(problemRepo, testCaseRepo)
+	service := NewProblemService(&repository.Repository{Problem: problemRepo, TestCase: testCaseRepo, ProblemRevision: newMockProblemRevisionRepository(), TestProgram: newMockTestProgramRepository()}, nil)
 
 	// Create a problem first
 	problem := &models.Problem{
@@ -217,13 +349,19 @@ func TestProblemService_GetProblem(t *testing.T) {
 		},
 	}
 
-	created, err := service.CreateProblem(problem)
+	created, err := service.CreateProblem(problem, 1)
 	if err != nil {
 		t.Fatalf("Failed to create problem: %v", err)
 	}
 
+	// Drafts are not public
+	if _, err := service.GetProblem(created.ID, 0); err == nil || !repository.IsNotFound(err) {
+		t.Fatalf("Expected not found error for a draft, got %v", err)
+	}
+	problemRepo.SetState(created.ID, models.ProblemStatePublished, nil)
+
 	// Get the problem
-	retrieved, err := service.GetProblem(created.ID)
+	retrieved, err := service.GetProblem(created.ID, 0)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -240,7 +378,7 @@ func TestProblemService_GetProblem(t *testing.T) {
 func TestProblemService_CreateTestCase(t *testing.T) {
 	problemRepo := newMockProblemRepository()
 	testCaseRepo := newMockTestCaseRepository()
-	service := NewProblemService(problemRepo, testCaseRepo)
+	service := NewProblemService(&repository.Repository{Problem: problemRepo, TestCase: testCaseRepo, ProblemRevision: newMockProblemRevisionRepository(), TestProgram: newMockTestProgramRepository()}, nil)
 
 	// Create a problem first
 	problem := &models.Problem{
@@ -255,7 +393,7 @@ func TestProblemService_CreateTestCase(t *testing.T) {
 		},
 	}
 
-	created, err := service.CreateProblem(problem)
+	created, err := service.CreateProblem(problem, 1)
 	if err != nil {
 		t.Fatalf("Failed to create problem: %v", err)
 	}
@@ -268,7 +406,7 @@ func TestProblemService_CreateTestCase(t *testing.T) {
 		IsHidden:       false,
 	}
 
-	createdTestCase, err := service.CreateTestCase(testCase)
+	createdTestCase, err := service.CreateTestCase(testCase, 1)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -285,7 +423,7 @@ func TestProblemService_CreateTestCase(t *testing.T) {
 func TestProblemService_ValidateFilters(t *testing.T) {
 	problemRepo := newMockProblemRepository()
 	testCaseRepo := newMockTestCaseRepository()
-	service := NewProblemService(problemRepo, testCaseRepo)
+	service := NewProblemService(&repository.Repository{Problem: problemRepo, TestCase: testCaseRepo, ProblemRevision: newMockProblemRevisionRepository(), TestProgram: newMockTestProgramRepository()}, nil)
 
 	// Test valid filters
 	filters := repository.ProblemFilters{
@@ -326,10 +464,60 @@ func TestProblemService_ValidateFilters(t *testing.T) {
 	}
 }
 
+func TestProblemService_ListProblems(t *testing.T) {
+	problemRepo := newMockProblemRepository()
+	service := NewProblemService(&repository.Repository{Problem: problemRepo, TestCase: newMockTestCaseRepository()}, nil)
+	for _, title := range []string{"Two Sum", "Valid Parentheses", "Merge Intervals"} {
+		problemRepo.Create(&models.Problem{Title: title, Difficulty: models.DifficultyEasy})
+	}
+
+	page, err := service.ListProblems(repository.ProblemFilters{Limit: 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(page.Problems) != 2 {
+		t.Errorf("Expected 2 problems, got %d", len(page.Problems))
+	}
+	if page.Total != 3 || page.Page != 1 || page.Limit != 2 || !page.HasNext {
+		t.Errorf("Unexpected pagination %+v", page.Meta)
+	}
+	if page.NextCursor == "" {
+		t.Fatal("Expected a next cursor")
+	}
+
+	// The cursor only works with the sort order it was created for
+	cursor, err := pagination.DecodeCursor(page.NextCursor)
+	if err != nil {
+		t.Fatalf("Failed to decode cursor: %v", err)
+	}
+	_, err = service.ListProblems(repository.ProblemFilters{Limit: 2, Cursor: cursor, SortBy: "title"})
+	if err == nil || !strings.Contains(err.Error(), "invalid filters") {
+		t.Errorf("Expected invalid filters error, got %v", err)
+	}
+
+	page, err = service.ListProblems(repository.ProblemFilters{Limit: 5})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if page.HasNext || page.NextCursor != "" {
+		t.Errorf("Expected no next page, got %+v", page.Meta)
+	}
+}
+
+func TestProblemService_SearchProblems_Cursor(t *testing.T) {
+	service := NewProblemService(&repository.Repository{Problem: newMockProblemRepository(), TestCase: newMockTestCaseRepository()}, nil)
+
+	cursor := &pagination.Cursor{Sort: "created_at:desc", Value: "2024-01-01T00:00:00Z", ID: 1}
+	_, err := service.SearchProblems("two sum", repository.ProblemFilters{Cursor: cursor})
+	if err == nil || !strings.Contains(err.Error(), "invalid filters") {
+		t.Errorf("Expected invalid filters error, got %v", err)
+	}
+}
+
 func TestProblemService_GenerateSlug(t *testing.T) {
 	problemRepo := newMockProblemRepository()
 	testCaseRepo := newMockTestCaseRepository()
-	service := NewProblemService(problemRepo, testCaseRepo)
+	service := NewProblemService(&repository.Repository{Problem: problemRepo, TestCase: testCaseRepo, ProblemRevision: newMockProblemRevisionRepository(), TestProgram: newMockTestProgramRepository()}, nil)
 
 	tests := []struct {
 		title    string
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+)
+
+// problemTransitions lists the states each lifecycle state can move to. Rescheduling a
+// scheduled problem moves it to scheduled again.
+var problemTransitions = map[string][]string{
+	models.ProblemStateDraft:     {models.ProblemStateReview, models.ProblemStateScheduled, models.ProblemStatePublished, models.ProblemStateArchived},
+	models.ProblemStateReview:    {models.ProblemStateDraft, models.ProblemStateScheduled, models.ProblemStatePublished, models.ProblemStateArchived},
+	models.ProblemStateScheduled: {models.ProblemStateDraft, models.ProblemStateReview, models.ProblemStateScheduled, models.ProblemStatePublished, models.ProblemStateArchived},
+	models.ProblemStatePublished: {models.ProblemStateArchived},
+	models.ProblemStateArchived:  {models.ProblemStateDraft, models.ProblemStatePublished},
+}
+
+// PublishCheck is one of the checks a problem must pass before it is published or scheduled
+type PublishCheck struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// PublishCheckError is returned when a problem cannot be published because checks failed
+type PublishCheckError struct {
+	Checks []PublishCheck
+}
+
+func (e *PublishCheckError) Error() string {
+	var failed []string
+	for _, check := range e.Checks {
+		if !check.Passed {
+			failed = append(failed, check.Message)
+		}
+	}
+	return "publish checks failed: " + strings.Join(failed, "; ")
+}
+
+// TransitionProblem moves a problem to another lifecycle state. publishAt is required to
+// schedule a problem and must be in the future; it is ignored for other states. It is stored in
+// UTC, since the publish_at column keeps no offset. Publishing or scheduling a problem requires
+// every publish check to pass.
+func (s *ProblemService) TransitionProblem(id int, state string, publishAt *time.Time) (*models.Problem, error) {
+	if _, exists := problemTransitions[state]; !exists {
+		return nil, fmt.Errorf("invalid state: must be one of: draft, review, scheduled, published, archived")
+	}
+	if state == models.ProblemStateScheduled {
+		if publishAt == nil {
+			return nil, fmt.Errorf("invalid state: publish_at is required to schedule a problem")
+		}
+		if !publishAt.After(time.Now()) {
+			return nil, fmt.Errorf("invalid state: publish_at must be in the future")
+		}
+		utc := publishAt.UTC()
+		publishAt = &utc
+	} else {
+		publishAt = nil
+	}
+
+	var updated *models.Problem
+	err := s.repo.WithTx(context.Background(), func(tx *repository.Repository) error {
+		// Lock the problem so that the scheduled publisher cannot publish it concurrently
+		if err := tx.ProblemRevision.LockProblem(id); err != nil {
+			return fmt.Errorf("failed to lock problem: %w", err)
+		}
+
+		problem, err := tx.Problem.GetByID(id)
+		if err != nil {
+			return fmt.Errorf("failed to get problem: %w", err)
+		}
+
+		if !canTransition(problem.State, state) {
+			return fmt.Errorf("invalid transition: a %s problem cannot move to %s", problem.State, state)
+		}
+
+		if state == models.ProblemStateScheduled || state == models.ProblemStatePublished {
+			checks, err := s.publishChecks(tx, problem)
+			if err != nil {
+				return err
+			}
+			if !allPassed(checks) {
+				return &PublishCheckError{Checks: checks}
+			}
+		}
+
+		updated, err = tx.Problem.SetState(id, state, publishAt)
+		if err != nil {
+			return fmt.Errorf("failed to update problem state: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// CheckPublishable runs the publish checks of a problem without changing it
+func (s *ProblemService) CheckPublishable(id int) ([]PublishCheck, error) {
+	problem, err := s.repo.Problem.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get problem: %w", err)
+	}
+
+	return s.publishChecks(s.repo, problem)
+}
+
+// PublishDueProblems publishes the scheduled problems whose publish time is at or before now
+// and returns how many were published. A scheduled problem that no longer passes the publish
+// checks is moved back to review instead.
+func (s *ProblemService) PublishDueProblems(now time.Time) (int, error) {
+	// Publish times are stored in UTC
+	ids, err := s.repo.Problem.ListScheduledDue(now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to list scheduled problems: %w", err)
+	}
+
+	published := 0
+	for _, id := range ids {
+		err := s.repo.WithTx(context.Background(), func(tx *repository.Repository) error {
+			if err := tx.ProblemRevision.LockProblem(id); err != nil {
+				return fmt.Errorf("failed to lock problem: %w", err)
+			}
+
+			// The problem may have been rescheduled or published since it was listed
+			problem, err := tx.Problem.GetByID(id)
+			if err != nil {
+				return fmt.Errorf("failed to get problem: %w", err)
+			}
+			if problem.State != models.ProblemStateScheduled || problem.PublishAt == nil || problem.PublishAt.After(now) {
+				return nil
+			}
+
+			checks, err := s.publishChecks(tx, problem)
+			if err != nil {
+				return err
+			}
+			if !allPassed(checks) {
+				fmt.Printf("Warning: scheduled problem %d moved back to review: %v\n", id, &PublishCheckError{Checks: checks})
+				_, err := tx.Problem.SetState(id, models.ProblemStateReview, nil)
+				return err
+			}
+
+			if _, err := tx.Problem.SetState(id, models.ProblemStatePublished, nil); err != nil {
+				return err
+			}
+			published++
+			return nil
+		})
+		if err != nil {
+			return published, fmt.Errorf("failed to publish problem %d: %w", id, err)
+		}
+	}
+
+	return published, nil
+}
+
+// StartScheduledPublishing publishes scheduled problems in the background every interval
+func (s *ProblemService) StartScheduledPublishing(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := s.PublishDueProblems(time.Now()); err != nil {
+				fmt.Printf("Warning: failed to publish scheduled problems: %v\n", err)
+			}
+		}
+	}()
+}
+
+// publishChecks runs the checks a problem must pass to be published: it must be valid, have at
+// least one public and one hidden test case, and have no expected output found stale or disputed
+// by its reference solutions
+func (s *ProblemService) publishChecks(repo *repository.Repository, problem *models.Problem) ([]PublishCheck, error) {
+	testCases, err := repo.TestCase.ListByProblemID(problem.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test cases: %w", err)
+	}
+
+	valid := PublishCheck{Name: "valid", Passed: true}
+	if err := s.validateProblem(problem); err != nil {
+		valid = PublishCheck{Name: "valid", Message: err.Error()}
+	}
+
+	public := PublishCheck{Name: "public_test_case", Message: "at least one public test case is required"}
+	hidden := PublishCheck{Name: "hidden_test_case", Message: "at least one hidden test case is required"}
+	outputs := PublishCheck{Name: "expected_outputs", Passed: true}
+	var rejected []string
+	for _, testCase := range testCases {
+		if testCase.IsHidden {
+			hidden.Passed = true
+		} else {
+			public.Passed = true
+		}
+		if testCase.OutputStatus == models.OutputStatusStale || testCase.OutputStatus == models.OutputStatusDisputed {
+			rejected = append(rejected, strconv.Itoa(testCase.ID))
+		}
+	}
+	for _, check := range []*PublishCheck{&public, &hidden} {
+		if check.Passed {
+			check.Message = ""
+		}
+	}
+	if len(rejected) > 0 {
+		outputs = PublishCheck{
+			Name:    "expected_outputs",
+			Message: "expected outputs of test cases " + strings.Join(rejected, ", ") + " do not match the reference solutions",
+		}
+	}
+
+	return []PublishCheck{valid, public, hidden, outputs}, nil
+}
+
+// canTransition reports whether a problem can move from one lifecycle state to another
+func canTransition(from, to string) bool {
+	for _, state := range problemTransitions[from] {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
+
+// allPassed reports whether every publish check passed
+func allPassed(checks []PublishCheck) bool {
+	for _, check := range checks {
+		if !check.Passed {
+			return false
+		}
+	}
+	return true
+}
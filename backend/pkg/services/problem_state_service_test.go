@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+)
+
+// newPublishableProblem creates a draft problem with a public and a hidden test case
+func newPublishableProblem(t *testing.T, service *ProblemService) *models.Problem {
+	t.Helper()
+
+	created, err := service.CreateProblem(revisionTestProblem("Two Sum"), 1)
+	if err != nil {
+		t.Fatalf("Failed to create problem: %v", err)
+	}
+	for _, hidden := range []bool{false, true} {
+		testCase := &models.TestCase{ProblemID: created.ID, Input: "1 2", ExpectedOutput: "3", IsHidden: hidden}
+		if _, err := service.CreateTestCase(testCase, 1); err != nil {
+			t.Fatalf("Failed to create test case: %v", err)
+		}
+	}
+
+	return created
+}
+
+func TestProblemService_TransitionProblem(t *testing.T) {
+	t.Run("new problems are drafts", func(t *testing.T) {
+		service, _, _, _ := newRevisionTestService()
+
+		created, err := service.CreateProblem(revisionTestProblem("Two Sum"), 1)
+		if err != nil {
+			t.Fatalf("Failed to create problem: %v", err)
+		}
+		if created.State != models.ProblemStateDraft {
+			t.Errorf("Expected state %q, got %q", models.ProblemStateDraft, created.State)
+		}
+	})
+
+	t.Run("publishes through review", func(t *testing.T) {
+		service, _, _, _ := newRevisionTestService()
+		created := newPublishableProblem(t, service)
+
+		if _, err := service.TransitionProblem(created.ID, models.ProblemStateReview, nil); err != nil {
+			t.Fatalf("Failed to move problem to review: %v", err)
+		}
+		published, err := service.TransitionProblem(created.ID, models.ProblemStatePublished, nil)
+		if err != nil {
+			t.Fatalf("Failed to publish problem: %v", err)
+		}
+		if published.State != models.ProblemStatePublished || published.PublishedAt == nil {
+			t.Errorf("Expected published problem with publish time, got %q at %v", published.State, published.PublishedAt)
+		}
+
+		if _, err := service.GetProblem(created.ID, 0); err != nil {
+			t.Errorf("Expected published problem to be public, got %v", err)
+		}
+	})
+
+	t.Run("blocks publishing until checks pass", func(t *testing.T) {
+		service, _, _, _ := newRevisionTestService()
+		created, _ := service.CreateProblem(revisionTestProblem("Two Sum"), 1)
+		service.CreateTestCase(&models.TestCase{ProblemID: created.ID, Input: "1 2", ExpectedOutput: "3"}, 1)
+
+		_, err := service.TransitionProblem(created.ID, models.ProblemStatePublished, nil)
+
+		var checkErr *PublishCheckError
+		if !errors.As(err, &checkErr) {
+			t.Fatalf("Expected publish check error, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "hidden test case") {
+			t.Errorf("Expected the missing hidden test case to be reported, got %v", err)
+		}
+		for _, check := range checkErr.Checks {
+			if check.Passed == (check.Name == "hidden_test_case") {
+				t.Errorf("Unexpected result of check %q: %+v", check.Name, check)
+			}
+		}
+	})
+
+	t.Run("rejects invalid transitions", func(t *testing.T) {
+		service, _, _, _ := newRevisionTestService()
+		created := newPublishableProblem(t, service)
+		service.TransitionProblem(created.ID, models.ProblemStatePublished, nil)
+
+		_, err := service.TransitionProblem(created.ID, models.ProblemStateDraft, nil)
+		if err == nil || !strings.Contains(err.Error(), "invalid transition") {
+			t.Errorf("Expected invalid transition error, got %v", err)
+		}
+
+		_, err = service.TransitionProblem(created.ID, "deleted", nil)
+		if err == nil || !strings.Contains(err.Error(), "invalid state") {
+			t.Errorf("Expected invalid state error, got %v", err)
+		}
+	})
+
+	t.Run("scheduling needs a future publish time", func(t *testing.T) {
+		service, _, _, _ := newRevisionTestService()
+		created := newPublishableProblem(t, service)
+		past := time.Now().Add(-time.Hour)
+
+		for _, publishAt := range []*time.Time{nil, &past} {
+			_, err := service.TransitionProblem(created.ID, models.ProblemStateScheduled, publishAt)
+			if err == nil || !strings.Contains(err.Error(), "invalid state") {
+				t.Errorf("Expected invalid state error for publish_at %v, got %v", publishAt, err)
+			}
+		}
+	})
+
+	t.Run("stores the publish time in UTC", func(t *testing.T) {
+		service, _, _, _ := newRevisionTestService()
+		created := newPublishableProblem(t, service)
+		publishAt := time.Now().Add(48 * time.Hour).In(time.FixedZone("UTC+7", 7*60*60))
+
+		scheduled, err := service.TransitionProblem(created.ID, models.ProblemStateScheduled, &publishAt)
+		if err != nil {
+			t.Fatalf("Failed to schedule problem: %v", err)
+		}
+		if scheduled.PublishAt == nil || scheduled.PublishAt.Location() != time.UTC || !scheduled.PublishAt.Equal(publishAt) {
+			t.Errorf("Expected publish time %v in UTC, got %v", publishAt.UTC(), scheduled.PublishAt)
+		}
+	})
+
+	t.Run("missing problem", func(t *testing.T) {
+		service, _, _, _ := newRevisionTestService()
+
+		_, err := service.TransitionProblem(99, models.ProblemStateReview, nil)
+		if err == nil || !repository.IsNotFound(err) {
+			t.Errorf("Expected not found error, got %v", err)
+		}
+	})
+}
+
+func TestProblemService_PublishDueProblems(t *testing.T) {
+	service, problemRepo, testCaseRepo, _ := newRevisionTestService()
+	publishAt := time.Now().Add(time.Hour)
+
+	due := newPublishableProblem(t, service)
+	later := newPublishableProblem(t, service)
+	broken := newPublishableProblem(t, service)
+	for _, problem := range []*models.Problem{due, later, broken} {
+		if _, err := service.TransitionProblem(problem.ID, models.ProblemStateScheduled, &publishAt); err != nil {
+			t.Fatalf("Failed to schedule problem: %v", err)
+		}
+	}
+	laterAt := publishAt.Add(time.Hour)
+	problemRepo.problems[later.ID].PublishAt = &laterAt
+
+	// The hidden test case of one problem is deleted after it was scheduled
+	testCases, _ := testCaseRepo.GetByProblemID(broken.ID)
+	for _, testCase := range testCases {
+		if testCase.IsHidden {
+			testCaseRepo.Delete(testCase.ID)
+		}
+	}
+
+	// The publisher may run in another time zone than the one the problems were scheduled in
+	published, err := service.PublishDueProblems(publishAt.In(time.FixedZone("UTC+7", 7*60*60)))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if problemRepo.dueAt.Location() != time.UTC {
+		t.Errorf("Expected due problems listed with a UTC time, got %v", problemRepo.dueAt)
+	}
+
+	if published != 1 {
+		t.Errorf("Expected 1 problem published, got %d", published)
+	}
+	expected := map[int]string{
+		due.ID:    models.ProblemStatePublished,
+		later.ID:  models.ProblemStateScheduled,
+		broken.ID: models.ProblemStateReview,
+	}
+	for id, state := range expected {
+		if problemRepo.problems[id].State != state {
+			t.Errorf("Problem %d: expected state %q, got %q", id, state, problemRepo.problems[id].State)
+		}
+	}
+}
+
+func TestProblemService_ListProblems_State(t *testing.T) {
+	service, problemRepo, _, _ := newRevisionTestService()
+	problemRepo.Create(&models.Problem{Title: "Draft", Difficulty: models.DifficultyEasy})
+	problemRepo.Create(&models.Problem{Title: "Published", Difficulty: models.DifficultyEasy, State: models.ProblemStatePublished})
+
+	page, err := service.ListProblems(repository.ProblemFilters{State: models.ProblemStatePublished})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if page.Total != 1 || len(page.Problems) != 1 || page.Problems[0].Title != "Published" {
+		t.Errorf("Expected only the published problem, got %d of %d", len(page.Problems), page.Total)
+	}
+
+	_, err = service.ListProblems(repository.ProblemFilters{State: "hidden"})
+	if err == nil || !strings.Contains(err.Error(), "invalid filters") {
+		t.Errorf("Expected invalid filters error, got %v", err)
+	}
+}
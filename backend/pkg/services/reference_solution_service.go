@@ -0,0 +1,386 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"leetcode-clone-backend/pkg/execution"
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+)
+
+// MaxReferenceSolutions is the number of reference solutions a problem can have
+const MaxReferenceSolutions = 10
+
+// ReferenceRun is the run of one reference solution on a test input
+type ReferenceRun struct {
+	Solution  string `json:"solution"`
+	Status    string `json:"status"` // Accepted when the solution ran to completion
+	Output    string `json:"output"` // First models.TestDataPreviewLength characters
+	RuntimeMs int    `json:"runtime_ms"`
+}
+
+// OutputCheck is the result of running the reference solutions of a problem on one test input
+type OutputCheck struct {
+	TestCaseID int            `json:"test_case_id,omitempty"`
+	Name       string         `json:"name,omitempty"` // Name of an uploaded test
+	Status     string         `json:"status"`         // One of the models.OutputStatus values
+	Message    string         `json:"message,omitempty"`
+	Runs       []ReferenceRun `json:"runs"`
+
+	output string // Output the reference solutions agree on
+}
+
+// label names the test case of a check in messages
+func (c *OutputCheck) label() string {
+	if c.Name != "" {
+		return "test " + c.Name
+	}
+	return fmt.Sprintf("test case %d", c.TestCaseID)
+}
+
+// OutputCheckReport is the result of checking the expected outputs of a problem's test cases
+type OutputCheckReport struct {
+	Checks   []*OutputCheck `json:"checks"`
+	Verified int            `json:"verified"`
+	Stale    int            `json:"stale"`
+	Disputed int            `json:"disputed"`
+}
+
+// OutputGenerationResult is the outcome of generating the expected outputs of a problem. Its
+// checks give the status of each test case before generation; stale test cases were updated.
+type OutputGenerationResult struct {
+	OutputCheckReport
+	Updated  int `json:"updated"`
+	Revision int `json:"revision,omitempty"` // Revision recorded when test cases were updated
+}
+
+// OutputCheckError is returned when expected outputs cannot be generated or accepted because the
+// reference solutions disagree, fail, or disagree with an uploaded output
+type OutputCheckError struct {
+	Checks []*OutputCheck
+}
+
+func (e *OutputCheckError) Error() string {
+	var failed []string
+	for _, check := range e.Checks {
+		if check.Status != models.OutputStatusVerified {
+			failed = append(failed, check.label()+": "+check.Message)
+		}
+	}
+	return "expected outputs rejected: " + strings.Join(failed, "; ")
+}
+
+// GetSolutions retrieves the reference solutions of a problem, ordered by name
+func (s *ProblemService) GetSolutions(problemID int) ([]*models.ProblemSolution, error) {
+	if _, err := s.repo.Problem.GetByID(problemID); err != nil {
+		return nil, fmt.Errorf("failed to get problem: %w", err)
+	}
+
+	solutions, err := s.repo.ProblemSolution.GetByProblemID(problemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reference solutions: %w", err)
+	}
+
+	return solutions, nil
+}
+
+// ReplaceSolutions replaces the reference solutions of a problem. The expected outputs of its
+// test cases become unverified, since they were checked against the previous solutions.
+func (s *ProblemService) ReplaceSolutions(problemID int, solutions []*models.ProblemSolution) ([]*models.ProblemSolution, error) {
+	if err := s.validateSolutions(solutions); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if _, err := s.repo.Problem.GetByID(problemID); err != nil {
+		return nil, fmt.Errorf("failed to get problem: %w", err)
+	}
+
+	var replaced []*models.ProblemSolution
+	err := s.repo.WithTx(context.Background(), func(tx *repository.Repository) error {
+		var err error
+		replaced, err = tx.ProblemSolution.Replace(problemID, solutions)
+		if err != nil {
+			return fmt.Errorf("failed to replace reference solutions: %w", err)
+		}
+		if err := tx.TestCase.ResetOutputStatus(problemID); err != nil {
+			return fmt.Errorf("failed to reset expected output status: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return replaced, nil
+}
+
+// CheckExpectedOutputs runs the reference solutions of a problem on the input of every test case
+// and records whether they agree with its expected output. Test cases changed while the
+// solutions ran keep their status.
+func (s *ProblemService) CheckExpectedOutputs(problemID int) (*OutputCheckReport, error) {
+	testCases, checks, err := s.checkProblemOutputs(problemID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.WithTx(context.Background(), func(tx *repository.Repository) error {
+		unchanged, err := unchangedTestCases(tx, problemID, testCases)
+		if err != nil {
+			return err
+		}
+		for _, check := range checks {
+			if !unchanged[check.TestCaseID] {
+				continue
+			}
+			if err := tx.TestCase.SetOutputStatus(check.TestCaseID, check.Status); err != nil {
+				return fmt.Errorf("failed to set expected output status: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newOutputCheckReport(checks), nil
+}
+
+// GenerateExpectedOutputs runs the reference solutions of a problem on the input of every test
+// case and replaces the stale expected outputs with the output they agree on, recorded as a
+// single revision authored by authorID. Nothing is changed if the solutions disagree or fail on
+// any input.
+func (s *ProblemService) GenerateExpectedOutputs(problemID, authorID int) (*OutputGenerationResult, error) {
+	testCases, checks, err := s.checkProblemOutputs(problemID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &OutputGenerationResult{OutputCheckReport: *newOutputCheckReport(checks)}
+	if result.Disputed > 0 {
+		return nil, &OutputCheckError{Checks: checks}
+	}
+
+	update := func(tx *repository.Repository) error {
+		unchanged, err := unchangedTestCases(tx, problemID, testCases)
+		if err != nil {
+			return err
+		}
+
+		for i, check := range checks {
+			if !unchanged[check.TestCaseID] {
+				return fmt.Errorf("test case %d changed while expected outputs were generated", check.TestCaseID)
+			}
+			if check.Status == models.OutputStatusStale {
+				testCase := testCases[i]
+				testCase.ExpectedOutput = check.output
+				if _, err := tx.TestCase.Update(testCase); err != nil {
+					return fmt.Errorf("failed to update test case %d: %w", testCase.ID, err)
+				}
+				result.Updated++
+			}
+			if err := tx.TestCase.SetOutputStatus(check.TestCaseID, models.OutputStatusVerified); err != nil {
+				return fmt.Errorf("failed to set expected output status: %w", err)
+			}
+		}
+		return nil
+	}
+
+	if result.Stale == 0 {
+		if err := s.repo.WithTx(context.Background(), update); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	change := revisionChange{authorID: authorID, action: models.RevisionActionOutputGenerate}
+	revisions, err := withProblemRevisions(s.repo, []int{problemID}, change, update)
+	if err != nil {
+		return nil, err
+	}
+	if revisions[0] != nil {
+		result.Revision = revisions[0].Revision
+	}
+
+	return result, nil
+}
+
+// checkProblemOutputs runs the reference solutions of a problem on its test cases, returned with
+// their data, and checks their expected outputs
+func (s *ProblemService) checkProblemOutputs(problemID int) ([]*models.TestCase, []*OutputCheck, error) {
+	if _, err := s.repo.Problem.GetByID(problemID); err != nil {
+		return nil, nil, fmt.Errorf("failed to get problem: %w", err)
+	}
+
+	testCases, err := s.repo.TestCase.GetByProblemID(problemID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get test cases: %w", err)
+	}
+	if len(testCases) == 0 {
+		return nil, nil, fmt.Errorf("validation failed: problem has no test cases")
+	}
+
+	checks, err := s.checkOutputs(problemID, testCases)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return testCases, checks, nil
+}
+
+// checkOutputs runs every reference solution of a problem on the inputs of testCases, with the
+// limits and checker of its judging settings. A test case is disputed when a solution fails or
+// two solutions disagree, and stale when the solutions agree on an output its expected output
+// does not match. Test cases without an expected output are only checked for agreement.
+func (s *ProblemService) checkOutputs(problemID int, testCases []*models.TestCase) ([]*OutputCheck, error) {
+	if s.executionService == nil {
+		return nil, fmt.Errorf("reference solutions cannot be run: no execution service configured")
+	}
+
+	solutions, err := s.repo.ProblemSolution.GetByProblemID(problemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reference solutions: %w", err)
+	}
+	if len(solutions) == 0 {
+		return nil, fmt.Errorf("validation failed: problem has no reference solutions")
+	}
+
+	settings, err := s.repo.JudgeSettings.GetByProblemID(problemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get judging settings: %w", err)
+	}
+
+	inputs := make([]string, len(testCases))
+	for i, testCase := range testCases {
+		inputs[i] = testCase.Input
+	}
+
+	runs := make([][]execution.RunResult, len(solutions))
+	for i, solution := range solutions {
+		results, err := s.executionService.RunCode(solution.Code, solution.Language, inputs, settings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run reference solution %s: %w", solution.Name, err)
+		}
+		if len(results) != len(inputs) {
+			return nil, fmt.Errorf("failed to run reference solution %s: got %d results for %d inputs", solution.Name, len(results), len(inputs))
+		}
+		runs[i] = results
+	}
+
+	checks := make([]*OutputCheck, len(testCases))
+	for j, testCase := range testCases {
+		check := &OutputCheck{TestCaseID: testCase.ID, Status: models.OutputStatusVerified}
+		for i, solution := range solutions {
+			run := runs[i][j]
+			check.Runs = append(check.Runs, ReferenceRun{
+				Solution:  solution.Name,
+				Status:    run.Status,
+				Output:    models.TestDataPreview(run.Output),
+				RuntimeMs: run.RuntimeMs,
+			})
+
+			switch {
+			case check.Status == models.OutputStatusDisputed:
+			case run.Status != models.StatusAccepted:
+				check.Status, check.Message = models.OutputStatusDisputed, fmt.Sprintf("solution %s: %s", solution.Name, run.Status)
+			case i == 0:
+				check.output = run.Output
+			case !execution.OutputsMatch(check.output, run.Output, settings):
+				check.Status, check.Message = models.OutputStatusDisputed, fmt.Sprintf("solutions %s and %s disagree", solutions[0].Name, solution.Name)
+			}
+		}
+
+		if check.Status == models.OutputStatusVerified {
+			if err := validateTestData("output", check.output); err != nil {
+				check.Status, check.Message = models.OutputStatusDisputed, err.Error()
+			} else if check.output == "" {
+				check.Status, check.Message = models.OutputStatusDisputed, "reference solutions produced no output"
+			}
+		}
+		if check.Status == models.OutputStatusVerified && testCase.ExpectedOutput != "" &&
+			!execution.OutputsMatch(testCase.ExpectedOutput, check.output, settings) {
+			check.Status, check.Message = models.OutputStatusStale, "expected output differs from the output of the reference solutions"
+		}
+		checks[j] = check
+	}
+
+	return checks, nil
+}
+
+// unchangedTestCases reports which of the test cases of a problem still have the same test data
+func unchangedTestCases(tx *repository.Repository, problemID int, testCases []*models.TestCase) (map[int]bool, error) {
+	current, err := tx.TestCase.ListByProblemID(problemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test cases: %w", err)
+	}
+
+	byID := make(map[int]*models.TestCase, len(current))
+	for _, testCase := range current {
+		byID[testCase.ID] = testCase
+	}
+
+	unchanged := make(map[int]bool, len(testCases))
+	for _, testCase := range testCases {
+		if now, exists := byID[testCase.ID]; exists {
+			unchanged[testCase.ID] = now.InputHash == testCase.InputHash && now.OutputHash == testCase.OutputHash
+		}
+	}
+	return unchanged, nil
+}
+
+// newOutputCheckReport counts the test cases of each status
+func newOutputCheckReport(checks []*OutputCheck) *OutputCheckReport {
+	report := &OutputCheckReport{Checks: checks}
+	for _, check := range checks {
+		switch check.Status {
+		case models.OutputStatusVerified:
+			report.Verified++
+		case models.OutputStatusStale:
+			report.Stale++
+		case models.OutputStatusDisputed:
+			report.Disputed++
+		}
+	}
+	return report
+}
+
+// validateSolutions validates a set of reference solutions
+func (s *ProblemService) validateSolutions(solutions []*models.ProblemSolution) error {
+	if len(solutions) > MaxReferenceSolutions {
+		return fmt.Errorf("a problem can have at most %d reference solutions", MaxReferenceSolutions)
+	}
+
+	names := make(map[string]bool, len(solutions))
+	for _, solution := range solutions {
+		if strings.TrimSpace(solution.Name) == "" {
+			return fmt.Errorf("solution name is required")
+		}
+		if len(solution.Name) > 100 {
+			return fmt.Errorf("solution %s: name must be 100 characters or less", solution.Name)
+		}
+		if names[solution.Name] {
+			return fmt.Errorf("solution %s is listed twice", solution.Name)
+		}
+		names[solution.Name] = true
+
+		switch solution.Language {
+		case models.LanguageJavaScript, models.LanguagePython, models.LanguageJava:
+		default:
+			return fmt.Errorf("solution %s: language must be one of: javascript, python, java", solution.Name)
+		}
+		if strings.TrimSpace(solution.Code) == "" {
+			return fmt.Errorf("solution %s: code is required", solution.Name)
+		}
+		if len(solution.Code) > execution.MaxCodeLength {
+			return fmt.Errorf("solution %s: code exceeds maximum length of %d bytes", solution.Name, execution.MaxCodeLength)
+		}
+		if s.executionService != nil {
+			if err := s.executionService.ValidateCode(solution.Code, solution.Language); err != nil {
+				return fmt.Errorf("solution %s: %w", solution.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
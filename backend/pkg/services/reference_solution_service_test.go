@@ -0,0 +1,330 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"leetcode-clone-backend/pkg/execution"
+	"leetcode-clone-backend/pkg/models"
+)
+
+// fakeStartupMs is the time every run of fakeSolutionRunner takes before its program starts
+const fakeStartupMs = 200
+
+// fakeSolutionRunner runs reference solutions that add the two numbers of their input, taking 10
+// ms and 1 MB per unit of the sum after fakeStartupMs. The code of a solution names its behavior:
+// "sum", "sum+1" or "crash". The generator "pair" turns a seed into an input of the seed twice,
+// the validator "max10" accepts inputs of numbers up to 10, and the calibration baselines echo
+// their input.
+type fakeSolutionRunner struct {
+	runs int
+}
+
+func (r *fakeSolutionRunner) ExecuteCode(code, language string, testCases []models.TestCase, settings *models.JudgeSettings) (*execution.ExecutionResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *fakeSolutionRunner) RunCode(code, language string, inputs []string, settings *models.JudgeSettings) ([]execution.RunResult, error) {
+	r.runs++
+	results := make([]execution.RunResult, len(inputs))
+	for i, input := range inputs {
+		if code == calibrationBaselines[language] {
+			results[i] = execution.RunResult{Output: input, Status: models.StatusAccepted, RuntimeMs: fakeStartupMs}
+			continue
+		}
+
+		if code == "crash" {
+			results[i] = execution.RunResult{Output: "error: exit status 1", Status: models.StatusRuntimeError}
+			continue
+		}
+
+		if code == "pair" {
+			results[i] = execution.RunResult{Output: input + " " + input, Status: models.StatusAccepted}
+			continue
+		}
+		if code == "max10" {
+			results[i] = execution.RunResult{Output: "OK", Status: models.StatusAccepted}
+			for _, field := range strings.Fields(input) {
+				if n, _ := strconv.Atoi(field); n > 10 {
+					results[i].Output = "a number exceeds 10"
+				}
+			}
+			continue
+		}
+
+		sum := 0
+		for _, field := range strings.Fields(input) {
+			n, _ := strconv.Atoi(field)
+			sum += n
+		}
+		if code == "sum+1" {
+			sum++
+		}
+		results[i] = execution.RunResult{Output: strconv.Itoa(sum), Status: models.StatusAccepted, RuntimeMs: fakeStartupMs + 10*sum, MemoryKb: 1024 * sum}
+	}
+	return results, nil
+}
+
+func (r *fakeSolutionRunner) ValidateCode(code, language string) error {
+	if strings.Contains(code, "import os") {
+		return errors.New("code contains potentially dangerous pattern: import os")
+	}
+	return nil
+}
+
+func newReferenceTestService(t *testing.T, solutions ...string) (*ProblemService, *mockTestCaseRepository, *mockProblemRevisionRepository, *models.Problem) {
+	t.Helper()
+
+	service, _, testCaseRepo, revisionRepo := newRevisionTestService()
+	service.executionService = &fakeSolutionRunner{}
+
+	problem, err := service.CreateProblem(revisionTestProblem("Two Sum"), 1)
+	if err != nil {
+		t.Fatalf("Failed to create problem: %v", err)
+	}
+
+	var references []*models.ProblemSolution
+	for _, code := range solutions {
+		references = append(references, &models.ProblemSolution{Name: code, Language: models.LanguagePython, Code: code})
+	}
+	if _, err := service.ReplaceSolutions(problem.ID, references); err != nil {
+		t.Fatalf("Failed to save reference solutions: %v", err)
+	}
+
+	return service, testCaseRepo, revisionRepo, problem
+}
+
+func TestProblemService_ReplaceSolutions(t *testing.T) {
+	service, testCaseRepo, _, problem := newReferenceTestService(t, "sum")
+	testCase, _ := service.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "1 2", ExpectedOutput: "3"}, 1)
+	testCaseRepo.SetOutputStatus(testCase.ID, models.OutputStatusVerified)
+
+	solution := func(name, language, code string) *models.ProblemSolution {
+		return &models.ProblemSolution{Name: name, Language: language, Code: code}
+	}
+
+	replaced, err := service.ReplaceSolutions(problem.ID, []*models.ProblemSolution{
+		solution("main", models.LanguagePython, "sum"),
+		solution("alt", models.LanguageJava, "sum"),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(replaced) != 2 {
+		t.Errorf("Expected 2 reference solutions, got %d", len(replaced))
+	}
+	if testCaseRepo.testCases[testCase.ID].OutputStatus != models.OutputStatusUnverified {
+		t.Errorf("Expected expected outputs unverified after the solutions changed, got %q", testCaseRepo.testCases[testCase.ID].OutputStatus)
+	}
+
+	tooMany := make([]*models.ProblemSolution, MaxReferenceSolutions+1)
+	for i := range tooMany {
+		tooMany[i] = solution("s"+strconv.Itoa(i), models.LanguagePython, "sum")
+	}
+
+	tests := []struct {
+		name          string
+		problemID     int
+		solutions     []*models.ProblemSolution
+		expectedError string
+	}{
+		{"missing name", problem.ID, []*models.ProblemSolution{solution(" ", models.LanguagePython, "sum")}, "name is required"},
+		{"duplicate name", problem.ID, []*models.ProblemSolution{solution("a", models.LanguagePython, "sum"), solution("a", models.LanguageJava, "sum")}, "listed twice"},
+		{"unsupported language", problem.ID, []*models.ProblemSolution{solution("a", "cpp", "sum")}, "language must be one of"},
+		{"empty code", problem.ID, []*models.ProblemSolution{solution("a", models.LanguagePython, "\n")}, "code is required"},
+		{"rejected by the sandbox", problem.ID, []*models.ProblemSolution{solution("a", models.LanguagePython, "import os")}, "dangerous pattern"},
+		{"too many solutions", problem.ID, tooMany, "at most 10"},
+		{"problem not found", 999, []*models.ProblemSolution{solution("a", models.LanguagePython, "sum")}, "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.ReplaceSolutions(tt.problemID, tt.solutions)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestProblemService_CheckExpectedOutputs(t *testing.T) {
+	service, testCaseRepo, _, problem := newReferenceTestService(t, "sum", "sum-again")
+	// "sum-again" behaves like "sum"
+	correct, _ := service.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "1 2", ExpectedOutput: "3\n"}, 1)
+	wrong, _ := service.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "2 2", ExpectedOutput: "5"}, 1)
+
+	report, err := service.CheckExpectedOutputs(problem.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if report.Verified != 1 || report.Stale != 1 || report.Disputed != 0 {
+		t.Errorf("Expected 1 verified and 1 stale test case, got %+v", report)
+	}
+	if len(report.Checks) != 2 || len(report.Checks[1].Runs) != 2 || report.Checks[1].Runs[0].Output != "4" {
+		t.Errorf("Expected the runs of both solutions in the report, got %+v", report.Checks)
+	}
+	if testCaseRepo.testCases[correct.ID].OutputStatus != models.OutputStatusVerified ||
+		testCaseRepo.testCases[wrong.ID].OutputStatus != models.OutputStatusStale {
+		t.Errorf("Expected the statuses recorded, got %q and %q",
+			testCaseRepo.testCases[correct.ID].OutputStatus, testCaseRepo.testCases[wrong.ID].OutputStatus)
+	}
+
+	checks, _ := service.CheckPublishable(problem.ID)
+	if checks[len(checks)-1].Passed || !strings.Contains(checks[len(checks)-1].Message, strconv.Itoa(wrong.ID)) {
+		t.Errorf("Expected the stale test case to block publishing, got %+v", checks[len(checks)-1])
+	}
+
+	t.Run("disputed when solutions disagree", func(t *testing.T) {
+		service, _, _, problem := newReferenceTestService(t, "sum", "sum+1")
+		service.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "1 2", ExpectedOutput: "3"}, 1)
+
+		report, err := service.CheckExpectedOutputs(problem.ID)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if report.Disputed != 1 || !strings.Contains(report.Checks[0].Message, "sum and sum+1 disagree") {
+			t.Errorf("Expected a disputed test case, got %+v", report.Checks[0])
+		}
+	})
+
+	t.Run("disputed when a solution fails", func(t *testing.T) {
+		service, _, _, problem := newReferenceTestService(t, "crash", "sum")
+		service.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "1 2", ExpectedOutput: "3"}, 1)
+
+		report, _ := service.CheckExpectedOutputs(problem.ID)
+		if report.Disputed != 1 || !strings.Contains(report.Checks[0].Message, "solution crash: Runtime Error") {
+			t.Errorf("Expected a disputed test case, got %+v", report.Checks[0])
+		}
+	})
+
+	t.Run("requires reference solutions", func(t *testing.T) {
+		service, _, _, problem := newReferenceTestService(t)
+		service.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "1 2", ExpectedOutput: "3"}, 1)
+
+		_, err := service.CheckExpectedOutputs(problem.ID)
+		if err == nil || !strings.Contains(err.Error(), "validation failed: problem has no reference solutions") {
+			t.Errorf("Expected missing solutions error, got %v", err)
+		}
+	})
+
+	t.Run("requires an execution service", func(t *testing.T) {
+		service, _, _, problem := newReferenceTestService(t, "sum")
+		service.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "1 2", ExpectedOutput: "3"}, 1)
+		service.executionService = nil
+
+		_, err := service.CheckExpectedOutputs(problem.ID)
+		if err == nil || !strings.Contains(err.Error(), "cannot be run") {
+			t.Errorf("Expected an error without an execution service, got %v", err)
+		}
+	})
+}
+
+func TestProblemService_GenerateExpectedOutputs(t *testing.T) {
+	service, testCaseRepo, revisionRepo, problem := newReferenceTestService(t, "sum")
+	correct, _ := service.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "1 2", ExpectedOutput: "3"}, 1)
+	wrong, _ := service.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "2 2", ExpectedOutput: "5"}, 1)
+	revisionsBefore := len(revisionRepo.revisions)
+
+	result, err := service.GenerateExpectedOutputs(problem.ID, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Updated != 1 || result.Stale != 1 {
+		t.Errorf("Expected the stale test case updated, got %+v", result)
+	}
+	if testCaseRepo.testCases[wrong.ID].ExpectedOutput != "4" {
+		t.Errorf("Expected generated output 4, got %q", testCaseRepo.testCases[wrong.ID].ExpectedOutput)
+	}
+	for _, id := range []int{correct.ID, wrong.ID} {
+		if status := testCaseRepo.testCases[id].OutputStatus; status != models.OutputStatusVerified {
+			t.Errorf("Expected test case %d verified, got %q", id, status)
+		}
+	}
+
+	if len(revisionRepo.revisions) != revisionsBefore+1 {
+		t.Fatalf("Expected one revision for the generated outputs, got %d", len(revisionRepo.revisions)-revisionsBefore)
+	}
+	revision := revisionRepo.revisions[len(revisionRepo.revisions)-1]
+	if revision.Action != models.RevisionActionOutputGenerate || result.Revision != revision.Revision {
+		t.Errorf("Expected generate revision %d, got %q revision %d", result.Revision, revision.Action, revision.Revision)
+	}
+
+	t.Run("records no revision when outputs are up to date", func(t *testing.T) {
+		result, err := service.GenerateExpectedOutputs(problem.ID, 2)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result.Updated != 0 || result.Revision != 0 || len(revisionRepo.revisions) != revisionsBefore+1 {
+			t.Errorf("Expected nothing updated, got %+v", result)
+		}
+	})
+
+	t.Run("rejects the test set when solutions disagree", func(t *testing.T) {
+		service, testCaseRepo, _, problem := newReferenceTestService(t, "sum", "sum+1")
+		testCase, _ := service.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "1 2", ExpectedOutput: "7"}, 1)
+
+		_, err := service.GenerateExpectedOutputs(problem.ID, 2)
+
+		var checkErr *OutputCheckError
+		if !errors.As(err, &checkErr) || len(checkErr.Checks) != 1 {
+			t.Fatalf("Expected output check error, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "disagree") {
+			t.Errorf("Expected the disagreement reported, got %v", err)
+		}
+		if testCaseRepo.testCases[testCase.ID].ExpectedOutput != "7" {
+			t.Errorf("Expected the test case unchanged, got %q", testCaseRepo.testCases[testCase.ID].ExpectedOutput)
+		}
+	})
+}
+
+func TestProblemService_UploadTestCases_GenerateOutputs(t *testing.T) {
+	service, _, _, problem := newReferenceTestService(t, "sum")
+
+	result, err := service.UploadTestCases(problem.ID, &TestCaseUpload{
+		Files:           testDataFiles(map[string]string{"1.in": "1 2", "2.in": "5 5", "2.out": "10"}),
+		Public:          []string{"1"},
+		GenerateOutputs: true,
+	}, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Count != 2 {
+		t.Fatalf("Expected 2 test cases, got %d", result.Count)
+	}
+	for _, testCase := range result.TestCases {
+		if testCase.OutputStatus != models.OutputStatusVerified {
+			t.Errorf("Expected test %s verified, got %q", testCase.Name, testCase.OutputStatus)
+		}
+	}
+	if stored, _ := service.GetTestCase(result.TestCases[0].ID); stored.ExpectedOutput != "3" {
+		t.Errorf("Expected generated output 3, got %q", stored.ExpectedOutput)
+	}
+
+	tests := []struct {
+		name          string
+		solutions     []string
+		files         map[string]string
+		expectedError string
+	}{
+		{"uploaded output differs", []string{"sum"}, map[string]string{"1.in": "1 2", "1.out": "4"}, "test 1: expected output differs"},
+		{"solutions disagree", []string{"sum", "sum+1"}, map[string]string{"1.in": "1 2"}, "test 1: solutions sum and sum+1 disagree"},
+		{"no solutions", nil, map[string]string{"1.in": "1 2"}, "no reference solutions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, testCaseRepo, _, problem := newReferenceTestService(t, tt.solutions...)
+
+			_, err := service.UploadTestCases(problem.ID, &TestCaseUpload{Files: testDataFiles(tt.files), GenerateOutputs: true}, 2)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectedError, err)
+			}
+			if len(testCaseRepo.testCases) != 0 {
+				t.Errorf("Expected no test cases created, got %d", len(testCaseRepo.testCases))
+			}
+		})
+	}
+}
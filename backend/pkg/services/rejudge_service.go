@@ -0,0 +1,518 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"leetcode-clone-backend/pkg/execution"
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+)
+
+// rejudgeProgressInterval is the number of processed submissions between job progress updates
+const rejudgeProgressInterval = 10
+
+// Rejudge job leases. A worker renews the lease of the job it runs every heartbeat interval; a
+// running job whose lease was not renewed for the lease timeout was abandoned and is resumed.
+const (
+	rejudgeHeartbeatInterval = time.Minute
+	rejudgeLeaseTimeout      = 5 * time.Minute
+)
+
+// errRejudgeLeaseLost is returned when a job was claimed again by another worker while it ran
+var errRejudgeLeaseLost = errors.New("rejudge job was claimed by another worker")
+
+// RejudgeServiceInterface defines the interface for rejudge service
+type RejudgeServiceInterface interface {
+	RejudgeSubmission(submissionID, adminID int) (*models.RejudgeJob, error)
+	RejudgeProblem(problemID int, from, to *time.Time, adminID int) (*models.RejudgeJob, error)
+	RejudgeRange(problemID int, from, to *time.Time, adminID int) (*models.RejudgeJob, error)
+	GetJob(id int, changedOnly bool) (*RejudgeJobDetails, error)
+	ListJobs(page, pageSize int) (*RejudgeJobListResponse, error)
+}
+
+// RejudgeService re-executes existing submissions against the current test cases.
+// Jobs are stored in the database and processed one at a time by a background worker, which
+// claims each job so that a single instance processes it.
+type RejudgeService struct {
+	repo             *repository.Repository
+	executionService execution.ExecutionServiceInterface
+	percentiles      PercentileServiceInterface
+	wake             chan struct{}
+}
+
+// NewRejudgeService creates a new rejudge service
+func NewRejudgeService(
+	repo *repository.Repository,
+	executionService execution.ExecutionServiceInterface,
+	percentiles PercentileServiceInterface,
+) *RejudgeService {
+	return &RejudgeService{
+		repo:             repo,
+		executionService: executionService,
+		percentiles:      percentiles,
+		wake:             make(chan struct{}, 1),
+	}
+}
+
+// RejudgeJobDetails represents a rejudge job together with its per-submission results
+type RejudgeJobDetails struct {
+	Job     *models.RejudgeJob      `json:"job"`
+	Results []*models.RejudgeResult `json:"results"`
+}
+
+// RejudgeJobListResponse represents a paginated list of rejudge jobs
+type RejudgeJobListResponse struct {
+	Jobs     []*models.RejudgeJob `json:"jobs"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"page_size"`
+	HasNext  bool                 `json:"has_next"`
+}
+
+// Start launches the background worker. Jobs left queued or running by a previous
+// process are picked up immediately.
+func (rs *RejudgeService) Start() {
+	go func() {
+		for range rs.wake {
+			rs.processPendingJobs()
+		}
+	}()
+	rs.notify()
+}
+
+// notify wakes up the worker without blocking if a wake-up is already pending
+func (rs *RejudgeService) notify() {
+	select {
+	case rs.wake <- struct{}{}:
+	default:
+	}
+}
+
+// RejudgeSubmission queues a rejudge of a single submission
+func (rs *RejudgeService) RejudgeSubmission(submissionID, adminID int) (*models.RejudgeJob, error) {
+	if _, err := rs.repo.Submission.GetByID(submissionID); err != nil {
+		return nil, fmt.Errorf("failed to retrieve submission: %w", err)
+	}
+
+	return rs.createJob(&models.RejudgeJob{
+		Scope:        models.RejudgeScopeSubmission,
+		SubmissionID: &submissionID,
+		Total:        1,
+	}, adminID)
+}
+
+// RejudgeProblem queues a rejudge of all submissions for a problem,
+// optionally limited to submissions made within [from, to)
+func (rs *RejudgeService) RejudgeProblem(problemID int, from, to *time.Time, adminID int) (*models.RejudgeJob, error) {
+	if err := validateRejudgeRange(from, to); err != nil {
+		return nil, fmt.Errorf("invalid rejudge request: %w", err)
+	}
+	if _, err := rs.repo.Problem.GetByID(problemID); err != nil {
+		return nil, fmt.Errorf("failed to retrieve problem: %w", err)
+	}
+
+	return rs.createJob(&models.RejudgeJob{
+		Scope:     models.RejudgeScopeProblem,
+		ProblemID: &problemID,
+		FromTime:  from,
+		ToTime:    to,
+	}, adminID)
+}
+
+// RejudgeRange queues a rejudge of all submissions made within [from, to),
+// optionally limited to a single problem when problemID is non-zero
+func (rs *RejudgeService) RejudgeRange(problemID int, from, to *time.Time, adminID int) (*models.RejudgeJob, error) {
+	if from == nil || to == nil {
+		return nil, fmt.Errorf("invalid rejudge request: both from and to are required")
+	}
+	if err := validateRejudgeRange(from, to); err != nil {
+		return nil, fmt.Errorf("invalid rejudge request: %w", err)
+	}
+
+	job := &models.RejudgeJob{
+		Scope:    models.RejudgeScopeRange,
+		FromTime: from,
+		ToTime:   to,
+	}
+	if problemID > 0 {
+		if _, err := rs.repo.Problem.GetByID(problemID); err != nil {
+			return nil, fmt.Errorf("failed to retrieve problem: %w", err)
+		}
+		job.ProblemID = &problemID
+	}
+
+	return rs.createJob(job, adminID)
+}
+
+// GetJob retrieves a rejudge job with its results, optionally only the changed verdicts
+func (rs *RejudgeService) GetJob(id int, changedOnly bool) (*RejudgeJobDetails, error) {
+	job, err := rs.repo.Rejudge.GetJob(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve rejudge job: %w", err)
+	}
+
+	results, err := rs.repo.Rejudge.GetResults(id, changedOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve rejudge results: %w", err)
+	}
+	if results == nil {
+		results = []*models.RejudgeResult{}
+	}
+
+	return &RejudgeJobDetails{Job: job, Results: results}, nil
+}
+
+// ListJobs retrieves rejudge jobs with pagination, newest first
+func (rs *RejudgeService) ListJobs(page, pageSize int) (*RejudgeJobListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20 // Default page size
+	}
+
+	offset := (page - 1) * pageSize
+
+	jobs, err := rs.repo.Rejudge.ListJobs(pageSize+1, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve rejudge jobs: %w", err)
+	}
+
+	hasNext := len(jobs) > pageSize
+	if hasNext {
+		jobs = jobs[:pageSize]
+	}
+	if jobs == nil {
+		jobs = []*models.RejudgeJob{}
+	}
+
+	return &RejudgeJobListResponse{
+		Jobs:     jobs,
+		Page:     page,
+		PageSize: pageSize,
+		HasNext:  hasNext,
+	}, nil
+}
+
+// createJob stores a queued job and wakes up the worker
+func (rs *RejudgeService) createJob(job *models.RejudgeJob, adminID int) (*models.RejudgeJob, error) {
+	job.Status = models.RejudgeStatusQueued
+	if adminID > 0 {
+		job.CreatedBy = &adminID
+	}
+
+	created, err := rs.repo.Rejudge.CreateJob(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rejudge job: %w", err)
+	}
+
+	rs.notify()
+	return created, nil
+}
+
+// processPendingJobs processes, in creation order, the queued jobs and the running jobs abandoned
+// by their worker. Jobs claimed by another instance are skipped.
+func (rs *RejudgeService) processPendingJobs() {
+	jobs, err := rs.repo.Rejudge.GetUnfinishedJobs()
+	if err != nil {
+		fmt.Printf("Warning: failed to retrieve pending rejudge jobs: %v\n", err)
+		return
+	}
+
+	for _, pending := range jobs {
+		job, err := rs.repo.Rejudge.ClaimJob(pending.ID, rejudgeLeaseTimeout)
+		if err != nil {
+			if !repository.IsNotFound(err) {
+				fmt.Printf("Warning: failed to claim rejudge job %d: %v\n", pending.ID, err)
+			}
+			continue
+		}
+
+		if err := rs.processJob(job); err != nil {
+			fmt.Printf("Warning: rejudge job %d failed: %v\n", job.ID, err)
+		}
+	}
+}
+
+// processJob rejudges every submission in the scope of a claimed job, records the old and new
+// verdicts and recomputes the progress of every affected user and the histograms of every affected problem.
+// A resumed job keeps the results recorded before it was interrupted, whose old verdicts are the
+// original ones, and rejudges only the other submissions. If the lease on the job is lost, the
+// job is left to the worker that claimed it again.
+func (rs *RejudgeService) processJob(job *models.RejudgeJob) error {
+	leaseLost, stopHeartbeat := rs.heartbeat(job)
+	defer stopHeartbeat()
+
+	if job.StartedAt == nil {
+		startedAt := time.Now()
+		job.StartedAt = &startedAt
+	}
+	job.Status = models.RejudgeStatusRunning
+	job.FinishedAt = nil
+	job.ErrorMessage = nil
+	job.Processed = 0
+	job.Changed = 0
+	job.Failed = 0
+
+	submissionIDs, err := rs.resolveSubmissionIDs(job)
+	if err != nil {
+		return rs.failJob(job, err)
+	}
+	job.Total = len(submissionIDs)
+
+	results, err := rs.repo.Rejudge.GetResults(job.ID, false)
+	if err != nil {
+		return rs.failJob(job, fmt.Errorf("failed to retrieve rejudge results: %w", err))
+	}
+	recorded := make(map[int]*models.RejudgeResult, len(results))
+	for _, result := range results {
+		recorded[result.SubmissionID] = result
+	}
+
+	if _, err := rs.repo.Rejudge.UpdateJob(job); err != nil {
+		return fmt.Errorf("failed to update rejudge job: %w", err)
+	}
+
+	judgeDataByProblem := make(map[int]*problemJudgeData)
+	affected := make(map[[2]int]struct{})
+
+	for _, submissionID := range submissionIDs {
+		select {
+		case <-leaseLost:
+			return errRejudgeLeaseLost
+		default:
+		}
+
+		var submission *models.Submission
+		var changed bool
+		if result, ok := recorded[submissionID]; ok {
+			submission, err = rs.repo.Submission.GetByID(submissionID)
+			changed = result.VerdictChanged
+		} else {
+			submission, changed, err = rs.rejudgeSubmission(job.ID, submissionID, judgeDataByProblem)
+		}
+		// A result recorded meanwhile for this job means another worker claimed it
+		if repository.IsConflict(err) {
+			return errRejudgeLeaseLost
+		}
+		if err != nil {
+			fmt.Printf("Warning: failed to rejudge submission %d: %v\n", submissionID, err)
+			job.Failed++
+		} else {
+			affected[[2]int{submission.UserID, submission.ProblemID}] = struct{}{}
+			if changed {
+				job.Changed++
+			}
+		}
+		job.Processed++
+
+		if job.Processed%rejudgeProgressInterval == 0 && job.Processed < job.Total {
+			if _, err := rs.repo.Rejudge.UpdateJob(job); err != nil {
+				if repository.IsNotFound(err) {
+					return errRejudgeLeaseLost
+				}
+				fmt.Printf("Warning: failed to update rejudge job progress: %v\n", err)
+			}
+		}
+	}
+
+	problems := make(map[int]struct{})
+	for key := range affected {
+		if _, err := rs.repo.UserProgress.Recompute(key[0], key[1]); err != nil {
+			fmt.Printf("Warning: failed to recompute user progress: %v\n", err)
+		}
+		problems[key[1]] = struct{}{}
+	}
+
+	// Verdicts and runtimes may have changed, so the performance histograms are rebuilt
+	for problemID := range problems {
+		if err := rs.percentiles.RebuildProblem(problemID); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+
+	finishedAt := time.Now()
+	job.Status = models.RejudgeStatusCompleted
+	job.FinishedAt = &finishedAt
+	if _, err := rs.repo.Rejudge.UpdateJob(job); err != nil {
+		return fmt.Errorf("failed to update rejudge job: %w", err)
+	}
+
+	return nil
+}
+
+// heartbeat renews the lease of a running job until the returned function is called. The
+// returned channel is closed when the job was claimed again by another worker.
+func (rs *RejudgeService) heartbeat(job *models.RejudgeJob) (<-chan struct{}, func()) {
+	done := make(chan struct{})
+	lost := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(rejudgeHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := rs.repo.Rejudge.Heartbeat(job.ID, job.ClaimToken)
+				if repository.IsNotFound(err) {
+					close(lost)
+					return
+				}
+				if err != nil {
+					fmt.Printf("Warning: failed to renew rejudge job %d: %v\n", job.ID, err)
+				}
+			}
+		}
+	}()
+	return lost, func() { close(done) }
+}
+
+// resolveSubmissionIDs returns the IDs of the submissions in the scope of a job
+func (rs *RejudgeService) resolveSubmissionIDs(job *models.RejudgeJob) ([]int, error) {
+	switch job.Scope {
+	case models.RejudgeScopeSubmission:
+		if job.SubmissionID == nil {
+			return nil, fmt.Errorf("submission no longer exists")
+		}
+		return []int{*job.SubmissionID}, nil
+	case models.RejudgeScopeProblem, models.RejudgeScopeRange:
+		problemID := 0
+		if job.ProblemID != nil {
+			problemID = *job.ProblemID
+		}
+		ids, err := rs.repo.Submission.GetIDsForRejudge(problemID, job.FromTime, job.ToTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve submissions: %w", err)
+		}
+		return ids, nil
+	default:
+		return nil, fmt.Errorf("unknown rejudge scope: %s", job.Scope)
+	}
+}
+
+// failJob marks a job as failed with the given error
+func (rs *RejudgeService) failJob(job *models.RejudgeJob, cause error) error {
+	finishedAt := time.Now()
+	message := cause.Error()
+	job.Status = models.RejudgeStatusFailed
+	job.ErrorMessage = &message
+	job.FinishedAt = &finishedAt
+
+	if _, err := rs.repo.Rejudge.UpdateJob(job); err != nil {
+		return fmt.Errorf("failed to update rejudge job: %w", err)
+	}
+	return cause
+}
+
+// problemJudgeData is what a rejudge job needs to judge the submissions of one problem
+type problemJudgeData struct {
+	testCases []*models.TestCase
+	settings  *models.JudgeSettings
+}
+
+// rejudgeSubmission re-executes a single submission, stores the new verdict and test results
+// and records the change. Test cases and judging settings are cached per problem for the
+// duration of a job.
+func (rs *RejudgeService) rejudgeSubmission(jobID, submissionID int, judgeDataByProblem map[int]*problemJudgeData) (*models.Submission, bool, error) {
+	submission, err := rs.repo.Submission.GetByID(submissionID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to retrieve submission: %w", err)
+	}
+
+	data, ok := judgeDataByProblem[submission.ProblemID]
+	if !ok {
+		data = &problemJudgeData{}
+		data.testCases, err = rs.repo.TestCase.GetByProblemID(submission.ProblemID)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to retrieve test cases: %w", err)
+		}
+		data.settings, err = rs.repo.JudgeSettings.GetByProblemID(submission.ProblemID)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to retrieve judging settings: %w", err)
+		}
+		judgeDataByProblem[submission.ProblemID] = data
+	}
+	testCases := data.testCases
+
+	if len(testCases) == 0 {
+		return nil, false, fmt.Errorf("no test cases available for problem %d", submission.ProblemID)
+	}
+
+	allTestCases := make([]models.TestCase, len(testCases))
+	for i, tc := range testCases {
+		allTestCases[i] = *tc
+	}
+
+	executionResult, err := rs.executionService.ExecuteCode(submission.Code, submission.Language, allTestCases, data.settings)
+	if err != nil {
+		return nil, false, fmt.Errorf("code execution failed: %w", err)
+	}
+
+	result := &models.RejudgeResult{
+		JobID:              jobID,
+		SubmissionID:       submission.ID,
+		OldStatus:          submission.Status,
+		OldTestCasesPassed: submission.TestCasesPassed,
+		OldRuntimeMs:       submission.RuntimeMs,
+	}
+
+	submission.Status = executionResult.Status
+	submission.TestCasesPassed = executionResult.TestCasesPassed
+	submission.TotalTestCases = executionResult.TotalTestCases
+	submission.RuntimeMs = nil
+	submission.MemoryKb = nil
+	submission.ErrorMessage = nil
+	if executionResult.RuntimeMs > 0 {
+		submission.RuntimeMs = &executionResult.RuntimeMs
+	}
+	if executionResult.MemoryKb > 0 {
+		submission.MemoryKb = &executionResult.MemoryKb
+	}
+	if executionResult.ErrorMessage != "" {
+		submission.ErrorMessage = &executionResult.ErrorMessage
+	}
+
+	// Replace the verdict, the per-test-case results and the rejudge result together
+	var updated *models.Submission
+	err = rs.repo.WithTx(context.Background(), func(tx *repository.Repository) error {
+		var err error
+		updated, err = tx.Submission.Update(submission)
+		if err != nil {
+			return fmt.Errorf("failed to update submission: %w", err)
+		}
+
+		if err := tx.SubmissionTestResult.DeleteBySubmissionID(updated.ID); err != nil {
+			return fmt.Errorf("failed to delete previous test case results: %w", err)
+		}
+		if err := tx.SubmissionTestResult.CreateBatch(buildSubmissionTestResults(updated.ID, testCases, executionResult.TestResults)); err != nil {
+			return fmt.Errorf("failed to store test case results: %w", err)
+		}
+
+		result.NewStatus = updated.Status
+		result.NewTestCasesPassed = updated.TestCasesPassed
+		result.NewRuntimeMs = updated.RuntimeMs
+		result.VerdictChanged = result.OldStatus != result.NewStatus
+
+		if _, err := tx.Rejudge.CreateResult(result); err != nil {
+			return fmt.Errorf("failed to record rejudge result: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return updated, result.VerdictChanged, nil
+}
+
+// validateRejudgeRange validates optional submission time bounds
+func validateRejudgeRange(from, to *time.Time) error {
+	if from != nil && to != nil && !from.Before(*to) {
+		return fmt.Errorf("from must be before to")
+	}
+	return nil
+}
@@ -0,0 +1,372 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"leetcode-clone-backend/pkg/execution"
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRejudgeRepository struct {
+	mock.Mock
+}
+
+func (m *MockRejudgeRepository) CreateJob(job *models.RejudgeJob) (*models.RejudgeJob, error) {
+	args := m.Called(job)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RejudgeJob), args.Error(1)
+}
+
+func (m *MockRejudgeRepository) GetJob(id int) (*models.RejudgeJob, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RejudgeJob), args.Error(1)
+}
+
+func (m *MockRejudgeRepository) ListJobs(limit, offset int) ([]*models.RejudgeJob, error) {
+	args := m.Called(limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.RejudgeJob), args.Error(1)
+}
+
+func (m *MockRejudgeRepository) GetUnfinishedJobs() ([]*models.RejudgeJob, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.RejudgeJob), args.Error(1)
+}
+
+func (m *MockRejudgeRepository) ClaimJob(id int, leaseTimeout time.Duration) (*models.RejudgeJob, error) {
+	args := m.Called(id, leaseTimeout)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RejudgeJob), args.Error(1)
+}
+
+func (m *MockRejudgeRepository) Heartbeat(id int, claimToken string) error {
+	args := m.Called(id, claimToken)
+	return args.Error(0)
+}
+
+func (m *MockRejudgeRepository) UpdateJob(job *models.RejudgeJob) (*models.RejudgeJob, error) {
+	args := m.Called(job)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RejudgeJob), args.Error(1)
+}
+
+func (m *MockRejudgeRepository) CreateResult(result *models.RejudgeResult) (*models.RejudgeResult, error) {
+	args := m.Called(result)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RejudgeResult), args.Error(1)
+}
+
+func (m *MockRejudgeRepository) GetResults(jobID int, changedOnly bool) ([]*models.RejudgeResult, error) {
+	args := m.Called(jobID, changedOnly)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.RejudgeResult), args.Error(1)
+}
+
+type rejudgeTestMocks struct {
+	submissionRepo   *MockSubmissionRepository
+	testCaseRepo     *MockTestCaseRepository
+	testResultRepo   *MockSubmissionTestResultRepository
+	userProgressRepo *MockUserProgressRepository
+	problemRepo      *mockProblemRepository
+	rejudgeRepo      *MockRejudgeRepository
+	executionService *MockExecutionService
+	percentiles      *MockPercentileService
+}
+
+func newRejudgeTestService() (*RejudgeService, *rejudgeTestMocks) {
+	mocks := &rejudgeTestMocks{
+		submissionRepo:   new(MockSubmissionRepository),
+		testCaseRepo:     new(MockTestCaseRepository),
+		testResultRepo:   new(MockSubmissionTestResultRepository),
+		userProgressRepo: new(MockUserProgressRepository),
+		problemRepo:      newMockProblemRepository(),
+		rejudgeRepo:      new(MockRejudgeRepository),
+		executionService: new(MockExecutionService),
+		percentiles:      new(MockPercentileService),
+	}
+	repo := &repository.Repository{
+		Submission:           mocks.submissionRepo,
+		TestCase:             mocks.testCaseRepo,
+		SubmissionTestResult: mocks.testResultRepo,
+		UserProgress:         mocks.userProgressRepo,
+		Problem:              mocks.problemRepo,
+		Rejudge:              mocks.rejudgeRepo,
+		JudgeSettings:        newMockJudgeSettingsRepository(),
+	}
+	service := NewRejudgeService(repo, mocks.executionService, mocks.percentiles)
+	return service, mocks
+}
+
+func TestRejudgeService_RejudgeProblem(t *testing.T) {
+	service, mocks := newRejudgeTestService()
+	mocks.problemRepo.Create(&models.Problem{Title: "Two Sum"})
+
+	t.Run("queues job for existing problem", func(t *testing.T) {
+		mocks.rejudgeRepo.On("CreateJob", mock.MatchedBy(func(job *models.RejudgeJob) bool {
+			return job.Scope == models.RejudgeScopeProblem && *job.ProblemID == 1 &&
+				job.Status == models.RejudgeStatusQueued && *job.CreatedBy == 7
+		})).Return(&models.RejudgeJob{ID: 3, Scope: models.RejudgeScopeProblem, Status: models.RejudgeStatusQueued}, nil).Once()
+
+		job, err := service.RejudgeProblem(1, nil, nil, 7)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, job.ID)
+		mocks.rejudgeRepo.AssertExpectations(t)
+	})
+
+	t.Run("problem not found", func(t *testing.T) {
+		job, err := service.RejudgeProblem(99, nil, nil, 7)
+
+		assert.Error(t, err)
+		assert.Nil(t, job)
+		assert.Contains(t, err.Error(), "not found")
+	})
+
+	t.Run("invalid date range", func(t *testing.T) {
+		from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		job, err := service.RejudgeProblem(1, &from, &to, 7)
+
+		assert.Error(t, err)
+		assert.Nil(t, job)
+		assert.Contains(t, err.Error(), "invalid rejudge request")
+	})
+}
+
+func TestRejudgeService_RejudgeRange(t *testing.T) {
+	service, _ := newRejudgeTestService()
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	job, err := service.RejudgeRange(0, &from, nil, 7)
+
+	assert.Error(t, err)
+	assert.Nil(t, job)
+	assert.Contains(t, err.Error(), "both from and to are required")
+}
+
+func TestRejudgeService_processJob(t *testing.T) {
+	service, mocks := newRejudgeTestService()
+
+	problemID := 1
+	job := &models.RejudgeJob{ID: 5, Scope: models.RejudgeScopeProblem, ProblemID: &problemID, Status: models.RejudgeStatusQueued}
+	runtime := 40
+	testCases := []*models.TestCase{
+		{ID: 1, ProblemID: 1, Input: "1", ExpectedOutput: "2"},
+		{ID: 2, ProblemID: 1, Input: "2", ExpectedOutput: "4", IsHidden: true},
+	}
+
+	// Submission 10 was accepted but fails the corrected test case; submission 11 keeps its verdict
+	submissions := map[int]*models.Submission{
+		10: {ID: 10, UserID: 1, ProblemID: 1, Language: "python", Code: "a", Status: models.StatusAccepted, TestCasesPassed: 2, TotalTestCases: 2, RuntimeMs: &runtime},
+		11: {ID: 11, UserID: 2, ProblemID: 1, Language: "python", Code: "b", Status: models.StatusWrongAnswer, TestCasesPassed: 1, TotalTestCases: 2},
+		12: {ID: 12, UserID: 3, ProblemID: 1, Language: "python", Code: "c", Status: models.StatusAccepted, TestCasesPassed: 2, TotalTestCases: 2},
+	}
+
+	mocks.submissionRepo.On("GetIDsForRejudge", 1, (*time.Time)(nil), (*time.Time)(nil)).Return([]int{10, 11, 12}, nil)
+	for id, submission := range submissions {
+		mocks.submissionRepo.On("GetByID", id).Return(submission, nil)
+		mocks.submissionRepo.On("Update", submission).Return(submission, nil)
+	}
+	mocks.testCaseRepo.On("GetByProblemID", 1).Return(testCases, nil).Once()
+
+	wrongAnswer := &execution.ExecutionResult{
+		Status:          models.StatusWrongAnswer,
+		TestCasesPassed: 1,
+		TotalTestCases:  2,
+		RuntimeMs:       35,
+		TestResults: []execution.TestResult{
+			{Input: "1", ExpectedOutput: "2", ActualOutput: "2", Passed: true, Status: models.StatusAccepted},
+			{Input: "2", ExpectedOutput: "4", ActualOutput: "5", Passed: false, Status: models.StatusWrongAnswer},
+		},
+	}
+	mocks.executionService.On("ExecuteCode", "a", "python", mock.AnythingOfType("[]models.TestCase"), mock.AnythingOfType("*models.JudgeSettings")).Return(wrongAnswer, nil)
+	mocks.executionService.On("ExecuteCode", "b", "python", mock.AnythingOfType("[]models.TestCase"), mock.AnythingOfType("*models.JudgeSettings")).Return(wrongAnswer, nil)
+	mocks.executionService.On("ExecuteCode", "c", "python", mock.AnythingOfType("[]models.TestCase"), mock.AnythingOfType("*models.JudgeSettings")).Return(nil, errors.New("sandbox unavailable"))
+
+	mocks.testResultRepo.On("DeleteBySubmissionID", mock.AnythingOfType("int")).Return(nil)
+	mocks.testResultRepo.On("CreateBatch", mock.AnythingOfType("[]*models.SubmissionTestResult")).Return(nil)
+	mocks.rejudgeRepo.On("CreateResult", mock.AnythingOfType("*models.RejudgeResult")).Return(&models.RejudgeResult{}, nil)
+	mocks.rejudgeRepo.On("GetResults", 5, false).Return([]*models.RejudgeResult(nil), nil)
+	mocks.rejudgeRepo.On("UpdateJob", job).Return(job, nil)
+	mocks.userProgressRepo.On("Recompute", 1, 1).Return(&models.UserProgress{UserID: 1, ProblemID: 1}, nil).Once()
+	mocks.userProgressRepo.On("Recompute", 2, 1).Return(&models.UserProgress{UserID: 2, ProblemID: 1}, nil).Once()
+	mocks.percentiles.On("RebuildProblem", 1).Return(nil).Once()
+
+	err := service.processJob(job)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.RejudgeStatusCompleted, job.Status)
+	assert.Equal(t, 3, job.Total)
+	assert.Equal(t, 3, job.Processed)
+	assert.Equal(t, 1, job.Changed)
+	assert.Equal(t, 1, job.Failed)
+	assert.NotNil(t, job.StartedAt)
+	assert.NotNil(t, job.FinishedAt)
+
+	mocks.rejudgeRepo.AssertCalled(t, "CreateResult", mock.MatchedBy(func(r *models.RejudgeResult) bool {
+		return r.SubmissionID == 10 && r.JobID == 5 && r.VerdictChanged &&
+			r.OldStatus == models.StatusAccepted && r.NewStatus == models.StatusWrongAnswer &&
+			*r.OldRuntimeMs == 40 && *r.NewRuntimeMs == 35
+	}))
+	mocks.rejudgeRepo.AssertCalled(t, "CreateResult", mock.MatchedBy(func(r *models.RejudgeResult) bool {
+		return r.SubmissionID == 11 && !r.VerdictChanged
+	}))
+	mocks.testResultRepo.AssertCalled(t, "DeleteBySubmissionID", 10)
+	mocks.userProgressRepo.AssertNotCalled(t, "Recompute", 3, 1)
+	mocks.testCaseRepo.AssertExpectations(t)
+	mocks.userProgressRepo.AssertExpectations(t)
+	mocks.percentiles.AssertExpectations(t)
+}
+
+func TestRejudgeService_processJob_FailsWhenSubmissionsCannotBeListed(t *testing.T) {
+	service, mocks := newRejudgeTestService()
+
+	job := &models.RejudgeJob{ID: 6, Scope: models.RejudgeScopeRange, Status: models.RejudgeStatusQueued}
+	mocks.submissionRepo.On("GetIDsForRejudge", 0, (*time.Time)(nil), (*time.Time)(nil)).Return(nil, errors.New("connection refused"))
+	mocks.rejudgeRepo.On("UpdateJob", job).Return(job, nil)
+
+	err := service.processJob(job)
+
+	assert.Error(t, err)
+	assert.Equal(t, models.RejudgeStatusFailed, job.Status)
+	assert.NotNil(t, job.ErrorMessage)
+	assert.Contains(t, *job.ErrorMessage, "connection refused")
+	mocks.executionService.AssertNotCalled(t, "ExecuteCode", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRejudgeService_processJob_ResumesInterruptedJob(t *testing.T) {
+	service, mocks := newRejudgeTestService()
+
+	problemID := 1
+	started := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	job := &models.RejudgeJob{ID: 7, Scope: models.RejudgeScopeProblem, ProblemID: &problemID, Status: models.RejudgeStatusRunning, StartedAt: &started, Processed: 1, Changed: 1}
+	testCases := []*models.TestCase{{ID: 1, ProblemID: 1, Input: "1", ExpectedOutput: "2"}}
+
+	// Submission 10 was rejudged from Accepted to Wrong Answer before the restart
+	submissions := map[int]*models.Submission{
+		10: {ID: 10, UserID: 1, ProblemID: 1, Language: "python", Code: "a", Status: models.StatusWrongAnswer, TestCasesPassed: 0, TotalTestCases: 1},
+		11: {ID: 11, UserID: 2, ProblemID: 1, Language: "python", Code: "b", Status: models.StatusAccepted, TestCasesPassed: 1, TotalTestCases: 1},
+	}
+	mocks.submissionRepo.On("GetIDsForRejudge", 1, (*time.Time)(nil), (*time.Time)(nil)).Return([]int{10, 11}, nil)
+	for id, submission := range submissions {
+		mocks.submissionRepo.On("GetByID", id).Return(submission, nil)
+		mocks.submissionRepo.On("Update", submission).Return(submission, nil)
+	}
+	mocks.testCaseRepo.On("GetByProblemID", 1).Return(testCases, nil).Once()
+	mocks.rejudgeRepo.On("GetResults", 7, false).Return([]*models.RejudgeResult{
+		{JobID: 7, SubmissionID: 10, OldStatus: models.StatusAccepted, NewStatus: models.StatusWrongAnswer, VerdictChanged: true},
+	}, nil)
+
+	accepted := &execution.ExecutionResult{
+		Status:          models.StatusAccepted,
+		TestCasesPassed: 1,
+		TotalTestCases:  1,
+		TestResults:     []execution.TestResult{{Input: "1", ExpectedOutput: "2", ActualOutput: "2", Passed: true, Status: models.StatusAccepted}},
+	}
+	mocks.executionService.On("ExecuteCode", "b", "python", mock.AnythingOfType("[]models.TestCase"), mock.AnythingOfType("*models.JudgeSettings")).Return(accepted, nil)
+	mocks.testResultRepo.On("DeleteBySubmissionID", 11).Return(nil)
+	mocks.testResultRepo.On("CreateBatch", mock.AnythingOfType("[]*models.SubmissionTestResult")).Return(nil)
+	mocks.rejudgeRepo.On("CreateResult", mock.AnythingOfType("*models.RejudgeResult")).Return(&models.RejudgeResult{}, nil)
+	mocks.rejudgeRepo.On("UpdateJob", job).Return(job, nil)
+	mocks.userProgressRepo.On("Recompute", 1, 1).Return(&models.UserProgress{UserID: 1, ProblemID: 1}, nil).Once()
+	mocks.userProgressRepo.On("Recompute", 2, 1).Return(&models.UserProgress{UserID: 2, ProblemID: 1}, nil).Once()
+	mocks.percentiles.On("RebuildProblem", 1).Return(nil).Once()
+
+	err := service.processJob(job)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.RejudgeStatusCompleted, job.Status)
+	assert.Equal(t, 2, job.Processed)
+	assert.Equal(t, 1, job.Changed)
+	assert.Equal(t, started, *job.StartedAt)
+	mocks.executionService.AssertNotCalled(t, "ExecuteCode", "a", mock.Anything, mock.Anything, mock.Anything)
+	mocks.rejudgeRepo.AssertNumberOfCalls(t, "CreateResult", 1)
+	mocks.rejudgeRepo.AssertCalled(t, "CreateResult", mock.MatchedBy(func(r *models.RejudgeResult) bool {
+		return r.SubmissionID == 11 && !r.VerdictChanged
+	}))
+	mocks.userProgressRepo.AssertExpectations(t)
+}
+
+func TestRejudgeService_processJob_StopsWhenClaimedByAnotherWorker(t *testing.T) {
+	service, mocks := newRejudgeTestService()
+
+	problemID := 1
+	job := &models.RejudgeJob{ID: 8, Scope: models.RejudgeScopeProblem, ProblemID: &problemID, Status: models.RejudgeStatusRunning, ClaimToken: "stale"}
+	testCases := []*models.TestCase{{ID: 1, ProblemID: 1, Input: "1", ExpectedOutput: "2"}}
+	submission := &models.Submission{ID: 10, UserID: 1, ProblemID: 1, Language: "python", Code: "a", Status: models.StatusAccepted, TestCasesPassed: 1, TotalTestCases: 1}
+
+	mocks.submissionRepo.On("GetIDsForRejudge", 1, (*time.Time)(nil), (*time.Time)(nil)).Return([]int{10, 11}, nil)
+	mocks.submissionRepo.On("GetByID", 10).Return(submission, nil)
+	mocks.submissionRepo.On("Update", submission).Return(submission, nil)
+	mocks.testCaseRepo.On("GetByProblemID", 1).Return(testCases, nil)
+	mocks.rejudgeRepo.On("GetResults", 8, false).Return([]*models.RejudgeResult(nil), nil)
+	mocks.rejudgeRepo.On("UpdateJob", job).Return(job, nil).Once()
+
+	accepted := &execution.ExecutionResult{
+		Status:          models.StatusAccepted,
+		TestCasesPassed: 1,
+		TotalTestCases:  1,
+		TestResults:     []execution.TestResult{{Input: "1", ExpectedOutput: "2", ActualOutput: "2", Passed: true, Status: models.StatusAccepted}},
+	}
+	mocks.executionService.On("ExecuteCode", "a", "python", mock.AnythingOfType("[]models.TestCase"), mock.AnythingOfType("*models.JudgeSettings")).Return(accepted, nil)
+	mocks.testResultRepo.On("DeleteBySubmissionID", 10).Return(nil)
+	mocks.testResultRepo.On("CreateBatch", mock.AnythingOfType("[]*models.SubmissionTestResult")).Return(nil)
+
+	// The worker that claimed the job again already recorded submission 10
+	mocks.rejudgeRepo.On("CreateResult", mock.AnythingOfType("*models.RejudgeResult")).Return(nil, repository.NewRepositoryError("CreateResult", repository.ErrConflict, "rejudge_result_exists"))
+
+	err := service.processJob(job)
+
+	assert.ErrorIs(t, err, errRejudgeLeaseLost)
+	assert.Equal(t, models.RejudgeStatusRunning, job.Status)
+	assert.Equal(t, 0, job.Changed)
+	mocks.submissionRepo.AssertNotCalled(t, "GetByID", 11)
+	mocks.rejudgeRepo.AssertNumberOfCalls(t, "UpdateJob", 1)
+	mocks.userProgressRepo.AssertNotCalled(t, "Recompute", mock.Anything, mock.Anything)
+}
+
+func TestRejudgeService_processPendingJobs_SkipsJobsClaimedElsewhere(t *testing.T) {
+	service, mocks := newRejudgeTestService()
+
+	claimed := &models.RejudgeJob{ID: 9, Scope: models.RejudgeScopeRange, Status: models.RejudgeStatusRunning}
+	mocks.rejudgeRepo.On("GetUnfinishedJobs").Return([]*models.RejudgeJob{
+		{ID: 8, Scope: models.RejudgeScopeRange, Status: models.RejudgeStatusRunning},
+		{ID: 9, Scope: models.RejudgeScopeRange, Status: models.RejudgeStatusQueued},
+	}, nil)
+	mocks.rejudgeRepo.On("ClaimJob", 8, rejudgeLeaseTimeout).Return(nil, repository.NewRepositoryError("ClaimJob", repository.ErrNotFound, "rejudge_job_not_claimable"))
+	mocks.rejudgeRepo.On("ClaimJob", 9, rejudgeLeaseTimeout).Return(claimed, nil)
+	mocks.submissionRepo.On("GetIDsForRejudge", 0, (*time.Time)(nil), (*time.Time)(nil)).Return([]int{}, nil)
+	mocks.rejudgeRepo.On("GetResults", 9, false).Return([]*models.RejudgeResult(nil), nil)
+	mocks.rejudgeRepo.On("UpdateJob", claimed).Return(claimed, nil)
+
+	service.processPendingJobs()
+
+	mocks.rejudgeRepo.AssertExpectations(t)
+	mocks.rejudgeRepo.AssertNumberOfCalls(t, "UpdateJob", 2)
+	assert.Equal(t, models.RejudgeStatusCompleted, claimed.Status)
+}
@@ -1,18 +1,28 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"time"
+	"unicode/utf8"
 
+	"leetcode-clone-backend/pkg/diff"
 	"leetcode-clone-backend/pkg/execution"
 	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/pagination"
 	"leetcode-clone-backend/pkg/repository"
 )
 
+// statsActivityDays is the number of days covered by the daily activity series of user stats
+const statsActivityDays = 365
+
 // SubmissionServiceInterface defines the interface for submission service
 type SubmissionServiceInterface interface {
 	ProcessSubmission(req *SubmissionRequest) (*SubmissionResponse, error)
 	GetSubmissionByID(id int) (*models.Submission, error)
+	GetSubmissionDetails(id int, includeHidden bool) (*SubmissionDetails, error)
+	DiffSubmissions(id, otherID int) (*SubmissionDiff, error)
+	ListSubmissions(filters repository.SubmissionFilters, params pagination.Params) (*SubmissionListResponse, error)
 	GetUserSubmissions(userID, page, pageSize int) (*SubmissionListResponse, error)
 	GetProblemSubmissions(problemID, page, pageSize int) (*SubmissionListResponse, error)
 	GetUserProblemSubmissions(userID, problemID, page, pageSize int) (*SubmissionListResponse, error)
@@ -21,24 +31,21 @@ type SubmissionServiceInterface interface {
 
 // SubmissionService handles business logic for code submissions
 type SubmissionService struct {
-	submissionRepo   repository.SubmissionRepository
-	testCaseRepo     repository.TestCaseRepository
-	userProgressRepo repository.UserProgressRepository
+	repo             *repository.Repository
 	executionService execution.ExecutionServiceInterface
+	percentiles      PercentileServiceInterface
 }
 
 // NewSubmissionService creates a new submission service
 func NewSubmissionService(
-	submissionRepo repository.SubmissionRepository,
-	testCaseRepo repository.TestCaseRepository,
-	userProgressRepo repository.UserProgressRepository,
+	repo *repository.Repository,
 	executionService execution.ExecutionServiceInterface,
+	percentiles PercentileServiceInterface,
 ) *SubmissionService {
 	return &SubmissionService{
-		submissionRepo:   submissionRepo,
-		testCaseRepo:     testCaseRepo,
-		userProgressRepo: userProgressRepo,
+		repo:             repo,
 		executionService: executionService,
+		percentiles:      percentiles,
 	}
 }
 
@@ -61,15 +68,52 @@ type SubmissionResponse struct {
 	ErrorMessage    *string                `json:"error_message"`
 	SubmittedAt     time.Time              `json:"submitted_at"`
 	TestResults     []execution.TestResult `json:"test_results,omitempty"`
+	Percentiles     *SubmissionPercentiles `json:"percentiles,omitempty"`
+	SandboxTime     time.Duration          `json:"-"` // Time spent running the code, charged to the execution budget
 }
 
 // SubmissionListResponse represents a paginated list of submissions
 type SubmissionListResponse struct {
 	Submissions []*models.Submission `json:"submissions"`
-	Total       int                  `json:"total"`
-	Page        int                  `json:"page"`
-	PageSize    int                  `json:"page_size"`
-	HasNext     bool                 `json:"has_next"`
+	pagination.Meta
+}
+
+// defaultSubmissionPageSize is the number of submissions listed per page unless the client asks for more
+const defaultSubmissionPageSize = 20
+
+// SubmissionDetails represents a submission together with the results of each executed test case
+type SubmissionDetails struct {
+	Submission      *models.Submission             `json:"submission"`
+	TestCaseResults []*models.SubmissionTestResult `json:"test_case_results"`
+	OverallStats    SubmissionOverallStats         `json:"overall_stats"`
+	Percentiles     *SubmissionPercentiles         `json:"percentiles,omitempty"`
+}
+
+// SubmissionDiff represents the line-based difference between the code of two submissions
+type SubmissionDiff struct {
+	From            *SubmissionDiffSide `json:"from"`
+	To              *SubmissionDiffSide `json:"to"`
+	LanguagesDiffer bool                `json:"languages_differ"`
+	Notes           []string            `json:"notes,omitempty"`
+	*diff.Result
+}
+
+// SubmissionDiffSide summarizes one of the submissions of a diff
+type SubmissionDiffSide struct {
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`
+	ProblemID   int       `json:"problem_id"`
+	Language    string    `json:"language"`
+	Status      string    `json:"status"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+// SubmissionOverallStats summarizes the performance of a submission
+type SubmissionOverallStats struct {
+	RuntimeMs       int `json:"runtime_ms"`
+	MemoryKb        int `json:"memory_kb"`
+	TestCasesPassed int `json:"test_cases_passed"`
+	TotalTestCases  int `json:"total_test_cases"`
 }
 
 // ProcessSubmission processes a code submission by executing it and storing the result
@@ -79,8 +123,17 @@ func (ss *SubmissionService) ProcessSubmission(req *SubmissionRequest) (*Submiss
 		return nil, fmt.Errorf("invalid submission request: %w", err)
 	}
 
+	// Only published problems can be judged, so hidden tests of other problems are not exposed
+	problem, err := ss.repo.Problem.GetByID(req.ProblemID)
+	if err == nil && problem.State != models.ProblemStatePublished {
+		err = repository.NewRepositoryError("GetByID", repository.ErrNotFound, "problem_not_found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve problem: %w", err)
+	}
+
 	// Get all test cases for the problem
-	testCases, err := ss.testCaseRepo.GetByProblemID(req.ProblemID)
+	testCases, err := ss.repo.TestCase.GetByProblemID(req.ProblemID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve test cases: %w", err)
 	}
@@ -89,6 +142,11 @@ func (ss *SubmissionService) ProcessSubmission(req *SubmissionRequest) (*Submiss
 		return nil, fmt.Errorf("no test cases available for problem %d", req.ProblemID)
 	}
 
+	settings, err := ss.repo.JudgeSettings.GetByProblemID(req.ProblemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve judging settings: %w", err)
+	}
+
 	// Convert []*models.TestCase to []models.TestCase for execution service
 	allTestCases := make([]models.TestCase, len(testCases))
 	for i, tc := range testCases {
@@ -96,7 +154,7 @@ func (ss *SubmissionService) ProcessSubmission(req *SubmissionRequest) (*Submiss
 	}
 
 	// Execute the code against all test cases
-	executionResult, err := ss.executionService.ExecuteCode(req.Code, req.Language, allTestCases)
+	executionResult, err := ss.executionService.ExecuteCode(req.Code, req.Language, allTestCases, settings)
 	if err != nil {
 		return nil, fmt.Errorf("code execution failed: %w", err)
 	}
@@ -125,19 +183,29 @@ func (ss *SubmissionService) ProcessSubmission(req *SubmissionRequest) (*Submiss
 		submission.ErrorMessage = &executionResult.ErrorMessage
 	}
 
-	// Store the submission
-	createdSubmission, err := ss.submissionRepo.Create(submission)
-	if err != nil {
-		return nil, fmt.Errorf("failed to store submission: %w", err)
-	}
+	// Store the submission, its per-test-case results and the user's progress in one transaction
+	var createdSubmission *models.Submission
+	err = ss.repo.WithTx(context.Background(), func(tx *repository.Repository) error {
+		created, err := tx.Submission.Create(submission)
+		if err != nil {
+			return fmt.Errorf("failed to store submission: %w", err)
+		}
+
+		// Store per-test-case results so they can be inspected after the response is sent
+		testResults := buildSubmissionTestResults(created.ID, testCases, executionResult.TestResults)
+		if err := tx.SubmissionTestResult.CreateBatch(testResults); err != nil {
+			return fmt.Errorf("failed to store test case results: %w", err)
+		}
 
-	// Update user progress if submission was accepted
-	if executionResult.Status == models.StatusAccepted {
-		if err := ss.updateUserProgress(req.UserID, req.ProblemID, createdSubmission.ID); err != nil {
-			// Log error but don't fail the submission
-			// In a production system, you might want to use a message queue for this
-			fmt.Printf("Warning: failed to update user progress: %v\n", err)
+		if err := applyToUserProgress(tx, created); err != nil {
+			return fmt.Errorf("failed to update user progress: %w", err)
 		}
+
+		createdSubmission = created
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Prepare response with filtered test results (only public test cases)
@@ -151,12 +219,27 @@ func (ss *SubmissionService) ProcessSubmission(req *SubmissionRequest) (*Submiss
 		ErrorMessage:    createdSubmission.ErrorMessage,
 		SubmittedAt:     createdSubmission.SubmittedAt,
 		TestResults:     make([]execution.TestResult, 0),
+		SandboxTime:     executionResult.SandboxTime(),
 	}
 
 	// Only include public test case results in the response
 	for i, testResult := range executionResult.TestResults {
 		if i < len(testCases) && !testCases[i].IsHidden {
 			response.TestResults = append(response.TestResults, testResult)
+		} else if !testResult.Passed && response.ErrorMessage != nil {
+			message := hiddenFailureMessage(createdSubmission.Status, i+1)
+			response.ErrorMessage = &message
+		}
+	}
+
+	// Compare accepted submissions with other accepted submissions in the same language
+	if createdSubmission.Status == models.StatusAccepted {
+		if err := ss.percentiles.RecordAccepted(createdSubmission); err != nil {
+			fmt.Printf("Warning: failed to record submission performance: %v\n", err)
+		} else if percentiles, err := ss.percentiles.GetPercentiles(createdSubmission); err != nil {
+			fmt.Printf("Warning: failed to compute submission percentiles: %v\n", err)
+		} else {
+			response.Percentiles = percentiles
 		}
 	}
 
@@ -165,171 +248,252 @@ func (ss *SubmissionService) ProcessSubmission(req *SubmissionRequest) (*Submiss
 
 // GetSubmissionByID retrieves a submission by its ID
 func (ss *SubmissionService) GetSubmissionByID(id int) (*models.Submission, error) {
-	submission, err := ss.submissionRepo.GetByID(id)
+	submission, err := ss.repo.Submission.GetByID(id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve submission: %w", err)
 	}
 	return submission, nil
 }
 
-// GetUserSubmissions retrieves submissions for a specific user with pagination
-func (ss *SubmissionService) GetUserSubmissions(userID, page, pageSize int) (*SubmissionListResponse, error) {
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20 // Default page size
+// GetSubmissionDetails retrieves a submission with its per-test-case results.
+// Content of hidden test cases is redacted unless includeHidden is set.
+func (ss *SubmissionService) GetSubmissionDetails(id int, includeHidden bool) (*SubmissionDetails, error) {
+	submission, err := ss.repo.Submission.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve submission: %w", err)
 	}
 
-	offset := (page - 1) * pageSize
-
-	submissions, err := ss.submissionRepo.GetByUserID(userID, pageSize+1, offset) // Get one extra to check if there's a next page
+	results, err := ss.repo.SubmissionTestResult.GetBySubmissionID(id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve user submissions: %w", err)
+		return nil, fmt.Errorf("failed to retrieve test case results: %w", err)
+	}
+	if results == nil {
+		results = []*models.SubmissionTestResult{}
+	}
+
+	if !includeHidden {
+		for _, result := range results {
+			if result.IsHidden {
+				// The error message of a failed submission quotes the outputs of the failing test
+				if !result.Passed && submission.ErrorMessage != nil {
+					redacted := *submission
+					message := hiddenFailureMessage(result.Status, result.Position)
+					redacted.ErrorMessage = &message
+					submission = &redacted
+				}
+				result.Input = ""
+				result.ExpectedOutput = ""
+				result.ActualOutput = ""
+				result.OutputTruncated = false
+				result.Redacted = true
+			}
+		}
 	}
 
-	hasNext := len(submissions) > pageSize
-	if hasNext {
-		submissions = submissions[:pageSize] // Remove the extra submission
+	details := &SubmissionDetails{
+		Submission:      submission,
+		TestCaseResults: results,
+		OverallStats: SubmissionOverallStats{
+			TestCasesPassed: submission.TestCasesPassed,
+			TotalTestCases:  submission.TotalTestCases,
+		},
+	}
+	if submission.RuntimeMs != nil {
+		details.OverallStats.RuntimeMs = *submission.RuntimeMs
+	}
+	if submission.MemoryKb != nil {
+		details.OverallStats.MemoryKb = *submission.MemoryKb
 	}
 
-	return &SubmissionListResponse{
-		Submissions: submissions,
-		Total:       len(submissions), // Note: This is not the total count, just current page count
-		Page:        page,
-		PageSize:    pageSize,
-		HasNext:     hasNext,
-	}, nil
+	if submission.Status == models.StatusAccepted {
+		percentiles, err := ss.percentiles.GetPercentiles(submission)
+		if err != nil {
+			fmt.Printf("Warning: failed to compute submission percentiles: %v\n", err)
+		} else {
+			details.Percentiles = percentiles
+		}
+	}
+
+	return details, nil
 }
 
-// GetProblemSubmissions retrieves submissions for a specific problem with pagination
-func (ss *SubmissionService) GetProblemSubmissions(problemID, page, pageSize int) (*SubmissionListResponse, error) {
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20 // Default page size
+// DiffSubmissions computes the difference from the code of one submission to the code of another.
+// Submissions in different languages or for different problems can be compared; the result notes
+// that they differ.
+func (ss *SubmissionService) DiffSubmissions(id, otherID int) (*SubmissionDiff, error) {
+	from, err := ss.repo.Submission.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve submission %d: %w", id, err)
 	}
 
-	offset := (page - 1) * pageSize
-
-	submissions, err := ss.submissionRepo.GetByProblemID(problemID, pageSize+1, offset)
+	to, err := ss.repo.Submission.GetByID(otherID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve problem submissions: %w", err)
+		return nil, fmt.Errorf("failed to retrieve submission %d: %w", otherID, err)
 	}
 
-	hasNext := len(submissions) > pageSize
-	if hasNext {
-		submissions = submissions[:pageSize]
+	result := &SubmissionDiff{
+		From:            newSubmissionDiffSide(from),
+		To:              newSubmissionDiffSide(to),
+		LanguagesDiffer: from.Language != to.Language,
+		Result: diff.Compute(
+			fmt.Sprintf("submission/%d (%s)", from.ID, from.Language),
+			fmt.Sprintf("submission/%d (%s)", to.ID, to.Language),
+			from.Code,
+			to.Code,
+			diff.DefaultContext,
+		),
 	}
 
-	return &SubmissionListResponse{
-		Submissions: submissions,
-		Total:       len(submissions),
-		Page:        page,
-		PageSize:    pageSize,
-		HasNext:     hasNext,
-	}, nil
+	if result.LanguagesDiffer {
+		result.Notes = append(result.Notes, fmt.Sprintf("The submissions are written in different languages (%s and %s)", from.Language, to.Language))
+	}
+	if from.ProblemID != to.ProblemID {
+		result.Notes = append(result.Notes, fmt.Sprintf("The submissions are for different problems (%d and %d)", from.ProblemID, to.ProblemID))
+	}
+
+	return result, nil
 }
 
-// GetUserProblemSubmissions retrieves submissions for a specific user and problem with pagination
-func (ss *SubmissionService) GetUserProblemSubmissions(userID, problemID, page, pageSize int) (*SubmissionListResponse, error) {
-	if page < 1 {
-		page = 1
+// newSubmissionDiffSide summarizes a submission for a diff
+func newSubmissionDiffSide(submission *models.Submission) *SubmissionDiffSide {
+	return &SubmissionDiffSide{
+		ID:          submission.ID,
+		UserID:      submission.UserID,
+		ProblemID:   submission.ProblemID,
+		Language:    submission.Language,
+		Status:      submission.Status,
+		SubmittedAt: submission.SubmittedAt,
 	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20 // Default page size
+}
+
+// ListSubmissions retrieves a page of submissions matching the filters, newest first.
+// The limit and offset or cursor of the filters are derived from params.
+func (ss *SubmissionService) ListSubmissions(filters repository.SubmissionFilters, params pagination.Params) (*SubmissionListResponse, error) {
+	if params.Limit < 1 || params.Limit > pagination.MaxLimit {
+		params.Limit = defaultSubmissionPageSize
+	}
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Offset <= 0 {
+		params.Offset = (params.Page - 1) * params.Limit
+	}
+
+	if err := ss.validateSubmissionFilters(&filters); err != nil {
+		return nil, fmt.Errorf("invalid filters: %w", err)
+	}
+	if params.Cursor != nil {
+		if err := params.Cursor.Check(repository.SubmissionSort); err != nil {
+			return nil, fmt.Errorf("invalid filters: %w", err)
+		}
+		if _, err := params.Cursor.Time(); err != nil {
+			return nil, fmt.Errorf("invalid filters: %w", err)
+		}
 	}
 
-	offset := (page - 1) * pageSize
+	// Fetch one extra submission to know whether there is a next page
+	filters.Limit = params.Limit + 1
+	filters.Offset = params.Offset
+	filters.Cursor = params.Cursor
 
-	submissions, err := ss.submissionRepo.GetByUserAndProblem(userID, problemID, pageSize+1, offset)
+	submissions, err := ss.repo.Submission.List(filters)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve user problem submissions: %w", err)
+		return nil, fmt.Errorf("failed to retrieve submissions: %w", err)
 	}
 
-	hasNext := len(submissions) > pageSize
+	total, err := ss.repo.Submission.Count(filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count submissions: %w", err)
+	}
+
+	hasNext := len(submissions) > params.Limit
 	if hasNext {
-		submissions = submissions[:pageSize]
+		submissions = submissions[:params.Limit]
+	}
+	if submissions == nil {
+		submissions = []*models.Submission{}
+	}
+
+	var next *pagination.Cursor
+	if len(submissions) > 0 {
+		next = repository.NewSubmissionCursor(submissions[len(submissions)-1])
 	}
 
 	return &SubmissionListResponse{
 		Submissions: submissions,
-		Total:       len(submissions),
-		Page:        page,
-		PageSize:    pageSize,
-		HasNext:     hasNext,
+		Meta:        pagination.NewMeta(params, total, hasNext, next),
 	}, nil
 }
 
-// GetUserSubmissionStats calculates submission statistics for a user
+// GetUserSubmissions retrieves submissions for a specific user with pagination
+func (ss *SubmissionService) GetUserSubmissions(userID, page, pageSize int) (*SubmissionListResponse, error) {
+	return ss.ListSubmissions(repository.SubmissionFilters{UserID: userID}, pagination.Params{Page: page, Limit: pageSize})
+}
+
+// GetProblemSubmissions retrieves submissions for a specific problem with pagination
+func (ss *SubmissionService) GetProblemSubmissions(problemID, page, pageSize int) (*SubmissionListResponse, error) {
+	return ss.ListSubmissions(repository.SubmissionFilters{ProblemID: problemID}, pagination.Params{Page: page, Limit: pageSize})
+}
+
+// GetUserProblemSubmissions retrieves submissions for a specific user and problem with pagination
+func (ss *SubmissionService) GetUserProblemSubmissions(userID, problemID, page, pageSize int) (*SubmissionListResponse, error) {
+	return ss.ListSubmissions(repository.SubmissionFilters{UserID: userID, ProblemID: problemID}, pagination.Params{Page: page, Limit: pageSize})
+}
+
+// GetUserSubmissionStats calculates submission statistics for a user.
+// Aggregation happens in the database, so the result covers all of the user's submissions.
 func (ss *SubmissionService) GetUserSubmissionStats(userID int) (map[string]interface{}, error) {
-	// Get all user submissions (we'll need to implement a count method in repository for efficiency)
-	submissions, err := ss.submissionRepo.GetByUserID(userID, 1000, 0) // Get a large number for stats
+	aggregate, err := ss.repo.Submission.GetStats(userID, statsActivityDays)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve user submissions for stats: %w", err)
+		return nil, fmt.Errorf("failed to aggregate user submission stats: %w", err)
 	}
 
-	stats := map[string]interface{}{
-		"total_submissions":     len(submissions),
-		"accepted":              0,
-		"wrong_answer":          0,
-		"time_limit_exceeded":   0,
-		"memory_limit_exceeded": 0,
-		"runtime_error":         0,
-		"compile_error":         0,
-		"acceptance_rate":       0.0,
-		"avg_runtime_ms":        0,
-		"avg_memory_kb":         0,
-	}
-
-	if len(submissions) == 0 {
-		return stats, nil
-	}
-
-	totalRuntime := 0
-	totalMemory := 0
-	runtimeCount := 0
-	memoryCount := 0
-
-	for _, submission := range submissions {
-		switch submission.Status {
-		case models.StatusAccepted:
-			stats["accepted"] = stats["accepted"].(int) + 1
-		case models.StatusWrongAnswer:
-			stats["wrong_answer"] = stats["wrong_answer"].(int) + 1
-		case models.StatusTimeLimitExceeded:
-			stats["time_limit_exceeded"] = stats["time_limit_exceeded"].(int) + 1
-		case models.StatusMemoryLimitExceeded:
-			stats["memory_limit_exceeded"] = stats["memory_limit_exceeded"].(int) + 1
-		case models.StatusRuntimeError:
-			stats["runtime_error"] = stats["runtime_error"].(int) + 1
-		case models.StatusCompileError:
-			stats["compile_error"] = stats["compile_error"].(int) + 1
-		}
+	solvedByDifficulty, err := ss.repo.UserProgress.GetSolvedCountByDifficulty(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve solved counts: %w", err)
+	}
 
-		if submission.RuntimeMs != nil {
-			totalRuntime += *submission.RuntimeMs
-			runtimeCount++
-		}
-		if submission.MemoryKb != nil {
-			totalMemory += *submission.MemoryKb
-			memoryCount++
-		}
+	statusStats := aggregate.StatusCounts
+	if statusStats == nil {
+		statusStats = map[string]int{}
+	}
+	languageStats := aggregate.LanguageCounts
+	if languageStats == nil {
+		languageStats = map[string]int{}
+	}
+	dailyActivity := aggregate.DailyActivity
+	if dailyActivity == nil {
+		dailyActivity = []models.DailySubmissionActivity{}
 	}
 
-	// Calculate acceptance rate
-	acceptedCount := stats["accepted"].(int)
-	if len(submissions) > 0 {
-		stats["acceptance_rate"] = float64(acceptedCount) / float64(len(submissions)) * 100
+	totalSolved := 0
+	for _, count := range solvedByDifficulty {
+		totalSolved += count
 	}
 
-	// Calculate average performance metrics
-	if runtimeCount > 0 {
-		stats["avg_runtime_ms"] = totalRuntime / runtimeCount
+	stats := map[string]interface{}{
+		"total_submissions":     aggregate.TotalSubmissions,
+		"accepted":              statusStats[models.StatusAccepted],
+		"wrong_answer":          statusStats[models.StatusWrongAnswer],
+		"time_limit_exceeded":   statusStats[models.StatusTimeLimitExceeded],
+		"memory_limit_exceeded": statusStats[models.StatusMemoryLimitExceeded],
+		"runtime_error":         statusStats[models.StatusRuntimeError],
+		"compile_error":         statusStats[models.StatusCompileError],
+		"acceptance_rate":       0.0,
+		"avg_runtime_ms":        int(aggregate.AvgRuntimeMs),
+		"avg_memory_kb":         int(aggregate.AvgMemoryKb),
+		"status_stats":          statusStats,
+		"language_stats":        languageStats,
+		"solved_by_difficulty":  solvedByDifficulty,
+		"total_solved":          totalSolved,
+		"runtime_percentiles":   aggregate.RuntimePercentiles,
+		"memory_percentiles":    aggregate.MemoryPercentiles,
+		"daily_activity":        dailyActivity,
 	}
-	if memoryCount > 0 {
-		stats["avg_memory_kb"] = totalMemory / memoryCount
+
+	// Calculate acceptance rate
+	if aggregate.TotalSubmissions > 0 {
+		stats["acceptance_rate"] = float64(statusStats[models.StatusAccepted]) / float64(aggregate.TotalSubmissions) * 100
 	}
 
 	return stats, nil
@@ -372,44 +536,123 @@ func (ss *SubmissionService) validateSubmissionRequest(req *SubmissionRequest) e
 	return nil
 }
 
-// updateUserProgress updates the user's progress for a problem when they get an accepted submission
-func (ss *SubmissionService) updateUserProgress(userID, problemID, submissionID int) error {
-	// Get existing progress
-	progress, err := ss.userProgressRepo.GetByUserAndProblem(userID, problemID)
-	if err != nil {
-		// If no progress exists, create new one
-		if repository.IsNotFound(err) {
-			newProgress := &models.UserProgress{
-				UserID:           userID,
-				ProblemID:        problemID,
-				IsSolved:         true,
-				BestSubmissionID: &submissionID,
-				Attempts:         1,
-				FirstSolvedAt:    &time.Time{},
+// validateSubmissionFilters validates submission list filters
+func (ss *SubmissionService) validateSubmissionFilters(filters *repository.SubmissionFilters) error {
+	if filters.Status != "" {
+		validStatuses := []string{
+			models.StatusAccepted,
+			models.StatusWrongAnswer,
+			models.StatusTimeLimitExceeded,
+			models.StatusMemoryLimitExceeded,
+			models.StatusRuntimeError,
+			models.StatusCompileError,
+			models.StatusInternalError,
+		}
+		valid := false
+		for _, status := range validStatuses {
+			if filters.Status == status {
+				valid = true
+				break
 			}
-			now := time.Now()
-			newProgress.FirstSolvedAt = &now
+		}
+		if !valid {
+			return fmt.Errorf("invalid status: %s", filters.Status)
+		}
+	}
 
-			_, err := ss.userProgressRepo.Create(newProgress)
-			return err
+	if filters.Language != "" {
+		switch filters.Language {
+		case models.LanguageJavaScript, models.LanguagePython, models.LanguageJava:
+		default:
+			return fmt.Errorf("unsupported language: %s", filters.Language)
 		}
+	}
+
+	if filters.From != nil && filters.To != nil && !filters.From.Before(*filters.To) {
+		return fmt.Errorf("from must be before to")
+	}
+
+	return nil
+}
+
+// hiddenFailureMessage replaces the error message of a submission that failed on a hidden test
+// case, which quotes the expected and actual output of the test
+func hiddenFailureMessage(status string, position int) string {
+	return fmt.Sprintf("%s on hidden test case %d", status, position)
+}
+
+// buildSubmissionTestResults converts execution test results into storable per-test-case results.
+// Execution stops at the first failing test case, so only the test cases that actually ran are included.
+func buildSubmissionTestResults(submissionID int, testCases []*models.TestCase, testResults []execution.TestResult) []*models.SubmissionTestResult {
+	results := make([]*models.SubmissionTestResult, 0, len(testResults))
+	for i, testResult := range testResults {
+		runtimeMs := testResult.RuntimeMs
+		memoryKb := testResult.MemoryKb
+
+		input, inputTruncated := truncateOutput(testResult.Input)
+		expectedOutput, expectedTruncated := truncateOutput(testResult.ExpectedOutput)
+		actualOutput, actualTruncated := truncateOutput(testResult.ActualOutput)
+
+		result := &models.SubmissionTestResult{
+			SubmissionID:    submissionID,
+			Position:        i + 1,
+			Status:          testResult.Status,
+			Passed:          testResult.Passed,
+			RuntimeMs:       &runtimeMs,
+			MemoryKb:        &memoryKb,
+			Input:           input,
+			ExpectedOutput:  expectedOutput,
+			ActualOutput:    actualOutput,
+			OutputTruncated: inputTruncated || expectedTruncated || actualTruncated,
+		}
+		if result.Status == "" {
+			result.Status = models.StatusWrongAnswer
+			if result.Passed {
+				result.Status = models.StatusAccepted
+			}
+		}
+		if i < len(testCases) {
+			testCaseID := testCases[i].ID
+			result.TestCaseID = &testCaseID
+			result.IsHidden = testCases[i].IsHidden
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// truncateOutput limits stored test data to models.MaxStoredOutputLength bytes without splitting a UTF-8 character
+func truncateOutput(output string) (string, bool) {
+	if len(output) <= models.MaxStoredOutputLength {
+		return output, false
+	}
+
+	cut := models.MaxStoredOutputLength
+	for cut > 0 && !utf8.RuneStart(output[cut]) {
+		cut--
+	}
+	return output[:cut], true
+}
+
+// applyToUserProgress counts a newly stored submission towards the user's progress on the problem.
+// The progress record is locked while it is updated, so concurrent submissions by the same user
+// are applied one after another when tx is a transaction.
+func applyToUserProgress(tx *repository.Repository, submission *models.Submission) error {
+	progress, err := tx.UserProgress.GetForUpdate(submission.UserID, submission.ProblemID)
+	if err != nil {
 		return err
 	}
 
-	// Update existing progress
-	progress.Attempts++
-	if !progress.IsSolved {
-		progress.IsSolved = true
-		progress.BestSubmissionID = &submissionID
-		now := time.Now()
-		progress.FirstSolvedAt = &now
-	} else {
-		// If already solved, update best submission if this one is better
-		// For now, we'll just update to the latest accepted submission
-		// In a more sophisticated system, you'd compare performance metrics
-		progress.BestSubmissionID = &submissionID
+	var currentBest *models.Submission
+	if progress.BestSubmissionID != nil {
+		currentBest, err = tx.Submission.GetByID(*progress.BestSubmissionID)
+		if err != nil && !repository.IsNotFound(err) {
+			return err
+		}
 	}
 
-	_, err = ss.userProgressRepo.Update(progress)
+	progress.ApplySubmission(submission, currentBest)
+
+	_, err = tx.UserProgress.Update(progress)
 	return err
 }
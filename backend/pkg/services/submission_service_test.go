@@ -1,11 +1,14 @@
 package services
 
 import (
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"leetcode-clone-backend/pkg/execution"
 	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/pagination"
 	"leetcode-clone-backend/pkg/repository"
 
 	"github.com/stretchr/testify/assert"
@@ -78,6 +81,35 @@ func (m *MockSubmissionRepository) GetLatestByUserAndProblem(userID, problemID i
 	return args.Get(0).(*models.Submission), args.Error(1)
 }
 
+func (m *MockSubmissionRepository) GetIDsForRejudge(problemID int, from, to *time.Time) ([]int, error) {
+	args := m.Called(problemID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *MockSubmissionRepository) List(filters repository.SubmissionFilters) ([]*models.Submission, error) {
+	args := m.Called(filters)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Submission), args.Error(1)
+}
+
+func (m *MockSubmissionRepository) GetStats(userID int, activityDays int) (*models.SubmissionStats, error) {
+	args := m.Called(userID, activityDays)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SubmissionStats), args.Error(1)
+}
+
+func (m *MockSubmissionRepository) Count(filters repository.SubmissionFilters) (int, error) {
+	args := m.Called(filters)
+	return args.Int(0), args.Error(1)
+}
+
 type MockTestCaseRepository struct {
 	mock.Mock
 }
@@ -114,6 +146,30 @@ func (m *MockTestCaseRepository) GetPublicByProblemID(problemID int) ([]*models.
 	return args.Get(0).([]*models.TestCase), args.Error(1)
 }
 
+func (m *MockTestCaseRepository) GetInlineByProblemID(problemID int) ([]*models.TestCase, error) {
+	args := m.Called(problemID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.TestCase), args.Error(1)
+}
+
+func (m *MockTestCaseRepository) ListByProblemID(problemID int) ([]*models.TestCase, error) {
+	args := m.Called(problemID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.TestCase), args.Error(1)
+}
+
+func (m *MockTestCaseRepository) ListPublicByProblemID(problemID int) ([]*models.TestCase, error) {
+	args := m.Called(problemID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.TestCase), args.Error(1)
+}
+
 func (m *MockTestCaseRepository) Update(testCase *models.TestCase) (*models.TestCase, error) {
 	args := m.Called(testCase)
 	if args.Get(0) == nil {
@@ -122,6 +178,16 @@ func (m *MockTestCaseRepository) Update(testCase *models.TestCase) (*models.Test
 	return args.Get(0).(*models.TestCase), args.Error(1)
 }
 
+func (m *MockTestCaseRepository) SetOutputStatus(id int, status string) error {
+	args := m.Called(id, status)
+	return args.Error(0)
+}
+
+func (m *MockTestCaseRepository) ResetOutputStatus(problemID int) error {
+	args := m.Called(problemID)
+	return args.Error(0)
+}
+
 func (m *MockTestCaseRepository) Delete(id int) error {
 	args := m.Called(id)
 	return args.Error(0)
@@ -152,6 +218,14 @@ func (m *MockUserProgressRepository) GetByUserAndProblem(userID, problemID int)
 	return args.Get(0).(*models.UserProgress), args.Error(1)
 }
 
+func (m *MockUserProgressRepository) GetForUpdate(userID, problemID int) (*models.UserProgress, error) {
+	args := m.Called(userID, problemID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserProgress), args.Error(1)
+}
+
 func (m *MockUserProgressRepository) GetByUserID(userID int) ([]*models.UserProgress, error) {
 	args := m.Called(userID)
 	if args.Get(0) == nil {
@@ -186,31 +260,103 @@ func (m *MockUserProgressRepository) GetSolvedCountByDifficulty(userID int) (map
 	return args.Get(0).(map[string]int), args.Error(1)
 }
 
+func (m *MockUserProgressRepository) Recompute(userID, problemID int) (*models.UserProgress, error) {
+	args := m.Called(userID, problemID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserProgress), args.Error(1)
+}
+
+type MockSubmissionTestResultRepository struct {
+	mock.Mock
+}
+
+func (m *MockSubmissionTestResultRepository) CreateBatch(results []*models.SubmissionTestResult) error {
+	args := m.Called(results)
+	return args.Error(0)
+}
+
+func (m *MockSubmissionTestResultRepository) GetBySubmissionID(submissionID int) ([]*models.SubmissionTestResult, error) {
+	args := m.Called(submissionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.SubmissionTestResult), args.Error(1)
+}
+
+func (m *MockSubmissionTestResultRepository) DeleteBySubmissionID(submissionID int) error {
+	args := m.Called(submissionID)
+	return args.Error(0)
+}
+
 type MockExecutionService struct {
 	mock.Mock
 }
 
-func (m *MockExecutionService) ExecuteCode(code, language string, testCases []models.TestCase) (*execution.ExecutionResult, error) {
-	args := m.Called(code, language, testCases)
+func (m *MockExecutionService) ExecuteCode(code, language string, testCases []models.TestCase, settings *models.JudgeSettings) (*execution.ExecutionResult, error) {
+	args := m.Called(code, language, testCases, settings)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*execution.ExecutionResult), args.Error(1)
 }
 
+func (m *MockExecutionService) RunCode(code, language string, inputs []string, settings *models.JudgeSettings) ([]execution.RunResult, error) {
+	args := m.Called(code, language, inputs, settings)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]execution.RunResult), args.Error(1)
+}
+
 func (m *MockExecutionService) ValidateCode(code, language string) error {
 	args := m.Called(code, language)
 	return args.Error(0)
 }
 
+type MockPercentileService struct {
+	mock.Mock
+}
+
+func (m *MockPercentileService) RecordAccepted(submission *models.Submission) error {
+	args := m.Called(submission)
+	return args.Error(0)
+}
+
+func (m *MockPercentileService) GetPercentiles(submission *models.Submission) (*SubmissionPercentiles, error) {
+	args := m.Called(submission)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*SubmissionPercentiles), args.Error(1)
+}
+
+func (m *MockPercentileService) RebuildProblem(problemID int) error {
+	args := m.Called(problemID)
+	return args.Error(0)
+}
+
+// newPublishedProblemRepository returns a problem repository holding published problems with the
+// given IDs
+func newPublishedProblemRepository(ids ...int) *mockProblemRepository {
+	problems := newMockProblemRepository()
+	for _, id := range ids {
+		problems.problems[id] = &models.Problem{ID: id, State: models.ProblemStatePublished}
+	}
+	return problems
+}
+
 func TestSubmissionService_ProcessSubmission(t *testing.T) {
 	// Setup mocks
 	mockSubmissionRepo := new(MockSubmissionRepository)
 	mockTestCaseRepo := new(MockTestCaseRepository)
 	mockUserProgressRepo := new(MockUserProgressRepository)
+	mockTestResultRepo := new(MockSubmissionTestResultRepository)
 	mockExecutionService := new(MockExecutionService)
+	mockPercentileService := new(MockPercentileService)
 
-	service := NewSubmissionService(mockSubmissionRepo, mockTestCaseRepo, mockUserProgressRepo, mockExecutionService)
+	service := NewSubmissionService(&repository.Repository{Submission: mockSubmissionRepo, TestCase: mockTestCaseRepo, UserProgress: mockUserProgressRepo, SubmissionTestResult: mockTestResultRepo, JudgeSettings: newMockJudgeSettingsRepository(), Problem: newPublishedProblemRepository(1)}, mockExecutionService, mockPercentileService)
 
 	t.Run("successful submission", func(t *testing.T) {
 		// Setup test data
@@ -265,10 +411,20 @@ func TestSubmissionService_ProcessSubmission(t *testing.T) {
 
 		// Setup expectations
 		mockTestCaseRepo.On("GetByProblemID", 1).Return(testCases, nil)
-		mockExecutionService.On("ExecuteCode", req.Code, req.Language, mock.AnythingOfType("[]models.TestCase")).Return(executionResult, nil)
+		mockExecutionService.On("ExecuteCode", req.Code, req.Language, mock.AnythingOfType("[]models.TestCase"), mock.AnythingOfType("*models.JudgeSettings")).Return(executionResult, nil)
 		mockSubmissionRepo.On("Create", mock.AnythingOfType("*models.Submission")).Return(createdSubmission, nil)
-		mockUserProgressRepo.On("GetByUserAndProblem", 1, 1).Return(nil, repository.NewRepositoryError("GetByUserAndProblem", repository.ErrNotFound, "not_found"))
-		mockUserProgressRepo.On("Create", mock.AnythingOfType("*models.UserProgress")).Return(&models.UserProgress{}, nil)
+		mockUserProgressRepo.On("GetForUpdate", 1, 1).Return(&models.UserProgress{UserID: 1, ProblemID: 1, Status: models.ProgressStatusUnsolved}, nil)
+		mockUserProgressRepo.On("Update", mock.MatchedBy(func(progress *models.UserProgress) bool {
+			return progress.IsSolved && progress.Attempts == 1 && *progress.BestSubmissionID == 1 && progress.Status == models.ProgressStatusSolved
+		})).Return(&models.UserProgress{}, nil)
+		mockTestResultRepo.On("CreateBatch", mock.MatchedBy(func(results []*models.SubmissionTestResult) bool {
+			return len(results) == 1 && results[0].SubmissionID == 1 && results[0].Status == models.StatusAccepted
+		})).Return(nil)
+		percentiles := &SubmissionPercentiles{
+			Runtime: &MetricPercentile{Value: 100, Beats: 75, Total: 5, BucketSize: models.RuntimeBucketMs},
+		}
+		mockPercentileService.On("RecordAccepted", createdSubmission).Return(nil)
+		mockPercentileService.On("GetPercentiles", createdSubmission).Return(percentiles, nil)
 
 		// Execute
 		result, err := service.ProcessSubmission(req)
@@ -280,12 +436,16 @@ func TestSubmissionService_ProcessSubmission(t *testing.T) {
 		assert.Equal(t, 1, result.TestCasesPassed)
 		assert.Equal(t, 1, result.TotalTestCases)
 		assert.Len(t, result.TestResults, 1)
+		assert.Equal(t, percentiles, result.Percentiles)
+		assert.Equal(t, 100*time.Millisecond, result.SandboxTime)
 
 		// Verify all expectations were met
 		mockTestCaseRepo.AssertExpectations(t)
 		mockExecutionService.AssertExpectations(t)
 		mockSubmissionRepo.AssertExpectations(t)
 		mockUserProgressRepo.AssertExpectations(t)
+		mockTestResultRepo.AssertExpectations(t)
+		mockPercentileService.AssertExpectations(t)
 	})
 
 	t.Run("invalid submission request", func(t *testing.T) {
@@ -303,14 +463,35 @@ func TestSubmissionService_ProcessSubmission(t *testing.T) {
 		assert.Contains(t, err.Error(), "invalid submission request")
 	})
 
+	t.Run("unpublished problem is rejected", func(t *testing.T) {
+		problems := newPublishedProblemRepository()
+		problems.problems[2] = &models.Problem{ID: 2, State: models.ProblemStateDraft}
+		service := NewSubmissionService(&repository.Repository{Problem: problems, TestCase: new(MockTestCaseRepository)}, new(MockExecutionService), new(MockPercentileService))
+
+		for _, problemID := range []int{2, 3} {
+			req := &SubmissionRequest{
+				UserID:    1,
+				ProblemID: problemID,
+				Language:  models.LanguagePython,
+				Code:      "print('test')",
+			}
+
+			result, err := service.ProcessSubmission(req)
+
+			assert.Nil(t, result)
+			assert.True(t, repository.IsNotFound(err), "expected not found for problem %d, got %v", problemID, err)
+		}
+	})
+
 	t.Run("no test cases available", func(t *testing.T) {
 		// Create new mocks for this test
 		mockSubmissionRepo2 := new(MockSubmissionRepository)
 		mockTestCaseRepo2 := new(MockTestCaseRepository)
 		mockUserProgressRepo2 := new(MockUserProgressRepository)
+		mockTestResultRepo2 := new(MockSubmissionTestResultRepository)
 		mockExecutionService2 := new(MockExecutionService)
 
-		service2 := NewSubmissionService(mockSubmissionRepo2, mockTestCaseRepo2, mockUserProgressRepo2, mockExecutionService2)
+		service2 := NewSubmissionService(&repository.Repository{Submission: mockSubmissionRepo2, TestCase: mockTestCaseRepo2, UserProgress: mockUserProgressRepo2, SubmissionTestResult: mockTestResultRepo2, JudgeSettings: newMockJudgeSettingsRepository(), Problem: newPublishedProblemRepository(1)}, mockExecutionService2, new(MockPercentileService))
 
 		req := &SubmissionRequest{
 			UserID:    1,
@@ -329,15 +510,52 @@ func TestSubmissionService_ProcessSubmission(t *testing.T) {
 
 		mockTestCaseRepo2.AssertExpectations(t)
 	})
+	t.Run("progress update failure fails the submission", func(t *testing.T) {
+		mockSubmissionRepo3 := new(MockSubmissionRepository)
+		mockTestCaseRepo3 := new(MockTestCaseRepository)
+		mockUserProgressRepo3 := new(MockUserProgressRepository)
+		mockTestResultRepo3 := new(MockSubmissionTestResultRepository)
+		mockExecutionService3 := new(MockExecutionService)
+		mockPercentileService3 := new(MockPercentileService)
+
+		service3 := NewSubmissionService(&repository.Repository{Submission: mockSubmissionRepo3, TestCase: mockTestCaseRepo3, UserProgress: mockUserProgressRepo3, SubmissionTestResult: mockTestResultRepo3, JudgeSettings: newMockJudgeSettingsRepository(), Problem: newPublishedProblemRepository(1)}, mockExecutionService3, mockPercentileService3)
+
+		req := &SubmissionRequest{
+			UserID:    1,
+			ProblemID: 1,
+			Language:  models.LanguagePython,
+			Code:      "print('test')",
+		}
+
+		executionResult := &execution.ExecutionResult{
+			Status:          models.StatusWrongAnswer,
+			TestCasesPassed: 0,
+			TotalTestCases:  1,
+		}
+
+		mockTestCaseRepo3.On("GetByProblemID", 1).Return([]*models.TestCase{{ID: 1, ProblemID: 1, Input: "in", ExpectedOutput: "out"}}, nil)
+		mockExecutionService3.On("ExecuteCode", req.Code, req.Language, mock.AnythingOfType("[]models.TestCase"), mock.AnythingOfType("*models.JudgeSettings")).Return(executionResult, nil)
+		mockSubmissionRepo3.On("Create", mock.AnythingOfType("*models.Submission")).Return(&models.Submission{ID: 2, UserID: 1, ProblemID: 1, Status: models.StatusWrongAnswer}, nil)
+		mockTestResultRepo3.On("CreateBatch", mock.Anything).Return(nil)
+		mockUserProgressRepo3.On("GetForUpdate", 1, 1).Return(nil, errors.New("database error"))
+
+		result, err := service3.ProcessSubmission(req)
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		assert.Contains(t, err.Error(), "failed to update user progress")
+		mockPercentileService3.AssertNotCalled(t, "RecordAccepted", mock.Anything)
+	})
 }
 
 func TestSubmissionService_GetSubmissionByID(t *testing.T) {
 	mockSubmissionRepo := new(MockSubmissionRepository)
 	mockTestCaseRepo := new(MockTestCaseRepository)
 	mockUserProgressRepo := new(MockUserProgressRepository)
+	mockTestResultRepo := new(MockSubmissionTestResultRepository)
 	mockExecutionService := new(MockExecutionService)
 
-	service := NewSubmissionService(mockSubmissionRepo, mockTestCaseRepo, mockUserProgressRepo, mockExecutionService)
+	service := NewSubmissionService(&repository.Repository{Submission: mockSubmissionRepo, TestCase: mockTestCaseRepo, UserProgress: mockUserProgressRepo, SubmissionTestResult: mockTestResultRepo, JudgeSettings: newMockJudgeSettingsRepository()}, mockExecutionService, new(MockPercentileService))
 
 	t.Run("successful retrieval", func(t *testing.T) {
 		expectedSubmission := &models.Submission{
@@ -371,56 +589,233 @@ func TestSubmissionService_GetSubmissionByID(t *testing.T) {
 	})
 }
 
+func TestSubmissionService_GetSubmissionDetails(t *testing.T) {
+	runtime := 120
+	memory := 2048
+	errorMessage := "Test case failed: expected 2, got 3"
+	submission := &models.Submission{
+		ID:              7,
+		UserID:          1,
+		ProblemID:       1,
+		Status:          models.StatusWrongAnswer,
+		RuntimeMs:       &runtime,
+		MemoryKb:        &memory,
+		TestCasesPassed: 1,
+		TotalTestCases:  3,
+		ErrorMessage:    &errorMessage,
+	}
+
+	newResults := func() []*models.SubmissionTestResult {
+		return []*models.SubmissionTestResult{
+			{SubmissionID: 7, Position: 1, Status: models.StatusAccepted, Passed: true, Input: "1", ExpectedOutput: "1", ActualOutput: "1"},
+			{SubmissionID: 7, Position: 2, Status: models.StatusWrongAnswer, IsHidden: true, Input: "secret", ExpectedOutput: "2", ActualOutput: "3"},
+		}
+	}
+
+	t.Run("hidden test content is redacted", func(t *testing.T) {
+		mockSubmissionRepo := new(MockSubmissionRepository)
+		mockTestResultRepo := new(MockSubmissionTestResultRepository)
+		service := NewSubmissionService(&repository.Repository{Submission: mockSubmissionRepo, TestCase: new(MockTestCaseRepository), UserProgress: new(MockUserProgressRepository), SubmissionTestResult: mockTestResultRepo, JudgeSettings: newMockJudgeSettingsRepository()}, new(MockExecutionService), new(MockPercentileService))
+
+		mockSubmissionRepo.On("GetByID", 7).Return(submission, nil)
+		mockTestResultRepo.On("GetBySubmissionID", 7).Return(newResults(), nil)
+
+		details, err := service.GetSubmissionDetails(7, false)
+
+		assert.NoError(t, err)
+		assert.Len(t, details.TestCaseResults, 2)
+		assert.Equal(t, "1", details.TestCaseResults[0].Input)
+		assert.False(t, details.TestCaseResults[0].Redacted)
+		assert.Equal(t, "", details.TestCaseResults[1].Input)
+		assert.Equal(t, "", details.TestCaseResults[1].ActualOutput)
+		assert.Equal(t, models.StatusWrongAnswer, details.TestCaseResults[1].Status)
+		assert.True(t, details.TestCaseResults[1].Redacted)
+		assert.Equal(t, 120, details.OverallStats.RuntimeMs)
+		assert.Equal(t, 2048, details.OverallStats.MemoryKb)
+		assert.Equal(t, 3, details.OverallStats.TotalTestCases)
+		assert.Equal(t, "Wrong Answer on hidden test case 2", *details.Submission.ErrorMessage)
+		assert.Equal(t, errorMessage, *submission.ErrorMessage, "the stored submission must not be changed")
+	})
+
+	t.Run("admins see hidden test content", func(t *testing.T) {
+		mockSubmissionRepo := new(MockSubmissionRepository)
+		mockTestResultRepo := new(MockSubmissionTestResultRepository)
+		service := NewSubmissionService(&repository.Repository{Submission: mockSubmissionRepo, TestCase: new(MockTestCaseRepository), UserProgress: new(MockUserProgressRepository), SubmissionTestResult: mockTestResultRepo, JudgeSettings: newMockJudgeSettingsRepository()}, new(MockExecutionService), new(MockPercentileService))
+
+		mockSubmissionRepo.On("GetByID", 7).Return(submission, nil)
+		mockTestResultRepo.On("GetBySubmissionID", 7).Return(newResults(), nil)
+
+		details, err := service.GetSubmissionDetails(7, true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", details.TestCaseResults[1].Input)
+		assert.False(t, details.TestCaseResults[1].Redacted)
+		assert.Equal(t, errorMessage, *details.Submission.ErrorMessage)
+	})
+}
+
+func TestBuildSubmissionTestResults(t *testing.T) {
+	testCases := []*models.TestCase{
+		{ID: 10, IsHidden: false},
+		{ID: 11, IsHidden: true},
+	}
+	longOutput := strings.Repeat("x", models.MaxStoredOutputLength+10)
+	testResults := []execution.TestResult{
+		{Input: "a", ExpectedOutput: "b", ActualOutput: "b", Passed: true, Status: models.StatusAccepted, RuntimeMs: 5, MemoryKb: 1024},
+		{Input: "c", ExpectedOutput: "d", ActualOutput: longOutput, Status: models.StatusWrongAnswer, RuntimeMs: 7, MemoryKb: 2048},
+	}
+
+	results := buildSubmissionTestResults(3, testCases, testResults)
+
+	assert.Len(t, results, 2)
+	assert.Equal(t, 1, results[0].Position)
+	assert.Equal(t, 10, *results[0].TestCaseID)
+	assert.False(t, results[0].OutputTruncated)
+	assert.Equal(t, 2, results[1].Position)
+	assert.True(t, results[1].IsHidden)
+	assert.True(t, results[1].OutputTruncated)
+	assert.Len(t, results[1].ActualOutput, models.MaxStoredOutputLength)
+	assert.Equal(t, 7, *results[1].RuntimeMs)
+}
+
 func TestSubmissionService_GetUserSubmissions(t *testing.T) {
 	mockSubmissionRepo := new(MockSubmissionRepository)
 	mockTestCaseRepo := new(MockTestCaseRepository)
 	mockUserProgressRepo := new(MockUserProgressRepository)
+	mockTestResultRepo := new(MockSubmissionTestResultRepository)
 	mockExecutionService := new(MockExecutionService)
 
-	service := NewSubmissionService(mockSubmissionRepo, mockTestCaseRepo, mockUserProgressRepo, mockExecutionService)
+	service := NewSubmissionService(&repository.Repository{Submission: mockSubmissionRepo, TestCase: mockTestCaseRepo, UserProgress: mockUserProgressRepo, SubmissionTestResult: mockTestResultRepo, JudgeSettings: newMockJudgeSettingsRepository()}, mockExecutionService, new(MockPercentileService))
 
 	t.Run("successful retrieval with pagination", func(t *testing.T) {
 		submissions := []*models.Submission{
 			{ID: 1, UserID: 1, Status: models.StatusAccepted},
 			{ID: 2, UserID: 1, Status: models.StatusWrongAnswer},
+			{ID: 3, UserID: 1, Status: models.StatusWrongAnswer},
 		}
 
-		mockSubmissionRepo.On("GetByUserID", 1, 21, 0).Return(submissions, nil) // 21 to check for next page
+		// One extra submission is fetched to know whether there is a next page
+		filters := repository.SubmissionFilters{UserID: 1, Limit: 3, Offset: 2}
+		mockSubmissionRepo.On("List", filters).Return(submissions, nil)
+		mockSubmissionRepo.On("Count", filters).Return(5, nil)
 
-		result, err := service.GetUserSubmissions(1, 1, 20)
+		result, err := service.GetUserSubmissions(1, 2, 2)
 
 		assert.NoError(t, err)
 		assert.NotNil(t, result)
 		assert.Len(t, result.Submissions, 2)
-		assert.Equal(t, 1, result.Page)
-		assert.Equal(t, 20, result.PageSize)
-		assert.False(t, result.HasNext)
+		assert.Equal(t, 5, result.Total)
+		assert.Equal(t, 2, result.Page)
+		assert.Equal(t, 2, result.Limit)
+		assert.True(t, result.HasNext)
+		assert.NotEmpty(t, result.NextCursor)
 
 		mockSubmissionRepo.AssertExpectations(t)
 	})
 }
 
+func TestSubmissionService_ListSubmissions(t *testing.T) {
+	mockSubmissionRepo := new(MockSubmissionRepository)
+	service := NewSubmissionService(&repository.Repository{Submission: mockSubmissionRepo, TestCase: new(MockTestCaseRepository), UserProgress: new(MockUserProgressRepository), SubmissionTestResult: new(MockSubmissionTestResultRepository), JudgeSettings: newMockJudgeSettingsRepository()}, new(MockExecutionService), new(MockPercentileService))
+
+	t.Run("filters are passed to the repository", func(t *testing.T) {
+		from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+		filters := repository.SubmissionFilters{
+			UserID:    1,
+			ProblemID: 3,
+			Status:    models.StatusAccepted,
+			Language:  models.LanguagePython,
+			From:      &from,
+			To:        &to,
+			Limit:     21,
+		}
+		submissions := []*models.Submission{
+			{ID: 7, UserID: 1, ProblemID: 3, Status: models.StatusAccepted, Problem: &models.SubmissionProblem{ID: 3, Title: "Two Sum", Difficulty: models.DifficultyEasy}},
+		}
+		mockSubmissionRepo.On("List", filters).Return(submissions, nil).Once()
+		mockSubmissionRepo.On("Count", filters).Return(1, nil).Once()
+
+		result, err := service.ListSubmissions(repository.SubmissionFilters{
+			UserID:    1,
+			ProblemID: 3,
+			Status:    models.StatusAccepted,
+			Language:  models.LanguagePython,
+			From:      &from,
+			To:        &to,
+		}, pagination.Params{Page: 1, Limit: 20})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.Total)
+		assert.False(t, result.HasNext)
+		assert.Equal(t, "Two Sum", result.Submissions[0].Problem.Title)
+		mockSubmissionRepo.AssertExpectations(t)
+	})
+
+	t.Run("empty result is not nil", func(t *testing.T) {
+		filters := repository.SubmissionFilters{UserID: 2, Limit: 21}
+		mockSubmissionRepo.On("List", filters).Return(nil, nil).Once()
+		mockSubmissionRepo.On("Count", filters).Return(0, nil).Once()
+
+		result, err := service.ListSubmissions(repository.SubmissionFilters{UserID: 2}, pagination.Params{Page: 1, Limit: 20})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, result.Submissions)
+		assert.Equal(t, 0, result.Total)
+	})
+
+	invalidTests := []struct {
+		name    string
+		filters repository.SubmissionFilters
+		errMsg  string
+	}{
+		{"invalid status", repository.SubmissionFilters{Status: "Pending"}, "invalid status"},
+		{"unsupported language", repository.SubmissionFilters{Language: "cobol"}, "unsupported language"},
+	}
+
+	for _, tt := range invalidTests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := service.ListSubmissions(tt.filters, pagination.Params{Page: 1, Limit: 20})
+
+			assert.Error(t, err)
+			assert.Nil(t, result)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
 func TestSubmissionService_GetUserSubmissionStats(t *testing.T) {
 	mockSubmissionRepo := new(MockSubmissionRepository)
 	mockTestCaseRepo := new(MockTestCaseRepository)
 	mockUserProgressRepo := new(MockUserProgressRepository)
+	mockTestResultRepo := new(MockSubmissionTestResultRepository)
 	mockExecutionService := new(MockExecutionService)
 
-	service := NewSubmissionService(mockSubmissionRepo, mockTestCaseRepo, mockUserProgressRepo, mockExecutionService)
+	service := NewSubmissionService(&repository.Repository{Submission: mockSubmissionRepo, TestCase: mockTestCaseRepo, UserProgress: mockUserProgressRepo, SubmissionTestResult: mockTestResultRepo, JudgeSettings: newMockJudgeSettingsRepository()}, mockExecutionService, new(MockPercentileService))
 
 	t.Run("calculate stats correctly", func(t *testing.T) {
-		runtime1 := 100
-		runtime2 := 200
-		memory1 := 1024
-		memory2 := 2048
-
-		submissions := []*models.Submission{
-			{ID: 1, Status: models.StatusAccepted, RuntimeMs: &runtime1, MemoryKb: &memory1},
-			{ID: 2, Status: models.StatusWrongAnswer, RuntimeMs: &runtime2, MemoryKb: &memory2},
-			{ID: 3, Status: models.StatusAccepted, RuntimeMs: &runtime2, MemoryKb: &memory1},
+		aggregate := &models.SubmissionStats{
+			TotalSubmissions: 3,
+			StatusCounts: map[string]int{
+				models.StatusAccepted:    2,
+				models.StatusWrongAnswer: 1,
+			},
+			LanguageCounts: map[string]int{
+				models.LanguagePython:     2,
+				models.LanguageJavaScript: 1,
+			},
+			AvgRuntimeMs:       166.67,
+			AvgMemoryKb:        1365.33,
+			RuntimePercentiles: models.PerformancePercentiles{P25: 150, P50: 200, P75: 200, P90: 200, P99: 200},
+			DailyActivity: []models.DailySubmissionActivity{
+				{Date: "2024-01-01", Submissions: 2, Accepted: 1},
+				{Date: "2024-01-03", Submissions: 1, Accepted: 1},
+			},
 		}
+		solved := map[string]int{models.DifficultyEasy: 1, models.DifficultyMedium: 1, models.DifficultyHard: 0}
 
-		mockSubmissionRepo.On("GetByUserID", 1, 1000, 0).Return(submissions, nil)
+		mockSubmissionRepo.On("GetStats", 1, statsActivityDays).Return(aggregate, nil)
+		mockUserProgressRepo.On("GetSolvedCountByDifficulty", 1).Return(solved, nil)
 
 		stats, err := service.GetUserSubmissionStats(1)
 
@@ -429,22 +824,32 @@ func TestSubmissionService_GetUserSubmissionStats(t *testing.T) {
 		assert.Equal(t, 3, stats["total_submissions"])
 		assert.Equal(t, 2, stats["accepted"])
 		assert.Equal(t, 1, stats["wrong_answer"])
+		assert.Equal(t, 0, stats["runtime_error"])
 		assert.InDelta(t, 66.67, stats["acceptance_rate"], 0.01)
-		assert.Equal(t, 166, stats["avg_runtime_ms"]) // (100+200+200)/3 = 166.67 rounded to 166
-		assert.Equal(t, 1365, stats["avg_memory_kb"]) // (1024+2048+1024)/3 = 1365.33 rounded to 1365
+		assert.Equal(t, 166, stats["avg_runtime_ms"])
+		assert.Equal(t, 1365, stats["avg_memory_kb"])
+		assert.Equal(t, aggregate.LanguageCounts, stats["language_stats"])
+		assert.Equal(t, aggregate.StatusCounts, stats["status_stats"])
+		assert.Equal(t, solved, stats["solved_by_difficulty"])
+		assert.Equal(t, 2, stats["total_solved"])
+		assert.Equal(t, aggregate.RuntimePercentiles, stats["runtime_percentiles"])
+		assert.Len(t, stats["daily_activity"], 2)
 
 		mockSubmissionRepo.AssertExpectations(t)
+		mockUserProgressRepo.AssertExpectations(t)
 	})
 
 	t.Run("empty submissions", func(t *testing.T) {
 		mockSubmissionRepo2 := new(MockSubmissionRepository)
 		mockTestCaseRepo2 := new(MockTestCaseRepository)
 		mockUserProgressRepo2 := new(MockUserProgressRepository)
+		mockTestResultRepo2 := new(MockSubmissionTestResultRepository)
 		mockExecutionService2 := new(MockExecutionService)
 
-		service2 := NewSubmissionService(mockSubmissionRepo2, mockTestCaseRepo2, mockUserProgressRepo2, mockExecutionService2)
+		service2 := NewSubmissionService(&repository.Repository{Submission: mockSubmissionRepo2, TestCase: mockTestCaseRepo2, UserProgress: mockUserProgressRepo2, SubmissionTestResult: mockTestResultRepo2, JudgeSettings: newMockJudgeSettingsRepository()}, mockExecutionService2, new(MockPercentileService))
 
-		mockSubmissionRepo2.On("GetByUserID", 1, 1000, 0).Return([]*models.Submission{}, nil)
+		mockSubmissionRepo2.On("GetStats", 1, statsActivityDays).Return(&models.SubmissionStats{}, nil)
+		mockUserProgressRepo2.On("GetSolvedCountByDifficulty", 1).Return(map[string]int{}, nil)
 
 		stats, err := service2.GetUserSubmissionStats(1)
 
@@ -452,18 +857,33 @@ func TestSubmissionService_GetUserSubmissionStats(t *testing.T) {
 		assert.NotNil(t, stats)
 		assert.Equal(t, 0, stats["total_submissions"])
 		assert.Equal(t, 0.0, stats["acceptance_rate"])
+		assert.Equal(t, map[string]int{}, stats["language_stats"])
+		assert.Equal(t, []models.DailySubmissionActivity{}, stats["daily_activity"])
 
 		mockSubmissionRepo2.AssertExpectations(t)
 	})
+
+	t.Run("aggregation error", func(t *testing.T) {
+		mockSubmissionRepo3 := new(MockSubmissionRepository)
+		service3 := NewSubmissionService(&repository.Repository{Submission: mockSubmissionRepo3, TestCase: new(MockTestCaseRepository), UserProgress: new(MockUserProgressRepository), SubmissionTestResult: new(MockSubmissionTestResultRepository), JudgeSettings: newMockJudgeSettingsRepository()}, new(MockExecutionService), new(MockPercentileService))
+
+		mockSubmissionRepo3.On("GetStats", 1, statsActivityDays).Return(nil, errors.New("database error"))
+
+		stats, err := service3.GetUserSubmissionStats(1)
+
+		assert.Error(t, err)
+		assert.Nil(t, stats)
+	})
 }
 
 func TestSubmissionService_validateSubmissionRequest(t *testing.T) {
 	mockSubmissionRepo := new(MockSubmissionRepository)
 	mockTestCaseRepo := new(MockTestCaseRepository)
 	mockUserProgressRepo := new(MockUserProgressRepository)
+	mockTestResultRepo := new(MockSubmissionTestResultRepository)
 	mockExecutionService := new(MockExecutionService)
 
-	service := NewSubmissionService(mockSubmissionRepo, mockTestCaseRepo, mockUserProgressRepo, mockExecutionService)
+	service := NewSubmissionService(&repository.Repository{Submission: mockSubmissionRepo, TestCase: mockTestCaseRepo, UserProgress: mockUserProgressRepo, SubmissionTestResult: mockTestResultRepo, JudgeSettings: newMockJudgeSettingsRepository()}, mockExecutionService, new(MockPercentileService))
 
 	tests := []struct {
 		name    string
@@ -539,3 +959,49 @@ func TestSubmissionService_validateSubmissionRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestSubmissionService_DiffSubmissions(t *testing.T) {
+	mockSubmissionRepo := new(MockSubmissionRepository)
+	service := NewSubmissionService(&repository.Repository{Submission: mockSubmissionRepo}, new(MockExecutionService), new(MockPercentileService))
+
+	t.Run("same language", func(t *testing.T) {
+		mockSubmissionRepo.On("GetByID", 1).Return(&models.Submission{ID: 1, UserID: 1, ProblemID: 1, Language: models.LanguagePython, Status: models.StatusWrongAnswer, Code: "def f(n):\n    return n\n"}, nil).Once()
+		mockSubmissionRepo.On("GetByID", 2).Return(&models.Submission{ID: 2, UserID: 1, ProblemID: 1, Language: models.LanguagePython, Status: models.StatusAccepted, Code: "def f(n):\n    return n + 1\n"}, nil).Once()
+
+		result, err := service.DiffSubmissions(1, 2)
+
+		assert.NoError(t, err)
+		assert.False(t, result.LanguagesDiffer)
+		assert.Empty(t, result.Notes)
+		assert.Equal(t, 1, result.Additions)
+		assert.Equal(t, 1, result.Deletions)
+		assert.Len(t, result.Hunks, 1)
+		assert.Contains(t, result.Unified, "--- submission/1 (python)\n+++ submission/2 (python)\n")
+		assert.Equal(t, models.StatusAccepted, result.To.Status)
+	})
+
+	t.Run("different languages", func(t *testing.T) {
+		mockSubmissionRepo.On("GetByID", 3).Return(&models.Submission{ID: 3, UserID: 1, ProblemID: 1, Language: models.LanguagePython, Code: "print(1)"}, nil).Once()
+		mockSubmissionRepo.On("GetByID", 4).Return(&models.Submission{ID: 4, UserID: 1, ProblemID: 1, Language: models.LanguageJavaScript, Code: "console.log(1)"}, nil).Once()
+
+		result, err := service.DiffSubmissions(3, 4)
+
+		assert.NoError(t, err)
+		assert.True(t, result.LanguagesDiffer)
+		assert.Len(t, result.Notes, 1)
+		assert.Contains(t, result.Notes[0], "different languages")
+	})
+
+	t.Run("submission not found", func(t *testing.T) {
+		mockSubmissionRepo.On("GetByID", 5).Return(&models.Submission{ID: 5}, nil).Once()
+		mockSubmissionRepo.On("GetByID", 999).Return(nil, repository.NewRepositoryError("GetByID", repository.ErrNotFound, "submission_not_found")).Once()
+
+		result, err := service.DiffSubmissions(5, 999)
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		assert.True(t, repository.IsNotFound(err))
+	})
+
+	mockSubmissionRepo.AssertExpectations(t)
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+)
+
+// TagServiceInterface defines the interface for tag service
+type TagServiceInterface interface {
+	ListTags(userID int) ([]*models.Tag, error)
+	UpdateTag(name string, description *string) (*models.Tag, error)
+	RenameTag(name, newName string, authorID int) (*TagMergeResult, error)
+	MergeTags(sources []string, target string, authorID int) (*TagMergeResult, error)
+}
+
+// TagMergeResult is the outcome of renaming or merging tags
+type TagMergeResult struct {
+	Tag             *models.Tag `json:"tag"`
+	ProblemsUpdated int64       `json:"problems_updated"`
+}
+
+// TagService handles business logic for the tag catalog
+type TagService struct {
+	repo *repository.Repository
+}
+
+// NewTagService creates a new tag service
+func NewTagService(repo *repository.Repository) *TagService {
+	return &TagService{
+		repo: repo,
+	}
+}
+
+// ListTags retrieves the tag catalog of published problems. If userID is not 0, the number of
+// problems the user has solved is included for every tag.
+func (ts *TagService) ListTags(userID int) ([]*models.Tag, error) {
+	tags, err := ts.repo.Tag.List(userID, models.ProblemStatePublished)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	if tags == nil {
+		tags = []*models.Tag{}
+	}
+
+	return tags, nil
+}
+
+// UpdateTag sets the description of a tag. An empty description removes it.
+func (ts *TagService) UpdateTag(name string, description *string) (*models.Tag, error) {
+	name, err := normalizeTagName(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag: %w", err)
+	}
+
+	if description != nil {
+		trimmed := strings.TrimSpace(*description)
+		if len(trimmed) > 1000 {
+			return nil, fmt.Errorf("invalid tag: description must be 1000 characters or less")
+		}
+		description = &trimmed
+		if trimmed == "" {
+			description = nil
+		}
+	}
+
+	if err := ts.repo.Tag.SetDescription(name, description); err != nil {
+		return nil, fmt.Errorf("failed to update tag: %w", err)
+	}
+
+	return ts.getTag(name)
+}
+
+// RenameTag renames a tag in every problem. Renaming to the name of another existing tag is a
+// conflict; use MergeTags to combine two tags. The change to each problem is recorded as a
+// revision authored by authorID.
+func (ts *TagService) RenameTag(name, newName string, authorID int) (*TagMergeResult, error) {
+	name, err := normalizeTagName(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag: %w", err)
+	}
+	newName, err = normalizeTagName(newName)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag: %w", err)
+	}
+
+	if _, err := ts.repo.Tag.Get(name); err != nil {
+		return nil, fmt.Errorf("failed to retrieve tag: %w", err)
+	}
+
+	if newName != name {
+		_, err := ts.repo.Tag.Get(newName)
+		if err == nil {
+			return nil, fmt.Errorf("tag %q already exists, merge the tags instead: %w", newName, repository.ErrConflict)
+		}
+		if !repository.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to retrieve tag: %w", err)
+		}
+	}
+
+	return ts.merge([]string{name}, newName, authorID)
+}
+
+// MergeTags replaces the source tags with the target tag in every problem. The target does not
+// need to exist yet. The change to each problem is recorded as a revision authored by authorID.
+func (ts *TagService) MergeTags(sources []string, target string, authorID int) (*TagMergeResult, error) {
+	target, err := normalizeTagName(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag: %w", err)
+	}
+
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("invalid tag: at least one source tag is required")
+	}
+
+	normalized := make([]string, 0, len(sources))
+	for _, source := range sources {
+		source, err := normalizeTagName(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag: %w", err)
+		}
+		normalized = append(normalized, source)
+	}
+
+	return ts.merge(normalized, target, authorID)
+}
+
+// merge merges tags and returns the resulting target tag
+func (ts *TagService) merge(sources []string, target string, authorID int) (*TagMergeResult, error) {
+	// A problem given a source tag after this list is read is merged without a revision of its
+	// own; its next revision still shows the change
+	problems, err := ts.repo.Problem.List(repository.ProblemFilters{Tags: sources})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tagged problems: %w", err)
+	}
+	problemIDs := make([]int, len(problems))
+	for i, problem := range problems {
+		problemIDs[i] = problem.ID
+	}
+
+	var changed int64
+	change := revisionChange{authorID: authorID, action: models.RevisionActionTagChange}
+	_, err = withProblemRevisions(ts.repo, problemIDs, change, func(tx *repository.Repository) error {
+		var err error
+		changed, err = tx.Tag.Merge(sources, target)
+		if err != nil {
+			return fmt.Errorf("failed to merge tags: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := ts.getTag(target)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TagMergeResult{Tag: tag, ProblemsUpdated: changed}, nil
+}
+
+// getTag retrieves a tag of the catalog
+func (ts *TagService) getTag(name string) (*models.Tag, error) {
+	tag, err := ts.repo.Tag.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve tag: %w", err)
+	}
+
+	return tag, nil
+}
+
+// normalizeTagName trims a tag name and checks that it is valid
+func normalizeTagName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("tag name is required")
+	}
+	if len(name) > models.MaxTagLength {
+		return "", fmt.Errorf("tag name must be %d characters or less", models.MaxTagLength)
+	}
+	if strings.Contains(name, ",") {
+		return "", fmt.Errorf("tag name cannot contain commas")
+	}
+
+	return name, nil
+}
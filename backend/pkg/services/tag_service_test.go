@@ -0,0 +1,208 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTagRepository struct {
+	mock.Mock
+}
+
+func (m *MockTagRepository) List(userID int, state string) ([]*models.Tag, error) {
+	args := m.Called(userID, state)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Tag), args.Error(1)
+}
+
+func (m *MockTagRepository) Get(name string) (*models.Tag, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Tag), args.Error(1)
+}
+
+func (m *MockTagRepository) SetDescription(name string, description *string) error {
+	args := m.Called(name, description)
+	return args.Error(0)
+}
+
+func (m *MockTagRepository) Merge(sources []string, target string) (int64, error) {
+	args := m.Called(sources, target)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func newTagTestService() (*TagService, *MockTagRepository) {
+	tagRepo := new(MockTagRepository)
+	return NewTagService(&repository.Repository{
+		Tag:             tagRepo,
+		Problem:         newMockProblemRepository(),
+		TestCase:        newMockTestCaseRepository(),
+		ProblemRevision: newMockProblemRevisionRepository(),
+	}), tagRepo
+}
+
+func tagNotFound() error {
+	return repository.NewRepositoryError("Get", repository.ErrNotFound, "tag_not_found")
+}
+
+func TestTagService_ListTags(t *testing.T) {
+	t.Run("returns empty list when there are no tags", func(t *testing.T) {
+		service, tagRepo := newTagTestService()
+		tagRepo.On("List", 0, models.ProblemStatePublished).Return(nil, nil)
+
+		tags, err := service.ListTags(0)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, tags)
+		assert.Empty(t, tags)
+	})
+
+	t.Run("passes the user through and counts published problems only", func(t *testing.T) {
+		service, tagRepo := newTagTestService()
+		solved := 2
+		expected := []*models.Tag{{Name: "Array", ProblemCount: 3, EasyCount: 3, SolvedCount: &solved}}
+		tagRepo.On("List", 7, models.ProblemStatePublished).Return(expected, nil)
+
+		tags, err := service.ListTags(7)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, tags)
+	})
+}
+
+func TestTagService_UpdateTag(t *testing.T) {
+	t.Run("trims the description", func(t *testing.T) {
+		service, tagRepo := newTagTestService()
+		description := "Problems on arrays"
+		tagRepo.On("SetDescription", "Array", &description).Return(nil)
+		tagRepo.On("Get", "Array").Return(&models.Tag{Name: "Array", Description: &description}, nil)
+
+		input := "  Problems on arrays  "
+		tag, err := service.UpdateTag(" Array ", &input)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Problems on arrays", *tag.Description)
+	})
+
+	t.Run("empty description is removed", func(t *testing.T) {
+		service, tagRepo := newTagTestService()
+		tagRepo.On("SetDescription", "Array", (*string)(nil)).Return(nil)
+		tagRepo.On("Get", "Array").Return(&models.Tag{Name: "Array"}, nil)
+
+		empty := " "
+		tag, err := service.UpdateTag("Array", &empty)
+
+		assert.NoError(t, err)
+		assert.Nil(t, tag.Description)
+	})
+
+	t.Run("invalid names", func(t *testing.T) {
+		service, _ := newTagTestService()
+
+		for _, name := range []string{"", "a,b", strings.Repeat("a", models.MaxTagLength+1)} {
+			_, err := service.UpdateTag(name, nil)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid tag")
+		}
+	})
+}
+
+func TestTagService_RenameTag(t *testing.T) {
+	t.Run("renames tag", func(t *testing.T) {
+		service, tagRepo := newTagTestService()
+		tagRepo.On("Get", "Hash Map").Return(&models.Tag{Name: "Hash Map", ProblemCount: 2}, nil).Once()
+		tagRepo.On("Get", "Hash Table").Return(nil, tagNotFound()).Once()
+		tagRepo.On("Merge", []string{"Hash Map"}, "Hash Table").Return(int64(2), nil)
+		tagRepo.On("Get", "Hash Table").Return(&models.Tag{Name: "Hash Table", ProblemCount: 2}, nil).Once()
+
+		result, err := service.RenameTag("Hash Map", "Hash Table", 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), result.ProblemsUpdated)
+		assert.Equal(t, "Hash Table", result.Tag.Name)
+		tagRepo.AssertExpectations(t)
+	})
+
+	t.Run("target already exists", func(t *testing.T) {
+		service, tagRepo := newTagTestService()
+		tagRepo.On("Get", "Hash Map").Return(&models.Tag{Name: "Hash Map"}, nil)
+		tagRepo.On("Get", "Hash Table").Return(&models.Tag{Name: "Hash Table"}, nil)
+
+		_, err := service.RenameTag("Hash Map", "Hash Table", 1)
+
+		assert.Error(t, err)
+		assert.True(t, repository.IsConflict(err))
+		tagRepo.AssertNotCalled(t, "Merge", mock.Anything, mock.Anything)
+	})
+
+	t.Run("tag not found", func(t *testing.T) {
+		service, tagRepo := newTagTestService()
+		tagRepo.On("Get", "Missing").Return(nil, tagNotFound())
+
+		_, err := service.RenameTag("Missing", "Other", 1)
+
+		assert.Error(t, err)
+		assert.True(t, repository.IsNotFound(err))
+	})
+}
+
+func TestTagService_MergeTags(t *testing.T) {
+	t.Run("merges normalized sources", func(t *testing.T) {
+		service, tagRepo := newTagTestService()
+		tagRepo.On("Merge", []string{"DP", "Dynamic programming"}, "Dynamic Programming").Return(int64(5), nil)
+		tagRepo.On("Get", "Dynamic Programming").Return(&models.Tag{Name: "Dynamic Programming", ProblemCount: 5}, nil)
+
+		result, err := service.MergeTags([]string{" DP", "Dynamic programming "}, "Dynamic Programming", 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), result.ProblemsUpdated)
+		assert.Equal(t, 5, result.Tag.ProblemCount)
+	})
+
+	t.Run("records a revision of each merged problem", func(t *testing.T) {
+		tagRepo := new(MockTagRepository)
+		problemRepo := newMockProblemRepository()
+		revisionRepo := newMockProblemRevisionRepository()
+		service := NewTagService(&repository.Repository{
+			Tag:             tagRepo,
+			Problem:         problemRepo,
+			TestCase:        newMockTestCaseRepository(),
+			ProblemRevision: revisionRepo,
+		})
+		problem, _ := problemRepo.Create(&models.Problem{Title: "Climbing Stairs", Tags: []string{"DP", "Math"}})
+		tagRepo.On("Merge", []string{"DP"}, "Dynamic Programming").Run(func(args mock.Arguments) {
+			problem.Tags = []string{"Dynamic Programming", "Math"}
+		}).Return(int64(1), nil)
+		tagRepo.On("Get", "Dynamic Programming").Return(&models.Tag{Name: "Dynamic Programming", ProblemCount: 1}, nil)
+
+		_, err := service.MergeTags([]string{"DP"}, "Dynamic Programming", 7)
+
+		assert.NoError(t, err)
+		if assert.Len(t, revisionRepo.revisions, 2) {
+			assert.Equal(t, models.RevisionActionBaseline, revisionRepo.revisions[0].Action)
+			tagChange := revisionRepo.revisions[1]
+			assert.Equal(t, models.RevisionActionTagChange, tagChange.Action)
+			assert.Equal(t, 7, *tagChange.AuthorID)
+			assert.Equal(t, []string{"tags"}, []string(tagChange.ChangedFields))
+		}
+	})
+
+	t.Run("requires sources", func(t *testing.T) {
+		service, _ := newTagTestService()
+
+		_, err := service.MergeTags(nil, "Array", 1)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid tag")
+	})
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+)
+
+// Test case upload limits
+const (
+	MaxTestCaseUploadSize  = 256 << 20 // Total size of the files of an upload
+	MaxTestCaseUploadCount = 500       // Test cases created by an upload
+)
+
+// TestDataFile is an uploaded test data file. Inputs end in .in and expected outputs in .out or
+// .ans; an input and its expected output share the rest of their path, the name of the test.
+type TestDataFile struct {
+	Name string
+	Data []byte
+}
+
+// TestCaseUpload is a set of test cases uploaded at once
+type TestCaseUpload struct {
+	Files   []TestDataFile
+	Public  []string // Names of the tests that are public; the others are hidden
+	Replace bool     // Delete the existing test cases of the problem first
+	// GenerateOutputs runs the reference solutions of the problem on every input: tests may leave
+	// out their expected output, which is generated, and uploaded outputs must match
+	GenerateOutputs bool
+}
+
+// UploadedTestCase is a test case created by an upload, without its data
+type UploadedTestCase struct {
+	Name string `json:"name"`
+	*models.TestCase
+}
+
+// TestCaseUploadResult is the outcome of a test case upload
+type TestCaseUploadResult struct {
+	TestCases []*UploadedTestCase `json:"test_cases"`
+	Count     int                 `json:"count"`
+	Deleted   int                 `json:"deleted"`            // Existing test cases deleted by a replacing upload
+	Revision  int                 `json:"revision,omitempty"` // Revision recorded by the upload
+}
+
+// uploadedTest is an input and expected output paired by name
+type uploadedTest struct {
+	name   string
+	input  *TestDataFile
+	output *TestDataFile
+}
+
+// UploadTestCases creates the test cases of an upload in one transaction, ordered by name with
+// numbers compared by value, and records them as a single revision authored by authorID. Large
+// test data is kept in the test data store by the repository. When outputs are generated, the
+// upload is rejected with an OutputCheckError unless the reference solutions agree on every input
+// and with every uploaded output. Inputs must pass the validator of the problem, if it has one.
+func (s *ProblemService) UploadTestCases(problemID int, upload *TestCaseUpload, authorID int) (*TestCaseUploadResult, error) {
+	tests, err := pairTestDataFiles(upload.Files, !upload.GenerateOutputs)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	public := make(map[string]bool, len(upload.Public))
+	for _, name := range upload.Public {
+		public[name] = true
+	}
+
+	testCases := make([]*models.TestCase, 0, len(tests))
+	for _, test := range tests {
+		testCase := &models.TestCase{
+			ProblemID: problemID,
+			Input:     string(test.input.Data),
+			IsHidden:  !public[test.name],
+		}
+		if test.output != nil {
+			testCase.ExpectedOutput = string(test.output.Data)
+			if err := s.validateTestCase(testCase); err != nil {
+				return nil, fmt.Errorf("validation failed: test %s: %w", test.name, err)
+			}
+		} else if err := validateTestData("input", testCase.Input); err != nil {
+			return nil, fmt.Errorf("validation failed: test %s: %w", test.name, err)
+		}
+		delete(public, test.name)
+		testCases = append(testCases, testCase)
+	}
+	if len(public) > 0 {
+		names := make([]string, 0, len(public))
+		for name := range public {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("validation failed: public tests not uploaded: %s", strings.Join(names, ", "))
+	}
+
+	if _, err := s.repo.Problem.GetByID(problemID); err != nil {
+		return nil, fmt.Errorf("problem not found: %w", err)
+	}
+
+	names := make([]string, len(tests))
+	for i, test := range tests {
+		names[i] = test.name
+	}
+	if err := s.validateInputs(problemID, names, testCases); err != nil {
+		return nil, err
+	}
+	if upload.GenerateOutputs {
+		if err := s.generateUploadedOutputs(problemID, names, testCases); err != nil {
+			return nil, err
+		}
+	}
+
+	change := revisionChange{authorID: authorID, action: models.RevisionActionTestCaseUpload}
+	return s.createUploadedTestCases(problemID, names, testCases, upload.Replace, change)
+}
+
+// createUploadedTestCases creates test cases named by names in one transaction, after deleting
+// the existing test cases of the problem if replace is set, and records them as a single revision
+func (s *ProblemService) createUploadedTestCases(problemID int, names []string, testCases []*models.TestCase, replace bool, change revisionChange) (*TestCaseUploadResult, error) {
+	result := &TestCaseUploadResult{TestCases: make([]*UploadedTestCase, 0, len(testCases))}
+	revisions, err := withProblemRevisions(s.repo, []int{problemID}, change, func(tx *repository.Repository) error {
+		if replace {
+			existing, err := tx.TestCase.ListByProblemID(problemID)
+			if err != nil {
+				return fmt.Errorf("failed to get test cases: %w", err)
+			}
+			if err := tx.TestCase.DeleteByProblemID(problemID); err != nil {
+				return fmt.Errorf("failed to delete test cases: %w", err)
+			}
+			result.Deleted = len(existing)
+		}
+
+		for i, testCase := range testCases {
+			created, err := tx.TestCase.Create(testCase)
+			if err != nil {
+				return fmt.Errorf("failed to create test case %s: %w", names[i], err)
+			}
+
+			summary := *created
+			summary.Input, summary.ExpectedOutput = "", ""
+			result.TestCases = append(result.TestCases, &UploadedTestCase{Name: names[i], TestCase: &summary})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Count = len(result.TestCases)
+	if revisions[0] != nil {
+		result.Revision = revisions[0].Revision
+	}
+
+	return result, nil
+}
+
+// generateUploadedOutputs checks new test cases, named by names, against the reference solutions
+// of the problem and fills in the expected outputs left out
+func (s *ProblemService) generateUploadedOutputs(problemID int, names []string, testCases []*models.TestCase) error {
+	checks, err := s.checkOutputs(problemID, testCases)
+	if err != nil {
+		return err
+	}
+
+	for i, check := range checks {
+		check.Name = names[i]
+		if check.Status != models.OutputStatusVerified {
+			return &OutputCheckError{Checks: checks}
+		}
+	}
+
+	for i, testCase := range testCases {
+		if testCase.ExpectedOutput == "" {
+			testCase.ExpectedOutput = checks[i].output
+			if err := s.validateTestCase(testCase); err != nil {
+				return fmt.Errorf("validation failed: test %s: %w", names[i], err)
+			}
+		}
+		testCase.OutputStatus = models.OutputStatusVerified
+	}
+
+	return nil
+}
+
+// pairTestDataFiles pairs the inputs and expected outputs of an upload by name, ordered by name.
+// Hidden files and folders, such as those added by macOS archivers, are skipped. Every test needs
+// an expected output if outputsRequired is set.
+func pairTestDataFiles(files []TestDataFile, outputsRequired bool) ([]*uploadedTest, error) {
+	byName := make(map[string]*uploadedTest)
+	for i := range files {
+		file := &files[i]
+		if hiddenTestDataFile(file.Name) {
+			continue
+		}
+
+		ext := path.Ext(file.Name)
+		name := strings.TrimSuffix(file.Name, ext)
+		test := byName[name]
+		if test == nil {
+			test = &uploadedTest{name: name}
+			byName[name] = test
+		}
+
+		switch ext {
+		case ".in":
+			test.input = file
+		case ".out", ".ans":
+			if test.output != nil {
+				return nil, fmt.Errorf("test %s has more than one expected output", name)
+			}
+			test.output = file
+		default:
+			return nil, fmt.Errorf("unexpected file %s: inputs must end in .in and expected outputs in .out or .ans", file.Name)
+		}
+	}
+
+	tests := make([]*uploadedTest, 0, len(byName))
+	for _, test := range byName {
+		tests = append(tests, test)
+	}
+	sort.Slice(tests, func(i, j int) bool { return lessNatural(tests[i].name, tests[j].name) })
+
+	for _, test := range tests {
+		if test.input == nil {
+			return nil, fmt.Errorf("test %s has no input", test.name)
+		}
+		if test.output == nil && outputsRequired {
+			return nil, fmt.Errorf("test %s has no expected output", test.name)
+		}
+	}
+	if len(tests) == 0 {
+		return nil, fmt.Errorf("no test data files uploaded")
+	}
+	if len(tests) > MaxTestCaseUploadCount {
+		return nil, fmt.Errorf("at most %d test cases can be uploaded at once", MaxTestCaseUploadCount)
+	}
+
+	return tests, nil
+}
+
+// hiddenTestDataFile reports whether a file or one of its folders is hidden
+func hiddenTestDataFile(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+// lessNatural orders names with runs of digits compared by value, so that test 2 comes before
+// test 10
+func lessNatural(a, b string) bool {
+	for a != "" && b != "" {
+		aDigits, bDigits := leadingDigits(a), leadingDigits(b)
+		if aDigits != "" && bDigits != "" {
+			aValue, bValue := strings.TrimLeft(aDigits, "0"), strings.TrimLeft(bDigits, "0")
+			if len(aValue) != len(bValue) {
+				return len(aValue) < len(bValue)
+			}
+			if aValue != bValue {
+				return aValue < bValue
+			}
+			if len(aDigits) != len(bDigits) {
+				return len(aDigits) < len(bDigits)
+			}
+			a, b = a[len(aDigits):], b[len(bDigits):]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+// leadingDigits returns the digits at the start of s
+func leadingDigits(s string) string {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	return s[:end]
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"leetcode-clone-backend/pkg/models"
+)
+
+func testDataFiles(contents map[string]string) []TestDataFile {
+	files := make([]TestDataFile, 0, len(contents))
+	for name, data := range contents {
+		files = append(files, TestDataFile{Name: name, Data: []byte(data)})
+	}
+	return files
+}
+
+func TestProblemService_UploadTestCases(t *testing.T) {
+	service, _, testCaseRepo, revisionRepo := newRevisionTestService()
+
+	created, err := service.CreateProblem(revisionTestProblem("Two Sum"), 1)
+	if err != nil {
+		t.Fatalf("Failed to create problem: %v", err)
+	}
+	service.CreateTestCase(&models.TestCase{ProblemID: created.ID, Input: "0 0", ExpectedOutput: "0"}, 1)
+	revisionsBefore := len(revisionRepo.revisions)
+
+	result, err := service.UploadTestCases(created.ID, &TestCaseUpload{
+		Files: testDataFiles(map[string]string{
+			"tests/10.in":         "5 5",
+			"tests/10.out":        "10",
+			"tests/2.in":          "1 1",
+			"tests/2.ans":         "2",
+			"tests/1.in":          "1 2",
+			"tests/1.out":         "3",
+			"tests/.DS_Store":     "",
+			"__MACOSX/tests/1.in": "",
+		}),
+		Public: []string{"tests/1"},
+	}, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var names []string
+	for _, testCase := range result.TestCases {
+		names = append(names, testCase.Name)
+		if testCase.Input != "" || testCase.ExpectedOutput != "" {
+			t.Errorf("Expected test %s without its data, got %q and %q", testCase.Name, testCase.Input, testCase.ExpectedOutput)
+		}
+		if testCase.InputHash == "" || testCase.OutputSize == 0 {
+			t.Errorf("Expected test %s with the hash and size of its data, got %+v", testCase.Name, testCase.TestCase)
+		}
+		if testCase.IsHidden != (testCase.Name != "tests/1") {
+			t.Errorf("Expected only tests/1 public, got %s hidden %v", testCase.Name, testCase.IsHidden)
+		}
+	}
+	if strings.Join(names, " ") != "tests/1 tests/2 tests/10" {
+		t.Errorf("Expected tests in natural order, got %v", names)
+	}
+	if result.Count != 3 || result.Deleted != 0 {
+		t.Errorf("Expected 3 test cases created and none deleted, got %d and %d", result.Count, result.Deleted)
+	}
+
+	if len(revisionRepo.revisions) != revisionsBefore+1 {
+		t.Fatalf("Expected one revision for the upload, got %d", len(revisionRepo.revisions)-revisionsBefore)
+	}
+	revision := revisionRepo.revisions[len(revisionRepo.revisions)-1]
+	if revision.Action != models.RevisionActionTestCaseUpload || result.Revision != revision.Revision {
+		t.Errorf("Expected upload revision %d, got %q revision %d", result.Revision, revision.Action, revision.Revision)
+	}
+	if len(revision.Snapshot.TestCases) != 4 {
+		t.Errorf("Expected 4 test cases in the snapshot, got %d", len(revision.Snapshot.TestCases))
+	}
+
+	t.Run("replaces existing test cases", func(t *testing.T) {
+		result, err := service.UploadTestCases(created.ID, &TestCaseUpload{
+			Files:   testDataFiles(map[string]string{"a.in": "7 8", "a.out": "15"}),
+			Replace: true,
+		}, 2)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result.Deleted != 4 || result.Count != 1 {
+			t.Errorf("Expected 4 deleted and 1 created, got %d and %d", result.Deleted, result.Count)
+		}
+
+		remaining, _ := testCaseRepo.GetByProblemID(created.ID)
+		if len(remaining) != 1 || remaining[0].Input != "7 8" || !remaining[0].IsHidden {
+			t.Errorf("Expected only the uploaded hidden test case, got %+v", remaining)
+		}
+	})
+
+	tests := []struct {
+		name          string
+		problemID     int
+		files         map[string]string
+		public        []string
+		expectedError string
+	}{
+		{"no files", created.ID, map[string]string{".hidden.in": "1"}, nil, "no test data files"},
+		{"missing output", created.ID, map[string]string{"1.in": "1", "2.in": "2", "2.out": "2"}, nil, "test 1 has no expected output"},
+		{"missing input", created.ID, map[string]string{"1.out": "1"}, nil, "test 1 has no input"},
+		{"two outputs", created.ID, map[string]string{"1.in": "1", "1.out": "1", "1.ans": "1"}, nil, "more than one expected output"},
+		{"unexpected file", created.ID, map[string]string{"1.in": "1", "1.out": "1", "notes.txt": "x"}, nil, "unexpected file notes.txt"},
+		{"empty output", created.ID, map[string]string{"1.in": "1", "1.out": " \n"}, nil, "test 1: expected output is required"},
+		{"binary input", created.ID, map[string]string{"1.in": "\x00\xff", "1.out": "1"}, nil, "UTF-8"},
+		{"unknown public test", created.ID, map[string]string{"1.in": "1", "1.out": "1"}, []string{"2"}, "public tests not uploaded: 2"},
+		{"problem not found", 999, map[string]string{"1.in": "1", "1.out": "1"}, nil, "problem not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(testCaseRepo.testCases)
+
+			_, err := service.UploadTestCases(tt.problemID, &TestCaseUpload{Files: testDataFiles(tt.files), Public: tt.public}, 2)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectedError, err)
+			}
+			if len(testCaseRepo.testCases) != before {
+				t.Errorf("Expected no test cases created, got %d", len(testCaseRepo.testCases)-before)
+			}
+		})
+	}
+}
+
+func TestLessNatural(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"2", "10", true},
+		{"10", "2", false},
+		{"02", "10", true},
+		{"2", "02", true},
+		{"sample/1", "secret/1", true},
+		{"test9b", "test10a", true},
+		{"a", "a1", true},
+		{"a1", "a1", false},
+	}
+
+	for _, tt := range tests {
+		if got := lessNatural(tt.a, tt.b); got != tt.expected {
+			t.Errorf("lessNatural(%q, %q) = %v, expected %v", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"leetcode-clone-backend/pkg/execution"
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/repository"
+)
+
+// ValidatorAccepted is the output of an input validator for an input that satisfies the
+// constraints of the problem; any other output is the constraint the input violates
+const ValidatorAccepted = "OK"
+
+// TestCaseGeneration asks for test cases generated by the generator of a problem
+type TestCaseGeneration struct {
+	Count  int   // Test cases to generate
+	Seed   int64 // Seed of the first test case; the following ones use the next seeds
+	Public bool  // Make the generated test cases public; they are hidden otherwise
+}
+
+// inputViolation is a test input rejected by the input validator of a problem
+type inputViolation struct {
+	index   int
+	message string
+}
+
+// GetTestPrograms retrieves the generator and validator of a problem, ordered by kind
+func (s *ProblemService) GetTestPrograms(problemID int) ([]*models.TestProgram, error) {
+	if _, err := s.repo.Problem.GetByID(problemID); err != nil {
+		return nil, fmt.Errorf("failed to get problem: %w", err)
+	}
+
+	programs, err := s.repo.TestProgram.GetByProblemID(problemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test programs: %w", err)
+	}
+
+	return programs, nil
+}
+
+// SetTestProgram creates or replaces the generator or validator of a problem. A validator is
+// first run on the existing test cases of the problem and rejected if it rejects any of them.
+func (s *ProblemService) SetTestProgram(program *models.TestProgram) (*models.TestProgram, error) {
+	if err := s.validateTestProgram(program); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if _, err := s.repo.Problem.GetByID(program.ProblemID); err != nil {
+		return nil, fmt.Errorf("failed to get problem: %w", err)
+	}
+
+	if program.Kind == models.TestProgramValidator {
+		testCases, err := s.repo.TestCase.GetByProblemID(program.ProblemID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get test cases: %w", err)
+		}
+
+		inputs := make([]string, len(testCases))
+		for i, testCase := range testCases {
+			inputs[i] = testCase.Input
+		}
+		violations, err := s.inputViolations(program, inputs)
+		if err != nil {
+			return nil, err
+		}
+		if len(violations) > 0 {
+			failed := make([]string, len(violations))
+			for i, violation := range violations {
+				failed[i] = fmt.Sprintf("test case %d: %s", testCases[violation.index].ID, violation.message)
+			}
+			return nil, fmt.Errorf("validation failed: validator rejects existing test cases: %s", strings.Join(failed, "; "))
+		}
+	}
+
+	saved, err := s.repo.TestProgram.Upsert(program)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save %s: %w", program.Kind, err)
+	}
+
+	return saved, nil
+}
+
+// DeleteTestProgram deletes the generator or validator of a problem
+func (s *ProblemService) DeleteTestProgram(problemID int, kind string) error {
+	if err := s.repo.TestProgram.Delete(problemID, kind); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", kind, err)
+	}
+	return nil
+}
+
+// GenerateTestCases runs the generator of a problem once for each seed and creates a test case
+// from every input it produces, recorded as a single revision authored by authorID. Inputs must
+// pass the validator of the problem, if it has one, and expected outputs are generated by its
+// reference solutions, which must agree on every input.
+func (s *ProblemService) GenerateTestCases(problemID int, generation *TestCaseGeneration, authorID int) (*TestCaseUploadResult, error) {
+	if generation.Count < 1 || generation.Count > MaxTestCaseUploadCount {
+		return nil, fmt.Errorf("validation failed: count must be between 1 and %d", MaxTestCaseUploadCount)
+	}
+
+	if _, err := s.repo.Problem.GetByID(problemID); err != nil {
+		return nil, fmt.Errorf("problem not found: %w", err)
+	}
+
+	generator, err := s.testProgram(problemID, models.TestProgramGenerator)
+	if err != nil {
+		return nil, err
+	}
+	if generator == nil {
+		return nil, fmt.Errorf("validation failed: problem has no generator")
+	}
+	if s.executionService == nil {
+		return nil, fmt.Errorf("generator cannot be run: no execution service configured")
+	}
+
+	names := make([]string, generation.Count)
+	seeds := make([]string, generation.Count)
+	for i := range seeds {
+		seeds[i] = strconv.FormatInt(generation.Seed+int64(i), 10)
+		names[i] = "seed " + seeds[i]
+	}
+
+	runs, err := s.executionService.RunCode(generator.Code, generator.Language, seeds, models.DefaultJudgeSettings(problemID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run generator: %w", err)
+	}
+	if len(runs) != len(seeds) {
+		return nil, fmt.Errorf("failed to run generator: got %d results for %d seeds", len(runs), len(seeds))
+	}
+
+	testCases := make([]*models.TestCase, len(runs))
+	for i, run := range runs {
+		if run.Status != models.StatusAccepted {
+			return nil, fmt.Errorf("validation failed: test %s: generator failed: %s", names[i], run.Status)
+		}
+		if strings.TrimSpace(run.Output) == "" {
+			return nil, fmt.Errorf("validation failed: test %s: generator produced no input", names[i])
+		}
+		if err := validateTestData("input", run.Output); err != nil {
+			return nil, fmt.Errorf("validation failed: test %s: %w", names[i], err)
+		}
+		testCases[i] = &models.TestCase{ProblemID: problemID, Input: run.Output, IsHidden: !generation.Public}
+	}
+
+	if err := s.validateInputs(problemID, names, testCases); err != nil {
+		return nil, err
+	}
+	if err := s.generateUploadedOutputs(problemID, names, testCases); err != nil {
+		return nil, err
+	}
+
+	change := revisionChange{authorID: authorID, action: models.RevisionActionTestCaseGenerate}
+	return s.createUploadedTestCases(problemID, names, testCases, false, change)
+}
+
+// validateInputs runs the validator of a problem, if it has one, on the inputs of test cases and
+// returns a validation error listing those it rejects. Test cases are named by names, or not at
+// all if names is nil.
+func (s *ProblemService) validateInputs(problemID int, names []string, testCases []*models.TestCase) error {
+	validator, err := s.testProgram(problemID, models.TestProgramValidator)
+	if err != nil || validator == nil {
+		return err
+	}
+
+	inputs := make([]string, len(testCases))
+	for i, testCase := range testCases {
+		inputs[i] = testCase.Input
+	}
+	violations, err := s.inputViolations(validator, inputs)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	failed := make([]string, len(violations))
+	for i, violation := range violations {
+		failed[i] = "input violates the constraints: " + violation.message
+		if names != nil {
+			failed[i] = fmt.Sprintf("test %s: %s", names[violation.index], failed[i])
+		}
+	}
+	return fmt.Errorf("validation failed: %s", strings.Join(failed, "; "))
+}
+
+// inputViolations runs a validator on inputs and returns those it rejects, with its message. The
+// validator runs with the default judging limits rather than those of the problem, since its
+// inputs may be the largest the problem allows.
+func (s *ProblemService) inputViolations(validator *models.TestProgram, inputs []string) ([]inputViolation, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	if s.executionService == nil {
+		return nil, fmt.Errorf("validator cannot be run: no execution service configured")
+	}
+
+	runs, err := s.executionService.RunCode(validator.Code, validator.Language, inputs, models.DefaultJudgeSettings(validator.ProblemID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run validator: %w", err)
+	}
+	if len(runs) != len(inputs) {
+		return nil, fmt.Errorf("failed to run validator: got %d results for %d inputs", len(runs), len(inputs))
+	}
+
+	var violations []inputViolation
+	for i, run := range runs {
+		output := strings.TrimSpace(run.Output)
+		switch {
+		case run.Status != models.StatusAccepted:
+			violations = append(violations, inputViolation{index: i, message: "validator failed: " + run.Status})
+		case output == "":
+			violations = append(violations, inputViolation{index: i, message: "rejected by the validator"})
+		case output != ValidatorAccepted:
+			violations = append(violations, inputViolation{index: i, message: models.TestDataPreview(output)})
+		}
+	}
+
+	return violations, nil
+}
+
+// testProgram retrieves the test program of a kind of a problem, or nil if it has none
+func (s *ProblemService) testProgram(problemID int, kind string) (*models.TestProgram, error) {
+	program, err := s.repo.TestProgram.Get(problemID, kind)
+	if err != nil {
+		if repository.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get %s: %w", kind, err)
+	}
+	return program, nil
+}
+
+// validateTestProgram validates a generator or validator
+func (s *ProblemService) validateTestProgram(program *models.TestProgram) error {
+	if program.Kind != models.TestProgramGenerator && program.Kind != models.TestProgramValidator {
+		return fmt.Errorf("kind must be one of: generator, validator")
+	}
+
+	switch program.Language {
+	case models.LanguageJavaScript, models.LanguagePython, models.LanguageJava:
+	default:
+		return fmt.Errorf("%s: language must be one of: javascript, python, java", program.Kind)
+	}
+	if strings.TrimSpace(program.Code) == "" {
+		return fmt.Errorf("%s: code is required", program.Kind)
+	}
+	if len(program.Code) > execution.MaxCodeLength {
+		return fmt.Errorf("%s: code exceeds maximum length of %d bytes", program.Kind, execution.MaxCodeLength)
+	}
+	if s.executionService != nil {
+		if err := s.executionService.ValidateCode(program.Code, program.Language); err != nil {
+			return fmt.Errorf("%s: %w", program.Kind, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/problempkg"
+	"leetcode-clone-backend/pkg/repository"
+)
+
+type mockTestProgramRepository struct {
+	programs map[int]map[string]*models.TestProgram
+}
+
+func newMockTestProgramRepository() *mockTestProgramRepository {
+	return &mockTestProgramRepository{programs: make(map[int]map[string]*models.TestProgram)}
+}
+
+func (m *mockTestProgramRepository) GetByProblemID(problemID int) ([]*models.TestProgram, error) {
+	var programs []*models.TestProgram
+	for _, kind := range []string{models.TestProgramGenerator, models.TestProgramValidator} {
+		if program, exists := m.programs[problemID][kind]; exists {
+			programs = append(programs, program)
+		}
+	}
+	return programs, nil
+}
+
+func (m *mockTestProgramRepository) Get(problemID int, kind string) (*models.TestProgram, error) {
+	program, exists := m.programs[problemID][kind]
+	if !exists {
+		return nil, repository.NewRepositoryError("Get", repository.ErrNotFound, "test_program_not_found")
+	}
+	return program, nil
+}
+
+func (m *mockTestProgramRepository) Upsert(program *models.TestProgram) (*models.TestProgram, error) {
+	if m.programs[program.ProblemID] == nil {
+		m.programs[program.ProblemID] = make(map[string]*models.TestProgram)
+	}
+	saved := *program
+	m.programs[program.ProblemID][program.Kind] = &saved
+	return &saved, nil
+}
+
+func (m *mockTestProgramRepository) Delete(problemID int, kind string) error {
+	if _, exists := m.programs[problemID][kind]; !exists {
+		return repository.NewRepositoryError("Delete", repository.ErrNotFound, "test_program_not_found")
+	}
+	delete(m.programs[problemID], kind)
+	return nil
+}
+
+func TestProblemService_SetTestProgram(t *testing.T) {
+	service, _, _, problem := newReferenceTestService(t, "sum")
+	service.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "20 1", ExpectedOutput: "21"}, 1)
+
+	program := func(kind, language, code string) *models.TestProgram {
+		return &models.TestProgram{ProblemID: problem.ID, Kind: kind, Language: language, Code: code}
+	}
+
+	tests := []struct {
+		name          string
+		program       *models.TestProgram
+		expectedError string
+	}{
+		{"unknown kind", program("checker", models.LanguagePython, "max10"), "kind must be one of"},
+		{"unsupported language", program(models.TestProgramGenerator, "ruby", "pair"), "generator: language must be one of"},
+		{"missing code", program(models.TestProgramValidator, models.LanguagePython, " "), "validator: code is required"},
+		{"unsafe code", program(models.TestProgramGenerator, models.LanguagePython, "import os"), "dangerous pattern"},
+		{"validator rejects existing test case", program(models.TestProgramValidator, models.LanguagePython, "max10"), "validator rejects existing test cases: test case 1: a number exceeds 10"},
+		{"problem not found", &models.TestProgram{ProblemID: 999, Kind: models.TestProgramGenerator, Language: models.LanguagePython, Code: "pair"}, "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.SetTestProgram(tt.program)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+
+	saved, err := service.SetTestProgram(program(models.TestProgramGenerator, models.LanguagePython, "pair"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if saved.Kind != models.TestProgramGenerator || saved.Code != "pair" {
+		t.Errorf("Expected the generator saved, got %+v", saved)
+	}
+	programs, _ := service.GetTestPrograms(problem.ID)
+	if len(programs) != 1 {
+		t.Errorf("Expected only the generator, got %d programs", len(programs))
+	}
+
+	if err := service.DeleteTestProgram(problem.ID, models.TestProgramGenerator); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := service.DeleteTestProgram(problem.ID, models.TestProgramGenerator); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+func TestProblemService_InputValidator(t *testing.T) {
+	service, testCaseRepo, _, problem := newReferenceTestService(t, "sum")
+	if _, err := service.SetTestProgram(&models.TestProgram{ProblemID: problem.ID, Kind: models.TestProgramValidator, Language: models.LanguagePython, Code: "max10"}); err != nil {
+		t.Fatalf("Failed to save validator: %v", err)
+	}
+
+	testCase, err := service.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "1 2", ExpectedOutput: "3"}, 1)
+	if err != nil {
+		t.Fatalf("Expected a valid input accepted, got %v", err)
+	}
+
+	_, err = service.CreateTestCase(&models.TestCase{ProblemID: problem.ID, Input: "11 2", ExpectedOutput: "13"}, 1)
+	if err == nil || err.Error() != "validation failed: input violates the constraints: a number exceeds 10" {
+		t.Errorf("Expected the validator's message, got %v", err)
+	}
+
+	_, err = service.UpdateTestCase(&models.TestCase{ID: testCase.ID, ProblemID: problem.ID, Input: "1 12", ExpectedOutput: "13"}, 1)
+	if err == nil || !strings.Contains(err.Error(), "a number exceeds 10") {
+		t.Errorf("Expected the updated input rejected, got %v", err)
+	}
+
+	_, err = service.UploadTestCases(problem.ID, &TestCaseUpload{
+		Files: testDataFiles(map[string]string{"1.in": "1 1", "1.out": "2", "2.in": "30 1", "2.out": "31"}),
+	}, 1)
+	if err == nil || !strings.Contains(err.Error(), "test 2: input violates the constraints: a number exceeds 10") {
+		t.Errorf("Expected the uploaded test named, got %v", err)
+	}
+	if len(testCaseRepo.testCases) != 1 {
+		t.Errorf("Expected only the first test case, got %d", len(testCaseRepo.testCases))
+	}
+
+	t.Run("package validator", func(t *testing.T) {
+		service, _, _, _ := newRevisionTestService()
+		service.executionService = &fakeSolutionRunner{}
+
+		pkg := testPackage()
+		pkg.Programs = map[string]problempkg.Program{models.TestProgramValidator: {Language: models.LanguagePython, Code: "max10"}}
+		pkg.Tests[1].Input = "[3,3]\n60"
+
+		err := service.ValidatePackage(pkg)
+		var validationErr *problempkg.ValidationError
+		if !errors.As(err, &validationErr) || !strings.Contains(err.Error(), "tests/02.in: input violates the constraints: a number exceeds 10") {
+			t.Fatalf("Expected the package test rejected by validation, got %v", err)
+		}
+
+		_, err = service.ImportPackage(pkg, 1)
+		if !errors.As(err, &validationErr) || !strings.Contains(err.Error(), "tests/02.in: input violates the constraints: a number exceeds 10") {
+			t.Fatalf("Expected the package test rejected, got %v", err)
+		}
+
+		pkg.Tests[1].Input = "[3,3]\n6"
+		result, err := service.ImportPackage(pkg, 1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		programs, _ := service.GetTestPrograms(result.Problem.ID)
+		if len(programs) != 1 || programs[0].Kind != models.TestProgramValidator {
+			t.Errorf("Expected the validator imported, got %+v", programs)
+		}
+	})
+}
+
+func TestProblemService_GenerateTestCases(t *testing.T) {
+	service, testCaseRepo, revisionRepo, problem := newReferenceTestService(t, "sum")
+
+	_, err := service.GenerateTestCases(problem.ID, &TestCaseGeneration{Count: 3, Seed: 1}, 2)
+	if err == nil || !strings.Contains(err.Error(), "problem has no generator") {
+		t.Errorf("Expected missing generator error, got %v", err)
+	}
+
+	service.SetTestProgram(&models.TestProgram{ProblemID: problem.ID, Kind: models.TestProgramGenerator, Language: models.LanguagePython, Code: "pair"})
+	result, err := service.GenerateTestCases(problem.ID, &TestCaseGeneration{Count: 3, Seed: 4}, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Count != 3 || result.TestCases[0].Name != "seed 4" || !result.TestCases[0].IsHidden {
+		t.Fatalf("Expected 3 hidden test cases named by seed, got %+v", result.TestCases)
+	}
+	stored, _ := service.GetTestCase(result.TestCases[2].ID)
+	if stored.Input != "6 6" || stored.ExpectedOutput != "12" || stored.OutputStatus != models.OutputStatusVerified {
+		t.Errorf("Expected input 6 6 with verified output 12, got %+v", stored)
+	}
+	latest := revisionRepo.revisions[len(revisionRepo.revisions)-1]
+	if result.Revision != latest.Revision || latest.Action != models.RevisionActionTestCaseGenerate {
+		t.Errorf("Expected a test_case_generate revision, got %q", latest.Action)
+	}
+
+	service.SetTestProgram(&models.TestProgram{ProblemID: problem.ID, Kind: models.TestProgramValidator, Language: models.LanguagePython, Code: "max10"})
+	tests := []struct {
+		name          string
+		generation    TestCaseGeneration
+		expectedError string
+	}{
+		{"no test cases", TestCaseGeneration{Count: 0}, "count must be between 1 and 500"},
+		{"too many test cases", TestCaseGeneration{Count: 501}, "count must be between 1 and 500"},
+		{"input violates constraints", TestCaseGeneration{Count: 3, Seed: 9}, "test seed 11: input violates the constraints: a number exceeds 10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.GenerateTestCases(problem.ID, &tt.generation, 2)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectedError, err)
+			}
+			if len(testCaseRepo.testCases) != 3 {
+				t.Errorf("Expected no test cases created, got %d", len(testCaseRepo.testCases)-3)
+			}
+		})
+	}
+
+	t.Run("solutions disagree", func(t *testing.T) {
+		service.ReplaceSolutions(problem.ID, []*models.ProblemSolution{
+			{Name: "a", Language: models.LanguagePython, Code: "sum"},
+			{Name: "b", Language: models.LanguagePython, Code: "sum+1"},
+		})
+
+		_, err := service.GenerateTestCases(problem.ID, &TestCaseGeneration{Count: 1, Seed: 1, Public: true}, 2)
+		var checkErr *OutputCheckError
+		if !errors.As(err, &checkErr) || checkErr.Checks[0].Name != "seed 1" {
+			t.Errorf("Expected output check error for seed 1, got %v", err)
+		}
+	})
+}
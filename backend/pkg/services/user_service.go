@@ -0,0 +1,90 @@
+package services
+
+import (
+	"fmt"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/pagination"
+	"leetcode-clone-backend/pkg/repository"
+)
+
+// UserServiceInterface defines the interface for user service
+type UserServiceInterface interface {
+	ListUsers(params pagination.Params) (*UserListResponse, error)
+}
+
+// UserListResponse represents a paginated list of users
+type UserListResponse struct {
+	Users []*models.User `json:"users"`
+	pagination.Meta
+}
+
+// defaultUserPageSize is the number of users listed per page unless the client asks for more
+const defaultUserPageSize = 20
+
+// UserService handles business logic for user accounts
+type UserService struct {
+	repo *repository.Repository
+}
+
+// NewUserService creates a new user service
+func NewUserService(repo *repository.Repository) *UserService {
+	return &UserService{
+		repo: repo,
+	}
+}
+
+// ListUsers retrieves a page of users, newest first
+func (us *UserService) ListUsers(params pagination.Params) (*UserListResponse, error) {
+	if params.Limit < 1 || params.Limit > pagination.MaxLimit {
+		params.Limit = defaultUserPageSize
+	}
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Offset <= 0 {
+		params.Offset = (params.Page - 1) * params.Limit
+	}
+
+	if params.Cursor != nil {
+		if err := params.Cursor.Check(repository.UserSort); err != nil {
+			return nil, fmt.Errorf("invalid pagination: %w", err)
+		}
+		if _, err := params.Cursor.Time(); err != nil {
+			return nil, fmt.Errorf("invalid pagination: %w", err)
+		}
+	}
+
+	total, err := us.repo.User.Count()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	// Fetch one extra user to know whether there is a next page
+	users, err := us.repo.User.List(repository.UserFilters{
+		Limit:  params.Limit + 1,
+		Offset: params.Offset,
+		Cursor: params.Cursor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	hasNext := len(users) > params.Limit
+	if hasNext {
+		users = users[:params.Limit]
+	}
+	if users == nil {
+		users = []*models.User{}
+	}
+
+	var next *pagination.Cursor
+	if len(users) > 0 {
+		next = repository.NewUserCursor(users[len(users)-1])
+	}
+
+	return &UserListResponse{
+		Users: users,
+		Meta:  pagination.NewMeta(params, total, hasNext, next),
+	}, nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"leetcode-clone-backend/pkg/models"
+	"leetcode-clone-backend/pkg/pagination"
+	"leetcode-clone-backend/pkg/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(user *models.User) (*models.User, error) {
+	args := m.Called(user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByID(id int) (*models.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByUsername(username string) (*models.User, error) {
+	args := m.Called(username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmail(email string) (*models.User, error) {
+	args := m.Called(email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Update(user *models.User) (*models.User, error) {
+	args := m.Called(user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Delete(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) List(filters repository.UserFilters) ([]*models.User, error) {
+	args := m.Called(filters)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Count() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
+
+func newUserTestService() (*UserService, *MockUserRepository) {
+	userRepo := new(MockUserRepository)
+	return NewUserService(&repository.Repository{User: userRepo}), userRepo
+}
+
+func TestUserService_ListUsers(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	users := []*models.User{
+		{ID: 3, Username: "carol", CreatedAt: createdAt},
+		{ID: 2, Username: "bob", CreatedAt: createdAt},
+		{ID: 1, Username: "alice", CreatedAt: createdAt},
+	}
+
+	t.Run("offset page with a next page", func(t *testing.T) {
+		service, userRepo := newUserTestService()
+		userRepo.On("Count").Return(5, nil)
+		userRepo.On("List", repository.UserFilters{Limit: 3, Offset: 2}).Return(users, nil)
+
+		result, err := service.ListUsers(pagination.Params{Page: 2, Limit: 2})
+
+		assert.NoError(t, err)
+		assert.Len(t, result.Users, 2)
+		assert.Equal(t, 5, result.Total)
+		assert.Equal(t, 2, result.Page)
+		assert.True(t, result.HasNext)
+
+		cursor, err := pagination.DecodeCursor(result.NextCursor)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, cursor.ID)
+		assert.Equal(t, repository.UserSort, cursor.Sort)
+	})
+
+	t.Run("cursor page", func(t *testing.T) {
+		service, userRepo := newUserTestService()
+		cursor := repository.NewUserCursor(users[0])
+		userRepo.On("Count").Return(3, nil)
+		userRepo.On("List", repository.UserFilters{Limit: 21, Cursor: cursor}).Return(users[1:], nil)
+
+		result, err := service.ListUsers(pagination.Params{Cursor: cursor})
+
+		assert.NoError(t, err)
+		assert.Len(t, result.Users, 2)
+		assert.Equal(t, 0, result.Page)
+		assert.False(t, result.HasNext)
+		assert.Empty(t, result.NextCursor)
+	})
+
+	t.Run("cursor from another list", func(t *testing.T) {
+		service, _ := newUserTestService()
+
+		_, err := service.ListUsers(pagination.Params{Cursor: &pagination.Cursor{Sort: "title:asc", Value: "Two Sum", ID: 1}})
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid pagination")
+	})
+
+	t.Run("empty result is not nil", func(t *testing.T) {
+		service, userRepo := newUserTestService()
+		userRepo.On("Count").Return(0, nil)
+		userRepo.On("List", repository.UserFilters{Limit: 21}).Return(nil, nil)
+
+		result, err := service.ListUsers(pagination.Params{})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, result.Users)
+		assert.Equal(t, 1, result.Page)
+		assert.Equal(t, 20, result.Limit)
+	})
+}
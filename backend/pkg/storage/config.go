@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+)
+
+// Store types
+const (
+	StoreLocal  = "local"
+	StoreMemory = "memory"
+)
+
+// Config selects and configures the store of test data
+type Config struct {
+	Store string // local or memory
+	Dir   string // Directory of the local store
+}
+
+// LoadConfigFromEnv loads test data store configuration from environment variables
+func LoadConfigFromEnv() *Config {
+	return &Config{
+		Store: getEnv("TEST_DATA_STORE", StoreLocal),
+		Dir:   getEnv("TEST_DATA_DIR", "data/testdata"),
+	}
+}
+
+// NewStore creates the store selected by config
+func NewStore(config *Config) (Store, error) {
+	switch config.Store {
+	case StoreLocal:
+		return NewLocalStore(config.Dir)
+	case StoreMemory:
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown test data store %q, expected %s or %s", config.Store, StoreLocal, StoreMemory)
+	}
+}
+
+// getEnv gets an environment variable with a default value
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
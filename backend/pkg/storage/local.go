@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// LocalStore keeps data in files of a directory on the local filesystem, named after their hash
+// and spread over subdirectories named after its first two characters. Replicas share data only
+// if the directory is shared.
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore creates a store in dir, creating the directory if needed
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create test data directory: %w", err)
+	}
+	return &LocalStore{dir: dir}, nil
+}
+
+// path returns the name of the file holding the data stored under hash
+func (s *LocalStore) path(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+// Put stores data and returns its hash. Data is written to a temporary file first and renamed
+// into place, so that a file under a hash is always complete.
+func (s *LocalStore) Put(data []byte) (string, error) {
+	hash := Hash(data)
+	name := s.path(hash)
+	if _, err := os.Stat(name); err == nil {
+		return hash, nil
+	}
+
+	dir := filepath.Dir(name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to store data: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to store data: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to store data: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to store data: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return "", fmt.Errorf("failed to store data: %w", err)
+	}
+
+	return hash, nil
+}
+
+// Get returns the data stored under hash, or ErrNotFound. Data that no longer matches its hash
+// is reported as corrupt.
+func (s *LocalStore) Get(hash string) ([]byte, error) {
+	if err := checkHash(hash); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.path(hash))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data: %w", err)
+	}
+	if Hash(data) != hash {
+		return nil, fmt.Errorf("data stored under %s is corrupt", hash)
+	}
+
+	return data, nil
+}
+
+// Exists reports whether data is stored under hash
+func (s *LocalStore) Exists(hash string) (bool, error) {
+	if err := checkHash(hash); err != nil {
+		return false, err
+	}
+
+	_, err := os.Stat(s.path(hash))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read data: %w", err)
+	}
+	return true, nil
+}
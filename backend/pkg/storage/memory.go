@@ -0,0 +1,57 @@
+package storage
+
+import "sync"
+
+// MemoryStore keeps data in process memory. Data is lost on restart and is not shared between
+// replicas.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+// NewMemoryStore creates a new in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: make(map[string][]byte)}
+}
+
+// Put stores data and returns its hash
+func (s *MemoryStore) Put(data []byte) (string, error) {
+	hash := Hash(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data[hash]; !ok {
+		s.data[hash] = append([]byte(nil), data...)
+	}
+	return hash, nil
+}
+
+// Get returns the data stored under hash, or ErrNotFound
+func (s *MemoryStore) Get(hash string) ([]byte, error) {
+	if err := checkHash(hash); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.data[hash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Exists reports whether data is stored under hash
+func (s *MemoryStore) Exists(hash string) (bool, error) {
+	if err := checkHash(hash); err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.data[hash]
+	return ok, nil
+}
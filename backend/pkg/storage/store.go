@@ -0,0 +1,51 @@
+// Package storage keeps test data in a content-addressed store: data is stored and retrieved
+// under the SHA-256 hash of its content, so identical data is stored once and stored data never
+// changes. Stores are pluggable; data is kept on the local filesystem or, in tests, in memory.
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned when no data is stored under a hash
+var ErrNotFound = errors.New("data not found")
+
+// Store keeps data under the hash of its content
+type Store interface {
+	// Put stores data and returns its hash. Storing data that is already stored is a no-op.
+	Put(data []byte) (string, error)
+	// Get returns the data stored under hash, or ErrNotFound
+	Get(hash string) ([]byte, error)
+	// Exists reports whether data is stored under hash
+	Exists(hash string) (bool, error)
+}
+
+// Hash returns the key of data in a store: its SHA-256 hash in lowercase hex
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidHash reports whether hash has the form of a key returned by Hash
+func ValidHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	for _, c := range hash {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// checkHash returns an error for a hash that no data can be stored under
+func checkHash(hash string) error {
+	if !ValidHash(hash) {
+		return fmt.Errorf("invalid hash %q", hash)
+	}
+	return nil
+}
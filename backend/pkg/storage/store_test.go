@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHash(t *testing.T) {
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", Hash(nil))
+	assert.True(t, ValidHash(Hash([]byte("1 2\n"))))
+	assert.False(t, ValidHash("../../etc/passwd"))
+	assert.False(t, ValidHash("E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855"))
+}
+
+func TestStores(t *testing.T) {
+	local, err := NewLocalStore(filepath.Join(t.TempDir(), "testdata"))
+	require.NoError(t, err)
+
+	stores := map[string]Store{
+		"local":  local,
+		"memory": NewMemoryStore(),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			data := []byte("3\n1 2 3\n")
+
+			hash, err := store.Put(data)
+			require.NoError(t, err)
+			assert.Equal(t, Hash(data), hash)
+
+			// Storing the same data again is a no-op
+			again, err := store.Put(data)
+			require.NoError(t, err)
+			assert.Equal(t, hash, again)
+
+			exists, err := store.Exists(hash)
+			require.NoError(t, err)
+			assert.True(t, exists)
+
+			stored, err := store.Get(hash)
+			require.NoError(t, err)
+			assert.Equal(t, data, stored)
+
+			missing := Hash([]byte("missing"))
+			exists, err = store.Exists(missing)
+			require.NoError(t, err)
+			assert.False(t, exists)
+
+			_, err = store.Get(missing)
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			_, err = store.Get("../" + hash[3:])
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLocalStore_Corrupt(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir)
+	require.NoError(t, err)
+
+	hash, err := store.Put([]byte("1 2\n"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, hash[:2], hash), []byte("2 1\n"), 0o644))
+
+	_, err = store.Get(hash)
+	assert.ErrorContains(t, err, "corrupt")
+}
+
+func TestNewStore(t *testing.T) {
+	store, err := NewStore(&Config{Store: StoreMemory})
+	require.NoError(t, err)
+	assert.IsType(t, &MemoryStore{}, store)
+
+	store, err = NewStore(&Config{Store: StoreLocal, Dir: t.TempDir()})
+	require.NoError(t, err)
+	assert.IsType(t, &LocalStore{}, store)
+
+	_, err = NewStore(&Config{Store: "s3"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"leetcode-clone-backend/pkg/database"
+	"leetcode-clone-backend/pkg/execution"
+	"leetcode-clone-backend/pkg/problempkg"
+	"leetcode-clone-backend/pkg/repository"
+	"leetcode-clone-backend/pkg/services"
+	"leetcode-clone-backend/pkg/storage"
+)
+
+const usage = `Usage: go run ./scripts/problempkg <command> [arguments]
+
+Commands:
+  validate <package>...          Check packages without importing them
+  import <package>...            Create or update the problems of packages, matched by slug
+  export [-o path] <id or slug>  Write a problem to a .zip file or a directory (default: <slug>.zip)
+  convert [-o path] <package>   Convert a Kattis or Polygon package and report what was dropped
+                                (default output: <slug>.zip)
+
+A package is a directory or a .zip file. import and export connect to the database configured
+by DB_HOST, DB_PORT, DB_USER, DB_PASSWORD and DB_NAME.
+`
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch command, args := os.Args[1], os.Args[2:]; command {
+	case "validate":
+		err = validate(args)
+	case "import":
+		err = importPackages(args)
+	case "export":
+		err = export(args)
+	case "convert":
+		err = convert(args)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// validate checks every package and prints its issues
+func validate(paths []string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("validate: no packages given")
+	}
+
+	// Validation needs no database: problem rules are checked by the package itself. Without an
+	// execution service the validator of a package is not run; the import runs it.
+	service := services.NewProblemService(&repository.Repository{}, nil)
+
+	invalid := 0
+	for _, path := range paths {
+		pkg, err := problempkg.ReadPath(path)
+		if err == nil {
+			err = service.ValidatePackage(pkg)
+		}
+		if err != nil {
+			invalid++
+			printError(path, err)
+			continue
+		}
+		fmt.Printf("✅ %s: %s (%d tests)\n", path, pkg.Manifest.Slug, len(pkg.Tests))
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d packages are invalid", invalid, len(paths))
+	}
+	return nil
+}
+
+// importPackages imports every package, stopping at the first that fails
+func importPackages(paths []string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("import: no packages given")
+	}
+
+	repo, closeDB, err := connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+	// Validators of packages run in the sandbox, like those of problems changed through the API
+	service := services.NewProblemService(repo, execution.NewExecutionService())
+
+	for _, path := range paths {
+		pkg, err := problempkg.ReadPath(path)
+		if err != nil {
+			printError(path, err)
+			return fmt.Errorf("import of %s failed", path)
+		}
+
+		// Changes made from the command line have no author
+		result, err := service.ImportPackage(pkg, 0)
+		if err != nil {
+			printError(path, err)
+			return fmt.Errorf("import of %s failed", path)
+		}
+		fmt.Printf("✅ %s: %s problem %d (%s)\n", path, result.Action, result.Problem.ID, result.Problem.Slug)
+	}
+
+	return nil
+}
+
+// export writes the package of a problem given by ID or slug
+func export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	output := flags.String("o", "", "output .zip file or directory")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("export: expected one problem ID or slug")
+	}
+
+	repo, closeDB, err := connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	id, err := strconv.Atoi(flags.Arg(0))
+	if err != nil {
+		problem, err := repo.Problem.GetBySlug(flags.Arg(0))
+		if err != nil {
+			return fmt.Errorf("failed to get problem %s: %w", flags.Arg(0), err)
+		}
+		id = problem.ID
+	}
+
+	pkg, err := services.NewProblemService(repo, nil).ExportPackage(id)
+	if err != nil {
+		return err
+	}
+
+	path := *output
+	if path == "" {
+		path = pkg.Manifest.Slug + ".zip"
+	}
+	if strings.HasSuffix(path, ".zip") {
+		err = writeZip(pkg, path)
+	} else {
+		err = pkg.WriteDir(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Exported %s with %d tests to %s\n", pkg.Manifest.Slug, len(pkg.Tests), path)
+	return nil
+}
+
+// convert converts a Kattis or Polygon package, writes it and prints what the conversion could
+// not carry over. Packages that are invalid after conversion are written anyway so that they can
+// be fixed by hand.
+func convert(args []string) error {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	output := flags.String("o", "", "output .zip file or directory")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("convert: expected one package")
+	}
+	source := flags.Arg(0)
+
+	pkg, report, err := problempkg.ConvertPath(source)
+	if err != nil {
+		printError(source, err)
+		return fmt.Errorf("conversion of %s failed", source)
+	}
+
+	path := *output
+	if path == "" {
+		path = pkg.Manifest.Slug + ".zip"
+	}
+	if strings.HasSuffix(path, ".zip") {
+		err = writeZip(pkg, path)
+	} else {
+		err = pkg.WriteDir(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Converted %s package %s with %d tests to %s\n", report.Format, pkg.Manifest.Slug, len(pkg.Tests), path)
+	for _, feature := range report.Unsupported {
+		fmt.Printf("   ⚠️  %s\n", feature)
+	}
+	for _, value := range report.Defaults {
+		fmt.Printf("   ℹ️  %s\n", value)
+	}
+
+	// Validation needs no database: problem rules are checked by the package itself
+	if err := services.NewProblemService(&repository.Repository{}, nil).ValidatePackage(pkg); err != nil {
+		printError(path, err)
+		return fmt.Errorf("%s must be fixed before it can be imported", path)
+	}
+	fmt.Printf("✅ %s is ready to import\n", path)
+	return nil
+}
+
+// connect opens the database and test data store configured by the environment
+func connect() (*repository.Repository, func(), error) {
+	testData, err := storage.NewStore(storage.LoadConfigFromEnv())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open test data store: %w", err)
+	}
+
+	db, err := database.Connect(database.LoadConfigFromEnv())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	return repository.NewRepository(db, testData), func() { db.Close() }, nil
+}
+
+// writeZip writes a package to a zip file
+func writeZip(pkg *problempkg.Package, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := pkg.WriteZip(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// printError prints why a package failed, one line per issue of an invalid package
+func printError(path string, err error) {
+	var validationErr *problempkg.ValidationError
+	if !errors.As(err, &validationErr) {
+		fmt.Printf("❌ %s: %v\n", path, err)
+		return
+	}
+
+	fmt.Printf("❌ %s:\n", path)
+	for _, issue := range validationErr.Issues {
+		fmt.Printf("   - %s\n", issue)
+	}
+}
@@ -477,8 +477,8 @@ func seedProblems(db *sql.DB) ([]Problem, error) {
 		}
 
 		query := `
-			INSERT INTO problems (title, slug, description, difficulty, tags, examples, constraints, template_code, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			INSERT INTO problems (title, slug, description, difficulty, tags, examples, constraints, template_code, state, published_at, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'published', $9, $9, $10)
 			RETURNING id, title, slug, description, difficulty, tags, examples, constraints, template_code, created_at, updated_at`
 
 		now := time.Now()
@@ -586,8 +586,11 @@ func seedTestCases(db *sql.DB, problems []Problem) ([]TestCase, error) {
 			testCase.ProblemID = problemID
 
 			query := `
-				INSERT INTO test_cases (problem_id, input, expected_output, is_hidden, created_at)
-				VALUES ($1, $2, $3, $4, $5)
+				INSERT INTO test_cases (problem_id, input, expected_output, is_hidden, created_at,
+				                        input_hash, input_size, input_preview, output_hash, output_size, output_preview)
+				VALUES ($1, $2::text, $3::text, $4, $5,
+				        encode(sha256(convert_to($2, 'UTF8')), 'hex'), octet_length($2), left($2, 256),
+				        encode(sha256(convert_to($3, 'UTF8')), 'hex'), octet_length($3), left($3, 256))
 				RETURNING id, problem_id, input, expected_output, is_hidden, created_at`
 
 			var createdTestCase TestCase
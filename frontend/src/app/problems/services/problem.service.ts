@@ -1,6 +1,6 @@
 import { Injectable, inject } from '@angular/core';
 import { HttpClient, HttpParams } from '@angular/common/http';
-import { Observable } from 'rxjs';
+import { Observable, map } from 'rxjs';
 import { Problem, ProblemListResponse, ProblemFilters } from '../models/problem.models';
 
 @Injectable({
@@ -43,6 +43,8 @@ export class ProblemService {
   }
 
   getAvailableTags(): Observable<string[]> {
-    return this.http.get<string[]>(`${this.apiUrl}/problems/tags`);
+    return this.http
+      .get<{ tags: { name: string }[] }>(`${this.apiUrl}/problems/tags`)
+      .pipe(map(response => response.tags.map(tag => tag.name)));
   }
 }
\ No newline at end of file